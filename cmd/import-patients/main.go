@@ -0,0 +1,125 @@
+// Command import-patients registers the patients of a CSV or XLSX file, for
+// loading the existing patients of a clinic when it is onboarded.
+//
+// It reads the same configuration as the API and applies the same rules as
+// POST /v1/medical/patient/import:
+//
+//	go run ./cmd/import-patients -file patients.xlsx -user "Onboarding Team"
+//
+// The rows that were not imported are listed with why, and the command exits
+// with status 1 when there are any, so the file can be fixed and imported
+// again. Patients already registered are reported and left as they are.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/adapter"
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/medical/patientimport"
+	"github.com/j03hanafi/halo-suster/internal/application/medical/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/medical/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// maxStaffName is the length of patient_events.staff_name.
+const maxStaffName = 50
+
+func main() {
+	path := flag.String("file", "", "CSV or XLSX file to import")
+	format := flag.String("format", "", "format of the file, csv or xlsx, taken from its extension when empty")
+	staffName := flag.String("user", "bulk import", "name the registrations are recorded under")
+	flag.Parse()
+
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "-file is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *format == "" {
+		*format = patientimport.Format(*path, "")
+	}
+	*format = strings.ToLower(*format)
+
+	if len(*staffName) > maxStaffName {
+		*staffName = (*staffName)[:maxStaffName]
+	}
+
+	os.Exit(run(*path, *format, *staffName))
+}
+
+func run(path, format, staffName string) int {
+	l := logger.Get()
+	defer func() {
+		_ = l.Sync()
+	}()
+	zap.ReplaceGlobals(l)
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	rows, err := patientimport.Read(file, format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, path+": "+err.Error())
+		return 2
+	}
+
+	db := adapter.GetDBPool()
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	medicalService := service.NewMedicalService(ctxTimeout, repository.NewMedicalRepository(db))
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	user.Name = staffName
+
+	report := domain.PatientImportAcquire()
+	defer domain.PatientImportRelease(report)
+
+	if err = medicalService.ImportPatients(ctx, rows, user, report, nil); err != nil {
+		fmt.Fprintln(os.Stderr, "import stopped: "+err.Error())
+		printReport(report)
+		return 1
+	}
+
+	printReport(report)
+
+	if report.Failed > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func printReport(report *domain.PatientImport) {
+	fmt.Printf("imported %d of %d patients, %d failed\n", report.Imported, report.Total, report.Failed)
+
+	for _, rowErr := range report.Errors {
+		identityNumber := rowErr.IdentityNumber
+		if identityNumber == "" {
+			identityNumber = "-"
+		}
+		fmt.Printf("row %d (%s): %s\n", rowErr.Row, identityNumber, strings.Join(rowErr.Errors, "; "))
+	}
+}
@@ -0,0 +1,242 @@
+// Command satusehat-stub serves the parts of the SATUSEHAT API the sync uses,
+// so it can be tried without credentials for the real one.
+//
+// Point the SATUSEHAT config at it:
+//
+//	AUTH_URL = "http://localhost:9090/oauth2/v1"
+//	BASE_URL = "http://localhost:9090/fhir-r4/v1"
+//
+// Every identity number is a registered patient, and created resources are
+// kept in memory and logged. With -fail-rate some requests fail with 503 to
+// exercise the retries.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	mrand "math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	authPath  = "/oauth2/v1"
+	fhirPath  = "/fhir-r4/v1"
+	nikSystem = "https://fhir.kemkes.go.id/id/nik"
+)
+
+// resourceTypes are the resources the stub takes.
+var resourceTypes = map[string]bool{
+	"Encounter":           true,
+	"Condition":           true,
+	"Observation":         true,
+	"MedicationStatement": true,
+}
+
+type stub struct {
+	clientID     string
+	clientSecret string
+	tokenTTL     time.Duration
+	failRate     float64
+
+	mu        sync.Mutex
+	tokens    map[string]time.Time
+	resources map[string]map[string]json.RawMessage
+}
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	clientID := flag.String("client-id", "", "client id to accept, any when empty")
+	clientSecret := flag.String("client-secret", "", "client secret to accept, any when empty")
+	tokenTTL := flag.Duration("token-ttl", time.Hour, "how long access tokens are valid")
+	failRate := flag.Float64("fail-rate", 0, "share of FHIR requests answered with 503, from 0 to 1")
+	flag.Parse()
+
+	s := &stub{
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		tokenTTL:     *tokenTTL,
+		failRate:     *failRate,
+		tokens:       map[string]time.Time{},
+		resources:    map[string]map[string]json.RawMessage{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+authPath+"/accesstoken", s.accessToken)
+	mux.HandleFunc("GET "+fhirPath+"/Patient", s.authorized(s.searchPatient))
+	mux.HandleFunc("POST "+fhirPath+"/{type}", s.authorized(s.create))
+	mux.HandleFunc("GET "+fhirPath+"/{type}/{id}", s.authorized(s.read))
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("SATUSEHAT stub listening on %s", *addr)
+	log.Fatal(server.ListenAndServe())
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeOutcome(w http.ResponseWriter, status int, code, diagnostics string) {
+	writeJSON(w, status, map[string]any{
+		"resourceType": "OperationOutcome",
+		"issue": []map[string]string{{
+			"severity":    "error",
+			"code":        code,
+			"diagnostics": diagnostics,
+		}},
+	})
+}
+
+// accessToken grants a token for the client credentials, expires_in is a
+// string like the real API sends it.
+func (s *stub) accessToken(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	clientID, clientSecret := r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	if (s.clientID != "" && clientID != s.clientID) || (s.clientSecret != "" && clientSecret != s.clientSecret) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(s.tokenTTL)
+	s.mu.Unlock()
+
+	log.Printf("token granted to %q", clientID)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": token,
+		"token_type":   "BearerToken",
+		"client_id":    clientID,
+		"expires_in":   fmt.Sprint(int(s.tokenTTL.Seconds())),
+	})
+}
+
+// authorized turns down requests without a valid token, and fails some of
+// the rest on purpose when a fail rate is set.
+func (s *stub) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		expiry, found := s.tokens[token]
+		s.mu.Unlock()
+
+		if !ok || !found || time.Now().After(expiry) {
+			writeOutcome(w, http.StatusUnauthorized, "login", "invalid or expired access token")
+			return
+		}
+
+		if s.failRate > 0 && mrand.Float64() < s.failRate { // #nosec G404
+			log.Printf("%s %s failed on purpose", r.Method, r.URL.Path)
+			writeOutcome(w, http.StatusServiceUnavailable, "transient", "stub failure")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// searchPatient finds every identity number, the id is derived from it so
+// the same patient always gets the same one.
+func (s *stub) searchPatient(w http.ResponseWriter, r *http.Request) {
+	system, nik, ok := strings.Cut(r.URL.Query().Get("identifier"), "|")
+	if !ok || system != nikSystem || nik == "" {
+		writeOutcome(w, http.StatusBadRequest, "invalid", "identifier must be "+nikSystem+"|<nik>")
+		return
+	}
+
+	id := "P" + nik
+	writeJSON(w, http.StatusOK, map[string]any{
+		"resourceType": "Bundle",
+		"type":         "searchset",
+		"total":        1,
+		"entry": []map[string]any{{
+			"fullUrl": "http://" + r.Host + fhirPath + "/Patient/" + id,
+			"resource": map[string]any{
+				"resourceType": "Patient",
+				"id":           id,
+				"identifier":   []map[string]string{{"system": nikSystem, "value": nik}},
+			},
+		}},
+	})
+}
+
+func (s *stub) create(w http.ResponseWriter, r *http.Request) {
+	resourceType := r.PathValue("type")
+	if !resourceTypes[resourceType] {
+		writeOutcome(w, http.StatusNotFound, "not-supported", resourceType+" is not supported by the stub")
+		return
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeOutcome(w, http.StatusBadRequest, "structure", err.Error())
+		return
+	}
+	if body["resourceType"] != resourceType {
+		writeOutcome(w, http.StatusBadRequest, "invalid", "resourceType must be "+resourceType)
+		return
+	}
+
+	id := newID()
+	body["id"] = id
+	body["meta"] = map[string]any{"versionId": "1", "lastUpdated": time.Now().UTC().Format(time.RFC3339)}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		writeOutcome(w, http.StatusInternalServerError, "exception", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	if s.resources[resourceType] == nil {
+		s.resources[resourceType] = map[string]json.RawMessage{}
+	}
+	s.resources[resourceType][id] = raw
+	s.mu.Unlock()
+
+	log.Printf("%s/%s created: %s", resourceType, id, raw)
+	w.Header().Set("Location", fhirPath+"/"+resourceType+"/"+id)
+	writeJSON(w, http.StatusCreated, json.RawMessage(raw))
+}
+
+func (s *stub) read(w http.ResponseWriter, r *http.Request) {
+	resourceType, id := r.PathValue("type"), r.PathValue("id")
+
+	s.mu.Lock()
+	raw, ok := s.resources[resourceType][id]
+	s.mu.Unlock()
+
+	if !ok {
+		writeOutcome(w, http.StatusNotFound, "not-found", resourceType+"/"+id+" not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, raw)
+}
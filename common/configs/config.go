@@ -84,6 +84,12 @@ func readEnv(runtimeViper *viper.Viper) error {
 	if err := runtimeViper.BindEnv("S3."+awsRegion, awsRegion); err != nil {
 		return err
 	}
+	if err := runtimeViper.BindEnv("SATUSEHAT."+satuSehatClientID, satuSehatClientID); err != nil {
+		return err
+	}
+	if err := runtimeViper.BindEnv("SATUSEHAT."+satuSehatSecret, satuSehatSecret); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -13,14 +13,23 @@ const (
 	awsSecretAccessKey = "AWS_SECRET_ACCESS_KEY" // #nosec G10
 	awsS3BucketName    = "AWS_S3_BUCKET_NAME"
 	awsRegion          = "AWS_REGION"
+	satuSehatClientID  = "SATUSEHAT_CLIENT_ID"
+	satuSehatSecret    = "SATUSEHAT_CLIENT_SECRET" // #nosec G101
 )
 
 type RuntimeConfig struct {
-	App appCfg `mapstructure:"APP"`
-	API apiCfg `mapstructure:"API"`
-	JWT jwtCfg `mapstructure:"JWT"`
-	DB  dbCfg  `mapstructure:"DB"`
-	S3  s3Cfg  `mapstructure:"S3"`
+	App       appCfg       `mapstructure:"APP"`
+	API       apiCfg       `mapstructure:"API"`
+	JWT       jwtCfg       `mapstructure:"JWT"`
+	DB        dbCfg        `mapstructure:"DB"`
+	S3        s3Cfg        `mapstructure:"S3"`
+	Shift     shiftCfg     `mapstructure:"SHIFT"`
+	Task      taskCfg      `mapstructure:"TASK"`
+	SatuSehat satuSehatCfg `mapstructure:"SATUSEHAT"`
+	HL7       hl7Cfg       `mapstructure:"HL7"`
+	Job       jobCfg       `mapstructure:"JOB"`
+	Webhook   webhookCfg   `mapstructure:"WEBHOOK"`
+	FHIR      fhirCfg      `mapstructure:"FHIR"`
 }
 
 type appCfg struct {
@@ -61,3 +70,61 @@ type s3Cfg struct {
 	BucketName      string `mapstructure:"AWS_S3_BUCKET_NAME"`
 	Region          string `mapstructure:"AWS_REGION"`
 }
+
+type shiftCfg struct {
+	MinRestHours   int `mapstructure:"MIN_REST_HOURS"`
+	MaxLengthHours int `mapstructure:"MAX_LENGTH_HOURS"`
+}
+
+type taskCfg struct {
+	SchedulerInterval   int `mapstructure:"SCHEDULER_INTERVAL"`
+	ReminderLeadMinutes int `mapstructure:"REMINDER_LEAD_MINUTES"`
+}
+
+type satuSehatCfg struct {
+	Enabled        bool   `mapstructure:"ENABLED"`
+	AuthURL        string `mapstructure:"AUTH_URL"`
+	BaseURL        string `mapstructure:"BASE_URL"`
+	ClientID       string `mapstructure:"SATUSEHAT_CLIENT_ID"`
+	ClientSecret   string `mapstructure:"SATUSEHAT_CLIENT_SECRET"`
+	OrganizationID string `mapstructure:"ORGANIZATION_ID"`
+	LocationID     string `mapstructure:"LOCATION_ID"`
+	SyncInterval   int    `mapstructure:"SYNC_INTERVAL"`
+	BatchSize      int    `mapstructure:"BATCH_SIZE"`
+	MaxAttempts    int    `mapstructure:"MAX_ATTEMPTS"`
+	RetryBaseDelay int    `mapstructure:"RETRY_BASE_DELAY"`
+	RetryMaxDelay  int    `mapstructure:"RETRY_MAX_DELAY"`
+	RequestTimeout int    `mapstructure:"REQUEST_TIMEOUT"`
+}
+
+type hl7Cfg struct {
+	Enabled        bool   `mapstructure:"ENABLED"`
+	Host           string `mapstructure:"HOST"`
+	Port           int    `mapstructure:"PORT"`
+	Facility       string `mapstructure:"FACILITY"`
+	ReadTimeout    int    `mapstructure:"READ_TIMEOUT"`
+	MaxMessageSize int    `mapstructure:"MAX_MESSAGE_SIZE"`
+}
+
+type jobCfg struct {
+	Workers        int `mapstructure:"WORKERS"`
+	PollInterval   int `mapstructure:"POLL_INTERVAL"`
+	Timeout        int `mapstructure:"TIMEOUT"`
+	Lease          int `mapstructure:"LEASE"`
+	MaxAttempts    int `mapstructure:"MAX_ATTEMPTS"`
+	RetryBaseDelay int `mapstructure:"RETRY_BASE_DELAY"`
+	RetryMaxDelay  int `mapstructure:"RETRY_MAX_DELAY"`
+}
+
+type webhookCfg struct {
+	DispatchInterval int `mapstructure:"DISPATCH_INTERVAL"`
+	BatchSize        int `mapstructure:"BATCH_SIZE"`
+	MaxAttempts      int `mapstructure:"MAX_ATTEMPTS"`
+	RetryBaseDelay   int `mapstructure:"RETRY_BASE_DELAY"`
+	RetryMaxDelay    int `mapstructure:"RETRY_MAX_DELAY"`
+	RequestTimeout   int `mapstructure:"REQUEST_TIMEOUT"`
+}
+
+type fhirCfg struct {
+	ExportTimeout int `mapstructure:"EXPORT_TIMEOUT"`
+}
@@ -0,0 +1,22 @@
+package timeparam
+
+import "time"
+
+// Parse accepts either a yyyy-mm-dd date or an ISO 8601 timestamp, the result
+// is in local time to match how timestamps are stored. It reports whether
+// param is a date only and whether it could be parsed at all.
+func Parse(param string) (time.Time, bool, bool) {
+	if param == "" {
+		return time.Time{}, false, false
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, param, time.Local); err == nil {
+		return t, true, true
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, param); err == nil {
+		return t.In(time.Local), false, true
+	}
+
+	return time.Time{}, false, false
+}
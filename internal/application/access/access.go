@@ -0,0 +1,33 @@
+// Package access holds the role checks routes are registered with, after the
+// JWT middleware has put the user of the token in the locals.
+package access
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// ITStaff lets only users from IT through.
+func ITStaff(c *fiber.Ctx) error {
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+
+	userFromToken := c.Locals(domain.UserFromToken)
+	if userFromToken == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized access",
+		})
+	}
+
+	*user = userFromToken.(domain.User)
+	if user.Role != domain.RoleIT {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized access",
+		})
+	}
+
+	return c.Next()
+}
@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/appointment/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	staffIDFromParam       = "staffId"
+	appointmentIDFromParam = "id"
+)
+
+type appointmentHandler struct {
+	appointmentService service.AppointmentServiceContract
+}
+
+func NewAppointmentHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	appointmentService service.AppointmentServiceContract,
+) {
+	handler := appointmentHandler{
+		appointmentService: appointmentService,
+	}
+
+	appointmentRouter := router.Group("/appointment", jwtMiddleware)
+	appointmentRouter.Post("", handler.CreateAppointment)
+	appointmentRouter.Get("", handler.GetAppointments)
+	appointmentRouter.Get("/slots", handler.GetSlots)
+	appointmentRouter.Get("/schedule/:"+staffIDFromParam, handler.GetSchedules)
+	appointmentRouter.Put("/schedule/:"+staffIDFromParam, handler.ReplaceSchedules)
+	appointmentRouter.Post("/:"+appointmentIDFromParam+"/reschedule", handler.RescheduleAppointment)
+	appointmentRouter.Post("/:"+appointmentIDFromParam+"/cancel", handler.CancelAppointment)
+	appointmentRouter.Post("/:"+appointmentIDFromParam+"/no-show", handler.NoShowAppointment)
+	appointmentRouter.Post("/:"+appointmentIDFromParam+"/complete", handler.CompleteAppointment)
+}
+
+func (h appointmentHandler) ReplaceSchedules(c *fiber.Ctx) error {
+	callerInfo := "[appointmentHandler.ReplaceSchedules]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	staffID, err := ulid.Parse(c.Params(staffIDFromParam))
+	if err != nil {
+		l.Error("error parsing staffIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := scheduleReqAcquire()
+	defer scheduleReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	schedules := domain.StaffSchedulesAcquire()
+	defer domain.StaffSchedulesRelease(schedules)
+
+	for _, window := range req.Schedules {
+		schedules = append(schedules, domain.StaffSchedule{
+			Weekday:     time.Weekday(window.Weekday),
+			StartMinute: window.startMinute,
+			EndMinute:   window.endMinute,
+			SlotMinutes: window.SlotMinutes,
+		})
+	}
+
+	err = h.appointmentService.ReplaceSchedules(userCtx, staffID, schedules, user)
+	if err != nil {
+		l.Error("failed to replace staff schedules", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Staff schedule saved successfully"
+	res.Data = newSchedulesRes(schedules)
+
+	return c.JSON(res)
+}
+
+func (h appointmentHandler) GetSchedules(c *fiber.Ctx) error {
+	callerInfo := "[appointmentHandler.GetSchedules]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	staffID, err := ulid.Parse(c.Params(staffIDFromParam))
+	if err != nil {
+		l.Error("error parsing staffIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	schedules := domain.StaffSchedulesAcquire()
+	defer domain.StaffSchedulesRelease(schedules)
+
+	schedules, err = h.appointmentService.GetSchedules(userCtx, staffID, schedules)
+	if err != nil {
+		l.Error("failed to get staff schedules", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Staff schedule retrieved successfully"
+	res.Data = newSchedulesRes(schedules)
+
+	return c.JSON(res)
+}
+
+// GetSlots lists the free slots of a staff member on a date.
+func (h appointmentHandler) GetSlots(c *fiber.Ctx) error {
+	callerInfo := "[appointmentHandler.GetSlots]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := querySlotAcquire()
+	defer querySlotRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := query.validate(); err != nil {
+		l.Error("error validating query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	slots, err := h.appointmentService.GetSlots(userCtx, query.staffID, query.day)
+	if err != nil {
+		l.Error("failed to get slots", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Slots retrieved successfully"
+	res.Data = newSlotsRes(slots)
+
+	return c.JSON(res)
+}
+
+func (h appointmentHandler) CreateAppointment(c *fiber.Ctx) error {
+	callerInfo := "[appointmentHandler.CreateAppointment]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createAppointmentReqAcquire()
+	defer createAppointmentReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	appointment := domain.AppointmentAcquire()
+	defer domain.AppointmentRelease(appointment)
+
+	appointment.PatientID = string(*req.IdentityNumber)
+	appointment.StaffID = req.staffID
+	appointment.RecordID = req.recordID
+	appointment.Type = req.Type
+	appointment.Notes = req.Notes
+	appointment.StartAt = req.startAt
+	appointment.EndAt = req.endAt
+
+	err := h.appointmentService.CreateAppointment(userCtx, appointment, user)
+	if err != nil {
+		l.Error("failed to create appointment", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Appointment booked successfully"
+	res.Data = newAppointmentRes(appointment)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h appointmentHandler) RescheduleAppointment(c *fiber.Ctx) error {
+	callerInfo := "[appointmentHandler.RescheduleAppointment]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	appointmentID, err := ulid.Parse(c.Params(appointmentIDFromParam))
+	if err != nil {
+		l.Error("error parsing appointmentIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := rescheduleReqAcquire()
+	defer rescheduleReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	appointment := domain.AppointmentAcquire()
+	defer domain.AppointmentRelease(appointment)
+
+	appointment.ID = appointmentID
+	appointment.StaffID = req.staffID
+	appointment.StartAt = req.startAt
+	appointment.EndAt = req.endAt
+
+	err = h.appointmentService.RescheduleAppointment(userCtx, appointment, user)
+	if err != nil {
+		l.Error("failed to reschedule appointment", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Appointment rescheduled successfully"
+	res.Data = newAppointmentRes(appointment)
+
+	return c.JSON(res)
+}
+
+func (h appointmentHandler) CancelAppointment(c *fiber.Ctx) error {
+	return h.closeAppointment(
+		c,
+		"[appointmentHandler.CancelAppointment]",
+		domain.AppointmentCancelled,
+		"Appointment cancelled successfully",
+	)
+}
+
+func (h appointmentHandler) NoShowAppointment(c *fiber.Ctx) error {
+	return h.closeAppointment(
+		c,
+		"[appointmentHandler.NoShowAppointment]",
+		domain.AppointmentNoShow,
+		"Appointment marked as no-show successfully",
+	)
+}
+
+func (h appointmentHandler) CompleteAppointment(c *fiber.Ctx) error {
+	return h.closeAppointment(
+		c,
+		"[appointmentHandler.CompleteAppointment]",
+		domain.AppointmentCompleted,
+		"Appointment completed successfully",
+	)
+}
+
+func (h appointmentHandler) closeAppointment(c *fiber.Ctx, callerInfo, status, message string) error {
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	appointmentID, err := ulid.Parse(c.Params(appointmentIDFromParam))
+	if err != nil {
+		l.Error("error parsing appointmentIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := closeAppointmentReqAcquire()
+	defer closeAppointmentReqRelease(req)
+
+	// only cancelling needs a reason, so an empty body is fine
+	if len(c.Body()) > 0 {
+		if err = c.BodyParser(req); err != nil {
+			l.Error("error parsing request body", zap.Error(err))
+			return errBadRequest{err: err}
+		}
+	}
+
+	if err = req.validate(status); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	appointment := domain.AppointmentAcquire()
+	defer domain.AppointmentRelease(appointment)
+
+	appointment.ID = appointmentID
+	appointment.Status = status
+	appointment.CloseReason = req.Reason
+
+	err = h.appointmentService.CloseAppointment(userCtx, appointment, user)
+	if err != nil {
+		l.Error("failed to close appointment", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+	res.Data = newAppointmentRes(appointment)
+
+	return c.JSON(res)
+}
+
+func (h appointmentHandler) GetAppointments(c *fiber.Ctx) error {
+	callerInfo := "[appointmentHandler.GetAppointments]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryAppointmentAcquire()
+	defer queryAppointmentRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterAppointmentAcquire()
+	defer domain.FilterAppointmentRelease(filter)
+
+	filter.PatientID = query.patientID
+	filter.StaffID = query.staffID
+	filter.Status = query.Status
+	filter.From = query.from
+	filter.To = query.to
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	appointments := domain.AppointmentsAcquire()
+	defer domain.AppointmentsRelease(appointments)
+
+	appointments, err := h.appointmentService.GetAppointments(userCtx, filter, appointments)
+	if err != nil {
+		l.Error("failed to get appointments", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Appointments retrieved successfully"
+
+	appointmentsRes := getAppointmentsResAcquire()
+	defer getAppointmentsResRelease(appointmentsRes)
+
+	for i := range appointments {
+		appointmentsRes = append(appointmentsRes, newAppointmentRes(&appointments[i]))
+	}
+
+	res.Data = appointmentsRes
+
+	return c.JSON(res)
+}
@@ -0,0 +1,599 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/timeparam"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+var scheduleReqPool = sync.Pool{
+	New: func() any {
+		return new(scheduleReq)
+	},
+}
+
+func scheduleReqAcquire() *scheduleReq {
+	return scheduleReqPool.Get().(*scheduleReq)
+}
+
+func scheduleReqRelease(t *scheduleReq) {
+	*t = scheduleReq{}
+	scheduleReqPool.Put(t)
+}
+
+// scheduleReq replaces the whole weekly schedule, an empty list clears it.
+type scheduleReq struct {
+	Schedules []scheduleWindowReq `json:"schedules"`
+}
+
+// scheduleWindowReq is open on Weekday, 0 being Sunday, from StartTime to
+// EndTime given as hh:mm, EndTime can be 24:00.
+type scheduleWindowReq struct {
+	Weekday     int    `json:"weekday"`
+	StartTime   string `json:"startTime"`
+	startMinute int
+	EndTime     string `json:"endTime"`
+	endMinute   int
+	SlotMinutes int `json:"slotMinutes"`
+}
+
+func (r *scheduleReq) validate() error {
+	const maxWindows = 50
+
+	if len(r.Schedules) > maxWindows {
+		return fmt.Errorf("schedules must have at most %d windows", maxWindows)
+	}
+
+	var errs error
+
+	for i := range r.Schedules {
+		w := &r.Schedules[i]
+
+		if w.Weekday < 0 || w.Weekday > 6 {
+			errs = multierr.Append(errs, fmt.Errorf("schedules[%d].weekday must be between 0 and 6", i))
+		}
+
+		startMinute, ok := parseClock(w.StartTime)
+		if !ok {
+			errs = multierr.Append(errs, fmt.Errorf("schedules[%d].startTime must be in hh:mm format", i))
+		}
+		w.startMinute = startMinute
+
+		endMinute, ok := parseClock(w.EndTime)
+		if !ok {
+			errs = multierr.Append(errs, fmt.Errorf("schedules[%d].endTime must be in hh:mm format", i))
+		} else if endMinute <= startMinute {
+			errs = multierr.Append(errs, fmt.Errorf("schedules[%d].endTime must be after startTime", i))
+		}
+		w.endMinute = endMinute
+
+		if w.SlotMinutes < 5 || w.SlotMinutes > 480 {
+			errs = multierr.Append(errs, fmt.Errorf("schedules[%d].slotMinutes must be between 5 and 480", i))
+		}
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	for i := range r.Schedules {
+		for j := i + 1; j < len(r.Schedules); j++ {
+			a, b := &r.Schedules[i], &r.Schedules[j]
+			if a.Weekday == b.Weekday && a.startMinute < b.endMinute && b.startMinute < a.endMinute {
+				errs = multierr.Append(errs, fmt.Errorf("schedules[%d] overlaps schedules[%d]", i, j))
+			}
+		}
+	}
+
+	return errs
+}
+
+// parseClock turns hh:mm into minutes from midnight.
+func parseClock(clock string) (int, bool) {
+	const endOfDay = 24 * 60
+
+	if clock == "24:00" {
+		return endOfDay, true
+	}
+
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+var createAppointmentReqPool = sync.Pool{
+	New: func() any {
+		return new(createAppointmentReq)
+	},
+}
+
+func createAppointmentReqAcquire() *createAppointmentReq {
+	return createAppointmentReqPool.Get().(*createAppointmentReq)
+}
+
+func createAppointmentReqRelease(t *createAppointmentReq) {
+	*t = createAppointmentReq{}
+	createAppointmentReqPool.Put(t)
+}
+
+// createAppointmentReq can point at the medical record the appointment
+// follows up on with RecordID.
+type createAppointmentReq struct {
+	IdentityNumber *idNumber `json:"identityNumber"`
+	StaffID        string    `json:"staffId"`
+	staffID        ulid.ULID
+	RecordID       string `json:"recordId"`
+	recordID       ulid.ULID
+	Type           string `json:"type"`
+	Notes          string `json:"notes"`
+	StartAt        string `json:"startAt"`
+	startAt        time.Time
+	EndAt          string `json:"endAt"`
+	endAt          time.Time
+}
+
+func (r *createAppointmentReq) validate() error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	staffID, err := ulid.Parse(r.StaffID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("staffId is invalid"))
+	}
+	r.staffID = staffID
+
+	if r.RecordID != "" {
+		recordID, err := ulid.Parse(r.RecordID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("recordId is invalid"))
+		}
+		r.recordID = recordID
+	}
+
+	if !slices.Contains(domain.AppointmentTypes, r.Type) {
+		errs = multierr.Append(errs, errors.New("type must be one of consultation, follow_up, procedure or other"))
+	}
+
+	if len(r.Notes) > 500 {
+		errs = multierr.Append(errs, errors.New("notes must have at most 500 characters"))
+	}
+
+	r.startAt, r.endAt, err = parsePeriod(r.StartAt, r.EndAt)
+	errs = multierr.Append(errs, err)
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var rescheduleReqPool = sync.Pool{
+	New: func() any {
+		return new(rescheduleReq)
+	},
+}
+
+func rescheduleReqAcquire() *rescheduleReq {
+	return rescheduleReqPool.Get().(*rescheduleReq)
+}
+
+func rescheduleReqRelease(t *rescheduleReq) {
+	*t = rescheduleReq{}
+	rescheduleReqPool.Put(t)
+}
+
+// rescheduleReq keeps the staff of the appointment unless StaffID is set.
+type rescheduleReq struct {
+	StaffID string `json:"staffId"`
+	staffID ulid.ULID
+	StartAt string `json:"startAt"`
+	startAt time.Time
+	EndAt   string `json:"endAt"`
+	endAt   time.Time
+}
+
+func (r *rescheduleReq) validate() error {
+	var errs, err error
+
+	if r.StaffID != "" {
+		staffID, err := ulid.Parse(r.StaffID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("staffId is invalid"))
+		}
+		r.staffID = staffID
+	}
+
+	r.startAt, r.endAt, err = parsePeriod(r.StartAt, r.EndAt)
+	errs = multierr.Append(errs, err)
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+// parsePeriod parses startAt and endAt in ISO 8601, endAt has to be after
+// startAt on the same day.
+func parsePeriod(startAt, endAt string) (time.Time, time.Time, error) {
+	var errs error
+
+	start, err := time.Parse(time.RFC3339Nano, startAt)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("startAt must be in ISO 8601 format"))
+	}
+	start = start.In(time.Local)
+
+	end, err := time.Parse(time.RFC3339Nano, endAt)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("endAt must be in ISO 8601 format"))
+	}
+	end = end.In(time.Local)
+
+	if errs == nil && !end.After(start) {
+		errs = multierr.Append(errs, errors.New("endAt must be after startAt"))
+	}
+
+	return start, end, errs
+}
+
+var closeAppointmentReqPool = sync.Pool{
+	New: func() any {
+		return new(closeAppointmentReq)
+	},
+}
+
+func closeAppointmentReqAcquire() *closeAppointmentReq {
+	return closeAppointmentReqPool.Get().(*closeAppointmentReq)
+}
+
+func closeAppointmentReqRelease(t *closeAppointmentReq) {
+	*t = closeAppointmentReq{}
+	closeAppointmentReqPool.Put(t)
+}
+
+// closeAppointmentReq needs a reason when the appointment is cancelled.
+type closeAppointmentReq struct {
+	Reason string `json:"reason"`
+}
+
+func (r *closeAppointmentReq) validate(status string) error {
+	if status == domain.AppointmentCancelled && r.Reason == "" {
+		return errors.New("reason is required when an appointment is cancelled")
+	}
+
+	if len(r.Reason) > 200 {
+		return errors.New("reason must have at most 200 characters")
+	}
+
+	return nil
+}
+
+var querySlotPool = sync.Pool{
+	New: func() any {
+		return new(querySlot)
+	},
+}
+
+func querySlotAcquire() *querySlot {
+	return querySlotPool.Get().(*querySlot)
+}
+
+func querySlotRelease(t *querySlot) {
+	*t = querySlot{}
+	querySlotPool.Put(t)
+}
+
+type querySlot struct {
+	StaffID string `query:"staffId"`
+	staffID ulid.ULID
+	Date    string `query:"date"`
+	day     time.Time
+}
+
+func (q *querySlot) validate() error {
+	var errs error
+
+	staffID, err := ulid.Parse(q.StaffID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("staffId is invalid"))
+	}
+	q.staffID = staffID
+
+	day, err := time.ParseInLocation(time.DateOnly, q.Date, time.Local)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("date must be in yyyy-mm-dd format"))
+	}
+	q.day = day
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var queryAppointmentPool = sync.Pool{
+	New: func() any {
+		return new(queryAppointment)
+	},
+}
+
+func queryAppointmentAcquire() *queryAppointment {
+	return queryAppointmentPool.Get().(*queryAppointment)
+}
+
+func queryAppointmentRelease(t *queryAppointment) {
+	*t = queryAppointment{}
+	queryAppointmentPool.Put(t)
+}
+
+type queryAppointment struct {
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	StaffID        string `query:"staffId"`
+	staffID        ulid.ULID
+	Status         string `query:"status"`
+	From           string `query:"from"`
+	from           time.Time
+	To             string `query:"to"`
+	to             time.Time
+	Limit          int `query:"limit"`
+	Offset         int `query:"offset"`
+}
+
+func (q *queryAppointment) validate() {
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.StaffID != "" {
+		q.staffID, _ = ulid.Parse(q.StaffID)
+	}
+
+	switch q.Status {
+	case domain.AppointmentScheduled, domain.AppointmentCompleted, domain.AppointmentCancelled,
+		domain.AppointmentNoShow, domain.AppointmentRescheduled:
+	default:
+		q.Status = ""
+	}
+
+	if from, _, ok := timeparam.Parse(q.From); ok {
+		q.from = from
+	}
+
+	// a bare date includes the whole day
+	if to, dateOnly, ok := timeparam.Parse(q.To); ok {
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		q.to = to
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+type scheduleRes struct {
+	Weekday     int    `json:"weekday"`
+	StartTime   string `json:"startTime"`
+	EndTime     string `json:"endTime"`
+	SlotMinutes int    `json:"slotMinutes"`
+}
+
+func newSchedulesRes(schedules domain.StaffSchedules) []scheduleRes {
+	res := make([]scheduleRes, 0, len(schedules))
+	for i := range schedules {
+		res = append(res, scheduleRes{
+			Weekday:     int(schedules[i].Weekday),
+			StartTime:   formatClock(schedules[i].StartMinute),
+			EndTime:     formatClock(schedules[i].EndMinute),
+			SlotMinutes: schedules[i].SlotMinutes,
+		})
+	}
+
+	return res
+}
+
+type slotRes struct {
+	StartAt string `json:"startAt"`
+	EndAt   string `json:"endAt"`
+}
+
+func newSlotsRes(slots domain.Slots) []slotRes {
+	res := make([]slotRes, 0, len(slots))
+	for i := range slots {
+		res = append(res, slotRes{
+			StartAt: slots[i].StartAt.Format(dateFormat),
+			EndAt:   slots[i].EndAt.Format(dateFormat),
+		})
+	}
+
+	return res
+}
+
+type patientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+}
+
+type staffRes struct {
+	UserID ulid.ULID `json:"userId"`
+	Name   string    `json:"name"`
+}
+
+type appointmentRes struct {
+	AppointmentID   ulid.ULID  `json:"appointmentId"`
+	Patient         patientRes `json:"patient"`
+	Staff           staffRes   `json:"staff"`
+	RecordID        *ulid.ULID `json:"recordId"`
+	Type            string     `json:"type"`
+	Status          string     `json:"status"`
+	Notes           string     `json:"notes"`
+	StartAt         string     `json:"startAt"`
+	EndAt           string     `json:"endAt"`
+	RescheduledFrom *ulid.ULID `json:"rescheduledFrom"`
+	CreatedBy       staffRes   `json:"createdBy"`
+	CreatedAt       string     `json:"createdAt"`
+	ClosedBy        *staffRes  `json:"closedBy"`
+	ClosedAt        string     `json:"closedAt,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+}
+
+func newAppointmentRes(appointment *domain.Appointment) appointmentRes {
+	res := appointmentRes{
+		AppointmentID: appointment.ID,
+		Patient: patientRes{
+			IdentityNumber: idNumber(appointment.PatientID),
+			Name:           appointment.PatientName,
+		},
+		Staff: staffRes{
+			UserID: appointment.StaffID,
+			Name:   appointment.StaffName,
+		},
+		Type:    appointment.Type,
+		Status:  appointment.Status,
+		Notes:   appointment.Notes,
+		StartAt: appointment.StartAt.Format(dateFormat),
+		EndAt:   appointment.EndAt.Format(dateFormat),
+		CreatedBy: staffRes{
+			UserID: appointment.CreatedBy,
+			Name:   appointment.CreatedByName,
+		},
+		CreatedAt: appointment.CreatedAt.Format(dateFormat),
+		Reason:    appointment.CloseReason,
+	}
+	if !id.IsZero(appointment.RecordID) {
+		recordID := appointment.RecordID
+		res.RecordID = &recordID
+	}
+	if !id.IsZero(appointment.RescheduledFrom) {
+		rescheduledFrom := appointment.RescheduledFrom
+		res.RescheduledFrom = &rescheduledFrom
+	}
+	if !id.IsZero(appointment.ClosedBy) {
+		res.ClosedBy = &staffRes{
+			UserID: appointment.ClosedBy,
+			Name:   appointment.ClosedByName,
+		}
+		res.ClosedAt = appointment.ClosedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+const appointmentInitCap = 5
+
+var getAppointmentsResPool = sync.Pool{
+	New: func() any {
+		return make(getAppointmentsRes, 0, appointmentInitCap)
+	},
+}
+
+func getAppointmentsResAcquire() getAppointmentsRes {
+	return getAppointmentsResPool.Get().(getAppointmentsRes)
+}
+
+func getAppointmentsResRelease(t getAppointmentsRes) {
+	t = t[:0]
+	getAppointmentsResPool.Put(t) // nolint:staticcheck
+}
+
+type getAppointmentsRes []appointmentRes
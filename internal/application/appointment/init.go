@@ -0,0 +1,21 @@
+package appointment
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/appointment/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/appointment/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/appointment/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	appointmentRepository := repository.NewAppointmentRepository(db)
+	appointmentService := service.NewAppointmentService(ctxTimeout, appointmentRepository)
+	handler.NewAppointmentHandler(router, jwtMiddleware, appointmentService)
+}
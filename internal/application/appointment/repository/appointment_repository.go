@@ -0,0 +1,646 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	appointmentColumns = `a.id, a.patient_id, p.name, a.staff_id, s.name, a.record_id, a.start_at, a.end_at, a.type, 
+		a.status, a.notes, a.rescheduled_from, a.created_by, a.created_by_name, a.created_at, a.closed_by, 
+		a.closed_by_name, a.closed_at, a.close_reason`
+	appointmentTables = ` FROM appointments a 
+		JOIN patients p ON p.id = a.patient_id 
+		JOIN users s ON s.id = a.staff_id`
+
+	patientOverlapConstraint = "appointments_patient_no_overlap"
+)
+
+type AppointmentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAppointmentRepository(db *pgxpool.Pool) *AppointmentRepository {
+	return &AppointmentRepository{db: db}
+}
+
+// ReplaceSchedules swaps the whole weekly schedule of the staff member for
+// schedules, booked appointments are kept as they are.
+func (r AppointmentRepository) ReplaceSchedules(
+	ctx context.Context,
+	staffID ulid.ULID,
+	schedules domain.StaffSchedules,
+) error {
+	callerInfo := "[AppointmentRepository.ReplaceSchedules]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// locking the staff row serializes concurrent replacements
+	var locked bool
+	lockQuery := `SELECT true FROM users WHERE id = @id FOR NO KEY UPDATE`
+	if err = tx.QueryRow(ctx, lockQuery, pgx.NamedArgs{"id": staffID}).Scan(&locked); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrUserNotFound)
+		}
+
+		l.Error("failed to lock staff", zap.Error(err))
+		return err
+	}
+
+	deleteQuery := `DELETE FROM staff_schedules WHERE staff_id = @staff_id`
+	if _, err = tx.Exec(ctx, deleteQuery, pgx.NamedArgs{"staff_id": staffID}); err != nil {
+		l.Error("failed to delete staff schedules", zap.Error(err))
+		return err
+	}
+
+	now := time.Now()
+	rows := make([][]any, 0, len(schedules))
+	for i := range schedules {
+		schedules[i].ID = id.New()
+		schedules[i].StaffID = staffID
+		rows = append(rows, []any{
+			schedules[i].ID.Bytes(),
+			staffID.Bytes(),
+			int16(schedules[i].Weekday),
+			int16(schedules[i].StartMinute),
+			int16(schedules[i].EndMinute),
+			int16(schedules[i].SlotMinutes),
+			now,
+		})
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"staff_schedules"},
+		[]string{"id", "staff_id", "weekday", "start_minute", "end_minute", "slot_minutes", "created_at"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		l.Error("failed to save staff schedules", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r AppointmentRepository) GetSchedules(
+	ctx context.Context,
+	staffID ulid.ULID,
+	schedules domain.StaffSchedules,
+) (domain.StaffSchedules, error) {
+	callerInfo := "[AppointmentRepository.GetSchedules]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	getQuery := `SELECT id, staff_id, weekday, start_minute, end_minute, slot_minutes FROM staff_schedules 
+		WHERE staff_id = @staff_id ORDER BY weekday ASC, start_minute ASC`
+
+	rows, err := r.db.Query(ctx, getQuery, pgx.NamedArgs{"staff_id": staffID})
+	if err != nil {
+		l.Error("failed to get staff schedules", zap.Error(err))
+		return schedules, err
+	}
+
+	dSchedule := domain.StaffScheduleAcquire()
+	defer domain.StaffScheduleRelease(dSchedule)
+	var weekday int16
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dSchedule.ID,
+			&dSchedule.StaffID,
+			&weekday,
+			&dSchedule.StartMinute,
+			&dSchedule.EndMinute,
+			&dSchedule.SlotMinutes,
+		},
+		func() error {
+			dSchedule.Weekday = time.Weekday(weekday)
+			schedules = append(schedules, *dSchedule)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get staff schedules", zap.Error(err))
+		return schedules, err
+	}
+
+	return schedules, nil
+}
+
+// CreateAppointment books the appointment, the exclusion constraints on
+// appointments reject a slot already taken by the staff or the patient.
+func (r AppointmentRepository) CreateAppointment(
+	ctx context.Context,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	callerInfo := "[AppointmentRepository.CreateAppointment]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if !id.IsZero(appointment.RecordID) {
+		var patientID string
+		recordQuery := `SELECT patient_id FROM medical_records WHERE id = @id`
+		err = tx.QueryRow(ctx, recordQuery, pgx.NamedArgs{"id": appointment.RecordID}).Scan(&patientID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return new(domain.ErrMedicalRecordNotFound)
+			}
+
+			l.Error("failed to get medical record", zap.Error(err))
+			return err
+		}
+
+		if patientID != appointment.PatientID {
+			return new(domain.ErrRecordPatientMismatch)
+		}
+	}
+
+	if err = r.insertAppointment(ctx, tx, appointment, user); err != nil {
+		l.Error("failed to create appointment", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, appointment, domain.PatientEventAppointmentBooked, user)
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// RescheduleAppointment replaces the scheduled appointment.ID with a new
+// appointment at appointment.StartAt and EndAt, with appointment.StaffID if
+// set. The old appointment is kept as rescheduled and appointment is filled
+// with the new one.
+func (r AppointmentRepository) RescheduleAppointment(
+	ctx context.Context,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	callerInfo := "[AppointmentRepository.RescheduleAppointment]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	old := domain.AppointmentAcquire()
+	defer domain.AppointmentRelease(old)
+
+	if err = r.lockScheduled(ctx, tx, appointment.ID, old); err != nil {
+		l.Error("failed to get appointment", zap.Error(err))
+		return err
+	}
+
+	if err = r.closeAppointment(ctx, tx, old, domain.AppointmentRescheduled, "", user); err != nil {
+		l.Error("failed to close appointment", zap.Error(err))
+		return err
+	}
+
+	staffID := appointment.StaffID
+	if id.IsZero(staffID) {
+		staffID = old.StaffID
+	}
+
+	*appointment = domain.Appointment{
+		PatientID:       old.PatientID,
+		StaffID:         staffID,
+		RecordID:        old.RecordID,
+		StartAt:         appointment.StartAt,
+		EndAt:           appointment.EndAt,
+		Type:            old.Type,
+		Notes:           old.Notes,
+		RescheduledFrom: old.ID,
+	}
+
+	// the old slot is released above, so moving within an overlapping time
+	// does not conflict with itself
+	if err = r.insertAppointment(ctx, tx, appointment, user); err != nil {
+		l.Error("failed to create appointment", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, appointment, domain.PatientEventAppointmentRescheduled, user)
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// CloseAppointment moves a scheduled appointment to appointment.Status, only
+// appointments that have started can be completed or marked as no-show.
+func (r AppointmentRepository) CloseAppointment(
+	ctx context.Context,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	callerInfo := "[AppointmentRepository.CloseAppointment]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	status, reason := appointment.Status, appointment.CloseReason
+
+	if err = r.lockScheduled(ctx, tx, appointment.ID, appointment); err != nil {
+		l.Error("failed to get appointment", zap.Error(err))
+		return err
+	}
+
+	if status != domain.AppointmentCancelled && appointment.StartAt.After(time.Now()) {
+		return new(domain.ErrAppointmentNotStarted)
+	}
+
+	if err = r.closeAppointment(ctx, tx, appointment, status, reason, user); err != nil {
+		l.Error("failed to close appointment", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, appointment, domain.PatientEventAppointmentClosed, user)
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r AppointmentRepository) GetAppointments(
+	ctx context.Context,
+	filter *domain.FilterAppointment,
+	appointments domain.Appointments,
+) (domain.Appointments, error) {
+	callerInfo := "[AppointmentRepository.GetAppointments]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterAppointment(filter)
+	getQuery := `SELECT ` + appointmentColumns + appointmentTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get appointments", zap.Error(err))
+		return appointments, err
+	}
+	defer rows.Close()
+
+	dAppointment := domain.AppointmentAcquire()
+	defer domain.AppointmentRelease(dAppointment)
+
+	for rows.Next() {
+		if err = r.scanAppointment(rows, dAppointment); err != nil {
+			l.Error("failed to scan appointment", zap.Error(err))
+			return appointments, err
+		}
+		appointments = append(appointments, *dAppointment)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get appointments", zap.Error(err))
+		return appointments, err
+	}
+
+	return appointments, nil
+}
+
+func (r AppointmentRepository) insertAppointment(
+	ctx context.Context,
+	tx pgx.Tx,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	appointment.ID = id.New()
+	appointment.Status = domain.AppointmentScheduled
+	appointment.CreatedBy = user.ID
+	appointment.CreatedByName = user.Name
+	appointment.CreatedAt = time.Now()
+
+	staffQuery := `SELECT name FROM users WHERE id = @id`
+	err := tx.QueryRow(ctx, staffQuery, pgx.NamedArgs{"id": appointment.StaffID}).Scan(&appointment.StaffName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrUserNotFound)
+		}
+		return err
+	}
+
+	var recordID, rescheduledFrom any
+	if !id.IsZero(appointment.RecordID) {
+		recordID = appointment.RecordID
+	}
+	if !id.IsZero(appointment.RescheduledFrom) {
+		rescheduledFrom = appointment.RescheduledFrom
+	}
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO appointments (
+				id, patient_id, staff_id, record_id, start_at, end_at, type, status, notes, rescheduled_from, 
+				created_by, created_by_name, created_at
+			)
+			SELECT @id, p.id, @staff_id, @record_id, @start_at, @end_at, @type, @status, @notes, @rescheduled_from, 
+				@created_by, @created_by_name, @created_at
+			FROM patients p WHERE p.id = @patient_id
+			RETURNING patient_id
+		)
+		SELECT p.name FROM inserted i JOIN patients p ON p.id = i.patient_id`
+	args := pgx.NamedArgs{
+		"id":               appointment.ID,
+		"patient_id":       appointment.PatientID,
+		"staff_id":         appointment.StaffID,
+		"record_id":        recordID,
+		"start_at":         appointment.StartAt,
+		"end_at":           appointment.EndAt,
+		"type":             appointment.Type,
+		"status":           appointment.Status,
+		"notes":            appointment.Notes,
+		"rescheduled_from": rescheduledFrom,
+		"created_by":       appointment.CreatedBy,
+		"created_by_name":  appointment.CreatedByName,
+		"created_at":       appointment.CreatedAt,
+	}
+
+	err = tx.QueryRow(ctx, insertQuery, args).Scan(&appointment.PatientName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ExclusionViolation {
+			if pgErr.ConstraintName == patientOverlapConstraint {
+				return new(domain.ErrPatientDoubleBooked)
+			}
+			return new(domain.ErrStaffDoubleBooked)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// lockScheduled reads appointment appointmentID into appointment and locks
+// it, the appointment has to be still scheduled.
+func (r AppointmentRepository) lockScheduled(
+	ctx context.Context,
+	tx pgx.Tx,
+	appointmentID ulid.ULID,
+	appointment *domain.Appointment,
+) error {
+	selectQuery := `SELECT ` + appointmentColumns + appointmentTables + ` WHERE a.id = @id FOR UPDATE OF a`
+
+	err := r.scanAppointment(tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": appointmentID}), appointment)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrAppointmentNotFound)
+		}
+		return err
+	}
+
+	if appointment.Status != domain.AppointmentScheduled {
+		return new(domain.ErrAppointmentClosed)
+	}
+
+	return nil
+}
+
+func (r AppointmentRepository) closeAppointment(
+	ctx context.Context,
+	tx pgx.Tx,
+	appointment *domain.Appointment,
+	status, reason string,
+	user *domain.User,
+) error {
+	appointment.Status = status
+	appointment.ClosedBy = user.ID
+	appointment.ClosedByName = user.Name
+	appointment.ClosedAt = time.Now()
+	appointment.CloseReason = reason
+
+	updateQuery := `UPDATE appointments SET status = @status, closed_by = @closed_by, closed_by_name = @closed_by_name, 
+			closed_at = @closed_at, close_reason = @close_reason 
+		WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":             appointment.ID,
+		"status":         appointment.Status,
+		"closed_by":      appointment.ClosedBy,
+		"closed_by_name": appointment.ClosedByName,
+		"closed_at":      appointment.ClosedAt,
+		"close_reason":   appointment.CloseReason,
+	}
+
+	_, err := tx.Exec(ctx, updateQuery, args)
+	return err
+}
+
+func (r AppointmentRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	appointment *domain.Appointment,
+	eventType string,
+	user *domain.User,
+) error {
+	data := map[string]any{
+		"appointmentId": appointment.ID.String(),
+		"staffId":       appointment.StaffID.String(),
+		"staffName":     appointment.StaffName,
+		"type":          appointment.Type,
+		"status":        appointment.Status,
+		"startAt":       appointment.StartAt,
+		"endAt":         appointment.EndAt,
+	}
+	if !id.IsZero(appointment.RescheduledFrom) {
+		data["rescheduledFrom"] = appointment.RescheduledFrom.String()
+	}
+	if appointment.CloseReason != "" {
+		data["reason"] = appointment.CloseReason
+	}
+
+	event, err := patientevent.New(appointment.PatientID, eventType, user, data)
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+func (r AppointmentRepository) scanAppointment(row pgx.Row, appointment *domain.Appointment) error {
+	var (
+		closedByName, closeReason *string
+		closedAt                  *time.Time
+	)
+
+	appointment.RecordID, appointment.RescheduledFrom, appointment.ClosedBy = ulid.ULID{}, ulid.ULID{}, ulid.ULID{}
+
+	err := row.Scan(
+		&appointment.ID,
+		&appointment.PatientID,
+		&appointment.PatientName,
+		&appointment.StaffID,
+		&appointment.StaffName,
+		&appointment.RecordID,
+		&appointment.StartAt,
+		&appointment.EndAt,
+		&appointment.Type,
+		&appointment.Status,
+		&appointment.Notes,
+		&appointment.RescheduledFrom,
+		&appointment.CreatedBy,
+		&appointment.CreatedByName,
+		&appointment.CreatedAt,
+		&appointment.ClosedBy,
+		&closedByName,
+		&closedAt,
+		&closeReason,
+	)
+	if err != nil {
+		return err
+	}
+
+	appointment.ClosedByName, appointment.CloseReason = "", ""
+	appointment.ClosedAt = time.Time{}
+
+	if closedByName != nil {
+		appointment.ClosedByName = *closedByName
+	}
+	if closedAt != nil {
+		appointment.ClosedAt = *closedAt
+	}
+	if closeReason != nil {
+		appointment.CloseReason = *closeReason
+	}
+
+	return nil
+}
+
+func (r AppointmentRepository) filterAppointment(filter *domain.FilterAppointment) (string, pgx.NamedArgs) {
+	const totalConditions = 6
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "a.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "a.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.StaffID) {
+		conditions = append(conditions, "a.staff_id = @staff_id")
+		params["staff_id"] = filter.StaffID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "a.status = @status")
+		params["status"] = filter.Status
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "a.end_at > @from")
+		params["from"] = filter.From
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "a.start_at < @to")
+		params["to"] = filter.To
+	}
+
+	order := " ORDER BY a.start_at ASC, a.id ASC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ AppointmentRepositoryContract = (*AppointmentRepository)(nil)
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type AppointmentRepositoryContract interface {
+	ReplaceSchedules(ctx context.Context, staffID ulid.ULID, schedules domain.StaffSchedules) error
+	GetSchedules(
+		ctx context.Context,
+		staffID ulid.ULID,
+		schedules domain.StaffSchedules,
+	) (domain.StaffSchedules, error)
+	CreateAppointment(ctx context.Context, appointment *domain.Appointment, user *domain.User) error
+	RescheduleAppointment(ctx context.Context, appointment *domain.Appointment, user *domain.User) error
+	CloseAppointment(ctx context.Context, appointment *domain.Appointment, user *domain.User) error
+	GetAppointments(
+		ctx context.Context,
+		filter *domain.FilterAppointment,
+		appointments domain.Appointments,
+	) (domain.Appointments, error)
+}
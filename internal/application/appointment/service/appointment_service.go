@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/appointment/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// maxAppointmentsPerDay bounds how many appointments of a staff member are
+// read to compute the free slots of a day.
+const maxAppointmentsPerDay = 500
+
+type AppointmentService struct {
+	appointmentRepository repository.AppointmentRepositoryContract
+	contextTimeout        time.Duration
+}
+
+func NewAppointmentService(
+	timeout time.Duration,
+	appointmentRepository repository.AppointmentRepositoryContract,
+) *AppointmentService {
+	return &AppointmentService{
+		appointmentRepository: appointmentRepository,
+		contextTimeout:        timeout,
+	}
+}
+
+// ReplaceSchedules is allowed to IT staff and to the staff member whose
+// schedule it is.
+func (s AppointmentService) ReplaceSchedules(
+	ctx context.Context,
+	staffID ulid.ULID,
+	schedules domain.StaffSchedules,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.ReplaceSchedules]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if user.Role != domain.RoleIT && user.ID.Compare(staffID) != 0 {
+		return new(domain.ErrScheduleForbidden)
+	}
+
+	err := s.appointmentRepository.ReplaceSchedules(ctx, staffID, schedules)
+	if err != nil {
+		l.Error("failed to replace staff schedules", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s AppointmentService) GetSchedules(
+	ctx context.Context,
+	staffID ulid.ULID,
+	schedules domain.StaffSchedules,
+) (domain.StaffSchedules, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.GetSchedules]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	schedules, err := s.appointmentRepository.GetSchedules(ctx, staffID, schedules)
+	if err != nil {
+		l.Error("failed to get staff schedules", zap.Error(err))
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// GetSlots returns the slots of the staff member still free on day, day is
+// midnight of the day in local time.
+func (s AppointmentService) GetSlots(ctx context.Context, staffID ulid.ULID, day time.Time) (domain.Slots, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.GetSlots]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	schedules := domain.StaffSchedulesAcquire()
+	defer domain.StaffSchedulesRelease(schedules)
+
+	schedules, err := s.appointmentRepository.GetSchedules(ctx, staffID, schedules)
+	if err != nil {
+		l.Error("failed to get staff schedules", zap.Error(err))
+		return nil, err
+	}
+
+	filter := domain.FilterAppointmentAcquire()
+	defer domain.FilterAppointmentRelease(filter)
+
+	filter.StaffID = staffID
+	filter.Status = domain.AppointmentScheduled
+	filter.From = day
+	filter.To = day.AddDate(0, 0, 1)
+	filter.Limit = maxAppointmentsPerDay
+
+	booked := domain.AppointmentsAcquire()
+	defer domain.AppointmentsRelease(booked)
+
+	booked, err = s.appointmentRepository.GetAppointments(ctx, filter, booked)
+	if err != nil {
+		l.Error("failed to get appointments", zap.Error(err))
+		return nil, err
+	}
+
+	return schedules.Slots(day, booked, time.Now()), nil
+}
+
+func (s AppointmentService) CreateAppointment(
+	ctx context.Context,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.CreateAppointment]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := s.checkSchedule(ctx, appointment.StaffID, appointment.StartAt, appointment.EndAt); err != nil {
+		l.Error("failed to check staff schedule", zap.Error(err))
+		return err
+	}
+
+	err := s.appointmentRepository.CreateAppointment(ctx, appointment, user)
+	if err != nil {
+		l.Error("failed to create appointment", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// RescheduleAppointment moves the appointment to another time, and to
+// another staff member when appointment.StaffID is set.
+func (s AppointmentService) RescheduleAppointment(
+	ctx context.Context,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.RescheduleAppointment]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	staffID := appointment.StaffID
+	if id.IsZero(staffID) {
+		filter := domain.FilterAppointmentAcquire()
+		defer domain.FilterAppointmentRelease(filter)
+
+		filter.ID = appointment.ID
+
+		current := domain.AppointmentsAcquire()
+		defer domain.AppointmentsRelease(current)
+
+		current, err := s.appointmentRepository.GetAppointments(ctx, filter, current)
+		if err != nil {
+			l.Error("failed to get appointment", zap.Error(err))
+			return err
+		}
+
+		if len(current) == 0 {
+			return new(domain.ErrAppointmentNotFound)
+		}
+		staffID = current[0].StaffID
+	}
+
+	if err := s.checkSchedule(ctx, staffID, appointment.StartAt, appointment.EndAt); err != nil {
+		l.Error("failed to check staff schedule", zap.Error(err))
+		return err
+	}
+
+	err := s.appointmentRepository.RescheduleAppointment(ctx, appointment, user)
+	if err != nil {
+		l.Error("failed to reschedule appointment", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s AppointmentService) CloseAppointment(
+	ctx context.Context,
+	appointment *domain.Appointment,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.CloseAppointment]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.appointmentRepository.CloseAppointment(ctx, appointment, user)
+	if err != nil {
+		l.Error("failed to close appointment", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s AppointmentService) GetAppointments(
+	ctx context.Context,
+	filter *domain.FilterAppointment,
+	appointments domain.Appointments,
+) (domain.Appointments, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[AppointmentService.GetAppointments]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	appointments, err := s.appointmentRepository.GetAppointments(ctx, filter, appointments)
+	if err != nil {
+		l.Error("failed to get appointments", zap.Error(err))
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// checkSchedule makes sure an appointment starts in the future and fits in
+// one window of the staff schedule.
+func (s AppointmentService) checkSchedule(ctx context.Context, staffID ulid.ULID, startAt, endAt time.Time) error {
+	if !startAt.After(time.Now()) {
+		return new(domain.ErrAppointmentInPast)
+	}
+
+	schedules := domain.StaffSchedulesAcquire()
+	defer domain.StaffSchedulesRelease(schedules)
+
+	schedules, err := s.appointmentRepository.GetSchedules(ctx, staffID, schedules)
+	if err != nil {
+		return err
+	}
+
+	if !schedules.Covers(startAt, endAt) {
+		return new(domain.ErrOutsideStaffSchedule)
+	}
+
+	return nil
+}
+
+var _ AppointmentServiceContract = (*AppointmentService)(nil)
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type AppointmentServiceContract interface {
+	ReplaceSchedules(
+		ctx context.Context,
+		staffID ulid.ULID,
+		schedules domain.StaffSchedules,
+		user *domain.User,
+	) error
+	GetSchedules(
+		ctx context.Context,
+		staffID ulid.ULID,
+		schedules domain.StaffSchedules,
+	) (domain.StaffSchedules, error)
+	GetSlots(ctx context.Context, staffID ulid.ULID, day time.Time) (domain.Slots, error)
+	CreateAppointment(ctx context.Context, appointment *domain.Appointment, user *domain.User) error
+	RescheduleAppointment(ctx context.Context, appointment *domain.Appointment, user *domain.User) error
+	CloseAppointment(ctx context.Context, appointment *domain.Appointment, user *domain.User) error
+	GetAppointments(
+		ctx context.Context,
+		filter *domain.FilterAppointment,
+		appointments domain.Appointments,
+	) (domain.Appointments, error)
+}
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/document/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	templateIDFromParam = "id"
+	documentIDFromParam = "id"
+)
+
+var contentTypes = map[string]string{
+	domain.DocumentFormatHTML: "text/html; charset=utf-8",
+	domain.DocumentFormatPDF:  "application/pdf",
+}
+
+type documentHandler struct {
+	documentService service.DocumentServiceContract
+}
+
+func NewDocumentHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	documentService service.DocumentServiceContract,
+) {
+	handler := documentHandler{
+		documentService: documentService,
+	}
+
+	documentRouter := router.Group("/document", jwtMiddleware)
+	documentRouter.Get("/template", handler.GetTemplates)
+	documentRouter.Post("/template", access.ITStaff, handler.CreateTemplate)
+	documentRouter.Put("/template/:"+templateIDFromParam, access.ITStaff, handler.UpdateTemplate)
+	documentRouter.Post("", handler.GenerateDocument)
+	documentRouter.Get("", handler.GetDocuments)
+	documentRouter.Get("/:"+documentIDFromParam+"/download", handler.DownloadDocument)
+}
+
+func (h documentHandler) CreateTemplate(c *fiber.Ctx) error {
+	callerInfo := "[documentHandler.CreateTemplate]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := templateReqAcquire()
+	defer templateReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	template := domain.DocumentTemplateAcquire()
+	defer domain.DocumentTemplateRelease(template)
+
+	template.Kind = req.Kind
+	template.Name = req.Name
+	template.Body = req.Body
+
+	err := h.documentService.CreateTemplate(userCtx, template, user)
+	if err != nil {
+		l.Error("failed to create document template", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Document template created successfully"
+	res.Data = newTemplateRes(template)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h documentHandler) UpdateTemplate(c *fiber.Ctx) error {
+	callerInfo := "[documentHandler.UpdateTemplate]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	templateID, err := ulid.Parse(c.Params(templateIDFromParam))
+	if err != nil {
+		l.Error("error parsing templateIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := templateReqAcquire()
+	defer templateReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	template := domain.DocumentTemplateAcquire()
+	defer domain.DocumentTemplateRelease(template)
+
+	template.ID = templateID
+	template.Kind = req.Kind
+	template.Name = req.Name
+	template.Body = req.Body
+
+	err = h.documentService.UpdateTemplate(userCtx, template, user)
+	if err != nil {
+		l.Error("failed to update document template", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Document template updated successfully"
+	res.Data = newTemplateRes(template)
+
+	return c.JSON(res)
+}
+
+func (h documentHandler) GetTemplates(c *fiber.Ctx) error {
+	callerInfo := "[documentHandler.GetTemplates]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryTemplateAcquire()
+	defer queryTemplateRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterDocumentTemplateAcquire()
+	defer domain.FilterDocumentTemplateRelease(filter)
+
+	filter.ID = query.templateID
+	filter.Kind = query.Kind
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	templates := domain.DocumentTemplatesAcquire()
+	defer domain.DocumentTemplatesRelease(templates)
+
+	templates, err := h.documentService.GetTemplates(userCtx, filter, templates)
+	if err != nil {
+		l.Error("failed to get document templates", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Document templates retrieved successfully"
+
+	templatesRes := getTemplatesResAcquire()
+	defer getTemplatesResRelease(templatesRes)
+
+	for i := range templates {
+		templatesRes = append(templatesRes, newTemplateRes(&templates[i]))
+	}
+
+	res.Data = templatesRes
+
+	return c.JSON(res)
+}
+
+func (h documentHandler) GenerateDocument(c *fiber.Ctx) error {
+	callerInfo := "[documentHandler.GenerateDocument]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := generateReqAcquire()
+	defer generateReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	document := domain.DocumentAcquire()
+	defer domain.DocumentRelease(document)
+
+	document.TemplateID = req.templateID
+	document.PatientID = string(*req.IdentityNumber)
+	document.RecordID = req.recordID
+	document.Recipient = req.Recipient
+	document.Notes = req.Notes
+
+	err := h.documentService.GenerateDocument(userCtx, document, user)
+	if err != nil {
+		l.Error("failed to generate document", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Document generated successfully"
+	res.Data = newDocumentRes(document)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h documentHandler) GetDocuments(c *fiber.Ctx) error {
+	callerInfo := "[documentHandler.GetDocuments]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryDocumentAcquire()
+	defer queryDocumentRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterDocumentAcquire()
+	defer domain.FilterDocumentRelease(filter)
+
+	filter.ID = query.documentID
+	filter.PatientID = query.patientID
+	filter.RecordID = query.recordID
+	filter.Kind = query.Kind
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	documents := domain.DocumentsAcquire()
+	defer domain.DocumentsRelease(documents)
+
+	documents, err := h.documentService.GetDocuments(userCtx, filter, documents)
+	if err != nil {
+		l.Error("failed to get documents", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Documents retrieved successfully"
+
+	documentsRes := getDocumentsResAcquire()
+	defer getDocumentsResRelease(documentsRes)
+
+	for i := range documents {
+		documentsRes = append(documentsRes, newDocumentRes(&documents[i]))
+	}
+
+	res.Data = documentsRes
+
+	return c.JSON(res)
+}
+
+// DownloadDocument streams the PDF of a document, or its HTML with
+// format=html.
+func (h documentHandler) DownloadDocument(c *fiber.Ctx) error {
+	callerInfo := "[documentHandler.DownloadDocument]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	documentID, err := ulid.Parse(c.Params(documentIDFromParam))
+	if err != nil {
+		l.Error("error parsing documentIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	format := c.Query("format", domain.DocumentFormatPDF)
+	contentType, ok := contentTypes[format]
+	if !ok {
+		l.Error("invalid document format", zap.String("format", format))
+		return errBadRequest{err: errors.New("format must be one of pdf or html")}
+	}
+
+	document := domain.DocumentAcquire()
+	defer domain.DocumentRelease(document)
+
+	document.ID = documentID
+
+	body, size, err := h.documentService.OpenDocument(userCtx, document, format)
+	if err != nil {
+		l.Error("failed to open document", zap.Error(err))
+		return err
+	}
+
+	fileName := fmt.Sprintf(
+		"%s-%s-%s.%s",
+		document.Kind,
+		document.PatientID,
+		document.CreatedAt.Format(time.DateOnly),
+		format,
+	)
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(fileName)
+
+	// fasthttp closes body once it has been sent
+	return c.SendStream(body, int(size))
+}
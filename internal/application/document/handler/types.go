@@ -0,0 +1,415 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/document/render"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+var templateReqPool = sync.Pool{
+	New: func() any {
+		return new(templateReq)
+	},
+}
+
+func templateReqAcquire() *templateReq {
+	return templateReqPool.Get().(*templateReq)
+}
+
+func templateReqRelease(t *templateReq) {
+	*t = templateReq{}
+	templateReqPool.Put(t)
+}
+
+// templateReq is used to both create and replace a template, Body is checked
+// by rendering it with sample data.
+type templateReq struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Body string `json:"body"`
+}
+
+func (r *templateReq) validate() error {
+	var errs error
+
+	if r.Kind != domain.DocumentReferral && r.Kind != domain.DocumentDischarge {
+		errs = multierr.Append(errs, errors.New("kind must be one of referral or discharge"))
+	}
+
+	if l := utf8.RuneCountInString(r.Name); l < 1 || l > 100 {
+		errs = multierr.Append(errs, errors.New("name must have 1 to 100 characters"))
+	}
+
+	const maxBodyLength = 100_000
+	if r.Body == "" || len(r.Body) > maxBodyLength {
+		errs = multierr.Append(errs, fmt.Errorf("body must have 1 to %d characters", maxBodyLength))
+	} else if err := render.Check(r.Body); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("body is not a valid template: %w", err))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var generateReqPool = sync.Pool{
+	New: func() any {
+		return new(generateReq)
+	},
+}
+
+func generateReqAcquire() *generateReq {
+	return generateReqPool.Get().(*generateReq)
+}
+
+func generateReqRelease(t *generateReq) {
+	*t = generateReq{}
+	generateReqPool.Put(t)
+}
+
+// generateReq renders a template for a record. Recipient is who a referral is
+// addressed to, Notes is the reason for a referral or the follow-up
+// instructions of a discharge.
+type generateReq struct {
+	TemplateID     string `json:"templateId"`
+	templateID     ulid.ULID
+	IdentityNumber *idNumber `json:"identityNumber"`
+	RecordID       string    `json:"recordId"`
+	recordID       ulid.ULID
+	Recipient      string `json:"recipient"`
+	Notes          string `json:"notes"`
+}
+
+func (r *generateReq) validate() error {
+	var errs error
+
+	templateID, err := ulid.Parse(r.TemplateID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("templateId is invalid"))
+	}
+	r.templateID = templateID
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	recordID, err := ulid.Parse(r.RecordID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("recordId is invalid"))
+	}
+	r.recordID = recordID
+
+	if utf8.RuneCountInString(r.Recipient) > 200 {
+		errs = multierr.Append(errs, errors.New("recipient must have at most 200 characters"))
+	}
+
+	if utf8.RuneCountInString(r.Notes) > 5000 {
+		errs = multierr.Append(errs, errors.New("notes must have at most 5000 characters"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var queryTemplatePool = sync.Pool{
+	New: func() any {
+		return new(queryTemplate)
+	},
+}
+
+func queryTemplateAcquire() *queryTemplate {
+	return queryTemplatePool.Get().(*queryTemplate)
+}
+
+func queryTemplateRelease(t *queryTemplate) {
+	*t = queryTemplate{}
+	queryTemplatePool.Put(t)
+}
+
+type queryTemplate struct {
+	TemplateID string `query:"templateId"`
+	templateID ulid.ULID
+	Kind       string `query:"kind"`
+	Limit      int    `query:"limit"`
+	Offset     int    `query:"offset"`
+}
+
+func (q *queryTemplate) validate() {
+	if q.TemplateID != "" {
+		q.templateID, _ = ulid.Parse(q.TemplateID)
+	}
+
+	if q.Kind != domain.DocumentReferral && q.Kind != domain.DocumentDischarge {
+		q.Kind = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+var queryDocumentPool = sync.Pool{
+	New: func() any {
+		return new(queryDocument)
+	},
+}
+
+func queryDocumentAcquire() *queryDocument {
+	return queryDocumentPool.Get().(*queryDocument)
+}
+
+func queryDocumentRelease(t *queryDocument) {
+	*t = queryDocument{}
+	queryDocumentPool.Put(t)
+}
+
+type queryDocument struct {
+	DocumentID     string `query:"documentId"`
+	documentID     ulid.ULID
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	RecordID       string `query:"recordId"`
+	recordID       ulid.ULID
+	Kind           string `query:"kind"`
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+}
+
+func (q *queryDocument) validate() {
+	if q.DocumentID != "" {
+		q.documentID, _ = ulid.Parse(q.DocumentID)
+	}
+
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.RecordID != "" {
+		q.recordID, _ = ulid.Parse(q.RecordID)
+	}
+
+	if q.Kind != domain.DocumentReferral && q.Kind != domain.DocumentDischarge {
+		q.Kind = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+type templateRes struct {
+	TemplateID ulid.ULID `json:"templateId"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	Body       string    `json:"body"`
+	Version    int       `json:"version"`
+	UpdatedBy  *staffRes `json:"updatedBy"`
+	CreatedAt  string    `json:"createdAt"`
+	UpdatedAt  string    `json:"updatedAt"`
+}
+
+func newTemplateRes(template *domain.DocumentTemplate) templateRes {
+	res := templateRes{
+		TemplateID: template.ID,
+		Kind:       template.Kind,
+		Name:       template.Name,
+		Body:       template.Body,
+		Version:    template.Version,
+		CreatedAt:  template.CreatedAt.Format(dateFormat),
+		UpdatedAt:  template.UpdatedAt.Format(dateFormat),
+	}
+	// the bundled templates have no author
+	if !id.IsZero(template.UpdatedBy) {
+		res.UpdatedBy = &staffRes{
+			UserID: template.UpdatedBy,
+			Name:   template.UpdatedByName,
+		}
+	}
+
+	return res
+}
+
+const documentInitCap = 5
+
+var getTemplatesResPool = sync.Pool{
+	New: func() any {
+		return make(getTemplatesRes, 0, documentInitCap)
+	},
+}
+
+func getTemplatesResAcquire() getTemplatesRes {
+	return getTemplatesResPool.Get().(getTemplatesRes)
+}
+
+func getTemplatesResRelease(t getTemplatesRes) {
+	t = t[:0]
+	getTemplatesResPool.Put(t) // nolint:staticcheck
+}
+
+type getTemplatesRes []templateRes
+
+type patientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+}
+
+type staffRes struct {
+	UserID ulid.ULID `json:"userId"`
+	Name   string    `json:"name"`
+}
+
+type templateRefRes struct {
+	TemplateID ulid.ULID `json:"templateId"`
+	Name       string    `json:"name"`
+	Version    int       `json:"version"`
+}
+
+type documentRes struct {
+	DocumentID ulid.ULID      `json:"documentId"`
+	Kind       string         `json:"kind"`
+	Template   templateRefRes `json:"template"`
+	Patient    patientRes     `json:"patient"`
+	RecordID   ulid.ULID      `json:"recordId"`
+	Recipient  string         `json:"recipient"`
+	Notes      string         `json:"notes"`
+	HTMLURL    string         `json:"htmlUrl"`
+	PDFURL     string         `json:"pdfUrl"`
+	CreatedBy  staffRes       `json:"createdBy"`
+	CreatedAt  string         `json:"createdAt"`
+}
+
+func newDocumentRes(document *domain.Document) documentRes {
+	return documentRes{
+		DocumentID: document.ID,
+		Kind:       document.Kind,
+		Template: templateRefRes{
+			TemplateID: document.TemplateID,
+			Name:       document.TemplateName,
+			Version:    document.TemplateVersion,
+		},
+		Patient: patientRes{
+			IdentityNumber: idNumber(document.PatientID),
+			Name:           document.PatientName,
+		},
+		RecordID:  document.RecordID,
+		Recipient: document.Recipient,
+		Notes:     document.Notes,
+		HTMLURL:   document.HTMLURL,
+		PDFURL:    document.PDFURL,
+		CreatedBy: staffRes{
+			UserID: document.CreatedBy,
+			Name:   document.CreatedByName,
+		},
+		CreatedAt: document.CreatedAt.Format(dateFormat),
+	}
+}
+
+var getDocumentsResPool = sync.Pool{
+	New: func() any {
+		return make(getDocumentsRes, 0, documentInitCap)
+	},
+}
+
+func getDocumentsResAcquire() getDocumentsRes {
+	return getDocumentsResPool.Get().(getDocumentsRes)
+}
+
+func getDocumentsResRelease(t getDocumentsRes) {
+	t = t[:0]
+	getDocumentsResPool.Put(t) // nolint:staticcheck
+}
+
+type getDocumentsRes []documentRes
@@ -0,0 +1,23 @@
+package document
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/document/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/document/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/document/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, s3 *s3.Client, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	documentRepository := repository.NewDocumentRepository(db)
+	storageRepository := repository.NewStorageRepository(s3)
+	documentService := service.NewDocumentService(ctxTimeout, documentRepository, storageRepository)
+	handler.NewDocumentHandler(router, jwtMiddleware, documentService)
+}
@@ -0,0 +1,324 @@
+// Package render turns document templates into HTML and PDF files.
+//
+// Templates are html/template sources, so values are escaped for the context
+// they are written in. Besides the builtins they can call date, datetime, age,
+// lines and labValue.
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	nethtml "golang.org/x/net/html"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("02 January 2006")
+	},
+	"datetime": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("02 January 2006 15:04")
+	},
+	"age": func(birthDate time.Time) int {
+		now := time.Now()
+		years := now.Year() - birthDate.Year()
+		if now.YearDay() < birthDate.YearDay() {
+			years--
+		}
+		return years
+	},
+	"lines": func(s string) template.HTML {
+		return template.HTML(strings.ReplaceAll(html.EscapeString(s), "\n", "<br>\n"))
+	},
+	"labValue": func(result domain.LabResult) template.HTML {
+		if result.Value != nil {
+			return template.HTML(strconv.FormatFloat(*result.Value, 'f', -1, 64))
+		}
+		return template.HTML(html.EscapeString(result.ValueText))
+	},
+}
+
+func parse(body string) (*template.Template, error) {
+	return template.New("document").Funcs(funcs).Parse(body)
+}
+
+// Check parses body and executes it against sample data, so templates that
+// refer to missing fields are rejected when they are saved rather than when a
+// document is generated.
+func Check(body string) error {
+	tmpl, err := parse(body)
+	if err != nil {
+		return err
+	}
+
+	value, now := 5.4, time.Now()
+	record := domain.DocumentRecord{
+		Symptoms:    "Fever for three days",
+		Medications: "Paracetamol 500 mg",
+		StaffName:   "Sample Nurse",
+		StaffNIP:    "3032000010100001",
+		CreatedAt:   now,
+	}
+	sample := &domain.DocumentData{
+		Kind:      domain.DocumentReferral,
+		Recipient: "Sample Hospital",
+		Notes:     "Sample notes",
+		Patient: domain.Patient{
+			ID:          "3200000000000001",
+			PhoneNumber: "+62800000000",
+			Name:        "Sample Patient",
+			BirthDate:   now.AddDate(-30, 0, 0),
+			Gender:      domain.GenderFemale,
+			CreatedAt:   now,
+		},
+		Record:  record,
+		Records: []domain.DocumentRecord{record},
+		Medications: domain.MedicationOrders{{
+			Medication:    "Paracetamol",
+			Dose:          "500 mg",
+			Route:         "oral",
+			StartAt:       now,
+			EndAt:         now,
+			IntervalHours: 8,
+			Status:        domain.MedicationOrderActive,
+		}},
+		LabResults: domain.LabResults{{
+			TestCode:   "K",
+			TestName:   "Potassium",
+			Unit:       "mmol/L",
+			ResultType: domain.LabResultNumeric,
+			Status:     domain.LabResultResulted,
+			Value:      &value,
+			Flag:       domain.LabFlagHigh,
+			ResultedAt: now,
+		}},
+		Author:      domain.User{Name: "Sample Nurse", NIP: "3032000010100001"},
+		GeneratedAt: now,
+	}
+
+	return tmpl.Execute(io.Discard, sample)
+}
+
+// HTML executes the template in body with data.
+func HTML(body string, data *domain.DocumentData) ([]byte, error) {
+	tmpl, err := parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// PDF lays out an HTML document as plain A4 pages. Headings, paragraphs,
+// lists, tables, line breaks, bold and italic are kept, everything else is
+// written as running text and the head of the document is skipped.
+func PDF(title string, htmlDoc []byte) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	w := &writer{
+		pdf:       pdf,
+		translate: pdf.UnicodeTranslatorFromDescriptor(""),
+		size:      bodySize,
+		lineStart: true,
+		blank:     true,
+	}
+	w.setFont()
+
+	skip := 0
+	z := nethtml.NewTokenizer(bytes.NewReader(htmlDoc))
+	for {
+		switch z.Next() {
+		case nethtml.ErrorToken:
+			if !errors.Is(z.Err(), io.EOF) {
+				return nil, z.Err()
+			}
+
+			var buf bytes.Buffer
+			if err := pdf.Output(&buf); err != nil {
+				return nil, fmt.Errorf("failed to write pdf: %w", err)
+			}
+			return buf.Bytes(), nil
+		case nethtml.TextToken:
+			if skip == 0 {
+				w.text(string(z.Text()))
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "head", "style", "script", "title":
+				skip++
+			default:
+				w.open(string(name))
+			}
+		case nethtml.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "head", "style", "script", "title":
+				skip--
+			default:
+				w.close(string(name))
+			}
+		}
+	}
+}
+
+const bodySize = 10.5
+
+var headingSizes = map[string]float64{
+	"h1": 16,
+	"h2": 13,
+	"h3": 11.5,
+}
+
+type writer struct {
+	pdf       *fpdf.Fpdf
+	translate func(string) string
+	size      float64
+	bold      int
+	italic    int
+	cell      int
+	lineStart bool
+	blank     bool
+	space     bool
+}
+
+func (w *writer) lineHeight() float64 {
+	// points to millimetres with some leading
+	return w.size * 0.5
+}
+
+func (w *writer) setFont() {
+	style := ""
+	if w.bold > 0 {
+		style += "B"
+	}
+	if w.italic > 0 {
+		style += "I"
+	}
+	w.pdf.SetFont("Helvetica", style, w.size)
+}
+
+func (w *writer) newline() {
+	if !w.lineStart {
+		w.pdf.Ln(w.lineHeight())
+		w.lineStart = true
+	}
+	w.space = false
+}
+
+// block ends the current line and leaves a gap, consecutive blocks share one
+// gap.
+func (w *writer) block() {
+	w.newline()
+	if !w.blank {
+		w.pdf.Ln(w.lineHeight() / 2)
+		w.blank = true
+	}
+}
+
+func (w *writer) write(s string) {
+	w.pdf.Write(w.lineHeight(), w.translate(s))
+	w.lineStart = false
+	w.blank = false
+	w.space = false
+}
+
+// text writes s with its runs of whitespace collapsed, a space between two
+// pieces of text is kept unless it falls at the start of a line.
+func (w *writer) text(s string) {
+	collapsed := strings.Join(strings.Fields(s), " ")
+	if collapsed == "" {
+		w.space = w.space || s != ""
+		return
+	}
+
+	if (w.space || unicode.IsSpace(rune(s[0]))) && !w.lineStart {
+		collapsed = " " + collapsed
+	}
+	w.write(collapsed)
+	w.space = unicode.IsSpace(rune(s[len(s)-1]))
+}
+
+func (w *writer) open(tag string) {
+	switch tag {
+	case "h1", "h2", "h3":
+		w.block()
+		w.size = headingSizes[tag]
+		w.bold++
+	case "p", "div", "ul", "ol", "table":
+		w.block()
+	case "br":
+		w.lineStart = false
+		w.newline()
+	case "tr":
+		w.newline()
+		w.cell = 0
+	case "td", "th":
+		if w.cell > 0 {
+			w.write("  |  ")
+		}
+		w.cell++
+		if tag == "th" {
+			w.bold++
+		}
+	case "li":
+		w.newline()
+		w.write("-  ")
+	case "b", "strong":
+		w.bold++
+	case "i", "em":
+		w.italic++
+	}
+	w.setFont()
+}
+
+func (w *writer) close(tag string) {
+	switch tag {
+	case "h1", "h2", "h3":
+		w.size = bodySize
+		w.bold--
+		w.block()
+	case "p", "div", "ul", "ol", "table":
+		w.block()
+	case "tr", "li":
+		w.newline()
+	case "th", "b", "strong":
+		w.bold--
+	case "i", "em":
+		w.italic--
+	}
+
+	if w.bold < 0 {
+		w.bold = 0
+	}
+	if w.italic < 0 {
+		w.italic = 0
+	}
+	w.setFont()
+}
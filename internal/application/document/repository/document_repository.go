@@ -0,0 +1,739 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	templateColumns = `id, kind, name, body, version, updated_by, updated_by_name, created_at, updated_at`
+
+	documentColumns = `d.id, d.template_id, d.template_name, d.template_version, d.kind, d.patient_id, p.name,
+		d.record_id, d.recipient, d.notes, d.html_url, d.pdf_url, d.created_by, d.created_by_name, d.created_at`
+	documentTables = ` FROM documents d JOIN patients p ON p.id = d.patient_id`
+
+	// how much history a document is rendered with
+	documentRecordsLimit    = 5
+	documentLabResultsLimit = 20
+)
+
+type DocumentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDocumentRepository(db *pgxpool.Pool) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+func (r DocumentRepository) CreateTemplate(
+	ctx context.Context,
+	template *domain.DocumentTemplate,
+	user *domain.User,
+) error {
+	callerInfo := "[DocumentRepository.CreateTemplate]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	template.ID = id.New()
+	template.Version = 1
+	template.UpdatedBy = user.ID
+	template.UpdatedByName = user.Name
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+
+	insertQuery := `INSERT INTO document_templates (` + templateColumns + `)
+		VALUES (@id, @kind, @name, @body, @version, @updated_by, @updated_by_name, @created_at, @updated_at)`
+	args := pgx.NamedArgs{
+		"id":              template.ID,
+		"kind":            template.Kind,
+		"name":            template.Name,
+		"body":            template.Body,
+		"version":         template.Version,
+		"updated_by":      template.UpdatedBy,
+		"updated_by_name": template.UpdatedByName,
+		"created_at":      template.CreatedAt,
+		"updated_at":      template.UpdatedAt,
+	}
+
+	if _, err := r.db.Exec(ctx, insertQuery, args); err != nil {
+		l.Error("failed to create document template", zap.Error(err))
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return new(domain.ErrDuplicateDocumentTemplate)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// UpdateTemplate replaces the kind, name and body of a template and bumps its
+// version, documents already generated keep the version they were made with.
+func (r DocumentRepository) UpdateTemplate(
+	ctx context.Context,
+	template *domain.DocumentTemplate,
+	user *domain.User,
+) error {
+	callerInfo := "[DocumentRepository.UpdateTemplate]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	template.UpdatedBy = user.ID
+	template.UpdatedByName = user.Name
+	template.UpdatedAt = time.Now()
+
+	updateQuery := `UPDATE document_templates
+		SET kind = @kind, name = @name, body = @body, version = version + 1,
+			updated_by = @updated_by, updated_by_name = @updated_by_name, updated_at = @updated_at
+		WHERE id = @id
+		RETURNING version, created_at`
+	args := pgx.NamedArgs{
+		"id":              template.ID,
+		"kind":            template.Kind,
+		"name":            template.Name,
+		"body":            template.Body,
+		"updated_by":      template.UpdatedBy,
+		"updated_by_name": template.UpdatedByName,
+		"updated_at":      template.UpdatedAt,
+	}
+
+	err := r.db.QueryRow(ctx, updateQuery, args).Scan(&template.Version, &template.CreatedAt)
+	if err != nil {
+		l.Error("failed to update document template", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrDocumentTemplateNotFound)
+		}
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return new(domain.ErrDuplicateDocumentTemplate)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r DocumentRepository) GetTemplates(
+	ctx context.Context,
+	filter *domain.FilterDocumentTemplate,
+	templates domain.DocumentTemplates,
+) (domain.DocumentTemplates, error) {
+	callerInfo := "[DocumentRepository.GetTemplates]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterTemplate(filter)
+	getQuery := `SELECT ` + templateColumns + ` FROM document_templates` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get document templates", zap.Error(err))
+		return templates, err
+	}
+
+	dTemplate := domain.DocumentTemplateAcquire()
+	defer domain.DocumentTemplateRelease(dTemplate)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dTemplate.ID,
+			&dTemplate.Kind,
+			&dTemplate.Name,
+			&dTemplate.Body,
+			&dTemplate.Version,
+			&dTemplate.UpdatedBy,
+			&dTemplate.UpdatedByName,
+			&dTemplate.CreatedAt,
+			&dTemplate.UpdatedAt,
+		},
+		func() error {
+			templates = append(templates, *dTemplate)
+			dTemplate.UpdatedBy = ulid.ULID{}
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get document templates", zap.Error(err))
+		return templates, err
+	}
+
+	return templates, nil
+}
+
+// GetDocumentData loads everything a template can refer to about the patient,
+// recordID has to be one of the records of the patient.
+func (r DocumentRepository) GetDocumentData(
+	ctx context.Context,
+	patientID string,
+	recordID ulid.ULID,
+	data *domain.DocumentData,
+) error {
+	callerInfo := "[DocumentRepository.GetDocumentData]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	// one snapshot so the record, medications and results agree with each other
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	patient := &data.Patient
+	var isMale bool
+	patientQuery := `SELECT id, phone_number, name, birth_date, is_male, img_url, created_at
+		FROM patients WHERE id = @id`
+	err = tx.QueryRow(ctx, patientQuery, pgx.NamedArgs{"id": patientID}).Scan(
+		&patient.ID,
+		&patient.PhoneNumber,
+		&patient.Name,
+		&patient.BirthDate,
+		&isMale,
+		&patient.ImgURL,
+		&patient.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	patient.Gender = domain.GenderMale
+	if !isMale {
+		patient.Gender = domain.GenderFemale
+	}
+
+	records, err := r.getRecords(ctx, tx, patientID, recordID)
+	if err != nil {
+		l.Error("failed to get medical records", zap.Error(err))
+		return err
+	}
+
+	data.Records = make([]domain.DocumentRecord, 0, len(records))
+	for i := range records {
+		symptoms, medications := records[i].Current()
+		record := domain.DocumentRecord{
+			ID:          records[i].ID,
+			Symptoms:    symptoms,
+			Medications: medications,
+			StaffName:   records[i].StaffName,
+			StaffNIP:    records[i].StaffNIP,
+			CreatedAt:   records[i].CreatedAt,
+		}
+
+		if record.ID == recordID {
+			data.Record = record
+		}
+		data.Records = append(data.Records, record)
+	}
+
+	if id.IsZero(data.Record.ID) {
+		var recordPatientID string
+		recordQuery := `SELECT patient_id FROM medical_records WHERE id = @id`
+		err = tx.QueryRow(ctx, recordQuery, pgx.NamedArgs{"id": recordID}).Scan(&recordPatientID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return new(domain.ErrMedicalRecordNotFound)
+			}
+
+			l.Error("failed to get medical record", zap.Error(err))
+			return err
+		}
+
+		return new(domain.ErrRecordPatientMismatch)
+	}
+
+	// the record the document is about is not part of the recent history
+	// when newer ones have been written since
+	if len(data.Records) > documentRecordsLimit {
+		data.Records = data.Records[:documentRecordsLimit]
+	}
+
+	data.Medications, err = r.getActiveMedications(ctx, tx, patientID)
+	if err != nil {
+		l.Error("failed to get medication orders", zap.Error(err))
+		return err
+	}
+
+	data.LabResults, err = r.getLabResults(ctx, tx, patientID)
+	if err != nil {
+		l.Error("failed to get lab results", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// getRecords returns the latest records of the patient newest first followed
+// by the record in recordID when it is not among them, with their amendments.
+func (r DocumentRepository) getRecords(
+	ctx context.Context,
+	tx pgx.Tx,
+	patientID string,
+	recordID ulid.ULID,
+) (domain.MedicalRecords, error) {
+	getQuery := `(SELECT id, staff_name, staff_nip, symptoms, medications, created_at
+			FROM medical_records WHERE patient_id = @patient_id ORDER BY created_at DESC LIMIT @limit)
+		UNION
+		(SELECT id, staff_name, staff_nip, symptoms, medications, created_at
+			FROM medical_records WHERE patient_id = @patient_id AND id = @record_id)
+		ORDER BY created_at DESC`
+	args := pgx.NamedArgs{
+		"patient_id": patientID,
+		"record_id":  recordID,
+		"limit":      documentRecordsLimit,
+	}
+
+	rows, err := tx.Query(ctx, getQuery, args)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(domain.MedicalRecords, 0, documentRecordsLimit+1)
+	dRecord := domain.MedicalRecordAcquire()
+	defer domain.MedicalRecordRelease(dRecord)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dRecord.ID,
+			&dRecord.StaffName,
+			&dRecord.StaffNIP,
+			&dRecord.Symptoms,
+			&dRecord.Medications,
+			&dRecord.CreatedAt,
+		},
+		func() error {
+			records = append(records, *dRecord)
+			return nil
+		},
+	)
+	if err != nil || len(records) == 0 {
+		return records, err
+	}
+
+	recordIndex := make(map[ulid.ULID]int, len(records))
+	recordIDs := make([][]byte, 0, len(records))
+	for i := range records {
+		recordIndex[records[i].ID] = i
+		recordIDs = append(recordIDs, records[i].ID.Bytes())
+	}
+
+	amendmentQuery := `SELECT record_id, type, symptoms, medications
+		FROM medical_record_amendments WHERE record_id = ANY(@record_ids) ORDER BY created_at ASC`
+
+	rows, err = tx.Query(ctx, amendmentQuery, pgx.NamedArgs{"record_ids": recordIDs})
+	if err != nil {
+		return records, err
+	}
+
+	dAmendment := domain.MedicalRecordAmendmentAcquire()
+	defer domain.MedicalRecordAmendmentRelease(dAmendment)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dAmendment.RecordID,
+			&dAmendment.Type,
+			&dAmendment.Symptoms,
+			&dAmendment.Medications,
+		},
+		func() error {
+			i := recordIndex[dAmendment.RecordID]
+			records[i].Amendments = append(records[i].Amendments, *dAmendment)
+			return nil
+		},
+	)
+
+	return records, err
+}
+
+func (r DocumentRepository) getActiveMedications(
+	ctx context.Context,
+	tx pgx.Tx,
+	patientID string,
+) (domain.MedicationOrders, error) {
+	getQuery := `SELECT id, medication, dose, route, instructions, start_at, end_at, interval_hours, status,
+			ordered_by, ordered_by_name, ordered_at
+		FROM medication_orders
+		WHERE patient_id = @patient_id AND status = @status AND end_at >= @now
+		ORDER BY start_at ASC`
+	args := pgx.NamedArgs{
+		"patient_id": patientID,
+		"status":     domain.MedicationOrderActive,
+		"now":        time.Now(),
+	}
+
+	rows, err := tx.Query(ctx, getQuery, args)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make(domain.MedicationOrders, 0)
+	dOrder := domain.MedicationOrderAcquire()
+	defer domain.MedicationOrderRelease(dOrder)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dOrder.ID,
+			&dOrder.Medication,
+			&dOrder.Dose,
+			&dOrder.Route,
+			&dOrder.Instructions,
+			&dOrder.StartAt,
+			&dOrder.EndAt,
+			&dOrder.IntervalHours,
+			&dOrder.Status,
+			&dOrder.OrderedBy,
+			&dOrder.OrderedByName,
+			&dOrder.OrderedAt,
+		},
+		func() error {
+			dOrder.PatientID = patientID
+			orders = append(orders, *dOrder)
+			return nil
+		},
+	)
+
+	return orders, err
+}
+
+func (r DocumentRepository) getLabResults(ctx context.Context, tx pgx.Tx, patientID string) (domain.LabResults, error) {
+	getQuery := `SELECT id, order_id, test_code, test_name, unit, result_type, status, value_numeric, value_text,
+			flag, reference_low, reference_high, reference_text, resulted_by, resulted_by_name, resulted_at
+		FROM lab_results
+		WHERE patient_id = @patient_id AND status = @status
+		ORDER BY resulted_at DESC LIMIT @limit`
+	args := pgx.NamedArgs{
+		"patient_id": patientID,
+		"status":     domain.LabResultResulted,
+		"limit":      documentLabResultsLimit,
+	}
+
+	rows, err := tx.Query(ctx, getQuery, args)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(domain.LabResults, 0)
+	dResult := domain.LabResultAcquire()
+	defer domain.LabResultRelease(dResult)
+
+	// every column below is only set once the result is recorded
+	var valueText, flag, referenceText, resultedByName *string
+	var resultedAt *time.Time
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dResult.ID,
+			&dResult.OrderID,
+			&dResult.TestCode,
+			&dResult.TestName,
+			&dResult.Unit,
+			&dResult.ResultType,
+			&dResult.Status,
+			&dResult.Value,
+			&valueText,
+			&flag,
+			&dResult.ReferenceLow,
+			&dResult.ReferenceHigh,
+			&referenceText,
+			&dResult.ResultedBy,
+			&resultedByName,
+			&resultedAt,
+		},
+		func() error {
+			result := *dResult
+			result.PatientID = patientID
+			if valueText != nil {
+				result.ValueText = *valueText
+			}
+			if flag != nil {
+				result.Flag = *flag
+			}
+			if referenceText != nil {
+				result.ReferenceText = *referenceText
+			}
+			if resultedByName != nil {
+				result.ResultedByName = *resultedByName
+			}
+			if resultedAt != nil {
+				result.ResultedAt = *resultedAt
+			}
+
+			results = append(results, result)
+			dResult.Value, dResult.ReferenceLow, dResult.ReferenceHigh = nil, nil, nil
+			valueText, flag, referenceText, resultedByName, resultedAt = nil, nil, nil, nil, nil
+			return nil
+		},
+	)
+
+	return results, err
+}
+
+// SaveDocument records a generated document and attaches its files to the
+// record it was generated from.
+func (r DocumentRepository) SaveDocument(ctx context.Context, document *domain.Document) error {
+	callerInfo := "[DocumentRepository.SaveDocument]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	insertQuery := `INSERT INTO documents (
+			id, template_id, template_name, template_version, kind, patient_id, record_id, recipient, notes,
+			html_url, pdf_url, created_by, created_by_name, created_at
+		)
+		VALUES (
+			@id, @template_id, @template_name, @template_version, @kind, @patient_id, @record_id, @recipient, @notes,
+			@html_url, @pdf_url, @created_by, @created_by_name, @created_at
+		)`
+	args := pgx.NamedArgs{
+		"id":               document.ID,
+		"template_id":      document.TemplateID,
+		"template_name":    document.TemplateName,
+		"template_version": document.TemplateVersion,
+		"kind":             document.Kind,
+		"patient_id":       document.PatientID,
+		"record_id":        document.RecordID,
+		"recipient":        document.Recipient,
+		"notes":            document.Notes,
+		"html_url":         document.HTMLURL,
+		"pdf_url":          document.PDFURL,
+		"created_by":       document.CreatedBy,
+		"created_by_name":  document.CreatedByName,
+		"created_at":       document.CreatedAt,
+	}
+
+	if _, err = tx.Exec(ctx, insertQuery, args); err != nil {
+		l.Error("failed to save document", zap.Error(err))
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return new(domain.ErrDocumentTemplateNotFound)
+		}
+
+		return err
+	}
+
+	description := "Generated from " + document.TemplateName + " version " + strconv.Itoa(document.TemplateVersion)
+	fileName := document.TemplateName + " " + document.CreatedAt.Format(time.DateOnly)
+	attachments := [][]any{
+		{
+			id.New().Bytes(), document.RecordID.Bytes(), document.HTMLURL, fileName + ".html", "text/html",
+			description, document.CreatedBy.Bytes(), document.CreatedAt,
+		},
+		{
+			id.New().Bytes(), document.RecordID.Bytes(), document.PDFURL, fileName + ".pdf", "application/pdf",
+			description, document.CreatedBy.Bytes(), document.CreatedAt,
+		},
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"medical_record_attachments"},
+		[]string{"id", "record_id", "url", "name", "content_type", "description", "staff_id", "created_at"},
+		pgx.CopyFromRows(attachments),
+	)
+	if err != nil {
+		l.Error("failed to attach document to medical record", zap.Error(err))
+		return err
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+
+	user.ID = document.CreatedBy
+	user.Name = document.CreatedByName
+
+	event, err := patientevent.New(document.PatientID, domain.PatientEventDocumentGenerated, user, map[string]any{
+		"documentId":   document.ID,
+		"kind":         document.Kind,
+		"templateName": document.TemplateName,
+		"recordId":     document.RecordID,
+	})
+	if err != nil {
+		l.Error("failed to build patient event", zap.Error(err))
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	if err = patientevent.Insert(ctx, tx, event); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r DocumentRepository) GetDocuments(
+	ctx context.Context,
+	filter *domain.FilterDocument,
+	documents domain.Documents,
+) (domain.Documents, error) {
+	callerInfo := "[DocumentRepository.GetDocuments]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterDocument(filter)
+	getQuery := `SELECT ` + documentColumns + documentTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get documents", zap.Error(err))
+		return documents, err
+	}
+
+	dDocument := domain.DocumentAcquire()
+	defer domain.DocumentRelease(dDocument)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dDocument.ID,
+			&dDocument.TemplateID,
+			&dDocument.TemplateName,
+			&dDocument.TemplateVersion,
+			&dDocument.Kind,
+			&dDocument.PatientID,
+			&dDocument.PatientName,
+			&dDocument.RecordID,
+			&dDocument.Recipient,
+			&dDocument.Notes,
+			&dDocument.HTMLURL,
+			&dDocument.PDFURL,
+			&dDocument.CreatedBy,
+			&dDocument.CreatedByName,
+			&dDocument.CreatedAt,
+		},
+		func() error {
+			documents = append(documents, *dDocument)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get documents", zap.Error(err))
+		return documents, err
+	}
+
+	return documents, nil
+}
+
+func (r DocumentRepository) filterTemplate(filter *domain.FilterDocumentTemplate) (string, pgx.NamedArgs) {
+	const totalConditions = 2
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.Kind != "" {
+		conditions = append(conditions, "kind = @kind")
+		params["kind"] = filter.Kind
+	}
+
+	order := " ORDER BY kind ASC, name ASC"
+
+	return r.withPaging(conditions, order, params, filter.Limit, filter.Offset)
+}
+
+func (r DocumentRepository) filterDocument(filter *domain.FilterDocument) (string, pgx.NamedArgs) {
+	const totalConditions = 4
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "d.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "d.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.RecordID) {
+		conditions = append(conditions, "d.record_id = @record_id")
+		params["record_id"] = filter.RecordID
+	}
+
+	if filter.Kind != "" {
+		conditions = append(conditions, "d.kind = @kind")
+		params["kind"] = filter.Kind
+	}
+
+	order := " ORDER BY d.created_at DESC"
+
+	return r.withPaging(conditions, order, params, filter.Limit, filter.Offset)
+}
+
+func (r DocumentRepository) withPaging(
+	conditions []string,
+	order string,
+	params pgx.NamedArgs,
+	limit, offset int,
+) (string, pgx.NamedArgs) {
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if limit != 0 {
+		params["limit"] = limit
+	}
+
+	if offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ DocumentRepositoryContract = (*DocumentRepository)(nil)
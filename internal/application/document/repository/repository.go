@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"io"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type DocumentRepositoryContract interface {
+	CreateTemplate(ctx context.Context, template *domain.DocumentTemplate, user *domain.User) error
+	UpdateTemplate(ctx context.Context, template *domain.DocumentTemplate, user *domain.User) error
+	GetTemplates(
+		ctx context.Context,
+		filter *domain.FilterDocumentTemplate,
+		templates domain.DocumentTemplates,
+	) (domain.DocumentTemplates, error)
+	GetDocumentData(ctx context.Context, patientID string, recordID ulid.ULID, data *domain.DocumentData) error
+	SaveDocument(ctx context.Context, document *domain.Document) error
+	GetDocuments(
+		ctx context.Context,
+		filter *domain.FilterDocument,
+		documents domain.Documents,
+	) (domain.Documents, error)
+}
+
+type StorageRepositoryContract interface {
+	Upload(ctx context.Context, key, contentType string, body []byte) (string, error)
+	Download(ctx context.Context, key string) (io.ReadCloser, int64, error)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/logger"
+)
+
+type StorageRepository struct {
+	client   *s3.Client
+	uploader *manager.Uploader
+}
+
+func NewStorageRepository(client *s3.Client) *StorageRepository {
+	return &StorageRepository{
+		client:   client,
+		uploader: manager.NewUploader(client),
+	}
+}
+
+func (r StorageRepository) Upload(ctx context.Context, key, contentType string, body []byte) (string, error) {
+	callerInfo := "[StorageRepository.Upload]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	params := &s3.PutObjectInput{
+		Bucket:      aws.String(configs.Get().S3.BucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	}
+
+	result, err := r.uploader.Upload(ctx, params)
+	if err != nil {
+		l.Error("error uploading document", zap.Error(err))
+		return "", err
+	}
+
+	return result.Location, nil
+}
+
+// Download opens the object under key, the caller closes the body.
+func (r StorageRepository) Download(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	callerInfo := "[StorageRepository.Download]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(configs.Get().S3.BucketName),
+		Key:    aws.String(key),
+	}
+
+	result, err := r.client.GetObject(ctx, params)
+	if err != nil {
+		l.Error("error downloading document", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return result.Body, aws.ToInt64(result.ContentLength), nil
+}
+
+var _ StorageRepositoryContract = (*StorageRepository)(nil)
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/document/render"
+	"github.com/j03hanafi/halo-suster/internal/application/document/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type DocumentService struct {
+	documentRepository repository.DocumentRepositoryContract
+	storageRepository  repository.StorageRepositoryContract
+	contextTimeout     time.Duration
+}
+
+func NewDocumentService(
+	timeout time.Duration,
+	documentRepository repository.DocumentRepositoryContract,
+	storageRepository repository.StorageRepositoryContract,
+) *DocumentService {
+	return &DocumentService{
+		documentRepository: documentRepository,
+		storageRepository:  storageRepository,
+		contextTimeout:     timeout,
+	}
+}
+
+func (s DocumentService) CreateTemplate(
+	ctx context.Context,
+	template *domain.DocumentTemplate,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[DocumentService.CreateTemplate]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.documentRepository.CreateTemplate(ctx, template, user)
+	if err != nil {
+		l.Error("failed to create document template", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s DocumentService) UpdateTemplate(
+	ctx context.Context,
+	template *domain.DocumentTemplate,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[DocumentService.UpdateTemplate]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.documentRepository.UpdateTemplate(ctx, template, user)
+	if err != nil {
+		l.Error("failed to update document template", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s DocumentService) GetTemplates(
+	ctx context.Context,
+	filter *domain.FilterDocumentTemplate,
+	templates domain.DocumentTemplates,
+) (domain.DocumentTemplates, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[DocumentService.GetTemplates]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	templates, err := s.documentRepository.GetTemplates(ctx, filter, templates)
+	if err != nil {
+		l.Error("failed to get document templates", zap.Error(err))
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// GenerateDocument renders the template in document.TemplateID for the record
+// in document.RecordID, uploads the HTML and PDF files and saves the document.
+// Files of a document that fails to save are left in the bucket.
+func (s DocumentService) GenerateDocument(ctx context.Context, document *domain.Document, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[DocumentService.GenerateDocument]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterDocumentTemplateAcquire()
+	defer domain.FilterDocumentTemplateRelease(filter)
+
+	filter.ID = document.TemplateID
+
+	templates := domain.DocumentTemplatesAcquire()
+	defer domain.DocumentTemplatesRelease(templates)
+
+	templates, err := s.documentRepository.GetTemplates(ctx, filter, templates)
+	if err != nil {
+		l.Error("failed to get document template", zap.Error(err))
+		return err
+	}
+
+	if len(templates) == 0 {
+		return new(domain.ErrDocumentTemplateNotFound)
+	}
+	template := &templates[0]
+
+	data := domain.DocumentDataAcquire()
+	defer domain.DocumentDataRelease(data)
+
+	err = s.documentRepository.GetDocumentData(ctx, document.PatientID, document.RecordID, data)
+	if err != nil {
+		l.Error("failed to get document data", zap.Error(err))
+		return err
+	}
+
+	document.ID = id.New()
+	document.TemplateName = template.Name
+	document.TemplateVersion = template.Version
+	document.Kind = template.Kind
+	document.PatientName = data.Patient.Name
+	document.CreatedBy = user.ID
+	document.CreatedByName = user.Name
+	document.CreatedAt = time.Now()
+
+	data.Kind = template.Kind
+	data.Recipient = document.Recipient
+	data.Notes = document.Notes
+	data.Author = *user
+	data.GeneratedAt = document.CreatedAt
+
+	htmlDoc, err := render.HTML(template.Body, data)
+	if err != nil {
+		l.Error("failed to render document", zap.Error(err))
+		return new(domain.ErrDocumentRender)
+	}
+
+	pdfDoc, err := render.PDF(template.Name, htmlDoc)
+	if err != nil {
+		l.Error("failed to render document pdf", zap.Error(err))
+		return new(domain.ErrDocumentRender)
+	}
+
+	document.HTMLURL, err = s.storageRepository.Upload(
+		ctx,
+		documentKey(document, domain.DocumentFormatHTML),
+		"text/html; charset=utf-8",
+		htmlDoc,
+	)
+	if err != nil {
+		l.Error("failed to upload document html", zap.Error(err))
+		return err
+	}
+
+	document.PDFURL, err = s.storageRepository.Upload(
+		ctx,
+		documentKey(document, domain.DocumentFormatPDF),
+		"application/pdf",
+		pdfDoc,
+	)
+	if err != nil {
+		l.Error("failed to upload document pdf", zap.Error(err))
+		return err
+	}
+
+	err = s.documentRepository.SaveDocument(ctx, document)
+	if err != nil {
+		l.Error("failed to save document", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s DocumentService) GetDocuments(
+	ctx context.Context,
+	filter *domain.FilterDocument,
+	documents domain.Documents,
+) (domain.Documents, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[DocumentService.GetDocuments]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	documents, err := s.documentRepository.GetDocuments(ctx, filter, documents)
+	if err != nil {
+		l.Error("failed to get documents", zap.Error(err))
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// OpenDocument fills document from its ID and opens its file in format, the
+// caller closes the file.
+func (s DocumentService) OpenDocument(
+	ctx context.Context,
+	document *domain.Document,
+	format string,
+) (io.ReadCloser, int64, error) {
+	callerInfo := "[DocumentService.OpenDocument]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterDocumentAcquire()
+	defer domain.FilterDocumentRelease(filter)
+
+	filter.ID = document.ID
+
+	documents := domain.DocumentsAcquire()
+	defer domain.DocumentsRelease(documents)
+
+	getCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	documents, err := s.documentRepository.GetDocuments(getCtx, filter, documents)
+	if err != nil {
+		l.Error("failed to get document", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if len(documents) == 0 {
+		return nil, 0, new(domain.ErrDocumentNotFound)
+	}
+	*document = documents[0]
+
+	// the body is streamed after this returns, so the request context
+	// bounds the download rather than the service timeout
+	body, size, err := s.storageRepository.Download(ctx, documentKey(document, format))
+	if err != nil {
+		l.Error("failed to download document", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return body, size, nil
+}
+
+func documentKey(document *domain.Document, format string) string {
+	return configs.Get().App.Name + "_document_" + document.ID.String() + "." + format
+}
+
+var _ DocumentServiceContract = (*DocumentService)(nil)
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type DocumentServiceContract interface {
+	CreateTemplate(ctx context.Context, template *domain.DocumentTemplate, user *domain.User) error
+	UpdateTemplate(ctx context.Context, template *domain.DocumentTemplate, user *domain.User) error
+	GetTemplates(
+		ctx context.Context,
+		filter *domain.FilterDocumentTemplate,
+		templates domain.DocumentTemplates,
+	) (domain.DocumentTemplates, error)
+	GenerateDocument(ctx context.Context, document *domain.Document, user *domain.User) error
+	GetDocuments(
+		ctx context.Context,
+		filter *domain.FilterDocument,
+		documents domain.Documents,
+	) (domain.Documents, error)
+	OpenDocument(ctx context.Context, document *domain.Document, format string) (io.ReadCloser, int64, error)
+}
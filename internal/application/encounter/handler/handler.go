@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/encounter/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const encounterIDFromParam = "id"
+
+type encounterHandler struct {
+	encounterService service.EncounterServiceContract
+}
+
+func NewEncounterHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	encounterService service.EncounterServiceContract,
+) {
+	handler := encounterHandler{
+		encounterService: encounterService,
+	}
+
+	encounterRouter := router.Group("/encounter", jwtMiddleware)
+	encounterRouter.Post("", handler.OpenEncounter)
+	encounterRouter.Get("", handler.GetEncounters)
+	encounterRouter.Post("/:"+encounterIDFromParam+"/close", handler.CloseEncounter)
+}
+
+func (h encounterHandler) OpenEncounter(c *fiber.Ctx) error {
+	callerInfo := "[encounterHandler.OpenEncounter]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := openEncounterReqAcquire()
+	defer openEncounterReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	encounter := domain.EncounterAcquire()
+	defer domain.EncounterRelease(encounter)
+
+	encounter.PatientID = string(*req.IdentityNumber)
+	encounter.Type = req.Type
+	encounter.Location = req.Location
+	encounter.AttendingStaffID = req.attendingStaffID
+
+	err := h.encounterService.OpenEncounter(userCtx, encounter, user)
+	if err != nil {
+		l.Error("failed to open encounter", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Encounter opened successfully"
+	res.Data = newEncounterRes(encounter)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h encounterHandler) CloseEncounter(c *fiber.Ctx) error {
+	callerInfo := "[encounterHandler.CloseEncounter]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	encounterID, err := ulid.Parse(c.Params(encounterIDFromParam))
+	if err != nil {
+		l.Error("error parsing encounterIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	encounter := domain.EncounterAcquire()
+	defer domain.EncounterRelease(encounter)
+
+	encounter.ID = encounterID
+
+	err = h.encounterService.CloseEncounter(userCtx, encounter, user)
+	if err != nil {
+		l.Error("failed to close encounter", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Encounter closed successfully"
+	res.Data = newEncounterRes(encounter)
+
+	return c.JSON(res)
+}
+
+func (h encounterHandler) GetEncounters(c *fiber.Ctx) error {
+	callerInfo := "[encounterHandler.GetEncounters]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryEncounterAcquire()
+	defer queryEncounterRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterEncounterAcquire()
+	defer domain.FilterEncounterRelease(filter)
+
+	filter.ID = query.encounterID
+	filter.PatientID = query.patientID
+	filter.AttendingStaffID = query.attendingStaffID
+	filter.Type = query.Type
+	filter.Status = query.Status
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+	filter.OpenedAt = query.OpenedAt
+
+	encounters := domain.EncountersAcquire()
+	defer domain.EncountersRelease(encounters)
+
+	encounters, err := h.encounterService.GetEncounters(userCtx, filter, encounters)
+	if err != nil {
+		l.Error("failed to get encounters", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Encounters retrieved successfully"
+
+	encountersRes := getEncountersResAcquire()
+	defer getEncountersResRelease(encountersRes)
+
+	for i := range encounters {
+		encountersRes = append(encountersRes, newEncounterRes(&encounters[i]))
+	}
+
+	res.Data = encountersRes
+
+	return c.JSON(res)
+}
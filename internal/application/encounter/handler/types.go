@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+var openEncounterReqPool = sync.Pool{
+	New: func() any {
+		return new(openEncounterReq)
+	},
+}
+
+func openEncounterReqAcquire() *openEncounterReq {
+	return openEncounterReqPool.Get().(*openEncounterReq)
+}
+
+func openEncounterReqRelease(t *openEncounterReq) {
+	*t = openEncounterReq{}
+	openEncounterReqPool.Put(t)
+}
+
+type openEncounterReq struct {
+	IdentityNumber   *idNumber `json:"identityNumber"`
+	Type             string    `json:"type"`
+	Location         string    `json:"location"`
+	AttendingStaffID string    `json:"attendingStaffId"`
+	attendingStaffID ulid.ULID
+}
+
+func (r *openEncounterReq) validate() error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	if r.Type == "" {
+		errs = multierr.Append(errs, errors.New("type is required"))
+	} else if !validEncounterType(r.Type) {
+		errs = multierr.Append(errs, errors.New("type must be one of outpatient, inpatient or emergency"))
+	}
+
+	if r.Location == "" {
+		errs = multierr.Append(errs, errors.New("location is required"))
+	} else if len(r.Location) > 100 {
+		errs = multierr.Append(errs, errors.New("location must have 1 to 100 characters"))
+	}
+
+	if r.AttendingStaffID != "" {
+		staffID, err := ulid.Parse(r.AttendingStaffID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("attendingStaffId must be a valid user id"))
+		}
+		r.attendingStaffID = staffID
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+func validEncounterType(t string) bool {
+	return t == domain.EncounterOutpatient || t == domain.EncounterInpatient || t == domain.EncounterEmergency
+}
+
+var queryEncounterPool = sync.Pool{
+	New: func() any {
+		return new(queryEncounter)
+	},
+}
+
+func queryEncounterAcquire() *queryEncounter {
+	return queryEncounterPool.Get().(*queryEncounter)
+}
+
+func queryEncounterRelease(t *queryEncounter) {
+	*t = queryEncounter{}
+	queryEncounterPool.Put(t)
+}
+
+type queryEncounter struct {
+	EncounterID      string `query:"encounterId"`
+	encounterID      ulid.ULID
+	IdentityNumber   int `query:"identityNumber"`
+	patientID        string
+	AttendingStaffID string `query:"attendingStaffId"`
+	attendingStaffID ulid.ULID
+	Type             string `query:"type"`
+	Status           string `query:"status"`
+	Limit            int    `query:"limit"`
+	Offset           int    `query:"offset"`
+	OpenedAt         string `query:"openedAt"`
+}
+
+func (r *queryEncounter) validate() {
+	if r.EncounterID != "" {
+		r.encounterID, _ = ulid.Parse(r.EncounterID)
+	}
+
+	if r.IdentityNumber != 0 {
+		r.patientID = strconv.Itoa(r.IdentityNumber)
+	}
+
+	if r.AttendingStaffID != "" {
+		r.attendingStaffID, _ = ulid.Parse(r.AttendingStaffID)
+	}
+
+	if r.Type != "" && !validEncounterType(r.Type) {
+		r.Type = ""
+	}
+
+	if r.Status != "" && r.Status != domain.EncounterOpen && r.Status != domain.EncounterClosed {
+		r.Status = ""
+	}
+
+	if r.OpenedAt != "" && r.OpenedAt != "asc" && r.OpenedAt != "desc" {
+		r.OpenedAt = ""
+	}
+}
+
+type attendingStaff struct {
+	UserID ulid.ULID `json:"userId"`
+	NIP    uint      `json:"nip"`
+	Name   string    `json:"name"`
+}
+
+type encounterRes struct {
+	EncounterID    ulid.ULID      `json:"encounterId"`
+	IdentityNumber idNumber       `json:"identityNumber"`
+	Type           string         `json:"type"`
+	Status         string         `json:"status"`
+	Location       string         `json:"location"`
+	AttendingStaff attendingStaff `json:"attendingStaff"`
+	OpenedAt       string         `json:"openedAt"`
+	ClosedAt       string         `json:"closedAt,omitempty"`
+}
+
+func newEncounterRes(encounter *domain.Encounter) encounterRes {
+	nip, _ := strconv.Atoi(encounter.AttendingStaffNIP)
+
+	res := encounterRes{
+		EncounterID:    encounter.ID,
+		IdentityNumber: idNumber(encounter.PatientID),
+		Type:           encounter.Type,
+		Status:         encounter.Status,
+		Location:       encounter.Location,
+		AttendingStaff: attendingStaff{
+			UserID: encounter.AttendingStaffID,
+			NIP:    uint(nip),
+			Name:   encounter.AttendingStaffName,
+		},
+		OpenedAt: encounter.OpenedAt.Format(dateFormat),
+	}
+	if !encounter.ClosedAt.IsZero() {
+		res.ClosedAt = encounter.ClosedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+const encountersInitCap = 5
+
+var getEncountersResPool = sync.Pool{
+	New: func() any {
+		return make(getEncountersRes, 0, encountersInitCap)
+	},
+}
+
+func getEncountersResAcquire() getEncountersRes {
+	return getEncountersResPool.Get().(getEncountersRes)
+}
+
+func getEncountersResRelease(t getEncountersRes) {
+	t = t[:0]
+	getEncountersResPool.Put(t) // nolint:staticcheck
+}
+
+type getEncountersRes []encounterRes
@@ -0,0 +1,21 @@
+package encounter
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/encounter/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/encounter/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/encounter/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	encounterRepository := repository.NewEncounterRepository(db)
+	encounterService := service.NewEncounterService(ctxTimeout, encounterRepository)
+	handler.NewEncounterHandler(router, jwtMiddleware, encounterService)
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type EncounterRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEncounterRepository(db *pgxpool.Pool) *EncounterRepository {
+	return &EncounterRepository{db: db}
+}
+
+func (r EncounterRepository) OpenEncounter(ctx context.Context, encounter *domain.Encounter, user *domain.User) error {
+	callerInfo := "[EncounterRepository.OpenEncounter]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	encounter.ID = id.New()
+	encounter.Status = domain.EncounterOpen
+	encounter.OpenedBy = user.ID
+	encounter.OpenedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	insertQuery := `INSERT INTO encounters (
+		id, patient_id, type, status, location, attending_staff_id, attending_staff_nip, attending_staff_name, 
+			opened_by, opened_at
+			)
+		SELECT @id, @patient_id, @type, @status, @location, u.id, u.nip, u.name, @opened_by, @opened_at
+		FROM users u WHERE u.id = @attending_staff_id
+		RETURNING attending_staff_nip, attending_staff_name`
+	args := pgx.NamedArgs{
+		"id":                 encounter.ID,
+		"patient_id":         encounter.PatientID,
+		"type":               encounter.Type,
+		"status":             encounter.Status,
+		"location":           encounter.Location,
+		"attending_staff_id": encounter.AttendingStaffID,
+		"opened_by":          encounter.OpenedBy,
+		"opened_at":          encounter.OpenedAt,
+	}
+
+	err = tx.QueryRow(ctx, insertQuery, args).Scan(&encounter.AttendingStaffNIP, &encounter.AttendingStaffName)
+	if err != nil {
+		l.Error("failed to open encounter", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrUserNotFound)
+		}
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return new(domain.ErrEncounterAlreadyOpen)
+			case pgerrcode.ForeignKeyViolation:
+				return new(domain.ErrPatientNotFound)
+			}
+		}
+
+		return err
+	}
+
+	if err = r.insertEvent(ctx, tx, encounter, domain.PatientEventEncounterOpened, user); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r EncounterRepository) CloseEncounter(ctx context.Context, encounter *domain.Encounter, user *domain.User) error {
+	callerInfo := "[EncounterRepository.CloseEncounter]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	encounter.Status = domain.EncounterClosed
+	encounter.ClosedBy = user.ID
+	encounter.ClosedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var status string
+	selectQuery := `SELECT patient_id, type, status, location, attending_staff_id, attending_staff_nip, 
+		attending_staff_name, opened_by, opened_at FROM encounters WHERE id = @id FOR UPDATE`
+	err = tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": encounter.ID}).Scan(
+		&encounter.PatientID,
+		&encounter.Type,
+		&status,
+		&encounter.Location,
+		&encounter.AttendingStaffID,
+		&encounter.AttendingStaffNIP,
+		&encounter.AttendingStaffName,
+		&encounter.OpenedBy,
+		&encounter.OpenedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrEncounterNotFound)
+		}
+
+		l.Error("failed to get encounter", zap.Error(err))
+		return err
+	}
+
+	if status != domain.EncounterOpen {
+		return new(domain.ErrEncounterClosed)
+	}
+
+	updateQuery := `UPDATE encounters SET status = @status, closed_by = @closed_by, closed_at = @closed_at 
+		WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":        encounter.ID,
+		"status":    encounter.Status,
+		"closed_by": encounter.ClosedBy,
+		"closed_at": encounter.ClosedAt,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to close encounter", zap.Error(err))
+		return err
+	}
+
+	if err = r.insertEvent(ctx, tx, encounter, domain.PatientEventEncounterClosed, user); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r EncounterRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	encounter *domain.Encounter,
+	eventType string,
+	user *domain.User,
+) error {
+	event, err := patientevent.New(encounter.PatientID, eventType, user, map[string]string{
+		"encounterId":        encounter.ID.String(),
+		"type":               encounter.Type,
+		"location":           encounter.Location,
+		"attendingStaffName": encounter.AttendingStaffName,
+	})
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+func (r EncounterRepository) GetEncounters(
+	ctx context.Context,
+	filter *domain.FilterEncounter,
+	encounters domain.Encounters,
+) (domain.Encounters, error) {
+	callerInfo := "[EncounterRepository.GetEncounters]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterEncounter(filter)
+	getQuery := `SELECT id, patient_id, type, status, location, attending_staff_id, attending_staff_nip, 
+		attending_staff_name, opened_by, opened_at, closed_by, closed_at FROM encounters` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get encounters", zap.Error(err))
+		return encounters, err
+	}
+
+	dEncounter := domain.EncounterAcquire()
+	defer domain.EncounterRelease(dEncounter)
+	var closedAt *time.Time
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dEncounter.ID,
+			&dEncounter.PatientID,
+			&dEncounter.Type,
+			&dEncounter.Status,
+			&dEncounter.Location,
+			&dEncounter.AttendingStaffID,
+			&dEncounter.AttendingStaffNIP,
+			&dEncounter.AttendingStaffName,
+			&dEncounter.OpenedBy,
+			&dEncounter.OpenedAt,
+			&dEncounter.ClosedBy,
+			&closedAt,
+		},
+		func() error {
+			dEncounter.ClosedAt = time.Time{}
+			if closedAt != nil {
+				dEncounter.ClosedAt = *closedAt
+			}
+			encounters = append(encounters, *dEncounter)
+			dEncounter.ClosedBy = ulid.ULID{}
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get encounters", zap.Error(err))
+		return encounters, err
+	}
+
+	return encounters, nil
+}
+
+func (r EncounterRepository) filterEncounter(filter *domain.FilterEncounter) (string, pgx.NamedArgs) {
+	const totalConditions = 5
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.AttendingStaffID) {
+		conditions = append(conditions, "attending_staff_id = @attending_staff_id")
+		params["attending_staff_id"] = filter.AttendingStaffID
+	}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "type = @type")
+		params["type"] = filter.Type
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = @status")
+		params["status"] = filter.Status
+	}
+
+	order := " ORDER BY opened_at DESC"
+	if filter.OpenedAt != "" && (filter.OpenedAt == "asc" || filter.OpenedAt == "desc") {
+		order = " ORDER BY opened_at " + filter.OpenedAt
+	}
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ EncounterRepositoryContract = (*EncounterRepository)(nil)
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/encounter/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type EncounterService struct {
+	encounterRepository repository.EncounterRepositoryContract
+	contextTimeout      time.Duration
+}
+
+func NewEncounterService(
+	timeout time.Duration,
+	encounterRepository repository.EncounterRepositoryContract,
+) *EncounterService {
+	return &EncounterService{
+		encounterRepository: encounterRepository,
+		contextTimeout:      timeout,
+	}
+}
+
+func (s EncounterService) OpenEncounter(ctx context.Context, encounter *domain.Encounter, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[EncounterService.OpenEncounter]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	// the staff opening the encounter attends it unless told otherwise
+	if id.IsZero(encounter.AttendingStaffID) {
+		encounter.AttendingStaffID = user.ID
+	}
+
+	err := s.encounterRepository.OpenEncounter(ctx, encounter, user)
+	if err != nil {
+		l.Error("failed to open encounter", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s EncounterService) CloseEncounter(ctx context.Context, encounter *domain.Encounter, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[EncounterService.CloseEncounter]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.encounterRepository.CloseEncounter(ctx, encounter, user)
+	if err != nil {
+		l.Error("failed to close encounter", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s EncounterService) GetEncounters(
+	ctx context.Context,
+	filter *domain.FilterEncounter,
+	encounters domain.Encounters,
+) (domain.Encounters, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[EncounterService.GetEncounters]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	encounters, err := s.encounterRepository.GetEncounters(ctx, filter, encounters)
+	if err != nil {
+		l.Error("failed to get encounters", zap.Error(err))
+		return nil, err
+	}
+
+	return encounters, nil
+}
+
+var _ EncounterServiceContract = (*EncounterService)(nil)
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type EncounterServiceContract interface {
+	OpenEncounter(ctx context.Context, encounter *domain.Encounter, user *domain.User) error
+	CloseEncounter(ctx context.Context, encounter *domain.Encounter, user *domain.User) error
+	GetEncounters(
+		ctx context.Context,
+		filter *domain.FilterEncounter,
+		encounters domain.Encounters,
+	) (domain.Encounters, error)
+}
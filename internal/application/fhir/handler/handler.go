@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	fhirPath            = "/fhir/R4"
+	resourceIDFromParam = "id"
+)
+
+type fhirHandler struct {
+	fhirService service.FHIRServiceContract
+}
+
+func NewFHIRHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	fhirService service.FHIRServiceContract,
+) {
+	handler := fhirHandler{
+		fhirService: fhirService,
+	}
+
+	fhirRouter := router.Group(fhirPath, operationOutcome)
+	fhirRouter.Get("/metadata", handler.Metadata)
+	fhirRouter.Get("/"+resource.TypePatient, jwtMiddleware, handler.SearchPatients)
+	fhirRouter.Get("/"+resource.TypePatient+"/:"+resourceIDFromParam, jwtMiddleware, handler.ReadPatient)
+	fhirRouter.Get(
+		"/"+resource.TypePatient+"/:"+resourceIDFromParam+"/export",
+		jwtMiddleware,
+		handler.ExportPatient,
+	)
+
+	for resourceType, newResource := range recordResources {
+		fhirRouter.Get("/"+resourceType, jwtMiddleware, handler.SearchRecords(resourceType, newResource))
+		fhirRouter.Get(
+			"/"+resourceType+"/:"+resourceIDFromParam,
+			jwtMiddleware,
+			handler.ReadRecord(resourceType, newResource),
+		)
+	}
+}
+
+// recordResources are the resource types mapped from medical records.
+var recordResources = map[string]func(record *domain.MedicalRecord) any{
+	resource.TypeCondition: func(record *domain.MedicalRecord) any {
+		return resource.NewCondition(record)
+	},
+	resource.TypeObservation: func(record *domain.MedicalRecord) any {
+		return resource.NewObservation(record)
+	},
+	resource.TypeMedicationStatement: func(record *domain.MedicalRecord) any {
+		return resource.NewMedicationStatement(record)
+	},
+}
+
+// baseURL is the absolute URL the FHIR API is served at, fullUrl and the
+// bundle links are built from it.
+func baseURL(c *fiber.Ctx) string {
+	return c.BaseURL() + configs.Get().API.BaseURL + fhirPath
+}
+
+func sendResource(c *fiber.Ctx, status int, body any) error {
+	return c.Status(status).JSON(body, resource.ContentType)
+}
+
+// Metadata returns the CapabilityStatement, it is served without a token so
+// clients can learn how to authenticate.
+func (h fhirHandler) Metadata(c *fiber.Ctx) error {
+	return sendResource(c, http.StatusOK, resource.NewCapabilityStatement(baseURL(c)))
+}
+
+func (h fhirHandler) SearchPatients(c *fiber.Ctx) error {
+	callerInfo := "[fhirHandler.SearchPatients]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryPatientAcquire()
+	defer queryPatientRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := query.validate(); err != nil {
+		l.Error("error validating query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	patients := domain.PatientsAcquire()
+	defer domain.PatientsRelease(patients)
+
+	total := 0
+	if !query.noMatch {
+		filter := domain.FilterPatientAcquire()
+		defer domain.FilterPatientRelease(filter)
+
+		filter.ID = query.patientID
+		filter.Name = query.Name
+		filter.Gender = query.Gender
+		filter.BirthDateFrom = query.birthDateFrom
+		filter.BirthDateTo = query.birthDateTo
+		filter.Limit = query.Count
+		filter.Offset = query.Offset
+
+		var err error
+		patients, total, err = h.fhirService.GetPatients(userCtx, filter, patients)
+		if err != nil {
+			l.Error("failed to get patients", zap.Error(err))
+			return err
+		}
+	}
+
+	searchURL := baseURL(c) + "/" + resource.TypePatient
+	bundle := resource.NewSearchset(total)
+	query.addLinks(bundle, searchURL, query.params(), total)
+
+	for i := range patients {
+		bundle.AddMatch(searchURL+"/"+patients[i].ID, resource.NewPatient(&patients[i]))
+	}
+
+	return sendResource(c, http.StatusOK, bundle)
+}
+
+func (h fhirHandler) ReadPatient(c *fiber.Ctx) error {
+	callerInfo := "[fhirHandler.ReadPatient]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	patientID := c.Params(resourceIDFromParam)
+	if !isIdentityNumber(patientID) {
+		return new(domain.ErrPatientNotFound)
+	}
+
+	patient := domain.PatientAcquire()
+	defer domain.PatientRelease(patient)
+
+	patient.ID = patientID
+
+	err := h.fhirService.GetPatient(userCtx, patient)
+	if err != nil {
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	return sendResource(c, http.StatusOK, resource.NewPatient(patient))
+}
+
+// ExportPatient streams a bundle of the patient and all of their medical
+// records for a transfer, as a collection or as a document that opens with a
+// Composition listing them.
+func (h fhirHandler) ExportPatient(c *fiber.Ctx) error {
+	callerInfo := "[fhirHandler.ExportPatient]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	patientID := c.Params(resourceIDFromParam)
+	if !isIdentityNumber(patientID) {
+		return new(domain.ErrPatientNotFound)
+	}
+
+	query := queryExportAcquire()
+	defer queryExportRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := query.validate(); err != nil {
+		l.Error("error validating query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	export := &service.Export{
+		Type:    query.Type,
+		Format:  query.Format,
+		BaseURL: baseURL(c),
+		User:    c.Locals(domain.UserFromToken).(domain.User),
+	}
+	export.Patient.ID = patientID
+
+	// a missing patient is still reported before the response starts
+	err := h.fhirService.GetPatient(userCtx, &export.Patient)
+	if err != nil {
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	contentType, extension := resource.ContentType, resource.FormatJSON
+	if export.Format == resource.FormatNDJSON {
+		contentType, extension = resource.ContentTypeNDJSON, resource.FormatNDJSON
+	}
+
+	// the status has been sent by the time an export fails, a JSON bundle
+	// that cannot be closed is cut off with the connection instead, so it is
+	// never taken for a whole one
+	conn := c.Context().Conn()
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment("patient-" + patientID + "-" + export.Type + "." + extension)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := h.fhirService.ExportPatient(userCtx, export, w)
+		if err != nil {
+			l.Error("failed to export patient", zap.Error(err))
+
+			if export.Format != resource.FormatNDJSON {
+				_ = conn.Close()
+				return
+			}
+		}
+
+		// an NDJSON export that failed ends with an OperationOutcome
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// SearchRecords searches the medical records and returns them as resources of
+// resourceType.
+func (h fhirHandler) SearchRecords(
+	resourceType string,
+	newResource func(record *domain.MedicalRecord) any,
+) fiber.Handler {
+	callerInfo := "[fhirHandler.SearchRecords]"
+
+	return func(c *fiber.Ctx) error {
+		userCtx := c.UserContext()
+		l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo), zap.String("type", resourceType))
+
+		query := queryRecordAcquire()
+		defer queryRecordRelease(query)
+
+		if err := c.QueryParser(query); err != nil {
+			l.Error("error parsing query params", zap.Error(err))
+			return errBadRequest{err: err}
+		}
+
+		if err := query.validate(); err != nil {
+			l.Error("error validating query params", zap.Error(err))
+			return errBadRequest{err: err}
+		}
+
+		records := domain.MedicalRecordsAcquire()
+		defer domain.MedicalRecordsRelease(records)
+
+		total := 0
+		if !query.noMatch {
+			filter := domain.FilterFHIRRecordAcquire()
+			defer domain.FilterFHIRRecordRelease(filter)
+
+			filter.ID = query.recordID
+			filter.PatientID = query.patientID
+			filter.Limit = query.Count
+			filter.Offset = query.Offset
+
+			var err error
+			records, total, err = h.fhirService.GetRecords(userCtx, filter, records)
+			if err != nil {
+				l.Error("failed to get medical records", zap.Error(err))
+				return err
+			}
+		}
+
+		searchURL := baseURL(c) + "/" + resourceType
+		bundle := resource.NewSearchset(total)
+		query.addLinks(bundle, searchURL, query.params(), total)
+
+		for i := range records {
+			bundle.AddMatch(searchURL+"/"+records[i].ID.String(), newResource(&records[i]))
+		}
+
+		return sendResource(c, http.StatusOK, bundle)
+	}
+}
+
+// ReadRecord returns a medical record as a resource of resourceType.
+func (h fhirHandler) ReadRecord(
+	resourceType string,
+	newResource func(record *domain.MedicalRecord) any,
+) fiber.Handler {
+	callerInfo := "[fhirHandler.ReadRecord]"
+
+	return func(c *fiber.Ctx) error {
+		userCtx := c.UserContext()
+		l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo), zap.String("type", resourceType))
+
+		recordID, err := ulid.Parse(c.Params(resourceIDFromParam))
+		if err != nil {
+			return new(domain.ErrMedicalRecordNotFound)
+		}
+
+		record := domain.MedicalRecordAcquire()
+		defer domain.MedicalRecordRelease(record)
+
+		record.ID = recordID
+
+		err = h.fhirService.GetRecord(userCtx, record)
+		if err != nil {
+			l.Error("failed to get medical record", zap.Error(err))
+			return err
+		}
+
+		return sendResource(c, http.StatusOK, newResource(record))
+	}
+}
+
+// operationOutcome reports the errors of the FHIR API as OperationOutcome
+// resources, including the responses the JWT middleware writes on its own.
+func operationOutcome(c *fiber.Ctx) error {
+	err := c.Next()
+	if err != nil {
+		status := http.StatusInternalServerError
+
+		var fiberErr *fiber.Error
+		var handlerErr interface{ Status() int }
+
+		switch {
+		case errors.As(err, &handlerErr):
+			status = handlerErr.Status()
+		case errors.As(err, &fiberErr):
+			status = fiberErr.Code
+		}
+
+		return sendResource(c, status, resource.NewOperationOutcome(status, err.Error()))
+	}
+
+	status := c.Response().StatusCode()
+	if status < http.StatusBadRequest || strings.HasPrefix(string(c.Response().Header.ContentType()), resource.ContentType) {
+		return nil
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(c.Response().Body(), &body)
+
+	return sendResource(c, status, resource.NewOperationOutcome(status, body.Message))
+}
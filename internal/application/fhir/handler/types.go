@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	defaultCount = 20
+	maxCount     = 100
+)
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+// isIdentityNumber reports whether s can be the id of a patient.
+func isIdentityNumber(s string) bool {
+	const idNumberLength = 16
+
+	if len(s) != idNumberLength {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// paging is shared by every search, it also builds the links of the bundle.
+type paging struct {
+	Count  int `query:"_count"`
+	Offset int `query:"_offset"`
+}
+
+func (p *paging) validate() error {
+	var errs error
+
+	switch {
+	case p.Count < 0:
+		errs = multierr.Append(errs, errors.New("_count must not be negative"))
+	case p.Count == 0:
+		p.Count = defaultCount
+	case p.Count > maxCount:
+		p.Count = maxCount
+	}
+
+	if p.Offset < 0 {
+		errs = multierr.Append(errs, errors.New("_offset must not be negative"))
+	}
+
+	return errs
+}
+
+// addLinks adds the self, first, previous and next links of the page to
+// bundle, params are the search parameters the search was made with.
+func (p *paging) addLinks(bundle *resource.Bundle, searchURL string, params url.Values, total int) {
+	link := func(offset int) string {
+		values := url.Values{}
+		for key, value := range params {
+			values[key] = value
+		}
+		values.Set("_count", strconv.Itoa(p.Count))
+		values.Set("_offset", strconv.Itoa(offset))
+		return searchURL + "?" + values.Encode()
+	}
+
+	bundle.AddLink("self", link(p.Offset))
+	bundle.AddLink("first", link(0))
+
+	if p.Offset > 0 {
+		bundle.AddLink("previous", link(max(p.Offset-p.Count, 0)))
+	}
+
+	if p.Offset+p.Count < total {
+		bundle.AddLink("next", link(p.Offset+p.Count))
+	}
+}
+
+var queryPatientPool = sync.Pool{
+	New: func() any {
+		return new(queryPatient)
+	},
+}
+
+func queryPatientAcquire() *queryPatient {
+	return queryPatientPool.Get().(*queryPatient)
+}
+
+func queryPatientRelease(t *queryPatient) {
+	*t = queryPatient{}
+	queryPatientPool.Put(t)
+}
+
+// queryPatient holds the Patient search parameters. Parameters that cannot
+// match any patient, such as an identifier of another system, set noMatch
+// rather than fail the search.
+type queryPatient struct {
+	ID         string   `query:"_id"`
+	Identifier string   `query:"identifier"`
+	Name       string   `query:"name"`
+	Gender     string   `query:"gender"`
+	BirthDate  []string `query:"birthdate"`
+	paging
+
+	patientID     string
+	birthDateFrom time.Time
+	birthDateTo   time.Time
+	noMatch       bool
+}
+
+func (q *queryPatient) validate() error {
+	errs := q.paging.validate()
+
+	q.patientID = q.ID
+	if q.Identifier != "" {
+		system, value, found := strings.Cut(q.Identifier, "|")
+		if !found {
+			system, value = "", q.Identifier
+		}
+		if system != "" && system != resource.NIKSystem {
+			q.noMatch = true
+		}
+		if q.patientID != "" && q.patientID != value {
+			q.noMatch = true
+		}
+		q.patientID = value
+	}
+	if q.patientID != "" && !isIdentityNumber(q.patientID) {
+		q.noMatch = true
+	}
+
+	switch q.Gender {
+	case "", domain.GenderMale, domain.GenderFemale:
+	case "other", "unknown":
+		q.noMatch = true
+	default:
+		errs = multierr.Append(errs, errors.New("gender must be one of male, female, other or unknown"))
+	}
+
+	for _, birthDate := range q.BirthDate {
+		if err := q.addBirthDate(birthDate); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
+	if !q.birthDateFrom.IsZero() && !q.birthDateTo.IsZero() && q.birthDateFrom.After(q.birthDateTo) {
+		q.noMatch = true
+	}
+
+	return errs
+}
+
+// addBirthDate narrows the birth date range by a date parameter. The date is
+// a year, a month or a day, and its prefix compares against all of it.
+func (q *queryPatient) addBirthDate(param string) error {
+	prefix := "eq"
+	if len(param) > 2 && param[0] >= 'a' && param[0] <= 'z' {
+		prefix, param = param[:2], param[2:]
+	}
+
+	var start, end time.Time
+	for _, layout := range []struct {
+		format      string
+		years, mons int
+		days        int
+	}{
+		{format: "2006", years: 1},
+		{format: "2006-01", mons: 1},
+		{format: "2006-01-02", days: 1},
+	} {
+		if len(param) != len(layout.format) {
+			continue
+		}
+		t, err := time.ParseInLocation(layout.format, param, time.Local)
+		if err != nil {
+			break
+		}
+		start, end = t, t.AddDate(layout.years, layout.mons, layout.days)
+	}
+	if start.IsZero() {
+		return errors.New("birthdate must be a date as YYYY, YYYY-MM or YYYY-MM-DD")
+	}
+
+	// the filter range is inclusive, last is the last day of the date
+	last := end.AddDate(0, 0, -1)
+
+	from, to := time.Time{}, time.Time{}
+	switch prefix {
+	case "eq":
+		from, to = start, last
+	case "gt":
+		from = end
+	case "ge":
+		from = start
+	case "lt":
+		to = start.AddDate(0, 0, -1)
+	case "le":
+		to = last
+	default:
+		return errors.New("birthdate prefix must be one of eq, gt, ge, lt or le")
+	}
+
+	if !from.IsZero() && from.After(q.birthDateFrom) {
+		q.birthDateFrom = from
+	}
+	if !to.IsZero() && (q.birthDateTo.IsZero() || to.Before(q.birthDateTo)) {
+		q.birthDateTo = to
+	}
+
+	return nil
+}
+
+// params are the search parameters the bundle links repeat.
+func (q *queryPatient) params() url.Values {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"_id":        q.ID,
+		"identifier": q.Identifier,
+		"name":       q.Name,
+		"gender":     q.Gender,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	for _, birthDate := range q.BirthDate {
+		values.Add("birthdate", birthDate)
+	}
+	return values
+}
+
+var queryRecordPool = sync.Pool{
+	New: func() any {
+		return new(queryRecord)
+	},
+}
+
+func queryRecordAcquire() *queryRecord {
+	return queryRecordPool.Get().(*queryRecord)
+}
+
+func queryRecordRelease(t *queryRecord) {
+	*t = queryRecord{}
+	queryRecordPool.Put(t)
+}
+
+// queryRecord holds the search parameters of the resources mapped from
+// medical records, patient and subject are the same reference.
+type queryRecord struct {
+	ID      string `query:"_id"`
+	Patient string `query:"patient"`
+	Subject string `query:"subject"`
+	paging
+
+	recordID  ulid.ULID
+	patientID string
+	noMatch   bool
+}
+
+func (q *queryRecord) validate() error {
+	errs := q.paging.validate()
+
+	if q.ID != "" {
+		recordID, err := ulid.Parse(q.ID)
+		if err != nil {
+			q.noMatch = true
+		}
+		q.recordID = recordID
+	}
+
+	for _, reference := range []string{q.Patient, q.Subject} {
+		if reference == "" {
+			continue
+		}
+
+		// a reference is Patient/[id], possibly absolute, or the bare id
+		patientID := reference
+		if i := strings.LastIndex(reference, "/"); i >= 0 {
+			if !strings.HasSuffix(reference[:i], resource.TypePatient) {
+				q.noMatch = true
+			}
+			patientID = reference[i+1:]
+		}
+
+		if !isIdentityNumber(patientID) || (q.patientID != "" && q.patientID != patientID) {
+			q.noMatch = true
+		}
+		q.patientID = patientID
+	}
+
+	return errs
+}
+
+func (q *queryRecord) params() url.Values {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"_id":     q.ID,
+		"patient": q.Patient,
+		"subject": q.Subject,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values
+}
+
+var queryExportPool = sync.Pool{
+	New: func() any {
+		return new(queryExport)
+	},
+}
+
+func queryExportAcquire() *queryExport {
+	return queryExportPool.Get().(*queryExport)
+}
+
+func queryExportRelease(t *queryExport) {
+	*t = queryExport{}
+	queryExportPool.Put(t)
+}
+
+// queryExport defaults to a collection as JSON, _format also takes the media
+// types of both formats.
+type queryExport struct {
+	Type   string `query:"type"`
+	Format string `query:"_format"`
+}
+
+func (q *queryExport) validate() error {
+	var errs error
+
+	switch q.Type {
+	case "":
+		q.Type = resource.BundleCollection
+	case resource.BundleCollection, resource.BundleDocument:
+	default:
+		errs = multierr.Append(errs, errors.New("type must be one of collection or document"))
+	}
+
+	switch q.Format {
+	case "", resource.FormatJSON, resource.ContentType, "application/json":
+		q.Format = resource.FormatJSON
+	case resource.FormatNDJSON, resource.ContentTypeNDJSON, "application/ndjson":
+		q.Format = resource.FormatNDJSON
+	default:
+		errs = multierr.Append(errs, errors.New("_format must be one of json or ndjson"))
+	}
+
+	return errs
+}
@@ -0,0 +1,22 @@
+package fhir
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	exportTimeout := time.Duration(configs.Get().FHIR.ExportTimeout) * time.Second
+
+	fhirRepository := repository.NewFHIRRepository(db)
+	fhirService := service.NewFHIRService(ctxTimeout, exportTimeout, fhirRepository)
+	handler.NewFHIRHandler(router, jwtMiddleware, fhirService)
+}
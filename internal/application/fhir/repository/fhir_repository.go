@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type FHIRRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFHIRRepository(db *pgxpool.Pool) *FHIRRepository {
+	return &FHIRRepository{db: db}
+}
+
+// GetPatients returns a page of the patients matching filter along with how
+// many match in total. Both are read from one snapshot so the total agrees
+// with the page.
+func (r FHIRRepository) GetPatients(
+	ctx context.Context,
+	filter *domain.FilterPatient,
+	patients domain.Patients,
+) (domain.Patients, int, error) {
+	callerInfo := "[FHIRRepository.GetPatients]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return patients, 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	conditions, params := r.filterPatient(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM patients` + conditions
+	if err = tx.QueryRow(ctx, countQuery, params).Scan(&total); err != nil {
+		l.Error("failed to count patients", zap.Error(err))
+		return patients, 0, err
+	}
+
+	getQuery := `SELECT id, phone_number, name, birth_date, is_male, img_url, created_at FROM patients` +
+		conditions + ` ORDER BY created_at DESC, id` + r.paging(params, filter.Limit, filter.Offset)
+
+	rows, err := tx.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get patients", zap.Error(err))
+		return patients, 0, err
+	}
+
+	dPatient := domain.PatientAcquire()
+	defer domain.PatientRelease(dPatient)
+	var isMale bool
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dPatient.ID,
+			&dPatient.PhoneNumber,
+			&dPatient.Name,
+			&dPatient.BirthDate,
+			&isMale,
+			&dPatient.ImgURL,
+			&dPatient.CreatedAt,
+		},
+		func() error {
+			dPatient.Gender = domain.GenderMale
+			if !isMale {
+				dPatient.Gender = domain.GenderFemale
+			}
+			patients = append(patients, *dPatient)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get patients", zap.Error(err))
+		return patients, 0, err
+	}
+
+	return patients, total, nil
+}
+
+func (r FHIRRepository) filterPatient(filter *domain.FilterPatient) (string, pgx.NamedArgs) {
+	const totalConditions = 5
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if filter.ID != "" {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.Name != "" {
+		conditions = append(conditions, "name ILIKE @name")
+		params["name"] = "%" + filter.Name + "%"
+	}
+
+	if filter.Gender != "" {
+		conditions = append(conditions, "is_male = @is_male")
+		params["is_male"] = filter.Gender == domain.GenderMale
+	}
+
+	if !filter.BirthDateFrom.IsZero() {
+		conditions = append(conditions, "birth_date >= @birth_date_from")
+		params["birth_date_from"] = filter.BirthDateFrom
+	}
+
+	if !filter.BirthDateTo.IsZero() {
+		conditions = append(conditions, "birth_date <= @birth_date_to")
+		params["birth_date_to"] = filter.BirthDateTo
+	}
+
+	return r.where(conditions), params
+}
+
+// GetRecords returns a page of the records matching filter with their
+// amendments, along with how many match in total.
+func (r FHIRRepository) GetRecords(
+	ctx context.Context,
+	filter *domain.FilterFHIRRecord,
+	records domain.MedicalRecords,
+) (domain.MedicalRecords, int, error) {
+	callerInfo := "[FHIRRepository.GetRecords]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return records, 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	conditions, params := r.filterRecord(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM medical_records` + conditions
+	if err = tx.QueryRow(ctx, countQuery, params).Scan(&total); err != nil {
+		l.Error("failed to count medical records", zap.Error(err))
+		return records, 0, err
+	}
+
+	getQuery := `SELECT id, patient_id, symptoms, medications, staff_id, staff_nip, staff_name, created_at
+		FROM medical_records` + conditions + ` ORDER BY created_at DESC, id` +
+		r.paging(params, filter.Limit, filter.Offset)
+
+	rows, err := tx.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get medical records", zap.Error(err))
+		return records, 0, err
+	}
+
+	dRecord := domain.MedicalRecordAcquire()
+	defer domain.MedicalRecordRelease(dRecord)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dRecord.ID,
+			&dRecord.PatientID,
+			&dRecord.Symptoms,
+			&dRecord.Medications,
+			&dRecord.StaffID,
+			&dRecord.StaffNIP,
+			&dRecord.StaffName,
+			&dRecord.CreatedAt,
+		},
+		func() error {
+			records = append(records, *dRecord)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get medical records", zap.Error(err))
+		return records, 0, err
+	}
+
+	if len(records) == 0 {
+		return records, total, nil
+	}
+
+	recordIndex := make(map[ulid.ULID]int, len(records))
+	recordIDs := make([][]byte, 0, len(records))
+	for i := range records {
+		recordIndex[records[i].ID] = i
+		recordIDs = append(recordIDs, records[i].ID.Bytes())
+	}
+
+	amendmentQuery := `SELECT record_id, type, symptoms, medications, created_at
+		FROM medical_record_amendments WHERE record_id = ANY(@record_ids) ORDER BY created_at ASC`
+
+	rows, err = tx.Query(ctx, amendmentQuery, pgx.NamedArgs{"record_ids": recordIDs})
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return records, 0, err
+	}
+
+	dAmendment := domain.MedicalRecordAmendmentAcquire()
+	defer domain.MedicalRecordAmendmentRelease(dAmendment)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dAmendment.RecordID,
+			&dAmendment.Type,
+			&dAmendment.Symptoms,
+			&dAmendment.Medications,
+			&dAmendment.CreatedAt,
+		},
+		func() error {
+			i := recordIndex[dAmendment.RecordID]
+			records[i].Amendments = append(records[i].Amendments, *dAmendment)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return records, 0, err
+	}
+
+	return records, total, nil
+}
+
+func (r FHIRRepository) filterRecord(filter *domain.FilterFHIRRecord) (string, pgx.NamedArgs) {
+	const totalConditions = 2
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	return r.where(conditions), params
+}
+
+// ExportRecords hands every record of the patient in patientID to writer,
+// reading them from one snapshot, and notes the export on the timeline of the
+// patient once writer has taken them all.
+func (r FHIRRepository) ExportRecords(
+	ctx context.Context,
+	patientID, exportType string,
+	user *domain.User,
+	writer RecordWriter,
+) error {
+	callerInfo := "[FHIRRepository.ExportRecords]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	args := pgx.NamedArgs{"patient_id": patientID}
+
+	amendmentQuery := `SELECT a.record_id, a.type, a.symptoms, a.medications, a.created_at
+		FROM medical_record_amendments a JOIN medical_records m ON m.id = a.record_id
+		WHERE m.patient_id = @patient_id ORDER BY a.created_at ASC`
+
+	rows, err := tx.Query(ctx, amendmentQuery, args)
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return err
+	}
+
+	amendments := make(map[ulid.ULID]domain.MedicalRecordAmendments)
+	dAmendment := domain.MedicalRecordAmendmentAcquire()
+	defer domain.MedicalRecordAmendmentRelease(dAmendment)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dAmendment.RecordID,
+			&dAmendment.Type,
+			&dAmendment.Symptoms,
+			&dAmendment.Medications,
+			&dAmendment.CreatedAt,
+		},
+		func() error {
+			amendments[dAmendment.RecordID] = append(amendments[dAmendment.RecordID], *dAmendment)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return err
+	}
+
+	idQuery := `SELECT id FROM medical_records WHERE patient_id = @patient_id ORDER BY created_at ASC, id`
+
+	rows, err = tx.Query(ctx, idQuery, args)
+	if err != nil {
+		l.Error("failed to get medical record ids", zap.Error(err))
+		return err
+	}
+
+	recordIDs, err := pgx.CollectRows(rows, pgx.RowTo[ulid.ULID])
+	if err != nil {
+		l.Error("failed to get medical record ids", zap.Error(err))
+		return err
+	}
+
+	if err = writer.Begin(recordIDs); err != nil {
+		l.Error("failed to begin export", zap.Error(err))
+		return err
+	}
+
+	getQuery := `SELECT id, patient_id, symptoms, medications, staff_id, staff_nip, staff_name, created_at
+		FROM medical_records WHERE patient_id = @patient_id ORDER BY created_at ASC, id`
+
+	rows, err = tx.Query(ctx, getQuery, args)
+	if err != nil {
+		l.Error("failed to get medical records", zap.Error(err))
+		return err
+	}
+
+	dRecord := domain.MedicalRecordAcquire()
+	defer domain.MedicalRecordRelease(dRecord)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dRecord.ID,
+			&dRecord.PatientID,
+			&dRecord.Symptoms,
+			&dRecord.Medications,
+			&dRecord.StaffID,
+			&dRecord.StaffNIP,
+			&dRecord.StaffName,
+			&dRecord.CreatedAt,
+		},
+		func() error {
+			dRecord.Amendments = amendments[dRecord.ID]
+			return writer.Write(dRecord)
+		},
+	)
+	if err != nil {
+		l.Error("failed to export medical records", zap.Error(err))
+		return err
+	}
+
+	event, err := patientevent.New(patientID, domain.PatientEventRecordExported, user, map[string]any{
+		"type":    exportType,
+		"records": len(recordIDs),
+	})
+	if err != nil {
+		l.Error("failed to build patient event", zap.Error(err))
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	if err = patientevent.Insert(ctx, tx, event); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r FHIRRepository) where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func (r FHIRRepository) paging(params pgx.NamedArgs, limit, offset int) string {
+	paging := " LIMIT @limit"
+	params["limit"] = 5
+	if limit != 0 {
+		params["limit"] = limit
+	}
+
+	if offset != 0 {
+		paging += " OFFSET @offset"
+		params["offset"] = offset
+	}
+
+	return paging
+}
+
+var _ FHIRRepositoryContract = (*FHIRRepository)(nil)
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type FHIRRepositoryContract interface {
+	GetPatients(ctx context.Context, filter *domain.FilterPatient, patients domain.Patients) (domain.Patients, int, error)
+	GetRecords(
+		ctx context.Context,
+		filter *domain.FilterFHIRRecord,
+		records domain.MedicalRecords,
+	) (domain.MedicalRecords, int, error)
+	ExportRecords(ctx context.Context, patientID, exportType string, user *domain.User, writer RecordWriter) error
+}
+
+// RecordWriter receives the records of an export oldest first, Begin is
+// called with all of their IDs before the first record.
+type RecordWriter interface {
+	Begin(recordIDs []ulid.ULID) error
+	Write(record *domain.MedicalRecord) error
+}
@@ -0,0 +1,201 @@
+package resource
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+)
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type BundleSearch struct {
+	Mode string `json:"mode"`
+}
+
+type BundleEntry struct {
+	FullURL  string        `json:"fullUrl"`
+	Resource any           `json:"resource"`
+	Search   *BundleSearch `json:"search,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp"`
+	Total        *int          `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// NewSearchset returns an empty searchset of total matches, entries are added
+// with AddMatch.
+func NewSearchset(total int) *Bundle {
+	return &Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Timestamp:    Instant(time.Now()),
+		Total:        &total,
+	}
+}
+
+func (b *Bundle) AddLink(relation, url string) {
+	b.Link = append(b.Link, BundleLink{Relation: relation, URL: url})
+}
+
+func (b *Bundle) AddMatch(fullURL string, resource any) {
+	b.Entry = append(b.Entry, BundleEntry{
+		FullURL:  fullURL,
+		Resource: resource,
+		Search:   &BundleSearch{Mode: "match"},
+	})
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+// issueCodes are the issue types of the statuses the API responds with, the
+// rest are reported as exceptions.
+var issueCodes = map[int]string{
+	http.StatusBadRequest:      "invalid",
+	http.StatusUnauthorized:    "login",
+	http.StatusForbidden:       "forbidden",
+	http.StatusNotFound:        "not-found",
+	http.StatusNotAcceptable:   "not-supported",
+	http.StatusTooManyRequests: "throttled",
+	http.StatusGatewayTimeout:  "timeout",
+}
+
+// NewOperationOutcome reports a failed request of status with diagnostics.
+func NewOperationOutcome(status int, diagnostics string) OperationOutcome {
+	code, ok := issueCodes[status]
+	if !ok {
+		code = "exception"
+	}
+
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue: []OperationOutcomeIssue{{
+			Severity:    "error",
+			Code:        code,
+			Diagnostics: diagnostics,
+		}},
+	}
+}
+
+type CapabilitySoftware struct {
+	Name string `json:"name"`
+}
+
+type CapabilityImplementation struct {
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+type CapabilitySecurity struct {
+	Service     []CodeableConcept `json:"service"`
+	Description string            `json:"description"`
+}
+
+type CapabilityInteraction struct {
+	Code string `json:"code"`
+}
+
+type CapabilitySearchParam struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+type CapabilityResource struct {
+	Type          string                  `json:"type"`
+	Documentation string                  `json:"documentation,omitempty"`
+	Interaction   []CapabilityInteraction `json:"interaction"`
+	SearchParam   []CapabilitySearchParam `json:"searchParam"`
+}
+
+type CapabilityRest struct {
+	Mode        string                  `json:"mode"`
+	Security    CapabilitySecurity      `json:"security"`
+	Resource    []CapabilityResource    `json:"resource"`
+	SearchParam []CapabilitySearchParam `json:"searchParam"`
+}
+
+type CapabilityStatement struct {
+	ResourceType   string                   `json:"resourceType"`
+	Status         string                   `json:"status"`
+	Date           string                   `json:"date"`
+	Kind           string                   `json:"kind"`
+	Software       CapabilitySoftware       `json:"software"`
+	Implementation CapabilityImplementation `json:"implementation"`
+	FHIRVersion    string                   `json:"fhirVersion"`
+	Format         []string                 `json:"format"`
+	Rest           []CapabilityRest         `json:"rest"`
+}
+
+var readSearch = []CapabilityInteraction{{Code: "read"}, {Code: "search-type"}}
+
+// recordSearchParams are shared by the resources mapped from medical records.
+var recordSearchParams = []CapabilitySearchParam{
+	{Name: "_id", Type: "token"},
+	{Name: "patient", Type: "reference", Documentation: "Patient/[id] or the bare identity number"},
+	{Name: "subject", Type: "reference", Documentation: "Same as patient"},
+}
+
+// NewCapabilityStatement describes the API served at baseURL.
+func NewCapabilityStatement(baseURL string) CapabilityStatement {
+	return CapabilityStatement{
+		ResourceType: "CapabilityStatement",
+		Status:       "active",
+		Date:         Instant(time.Now()),
+		Kind:         "instance",
+		Software:     CapabilitySoftware{Name: configs.Get().App.Name},
+		Implementation: CapabilityImplementation{
+			Description: "Read only access to patients and medical records",
+			URL:         baseURL,
+		},
+		FHIRVersion: FHIRVersion,
+		Format:      []string{"json"},
+		Rest: []CapabilityRest{{
+			Mode: "server",
+			Security: CapabilitySecurity{
+				Service: []CodeableConcept{{Text: "Bearer"}},
+				Description: "Send the access token of a staff login as a bearer token, " +
+					"this statement is the only resource served without one.",
+			},
+			Resource: []CapabilityResource{
+				{
+					Type: TypePatient,
+					Documentation: "GET Patient/[id]/export returns the patient and all of their records " +
+						"as a collection or document bundle, type=collection|document and _format=json|ndjson",
+					Interaction: readSearch,
+					SearchParam: []CapabilitySearchParam{
+						{Name: "_id", Type: "token"},
+						{Name: "identifier", Type: "token", Documentation: "[system|]identity number, system is " + NIKSystem},
+						{Name: "name", Type: "string", Documentation: "Matches any part of the name"},
+						{Name: "birthdate", Type: "date", Documentation: "eq, gt, ge, lt and le prefixes, may be repeated"},
+						{Name: "gender", Type: "token"},
+					},
+				},
+				{Type: TypeCondition, Interaction: readSearch, SearchParam: recordSearchParams},
+				{Type: TypeObservation, Interaction: readSearch, SearchParam: recordSearchParams},
+				{Type: TypeMedicationStatement, Interaction: readSearch, SearchParam: recordSearchParams},
+			},
+			SearchParam: []CapabilitySearchParam{
+				{Name: "_count", Type: "number", Documentation: "Page size, at most 100"},
+				{Name: "_offset", Type: "number", Documentation: "Matches to skip, used by the paging links"},
+			},
+		}},
+	}
+}
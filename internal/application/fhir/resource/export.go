@@ -0,0 +1,194 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	BundleCollection = "collection"
+	BundleDocument   = "document"
+
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+
+	ContentTypeNDJSON = "application/fhir+ndjson"
+
+	TypeComposition = "Composition"
+)
+
+// URN formats u as a urn:uuid, for resources that have no URL of their own.
+func URN(u ulid.ULID) string {
+	b := make([]byte, 0, 36)
+	for i, part := range [][]byte{u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]} {
+		if i > 0 {
+			b = append(b, '-')
+		}
+		b = hex.AppendEncode(b, part)
+	}
+	return "urn:uuid:" + string(b)
+}
+
+// BundleWriter writes a Bundle one entry at a time, so an export never holds
+// the whole record in memory. As NDJSON it writes the resources of the
+// entries one per line and leaves the bundle out.
+type BundleWriter struct {
+	w       io.Writer
+	format  string
+	entries int
+}
+
+type bundleHeader struct {
+	ResourceType string     `json:"resourceType"`
+	ID           string     `json:"id"`
+	Identifier   Identifier `json:"identifier"`
+	Type         string     `json:"type"`
+	Timestamp    string     `json:"timestamp"`
+}
+
+// NewBundleWriter starts a bundle of bundleType identified by bundleID.
+func NewBundleWriter(w io.Writer, bundleID ulid.ULID, bundleType, format string) (*BundleWriter, error) {
+	b := &BundleWriter{w: w, format: format}
+	if format == FormatNDJSON {
+		return b, nil
+	}
+
+	header, err := json.Marshal(bundleHeader{
+		ResourceType: "Bundle",
+		ID:           bundleID.String(),
+		Identifier:   Identifier{System: "urn:ietf:rfc:3986", Value: URN(bundleID)},
+		Type:         bundleType,
+		Timestamp:    Instant(time.Now()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the entries go in place of the closing brace
+	header = append(header[:len(header)-1], `,"entry":[`...)
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Add writes an entry of resource found at fullURL.
+func (b *BundleWriter) Add(fullURL string, resource any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	var err error
+	if b.format == FormatNDJSON {
+		err = enc.Encode(resource)
+	} else {
+		if b.entries > 0 {
+			buf.WriteByte(',')
+		}
+		err = enc.Encode(BundleEntry{FullURL: fullURL, Resource: resource})
+		// Encode ends the entry with a newline, the list goes on after it
+	}
+	if err != nil {
+		return err
+	}
+
+	b.entries++
+	_, err = b.w.Write(buf.Bytes())
+	return err
+}
+
+// Close ends the bundle, it does not close the underlying writer.
+func (b *BundleWriter) Close() error {
+	if b.format == FormatNDJSON {
+		return nil
+	}
+	_, err := b.w.Write([]byte("]}\n"))
+	return err
+}
+
+type CompositionSection struct {
+	Title       string           `json:"title"`
+	Code        CodeableConcept  `json:"code"`
+	Text        *Narrative       `json:"text,omitempty"`
+	Entry       []Reference      `json:"entry,omitempty"`
+	EmptyReason *CodeableConcept `json:"emptyReason,omitempty"`
+}
+
+type Composition struct {
+	ResourceType string               `json:"resourceType"`
+	ID           string               `json:"id"`
+	Status       string               `json:"status"`
+	Type         CodeableConcept      `json:"type"`
+	Subject      Reference            `json:"subject"`
+	Date         string               `json:"date"`
+	Author       []Reference          `json:"author"`
+	Title        string               `json:"title"`
+	Section      []CompositionSection `json:"section"`
+}
+
+// NewComposition lists the resources of a transfer summary of patient made
+// by author. References are absolute under baseURL, the same as the fullUrl
+// of the entries they point to.
+func NewComposition(
+	compositionID ulid.ULID,
+	baseURL string,
+	patient *domain.Patient,
+	author *domain.User,
+	recordIDs []ulid.ULID,
+) Composition {
+	section := func(title, code, display, resourceType string) CompositionSection {
+		s := CompositionSection{
+			Title: title,
+			Code:  CodeableConcept{Coding: []Coding{{System: "http://loinc.org", Code: code, Display: display}}},
+		}
+
+		for _, recordID := range recordIDs {
+			s.Entry = append(s.Entry, Reference{Reference: baseURL + "/" + resourceType + "/" + recordID.String()})
+		}
+
+		// a section needs a text when it has no entries
+		if len(s.Entry) == 0 {
+			s.Text = &Narrative{
+				Status: "generated",
+				Div:    `<div xmlns="http://www.w3.org/1999/xhtml">No records</div>`,
+			}
+			s.EmptyReason = &CodeableConcept{Coding: []Coding{{
+				System: "http://terminology.hl7.org/CodeSystem/list-empty-reason",
+				Code:   "nilknown",
+			}}}
+		}
+
+		return s
+	}
+
+	return Composition{
+		ResourceType: TypeComposition,
+		ID:           compositionID.String(),
+		Status:       "final",
+		Type: CodeableConcept{Coding: []Coding{{
+			System:  "http://loinc.org",
+			Code:    "18761-7",
+			Display: "Transfer summary note",
+		}}},
+		Subject: Reference{Reference: baseURL + "/" + TypePatient + "/" + patient.ID},
+		Date:    Instant(time.Now()),
+		Author: []Reference{{
+			Identifier: &Identifier{System: NIPSystem(), Value: author.NIP},
+			Display:    author.Name,
+		}},
+		Title: "Transfer summary of " + patient.Name,
+		Section: []CompositionSection{
+			section("Problems", "11450-4", "Problem list - Reported", TypeCondition),
+			section("Symptoms", "10187-3", "Review of systems Narrative - Reported", TypeObservation),
+			section("Medications", "10160-0", "History of Medication use Narrative", TypeMedicationStatement),
+		},
+	}
+}
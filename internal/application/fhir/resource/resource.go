@@ -0,0 +1,267 @@
+// Package resource maps patients and medical records to FHIR R4 resources.
+//
+// A medical record has no coded diagnosis, so it is exposed three ways under
+// its own id: the symptoms as a provisional Condition and as an Observation,
+// and the medications as a MedicationStatement. All of them read as they do
+// after the amendments of the record. The API serves them without an
+// Encounter, the fields for one are filled by integrations that send them on.
+package resource
+
+import (
+	"time"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	FHIRVersion = "4.0.1"
+	ContentType = "application/fhir+json"
+
+	TypePatient             = "Patient"
+	TypeCondition           = "Condition"
+	TypeObservation         = "Observation"
+	TypeMedicationStatement = "MedicationStatement"
+
+	// NIKSystem is the national identity number, which patients are
+	// registered with.
+	NIKSystem = "https://fhir.kemkes.go.id/id/nik"
+
+	dateFormat    = "2006-01-02"
+	instantFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// NIPSystem is the system of staff identity numbers, they are only unique
+// within this application.
+func NIPSystem() string {
+	return "urn:" + configs.Get().App.Name + ":nip"
+}
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type Reference struct {
+	Reference  string      `json:"reference,omitempty"`
+	Identifier *Identifier `json:"identifier,omitempty"`
+	Display    string      `json:"display,omitempty"`
+}
+
+type HumanName struct {
+	Use  string `json:"use,omitempty"`
+	Text string `json:"text"`
+}
+
+type ContactPoint struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+	Use    string `json:"use,omitempty"`
+}
+
+// Narrative is the human readable text of a resource, div is XHTML.
+type Narrative struct {
+	Status string `json:"status"`
+	Div    string `json:"div"`
+}
+
+type Attachment struct {
+	URL string `json:"url"`
+}
+
+type Patient struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Identifier   []Identifier   `json:"identifier"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Gender       string         `json:"gender"`
+	BirthDate    string         `json:"birthDate"`
+	Photo        []Attachment   `json:"photo,omitempty"`
+}
+
+type Condition struct {
+	ResourceType       string            `json:"resourceType"`
+	ID                 string            `json:"id,omitempty"`
+	Identifier         []Identifier      `json:"identifier,omitempty"`
+	Meta               *Meta             `json:"meta,omitempty"`
+	VerificationStatus CodeableConcept   `json:"verificationStatus"`
+	Category           []CodeableConcept `json:"category"`
+	Code               CodeableConcept   `json:"code"`
+	Subject            Reference         `json:"subject"`
+	Encounter          *Reference        `json:"encounter,omitempty"`
+	RecordedDate       string            `json:"recordedDate"`
+	Recorder           Reference         `json:"recorder"`
+}
+
+type Observation struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id,omitempty"`
+	Identifier        []Identifier      `json:"identifier,omitempty"`
+	Meta              *Meta             `json:"meta,omitempty"`
+	Status            string            `json:"status"`
+	Category          []CodeableConcept `json:"category"`
+	Code              CodeableConcept   `json:"code"`
+	Subject           Reference         `json:"subject"`
+	Encounter         *Reference        `json:"encounter,omitempty"`
+	EffectiveDateTime string            `json:"effectiveDateTime"`
+	Issued            string            `json:"issued"`
+	Performer         []Reference       `json:"performer"`
+	ValueString       string            `json:"valueString"`
+}
+
+type MedicationStatement struct {
+	ResourceType              string          `json:"resourceType"`
+	ID                        string          `json:"id,omitempty"`
+	Identifier                []Identifier    `json:"identifier,omitempty"`
+	Meta                      *Meta           `json:"meta,omitempty"`
+	Status                    string          `json:"status"`
+	MedicationCodeableConcept CodeableConcept `json:"medicationCodeableConcept"`
+	Subject                   Reference       `json:"subject"`
+	Context                   *Reference      `json:"context,omitempty"`
+	DateAsserted              string          `json:"dateAsserted"`
+	InformationSource         Reference       `json:"informationSource"`
+}
+
+// Instant formats t as a FHIR instant.
+func Instant(t time.Time) string {
+	return t.Format(instantFormat)
+}
+
+func PatientReference(patientID string) Reference {
+	return Reference{Reference: TypePatient + "/" + patientID}
+}
+
+func NewPatient(patient *domain.Patient) Patient {
+	p := Patient{
+		ResourceType: TypePatient,
+		ID:           patient.ID,
+		Meta:         &Meta{LastUpdated: Instant(patient.CreatedAt)},
+		Identifier:   []Identifier{{Use: "official", System: NIKSystem, Value: patient.ID}},
+		Active:       true,
+		Name:         []HumanName{{Use: "official", Text: patient.Name}},
+		Gender:       patient.Gender,
+		BirthDate:    patient.BirthDate.Format(dateFormat),
+	}
+
+	if patient.PhoneNumber != "" {
+		p.Telecom = []ContactPoint{{System: "phone", Value: patient.PhoneNumber, Use: "mobile"}}
+	}
+
+	if patient.ImgURL != "" {
+		p.Photo = []Attachment{{URL: patient.ImgURL}}
+	}
+
+	return p
+}
+
+// lastUpdated is when the record was last amended.
+func lastUpdated(record *domain.MedicalRecord) *Meta {
+	updatedAt := record.CreatedAt
+	for _, amendment := range record.Amendments {
+		if amendment.CreatedAt.After(updatedAt) {
+			updatedAt = amendment.CreatedAt
+		}
+	}
+	return &Meta{LastUpdated: Instant(updatedAt)}
+}
+
+func staffReference(record *domain.MedicalRecord) Reference {
+	return Reference{
+		Identifier: &Identifier{System: NIPSystem(), Value: record.StaffNIP},
+		Display:    record.StaffName,
+	}
+}
+
+// NewCondition records the symptoms as a provisional encounter diagnosis,
+// they are what the nurse observed rather than a confirmed diagnosis.
+func NewCondition(record *domain.MedicalRecord) Condition {
+	symptoms, _ := record.Current()
+
+	return Condition{
+		ResourceType: TypeCondition,
+		ID:           record.ID.String(),
+		Meta:         lastUpdated(record),
+		VerificationStatus: CodeableConcept{Coding: []Coding{{
+			System: "http://terminology.hl7.org/CodeSystem/condition-ver-status",
+			Code:   "provisional",
+		}}},
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System:  "http://terminology.hl7.org/CodeSystem/condition-category",
+			Code:    "encounter-diagnosis",
+			Display: "Encounter Diagnosis",
+		}}}},
+		Code:         CodeableConcept{Text: symptoms},
+		Subject:      PatientReference(record.PatientID),
+		RecordedDate: Instant(record.CreatedAt),
+		Recorder:     staffReference(record),
+	}
+}
+
+// NewObservation records the symptoms as a free text finding, it is amended
+// once the record has amendments.
+func NewObservation(record *domain.MedicalRecord) Observation {
+	symptoms, _ := record.Current()
+
+	status := "final"
+	if len(record.Amendments) > 0 {
+		status = "amended"
+	}
+
+	meta := lastUpdated(record)
+	return Observation{
+		ResourceType: TypeObservation,
+		ID:           record.ID.String(),
+		Meta:         meta,
+		Status:       status,
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System:  "http://terminology.hl7.org/CodeSystem/observation-category",
+			Code:    "exam",
+			Display: "Exam",
+		}}}},
+		Code: CodeableConcept{
+			Coding: []Coding{{System: "http://loinc.org", Code: "75325-1", Display: "Symptom"}},
+			Text:   "Symptoms",
+		},
+		Subject:           PatientReference(record.PatientID),
+		EffectiveDateTime: Instant(record.CreatedAt),
+		Issued:            meta.LastUpdated,
+		Performer:         []Reference{staffReference(record)},
+		ValueString:       symptoms,
+	}
+}
+
+// NewMedicationStatement records the medications as free text, the record
+// does not say whether they are still taken.
+func NewMedicationStatement(record *domain.MedicalRecord) MedicationStatement {
+	_, medications := record.Current()
+
+	return MedicationStatement{
+		ResourceType:              TypeMedicationStatement,
+		ID:                        record.ID.String(),
+		Meta:                      lastUpdated(record),
+		Status:                    "unknown",
+		MedicationCodeableConcept: CodeableConcept{Text: medications},
+		Subject:                   PatientReference(record.PatientID),
+		DateAsserted:              Instant(record.CreatedAt),
+		InformationSource:         staffReference(record),
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// exportFlushEvery is how many records are written between flushes, so a
+// large export reaches the client as it is read.
+const exportFlushEvery = 20
+
+// Export is a bundle of everything about Patient, made by User. BaseURL is
+// where the FHIR API is served, the entries are found under it.
+type Export struct {
+	Patient domain.Patient
+	User    domain.User
+	Type    string
+	Format  string
+	BaseURL string
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// bundleExport writes the records of an export as resources of a bundle, the
+// patient goes first, after the composition of a document.
+type bundleExport struct {
+	export  *Export
+	w       io.Writer
+	bundle  *resource.BundleWriter
+	records int
+}
+
+func (b *bundleExport) Begin(recordIDs []ulid.ULID) error {
+	bundle, err := resource.NewBundleWriter(b.w, id.New(), b.export.Type, b.export.Format)
+	if err != nil {
+		return err
+	}
+	b.bundle = bundle
+
+	if b.export.Type == resource.BundleDocument {
+		compositionID := id.New()
+		composition := resource.NewComposition(
+			compositionID,
+			b.export.BaseURL,
+			&b.export.Patient,
+			&b.export.User,
+			recordIDs,
+		)
+		if err = b.bundle.Add(resource.URN(compositionID), composition); err != nil {
+			return err
+		}
+	}
+
+	return b.bundle.Add(
+		b.export.BaseURL+"/"+resource.TypePatient+"/"+b.export.Patient.ID,
+		resource.NewPatient(&b.export.Patient),
+	)
+}
+
+func (b *bundleExport) Write(record *domain.MedicalRecord) error {
+	for _, entry := range []struct {
+		resourceType string
+		resource     any
+	}{
+		{resource.TypeCondition, resource.NewCondition(record)},
+		{resource.TypeObservation, resource.NewObservation(record)},
+		{resource.TypeMedicationStatement, resource.NewMedicationStatement(record)},
+	} {
+		fullURL := b.export.BaseURL + "/" + entry.resourceType + "/" + record.ID.String()
+		if err := b.bundle.Add(fullURL, entry.resource); err != nil {
+			return err
+		}
+	}
+
+	b.records++
+	if f, ok := b.w.(flusher); ok && b.records%exportFlushEvery == 0 {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+func (b *bundleExport) close() error {
+	return b.bundle.Close()
+}
+
+// fail ends an NDJSON export that was cut off by err with an OperationOutcome,
+// so the lines before it are not taken for the whole record. A JSON bundle
+// has nowhere to put one, it is left unclosed.
+func (b *bundleExport) fail(err error) error {
+	if b.export.Format != resource.FormatNDJSON {
+		return nil
+	}
+
+	status := http.StatusInternalServerError
+	diagnostics := "The export failed, the resources before this line are incomplete"
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+		diagnostics = "The export timed out, the resources before this line are incomplete"
+	}
+
+	// Begin may not have been reached
+	bundle := b.bundle
+	if bundle == nil {
+		bundle, err = resource.NewBundleWriter(b.w, id.New(), b.export.Type, b.export.Format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bundle.Add("", resource.NewOperationOutcome(status, diagnostics))
+}
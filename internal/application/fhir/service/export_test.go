@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+var definitionsDir string
+
+// TestMain runs the tests from a copy of the example config, the staff
+// identifiers are named after the application.
+func TestMain(m *testing.M) {
+	os.Exit(func() int {
+		var err error
+		definitionsDir, err = filepath.Abs(filepath.Join("testdata", "r4"))
+		if err != nil {
+			panic(err)
+		}
+
+		config, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "configs", "config.toml.example"))
+		if err != nil {
+			panic(err)
+		}
+
+		dir, err := os.MkdirTemp("", "fhir")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(dir)
+
+		if err = os.Mkdir(filepath.Join(dir, "configs"), 0o700); err != nil {
+			panic(err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, "configs", "config.toml"), config, 0o600); err != nil {
+			panic(err)
+		}
+		if err = os.Chdir(dir); err != nil {
+			panic(err)
+		}
+
+		return m.Run()
+	}())
+}
+
+func testValidator(t *testing.T) *validator {
+	t.Helper()
+
+	v, err := loadValidator(definitionsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func testRecords() domain.MedicalRecords {
+	createdAt := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+
+	return domain.MedicalRecords{
+		{
+			ID:          id.New(),
+			PatientID:   "3201010101010001",
+			Symptoms:    "Demam sejak dua hari",
+			Medications: "Paracetamol 500mg",
+			StaffNIP:    "6152000101001",
+			StaffName:   "Siti",
+			CreatedAt:   createdAt,
+		},
+		{
+			ID:          id.New(),
+			PatientID:   "3201010101010001",
+			Symptoms:    "Batuk <berdahak> & pilek",
+			Medications: "Ambroxol",
+			StaffNIP:    "6152000101001",
+			StaffName:   "Siti",
+			CreatedAt:   createdAt.Add(time.Hour),
+			Amendments: domain.MedicalRecordAmendments{
+				{
+					Type:        domain.AmendmentCorrection,
+					Medications: "Ambroxol 30mg",
+					CreatedAt:   createdAt.Add(2 * time.Hour),
+				},
+				{
+					Type:      domain.AmendmentAddendum,
+					Symptoms:  "Sesak napas",
+					CreatedAt: createdAt.Add(3 * time.Hour),
+				},
+			},
+		},
+	}
+}
+
+func TestBundleExport(t *testing.T) {
+	v := testValidator(t)
+
+	patient := domain.Patient{
+		ID:          "3201010101010001",
+		PhoneNumber: "+6281234567890",
+		Name:        "Budi Santoso",
+		BirthDate:   time.Date(1990, 2, 3, 0, 0, 0, 0, time.UTC),
+		Gender:      "male",
+		ImgURL:      "https://bucket.s3.amazonaws.com/budi.jpg",
+		CreatedAt:   time.Date(2024, 4, 1, 7, 0, 0, 0, time.UTC),
+	}
+	user := domain.User{ID: id.New(), NIP: "6152000101001", Name: "Siti"}
+
+	tests := []struct {
+		name    string
+		records domain.MedicalRecords
+	}{
+		{name: "records", records: testRecords()},
+		{name: "no records", records: domain.MedicalRecords{}},
+	}
+
+	for _, bundleType := range []string{resource.BundleDocument, resource.BundleCollection} {
+		for _, format := range []string{resource.FormatJSON, resource.FormatNDJSON} {
+			for _, tt := range tests {
+				t.Run(bundleType+"/"+format+"/"+tt.name, func(t *testing.T) {
+					var buf bytes.Buffer
+					export := &bundleExport{
+						export: &Export{
+							Patient: patient,
+							User:    user,
+							Type:    bundleType,
+							Format:  format,
+							BaseURL: "https://example.com/fhir",
+						},
+						w: &buf,
+					}
+
+					recordIDs := make([]ulid.ULID, 0, len(tt.records))
+					for i := range tt.records {
+						recordIDs = append(recordIDs, tt.records[i].ID)
+					}
+					if err := export.Begin(recordIDs); err != nil {
+						t.Fatal(err)
+					}
+					for i := range tt.records {
+						if err := export.Write(&tt.records[i]); err != nil {
+							t.Fatal(err)
+						}
+					}
+					if err := export.close(); err != nil {
+						t.Fatal(err)
+					}
+
+					resources := resourcesOf(t, v, format, buf.Bytes())
+
+					// the patient and three resources of every record, after
+					// the composition of a document
+					want := 1 + 3*len(tt.records)
+					if bundleType == resource.BundleDocument {
+						want++
+					}
+					if len(resources) != want {
+						t.Fatalf("exported %d resources, want %d", len(resources), want)
+					}
+
+					if bundleType == resource.BundleDocument {
+						if got := resources[0]["resourceType"]; got != resource.TypeComposition {
+							t.Errorf("first resource is %v, want %s", got, resource.TypeComposition)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// resourcesOf validates the bundle of a JSON export as a whole, or every line
+// of an NDJSON export, and returns the resources exported.
+func resourcesOf(t *testing.T, v *validator, format string, b []byte) []map[string]any {
+	t.Helper()
+
+	if format == resource.FormatJSON {
+		bundle, err := decodeResource(b)
+		if err != nil {
+			t.Fatalf("bundle is not JSON: %v", err)
+		}
+		for _, msg := range v.Validate(bundle) {
+			t.Error(msg)
+		}
+
+		fullURLs := make(map[string]bool)
+		resources := make([]map[string]any, 0)
+		for _, entry := range objects(bundle["entry"]) {
+			fullURL, _ := entry["fullUrl"].(string)
+			fullURLs[fullURL] = true
+			if obj, ok := entry["resource"].(map[string]any); ok {
+				resources = append(resources, obj)
+			}
+		}
+
+		// a document holds every resource its composition lists
+		if bundle["type"] == resource.BundleDocument && len(resources) > 0 {
+			composition := resources[0]
+			subject, _ := composition["subject"].(map[string]any)
+			references := []any{subject["reference"]}
+			for _, section := range objects(composition["section"]) {
+				for _, entry := range objects(section["entry"]) {
+					references = append(references, entry["reference"])
+				}
+			}
+			for _, reference := range references {
+				if s, _ := reference.(string); !fullURLs[s] {
+					t.Errorf("composition references %v, which is not in the bundle", reference)
+				}
+			}
+		}
+
+		return resources
+	}
+
+	resources := make([]map[string]any, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		obj, err := decodeResource(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("line %d is not JSON: %v", len(resources)+1, err)
+		}
+		for _, msg := range v.Validate(obj) {
+			t.Error(msg)
+		}
+		resources = append(resources, obj)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(b, []byte("\n")) {
+		t.Error("last line is not terminated")
+	}
+
+	return resources
+}
+
+func TestOperationOutcome(t *testing.T) {
+	v := testValidator(t)
+
+	for _, status := range []int{
+		http.StatusBadRequest,
+		http.StatusNotFound,
+		http.StatusGatewayTimeout,
+		http.StatusInternalServerError,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var buf bytes.Buffer
+			bundle, err := resource.NewBundleWriter(&buf, id.New(), resource.BundleCollection, resource.FormatNDJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = bundle.Add("", resource.NewOperationOutcome(status, "export failed")); err != nil {
+				t.Fatal(err)
+			}
+
+			obj, err := decodeResource(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, msg := range v.Validate(obj) {
+				t.Error(msg)
+			}
+		})
+	}
+}
+
+// failingRepository exports records, then waits for the export to time out.
+type failingRepository struct {
+	repository.FHIRRepositoryContract
+	records domain.MedicalRecords
+}
+
+func (r failingRepository) ExportRecords(
+	ctx context.Context,
+	_, _ string,
+	_ *domain.User,
+	writer repository.RecordWriter,
+) error {
+	recordIDs := make([]ulid.ULID, 0, len(r.records))
+	for i := range r.records {
+		recordIDs = append(recordIDs, r.records[i].ID)
+	}
+	if err := writer.Begin(recordIDs); err != nil {
+		return err
+	}
+	for i := range r.records {
+		if err := writer.Write(&r.records[i]); err != nil {
+			return err
+		}
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestExportPatientFails(t *testing.T) {
+	v := testValidator(t)
+	records := testRecords()
+
+	for _, format := range []string{resource.FormatJSON, resource.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			// the request timeout is shorter than the export, which is cut
+			// off by its own
+			s := NewFHIRService(time.Nanosecond, 50*time.Millisecond, failingRepository{records: records})
+
+			var buf bytes.Buffer
+			export := &Export{
+				Patient: domain.Patient{ID: "3201010101010001", Name: "Budi", Gender: "male"},
+				User:    domain.User{NIP: "6152000101001", Name: "Siti"},
+				Type:    resource.BundleCollection,
+				Format:  format,
+				BaseURL: "https://example.com/fhir",
+			}
+
+			err := s.ExportPatient(context.Background(), export, &buf)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("ExportPatient() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			if format == resource.FormatJSON {
+				if _, err = decodeResource(buf.Bytes()); err == nil {
+					t.Error("a failed JSON export is a whole bundle")
+				}
+				return
+			}
+
+			resources := resourcesOf(t, v, format, buf.Bytes())
+			if want := 2 + 3*len(records); len(resources) != want {
+				t.Fatalf("exported %d lines, want %d", len(resources), want)
+			}
+
+			last := resources[len(resources)-1]
+			issues := objects(last["issue"])
+			if last["resourceType"] != "OperationOutcome" || len(issues) != 1 || issues[0]["code"] != "timeout" {
+				t.Errorf("last line = %v, want an OperationOutcome of a timeout", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type FHIRService struct {
+	fhirRepository repository.FHIRRepositoryContract
+	contextTimeout time.Duration
+	exportTimeout  time.Duration
+}
+
+func NewFHIRService(
+	timeout time.Duration,
+	exportTimeout time.Duration,
+	fhirRepository repository.FHIRRepositoryContract,
+) *FHIRService {
+	return &FHIRService{
+		fhirRepository: fhirRepository,
+		contextTimeout: timeout,
+		exportTimeout:  exportTimeout,
+	}
+}
+
+func (s FHIRService) GetPatients(
+	ctx context.Context,
+	filter *domain.FilterPatient,
+	patients domain.Patients,
+) (domain.Patients, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[FHIRService.GetPatients]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	patients, total, err := s.fhirRepository.GetPatients(ctx, filter, patients)
+	if err != nil {
+		l.Error("failed to get patients", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return patients, total, nil
+}
+
+// GetPatient fills patient from its ID.
+func (s FHIRService) GetPatient(ctx context.Context, patient *domain.Patient) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[FHIRService.GetPatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterPatientAcquire()
+	defer domain.FilterPatientRelease(filter)
+
+	filter.ID = patient.ID
+	filter.Limit = 1
+
+	patients := domain.PatientsAcquire()
+	defer domain.PatientsRelease(patients)
+
+	patients, _, err := s.fhirRepository.GetPatients(ctx, filter, patients)
+	if err != nil {
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	if len(patients) == 0 {
+		return new(domain.ErrPatientNotFound)
+	}
+
+	*patient = patients[0]
+	return nil
+}
+
+func (s FHIRService) GetRecords(
+	ctx context.Context,
+	filter *domain.FilterFHIRRecord,
+	records domain.MedicalRecords,
+) (domain.MedicalRecords, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[FHIRService.GetRecords]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	records, total, err := s.fhirRepository.GetRecords(ctx, filter, records)
+	if err != nil {
+		l.Error("failed to get medical records", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
+
+// GetRecord fills record and its amendments from its ID.
+func (s FHIRService) GetRecord(ctx context.Context, record *domain.MedicalRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[FHIRService.GetRecord]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterFHIRRecordAcquire()
+	defer domain.FilterFHIRRecordRelease(filter)
+
+	filter.ID = record.ID
+	filter.Limit = 1
+
+	records := domain.MedicalRecordsAcquire()
+	defer domain.MedicalRecordsRelease(records)
+
+	records, _, err := s.fhirRepository.GetRecords(ctx, filter, records)
+	if err != nil {
+		l.Error("failed to get medical record", zap.Error(err))
+		return err
+	}
+
+	if len(records) == 0 {
+		return new(domain.ErrMedicalRecordNotFound)
+	}
+
+	*record = records[0]
+	return nil
+}
+
+// ExportPatient writes the patient of export and all of their records to w as
+// a bundle. The patient is expected to exist, so a failure comes after part
+// of the bundle has been written. An NDJSON export then ends with an
+// OperationOutcome, a JSON bundle is left unclosed for the caller to abort.
+func (s FHIRService) ExportPatient(ctx context.Context, export *Export, w io.Writer) error {
+	// the export streams the whole record, a request timeout would cut off
+	// any large one
+	ctx, cancel := context.WithTimeout(ctx, s.exportTimeout)
+	defer cancel()
+
+	callerInfo := "[FHIRService.ExportPatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	writer := &bundleExport{export: export, w: w}
+
+	err := s.fhirRepository.ExportRecords(ctx, export.Patient.ID, export.Type, &export.User, writer)
+	if err != nil {
+		l.Error("failed to export medical records", zap.Error(err))
+		if failErr := writer.fail(err); failErr != nil {
+			l.Error("failed to report the failed export", zap.Error(failErr))
+		}
+		return err
+	}
+
+	if err = writer.close(); err != nil {
+		l.Error("failed to close bundle", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+var _ FHIRServiceContract = (*FHIRService)(nil)
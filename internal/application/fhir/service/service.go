@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type FHIRServiceContract interface {
+	GetPatients(ctx context.Context, filter *domain.FilterPatient, patients domain.Patients) (domain.Patients, int, error)
+	GetPatient(ctx context.Context, patient *domain.Patient) error
+	GetRecords(
+		ctx context.Context,
+		filter *domain.FilterFHIRRecord,
+		records domain.MedicalRecords,
+	) (domain.MedicalRecords, int, error)
+	GetRecord(ctx context.Context, record *domain.MedicalRecord) error
+	ExportPatient(ctx context.Context, export *Export, w io.Writer) error
+}
@@ -0,0 +1,654 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The definitions in testdata/r4 are the elements, cardinalities, types,
+// required bindings and invariants of the R4 (4.0.1) core definitions of the
+// resources and data types the API emits, without their narrative and
+// mappings. validator checks a resource against them the way the reference
+// validator does for the structure, it does not evaluate FHIRPath, every
+// invariant a definition declares has its check in invariants instead.
+
+const regexExtension = "http://hl7.org/fhir/StructureDefinition/regex"
+
+type elementType struct {
+	Code      string `json:"code"`
+	Extension []struct {
+		URL         string `json:"url"`
+		ValueString string `json:"valueString"`
+	} `json:"extension"`
+}
+
+type elementDefinition struct {
+	Path             string        `json:"path"`
+	Min              int           `json:"min"`
+	Max              string        `json:"max"`
+	Type             []elementType `json:"type"`
+	ContentReference string        `json:"contentReference"`
+	Binding          *struct {
+		Strength string `json:"strength"`
+		ValueSet string `json:"valueSet"`
+	} `json:"binding"`
+	Constraint []struct {
+		Key        string `json:"key"`
+		Severity   string `json:"severity"`
+		Human      string `json:"human"`
+		Expression string `json:"expression"`
+	} `json:"constraint"`
+}
+
+type structureDefinition struct {
+	Kind     string `json:"kind"`
+	Type     string `json:"type"`
+	Snapshot struct {
+		Element []elementDefinition `json:"element"`
+	} `json:"snapshot"`
+
+	elements map[string]*elementDefinition
+	// children lists the element paths under a path, in order
+	children map[string][]string
+	regex    *regexp.Regexp
+}
+
+type valueSet struct {
+	URL     string `json:"url"`
+	Compose struct {
+		Include []struct {
+			System  string `json:"system"`
+			Concept []struct {
+				Code string `json:"code"`
+			} `json:"concept"`
+		} `json:"include"`
+	} `json:"compose"`
+}
+
+func (v *valueSet) contains(system, code string) bool {
+	for _, include := range v.Compose.Include {
+		if system != "" && system != include.System {
+			continue
+		}
+		for _, concept := range include.Concept {
+			if concept.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type validator struct {
+	structures map[string]*structureDefinition
+	valueSets  map[string]*valueSet
+}
+
+// invariants checks the invariants of the definitions by key, on the object
+// of the element that declares them.
+var invariants = map[string]func(obj map[string]any) bool{
+	"bdl-1": func(obj map[string]any) bool {
+		return obj["total"] == nil || obj["type"] == "searchset" || obj["type"] == "history"
+	},
+	"bdl-2": func(obj map[string]any) bool {
+		if obj["type"] == "searchset" {
+			return true
+		}
+		for _, entry := range objects(obj["entry"]) {
+			if entry["search"] != nil {
+				return false
+			}
+		}
+		return true
+	},
+	"bdl-7": func(obj map[string]any) bool {
+		if obj["type"] == "history" {
+			return true
+		}
+		seen := make(map[string]bool)
+		for _, entry := range objects(obj["entry"]) {
+			fullURL, ok := entry["fullUrl"].(string)
+			if !ok {
+				continue
+			}
+			if seen[fullURL] {
+				return false
+			}
+			seen[fullURL] = true
+		}
+		return true
+	},
+	"bdl-9": func(obj map[string]any) bool {
+		if obj["type"] != "document" {
+			return true
+		}
+		identifier, _ := obj["identifier"].(map[string]any)
+		return identifier["system"] != nil && identifier["value"] != nil
+	},
+	"bdl-10": func(obj map[string]any) bool {
+		return obj["type"] != "document" || obj["timestamp"] != nil
+	},
+	"bdl-11": func(obj map[string]any) bool {
+		return obj["type"] != "document" || firstResourceType(obj) == "Composition"
+	},
+	"bdl-12": func(obj map[string]any) bool {
+		return obj["type"] != "message" || firstResourceType(obj) == "MessageHeader"
+	},
+	"con-5": func(obj map[string]any) bool {
+		verificationStatus, _ := obj["verificationStatus"].(map[string]any)
+		for _, coding := range objects(verificationStatus["coding"]) {
+			if coding["system"] == "http://terminology.hl7.org/CodeSystem/condition-ver-status" &&
+				coding["code"] == "entered-in-error" {
+				return obj["clinicalStatus"] == nil
+			}
+		}
+		return true
+	},
+	"obs-6": func(obj map[string]any) bool {
+		if obj["dataAbsentReason"] == nil {
+			return true
+		}
+		for key := range obj {
+			if strings.HasPrefix(key, "value") {
+				return false
+			}
+		}
+		return true
+	},
+	"cmp-1": func(obj map[string]any) bool {
+		return allSections(obj, func(section map[string]any) bool {
+			return section["text"] != nil || section["entry"] != nil || section["section"] != nil
+		})
+	},
+	"cmp-2": func(obj map[string]any) bool {
+		return allSections(obj, func(section map[string]any) bool {
+			return section["emptyReason"] == nil || section["entry"] == nil
+		})
+	},
+	"ref-1": func(obj map[string]any) bool {
+		// nothing the API emits is contained, so no local reference resolves
+		reference, _ := obj["reference"].(string)
+		return !strings.HasPrefix(reference, "#")
+	},
+	"cpt-2": func(obj map[string]any) bool {
+		return obj["value"] == nil || obj["system"] != nil
+	},
+	"per-1": func(obj map[string]any) bool {
+		start, _ := obj["start"].(string)
+		end, _ := obj["end"].(string)
+		// the instants the API emits share their format, so they sort as text
+		return start == "" || end == "" || start <= end
+	},
+}
+
+func objects(v any) []map[string]any {
+	list, _ := v.([]any)
+	objs := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if obj, ok := item.(map[string]any); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+func firstResourceType(bundle map[string]any) any {
+	entries := objects(bundle["entry"])
+	if len(entries) == 0 {
+		return nil
+	}
+	resource, _ := entries[0]["resource"].(map[string]any)
+	return resource["resourceType"]
+}
+
+func allSections(obj map[string]any, check func(section map[string]any) bool) bool {
+	for _, section := range objects(obj["section"]) {
+		if !check(section) || !allSections(section, check) {
+			return false
+		}
+	}
+	return true
+}
+
+func loadValidator(dir string) (*validator, error) {
+	v := &validator{
+		structures: make(map[string]*structureDefinition),
+		valueSets:  make(map[string]*valueSet),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = v.add(b); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+
+	return v, nil
+}
+
+// add reads a StructureDefinition, a ValueSet or a Bundle of them.
+func (v *validator) add(b []byte) error {
+	var header struct {
+		ResourceType string `json:"resourceType"`
+		Entry        []struct {
+			Resource json.RawMessage `json:"resource"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return err
+	}
+
+	switch header.ResourceType {
+	case "Bundle":
+		for _, entry := range header.Entry {
+			if err := v.add(entry.Resource); err != nil {
+				return err
+			}
+		}
+	case "ValueSet":
+		vs := new(valueSet)
+		if err := json.Unmarshal(b, vs); err != nil {
+			return err
+		}
+		v.valueSets[vs.URL] = vs
+	case "StructureDefinition":
+		sd := new(structureDefinition)
+		if err := json.Unmarshal(b, sd); err != nil {
+			return err
+		}
+
+		sd.elements = make(map[string]*elementDefinition)
+		sd.children = make(map[string][]string)
+		for i := range sd.Snapshot.Element {
+			e := &sd.Snapshot.Element[i]
+			sd.elements[e.Path] = e
+			if parent, _, ok := cutLast(e.Path); ok {
+				sd.children[parent] = append(sd.children[parent], e.Path)
+			}
+			for _, c := range e.Constraint {
+				if _, ok := invariants[c.Key]; !ok {
+					return fmt.Errorf("%s declares %s, which has no check", e.Path, c.Key)
+				}
+			}
+		}
+
+		if sd.Kind == "primitive-type" {
+			if value, ok := sd.elements[sd.Type+".value"]; ok {
+				for _, ext := range value.Type[0].Extension {
+					if ext.URL == regexExtension {
+						sd.regex = regexp.MustCompile(`^(?:` + ext.ValueString + `)$`)
+					}
+				}
+			}
+		}
+
+		v.structures[sd.Type] = sd
+	default:
+		return fmt.Errorf("unexpected %s", header.ResourceType)
+	}
+
+	return nil
+}
+
+func cutLast(path string) (string, string, bool) {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return "", "", false
+	}
+	return path[:i], path[i+1:], true
+}
+
+// decodeResource decodes b keeping numbers as they were written, so they are
+// checked against the regex of their type.
+func decodeResource(b []byte) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Validate returns every way obj breaks the definition of its resourceType.
+func (v *validator) Validate(obj map[string]any) []string {
+	var errs []string
+	v.resource(obj, "", &errs)
+	return errs
+}
+
+func (v *validator) resource(obj map[string]any, location string, errs *[]string) {
+	resourceType, _ := obj["resourceType"].(string)
+	sd, ok := v.structures[resourceType]
+	if !ok || sd.Kind != "resource" {
+		*errs = append(*errs, fmt.Sprintf("%s: no definition of resource %q", location, resourceType))
+		return
+	}
+
+	if location == "" {
+		location = resourceType
+	}
+	v.object(sd, resourceType, obj, location, errs)
+}
+
+// object checks the elements of obj against the children of path in sd.
+func (v *validator) object(sd *structureDefinition, path string, obj map[string]any, location string, errs *[]string) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, location+": "+fmt.Sprintf(format, args...))
+	}
+
+	for key, value := range obj {
+		if key == "resourceType" && path == sd.Type && sd.Kind == "resource" {
+			continue
+		}
+
+		e, typeCode := sd.child(path, key)
+		if e == nil {
+			fail("unknown element %q", key)
+			continue
+		}
+
+		if e.Max == "*" {
+			list, ok := value.([]any)
+			if !ok {
+				fail("%s must be an array", key)
+				continue
+			}
+			if len(list) == 0 {
+				fail("%s must not be empty", key)
+			}
+			for i, item := range list {
+				v.value(sd, e, typeCode, item, fmt.Sprintf("%s.%s[%d]", location, key, i), errs)
+			}
+			continue
+		}
+
+		if _, ok := value.([]any); ok {
+			fail("%s must not be an array", key)
+			continue
+		}
+		v.value(sd, e, typeCode, value, location+"."+key, errs)
+	}
+
+	for _, childPath := range sd.children[path] {
+		e := sd.elements[childPath]
+		if e.Min == 0 {
+			continue
+		}
+
+		_, name, _ := cutLast(childPath)
+		present := obj[name] != nil
+		if choice, ok := strings.CutSuffix(name, "[x]"); ok {
+			for key := range obj {
+				if strings.HasPrefix(key, choice) {
+					if found, _ := sd.child(path, key); found == e {
+						present = true
+					}
+				}
+			}
+		}
+		if !present {
+			fail("%s is required", name)
+		}
+	}
+
+	if e, ok := sd.elements[path]; ok {
+		for _, c := range e.Constraint {
+			if c.Severity == "error" && !invariants[c.Key](obj) {
+				fail("%s: %s", c.Key, c.Human)
+			}
+		}
+	}
+}
+
+// child finds the element of key under path, and the type it takes there.
+func (sd *structureDefinition) child(path, key string) (*elementDefinition, string) {
+	if e, ok := sd.elements[path+"."+key]; ok {
+		if e.ContentReference != "" {
+			return e, ""
+		}
+		if len(e.Type) == 1 {
+			return e, e.Type[0].Code
+		}
+	}
+
+	for _, childPath := range sd.children[path] {
+		_, name, _ := cutLast(childPath)
+		choice, ok := strings.CutSuffix(name, "[x]")
+		if !ok || !strings.HasPrefix(key, choice) {
+			continue
+		}
+		e := sd.elements[childPath]
+		suffix := key[len(choice):]
+		if suffix == "" {
+			continue
+		}
+		for _, t := range e.Type {
+			if strings.EqualFold(t.Code[:1], suffix[:1]) && t.Code[1:] == suffix[1:] {
+				return e, t.Code
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+func (v *validator) value(
+	sd *structureDefinition,
+	e *elementDefinition,
+	typeCode string,
+	value any,
+	location string,
+	errs *[]string,
+) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, location+": "+fmt.Sprintf(format, args...))
+	}
+
+	if e.ContentReference != "" {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		v.object(sd, strings.TrimPrefix(e.ContentReference, "#"), obj, location, errs)
+		return
+	}
+
+	switch typeCode {
+	case "BackboneElement":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		v.object(sd, e.Path, obj, location, errs)
+		return
+	case "Resource":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		v.resource(obj, location, errs)
+		return
+	case "xhtml":
+		div, _ := value.(string)
+		if !strings.HasPrefix(div, `<div xmlns="http://www.w3.org/1999/xhtml">`) || !strings.HasSuffix(div, "</div>") {
+			fail("must be an XHTML div")
+		}
+		return
+	}
+
+	typeSD, ok := v.structures[typeCode]
+	if !ok {
+		fail("no definition of type %q", typeCode)
+		return
+	}
+
+	if typeSD.Kind == "primitive-type" {
+		var text string
+		switch value := value.(type) {
+		case string:
+			if typeCode == "boolean" || slices.Contains(numberTypes, typeCode) {
+				fail("%s must not be a string", typeCode)
+				return
+			}
+			text = value
+		case json.Number:
+			if !slices.Contains(numberTypes, typeCode) {
+				fail("%s must not be a number", typeCode)
+				return
+			}
+			text = value.String()
+		case bool:
+			if typeCode != "boolean" {
+				fail("%s must not be a boolean", typeCode)
+				return
+			}
+			text = fmt.Sprint(value)
+		default:
+			fail("%s must be a JSON primitive", typeCode)
+			return
+		}
+
+		if typeSD.regex != nil && !typeSD.regex.MatchString(text) {
+			fail("%q is not a valid %s", text, typeCode)
+		}
+
+		if e.Binding != nil && e.Binding.Strength == "required" && typeCode == "code" {
+			if vs := v.valueSet(e.Binding.ValueSet); vs == nil {
+				fail("no value set %s", e.Binding.ValueSet)
+			} else if !vs.contains("", text) {
+				fail("%q is not in %s", text, vs.URL)
+			}
+		}
+		return
+	}
+
+	obj, ok := value.(map[string]any)
+	if !ok {
+		fail("%s must be an object", typeCode)
+		return
+	}
+	v.object(typeSD, typeCode, obj, location, errs)
+
+	// a required binding of a concept needs a coding from the value set
+	if e.Binding != nil && e.Binding.Strength == "required" && typeCode == "CodeableConcept" {
+		vs := v.valueSet(e.Binding.ValueSet)
+		if vs == nil {
+			fail("no value set %s", e.Binding.ValueSet)
+			return
+		}
+		for _, coding := range objects(obj["coding"]) {
+			system, _ := coding["system"].(string)
+			code, _ := coding["code"].(string)
+			if vs.contains(system, code) {
+				return
+			}
+		}
+		fail("has no coding from %s", vs.URL)
+	}
+}
+
+var numberTypes = []string{"integer", "unsignedInt", "positiveInt", "decimal"}
+
+func (v *validator) valueSet(canonical string) *valueSet {
+	url, _, _ := strings.Cut(canonical, "|")
+	return v.valueSets[url]
+}
+
+func TestValidator(t *testing.T) {
+	v := testValidator(t)
+
+	tests := []struct {
+		name     string
+		resource string
+		want     string
+	}{
+		{
+			name:     "unknown element",
+			resource: `{"resourceType":"Patient","id":"1","nickname":"a"}`,
+			want:     `unknown element "nickname"`,
+		},
+		{
+			name:     "missing required element",
+			resource: `{"resourceType":"Observation","code":{"text":"a"}}`,
+			want:     "status is required",
+		},
+		{
+			name:     "code outside the value set",
+			resource: `{"resourceType":"Patient","gender":"M"}`,
+			want:     `"M" is not in http://hl7.org/fhir/ValueSet/administrative-gender`,
+		},
+		{
+			name:     "single element as an array",
+			resource: `{"resourceType":"Patient","birthDate":["2000-01-01"]}`,
+			want:     "birthDate must not be an array",
+		},
+		{
+			name:     "repeating element as an object",
+			resource: `{"resourceType":"Patient","name":{"text":"a"}}`,
+			want:     "name must be an array",
+		},
+		{
+			name:     "malformed primitive",
+			resource: `{"resourceType":"Patient","birthDate":"01-01-2000"}`,
+			want:     `"01-01-2000" is not a valid date`,
+		},
+		{
+			name:     "empty string",
+			resource: `{"resourceType":"Patient","identifier":[{"value":""}]}`,
+			want:     `"" is not a valid string`,
+		},
+		{
+			name:     "choice of a type it does not take",
+			resource: `{"resourceType":"MedicationStatement","status":"unknown","subject":{},"medicationString":"a"}`,
+			want:     `unknown element "medicationString"`,
+		},
+		{
+			name:     "concept without a coding from the value set",
+			resource: `{"resourceType":"Condition","subject":{},"verificationStatus":{"text":"provisional"}}`,
+			want:     "has no coding from http://hl7.org/fhir/ValueSet/condition-ver-status",
+		},
+		{
+			name:     "invariant",
+			resource: `{"resourceType":"Bundle","type":"document","entry":[{"resource":{"resourceType":"OperationOutcome","issue":[{"severity":"error","code":"exception"}]}}]}`,
+			want:     "bdl-9",
+		},
+		{
+			name:     "contained resource",
+			resource: `{"resourceType":"Bundle","type":"collection","entry":[{"resource":{"resourceType":"OperationOutcome"}}]}`,
+			want:     "Bundle.entry[0].resource: issue is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := decodeResource([]byte(tt.resource))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errs := v.Validate(obj)
+			if !slices.ContainsFunc(errs, func(err string) bool { return strings.Contains(err, tt.want) }) {
+				t.Errorf("Validate() = %q, want an error containing %q", errs, tt.want)
+			}
+		})
+	}
+}
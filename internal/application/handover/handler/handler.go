@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/handover/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const handoverIDFromParam = "id"
+
+type handoverHandler struct {
+	handoverService service.HandoverServiceContract
+}
+
+func NewHandoverHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	handoverService service.HandoverServiceContract,
+) {
+	handler := handoverHandler{
+		handoverService: handoverService,
+	}
+
+	handoverRouter := router.Group("/handover", jwtMiddleware)
+	handoverRouter.Post("", handler.CreateHandover)
+	handoverRouter.Get("", handler.GetHandovers)
+	handoverRouter.Get("/pending", handler.GetPendingHandovers)
+	handoverRouter.Post("/:"+handoverIDFromParam+"/acknowledge", handler.AcknowledgeHandover)
+}
+
+func (h handoverHandler) CreateHandover(c *fiber.Ctx) error {
+	callerInfo := "[handoverHandler.CreateHandover]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createHandoverReqAcquire()
+	defer createHandoverReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	if req.toNurseID == user.ID {
+		l.Error("handover to self", zap.String("userID", user.ID.String()))
+		return errBadRequest{err: errors.New("toNurseId must be another nurse")}
+	}
+
+	handover := domain.HandoverAcquire()
+	defer domain.HandoverRelease(handover)
+
+	handover.PatientID = string(*req.IdentityNumber)
+	handover.ToNurseID = req.toNurseID
+	handover.Situation = req.Situation
+	handover.Background = req.Background
+	handover.Assessment = req.Assessment
+	handover.Recommendation = req.Recommendation
+
+	err := h.handoverService.CreateHandover(userCtx, handover, user)
+	if err != nil {
+		l.Error("failed to create handover", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Handover created successfully"
+	res.Data = newHandoverRes(handover)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h handoverHandler) AcknowledgeHandover(c *fiber.Ctx) error {
+	callerInfo := "[handoverHandler.AcknowledgeHandover]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	handoverID, err := ulid.Parse(c.Params(handoverIDFromParam))
+	if err != nil {
+		l.Error("error parsing handoverIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	handover := domain.HandoverAcquire()
+	defer domain.HandoverRelease(handover)
+
+	handover.ID = handoverID
+
+	err = h.handoverService.AcknowledgeHandover(userCtx, handover, user)
+	if err != nil {
+		l.Error("failed to acknowledge handover", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Handover acknowledged successfully"
+	res.Data = newHandoverRes(handover)
+
+	return c.JSON(res)
+}
+
+func (h handoverHandler) GetHandovers(c *fiber.Ctx) error {
+	callerInfo := "[handoverHandler.GetHandovers]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryHandoverAcquire()
+	defer queryHandoverRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterHandoverAcquire()
+	defer domain.FilterHandoverRelease(filter)
+
+	filter.PatientID = query.patientID
+	filter.FromNurseID = query.fromNurseID
+	filter.ToNurseID = query.toNurseID
+	filter.PendingOnly = query.Pending
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	return h.getHandovers(c, l, filter)
+}
+
+// GetPendingHandovers lists the handovers still waiting for the logged-in
+// nurse to acknowledge them, oldest first.
+func (h handoverHandler) GetPendingHandovers(c *fiber.Ctx) error {
+	callerInfo := "[handoverHandler.GetPendingHandovers]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryHandoverAcquire()
+	defer queryHandoverRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterHandoverAcquire()
+	defer domain.FilterHandoverRelease(filter)
+
+	filter.ToNurseID = c.Locals(domain.UserFromToken).(domain.User).ID
+	filter.PendingOnly = true
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	return h.getHandovers(c, l, filter)
+}
+
+func (h handoverHandler) getHandovers(c *fiber.Ctx, l *zap.Logger, filter *domain.FilterHandover) error {
+	handovers := domain.HandoversAcquire()
+	defer domain.HandoversRelease(handovers)
+
+	handovers, err := h.handoverService.GetHandovers(c.UserContext(), filter, handovers)
+	if err != nil {
+		l.Error("failed to get handovers", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Handovers retrieved successfully"
+
+	handoversRes := getHandoversResAcquire()
+	defer getHandoversResRelease(handoversRes)
+
+	for i := range handovers {
+		handoversRes = append(handoversRes, newHandoverRes(&handovers[i]))
+	}
+
+	res.Data = handoversRes
+
+	return c.JSON(res)
+}
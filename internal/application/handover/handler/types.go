@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	dateFormat = "2006-01-02T15:04:05.999Z"
+
+	handoversInitCap = 5
+	sbarMaxLength    = 2000
+)
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+var createHandoverReqPool = sync.Pool{
+	New: func() any {
+		return new(createHandoverReq)
+	},
+}
+
+func createHandoverReqAcquire() *createHandoverReq {
+	return createHandoverReqPool.Get().(*createHandoverReq)
+}
+
+func createHandoverReqRelease(t *createHandoverReq) {
+	*t = createHandoverReq{}
+	createHandoverReqPool.Put(t)
+}
+
+// createHandoverReq follows SBAR: situation, background, assessment and
+// recommendation are all required.
+type createHandoverReq struct {
+	IdentityNumber *idNumber `json:"identityNumber"`
+	ToNurseID      string    `json:"toNurseId"`
+	toNurseID      ulid.ULID
+	Situation      string `json:"situation"`
+	Background     string `json:"background"`
+	Assessment     string `json:"assessment"`
+	Recommendation string `json:"recommendation"`
+}
+
+func (r *createHandoverReq) validate() error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	toNurseID, err := ulid.Parse(r.ToNurseID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("toNurseId must be a valid user id"))
+	}
+	r.toNurseID = toNurseID
+
+	errs = multierr.Append(errs, validateSBAR("situation", r.Situation))
+	errs = multierr.Append(errs, validateSBAR("background", r.Background))
+	errs = multierr.Append(errs, validateSBAR("assessment", r.Assessment))
+	errs = multierr.Append(errs, validateSBAR("recommendation", r.Recommendation))
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+func validateSBAR(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", field)
+	} else if len(value) > sbarMaxLength {
+		return fmt.Errorf("%s must have 1 to %d characters", field, sbarMaxLength)
+	}
+
+	return nil
+}
+
+var queryHandoverPool = sync.Pool{
+	New: func() any {
+		return new(queryHandover)
+	},
+}
+
+func queryHandoverAcquire() *queryHandover {
+	return queryHandoverPool.Get().(*queryHandover)
+}
+
+func queryHandoverRelease(t *queryHandover) {
+	*t = queryHandover{}
+	queryHandoverPool.Put(t)
+}
+
+type queryHandover struct {
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	FromNurseID    string `query:"fromNurseId"`
+	fromNurseID    ulid.ULID
+	ToNurseID      string `query:"toNurseId"`
+	toNurseID      ulid.ULID
+	Pending        bool `query:"pending"`
+	Limit          int  `query:"limit"`
+	Offset         int  `query:"offset"`
+}
+
+func (q *queryHandover) validate() {
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.FromNurseID != "" {
+		q.fromNurseID, _ = ulid.Parse(q.FromNurseID)
+	}
+
+	if q.ToNurseID != "" {
+		q.toNurseID, _ = ulid.Parse(q.ToNurseID)
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+type handoverNurseRes struct {
+	UserID ulid.ULID `json:"userId"`
+	Name   string    `json:"name"`
+}
+
+type handoverPatientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+}
+
+type handoverRes struct {
+	HandoverID     ulid.ULID          `json:"handoverId"`
+	Patient        handoverPatientRes `json:"patient"`
+	FromNurse      handoverNurseRes   `json:"fromNurse"`
+	ToNurse        handoverNurseRes   `json:"toNurse"`
+	Situation      string             `json:"situation"`
+	Background     string             `json:"background"`
+	Assessment     string             `json:"assessment"`
+	Recommendation string             `json:"recommendation"`
+	Acknowledged   bool               `json:"acknowledged"`
+	AcknowledgedAt string             `json:"acknowledgedAt,omitempty"`
+	CreatedAt      string             `json:"createdAt"`
+}
+
+func newHandoverRes(handover *domain.Handover) handoverRes {
+	res := handoverRes{
+		HandoverID: handover.ID,
+		Patient: handoverPatientRes{
+			IdentityNumber: idNumber(handover.PatientID),
+			Name:           handover.PatientName,
+		},
+		FromNurse: handoverNurseRes{
+			UserID: handover.FromNurseID,
+			Name:   handover.FromNurseName,
+		},
+		ToNurse: handoverNurseRes{
+			UserID: handover.ToNurseID,
+			Name:   handover.ToNurseName,
+		},
+		Situation:      handover.Situation,
+		Background:     handover.Background,
+		Assessment:     handover.Assessment,
+		Recommendation: handover.Recommendation,
+		CreatedAt:      handover.CreatedAt.Format(dateFormat),
+	}
+	if !handover.AcknowledgedAt.IsZero() {
+		res.Acknowledged = true
+		res.AcknowledgedAt = handover.AcknowledgedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+var getHandoversResPool = sync.Pool{
+	New: func() any {
+		return make(getHandoversRes, 0, handoversInitCap)
+	},
+}
+
+func getHandoversResAcquire() getHandoversRes {
+	return getHandoversResPool.Get().(getHandoversRes)
+}
+
+func getHandoversResRelease(t getHandoversRes) {
+	t = t[:0]
+	getHandoversResPool.Put(t) // nolint:staticcheck
+}
+
+type getHandoversRes []handoverRes
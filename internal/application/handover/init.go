@@ -0,0 +1,21 @@
+package handover
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/handover/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/handover/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/handover/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	handoverRepository := repository.NewHandoverRepository(db)
+	handoverService := service.NewHandoverService(ctxTimeout, handoverRepository)
+	handler.NewHandoverHandler(router, jwtMiddleware, handoverService)
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	handoverColumns = `h.id, h.patient_id, p.name, h.from_nurse_id, f.name, h.to_nurse_id, t.name, 
+		h.situation, h.background, h.assessment, h.recommendation, h.created_at, h.acknowledged_at`
+	handoverTables = ` FROM handovers h 
+		JOIN patients p ON p.id = h.patient_id 
+		JOIN users f ON f.id = h.from_nurse_id 
+		JOIN users t ON t.id = h.to_nurse_id`
+)
+
+type HandoverRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHandoverRepository(db *pgxpool.Pool) *HandoverRepository {
+	return &HandoverRepository{db: db}
+}
+
+// CreateHandover saves a handover from user to handover.ToNurseID, the
+// incoming nurse must be a registered nurse.
+func (r HandoverRepository) CreateHandover(ctx context.Context, handover *domain.Handover, user *domain.User) error {
+	callerInfo := "[HandoverRepository.CreateHandover]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	handover.ID = id.New()
+	handover.FromNurseID = user.ID
+	handover.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO handovers (
+				id, patient_id, from_nurse_id, to_nurse_id, situation, background, assessment, recommendation, created_at
+			)
+			SELECT @id, @patient_id, @from_nurse_id, u.id, @situation, @background, @assessment, @recommendation, 
+				@created_at
+			FROM users u WHERE u.id = @to_nurse_id AND u.nip LIKE '303%'
+			RETURNING patient_id, from_nurse_id, to_nurse_id
+		)
+		SELECT p.name, f.name, t.name FROM inserted i 
+		JOIN patients p ON p.id = i.patient_id 
+		JOIN users f ON f.id = i.from_nurse_id 
+		JOIN users t ON t.id = i.to_nurse_id`
+	args := pgx.NamedArgs{
+		"id":             handover.ID,
+		"patient_id":     handover.PatientID,
+		"from_nurse_id":  handover.FromNurseID,
+		"to_nurse_id":    handover.ToNurseID,
+		"situation":      handover.Situation,
+		"background":     handover.Background,
+		"assessment":     handover.Assessment,
+		"recommendation": handover.Recommendation,
+		"created_at":     handover.CreatedAt,
+	}
+
+	err = tx.QueryRow(ctx, insertQuery, args).Scan(
+		&handover.PatientName,
+		&handover.FromNurseName,
+		&handover.ToNurseName,
+	)
+	if err != nil {
+		l.Error("failed to create handover", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrUserNotFound)
+		}
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return new(domain.ErrPatientNotFound)
+		}
+
+		return err
+	}
+
+	if err = r.insertEvent(ctx, tx, handover, domain.PatientEventHandoverCreated, user); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r HandoverRepository) AcknowledgeHandover(
+	ctx context.Context,
+	handover *domain.Handover,
+	user *domain.User,
+) error {
+	callerInfo := "[HandoverRepository.AcknowledgeHandover]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	selectQuery := `SELECT ` + handoverColumns + handoverTables + ` WHERE h.id = @id FOR UPDATE OF h`
+
+	err = r.scanHandover(tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": handover.ID}), handover)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrHandoverNotFound)
+		}
+
+		l.Error("failed to get handover", zap.Error(err))
+		return err
+	}
+
+	if handover.ToNurseID != user.ID {
+		return new(domain.ErrHandoverNotRecipient)
+	}
+
+	if !handover.AcknowledgedAt.IsZero() {
+		return new(domain.ErrHandoverAcknowledged)
+	}
+
+	handover.AcknowledgedAt = time.Now()
+
+	updateQuery := `UPDATE handovers SET acknowledged_at = @acknowledged_at WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":              handover.ID,
+		"acknowledged_at": handover.AcknowledgedAt,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to acknowledge handover", zap.Error(err))
+		return err
+	}
+
+	if err = r.insertEvent(ctx, tx, handover, domain.PatientEventHandoverAcknowledged, user); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r HandoverRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	handover *domain.Handover,
+	eventType string,
+	user *domain.User,
+) error {
+	event, err := patientevent.New(handover.PatientID, eventType, user, map[string]string{
+		"handoverId":    handover.ID.String(),
+		"fromNurseName": handover.FromNurseName,
+		"toNurseName":   handover.ToNurseName,
+	})
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+func (r HandoverRepository) GetHandovers(
+	ctx context.Context,
+	filter *domain.FilterHandover,
+	handovers domain.Handovers,
+) (domain.Handovers, error) {
+	callerInfo := "[HandoverRepository.GetHandovers]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterHandover(filter)
+	getQuery := `SELECT ` + handoverColumns + handoverTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get handovers", zap.Error(err))
+		return handovers, err
+	}
+	defer rows.Close()
+
+	dHandover := domain.HandoverAcquire()
+	defer domain.HandoverRelease(dHandover)
+
+	for rows.Next() {
+		if err = r.scanHandover(rows, dHandover); err != nil {
+			l.Error("failed to scan handover", zap.Error(err))
+			return handovers, err
+		}
+		handovers = append(handovers, *dHandover)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get handovers", zap.Error(err))
+		return handovers, err
+	}
+
+	return handovers, nil
+}
+
+func (r HandoverRepository) scanHandover(row pgx.Row, handover *domain.Handover) error {
+	var acknowledgedAt *time.Time
+
+	err := row.Scan(
+		&handover.ID,
+		&handover.PatientID,
+		&handover.PatientName,
+		&handover.FromNurseID,
+		&handover.FromNurseName,
+		&handover.ToNurseID,
+		&handover.ToNurseName,
+		&handover.Situation,
+		&handover.Background,
+		&handover.Assessment,
+		&handover.Recommendation,
+		&handover.CreatedAt,
+		&acknowledgedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	handover.AcknowledgedAt = time.Time{}
+	if acknowledgedAt != nil {
+		handover.AcknowledgedAt = *acknowledgedAt
+	}
+
+	return nil
+}
+
+func (r HandoverRepository) filterHandover(filter *domain.FilterHandover) (string, pgx.NamedArgs) {
+	const totalConditions = 4
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "h.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.FromNurseID) {
+		conditions = append(conditions, "h.from_nurse_id = @from_nurse_id")
+		params["from_nurse_id"] = filter.FromNurseID
+	}
+
+	if !id.IsZero(filter.ToNurseID) {
+		conditions = append(conditions, "h.to_nurse_id = @to_nurse_id")
+		params["to_nurse_id"] = filter.ToNurseID
+	}
+
+	// pending handovers are read oldest first so nothing waits at the bottom
+	order := " ORDER BY h.created_at DESC"
+	if filter.PendingOnly {
+		conditions = append(conditions, "h.acknowledged_at IS NULL")
+		order = " ORDER BY h.created_at ASC"
+	}
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ HandoverRepositoryContract = (*HandoverRepository)(nil)
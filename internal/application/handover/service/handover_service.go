@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/handover/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type HandoverService struct {
+	handoverRepository repository.HandoverRepositoryContract
+	contextTimeout     time.Duration
+}
+
+func NewHandoverService(
+	timeout time.Duration,
+	handoverRepository repository.HandoverRepositoryContract,
+) *HandoverService {
+	return &HandoverService{
+		handoverRepository: handoverRepository,
+		contextTimeout:     timeout,
+	}
+}
+
+func (s HandoverService) CreateHandover(ctx context.Context, handover *domain.Handover, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[HandoverService.CreateHandover]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.handoverRepository.CreateHandover(ctx, handover, user)
+	if err != nil {
+		l.Error("failed to create handover", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s HandoverService) AcknowledgeHandover(
+	ctx context.Context,
+	handover *domain.Handover,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[HandoverService.AcknowledgeHandover]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.handoverRepository.AcknowledgeHandover(ctx, handover, user)
+	if err != nil {
+		l.Error("failed to acknowledge handover", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s HandoverService) GetHandovers(
+	ctx context.Context,
+	filter *domain.FilterHandover,
+	handovers domain.Handovers,
+) (domain.Handovers, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[HandoverService.GetHandovers]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	handovers, err := s.handoverRepository.GetHandovers(ctx, filter, handovers)
+	if err != nil {
+		l.Error("failed to get handovers", zap.Error(err))
+		return nil, err
+	}
+
+	return handovers, nil
+}
+
+var _ HandoverServiceContract = (*HandoverService)(nil)
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type HandoverServiceContract interface {
+	CreateHandover(ctx context.Context, handover *domain.Handover, user *domain.User) error
+	AcknowledgeHandover(ctx context.Context, handover *domain.Handover, user *domain.User) error
+	GetHandovers(
+		ctx context.Context,
+		filter *domain.FilterHandover,
+		handovers domain.Handovers,
+	) (domain.Handovers, error)
+}
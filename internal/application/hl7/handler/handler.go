@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const messageIDFromParam = "id"
+
+type hl7Handler struct {
+	hl7Service service.HL7ServiceContract
+}
+
+func NewHL7Handler(router fiber.Router, jwtMiddleware fiber.Handler, hl7Service service.HL7ServiceContract) {
+	handler := hl7Handler{
+		hl7Service: hl7Service,
+	}
+
+	hl7Router := router.Group("/hl7", jwtMiddleware, access.ITStaff)
+	hl7Router.Get("/rejected", handler.GetRejected)
+	hl7Router.Post("/rejected/:"+messageIDFromParam+"/replay", handler.Replay)
+}
+
+// GetRejected returns the inbound messages that were turned down, most recent
+// first.
+func (h hl7Handler) GetRejected(c *fiber.Ctx) error {
+	callerInfo := "[hl7Handler.GetRejected]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryMessageAcquire()
+	defer queryMessageRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterHL7MessageAcquire()
+	defer domain.FilterHL7MessageRelease(filter)
+
+	query.toFilter(filter)
+
+	messages := domain.HL7MessagesAcquire()
+	defer domain.HL7MessagesRelease(messages)
+
+	messages, err := h.hl7Service.GetRejected(userCtx, filter, messages)
+	if err != nil {
+		l.Error("failed to get rejected messages", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Rejected HL7 messages retrieved successfully"
+
+	messagesRes := getMessagesResAcquire()
+	defer getMessagesResRelease(messagesRes)
+
+	for i := range messages {
+		messagesRes = append(messagesRes, newMessageRes(&messages[i]))
+	}
+
+	res.Data = messagesRes
+
+	return c.JSON(res)
+}
+
+// Replay applies a rejected message again as if it had just been received.
+func (h hl7Handler) Replay(c *fiber.Ctx) error {
+	callerInfo := "[hl7Handler.Replay]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	messageID, err := ulid.Parse(c.Params(messageIDFromParam))
+	if err != nil {
+		l.Error("error parsing messageIDParam", zap.Error(err))
+		return new(domain.ErrHL7MessageNotFound)
+	}
+
+	message := domain.HL7MessageAcquire()
+	defer domain.HL7MessageRelease(message)
+
+	message.ID = messageID
+
+	err = h.hl7Service.Replay(userCtx, message)
+	if err != nil {
+		l.Error("failed to replay message", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "HL7 message replayed successfully"
+	res.Data = newMessageRes(message)
+
+	return c.JSON(res)
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+var queryMessagePool = sync.Pool{
+	New: func() any {
+		return new(queryMessage)
+	},
+}
+
+func queryMessageAcquire() *queryMessage {
+	return queryMessagePool.Get().(*queryMessage)
+}
+
+func queryMessageRelease(t *queryMessage) {
+	*t = queryMessage{}
+	queryMessagePool.Put(t)
+}
+
+type queryMessage struct {
+	ControlID   string `query:"controlId"`
+	MessageType string `query:"messageType"`
+	Status      string `query:"status"`
+	Limit       int    `query:"limit"`
+	Offset      int    `query:"offset"`
+}
+
+func (q *queryMessage) validate() {
+	switch q.Status {
+	case domain.HL7MessagePending, domain.HL7MessageReplayed:
+	default:
+		q.Status = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *queryMessage) toFilter(filter *domain.FilterHL7Message) {
+	filter.ControlID = q.ControlID
+	filter.MessageType = q.MessageType
+	filter.Status = q.Status
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+}
+
+type messageRes struct {
+	MessageID          ulid.ULID `json:"messageId"`
+	ControlID          string    `json:"controlId"`
+	MessageType        string    `json:"messageType"`
+	SendingApplication string    `json:"sendingApplication"`
+	RemoteAddr         string    `json:"remoteAddr"`
+	Raw                string    `json:"raw"`
+	Error              string    `json:"error"`
+	Status             string    `json:"status"`
+	ReceivedAt         string    `json:"receivedAt"`
+	ReplayCount        int       `json:"replayCount"`
+	ReplayedAt         string    `json:"replayedAt,omitempty"`
+}
+
+func newMessageRes(message *domain.HL7Message) messageRes {
+	res := messageRes{
+		MessageID:          message.ID,
+		ControlID:          message.ControlID,
+		MessageType:        message.MessageType,
+		SendingApplication: message.SendingApplication,
+		RemoteAddr:         message.RemoteAddr,
+		Raw:                message.Raw,
+		Error:              message.Error,
+		Status:             domain.HL7MessagePending,
+		ReceivedAt:         message.ReceivedAt.Format(dateFormat),
+		ReplayCount:        message.ReplayCount,
+	}
+	if !message.ReplayedAt.IsZero() {
+		res.Status = domain.HL7MessageReplayed
+		res.ReplayedAt = message.ReplayedAt.Format(dateFormat)
+	}
+	return res
+}
+
+const messageInitCap = 5
+
+var getMessagesResPool = sync.Pool{
+	New: func() any {
+		return make(getMessagesRes, 0, messageInitCap)
+	},
+}
+
+func getMessagesResAcquire() getMessagesRes {
+	return getMessagesResPool.Get().(getMessagesRes)
+}
+
+func getMessagesResRelease(t getMessagesRes) {
+	t = t[:0]
+	getMessagesResPool.Put(t) // nolint:staticcheck
+}
+
+type getMessagesRes []messageRes
@@ -0,0 +1,47 @@
+package hl7
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/message"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/mllp"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/service"
+)
+
+// NewModule registers the routes for rejected messages and, when the
+// listener is enabled, receives ADT messages over MLLP until ctx is done.
+// With prefork only the parent process listens.
+func NewModule(ctx context.Context, router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	cfg := configs.Get().HL7
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	readTimeout := time.Duration(cfg.ReadTimeout) * time.Second
+
+	sender := message.Sender{
+		Application: configs.Get().App.Name,
+		Facility:    cfg.Facility,
+	}
+
+	hl7Repository := repository.NewHL7Repository(db)
+	hl7Service := service.NewHL7Service(ctxTimeout, sender, hl7Repository)
+	handler.NewHL7Handler(router, jwtMiddleware, hl7Service)
+
+	if cfg.Enabled && !fiber.IsChild() {
+		addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+		server := mllp.NewServer(addr, readTimeout, cfg.MaxMessageSize, hl7Service.Ingest)
+
+		go func() {
+			if err := server.ListenAndServe(ctx); err != nil {
+				zap.L().Error("MLLP listener failed", zap.String("addr", addr), zap.Error(err))
+			}
+		}()
+	}
+}
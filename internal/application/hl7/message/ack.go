@@ -0,0 +1,115 @@
+package message
+
+import (
+	"strings"
+	"time"
+)
+
+// Acknowledgment codes of MSA-1.
+const (
+	AckAccept = "AA"
+	AckError  = "AE"
+	AckReject = "AR"
+)
+
+// Error codes of ERR-3, from HL7 table 0357.
+const (
+	ErrSegmentSequence      = "100"
+	ErrRequiredFieldMissing = "101"
+	ErrDataType             = "102"
+	ErrUnsupportedMessage   = "200"
+	ErrUnsupportedEvent     = "201"
+	ErrInternal             = "207"
+)
+
+var errorTexts = map[string]string{
+	ErrSegmentSequence:      "Segment sequence error",
+	ErrRequiredFieldMissing: "Required field missing",
+	ErrDataType:             "Data type error",
+	ErrUnsupportedMessage:   "Unsupported message type",
+	ErrUnsupportedEvent:     "Unsupported event code",
+	ErrInternal:             "Application internal error",
+}
+
+const timestampFormat = "20060102150405-0700"
+
+// Ack is the outcome reported back to the sender. ErrorCode and Text are
+// left empty when the message is accepted.
+type Ack struct {
+	Code      string
+	ErrorCode string
+	Text      string
+}
+
+// Sender names the application answering in MSH-3 and MSH-4 of the ACK.
+type Sender struct {
+	Application string
+	Facility    string
+}
+
+// NewACK answers req, which is nil when the message could not be parsed. The
+// ACK goes back to the sender of req, echoes its control id in MSA-2 and
+// uses its delimiters, processing id and version.
+func NewACK(req *Message, sender Sender, controlID string, ack Ack, now time.Time) []byte {
+	d := DefaultDelimiters
+	receivingApplication, receivingFacility, event, requestControlID := "", "", "", ""
+	processingID, version := "P", "2.5"
+
+	if req != nil {
+		d = req.Delimiters
+		receivingApplication, receivingFacility = req.SendingApplication(), req.SendingFacility()
+		event, requestControlID = req.Event(), req.ControlID()
+		if id := req.ProcessingID(); id != "" {
+			processingID = id
+		}
+		if v := req.Version(); v != "" {
+			version = v
+		}
+	}
+
+	field, component := string(d.Field), string(d.Component)
+
+	msh := []string{
+		"MSH",
+		d.encodingCharacters(),
+		d.Escape(sender.Application),
+		d.Escape(sender.Facility),
+		d.Escape(receivingApplication),
+		d.Escape(receivingFacility),
+		now.Format(timestampFormat),
+		"",
+		"ACK" + component + d.Escape(event) + component + "ACK",
+		d.Escape(controlID),
+		d.Escape(processingID),
+		d.Escape(version),
+	}
+
+	msa := []string{"MSA", ack.Code, d.Escape(requestControlID)}
+	if ack.Text != "" {
+		msa = append(msa, d.Escape(ack.Text))
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(msh, field))
+	b.WriteByte('\r')
+	b.WriteString(strings.Join(msa, field))
+	b.WriteByte('\r')
+
+	if ack.ErrorCode != "" {
+		err := []string{
+			"ERR",
+			"",
+			"",
+			ack.ErrorCode + component + d.Escape(errorTexts[ack.ErrorCode]) + component + "HL70357",
+			"E",
+			"",
+			"",
+			"",
+			d.Escape(ack.Text),
+		}
+		b.WriteString(strings.Join(err, field))
+		b.WriteByte('\r')
+	}
+
+	return []byte(b.String())
+}
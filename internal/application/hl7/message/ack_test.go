@@ -0,0 +1,184 @@
+package message
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewACK(t *testing.T) {
+	sender := Sender{Application: "HALO", Facility: "HALO_SUSTER"}
+	now := time.Date(2024, 5, 1, 8, 30, 5, 0, time.FixedZone("WIB", 7*60*60))
+
+	tests := []struct {
+		name string
+		// file is the message answered, none when it could not be parsed
+		file     string
+		ack      Ack
+		prefix   string
+		segments []string
+		want     map[string]string
+	}{
+		{
+			name:     "accepted",
+			file:     "adt_a01.hl7",
+			ack:      Ack{Code: AckAccept},
+			prefix:   "MSH|^~\\&|",
+			segments: []string{"MSH", "MSA"},
+			want: map[string]string{
+				"MSH-3":  "HALO",
+				"MSH-4":  "HALO_SUSTER",
+				"MSH-5":  "SIMRS",
+				"MSH-6":  "RSUD_BANDUNG",
+				"MSH-7":  "20240501083005+0700",
+				"MSH-9":  "ACK^A01^ACK",
+				"MSH-10": "ACK00001",
+				"MSH-11": "P",
+				"MSH-12": "2.5",
+				"MSA-1":  AckAccept,
+				"MSA-2":  "MSG00001",
+				"MSA-3":  "",
+			},
+		},
+		{
+			name: "error",
+			file: "adt_a04.hl7",
+			ack: Ack{
+				Code:      AckError,
+				ErrorCode: ErrRequiredFieldMissing,
+				Text:      "PID-5 patient name is required",
+			},
+			prefix:   "MSH|^~\\&|",
+			segments: []string{"MSH", "MSA", "ERR"},
+			want: map[string]string{
+				"MSH-5":  "SIMRS",
+				"MSH-6":  "PUSKESMAS_CIBIRU",
+				"MSH-9":  "ACK^A04^ACK",
+				"MSH-12": "2.5.1",
+				"MSA-1":  AckError,
+				"MSA-2":  "MSG00002",
+				"MSA-3":  "PID-5 patient name is required",
+				"ERR-3":  "101^Required field missing^HL70357",
+				"ERR-4":  "E",
+				"ERR-8":  "PID-5 patient name is required",
+			},
+		},
+		{
+			name: "rejected event",
+			file: "adt_a03.hl7",
+			ack: Ack{
+				Code:      AckReject,
+				ErrorCode: ErrUnsupportedEvent,
+				Text:      "trigger event A03 is not supported",
+			},
+			prefix:   "MSH|^~\\&|",
+			segments: []string{"MSH", "MSA", "ERR"},
+			want: map[string]string{
+				"MSH-9": "ACK^A03^ACK",
+				"MSA-1": AckReject,
+				"MSA-2": "MSG00006",
+				"ERR-3": "201^Unsupported event code^HL70357",
+			},
+		},
+		{
+			name: "message that could not be parsed",
+			ack: Ack{
+				Code:      AckReject,
+				ErrorCode: ErrSegmentSequence,
+				Text:      ErrNoHeader.Error(),
+			},
+			prefix:   "MSH|^~\\&|",
+			segments: []string{"MSH", "MSA", "ERR"},
+			want: map[string]string{
+				"MSH-5":  "",
+				"MSH-6":  "",
+				"MSH-9":  "ACK^^ACK",
+				"MSH-11": "P",
+				"MSH-12": "2.5",
+				"MSA-1":  AckReject,
+				"MSA-2":  "",
+				"MSA-3":  ErrNoHeader.Error(),
+				"ERR-3":  "100^Segment sequence error^HL70357",
+			},
+		},
+		{
+			name:     "processing id and version of the request",
+			file:     "adt_a08.hl7",
+			ack:      Ack{Code: AckAccept},
+			prefix:   "MSH|^~\\&|",
+			segments: []string{"MSH", "MSA"},
+			want: map[string]string{
+				"MSH-9":  "ACK^A08^ACK",
+				"MSH-11": "T",
+				"MSH-12": "2.3",
+			},
+		},
+		{
+			name:     "delimiters of the request",
+			file:     "adt_a04_custom_delimiters.hl7",
+			ack:      Ack{Code: AckError, ErrorCode: ErrDataType, Text: "PID-8 must be M#F"},
+			prefix:   "MSH#*$!@#",
+			segments: []string{"MSH", "MSA", "ERR"},
+			want: map[string]string{
+				"MSH-9": "ACK*A04*ACK",
+				"MSA-2": "MSG00004",
+				"MSA-3": "PID-8 must be M#F",
+				"ERR-3": "102*Data type error*HL70357",
+			},
+		},
+		{
+			name: "delimiters in the text escaped",
+			file: "adt_a01.hl7",
+			ack: Ack{
+				Code:      AckError,
+				ErrorCode: ErrDataType,
+				Text:      "PID-13 phone number must be an Indonesian number, got 021|555^1234",
+			},
+			prefix:   "MSH|^~\\&|",
+			segments: []string{"MSH", "MSA", "ERR"},
+			want: map[string]string{
+				"MSA-3": "PID-13 phone number must be an Indonesian number, got 021|555^1234",
+				"ERR-8": "PID-13 phone number must be an Indonesian number, got 021|555^1234",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *Message
+			if tt.file != "" {
+				var err error
+				if req, err = Parse(sample(t, tt.file)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			raw := NewACK(req, sender, "ACK00001", tt.ack, now)
+
+			if !bytes.HasPrefix(raw, []byte(tt.prefix)) {
+				t.Errorf("ACK starts with %q, want %q", raw[:min(len(raw), len(tt.prefix))], tt.prefix)
+			}
+			if !bytes.HasSuffix(raw, []byte("\r")) || bytes.ContainsRune(raw, '\n') {
+				t.Errorf("ACK segments are not ended by carriage returns: %q", raw)
+			}
+
+			ack, err := Parse(raw)
+			if err != nil {
+				t.Fatalf("ACK does not parse: %v", err)
+			}
+			if got := segmentNames(ack); !reflect.DeepEqual(got, tt.segments) {
+				t.Errorf("ACK segments = %v, want %v", got, tt.segments)
+			}
+			if ack.Type() != "ACK" {
+				t.Errorf("ACK type = %q, want ACK", ack.Type())
+			}
+
+			for field, want := range tt.want {
+				if got := value(t, ack, field).String(); got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
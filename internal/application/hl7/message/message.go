@@ -0,0 +1,269 @@
+// Package message parses HL7 v2 messages and builds the ACKs that answer
+// them.
+//
+// Only the structure is parsed, segments into fields, repetitions and
+// components, with the delimiters the message declares in MSH. Fields are
+// numbered the way the standard numbers them, so MSH-9 is Field(9) on the
+// MSH segment and PID-3 is Field(3) on the PID segment.
+package message
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	SegmentMSH = "MSH"
+	SegmentPID = "PID"
+)
+
+var ErrNoHeader = errors.New("message does not start with an MSH segment")
+
+// Delimiters are the separators a message declares in MSH-1 and MSH-2.
+type Delimiters struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	EscapeChar   byte
+	Subcomponent byte
+}
+
+// DefaultDelimiters are the ones nearly every sender uses, |^~\&.
+var DefaultDelimiters = Delimiters{
+	Field:        '|',
+	Component:    '^',
+	Repetition:   '~',
+	EscapeChar:   '\\',
+	Subcomponent: '&',
+}
+
+func (d Delimiters) encodingCharacters() string {
+	return string([]byte{d.Component, d.Repetition, d.EscapeChar, d.Subcomponent})
+}
+
+// Unescape replaces the escape sequences of the delimiters with the
+// characters they stand for. Formatting sequences such as \.br\ are dropped.
+func (d Delimiters) Unescape(value string) string {
+	if strings.IndexByte(value, d.EscapeChar) < 0 {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != d.EscapeChar {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		end := strings.IndexByte(value[i+1:], d.EscapeChar)
+		if end < 0 {
+			b.WriteString(value[i:])
+			break
+		}
+
+		switch value[i+1 : i+1+end] {
+		case "F":
+			b.WriteByte(d.Field)
+		case "S":
+			b.WriteByte(d.Component)
+		case "R":
+			b.WriteByte(d.Repetition)
+		case "E":
+			b.WriteByte(d.EscapeChar)
+		case "T":
+			b.WriteByte(d.Subcomponent)
+		}
+		i += end + 1
+	}
+
+	return b.String()
+}
+
+// Escape replaces the delimiters in value with their escape sequences.
+func (d Delimiters) Escape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case d.EscapeChar:
+			b.WriteString(string(d.EscapeChar) + "E" + string(d.EscapeChar))
+		case d.Field:
+			b.WriteString(string(d.EscapeChar) + "F" + string(d.EscapeChar))
+		case d.Component:
+			b.WriteString(string(d.EscapeChar) + "S" + string(d.EscapeChar))
+		case d.Repetition:
+			b.WriteString(string(d.EscapeChar) + "R" + string(d.EscapeChar))
+		case d.Subcomponent:
+			b.WriteString(string(d.EscapeChar) + "T" + string(d.EscapeChar))
+		case '\r', '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+
+	return b.String()
+}
+
+// Field is the raw value of a field, or of one of its repetitions.
+type Field struct {
+	raw        string
+	delimiters Delimiters
+}
+
+// String is the whole value unescaped, components and all.
+func (f Field) String() string {
+	return f.delimiters.Unescape(f.raw)
+}
+
+func (f Field) IsEmpty() bool {
+	return f.raw == "" || f.raw == `""`
+}
+
+// Repetitions splits a repeating field.
+func (f Field) Repetitions() []Field {
+	if f.raw == "" {
+		return nil
+	}
+
+	parts := strings.Split(f.raw, string(f.delimiters.Repetition))
+	repetitions := make([]Field, 0, len(parts))
+	for _, part := range parts {
+		repetitions = append(repetitions, Field{raw: part, delimiters: f.delimiters})
+	}
+
+	return repetitions
+}
+
+// Component returns component n, counted from 1, of the first repetition.
+func (f Field) Component(n int) string {
+	value := f.raw
+	if i := strings.IndexByte(value, f.delimiters.Repetition); i >= 0 {
+		value = value[:i]
+	}
+
+	components := strings.Split(value, string(f.delimiters.Component))
+	if n < 1 || n > len(components) {
+		return ""
+	}
+
+	return f.delimiters.Unescape(components[n-1])
+}
+
+type Segment struct {
+	Name       string
+	fields     []string
+	delimiters Delimiters
+}
+
+// Field returns field n as the standard numbers it. A field the segment does
+// not have is empty.
+func (s Segment) Field(n int) Field {
+	if n < 1 || n >= len(s.fields) {
+		return Field{delimiters: s.delimiters}
+	}
+
+	return Field{raw: s.fields[n], delimiters: s.delimiters}
+}
+
+type Message struct {
+	Delimiters Delimiters
+	Segments   []Segment
+}
+
+// Parse splits raw into segments. Segments end with a carriage return, line
+// feeds are accepted as well since some senders use them.
+func Parse(raw []byte) (*Message, error) {
+	text := strings.TrimLeft(string(raw), "\r\n\x00 ")
+	if len(text) < 8 || !strings.HasPrefix(text, SegmentMSH) {
+		return nil, ErrNoHeader
+	}
+
+	// MSH-1 is the character after the name, MSH-2 the four after it
+	d := Delimiters{
+		Field:        text[3],
+		Component:    text[4],
+		Repetition:   text[5],
+		EscapeChar:   text[6],
+		Subcomponent: text[7],
+	}
+	if d.Field == d.Component || d.Field == d.Repetition || d.Field == d.EscapeChar || d.Field == d.Subcomponent {
+		return nil, errors.New("MSH-2 does not declare the encoding characters")
+	}
+
+	lines := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	})
+
+	m := &Message{
+		Delimiters: d,
+		Segments:   make([]Segment, 0, len(lines)),
+	}
+
+	for _, line := range lines {
+		fields := strings.Split(line, string(d.Field))
+		name := fields[0]
+		if len(name) != 3 {
+			return nil, errors.New("segment name must have 3 characters: " + name)
+		}
+
+		if name == SegmentMSH {
+			// MSH-1 is the separator itself, so MSH-n lands at index n
+			fields = append([]string{SegmentMSH, string(d.Field)}, fields[1:]...)
+		}
+
+		m.Segments = append(m.Segments, Segment{Name: name, fields: fields, delimiters: d})
+	}
+
+	if m.Segments[0].Name != SegmentMSH {
+		return nil, ErrNoHeader
+	}
+
+	return m, nil
+}
+
+// Segment returns the first segment called name.
+func (m *Message) Segment(name string) (Segment, bool) {
+	for i := range m.Segments {
+		if m.Segments[i].Name == name {
+			return m.Segments[i], true
+		}
+	}
+
+	return Segment{}, false
+}
+
+func (m *Message) header() Segment {
+	return m.Segments[0]
+}
+
+// Type is MSH-9.1, ADT for example.
+func (m *Message) Type() string {
+	return m.header().Field(9).Component(1)
+}
+
+// Event is the trigger event in MSH-9.2, A04 for example.
+func (m *Message) Event() string {
+	return m.header().Field(9).Component(2)
+}
+
+// ControlID is MSH-10, which the ACK refers back to.
+func (m *Message) ControlID() string {
+	return m.header().Field(10).String()
+}
+
+func (m *Message) SendingApplication() string {
+	return m.header().Field(3).Component(1)
+}
+
+func (m *Message) SendingFacility() string {
+	return m.header().Field(4).Component(1)
+}
+
+// ProcessingID is MSH-11, P for production, T for training, D for debugging.
+func (m *Message) ProcessingID() string {
+	return m.header().Field(11).Component(1)
+}
+
+func (m *Message) Version() string {
+	return m.header().Field(12).Component(1)
+}
@@ -0,0 +1,418 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sample reads a message from the testdata of the module, the files have a
+// line per segment and are sent with carriage returns between them.
+func sample(t *testing.T, name string) []byte {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.ReplaceAll(raw, []byte("\n"), []byte("\r"))
+}
+
+// value returns a field such as "PID-3" of the first segment with its name.
+func value(t *testing.T, m *Message, field string) Field {
+	t.Helper()
+
+	name, n, ok := strings.Cut(field, "-")
+	if !ok {
+		t.Fatalf("field %q is not named like PID-3", field)
+	}
+	i, err := strconv.Atoi(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segment, ok := m.Segment(name)
+	if !ok {
+		t.Fatalf("message has no %s segment", name)
+	}
+
+	return segment.Field(i)
+}
+
+func segmentNames(m *Message) []string {
+	names := make([]string, 0, len(m.Segments))
+	for _, segment := range m.Segments {
+		names = append(names, segment.Name)
+	}
+	return names
+}
+
+func TestParse(t *testing.T) {
+	custom := Delimiters{Field: '#', Component: '*', Repetition: '$', EscapeChar: '!', Subcomponent: '@'}
+
+	tests := []struct {
+		name         string
+		raw          func(t *testing.T) []byte
+		delimiters   Delimiters
+		segments     []string
+		msgType      string
+		event        string
+		controlID    string
+		application  string
+		facility     string
+		processingID string
+		version      string
+	}{
+		{
+			name:         "admission",
+			raw:          func(t *testing.T) []byte { return sample(t, "adt_a01.hl7") },
+			delimiters:   DefaultDelimiters,
+			segments:     []string{"MSH", "EVN", "PID", "PV1"},
+			msgType:      "ADT",
+			event:        "A01",
+			controlID:    "MSG00001",
+			application:  "SIMRS",
+			facility:     "RSUD_BANDUNG",
+			processingID: "P",
+			version:      "2.5",
+		},
+		{
+			name:         "registration",
+			raw:          func(t *testing.T) []byte { return sample(t, "adt_a04.hl7") },
+			delimiters:   DefaultDelimiters,
+			segments:     []string{"MSH", "EVN", "PID", "PV1"},
+			msgType:      "ADT",
+			event:        "A04",
+			controlID:    "MSG00002",
+			application:  "SIMRS",
+			facility:     "PUSKESMAS_CIBIRU",
+			processingID: "P",
+			version:      "2.5.1",
+		},
+		{
+			name:         "update for training",
+			raw:          func(t *testing.T) []byte { return sample(t, "adt_a08.hl7") },
+			delimiters:   DefaultDelimiters,
+			segments:     []string{"MSH", "EVN", "PID"},
+			msgType:      "ADT",
+			event:        "A08",
+			controlID:    "MSG00003",
+			application:  "SIMRS",
+			facility:     "RSUD_BANDUNG",
+			processingID: "T",
+			version:      "2.3",
+		},
+		{
+			name:         "custom delimiters",
+			raw:          func(t *testing.T) []byte { return sample(t, "adt_a04_custom_delimiters.hl7") },
+			delimiters:   custom,
+			segments:     []string{"MSH", "PID"},
+			msgType:      "ADT",
+			event:        "A04",
+			controlID:    "MSG00004",
+			application:  "SIMRS",
+			facility:     "PUSKESMAS_CIBIRU",
+			processingID: "P",
+			version:      "2.5",
+		},
+		{
+			name: "line feeds",
+			raw: func(t *testing.T) []byte {
+				raw, err := os.ReadFile(filepath.Join("..", "testdata", "adt_a08_lf.hl7"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return raw
+			},
+			delimiters:   DefaultDelimiters,
+			segments:     []string{"MSH", "PID"},
+			msgType:      "ADT",
+			event:        "A08",
+			controlID:    "MSG00005",
+			application:  "SIMRS",
+			facility:     "RSUD_BANDUNG",
+			processingID: "P",
+			version:      "2.5",
+		},
+		{
+			name: "carriage returns and line feeds after blank lines",
+			raw: func(t *testing.T) []byte {
+				raw := sample(t, "adt_a08_lf.hl7")
+				return append([]byte("\r\n\x00 "), bytes.ReplaceAll(raw, []byte("\r"), []byte("\r\n\r\n"))...)
+			},
+			delimiters:   DefaultDelimiters,
+			segments:     []string{"MSH", "PID"},
+			msgType:      "ADT",
+			event:        "A08",
+			controlID:    "MSG00005",
+			application:  "SIMRS",
+			facility:     "RSUD_BANDUNG",
+			processingID: "P",
+			version:      "2.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.raw(t))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if m.Delimiters != tt.delimiters {
+				t.Errorf("Delimiters = %q, want %q", m.Delimiters, tt.delimiters)
+			}
+			if got := segmentNames(m); !reflect.DeepEqual(got, tt.segments) {
+				t.Errorf("segments = %v, want %v", got, tt.segments)
+			}
+
+			for _, field := range []struct{ name, got, want string }{
+				{"Type", m.Type(), tt.msgType},
+				{"Event", m.Event(), tt.event},
+				{"ControlID", m.ControlID(), tt.controlID},
+				{"SendingApplication", m.SendingApplication(), tt.application},
+				{"SendingFacility", m.SendingFacility(), tt.facility},
+				{"ProcessingID", m.ProcessingID(), tt.processingID},
+				{"Version", m.Version(), tt.version},
+			} {
+				if field.got != field.want {
+					t.Errorf("%s() = %q, want %q", field.name, field.got, field.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      func(t *testing.T) []byte
+		noHeader bool
+	}{
+		{
+			name:     "empty",
+			raw:      func(*testing.T) []byte { return nil },
+			noHeader: true,
+		},
+		{
+			name:     "only blank lines",
+			raw:      func(*testing.T) []byte { return []byte("\r\n\r\n") },
+			noHeader: true,
+		},
+		{
+			name:     "MSH segment missing",
+			raw:      func(t *testing.T) []byte { return sample(t, "missing_msh.hl7") },
+			noHeader: true,
+		},
+		{
+			name:     "header cut short",
+			raw:      func(*testing.T) []byte { return []byte("MSH|^~\\") },
+			noHeader: true,
+		},
+		{
+			name:     "lowercase header",
+			raw:      func(*testing.T) []byte { return []byte("msh|^~\\&|SIMRS\r") },
+			noHeader: true,
+		},
+		{
+			name: "encoding characters repeat the field separator",
+			raw:  func(t *testing.T) []byte { return sample(t, "bad_encoding.hl7") },
+		},
+		{
+			name: "field separator as the escape character",
+			raw:  func(*testing.T) []byte { return []byte("MSH|^~|&|SIMRS\r") },
+		},
+		{
+			name: "segment name too long",
+			raw:  func(*testing.T) []byte { return []byte("MSH|^~\\&|SIMRS\rPIDX|1\r") },
+		},
+		{
+			name: "segment name too short",
+			raw:  func(*testing.T) []byte { return []byte("MSH|^~\\&|SIMRS\rPI|1\r") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.raw(t))
+			if err == nil {
+				t.Fatalf("Parse() = %v, want an error", m)
+			}
+			if got := errors.Is(err, ErrNoHeader); got != tt.noHeader {
+				t.Errorf("Parse() error = %v, is ErrNoHeader %v, want %v", err, got, tt.noHeader)
+			}
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		field string
+		// component 0 is the whole field
+		component int
+		want      string
+	}{
+		{name: "MSH-1 is the field separator", file: "adt_a01.hl7", field: "MSH-1", want: "|"},
+		{name: "MSH-2 is the encoding characters", file: "adt_a01.hl7", field: "MSH-2", want: `^~\&`},
+		{name: "message structure", file: "adt_a01.hl7", field: "MSH-9", component: 3, want: "ADT_A01"},
+		{name: "whole field", file: "adt_a01.hl7", field: "MSH-9", want: "ADT^A01^ADT_A01"},
+		{name: "identifier", file: "adt_a01.hl7", field: "PID-3", component: 1, want: "3201010101010001"},
+		{name: "assigning authority", file: "adt_a01.hl7", field: "PID-3", component: 4, want: "NIK"},
+		{name: "identifier type", file: "adt_a01.hl7", field: "PID-3", component: 5, want: "NNIDN"},
+		{name: "given name", file: "adt_a01.hl7", field: "PID-5", component: 2, want: "BUDI"},
+		{name: "empty component", file: "adt_a01.hl7", field: "PID-5", component: 3, want: ""},
+		{name: "component past the last", file: "adt_a01.hl7", field: "PID-8", component: 2, want: ""},
+		{name: "component 0", file: "adt_a01.hl7", field: "PID-5", component: -1, want: ""},
+		{name: "address city", file: "adt_a01.hl7", field: "PID-11", component: 3, want: "BANDUNG"},
+		{name: "component of the first repetition", file: "adt_a04.hl7", field: "PID-3", component: 5, want: "MR"},
+		{name: "field past the last", file: "adt_a01.hl7", field: "PID-30", want: ""},
+		{name: "escaped subcomponent separator", file: "adt_a08.hl7", field: "PID-5", component: 1, want: "O&SANTOSO"},
+		{name: "custom component", file: "adt_a04_custom_delimiters.hl7", field: "PID-5", component: 3, want: "NUR"},
+		{name: "custom identifier type", file: "adt_a04_custom_delimiters.hl7", field: "PID-3", component: 5, want: "NNIDN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(sample(t, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			field := value(t, m, tt.field)
+
+			var got string
+			switch {
+			case tt.component == 0:
+				got = field.String()
+			case tt.component < 0:
+				got = field.Component(0)
+			default:
+				got = field.Component(tt.component)
+			}
+
+			if got != tt.want {
+				t.Errorf("%s.%d = %q, want %q", tt.field, tt.component, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldRepetitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		field string
+		// component 1 of every repetition
+		want []string
+	}{
+		{name: "identifiers", file: "adt_a04.hl7", field: "PID-3", want: []string{"RM-000123", "3273014502950002"}},
+		{name: "phone and email", file: "adt_a01.hl7", field: "PID-13", want: []string{"", ""}},
+		{name: "single", file: "adt_a08.hl7", field: "PID-3", want: []string{"3201010101010001"}},
+		{name: "empty", file: "adt_a08.hl7", field: "PID-4", want: []string{}},
+		{name: "custom repetition separator", file: "adt_a04_custom_delimiters.hl7", field: "PID-3", want: []string{"3273014502950002"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(sample(t, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0)
+			for _, repetition := range value(t, m, tt.field).Repetitions() {
+				got = append(got, repetition.Component(1))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s repetitions = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldIsEmpty(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "", want: true},
+		{raw: `""`, want: true},
+		{raw: "M", want: false},
+		{raw: "^", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			field := Field{raw: tt.raw, delimiters: DefaultDelimiters}
+			if got := field.IsEmpty(); got != tt.want {
+				t.Errorf("IsEmpty() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	custom := Delimiters{Field: '#', Component: '*', Repetition: '$', EscapeChar: '!', Subcomponent: '@'}
+
+	tests := []struct {
+		name       string
+		delimiters Delimiters
+		value      string
+		want       string
+	}{
+		{name: "no escapes", delimiters: DefaultDelimiters, value: "SANTOSO", want: "SANTOSO"},
+		{name: "field separator", delimiters: DefaultDelimiters, value: `A\F\B`, want: "A|B"},
+		{name: "every delimiter", delimiters: DefaultDelimiters, value: `\F\\S\\R\\E\\T\`, want: `|^~\&`},
+		{name: "formatting dropped", delimiters: DefaultDelimiters, value: `line\.br\break`, want: "linebreak"},
+		{name: "unknown sequence dropped", delimiters: DefaultDelimiters, value: `A\X0D\B`, want: "AB"},
+		{name: "unterminated sequence kept", delimiters: DefaultDelimiters, value: `A\F`, want: `A\F`},
+		{name: "custom escape character", delimiters: custom, value: "A!F!B!S!C", want: "A#B*C"},
+		{name: "default escapes under custom delimiters", delimiters: custom, value: `A\F\B`, want: `A\F\B`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.delimiters.Unescape(tt.value); got != tt.want {
+				t.Errorf("Unescape(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	custom := Delimiters{Field: '#', Component: '*', Repetition: '$', EscapeChar: '!', Subcomponent: '@'}
+
+	tests := []struct {
+		name       string
+		delimiters Delimiters
+		value      string
+		want       string
+	}{
+		{name: "no delimiters", delimiters: DefaultDelimiters, value: "SANTOSO", want: "SANTOSO"},
+		{name: "every delimiter", delimiters: DefaultDelimiters, value: `a|b^c~d\e&f`, want: `a\F\b\S\c\R\d\E\e\T\f`},
+		{name: "line breaks", delimiters: DefaultDelimiters, value: "two\r\nlines", want: "two  lines"},
+		{name: "custom delimiters", delimiters: custom, value: "a#b*c|d", want: "a!F!b!S!c|d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.delimiters.Escape(tt.value)
+			if got != tt.want {
+				t.Errorf("Escape(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+
+			if want := strings.NewReplacer("\r", " ", "\n", " ").Replace(tt.value); tt.delimiters.Unescape(got) != want {
+				t.Errorf("Unescape(Escape(%q)) = %q, want %q", tt.value, tt.delimiters.Unescape(got), want)
+			}
+		})
+	}
+}
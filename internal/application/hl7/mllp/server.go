@@ -0,0 +1,185 @@
+// Package mllp serves HL7 v2 over the minimal lower layer protocol, each
+// message framed by a vertical tab in front and a file separator and carriage
+// return behind.
+package mllp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+)
+
+const (
+	startBlock  = 0x0b
+	endBlock    = 0x1c
+	endOfFrame  = 0x0d
+	readBufSize = 64 * 1024
+)
+
+var ErrMessageTooLarge = errors.New("message exceeds the maximum size")
+
+// Handler answers a message, the answer is sent back framed. The context
+// carries a logger for the connection.
+type Handler func(ctx context.Context, msg []byte, remoteAddr string) []byte
+
+type Server struct {
+	addr           string
+	handler        Handler
+	readTimeout    time.Duration
+	maxMessageSize int
+}
+
+func NewServer(addr string, readTimeout time.Duration, maxMessageSize int, handler Handler) *Server {
+	return &Server{
+		addr:           addr,
+		handler:        handler,
+		readTimeout:    readTimeout,
+		maxMessageSize: maxMessageSize,
+	}
+}
+
+// ListenAndServe accepts connections until ctx is done, then closes the open
+// ones once their current message is answered.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	callerInfo := "[Server.ListenAndServe]"
+	l := zap.L().With(zap.String("caller", callerInfo))
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	l.Info("MLLP listener started", zap.String("addr", listener.Addr().String()))
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				l.Info("MLLP listener stopped")
+				return nil
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serve(ctx, conn)
+		}()
+	}
+}
+
+// serve answers the messages of a connection one at a time, senders wait for
+// the ACK before they send the next one.
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	callerInfo := "[Server.serve]"
+	remoteAddr := conn.RemoteAddr().String()
+	l := zap.L().With(zap.String("caller", callerInfo), zap.String("remoteAddr", remoteAddr))
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// shutting down interrupts a connection waiting for its next message, a
+	// message being handled is still answered
+	go func() {
+		<-connCtx.Done()
+		_ = conn.SetReadDeadline(time.Now())
+	}()
+
+	handlerCtx := logger.WithCtx(context.WithoutCancel(ctx), zap.L().With(zap.String("remoteAddr", remoteAddr)))
+	reader := bufio.NewReaderSize(conn, readBufSize)
+
+	for {
+		if s.readTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(s.readTimeout))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		msg, err := s.readFrame(reader)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, io.EOF) {
+				l.Warn("failed to read MLLP frame, closing connection", zap.Error(err))
+			}
+			return
+		}
+
+		ack := s.handler(handlerCtx, msg, remoteAddr)
+
+		if _, err = conn.Write(frame(ack)); err != nil {
+			l.Warn("failed to write MLLP frame, closing connection", zap.Error(err))
+			return
+		}
+	}
+}
+
+// readFrame returns the next message, bytes in front of the start block are
+// skipped.
+func (s *Server) readFrame(reader *bufio.Reader) ([]byte, error) {
+	if _, err := reader.ReadBytes(startBlock); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	for {
+		chunk, err := reader.ReadSlice(endBlock)
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+
+		msg.Write(chunk)
+		if s.maxMessageSize > 0 && msg.Len() > s.maxMessageSize+1 {
+			return nil, ErrMessageTooLarge
+		}
+
+		if err == nil {
+			break
+		}
+	}
+
+	next, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if next != endOfFrame {
+		return nil, fmt.Errorf("frame ends with %#x instead of a carriage return", next)
+	}
+
+	return bytes.TrimSuffix(msg.Bytes(), []byte{endBlock}), nil
+}
+
+func frame(msg []byte) []byte {
+	framed := make([]byte, 0, len(msg)+3)
+	framed = append(framed, startBlock)
+	framed = append(framed, msg...)
+	return append(framed, endBlock, endOfFrame)
+}
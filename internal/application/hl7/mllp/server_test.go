@@ -0,0 +1,321 @@
+package mllp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// errFraming stands for any error of a frame that is not ended properly.
+var errFraming = errors.New("malformed frame")
+
+// sample reads a message from the testdata of the module, with carriage
+// returns between the segments.
+func sample(t *testing.T, name string) []byte {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.ReplaceAll(raw, []byte("\n"), []byte("\r"))
+}
+
+// padded is msg with a trailing segment that makes it size bytes long.
+func padded(msg []byte, size int) []byte {
+	pad := size - len(msg) - len("NTE|1||\r")
+	return append(append(append([]byte{}, msg...), "NTE|1||"+string(bytes.Repeat([]byte("x"), pad))...), '\r')
+}
+
+func TestReadFrame(t *testing.T) {
+	a01 := sample(t, "adt_a01.hl7")
+	a04 := sample(t, "adt_a04.hl7")
+	large := padded(a01, 3*readBufSize+17)
+
+	tests := []struct {
+		name           string
+		input          []byte
+		maxMessageSize int
+		want           [][]byte
+		// err is what the read after the wanted messages fails with
+		err error
+	}{
+		{
+			name:           "single frame",
+			input:          frame(a01),
+			maxMessageSize: 1 << 20,
+			want:           [][]byte{a01},
+			err:            io.EOF,
+		},
+		{
+			name:           "frames one after another",
+			input:          append(frame(a01), frame(a04)...),
+			maxMessageSize: 1 << 20,
+			want:           [][]byte{a01, a04},
+			err:            io.EOF,
+		},
+		{
+			name:           "bytes in front of the start block skipped",
+			input:          append([]byte("\r\nnoise\x1c\r"), frame(a04)...),
+			maxMessageSize: 1 << 20,
+			want:           [][]byte{a04},
+			err:            io.EOF,
+		},
+		{
+			name:           "empty frame",
+			input:          frame(nil),
+			maxMessageSize: 1 << 20,
+			want:           [][]byte{{}},
+			err:            io.EOF,
+		},
+		{
+			name:           "larger than the read buffer",
+			input:          frame(large),
+			maxMessageSize: 1 << 20,
+			want:           [][]byte{large},
+			err:            io.EOF,
+		},
+		{
+			name:           "no maximum size",
+			input:          frame(large),
+			maxMessageSize: 0,
+			want:           [][]byte{large},
+			err:            io.EOF,
+		},
+		{
+			name:           "exactly the maximum size",
+			input:          frame(a01),
+			maxMessageSize: len(a01),
+			want:           [][]byte{a01},
+			err:            io.EOF,
+		},
+		{
+			name:           "a byte over the maximum size",
+			input:          frame(a01),
+			maxMessageSize: len(a01) - 1,
+			err:            ErrMessageTooLarge,
+		},
+		{
+			name:           "exactly the maximum size over several reads",
+			input:          frame(large),
+			maxMessageSize: len(large),
+			want:           [][]byte{large},
+			err:            io.EOF,
+		},
+		{
+			name:           "a byte over the maximum size over several reads",
+			input:          frame(large),
+			maxMessageSize: len(large) - 1,
+			err:            ErrMessageTooLarge,
+		},
+		{
+			name:           "oversized frame with no end block",
+			input:          append([]byte{startBlock}, large...),
+			maxMessageSize: readBufSize,
+			err:            ErrMessageTooLarge,
+		},
+		{
+			name:           "oversized frame after a good one",
+			input:          append(frame(a04), frame(large)...),
+			maxMessageSize: len(a04),
+			want:           [][]byte{a04},
+			err:            ErrMessageTooLarge,
+		},
+		{
+			name:           "end block without a carriage return",
+			input:          append(append([]byte{startBlock}, a01...), endBlock, '\n'),
+			maxMessageSize: 1 << 20,
+			err:            errFraming,
+		},
+		{
+			name:           "closed inside a frame",
+			input:          append([]byte{startBlock}, a01...),
+			maxMessageSize: 1 << 20,
+			err:            io.EOF,
+		},
+		{
+			name:           "closed after the end block",
+			input:          append(append([]byte{startBlock}, a01...), endBlock),
+			maxMessageSize: 1 << 20,
+			err:            io.EOF,
+		},
+		{
+			name:           "no start block",
+			input:          a01,
+			maxMessageSize: 1 << 20,
+			err:            io.EOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer("", 0, tt.maxMessageSize, nil)
+			reader := bufio.NewReaderSize(bytes.NewReader(tt.input), readBufSize)
+
+			for i, want := range tt.want {
+				msg, err := s.readFrame(reader)
+				if err != nil {
+					t.Fatalf("readFrame() %d error = %v", i+1, err)
+				}
+				if !bytes.Equal(msg, want) {
+					t.Fatalf("readFrame() %d = %d bytes, want %d", i+1, len(msg), len(want))
+				}
+			}
+
+			msg, err := s.readFrame(reader)
+			switch {
+			case err == nil:
+				t.Errorf("readFrame() = %d bytes, want error %v", len(msg), tt.err)
+			case tt.err == errFraming:
+				if errors.Is(err, io.EOF) || errors.Is(err, ErrMessageTooLarge) {
+					t.Errorf("readFrame() error = %v, want a framing error", err)
+				}
+			case !errors.Is(err, tt.err):
+				t.Errorf("readFrame() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// client is the sending end of a connection served by s.
+func client(ctx context.Context, t *testing.T, s *Server) (net.Conn, <-chan struct{}) {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.serve(ctx, serverConn)
+	}()
+
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		<-done
+	})
+
+	return clientConn, done
+}
+
+func TestServe(t *testing.T) {
+	a01 := sample(t, "adt_a01.hl7")
+	a04 := sample(t, "adt_a04.hl7")
+	large := padded(a01, 2*readBufSize)
+
+	tests := []struct {
+		name           string
+		maxMessageSize int
+		frames         [][]byte
+		// answered are the messages the handler gets, each answered in turn,
+		// before the server closes the connection
+		answered [][]byte
+	}{
+		{
+			name:           "answers every message in turn",
+			maxMessageSize: 1 << 20,
+			frames:         [][]byte{frame(a01), frame(a04)},
+			answered:       [][]byte{a01, a04},
+		},
+		{
+			name:           "oversized message closes the connection",
+			maxMessageSize: len(a04),
+			frames:         [][]byte{frame(a04), frame(large)},
+			answered:       [][]byte{a04},
+		},
+		{
+			name:           "malformed frame closes the connection",
+			maxMessageSize: 1 << 20,
+			frames:         [][]byte{frame(a01), append(append([]byte{startBlock}, a04...), endBlock, endBlock)},
+			answered:       [][]byte{a01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled atomic.Int32
+			s := NewServer("", time.Second, tt.maxMessageSize, func(_ context.Context, msg []byte, _ string) []byte {
+				handled.Add(1)
+				return append([]byte("ACK "), msg...)
+			})
+
+			conn, done := client(context.Background(), t, s)
+			reader := bufio.NewReaderSize(conn, readBufSize)
+			answers := NewServer("", 0, 0, nil)
+
+			for i, f := range tt.frames {
+				// a pipe blocks the writer until the server reads, which it
+				// stops doing when it closes the connection
+				go func() {
+					_, _ = conn.Write(f)
+				}()
+
+				ack, err := answers.readFrame(reader)
+				if i >= len(tt.answered) {
+					if err == nil {
+						t.Fatalf("frame %d answered with %q, want the connection closed", i+1, ack)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("frame %d not answered: %v", i+1, err)
+				}
+				if want := append([]byte("ACK "), tt.answered[i]...); !bytes.Equal(ack, want) {
+					t.Fatalf("frame %d answered with %d bytes, want %d", i+1, len(ack), len(want))
+				}
+			}
+
+			if len(tt.answered) < len(tt.frames) {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("connection still open")
+				}
+			}
+
+			if got := int(handled.Load()); got != len(tt.answered) {
+				t.Errorf("handled %d messages, want %d", got, len(tt.answered))
+			}
+		})
+	}
+}
+
+func TestServeClosesIdleConnections(t *testing.T) {
+	tests := []struct {
+		name        string
+		readTimeout time.Duration
+		shutdown    bool
+	}{
+		{name: "read timeout", readTimeout: 20 * time.Millisecond},
+		{name: "shutdown", shutdown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			s := NewServer("", tt.readTimeout, 1<<20, func(context.Context, []byte, string) []byte {
+				return nil
+			})
+			_, done := client(ctx, t, s)
+
+			if tt.shutdown {
+				cancel()
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("idle connection still open")
+			}
+		})
+	}
+}
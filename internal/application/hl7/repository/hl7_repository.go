@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/outbox"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type HL7Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewHL7Repository(db *pgxpool.Pool) *HL7Repository {
+	return &HL7Repository{db: db}
+}
+
+// UpsertPatient registers the patient, or updates them when they are already
+// registered, and reports whether they were registered. A patient has no
+// identity card scan when they come from the registration desk, an update
+// keeps the one they have.
+func (r HL7Repository) UpsertPatient(ctx context.Context, patient *domain.Patient, user *domain.User) (bool, error) {
+	callerInfo := "[HL7Repository.UpsertPatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	old := domain.PatientAcquire()
+	defer domain.PatientRelease(old)
+	var isMale bool
+
+	registered := false
+	selectQuery := `SELECT phone_number, name, birth_date, is_male, img_url, created_at
+		FROM patients WHERE id = @id FOR UPDATE`
+	err = tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": patient.ID}).
+		Scan(&old.PhoneNumber, &old.Name, &old.BirthDate, &isMale, &old.ImgURL, &old.CreatedAt)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		registered = true
+	case err != nil:
+		l.Error("failed to get patient", zap.Error(err))
+		return false, err
+	}
+
+	var event *domain.PatientEvent
+	var changes map[string]patientevent.Change
+
+	if registered {
+		patient.CreatedAt = time.Now()
+
+		// a concurrent registration of the same patient fails on the primary
+		// key, the sender gets an error and sends the message again
+		insertQuery := `INSERT INTO patients (id, phone_number, name, birth_date, is_male, img_url, created_at)
+			VALUES (@id, @phone_number, @name, @birth_date, @is_male, @img_url, @created_at)`
+		args := pgx.NamedArgs{
+			"id":           patient.ID,
+			"phone_number": patient.PhoneNumber,
+			"name":         patient.Name,
+			"birth_date":   patient.BirthDate,
+			"is_male":      patient.Gender == domain.GenderMale,
+			"img_url":      patient.ImgURL,
+			"created_at":   patient.CreatedAt,
+		}
+
+		if _, err = tx.Exec(ctx, insertQuery, args); err != nil {
+			l.Error("failed to register patient", zap.Error(err))
+			return false, err
+		}
+
+		event, err = patientevent.New(patient.ID, domain.PatientEventRegistered, user, map[string]string{
+			"phoneNumber": patient.PhoneNumber,
+			"name":        patient.Name,
+			"birthDate":   patient.BirthDate.Format(time.DateOnly),
+			"gender":      patient.Gender,
+		})
+		if err != nil {
+			l.Error("failed to encode patient event", zap.Error(err))
+			return false, err
+		}
+		event.CreatedAt = patient.CreatedAt
+	} else {
+		old.Gender = domain.GenderMale
+		if !isMale {
+			old.Gender = domain.GenderFemale
+		}
+		patient.ImgURL = old.ImgURL
+		patient.CreatedAt = old.CreatedAt
+
+		changes = patientevent.PatientChanges(old, patient)
+		if len(changes) == 0 {
+			return false, nil
+		}
+
+		updateQuery := `UPDATE patients SET phone_number = @phone_number, name = @name, birth_date = @birth_date,
+			is_male = @is_male WHERE id = @id`
+		args := pgx.NamedArgs{
+			"id":           patient.ID,
+			"phone_number": patient.PhoneNumber,
+			"name":         patient.Name,
+			"birth_date":   patient.BirthDate,
+			"is_male":      patient.Gender == domain.GenderMale,
+		}
+
+		if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+			l.Error("failed to update patient", zap.Error(err))
+			return false, err
+		}
+
+		event, err = patientevent.New(patient.ID, domain.PatientEventUpdated, user, changes)
+		if err != nil {
+			l.Error("failed to encode patient event", zap.Error(err))
+			return false, err
+		}
+	}
+	defer domain.PatientEventRelease(event)
+
+	if err = patientevent.Insert(ctx, tx, event); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return false, err
+	}
+
+	outboxType, data := domain.OutboxPatientRegistered, outbox.Patient(patient, user)
+	if !registered {
+		outboxType = domain.OutboxPatientUpdated
+		data.Changes = changes
+	}
+
+	if err = outbox.Write(ctx, tx, outboxType, patient.ID, data); err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return false, err
+	}
+
+	return registered, nil
+}
+
+// SaveRejected keeps a message that was turned down so it can be replayed.
+func (r HL7Repository) SaveRejected(ctx context.Context, message *domain.HL7Message) error {
+	callerInfo := "[HL7Repository.SaveRejected]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	message.ID = id.New()
+
+	insertQuery := `INSERT INTO hl7_rejected_messages (id, control_id, message_type, sending_application,
+		remote_addr, raw, error, received_at)
+		VALUES (@id, @control_id, @message_type, @sending_application, @remote_addr, @raw, @error, @received_at)`
+	args := pgx.NamedArgs{
+		"id":                  message.ID,
+		"control_id":          message.ControlID,
+		"message_type":        message.MessageType,
+		"sending_application": message.SendingApplication,
+		"remote_addr":         message.RemoteAddr,
+		"raw":                 message.Raw,
+		"error":               message.Error,
+		"received_at":         message.ReceivedAt,
+	}
+
+	if _, err := r.db.Exec(ctx, insertQuery, args); err != nil {
+		l.Error("failed to save rejected message", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SaveReplay stores the outcome of replaying message, ReplayedAt is set when
+// the replay was accepted.
+func (r HL7Repository) SaveReplay(ctx context.Context, message *domain.HL7Message) error {
+	callerInfo := "[HL7Repository.SaveReplay]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var replayedAt any
+	if !message.ReplayedAt.IsZero() {
+		replayedAt = message.ReplayedAt
+	}
+
+	updateQuery := `UPDATE hl7_rejected_messages SET error = @error, replay_count = @replay_count,
+		replayed_at = @replayed_at WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":           message.ID,
+		"error":        message.Error,
+		"replay_count": message.ReplayCount,
+		"replayed_at":  replayedAt,
+	}
+
+	if _, err := r.db.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to save replay", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r HL7Repository) GetRejected(
+	ctx context.Context,
+	filter *domain.FilterHL7Message,
+	messages domain.HL7Messages,
+) (domain.HL7Messages, error) {
+	callerInfo := "[HL7Repository.GetRejected]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterRejected(filter)
+	getQuery := `SELECT id, control_id, message_type, sending_application, remote_addr, raw, error,
+		received_at, replay_count, replayed_at FROM hl7_rejected_messages` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get rejected messages", zap.Error(err))
+		return messages, err
+	}
+
+	dMessage := domain.HL7MessageAcquire()
+	defer domain.HL7MessageRelease(dMessage)
+	var replayedAt *time.Time
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dMessage.ID,
+			&dMessage.ControlID,
+			&dMessage.MessageType,
+			&dMessage.SendingApplication,
+			&dMessage.RemoteAddr,
+			&dMessage.Raw,
+			&dMessage.Error,
+			&dMessage.ReceivedAt,
+			&dMessage.ReplayCount,
+			&replayedAt,
+		},
+		func() error {
+			dMessage.ReplayedAt = time.Time{}
+			if replayedAt != nil {
+				dMessage.ReplayedAt = *replayedAt
+			}
+			messages = append(messages, *dMessage)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get rejected messages", zap.Error(err))
+		return messages, err
+	}
+
+	return messages, nil
+}
+
+func (r HL7Repository) filterRejected(filter *domain.FilterHL7Message) (string, pgx.NamedArgs) {
+	const totalConditions = 4
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.ControlID != "" {
+		conditions = append(conditions, "control_id = @control_id")
+		params["control_id"] = filter.ControlID
+	}
+
+	if filter.MessageType != "" {
+		conditions = append(conditions, "message_type = @message_type")
+		params["message_type"] = filter.MessageType
+	}
+
+	switch filter.Status {
+	case domain.HL7MessagePending:
+		conditions = append(conditions, "replayed_at IS NULL")
+	case domain.HL7MessageReplayed:
+		conditions = append(conditions, "replayed_at IS NOT NULL")
+	}
+
+	order := " ORDER BY received_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ HL7RepositoryContract = (*HL7Repository)(nil)
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type HL7RepositoryContract interface {
+	UpsertPatient(ctx context.Context, patient *domain.Patient, user *domain.User) (bool, error)
+	SaveRejected(ctx context.Context, message *domain.HL7Message) error
+	SaveReplay(ctx context.Context, message *domain.HL7Message) error
+	GetRejected(
+		ctx context.Context,
+		filter *domain.FilterHL7Message,
+		messages domain.HL7Messages,
+	) (domain.HL7Messages, error)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/message"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	typeADT = "ADT"
+
+	// identifierTypeNational is the CX.5 type of a national identity number,
+	// HL7 table 0203
+	identifierTypeNational = "NNIDN"
+	assigningAuthorityNIK  = "NIK"
+)
+
+// adtEvents are the trigger events that register or update a patient:
+// admission, registration and a change of patient information.
+var adtEvents = map[string]bool{
+	"A01": true,
+	"A04": true,
+	"A08": true,
+}
+
+// rejection is a message turned down, with the ACK code and the table 0357
+// error code to answer with.
+type rejection struct {
+	ack       string
+	errorCode string
+	err       error
+}
+
+func (r rejection) Error() string {
+	return r.err.Error()
+}
+
+func reject(errorCode string, err error) rejection {
+	return rejection{ack: message.AckReject, errorCode: errorCode, err: err}
+}
+
+func invalid(errorCode string, err error) rejection {
+	return rejection{ack: message.AckError, errorCode: errorCode, err: err}
+}
+
+// checkType turns down what is not an ADT message this module handles.
+func checkType(msg *message.Message) error {
+	if msg.Type() != typeADT {
+		return reject(message.ErrUnsupportedMessage, errors.New("message type "+msg.Type()+" is not supported"))
+	}
+
+	if !adtEvents[msg.Event()] {
+		return reject(message.ErrUnsupportedEvent, errors.New("trigger event "+msg.Event()+" is not supported"))
+	}
+
+	return nil
+}
+
+// newPatient maps PID to a patient with the same rules the registration
+// endpoint applies.
+func newPatient(msg *message.Message, patient *domain.Patient) error {
+	pid, ok := msg.Segment(message.SegmentPID)
+	if !ok {
+		return invalid(message.ErrSegmentSequence, errors.New("PID segment is missing"))
+	}
+
+	identityNumber := pidIdentityNumber(pid.Field(3))
+	if identityNumber == "" {
+		return invalid(message.ErrRequiredFieldMissing, errors.New("PID-3 has no national identity number"))
+	}
+	if len(identityNumber) != 16 || strings.IndexFunc(identityNumber, notDigit) >= 0 {
+		return invalid(message.ErrDataType, errors.New("PID-3 identity number must have 16 digits"))
+	}
+
+	name := pidName(pid.Field(5))
+	if name == "" {
+		return invalid(message.ErrRequiredFieldMissing, errors.New("PID-5 patient name is required"))
+	}
+	if len(name) < 3 || len(name) > 30 {
+		return invalid(message.ErrDataType, errors.New("PID-5 patient name must have 3 to 30 characters"))
+	}
+
+	birthDate := pid.Field(7).Component(1)
+	if birthDate == "" {
+		return invalid(message.ErrRequiredFieldMissing, errors.New("PID-7 date of birth is required"))
+	}
+	if len(birthDate) < 8 {
+		return invalid(message.ErrDataType, errors.New("PID-7 date of birth must be in format YYYYMMDD"))
+	}
+	parsedBirthDate, err := time.Parse("20060102", birthDate[:8])
+	if err != nil {
+		return invalid(message.ErrDataType, errors.New("PID-7 date of birth must be in format YYYYMMDD"))
+	}
+
+	var gender string
+	switch pid.Field(8).Component(1) {
+	case "M":
+		gender = domain.GenderMale
+	case "F":
+		gender = domain.GenderFemale
+	case "":
+		return invalid(message.ErrRequiredFieldMissing, errors.New("PID-8 administrative sex is required"))
+	default:
+		return invalid(message.ErrDataType, errors.New("PID-8 administrative sex must be M or F"))
+	}
+
+	phoneNumber := pidPhoneNumber(pid.Field(13))
+	if phoneNumber == "" {
+		return invalid(message.ErrRequiredFieldMissing, errors.New("PID-13 home phone number is required"))
+	}
+	if !strings.HasPrefix(phoneNumber, "62") || len(phoneNumber) < 9 || len(phoneNumber) > 14 {
+		return invalid(message.ErrDataType, errors.New("PID-13 phone number must be an Indonesian number"))
+	}
+
+	patient.ID = identityNumber
+	patient.Name = name
+	patient.BirthDate = parsedBirthDate
+	patient.Gender = gender
+	patient.PhoneNumber = phoneNumber
+
+	return nil
+}
+
+func notDigit(r rune) bool {
+	return !unicode.IsDigit(r)
+}
+
+// pidIdentityNumber picks the national identity number out of the patient
+// identifiers, by its identifier type or assigning authority, or the only
+// identifier when there is just one and it has no type.
+func pidIdentityNumber(field message.Field) string {
+	repetitions := field.Repetitions()
+
+	for _, repetition := range repetitions {
+		if repetition.Component(5) == identifierTypeNational ||
+			strings.EqualFold(repetition.Component(4), assigningAuthorityNIK) {
+			return repetition.Component(1)
+		}
+	}
+
+	if len(repetitions) == 1 && repetitions[0].Component(5) == "" {
+		return repetitions[0].Component(1)
+	}
+
+	return ""
+}
+
+// pidName joins the given names and the family name of the first name in
+// PID-5.
+func pidName(field message.Field) string {
+	parts := []string{field.Component(2), field.Component(3), field.Component(1)}
+
+	name := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			name = append(name, part)
+		}
+	}
+
+	return strings.Join(name, " ")
+}
+
+// pidPhoneNumber takes the first home number, from the unformatted number in
+// XTN.12 or the old style number in XTN.1, and stores it the way the
+// registration endpoint does, 62 followed by the number without its
+// leading zero.
+func pidPhoneNumber(field message.Field) string {
+	number := field.Component(12)
+	if number == "" {
+		number = field.Component(1)
+	}
+	if number == "" && field.Component(7) != "" {
+		number = field.Component(6) + field.Component(7)
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, number)
+
+	if strings.HasPrefix(digits, "0") {
+		digits = "62" + strings.TrimPrefix(digits, "0")
+	}
+
+	return digits
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/message"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// sample reads a message from the testdata of the module, with carriage
+// returns between the segments.
+func sample(t *testing.T, name string) []byte {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.ReplaceAll(raw, []byte("\n"), []byte("\r"))
+}
+
+func parse(t *testing.T, raw []byte) *message.Message {
+	t.Helper()
+
+	msg, err := message.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestNewPatient(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want domain.Patient
+	}{
+		{
+			name: "admission",
+			file: "adt_a01.hl7",
+			want: domain.Patient{
+				ID:          "3201010101010001",
+				Name:        "BUDI SANTOSO",
+				BirthDate:   time.Date(1990, 2, 3, 0, 0, 0, 0, time.UTC),
+				Gender:      domain.GenderMale,
+				PhoneNumber: "6281234567890",
+			},
+		},
+		{
+			name: "registration",
+			file: "adt_a04.hl7",
+			want: domain.Patient{
+				ID:          "3273014502950002",
+				Name:        "SITI NUR AMINAH",
+				BirthDate:   time.Date(1995, 2, 5, 0, 0, 0, 0, time.UTC),
+				Gender:      domain.GenderFemale,
+				PhoneNumber: "6281234567890",
+			},
+		},
+		{
+			name: "update",
+			file: "adt_a08.hl7",
+			want: domain.Patient{
+				ID:          "3201010101010001",
+				Name:        "BUDI O&SANTOSO",
+				BirthDate:   time.Date(1990, 2, 3, 0, 0, 0, 0, time.UTC),
+				Gender:      domain.GenderMale,
+				PhoneNumber: "628123456789",
+			},
+		},
+		{
+			name: "update with line feeds",
+			file: "adt_a08_lf.hl7",
+			want: domain.Patient{
+				ID:          "3201010101010001",
+				Name:        "BUDI SANTOSO",
+				BirthDate:   time.Date(1990, 2, 3, 0, 0, 0, 0, time.UTC),
+				Gender:      domain.GenderMale,
+				PhoneNumber: "6281234567890",
+			},
+		},
+		{
+			name: "registration with custom delimiters",
+			file: "adt_a04_custom_delimiters.hl7",
+			want: domain.Patient{
+				ID:          "3273014502950002",
+				Name:        "SITI NUR AMINAH",
+				BirthDate:   time.Date(1995, 2, 5, 0, 0, 0, 0, time.UTC),
+				Gender:      domain.GenderFemale,
+				PhoneNumber: "6281234567890",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := parse(t, sample(t, tt.file))
+
+			if err := checkType(msg); err != nil {
+				t.Fatalf("checkType() error = %v", err)
+			}
+
+			var patient domain.Patient
+			if err := newPatient(msg, &patient); err != nil {
+				t.Fatalf("newPatient() error = %v", err)
+			}
+
+			if patient != tt.want {
+				t.Errorf("newPatient() = %+v, want %+v", patient, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckType(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		errorCode string
+	}{
+		{name: "admission", file: "adt_a01.hl7"},
+		{name: "registration", file: "adt_a04.hl7"},
+		{name: "update", file: "adt_a08.hl7"},
+		{name: "discharge", file: "adt_a03.hl7", errorCode: message.ErrUnsupportedEvent},
+		{name: "order", file: "orm_o01.hl7", errorCode: message.ErrUnsupportedMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkType(parse(t, sample(t, tt.file)))
+			if tt.errorCode == "" {
+				if err != nil {
+					t.Errorf("checkType() error = %v", err)
+				}
+				return
+			}
+
+			var rejected rejection
+			if !errors.As(err, &rejected) {
+				t.Fatalf("checkType() error = %v, want a rejection", err)
+			}
+			if rejected.ack != message.AckReject || rejected.errorCode != tt.errorCode {
+				t.Errorf("checkType() = %s %s, want %s %s",
+					rejected.ack, rejected.errorCode, message.AckReject, tt.errorCode)
+			}
+		})
+	}
+}
+
+// pidMessage is an A04 whose PID has the fields of a valid registration,
+// replaced by the ones given.
+func pidMessage(fields map[int]string) []byte {
+	pid := map[int]string{
+		1:  "1",
+		3:  "3201010101010001^^^NIK^NNIDN",
+		5:  "SANTOSO^BUDI",
+		7:  "19900203",
+		8:  "M",
+		13: "081234567890",
+	}
+	for n, value := range fields {
+		pid[n] = value
+	}
+
+	segment := make([]string, 14)
+	segment[0] = message.SegmentPID
+	for n, value := range pid {
+		segment[n] = value
+	}
+
+	return []byte("MSH|^~\\&|SIMRS|RSUD_BANDUNG|HALO|HALO_SUSTER|20240501083000+0700||ADT^A04^ADT_A01|MSG00001|P|2.5\r" +
+		strings.Join(segment, "|") + "\r")
+}
+
+func TestNewPatientInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       func(t *testing.T) []byte
+		errorCode string
+	}{
+		{
+			name:      "PID segment missing",
+			raw:       func(t *testing.T) []byte { return sample(t, "adt_a04_no_pid.hl7") },
+			errorCode: message.ErrSegmentSequence,
+		},
+		{
+			name:      "no identifier",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{3: ""}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "only a medical record number",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{3: "RM-000123^^^PKM^MR"}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "several identifiers without a type",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{3: "3201010101010001~3201010101010002"}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "identity number too short",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{3: "320101010101000^^^NIK"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "identity number with letters",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{3: "32010101010100AB^^^NIK"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "no name",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{5: "^^"}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "name too short",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{5: "AB"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "name too long",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{5: "SANTOSO^BUDI^PRATAMA WIJAYA KUSUMA"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "no date of birth",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{7: ""}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "date of birth without a day",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{7: "199002"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "date of birth out of range",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{7: "19901340"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "no administrative sex",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{8: ""}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "unknown administrative sex",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{8: "U"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "no phone number",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{13: ""}) },
+			errorCode: message.ErrRequiredFieldMissing,
+		},
+		{
+			name:      "foreign phone number",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{13: "^PRN^PH^^^^^^^^^+6581234567"}) },
+			errorCode: message.ErrDataType,
+		},
+		{
+			name:      "phone number too short",
+			raw:       func(*testing.T) []byte { return pidMessage(map[int]string{13: "0812"}) },
+			errorCode: message.ErrDataType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patient domain.Patient
+			err := newPatient(parse(t, tt.raw(t)), &patient)
+
+			var rejected rejection
+			if !errors.As(err, &rejected) {
+				t.Fatalf("newPatient() error = %v, want a rejection", err)
+			}
+			if rejected.ack != message.AckError || rejected.errorCode != tt.errorCode {
+				t.Errorf("newPatient() = %s %s (%v), want %s %s",
+					rejected.ack, rejected.errorCode, rejected.err, message.AckError, tt.errorCode)
+			}
+			if patient != (domain.Patient{}) {
+				t.Errorf("newPatient() filled in %+v", patient)
+			}
+		})
+	}
+}
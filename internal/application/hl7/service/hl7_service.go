@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/message"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// maxStaffName is the length of patient_events.staff_name.
+const maxStaffName = 50
+
+type HL7Service struct {
+	hl7Repository  repository.HL7RepositoryContract
+	contextTimeout time.Duration
+	sender         message.Sender
+}
+
+func NewHL7Service(
+	timeout time.Duration,
+	sender message.Sender,
+	hl7Repository repository.HL7RepositoryContract,
+) *HL7Service {
+	return &HL7Service{
+		hl7Repository:  hl7Repository,
+		contextTimeout: timeout,
+		sender:         sender,
+	}
+}
+
+// Ingest handles a message received over MLLP and returns the ACK to answer
+// it with. A message that is turned down is kept for replay, the ACK tells
+// the sender why.
+func (s HL7Service) Ingest(ctx context.Context, raw []byte, remoteAddr string) []byte {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[HL7Service.Ingest]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	now := time.Now()
+	msg, ack := s.process(ctx, raw)
+
+	if ack.Code != message.AckAccept {
+		rejected := domain.HL7MessageAcquire()
+		defer domain.HL7MessageRelease(rejected)
+
+		rejected.RemoteAddr = remoteAddr
+		rejected.Raw = string(raw)
+		rejected.Error = ack.Text
+		rejected.ReceivedAt = now
+		if msg != nil {
+			rejected.ControlID = msg.ControlID()
+			rejected.MessageType = msg.Type() + "^" + msg.Event()
+			rejected.SendingApplication = msg.SendingApplication()
+		}
+
+		l.Warn("HL7 message rejected",
+			zap.String("controlId", rejected.ControlID),
+			zap.String("type", rejected.MessageType),
+			zap.String("ack", ack.Code),
+			zap.String("error", ack.Text),
+		)
+
+		// the ACK is sent anyway, the sender keeps its own copy
+		if err := s.hl7Repository.SaveRejected(ctx, rejected); err != nil {
+			l.Error("failed to save rejected message", zap.Error(err))
+		}
+	}
+
+	return message.NewACK(msg, s.sender, id.New().String(), ack, now)
+}
+
+// process applies the message and returns the ACK for it, along with the
+// parsed message unless it could not be parsed.
+func (s HL7Service) process(ctx context.Context, raw []byte) (*message.Message, message.Ack) {
+	callerInfo := "[HL7Service.process]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	msg, err := message.Parse(raw)
+	if err != nil {
+		return nil, message.Ack{Code: message.AckReject, ErrorCode: message.ErrSegmentSequence, Text: err.Error()}
+	}
+
+	err = s.apply(ctx, msg)
+
+	var rejected rejection
+	switch {
+	case err == nil:
+		return msg, message.Ack{Code: message.AckAccept}
+	case errors.As(err, &rejected):
+		return msg, message.Ack{Code: rejected.ack, ErrorCode: rejected.errorCode, Text: rejected.Error()}
+	default:
+		// the sender may send it again, so the cause is not reported to it
+		l.Error("failed to apply HL7 message", zap.String("controlId", msg.ControlID()), zap.Error(err))
+		return msg, message.Ack{
+			Code:      message.AckError,
+			ErrorCode: message.ErrInternal,
+			Text:      "Message could not be processed, send it again later",
+		}
+	}
+}
+
+// apply registers or updates the patient in PID, the change is authored by
+// the sending application.
+func (s HL7Service) apply(ctx context.Context, msg *message.Message) error {
+	callerInfo := "[HL7Service.apply]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := checkType(msg); err != nil {
+		return err
+	}
+
+	patient := domain.PatientAcquire()
+	defer domain.PatientRelease(patient)
+
+	if err := newPatient(msg, patient); err != nil {
+		return err
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+
+	user.Name = "HL7 " + msg.SendingApplication()
+	if len(user.Name) > maxStaffName {
+		user.Name = user.Name[:maxStaffName]
+	}
+
+	registered, err := s.hl7Repository.UpsertPatient(ctx, patient, user)
+	if err != nil {
+		return err
+	}
+
+	l.Info("HL7 message applied",
+		zap.String("controlId", msg.ControlID()),
+		zap.String("event", msg.Event()),
+		zap.String("patientId", patient.ID),
+		zap.Bool("registered", registered),
+	)
+
+	return nil
+}
+
+func (s HL7Service) GetRejected(
+	ctx context.Context,
+	filter *domain.FilterHL7Message,
+	messages domain.HL7Messages,
+) (domain.HL7Messages, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[HL7Service.GetRejected]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	messages, err := s.hl7Repository.GetRejected(ctx, filter, messages)
+	if err != nil {
+		l.Error("failed to get rejected messages", zap.Error(err))
+		return messages, err
+	}
+
+	return messages, nil
+}
+
+// Replay applies a rejected message again, for when its cause has been fixed
+// on either side. A replay that is turned down again keeps the new reason.
+func (s HL7Service) Replay(ctx context.Context, rejected *domain.HL7Message) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[HL7Service.Replay]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterHL7MessageAcquire()
+	defer domain.FilterHL7MessageRelease(filter)
+
+	filter.ID = rejected.ID
+	filter.Limit = 1
+
+	messages := domain.HL7MessagesAcquire()
+	defer domain.HL7MessagesRelease(messages)
+
+	messages, err := s.hl7Repository.GetRejected(ctx, filter, messages)
+	if err != nil {
+		l.Error("failed to get rejected message", zap.Error(err))
+		return err
+	}
+	if len(messages) == 0 {
+		return new(domain.ErrHL7MessageNotFound)
+	}
+	*rejected = messages[0]
+
+	if !rejected.ReplayedAt.IsZero() {
+		return new(domain.ErrHL7MessageReplayed)
+	}
+
+	_, ack := s.process(ctx, []byte(rejected.Raw))
+
+	rejected.ReplayCount++
+	if ack.Code == message.AckAccept {
+		rejected.ReplayedAt = time.Now()
+	} else {
+		rejected.Error = ack.Text
+	}
+
+	if err = s.hl7Repository.SaveReplay(ctx, rejected); err != nil {
+		l.Error("failed to save replay", zap.Error(err))
+		return err
+	}
+
+	if ack.Code != message.AckAccept {
+		return domain.ErrHL7MessageRejected{Reason: ack.Text}
+	}
+
+	return nil
+}
+
+var _ HL7ServiceContract = (*HL7Service)(nil)
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/message"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// fakeRepository keeps what the service stores, upserts fail with err.
+type fakeRepository struct {
+	repository.HL7RepositoryContract
+	err      error
+	patients []domain.Patient
+	users    []string
+	rejected []domain.HL7Message
+}
+
+func (r *fakeRepository) UpsertPatient(_ context.Context, patient *domain.Patient, user *domain.User) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+
+	r.patients = append(r.patients, *patient)
+	r.users = append(r.users, user.Name)
+	return true, nil
+}
+
+func (r *fakeRepository) SaveRejected(_ context.Context, msg *domain.HL7Message) error {
+	r.rejected = append(r.rejected, *msg)
+	return nil
+}
+
+func TestIngest(t *testing.T) {
+	sender := message.Sender{Application: "HALO", Facility: "HALO_SUSTER"}
+
+	tests := []struct {
+		name string
+		file string
+		err  error
+		// patient is the one registered when the message is accepted
+		patient   string
+		ack       string
+		controlID string
+		errorCode string
+		text      string
+		// messageType is saved with a rejected message
+		messageType string
+	}{
+		{
+			name:      "admission",
+			file:      "adt_a01.hl7",
+			patient:   "3201010101010001",
+			ack:       message.AckAccept,
+			controlID: "MSG00001",
+		},
+		{
+			name:      "registration",
+			file:      "adt_a04.hl7",
+			patient:   "3273014502950002",
+			ack:       message.AckAccept,
+			controlID: "MSG00002",
+		},
+		{
+			name:      "update",
+			file:      "adt_a08.hl7",
+			patient:   "3201010101010001",
+			ack:       message.AckAccept,
+			controlID: "MSG00003",
+		},
+		{
+			name:        "unsupported event",
+			file:        "adt_a03.hl7",
+			ack:         message.AckReject,
+			controlID:   "MSG00006",
+			errorCode:   message.ErrUnsupportedEvent,
+			text:        "trigger event A03 is not supported",
+			messageType: "ADT^A03",
+		},
+		{
+			name:        "unsupported message type",
+			file:        "orm_o01.hl7",
+			ack:         message.AckReject,
+			controlID:   "MSG00007",
+			errorCode:   message.ErrUnsupportedMessage,
+			text:        "message type ORM is not supported",
+			messageType: "ORM^O01",
+		},
+		{
+			name:        "PID segment missing",
+			file:        "adt_a04_no_pid.hl7",
+			ack:         message.AckError,
+			controlID:   "MSG00008",
+			errorCode:   message.ErrSegmentSequence,
+			text:        "PID segment is missing",
+			messageType: "ADT^A04",
+		},
+		{
+			name:      "MSH segment missing",
+			file:      "missing_msh.hl7",
+			ack:       message.AckReject,
+			errorCode: message.ErrSegmentSequence,
+			text:      message.ErrNoHeader.Error(),
+		},
+		{
+			name:      "bad encoding characters",
+			file:      "bad_encoding.hl7",
+			ack:       message.AckReject,
+			errorCode: message.ErrSegmentSequence,
+			text:      "MSH-2 does not declare the encoding characters",
+		},
+		{
+			name:        "repository failure",
+			file:        "adt_a01.hl7",
+			err:         errors.New("connection refused"),
+			ack:         message.AckError,
+			controlID:   "MSG00001",
+			errorCode:   message.ErrInternal,
+			text:        "Message could not be processed, send it again later",
+			messageType: "ADT^A01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{err: tt.err}
+			s := NewHL7Service(time.Second, sender, repo)
+
+			raw := sample(t, tt.file)
+			ack := parse(t, s.Ingest(context.Background(), raw, "10.0.0.5:52100"))
+
+			msa, ok := ack.Segment("MSA")
+			if !ok {
+				t.Fatal("ACK has no MSA segment")
+			}
+			if got := msa.Field(1).String(); got != tt.ack {
+				t.Errorf("MSA-1 = %q, want %q", got, tt.ack)
+			}
+			if got := msa.Field(2).String(); got != tt.controlID {
+				t.Errorf("MSA-2 = %q, want %q", got, tt.controlID)
+			}
+			if got := msa.Field(3).String(); got != tt.text {
+				t.Errorf("MSA-3 = %q, want %q", got, tt.text)
+			}
+
+			errSegment, hasErr := ack.Segment("ERR")
+			if got := errSegment.Field(3).Component(1); hasErr != (tt.errorCode != "") || got != tt.errorCode {
+				t.Errorf("ERR-3 = %q, want %q", got, tt.errorCode)
+			}
+
+			if tt.ack == message.AckAccept {
+				if len(repo.patients) != 1 || repo.patients[0].ID != tt.patient {
+					t.Errorf("registered %+v, want patient %s", repo.patients, tt.patient)
+				}
+				if len(repo.users) != 1 || repo.users[0] != "HL7 SIMRS" {
+					t.Errorf("registered by %v, want HL7 SIMRS", repo.users)
+				}
+				if len(repo.rejected) != 0 {
+					t.Errorf("saved %d rejected messages, want none", len(repo.rejected))
+				}
+				return
+			}
+
+			if len(repo.patients) != 0 {
+				t.Errorf("registered %+v, want none", repo.patients)
+			}
+			if len(repo.rejected) != 1 {
+				t.Fatalf("saved %d rejected messages, want 1", len(repo.rejected))
+			}
+
+			rejected := repo.rejected[0]
+			if rejected.Raw != string(raw) || rejected.RemoteAddr != "10.0.0.5:52100" {
+				t.Errorf("saved %q from %s, want the message received", rejected.Raw, rejected.RemoteAddr)
+			}
+			if rejected.ControlID != tt.controlID || rejected.MessageType != tt.messageType || rejected.Error != tt.text {
+				t.Errorf("saved %s %s %q, want %s %s %q",
+					rejected.ControlID, rejected.MessageType, rejected.Error, tt.controlID, tt.messageType, tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type HL7ServiceContract interface {
+	Ingest(ctx context.Context, raw []byte, remoteAddr string) []byte
+	GetRejected(
+		ctx context.Context,
+		filter *domain.FilterHL7Message,
+		messages domain.HL7Messages,
+	) (domain.HL7Messages, error)
+	Replay(ctx context.Context, message *domain.HL7Message) error
+}
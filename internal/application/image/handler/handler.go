@@ -2,6 +2,9 @@ package handler
 
 import (
 	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
@@ -37,15 +40,24 @@ func (h imageHandler) UploadImage(c *fiber.Ctx) error {
 	}
 
 	// Validate File
-	const minSize, maxSize = 10 * 1024, 2 * 1024 * 1024
-	if file.Size < int64(minSize) || file.Size > int64(maxSize) {
+	contentType := file.Header.Get("Content-Type")
+	limit, ok := uploadLimits[contentType]
+	if !ok {
+		l.Error("invalid file type")
+		return errBadRequest{err: errors.New("file type must be image/jpeg, image/png or application/pdf")}
+	}
+
+	if file.Size < limit.minSize || file.Size > limit.maxSize {
 		l.Error("invalid file size")
-		return errBadRequest{err: errors.New("file size must be between 10KB and 2MB")}
+		return errBadRequest{err: errors.New("file size must be between " + limit.sizes)}
 	}
 
-	if file.Header.Get("Content-Type") != "image/jpeg" {
-		l.Error("invalid file type")
-		return errBadRequest{err: errors.New("file type must be image/jpeg")}
+	if detected, err := detectContentType(file); err != nil {
+		l.Error("error reading file", zap.Error(err))
+		return errBadRequest{err: err}
+	} else if detected != contentType {
+		l.Error("file content does not match its type", zap.String("detected", detected))
+		return errBadRequest{err: errors.New("file content must be " + contentType)}
 	}
 
 	url, err := h.imageService.UploadImage(userCtx, file)
@@ -68,3 +80,34 @@ func (h imageHandler) UploadImage(c *fiber.Ctx) error {
 
 	return c.JSON(res)
 }
+
+type uploadLimit struct {
+	minSize, maxSize int64
+	sizes            string
+}
+
+// uploadLimits keeps the limits of a photo for JPEG, scanned documents and
+// reports are larger.
+var uploadLimits = map[string]uploadLimit{
+	"image/jpeg":      {minSize: 10 * 1024, maxSize: 2 * 1024 * 1024, sizes: "10KB and 2MB"},
+	"image/png":       {minSize: 1024, maxSize: 10 * 1024 * 1024, sizes: "1KB and 10MB"},
+	"application/pdf": {minSize: 1024, maxSize: 10 * 1024 * 1024, sizes: "1KB and 10MB"},
+}
+
+// detectContentType sniffs the type of the file from its content, the type
+// the client declares is not trusted on its own.
+func detectContentType(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+
+	return http.DetectContentType(head[:n]), nil
+}
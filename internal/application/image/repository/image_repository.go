@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
+	"net/url"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
@@ -25,6 +28,19 @@ func NewImageRepository(client *s3.Client) *ImageRepository {
 	}
 }
 
+// IsUploadURL reports whether u is the location of an object of the bucket,
+// the virtual-hosted form the uploader returns, so a record cannot link to a
+// file outside of it.
+func IsUploadURL(u *url.URL) bool {
+	s3Config := configs.Get().S3
+	host := s3Config.BucketName + ".s3." + s3Config.Region + ".amazonaws.com"
+
+	return u.Scheme == "https" &&
+		u.User == nil &&
+		strings.EqualFold(u.Host, host) &&
+		strings.TrimPrefix(u.Path, "/") != ""
+}
+
 func (r ImageRepository) UploadImage(ctx context.Context, image *multipart.FileHeader) (string, error) {
 	callerInfo := "[ImageRepository.UploadImage]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
@@ -34,11 +50,13 @@ func (r ImageRepository) UploadImage(ctx context.Context, image *multipart.FileH
 		l.Error("error opening image", zap.Error(err))
 		return "", err
 	}
+	defer img.Close()
 
 	params := &s3.PutObjectInput{
-		Bucket: aws.String(configs.Get().S3.BucketName),
-		Key:    aws.String(image.Filename),
-		Body:   img,
+		Bucket:      aws.String(configs.Get().S3.BucketName),
+		Key:         aws.String(image.Filename),
+		Body:        img,
+		ContentType: aws.String(image.Header.Get("Content-Type")),
 	}
 
 	result, err := r.uploader.Upload(ctx, params)
@@ -50,4 +68,63 @@ func (r ImageRepository) UploadImage(ctx context.Context, image *multipart.FileH
 	return result.Location, nil
 }
 
+// Upload stores body under key, for files made by the API rather than
+// uploaded by a user.
+func (r ImageRepository) Upload(ctx context.Context, key, contentType string, body io.Reader) (string, error) {
+	callerInfo := "[ImageRepository.Upload]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	params := &s3.PutObjectInput{
+		Bucket:      aws.String(configs.Get().S3.BucketName),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	}
+
+	result, err := r.uploader.Upload(ctx, params)
+	if err != nil {
+		l.Error("error uploading file", zap.Error(err))
+		return "", err
+	}
+
+	return result.Location, nil
+}
+
+// Download opens the object under key, the caller closes the body.
+func (r ImageRepository) Download(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	callerInfo := "[ImageRepository.Download]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(configs.Get().S3.BucketName),
+		Key:    aws.String(key),
+	}
+
+	result, err := r.client.GetObject(ctx, params)
+	if err != nil {
+		l.Error("error downloading file", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return result.Body, aws.ToInt64(result.ContentLength), nil
+}
+
+// Delete removes the object under key.
+func (r ImageRepository) Delete(ctx context.Context, key string) error {
+	callerInfo := "[ImageRepository.Delete]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	params := &s3.DeleteObjectInput{
+		Bucket: aws.String(configs.Get().S3.BucketName),
+		Key:    aws.String(key),
+	}
+
+	if _, err := r.client.DeleteObject(ctx, params); err != nil {
+		l.Error("error deleting file", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 var _ ImageRepositoryContract = (*ImageRepository)(nil)
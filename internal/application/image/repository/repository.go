@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
 )
 
 type ImageRepositoryContract interface {
 	UploadImage(ctx context.Context, image *multipart.FileHeader) (string, error)
+	Upload(ctx context.Context, key, contentType string, body io.Reader) (string, error)
+	Download(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	Delete(ctx context.Context, key string) error
 }
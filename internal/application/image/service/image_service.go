@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"mime/multipart"
-	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,6 +14,14 @@ import (
 	"github.com/j03hanafi/halo-suster/internal/application/image/repository"
 )
 
+// fileExtensions names the uploaded files after their type rather than the
+// name the client sent.
+var fileExtensions = map[string]string{
+	"image/jpeg":      "jpg",
+	"image/png":       "png",
+	"application/pdf": "pdf",
+}
+
 type ImageService struct {
 	imageRepository repository.ImageRepositoryContract
 	contextTimeout  time.Duration
@@ -34,12 +41,11 @@ func (s ImageService) UploadImage(ctx context.Context, image *multipart.FileHead
 	callerInfo := "[ImageService.UploadImage]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
 
-	getExt := strings.Split(image.Filename, ".")
-	if len(getExt) == 0 {
-		l.Error("invalid image extension", zap.String("filename", image.Filename))
-		return "", errors.New("failed to get image extension")
+	ext, ok := fileExtensions[image.Header.Get("Content-Type")]
+	if !ok {
+		l.Error("invalid file type", zap.String("contentType", image.Header.Get("Content-Type")))
+		return "", errors.New("failed to get file extension")
 	}
-	ext := getExt[len(getExt)-1]
 
 	image.Filename = configs.Get().App.Name + "_" + id.New().String() + "." + ext
 
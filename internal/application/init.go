@@ -1,23 +1,66 @@
 package application
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/patrickmn/go-cache"
 
 	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/appointment"
+	"github.com/j03hanafi/halo-suster/internal/application/document"
+	"github.com/j03hanafi/halo-suster/internal/application/encounter"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir"
+	"github.com/j03hanafi/halo-suster/internal/application/handover"
+	"github.com/j03hanafi/halo-suster/internal/application/hl7"
 	"github.com/j03hanafi/halo-suster/internal/application/image"
 	"github.com/j03hanafi/halo-suster/internal/application/info"
+	"github.com/j03hanafi/halo-suster/internal/application/job"
+	"github.com/j03hanafi/halo-suster/internal/application/lab"
+	"github.com/j03hanafi/halo-suster/internal/application/label"
+	"github.com/j03hanafi/halo-suster/internal/application/live"
 	"github.com/j03hanafi/halo-suster/internal/application/medical"
+	"github.com/j03hanafi/halo-suster/internal/application/medication"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat"
+	"github.com/j03hanafi/halo-suster/internal/application/shift"
+	"github.com/j03hanafi/halo-suster/internal/application/task"
 	"github.com/j03hanafi/halo-suster/internal/application/user"
+	"github.com/j03hanafi/halo-suster/internal/application/ward"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook"
 )
 
-func New(server *fiber.App, db *pgxpool.Pool, s3 *s3.Client, jwtCache *cache.Cache, jwtMiddleware fiber.Handler) {
+// New registers every module on server, background work started by the
+// modules runs until ctx is done.
+func New(
+	ctx context.Context,
+	server *fiber.App,
+	db *pgxpool.Pool,
+	s3 *s3.Client,
+	jwtCache *cache.Cache,
+	jwtMiddleware fiber.Handler,
+) {
 	router := server.Group(configs.Get().API.BaseURL)
 
 	info.NewModule(router, db)
 	user.NewModule(router, db, jwtCache, jwtMiddleware)
 	medical.NewModule(router, db, jwtMiddleware)
+	encounter.NewModule(router, db, jwtMiddleware)
+	ward.NewModule(router, db, jwtMiddleware)
+	shift.NewModule(router, db, jwtMiddleware)
+	handover.NewModule(router, db, jwtMiddleware)
+	medication.NewModule(router, db, jwtMiddleware)
+	task.NewModule(ctx, router, db, jwtMiddleware)
+	appointment.NewModule(router, db, jwtMiddleware)
+	lab.NewModule(router, db, jwtMiddleware)
+	document.NewModule(router, db, s3, jwtMiddleware)
+	label.NewModule(router, db, jwtMiddleware)
+	fhir.NewModule(router, db, jwtMiddleware)
 	image.NewModule(router, s3, jwtMiddleware)
+	satusehat.NewModule(ctx, router, db, jwtMiddleware)
+	hl7.NewModule(ctx, router, db, jwtMiddleware)
+	job.NewModule(ctx, router, db, s3, jwtCache, jwtMiddleware)
+	webhook.NewModule(ctx, router, db, jwtMiddleware)
+	live.NewModule(ctx, router, db, jwtMiddleware)
 }
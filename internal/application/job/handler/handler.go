@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/job/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const jobIDFromParam = "id"
+
+type jobHandler struct {
+	jobService service.JobServiceContract
+}
+
+func NewJobHandler(router fiber.Router, jwtMiddleware fiber.Handler, jobService service.JobServiceContract) {
+	handler := jobHandler{
+		jobService: jobService,
+	}
+
+	jobRouter := router.Group("/job", jwtMiddleware)
+	jobRouter.Get("", handler.GetJobs)
+	jobRouter.Get("/:"+jobIDFromParam, handler.GetJob)
+	jobRouter.Get("/:"+jobIDFromParam+"/result", handler.GetJobResult)
+	jobRouter.Post("/:"+jobIDFromParam+"/retry", handler.RetryJob)
+}
+
+// GetJobs returns the jobs of the user, or of everyone for IT staff, most
+// recently queued first.
+func (h jobHandler) GetJobs(c *fiber.Ctx) error {
+	callerInfo := "[jobHandler.GetJobs]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryJobAcquire()
+	defer queryJobRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterJobAcquire()
+	defer domain.FilterJobRelease(filter)
+
+	query.toFilter(filter)
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	jobs := domain.JobsAcquire()
+	defer domain.JobsRelease(jobs)
+
+	jobs, err := h.jobService.GetJobs(userCtx, filter, user, jobs)
+	if err != nil {
+		l.Error("failed to get jobs", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Jobs retrieved successfully"
+
+	jobsRes := getJobsResAcquire()
+	defer getJobsResRelease(jobsRes)
+
+	for i := range jobs {
+		jobsRes = append(jobsRes, newJobRes(&jobs[i]))
+	}
+
+	res.Data = jobsRes
+
+	return c.JSON(res)
+}
+
+// GetJob returns the status and progress of a job.
+func (h jobHandler) GetJob(c *fiber.Ctx) error {
+	callerInfo := "[jobHandler.GetJob]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	jobID, err := ulid.Parse(c.Params(jobIDFromParam))
+	if err != nil {
+		l.Error("error parsing jobIDParam", zap.Error(err))
+		return new(domain.ErrJobNotFound)
+	}
+
+	job := domain.JobAcquire()
+	defer domain.JobRelease(job)
+
+	job.ID = jobID
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	err = h.jobService.GetJob(userCtx, job, user)
+	if err != nil {
+		l.Error("failed to get job", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Job retrieved successfully"
+	res.Data = newJobRes(job)
+
+	return c.JSON(res)
+}
+
+// GetJobResult sends the result file of a job that succeeded.
+func (h jobHandler) GetJobResult(c *fiber.Ctx) error {
+	callerInfo := "[jobHandler.GetJobResult]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	jobID, err := ulid.Parse(c.Params(jobIDFromParam))
+	if err != nil {
+		l.Error("error parsing jobIDParam", zap.Error(err))
+		return new(domain.ErrJobNotFound)
+	}
+
+	job := domain.JobAcquire()
+	defer domain.JobRelease(job)
+
+	job.ID = jobID
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	body, size, err := h.jobService.OpenResult(userCtx, job, user)
+	if err != nil {
+		l.Error("failed to open job result", zap.Error(err))
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, job.ResultType)
+	c.Attachment(job.ResultName)
+
+	// fasthttp closes body once it has been sent
+	return c.SendStream(body, int(size))
+}
+
+// RetryJob queues a failed job again with its attempts reset.
+func (h jobHandler) RetryJob(c *fiber.Ctx) error {
+	callerInfo := "[jobHandler.RetryJob]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	jobID, err := ulid.Parse(c.Params(jobIDFromParam))
+	if err != nil {
+		l.Error("error parsing jobIDParam", zap.Error(err))
+		return new(domain.ErrJobNotFound)
+	}
+
+	job := domain.JobAcquire()
+	defer domain.JobRelease(job)
+
+	job.ID = jobID
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	err = h.jobService.RetryJob(userCtx, job, user)
+	if err != nil {
+		l.Error("failed to retry job", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Job queued again"
+	res.Data = newJobRes(job)
+
+	return c.JSON(res)
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+var queryJobPool = sync.Pool{
+	New: func() any {
+		return new(queryJob)
+	},
+}
+
+func queryJobAcquire() *queryJob {
+	return queryJobPool.Get().(*queryJob)
+}
+
+func queryJobRelease(t *queryJob) {
+	*t = queryJob{}
+	queryJobPool.Put(t)
+}
+
+type queryJob struct {
+	Kind   string `query:"kind"`
+	Status string `query:"status"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+func (q *queryJob) validate() {
+	switch q.Kind {
+	case domain.JobPatientExport, domain.JobRecordExport, domain.JobUserExport, domain.JobPatientImport:
+	default:
+		q.Kind = ""
+	}
+
+	switch q.Status {
+	case domain.JobQueued, domain.JobRunning, domain.JobSucceeded, domain.JobFailed:
+	default:
+		q.Status = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *queryJob) toFilter(filter *domain.FilterJob) {
+	filter.Kind = q.Kind
+	filter.Status = q.Status
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+}
+
+type jobResultRes struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+}
+
+type jobRes struct {
+	JobID       ulid.ULID     `json:"jobId"`
+	Kind        string        `json:"kind"`
+	Status      string        `json:"status"`
+	Progress    int           `json:"progress"`
+	Total       int           `json:"total"`
+	Attempts    int           `json:"attempts"`
+	MaxAttempts int           `json:"maxAttempts"`
+	NextRunAt   string        `json:"nextRunAt,omitempty"`
+	LastError   string        `json:"lastError,omitempty"`
+	CreatedBy   string        `json:"createdBy"`
+	Result      *jobResultRes `json:"result,omitempty"`
+	CreatedAt   string        `json:"createdAt"`
+	UpdatedAt   string        `json:"updatedAt"`
+	StartedAt   string        `json:"startedAt,omitempty"`
+	FinishedAt  string        `json:"finishedAt,omitempty"`
+}
+
+func newJobRes(job *domain.Job) jobRes {
+	res := jobRes{
+		JobID:       job.ID,
+		Kind:        job.Kind,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		Total:       job.Total,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LastError:   job.LastError,
+		CreatedBy:   job.UserName,
+		CreatedAt:   job.CreatedAt.Format(dateFormat),
+		UpdatedAt:   job.UpdatedAt.Format(dateFormat),
+	}
+	// only a queued job has a run coming, a running one holds a lease until then
+	if job.Status == domain.JobQueued {
+		res.NextRunAt = job.RunAt.Format(dateFormat)
+	}
+	if job.Status == domain.JobSucceeded {
+		res.Result = &jobResultRes{
+			Name:        job.ResultName,
+			ContentType: job.ResultType,
+			Size:        job.ResultSize,
+			URL:         configs.Get().API.BaseURL + "/job/" + job.ID.String() + "/result",
+		}
+	}
+	if !job.StartedAt.IsZero() {
+		res.StartedAt = job.StartedAt.Format(dateFormat)
+	}
+	if !job.FinishedAt.IsZero() {
+		res.FinishedAt = job.FinishedAt.Format(dateFormat)
+	}
+	return res
+}
+
+const jobInitCap = 5
+
+var getJobsResPool = sync.Pool{
+	New: func() any {
+		return make(getJobsRes, 0, jobInitCap)
+	},
+}
+
+func getJobsResAcquire() getJobsRes {
+	return getJobsResPool.Get().(getJobsRes)
+}
+
+func getJobsResRelease(t getJobsRes) {
+	t = t[:0]
+	getJobsResPool.Put(t) // nolint:staticcheck
+}
+
+type getJobsRes []jobRes
@@ -0,0 +1,70 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	imagerepository "github.com/j03hanafi/halo-suster/internal/application/image/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/job/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/job/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/job/runner"
+	"github.com/j03hanafi/halo-suster/internal/application/job/service"
+	"github.com/j03hanafi/halo-suster/internal/application/job/worker"
+	medicalrepository "github.com/j03hanafi/halo-suster/internal/application/medical/repository"
+	medicalservice "github.com/j03hanafi/halo-suster/internal/application/medical/service"
+	userrepository "github.com/j03hanafi/halo-suster/internal/application/user/repository"
+	userservice "github.com/j03hanafi/halo-suster/internal/application/user/service"
+)
+
+// NewModule registers the job routes and starts the workers, which run jobs
+// until ctx is done. With prefork only the parent process runs jobs.
+func NewModule(
+	ctx context.Context,
+	router fiber.Router,
+	db *pgxpool.Pool,
+	s3 *s3.Client,
+	jwtCache *cache.Cache,
+	jwtMiddleware fiber.Handler,
+) {
+	cfg := configs.Get().Job
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	jobTimeout := time.Duration(cfg.Timeout) * time.Second
+	pollInterval := time.Duration(cfg.PollInterval) * time.Second
+
+	options := service.Options{
+		Timeout:        jobTimeout,
+		Lease:          time.Duration(cfg.Lease) * time.Second,
+		RetryBaseDelay: time.Duration(cfg.RetryBaseDelay) * time.Second,
+		RetryMaxDelay:  time.Duration(cfg.RetryMaxDelay) * time.Second,
+	}
+
+	// the work of a job is bound by the job timeout rather than the one of a
+	// request
+	medicalService := medicalservice.NewMedicalService(jobTimeout, medicalrepository.NewMedicalRepository(db))
+	userService := userservice.NewUserService(jobTimeout, userrepository.NewUserRepository(db, jwtCache))
+
+	jobRepository := repository.NewJobRepository(db)
+	storageRepository := imagerepository.NewImageRepository(s3)
+	jobService := service.NewJobService(
+		ctxTimeout,
+		options,
+		jobRepository,
+		storageRepository,
+		runner.New(medicalService, userService),
+	)
+	handler.NewJobHandler(router, jwtMiddleware, jobService)
+
+	if fiber.IsChild() || pollInterval <= 0 {
+		return
+	}
+
+	for i := range cfg.Workers {
+		go worker.NewWorker(i+1, pollInterval, jobService).Run(ctx)
+	}
+}
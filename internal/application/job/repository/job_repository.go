@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// jobColumns leaves out input, it is only read by the worker that claims the
+// job.
+const jobColumns = `id, kind, status, params, user_id, user_nip, user_name, progress, total, attempts,
+	max_attempts, run_at, last_error, result_key, result_name, result_type, result_size, created_at, updated_at,
+	started_at, finished_at`
+
+type JobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewJobRepository(db *pgxpool.Pool) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Claim takes the job that has been due the longest and leases it until
+// leaseUntil, so other workers skip it meanwhile. A running job whose lease
+// has run out lost its worker and is taken over. It reports whether there
+// was a job to claim.
+func (r JobRepository) Claim(ctx context.Context, now, leaseUntil time.Time, job *domain.Job) (bool, error) {
+	callerInfo := "[JobRepository.Claim]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	claimQuery := `UPDATE jobs SET status = @running, attempts = attempts + 1, run_at = @lease_until,
+		started_at = @now, updated_at = @now
+		WHERE id = (
+			SELECT id FROM jobs WHERE status IN (@queued, @running) AND run_at <= @now
+			ORDER BY run_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING ` + jobColumns + `, input`
+	args := pgx.NamedArgs{
+		"queued":      domain.JobQueued,
+		"running":     domain.JobRunning,
+		"now":         now,
+		"lease_until": leaseUntil,
+	}
+
+	rows, err := r.db.Query(ctx, claimQuery, args)
+	if err != nil {
+		l.Error("failed to claim job", zap.Error(err))
+		return false, err
+	}
+
+	jobs, err := r.collectJobs(rows, make(domain.Jobs, 0, 1), true)
+	if err != nil {
+		l.Error("failed to claim job", zap.Error(err))
+		return false, err
+	}
+
+	if len(jobs) == 0 {
+		return false, nil
+	}
+	*job = jobs[0]
+
+	return true, nil
+}
+
+// Heartbeat saves how far a running job has got and extends its lease. The
+// job is only updated while it is still on the attempt the worker claimed,
+// once another worker has taken it over it reports ErrJobLeaseLost.
+func (r JobRepository) Heartbeat(
+	ctx context.Context,
+	jobID ulid.ULID,
+	attempts, progress, total int,
+	leaseUntil time.Time,
+) error {
+	callerInfo := "[JobRepository.Heartbeat]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	updateQuery := `UPDATE jobs SET progress = @progress, total = @total, run_at = @lease_until, updated_at = @now
+		WHERE id = @id AND status = @running AND attempts = @claimed_attempts`
+	args := pgx.NamedArgs{
+		"id":               jobID,
+		"running":          domain.JobRunning,
+		"claimed_attempts": attempts,
+		"progress":         progress,
+		"total":            total,
+		"lease_until":      leaseUntil,
+		"now":              time.Now(),
+	}
+
+	result, err := r.db.Exec(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to save job progress", zap.Error(err))
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return new(domain.ErrJobLeaseLost)
+	}
+
+	return nil
+}
+
+// SaveResult stores the outcome of running job, which was claimed on its
+// attempts attempt. The input of a job that succeeded is let go, a failed
+// one keeps it to be retried. A job taken over by another worker meanwhile
+// is left to it and ErrJobLeaseLost is reported.
+func (r JobRepository) SaveResult(ctx context.Context, job *domain.Job, attempts int) error {
+	callerInfo := "[JobRepository.SaveResult]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var finishedAt any
+	if !job.FinishedAt.IsZero() {
+		finishedAt = job.FinishedAt
+	}
+
+	updateQuery := `UPDATE jobs SET status = @status, attempts = @attempts, run_at = @run_at, progress = @progress,
+		total = @total, last_error = @last_error, result_key = @result_key, result_name = @result_name,
+		result_type = @result_type, result_size = @result_size, updated_at = @updated_at, finished_at = @finished_at,
+		input = CASE WHEN @clear_input THEN NULL ELSE input END
+		WHERE id = @id AND status = @running AND attempts = @claimed_attempts`
+	args := pgx.NamedArgs{
+		"id":               job.ID,
+		"running":          domain.JobRunning,
+		"claimed_attempts": attempts,
+		"status":           job.Status,
+		"attempts":         job.Attempts,
+		"run_at":           job.RunAt,
+		"progress":         job.Progress,
+		"total":            job.Total,
+		"last_error":       job.LastError,
+		"result_key":       job.ResultKey,
+		"result_name":      job.ResultName,
+		"result_type":      job.ResultType,
+		"result_size":      job.ResultSize,
+		"updated_at":       job.UpdatedAt,
+		"finished_at":      finishedAt,
+		"clear_input":      job.Status == domain.JobSucceeded,
+	}
+
+	result, err := r.db.Exec(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to save job result", zap.Error(err))
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		l.Warn("job was taken over by another worker", zap.String("id", job.ID.String()))
+		return new(domain.ErrJobLeaseLost)
+	}
+
+	return nil
+}
+
+// Retry queues the failed job in job.ID again with its attempts reset, and
+// fills job in.
+func (r JobRepository) Retry(ctx context.Context, job *domain.Job, now time.Time) error {
+	callerInfo := "[JobRepository.Retry]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	updateQuery := `UPDATE jobs SET status = @queued, attempts = 0, run_at = @now, progress = 0, total = 0,
+		last_error = '', updated_at = @now, started_at = NULL, finished_at = NULL
+		WHERE id = @id AND status = @failed RETURNING ` + jobColumns
+	args := pgx.NamedArgs{
+		"id":     job.ID,
+		"queued": domain.JobQueued,
+		"failed": domain.JobFailed,
+		"now":    now,
+	}
+
+	rows, err := r.db.Query(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to retry job", zap.Error(err))
+		return err
+	}
+
+	jobs, err := r.collectJobs(rows, make(domain.Jobs, 0, 1), false)
+	if err != nil {
+		l.Error("failed to retry job", zap.Error(err))
+		return err
+	}
+
+	if len(jobs) == 0 {
+		return new(domain.ErrJobNotFailed)
+	}
+	*job = jobs[0]
+
+	return nil
+}
+
+func (r JobRepository) GetJobs(ctx context.Context, filter *domain.FilterJob, jobs domain.Jobs) (domain.Jobs, error) {
+	callerInfo := "[JobRepository.GetJobs]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterJob(filter)
+	getQuery := `SELECT ` + jobColumns + ` FROM jobs` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get jobs", zap.Error(err))
+		return jobs, err
+	}
+
+	jobs, err = r.collectJobs(rows, jobs, false)
+	if err != nil {
+		l.Error("failed to get jobs", zap.Error(err))
+		return jobs, err
+	}
+
+	return jobs, nil
+}
+
+// collectJobs scans rows of jobColumns, followed by input when withInput is
+// set.
+func (r JobRepository) collectJobs(rows pgx.Rows, jobs domain.Jobs, withInput bool) (domain.Jobs, error) {
+	dJob := domain.JobAcquire()
+	defer domain.JobRelease(dJob)
+	var startedAt, finishedAt *time.Time
+
+	scans := []any{
+		&dJob.ID,
+		&dJob.Kind,
+		&dJob.Status,
+		&dJob.Params,
+		&dJob.UserID,
+		&dJob.UserNIP,
+		&dJob.UserName,
+		&dJob.Progress,
+		&dJob.Total,
+		&dJob.Attempts,
+		&dJob.MaxAttempts,
+		&dJob.RunAt,
+		&dJob.LastError,
+		&dJob.ResultKey,
+		&dJob.ResultName,
+		&dJob.ResultType,
+		&dJob.ResultSize,
+		&dJob.CreatedAt,
+		&dJob.UpdatedAt,
+		&startedAt,
+		&finishedAt,
+	}
+	if withInput {
+		scans = append(scans, &dJob.Input)
+	}
+
+	_, err := pgx.ForEachRow(rows, scans, func() error {
+		dJob.StartedAt = time.Time{}
+		if startedAt != nil {
+			dJob.StartedAt = *startedAt
+		}
+		dJob.FinishedAt = time.Time{}
+		if finishedAt != nil {
+			dJob.FinishedAt = *finishedAt
+		}
+		jobs = append(jobs, *dJob)
+		dJob.Params, dJob.Input = nil, nil
+		return nil
+	})
+
+	return jobs, err
+}
+
+func (r JobRepository) filterJob(filter *domain.FilterJob) (string, pgx.NamedArgs) {
+	const totalConditions = 4
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if !id.IsZero(filter.UserID) {
+		conditions = append(conditions, "user_id = @user_id")
+		params["user_id"] = filter.UserID
+	}
+
+	if filter.Kind != "" {
+		conditions = append(conditions, "kind = @kind")
+		params["kind"] = filter.Kind
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = @status")
+		params["status"] = filter.Status
+	}
+
+	order := " ORDER BY created_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ JobRepositoryContract = (*JobRepository)(nil)
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type JobRepositoryContract interface {
+	Claim(ctx context.Context, now, leaseUntil time.Time, job *domain.Job) (bool, error)
+	Heartbeat(ctx context.Context, jobID ulid.ULID, attempts, progress, total int, leaseUntil time.Time) error
+	SaveResult(ctx context.Context, job *domain.Job, attempts int) error
+	Retry(ctx context.Context, job *domain.Job, now time.Time) error
+	GetJobs(ctx context.Context, filter *domain.FilterJob, jobs domain.Jobs) (domain.Jobs, error)
+}
+
+// StorageRepositoryContract keeps the result files of jobs, the image
+// repository stores them in S3.
+type StorageRepositoryContract interface {
+	Upload(ctx context.Context, key, contentType string, body io.Reader) (string, error)
+	Download(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	Delete(ctx context.Context, key string) error
+}
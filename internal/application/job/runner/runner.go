@@ -0,0 +1,190 @@
+// Package runner does the work of each kind of job through the services of
+// the modules the job belongs to.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/j03hanafi/halo-suster/internal/application/job/service"
+	"github.com/j03hanafi/halo-suster/internal/application/medical/patientimport"
+	medicalservice "github.com/j03hanafi/halo-suster/internal/application/medical/service"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
+	userservice "github.com/j03hanafi/halo-suster/internal/application/user/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	nameDateFormat = "20060102"
+
+	contentTypeJSON = "application/json"
+)
+
+// New returns the runner of every kind of job.
+func New(
+	medicalService medicalservice.MedicalServiceContract,
+	userService userservice.UserServiceContract,
+) map[string]service.Runner {
+	return map[string]service.Runner{
+		domain.JobPatientExport: patientExport{medicalService: medicalService},
+		domain.JobRecordExport:  recordExport{medicalService: medicalService},
+		domain.JobUserExport:    userExport{userService: userService},
+		domain.JobPatientImport: patientImport{medicalService: medicalService},
+	}
+}
+
+// decode reads the params of job into params.
+func decode(job *domain.Job, params any) error {
+	if err := json.Unmarshal(job.Params, params); err != nil {
+		return domain.ErrJobInvalid{Err: err}
+	}
+	return nil
+}
+
+type patientExport struct {
+	medicalService medicalservice.MedicalServiceContract
+}
+
+func (r patientExport) Run(
+	ctx context.Context,
+	job *domain.Job,
+	progress *service.Progress,
+	w io.Writer,
+) (string, string, error) {
+	var params domain.PatientExportParams
+	if err := decode(job, &params); err != nil {
+		return "", "", err
+	}
+
+	err := r.medicalService.ExportPatients(ctx, &params.Filter, params.Format, w, progress.Done)
+	if err != nil {
+		return "", "", err
+	}
+
+	return "patients-" + job.CreatedAt.Format(nameDateFormat) + "." + params.Format,
+		sheet.ContentType(params.Format), nil
+}
+
+type recordExport struct {
+	medicalService medicalservice.MedicalServiceContract
+}
+
+func (r recordExport) Run(
+	ctx context.Context,
+	job *domain.Job,
+	progress *service.Progress,
+	w io.Writer,
+) (string, string, error) {
+	var params domain.RecordExportParams
+	if err := decode(job, &params); err != nil {
+		return "", "", err
+	}
+
+	err := r.medicalService.ExportMedicalRecords(ctx, &params.Filter, params.Format, w, progress.Done)
+	if err != nil {
+		return "", "", err
+	}
+
+	return "medical-records-" + job.CreatedAt.Format(nameDateFormat) + "." + params.Format,
+		sheet.ContentType(params.Format), nil
+}
+
+type userExport struct {
+	userService userservice.UserServiceContract
+}
+
+func (r userExport) Run(
+	ctx context.Context,
+	job *domain.Job,
+	progress *service.Progress,
+	w io.Writer,
+) (string, string, error) {
+	var params domain.UserExportParams
+	if err := decode(job, &params); err != nil {
+		return "", "", err
+	}
+
+	err := r.userService.ExportUsers(ctx, &params.Filter, params.Format, w, progress.Done)
+	if err != nil {
+		return "", "", err
+	}
+
+	return "users-" + job.CreatedAt.Format(nameDateFormat) + "." + params.Format,
+		sheet.ContentType(params.Format), nil
+}
+
+// patientImport registers the patients of the file uploaded with the job and
+// writes the same report as POST /v1/medical/patient/import. An import that
+// is retried reports the rows imported by the earlier attempt as already
+// registered.
+type patientImport struct {
+	medicalService medicalservice.MedicalServiceContract
+}
+
+type importReport struct {
+	Total    int                 `json:"total"`
+	Imported int                 `json:"imported"`
+	Failed   int                 `json:"failed"`
+	Errors   []importReportError `json:"errors"`
+}
+
+type importReportError struct {
+	Row            int      `json:"row"`
+	IdentityNumber string   `json:"identityNumber"`
+	Errors         []string `json:"errors"`
+}
+
+func (r patientImport) Run(
+	ctx context.Context,
+	job *domain.Job,
+	progress *service.Progress,
+	w io.Writer,
+) (string, string, error) {
+	var params domain.PatientImportParams
+	if err := decode(job, &params); err != nil {
+		return "", "", err
+	}
+
+	rows, err := patientimport.Read(bytes.NewReader(job.Input), params.Format)
+	if err != nil {
+		return "", "", domain.ErrJobInvalid{Err: err}
+	}
+	progress.SetTotal(len(rows))
+
+	// the registrations are recorded under the user who queued the job
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+
+	user.ID = job.UserID
+	user.NIP = job.UserNIP
+	user.Name = job.UserName
+
+	report := domain.PatientImportAcquire()
+	defer domain.PatientImportRelease(report)
+
+	if err = r.medicalService.ImportPatients(ctx, rows, user, report, progress.Done); err != nil {
+		return "", "", err
+	}
+
+	res := importReport{
+		Total:    report.Total,
+		Imported: report.Imported,
+		Failed:   report.Failed,
+		Errors:   make([]importReportError, 0, len(report.Errors)),
+	}
+	for _, rowErr := range report.Errors {
+		res.Errors = append(res.Errors, importReportError{
+			Row:            rowErr.Row,
+			IdentityNumber: rowErr.IdentityNumber,
+			Errors:         rowErr.Errors,
+		})
+	}
+
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		return "", "", err
+	}
+
+	return "patient-import-" + job.CreatedAt.Format(nameDateFormat) + "-report.json", contentTypeJSON, nil
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/job/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/retry"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// Options configures how jobs are run.
+type Options struct {
+	// Timeout is how long a job may run before it fails.
+	Timeout time.Duration
+	// Lease is how long a claimed job is kept from other workers, it is
+	// extended while the job runs.
+	Lease          time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// Progress counts the rows a running job has done, out of Total once it is
+// known. It is read by the heartbeat while the runner updates it.
+type Progress struct {
+	done  atomic.Int64
+	total atomic.Int64
+}
+
+// Done records that rows rows are done.
+func (p *Progress) Done(rows int) {
+	p.done.Store(int64(rows))
+}
+
+// SetTotal records how many rows there are to do.
+func (p *Progress) SetTotal(rows int) {
+	p.total.Store(int64(rows))
+}
+
+func (p *Progress) values() (int, int) {
+	return int(p.done.Load()), int(p.total.Load())
+}
+
+// errTimedOut reports a job that ran longer than Options.Timeout.
+type errTimedOut struct {
+	timeout time.Duration
+}
+
+func (e errTimedOut) Error() string {
+	return "job did not finish within " + e.timeout.String()
+}
+
+type JobService struct {
+	jobRepository     repository.JobRepositoryContract
+	storageRepository repository.StorageRepositoryContract
+	runners           map[string]Runner
+	contextTimeout    time.Duration
+	options           Options
+}
+
+func NewJobService(
+	timeout time.Duration,
+	options Options,
+	jobRepository repository.JobRepositoryContract,
+	storageRepository repository.StorageRepositoryContract,
+	runners map[string]Runner,
+) *JobService {
+	return &JobService{
+		jobRepository:     jobRepository,
+		storageRepository: storageRepository,
+		runners:           runners,
+		contextTimeout:    timeout,
+		options:           options,
+	}
+}
+
+// Work claims the job that is due and runs it, it reports whether there was
+// one. A job that fails is tried again later with an exponential backoff
+// until it runs out of attempts. A job stopped because ctx is done is queued
+// again without using up an attempt.
+func (s JobService) Work(ctx context.Context) (bool, error) {
+	callerInfo := "[JobService.Work]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	job := domain.JobAcquire()
+	defer domain.JobRelease(job)
+
+	claimCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	claimed, err := s.jobRepository.Claim(claimCtx, now, now.Add(s.options.Lease), job)
+	if err != nil {
+		l.Error("failed to claim job", zap.Error(err))
+		return false, err
+	}
+
+	if !claimed {
+		return false, nil
+	}
+
+	// the job is only this worker's while it stays on this attempt, run may
+	// change Attempts on the way
+	attempts := job.Attempts
+
+	s.run(ctx, job)
+
+	// the outcome is saved even when the worker is stopping
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), s.contextTimeout)
+	defer cancelSave()
+
+	var leaseLost *domain.ErrJobLeaseLost
+	err = s.jobRepository.SaveResult(saveCtx, job, attempts)
+	if errors.As(err, &leaseLost) {
+		// the worker that took the job over stores a result of its own
+		l.Warn("job was taken over, dropping its result", zap.String("id", job.ID.String()))
+		if job.ResultKey != "" {
+			if err = s.storageRepository.Delete(saveCtx, job.ResultKey); err != nil {
+				l.Error("failed to delete dropped job result", zap.String("key", job.ResultKey), zap.Error(err))
+			}
+		}
+		return true, nil
+	}
+	if err != nil {
+		l.Error("failed to save job result", zap.String("id", job.ID.String()), zap.Error(err))
+		return true, err
+	}
+
+	return true, nil
+}
+
+// run runs job and records the outcome on it.
+func (s JobService) run(ctx context.Context, job *domain.Job) {
+	callerInfo := "[JobService.run]"
+	l := logger.FromCtx(ctx).With(
+		zap.String("caller", callerInfo),
+		zap.String("id", job.ID.String()),
+		zap.String("kind", job.Kind),
+		zap.Int("attempts", job.Attempts),
+	)
+
+	var err error
+	runner, ok := s.runners[job.Kind]
+	switch {
+	case !ok:
+		err = domain.ErrJobInvalid{Err: errors.New("no worker runs jobs of kind " + job.Kind)}
+	case job.Attempts > job.MaxAttempts:
+		// claimed again after its lease ran out, the workers that took it stopped
+		job.Attempts = job.MaxAttempts
+		err = domain.ErrJobInvalid{Err: errors.New("job was stopped before it finished on every attempt")}
+	default:
+		l.Info("job started")
+		err = s.execute(ctx, job, runner)
+	}
+
+	now := time.Now()
+	job.UpdatedAt = now
+	job.RunAt = now
+
+	switch {
+	case err == nil:
+		job.Status = domain.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = now
+		l.Info("job succeeded", zap.Int("progress", job.Progress), zap.String("result", job.ResultKey))
+		return
+	case ctx.Err() != nil:
+		// the server is stopping, the job is picked up again once it is back
+		job.Status = domain.JobQueued
+		job.Attempts--
+		l.Warn("job stopped, queued again", zap.Error(err))
+		return
+	}
+
+	job.LastError = retry.LastError(err)
+
+	if !retryable(err) || job.Attempts >= job.MaxAttempts {
+		job.Status = domain.JobFailed
+		job.FinishedAt = now
+		l.Error("job failed", zap.Error(err))
+		return
+	}
+
+	job.Status = domain.JobQueued
+	job.RunAt = now.Add(retry.Backoff(s.options.RetryBaseDelay, s.options.RetryMaxDelay, job.Attempts))
+	l.Warn("job failed, retrying", zap.Time("next attempt", job.RunAt), zap.Error(err))
+}
+
+// execute runs runner on job into a temporary file and stores the file once
+// it is complete.
+func (s JobService) execute(ctx context.Context, job *domain.Job, runner Runner) error {
+	runCtx, cancel := context.WithTimeout(ctx, s.options.Timeout)
+	defer cancel()
+
+	file, err := os.CreateTemp("", "job-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	progress := new(Progress)
+	stop := s.heartbeat(runCtx, job, progress, cancel)
+
+	name, contentType, err := runner.Run(runCtx, job, progress, file)
+
+	stop()
+	job.Progress, job.Total = progress.values()
+
+	if err == nil {
+		err = s.store(runCtx, job, file, name, contentType)
+	}
+
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return errTimedOut{timeout: s.options.Timeout}
+	}
+
+	if err == nil && job.Total == 0 {
+		// the rows of an export are only known once it is done
+		job.Total = job.Progress
+	}
+
+	return err
+}
+
+// store uploads the result file of job.
+func (s JobService) store(ctx context.Context, job *domain.Job, file *os.File, name, contentType string) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// every attempt stores under a key of its own, so one that is dropped
+	// after its job was taken over can be deleted
+	key := configs.Get().App.Name + "_job_" + job.ID.String() + "_" + strconv.Itoa(job.Attempts) + "_" + name
+	if _, err = s.storageRepository.Upload(ctx, key, contentType, file); err != nil {
+		return err
+	}
+
+	job.ResultKey = key
+	job.ResultName = name
+	job.ResultType = contentType
+	job.ResultSize = info.Size()
+
+	return nil
+}
+
+// heartbeat saves the progress of job and extends its lease every third of
+// the lease until stop is called. It calls lost once the job has been taken
+// over by another worker, there is no point in running it further.
+func (s JobService) heartbeat(
+	ctx context.Context,
+	job *domain.Job,
+	progress *Progress,
+	lost context.CancelFunc,
+) (stop func()) {
+	callerInfo := "[JobService.heartbeat]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo), zap.String("id", job.ID.String()))
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	jobID, attempts := job.ID, job.Attempts
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(max(s.options.Lease/3, time.Second))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				done, total := progress.values()
+
+				beatCtx, cancelBeat := context.WithTimeout(ctx, s.contextTimeout)
+				err := s.jobRepository.Heartbeat(beatCtx, jobID, attempts, done, total, now.Add(s.options.Lease))
+				cancelBeat()
+
+				var leaseLost *domain.ErrJobLeaseLost
+				if errors.As(err, &leaseLost) {
+					l.Warn("job was taken over by another worker, stopping it")
+					lost()
+					return
+				}
+
+				if err != nil && ctx.Err() == nil {
+					// the next beat tries again before the lease runs out
+					l.Warn("failed to extend job lease", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// retryable reports whether running the job again can succeed, a job that
+// timed out or whose params or file are invalid cannot.
+func retryable(err error) bool {
+	var timedOut errTimedOut
+	var handlerErr interface{ Status() int }
+
+	return !errors.As(err, &timedOut) && !errors.As(err, &handlerErr)
+}
+
+// GetJobs returns the jobs matching filter, only their own unless user is
+// from IT.
+func (s JobService) GetJobs(
+	ctx context.Context,
+	filter *domain.FilterJob,
+	user *domain.User,
+	jobs domain.Jobs,
+) (domain.Jobs, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[JobService.GetJobs]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if user.Role != domain.RoleIT {
+		filter.UserID = user.ID
+	}
+
+	jobs, err := s.jobRepository.GetJobs(ctx, filter, jobs)
+	if err != nil {
+		l.Error("failed to get jobs", zap.Error(err))
+		return jobs, err
+	}
+
+	return jobs, nil
+}
+
+// GetJob fills job from its ID. The job of another user is not found unless
+// user is from IT.
+func (s JobService) GetJob(ctx context.Context, job *domain.Job, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[JobService.GetJob]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterJobAcquire()
+	defer domain.FilterJobRelease(filter)
+
+	filter.ID = job.ID
+	filter.Limit = 1
+	if user.Role != domain.RoleIT {
+		filter.UserID = user.ID
+	}
+
+	jobs := domain.JobsAcquire()
+	defer domain.JobsRelease(jobs)
+
+	jobs, err := s.jobRepository.GetJobs(ctx, filter, jobs)
+	if err != nil {
+		l.Error("failed to get job", zap.Error(err))
+		return err
+	}
+
+	if len(jobs) == 0 {
+		return new(domain.ErrJobNotFound)
+	}
+	*job = jobs[0]
+
+	return nil
+}
+
+// OpenResult fills job from its ID and opens its result file, the caller
+// closes the file.
+func (s JobService) OpenResult(ctx context.Context, job *domain.Job, user *domain.User) (io.ReadCloser, int64, error) {
+	callerInfo := "[JobService.OpenResult]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := s.GetJob(ctx, job, user); err != nil {
+		return nil, 0, err
+	}
+
+	if job.Status != domain.JobSucceeded {
+		return nil, 0, new(domain.ErrJobNoResult)
+	}
+
+	// the body is streamed after this returns, so the request context
+	// bounds the download rather than the service timeout
+	body, size, err := s.storageRepository.Download(ctx, job.ResultKey)
+	if err != nil {
+		l.Error("failed to download job result", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return body, size, nil
+}
+
+// RetryJob queues a failed job again with its attempts reset.
+func (s JobService) RetryJob(ctx context.Context, job *domain.Job, user *domain.User) error {
+	callerInfo := "[JobService.RetryJob]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := s.GetJob(ctx, job, user); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if err := s.jobRepository.Retry(ctx, job, time.Now()); err != nil {
+		l.Error("failed to retry job", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+var _ JobServiceContract = (*JobService)(nil)
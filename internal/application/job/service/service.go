@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type JobServiceContract interface {
+	Work(ctx context.Context) (bool, error)
+	GetJobs(ctx context.Context, filter *domain.FilterJob, user *domain.User, jobs domain.Jobs) (domain.Jobs, error)
+	GetJob(ctx context.Context, job *domain.Job, user *domain.User) error
+	OpenResult(ctx context.Context, job *domain.Job, user *domain.User) (io.ReadCloser, int64, error)
+	RetryJob(ctx context.Context, job *domain.Job, user *domain.User) error
+}
+
+// Runner does the work of one kind of job. It writes the result file to w
+// and returns its name and media type, an error wrapped in
+// domain.ErrJobInvalid fails the job without another attempt.
+type Runner interface {
+	Run(ctx context.Context, job *domain.Job, progress *Progress, w io.Writer) (name, contentType string, err error)
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/internal/application/job/service"
+)
+
+// Worker runs the jobs that are due every interval until its context is
+// done. Jobs are claimed with a lease, so running more than one worker, in
+// one process or several, is safe.
+type Worker struct {
+	jobService service.JobServiceContract
+	interval   time.Duration
+	number     int
+}
+
+func NewWorker(number int, interval time.Duration, jobService service.JobServiceContract) *Worker {
+	return &Worker{
+		jobService: jobService,
+		interval:   interval,
+		number:     number,
+	}
+}
+
+func (w Worker) Run(ctx context.Context) {
+	callerInfo := "[Worker.Run]"
+	l := zap.L().With(zap.String("caller", callerInfo), zap.Int("worker", w.number))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	l.Info("job worker started", zap.Duration("interval", w.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Info("job worker stopped")
+			return
+		case <-ticker.C:
+			// a queue that is backed up is worked through without waiting for
+			// the next tick, errors are already logged by the service
+			for ctx.Err() == nil {
+				worked, err := w.jobService.Work(ctx)
+				if err != nil || !worked {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package jobqueue queues background jobs from the modules whose work they
+// do, the job module runs them.
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// New builds a job of kind queued by user with params encoded as JSON.
+func New(kind string, user *domain.User, params any) (*domain.Job, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Job{
+		Kind:     kind,
+		Params:   encoded,
+		UserID:   user.ID,
+		UserNIP:  user.NIP,
+		UserName: user.Name,
+	}, nil
+}
+
+// Insert queues job to run as soon as a worker is free and fills it in. db is
+// either the pool or a transaction the job should only be queued with.
+func Insert(ctx context.Context, db execer, job *domain.Job) error {
+	now := time.Now()
+
+	job.ID = id.New()
+	job.Status = domain.JobQueued
+	job.MaxAttempts = max(configs.Get().Job.MaxAttempts, 1)
+	job.RunAt = now
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	insertQuery := `INSERT INTO jobs (
+		id, kind, status, params, input, user_id, user_nip, user_name, max_attempts, run_at, created_at, updated_at
+			)
+		VALUES (
+		@id, @kind, @status, @params, @input, @user_id, @user_nip, @user_name, @max_attempts, @run_at, @created_at, @updated_at
+			)`
+	args := pgx.NamedArgs{
+		"id":           job.ID,
+		"kind":         job.Kind,
+		"status":       job.Status,
+		"params":       job.Params,
+		"input":        job.Input,
+		"user_id":      job.UserID,
+		"user_nip":     job.UserNIP,
+		"user_name":    job.UserName,
+		"max_attempts": job.MaxAttempts,
+		"run_at":       job.RunAt,
+		"created_at":   job.CreatedAt,
+		"updated_at":   job.UpdatedAt,
+	}
+
+	_, err := db.Exec(ctx, insertQuery, args)
+	return err
+}
@@ -0,0 +1,133 @@
+// Package catalogue holds the lab tests that can be ordered, bundled with the
+// binary from catalogue.json.
+package catalogue
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+//go:embed catalogue.json
+var catalogueJSON []byte
+
+type rangeJSON struct {
+	Low  *float64 `json:"low"`
+	High *float64 `json:"high"`
+}
+
+func (r rangeJSON) isSet() bool {
+	return r.Low != nil || r.High != nil
+}
+
+type ageRangeJSON struct {
+	MinAge int       `json:"minAge"`
+	MaxAge int       `json:"maxAge"`
+	Range  rangeJSON `json:"range"`
+	Male   rangeJSON `json:"male"`
+	Female rangeJSON `json:"female"`
+}
+
+type testJSON struct {
+	Code         string         `json:"code"`
+	Name         string         `json:"name"`
+	Specimen     string         `json:"specimen"`
+	Unit         string         `json:"unit"`
+	ResultType   string         `json:"resultType"`
+	Range        rangeJSON      `json:"range"`
+	Male         rangeJSON      `json:"male"`
+	Female       rangeJSON      `json:"female"`
+	Ages         []ageRangeJSON `json:"ages"`
+	CriticalLow  *float64       `json:"criticalLow"`
+	CriticalHigh *float64       `json:"criticalHigh"`
+	NormalValues []string       `json:"normalValues"`
+}
+
+type Catalogue struct {
+	tests  domain.LabTests
+	byCode map[string]int
+}
+
+// Load parses the bundled catalogue, it fails on duplicate codes, unknown
+// result types and age bands that are empty, overlap or leave out a sex so a
+// bad catalogue is caught at startup.
+func Load() (*Catalogue, error) {
+	var raw []testJSON
+	if err := json.Unmarshal(catalogueJSON, &raw); err != nil {
+		return nil, fmt.Errorf("[catalogue.Load] failed to decode catalogue: %w", err)
+	}
+
+	c := &Catalogue{
+		tests:  make(domain.LabTests, 0, len(raw)),
+		byCode: make(map[string]int, len(raw)),
+	}
+
+	for _, t := range raw {
+		if _, ok := c.byCode[t.Code]; ok {
+			return nil, fmt.Errorf("[catalogue.Load] duplicate test code %q", t.Code)
+		}
+		if t.ResultType != domain.LabResultNumeric && t.ResultType != domain.LabResultText {
+			return nil, fmt.Errorf("[catalogue.Load] test %q has unknown result type %q", t.Code, t.ResultType)
+		}
+
+		ageRanges := make([]domain.LabAgeRange, 0, len(t.Ages))
+		for i, age := range t.Ages {
+			if age.MinAge < 0 || age.MaxAge <= age.MinAge {
+				return nil, fmt.Errorf("[catalogue.Load] test %q has an empty age band %d-%d", t.Code, age.MinAge, age.MaxAge)
+			}
+			if !age.Range.isSet() && (!age.Male.isSet() || !age.Female.isSet()) {
+				return nil, fmt.Errorf("[catalogue.Load] test %q has no range for both sexes aged %d-%d", t.Code, age.MinAge, age.MaxAge)
+			}
+			if i > 0 && age.MinAge < t.Ages[i-1].MaxAge {
+				return nil, fmt.Errorf("[catalogue.Load] test %q has overlapping age bands at %d", t.Code, age.MinAge)
+			}
+
+			ageRanges = append(ageRanges, domain.LabAgeRange{
+				MinAge:    age.MinAge,
+				MaxAge:    age.MaxAge,
+				LabRanges: newRanges(age.Range, age.Male, age.Female),
+			})
+		}
+
+		c.byCode[t.Code] = len(c.tests)
+		c.tests = append(c.tests, domain.LabTest{
+			Code:         t.Code,
+			Name:         t.Name,
+			Specimen:     t.Specimen,
+			Unit:         t.Unit,
+			ResultType:   t.ResultType,
+			LabRanges:    newRanges(t.Range, t.Male, t.Female),
+			AgeRanges:    ageRanges,
+			CriticalLow:  t.CriticalLow,
+			CriticalHigh: t.CriticalHigh,
+			NormalValues: t.NormalValues,
+		})
+	}
+
+	return c, nil
+}
+
+func newRanges(r, male, female rangeJSON) domain.LabRanges {
+	return domain.LabRanges{
+		Range:       domain.LabRange{Low: r.Low, High: r.High},
+		MaleRange:   domain.LabRange{Low: male.Low, High: male.High},
+		FemaleRange: domain.LabRange{Low: female.Low, High: female.High},
+	}
+}
+
+// Get returns the test with code, the test must not be modified.
+func (c *Catalogue) Get(code string) (*domain.LabTest, bool) {
+	i, ok := c.byCode[code]
+	if !ok {
+		return nil, false
+	}
+
+	return &c.tests[i], true
+}
+
+// All returns every test in catalogue order, the tests must not be modified.
+func (c *Catalogue) All() domain.LabTests {
+	return c.tests
+}
@@ -0,0 +1,151 @@
+package catalogue
+
+import (
+	"testing"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+func TestCatalogueFlag(t *testing.T) {
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		code   string
+		value  float64
+		isMale bool
+		age    int
+		want   string
+	}{
+		{name: "hemoglobin of a man at the low bound", code: "HGB", value: 13.5, isMale: true, age: 35, want: domain.LabFlagNormal},
+		{name: "hemoglobin of a woman at the male low bound", code: "HGB", value: 13.4, age: 35, want: domain.LabFlagNormal},
+		{name: "hemoglobin of a man under the low bound", code: "HGB", value: 13.4, isMale: true, age: 35, want: domain.LabFlagLow},
+		{name: "hemoglobin of a toddler", code: "HGB", value: 11.5, isMale: true, age: 2, want: domain.LabFlagNormal},
+		{name: "hemoglobin of a school child", code: "HGB", value: 11.4, age: 9, want: domain.LabFlagLow},
+		{name: "hemoglobin of a teenage girl", code: "HGB", value: 15.8, age: 15, want: domain.LabFlagNormal},
+		{name: "critical hemoglobin of a child", code: "HGB", value: 6.9, age: 2, want: domain.LabFlagCriticalLow},
+		{name: "white cells of a toddler", code: "WBC", value: 16.0, age: 2, want: domain.LabFlagNormal},
+		{name: "white cells of an adult", code: "WBC", value: 16.0, age: 30, want: domain.LabFlagHigh},
+		{name: "white cells at the critical limit", code: "WBC", value: 30.0, age: 30, want: domain.LabFlagHigh},
+		{name: "white cells over the critical limit", code: "WBC", value: 30.1, age: 2, want: domain.LabFlagCriticalHigh},
+		{name: "creatinine of a child", code: "CREA", value: 0.5, isMale: true, age: 10, want: domain.LabFlagNormal},
+		{name: "creatinine of a man", code: "CREA", value: 0.5, isMale: true, age: 18, want: domain.LabFlagLow},
+		{name: "creatinine of a woman", code: "CREA", value: 1.1, age: 60, want: domain.LabFlagHigh},
+		{name: "creatinine of a young child", code: "CREA", value: 0.5, age: 3, want: domain.LabFlagHigh},
+		{name: "fasting glucose at the high bound", code: "GLU-F", value: 99, age: 50, want: domain.LabFlagNormal},
+		{name: "fasting glucose over the high bound", code: "GLU-F", value: 100, age: 50, want: domain.LabFlagHigh},
+		{name: "cholesterol with an upper bound only", code: "CHOL", value: 120, age: 50, want: domain.LabFlagNormal},
+		{name: "potassium under the critical limit", code: "K", value: 2.4, isMale: true, age: 70, want: domain.LabFlagCriticalLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test, ok := c.Get(tt.code)
+			if !ok {
+				t.Fatalf("test %s is not in the catalogue", tt.code)
+			}
+
+			if got := test.FlagNumeric(tt.value, tt.isMale, tt.age); got != tt.want {
+				t.Errorf("FlagNumeric(%v, %v, %d) = %q, want %q", tt.value, tt.isMale, tt.age, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalogueFlagText(t *testing.T) {
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		code  string
+		value string
+		want  string
+	}{
+		{code: "HBSAG", value: "Non-Reactive", want: domain.LabFlagNormal},
+		{code: "HBSAG", value: "reactive", want: domain.LabFlagAbnormal},
+		{code: "NS1", value: "positive", want: domain.LabFlagAbnormal},
+		{code: "MAL", value: "No parasites seen", want: domain.LabFlagNormal},
+		{code: "MAL", value: "P. falciparum seen", want: domain.LabFlagAbnormal},
+		{code: "BCULT", value: "no growth ", want: domain.LabFlagNormal},
+		{code: "UPRO", value: "Trace", want: domain.LabFlagNormal},
+		{code: "UPRO", value: "+2", want: domain.LabFlagAbnormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+"/"+tt.value, func(t *testing.T) {
+			test, ok := c.Get(tt.code)
+			if !ok {
+				t.Fatalf("test %s is not in the catalogue", tt.code)
+			}
+			if test.ResultType != domain.LabResultText {
+				t.Fatalf("test %s has result type %s", tt.code, test.ResultType)
+			}
+
+			if got := test.FlagText(tt.value); got != tt.want {
+				t.Errorf("FlagText(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		catalogue string
+	}{
+		{
+			name:      "not JSON",
+			catalogue: `{"code": "HGB"`,
+		},
+		{
+			name: "duplicate code",
+			catalogue: `[{"code": "HGB", "resultType": "numeric"},
+				{"code": "HGB", "resultType": "numeric"}]`,
+		},
+		{
+			name:      "unknown result type",
+			catalogue: `[{"code": "HGB", "resultType": "boolean"}]`,
+		},
+		{
+			name: "empty age band",
+			catalogue: `[{"code": "HGB", "resultType": "numeric",
+				"ages": [{"minAge": 5, "maxAge": 5, "range": {"low": 11}}]}]`,
+		},
+		{
+			name: "negative age",
+			catalogue: `[{"code": "HGB", "resultType": "numeric",
+				"ages": [{"minAge": -1, "maxAge": 5, "range": {"low": 11}}]}]`,
+		},
+		{
+			name: "overlapping age bands",
+			catalogue: `[{"code": "HGB", "resultType": "numeric",
+				"ages": [{"minAge": 1, "maxAge": 5, "range": {"low": 11}},
+					{"minAge": 4, "maxAge": 12, "range": {"low": 11.5}}]}]`,
+		},
+		{
+			name: "age band for one sex",
+			catalogue: `[{"code": "HGB", "resultType": "numeric",
+				"ages": [{"minAge": 12, "maxAge": 18, "male": {"low": 13}}]}]`,
+		},
+	}
+
+	bundled := catalogueJSON
+	t.Cleanup(func() {
+		catalogueJSON = bundled
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalogueJSON = []byte(tt.catalogue)
+
+			if _, err := Load(); err == nil {
+				t.Error("Load() succeeded, want an error")
+			}
+		})
+	}
+}
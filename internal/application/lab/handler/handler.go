@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	orderIDFromParam        = "id"
+	resultIDFromParam       = "id"
+	identityNumberFromParam = "identityNumber"
+
+	// cumulativeLimit is used when the cumulative view is asked without a
+	// limit, the default page of 5 would cut a trend short.
+	cumulativeLimit = 500
+)
+
+type labHandler struct {
+	labService service.LabServiceContract
+}
+
+func NewLabHandler(router fiber.Router, jwtMiddleware fiber.Handler, labService service.LabServiceContract) {
+	handler := labHandler{
+		labService: labService,
+	}
+
+	labRouter := router.Group("/lab", jwtMiddleware)
+	labRouter.Get("/catalogue", handler.GetCatalogue)
+	labRouter.Post("/order", handler.CreateOrder)
+	labRouter.Get("/order", handler.GetOrders)
+	labRouter.Post("/order/:"+orderIDFromParam+"/collect", handler.CollectOrder)
+	labRouter.Post("/order/:"+orderIDFromParam+"/cancel", handler.CancelOrder)
+	labRouter.Post("/result/:"+resultIDFromParam, handler.RecordResult)
+	labRouter.Get("/patient/:"+identityNumberFromParam+"/results", handler.GetCumulativeResults)
+}
+
+func (h labHandler) GetCatalogue(c *fiber.Ctx) error {
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab catalogue retrieved successfully"
+	res.Data = newCatalogueRes(h.labService.GetCatalogue())
+
+	return c.JSON(res)
+}
+
+func (h labHandler) CreateOrder(c *fiber.Ctx) error {
+	callerInfo := "[labHandler.CreateOrder]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createOrderReqAcquire()
+	defer createOrderReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	order := domain.LabOrderAcquire()
+	defer domain.LabOrderRelease(order)
+
+	order.PatientID = string(*req.IdentityNumber)
+	order.RecordID = req.recordID
+	order.Priority = req.Priority
+	order.Notes = req.Notes
+
+	err := h.labService.CreateOrder(userCtx, order, req.Tests, user)
+	if err != nil {
+		l.Error("failed to create lab order", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab order created successfully"
+	res.Data = newOrderRes(order)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h labHandler) CollectOrder(c *fiber.Ctx) error {
+	callerInfo := "[labHandler.CollectOrder]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	orderID, err := ulid.Parse(c.Params(orderIDFromParam))
+	if err != nil {
+		l.Error("error parsing orderIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	order := domain.LabOrderAcquire()
+	defer domain.LabOrderRelease(order)
+
+	order.ID = orderID
+
+	err = h.labService.CollectOrder(userCtx, order, user)
+	if err != nil {
+		l.Error("failed to collect lab order", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab specimen collected successfully"
+	res.Data = newOrderRes(order)
+
+	return c.JSON(res)
+}
+
+func (h labHandler) CancelOrder(c *fiber.Ctx) error {
+	callerInfo := "[labHandler.CancelOrder]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	orderID, err := ulid.Parse(c.Params(orderIDFromParam))
+	if err != nil {
+		l.Error("error parsing orderIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := cancelOrderReqAcquire()
+	defer cancelOrderReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	order := domain.LabOrderAcquire()
+	defer domain.LabOrderRelease(order)
+
+	order.ID = orderID
+	order.CancelReason = req.Reason
+
+	err = h.labService.CancelOrder(userCtx, order, user)
+	if err != nil {
+		l.Error("failed to cancel lab order", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab order cancelled successfully"
+	res.Data = newOrderRes(order)
+
+	return c.JSON(res)
+}
+
+func (h labHandler) GetOrders(c *fiber.Ctx) error {
+	callerInfo := "[labHandler.GetOrders]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryOrderAcquire()
+	defer queryOrderRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterLabOrderAcquire()
+	defer domain.FilterLabOrderRelease(filter)
+
+	filter.ID = query.orderID
+	filter.PatientID = query.patientID
+	filter.RecordID = query.recordID
+	filter.Status = query.Status
+	filter.Priority = query.Priority
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	orders := domain.LabOrdersAcquire()
+	defer domain.LabOrdersRelease(orders)
+
+	orders, err := h.labService.GetOrders(userCtx, filter, orders)
+	if err != nil {
+		l.Error("failed to get lab orders", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab orders retrieved successfully"
+
+	ordersRes := getOrdersResAcquire()
+	defer getOrdersResRelease(ordersRes)
+
+	for i := range orders {
+		ordersRes = append(ordersRes, newOrderRes(&orders[i]))
+	}
+
+	res.Data = ordersRes
+
+	return c.JSON(res)
+}
+
+func (h labHandler) RecordResult(c *fiber.Ctx) error {
+	callerInfo := "[labHandler.RecordResult]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	resultID, err := ulid.Parse(c.Params(resultIDFromParam))
+	if err != nil {
+		l.Error("error parsing resultIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := recordResultReqAcquire()
+	defer recordResultReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	result := domain.LabResultAcquire()
+	defer domain.LabResultRelease(result)
+
+	result.ID = resultID
+	result.Value = req.Value
+	result.ValueText = req.Text
+	result.Note = req.Note
+	if req.Attachment != nil {
+		result.AttachmentURL = req.Attachment.URL
+		result.AttachmentName = req.Attachment.Name
+		result.AttachmentContentType = req.Attachment.ContentType
+	}
+
+	err = h.labService.RecordResult(userCtx, result, user)
+	if err != nil {
+		l.Error("failed to record lab result", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab result recorded successfully"
+	res.Data = newResultRes(result)
+
+	return c.JSON(res)
+}
+
+// GetCumulativeResults lists the resulted tests of a patient grouped by test,
+// each group ordered from the oldest result.
+func (h labHandler) GetCumulativeResults(c *fiber.Ctx) error {
+	callerInfo := "[labHandler.GetCumulativeResults]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	patientID, err := idNumberFromParam(c.Params(identityNumberFromParam))
+	if err != nil {
+		l.Error("error parsing identityNumberParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query := queryResultAcquire()
+	defer queryResultRelease(query)
+
+	if err = c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterLabResultAcquire()
+	defer domain.FilterLabResultRelease(filter)
+
+	filter.PatientID = string(patientID)
+	filter.TestCode = query.TestCode
+	filter.Status = domain.LabResultResulted
+	filter.From = query.from
+	filter.To = query.to
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+	if filter.Limit == 0 {
+		filter.Limit = cumulativeLimit
+	}
+
+	results := domain.LabResultsAcquire()
+	defer domain.LabResultsRelease(results)
+
+	results, err = h.labService.GetResults(userCtx, filter, results)
+	if err != nil {
+		l.Error("failed to get lab results", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Lab results retrieved successfully"
+	res.Data = newCumulativeRes(results)
+
+	return c.JSON(res)
+}
@@ -0,0 +1,625 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/timeparam"
+	imagerepository "github.com/j03hanafi/halo-suster/internal/application/image/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+func idNumberFromParam(param string) (idNumber, error) {
+	if _, err := strconv.ParseUint(param, 10, 64); err != nil {
+		return "", errors.New("identityNumber must be a number")
+	}
+
+	n := idNumber(param)
+	return n, n.validate()
+}
+
+var createOrderReqPool = sync.Pool{
+	New: func() any {
+		return new(createOrderReq)
+	},
+}
+
+func createOrderReqAcquire() *createOrderReq {
+	return createOrderReqPool.Get().(*createOrderReq)
+}
+
+func createOrderReqRelease(t *createOrderReq) {
+	*t = createOrderReq{}
+	createOrderReqPool.Put(t)
+}
+
+// createOrderReq orders Tests, codes from the catalogue, for the record the
+// order comes from. Priority defaults to routine.
+type createOrderReq struct {
+	IdentityNumber *idNumber `json:"identityNumber"`
+	RecordID       string    `json:"recordId"`
+	recordID       ulid.ULID
+	Tests          []string `json:"tests"`
+	Priority       string   `json:"priority"`
+	Notes          string   `json:"notes"`
+}
+
+func (r *createOrderReq) validate() error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	recordID, err := ulid.Parse(r.RecordID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("recordId is invalid"))
+	}
+	r.recordID = recordID
+
+	if len(r.Tests) == 0 || len(r.Tests) > domain.MaxTestsPerLabOrder {
+		errs = multierr.Append(errs, fmt.Errorf("tests must have 1 to %d items", domain.MaxTestsPerLabOrder))
+	} else {
+		seen := make(map[string]struct{}, len(r.Tests))
+		for _, code := range r.Tests {
+			if _, ok := seen[code]; ok {
+				errs = multierr.Append(errs, fmt.Errorf("test %s is ordered more than once", code))
+			}
+			seen[code] = struct{}{}
+		}
+	}
+
+	switch r.Priority {
+	case "":
+		r.Priority = domain.LabPriorityRoutine
+	case domain.LabPriorityRoutine, domain.LabPriorityUrgent:
+	default:
+		errs = multierr.Append(errs, errors.New("priority must be one of routine or urgent"))
+	}
+
+	if len(r.Notes) > 500 {
+		errs = multierr.Append(errs, errors.New("notes must have at most 500 characters"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var cancelOrderReqPool = sync.Pool{
+	New: func() any {
+		return new(cancelOrderReq)
+	},
+}
+
+func cancelOrderReqAcquire() *cancelOrderReq {
+	return cancelOrderReqPool.Get().(*cancelOrderReq)
+}
+
+func cancelOrderReqRelease(t *cancelOrderReq) {
+	*t = cancelOrderReq{}
+	cancelOrderReqPool.Put(t)
+}
+
+type cancelOrderReq struct {
+	Reason string `json:"reason"`
+}
+
+func (r *cancelOrderReq) validate() error {
+	if r.Reason == "" || len(r.Reason) > 200 {
+		return errors.New("reason must have 1 to 200 characters")
+	}
+
+	return nil
+}
+
+var recordResultReqPool = sync.Pool{
+	New: func() any {
+		return new(recordResultReq)
+	},
+}
+
+func recordResultReqAcquire() *recordResultReq {
+	return recordResultReqPool.Get().(*recordResultReq)
+}
+
+func recordResultReqRelease(t *recordResultReq) {
+	*t = recordResultReq{}
+	recordResultReqPool.Put(t)
+}
+
+// recordResultReq takes Value for numeric tests and Text for text ones, a
+// scan of the report can be attached with an image uploaded through /image.
+type recordResultReq struct {
+	Value      *float64       `json:"value"`
+	Text       string         `json:"text"`
+	Attachment *attachmentReq `json:"attachment"`
+	Note       string         `json:"note"`
+}
+
+type attachmentReq struct {
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+}
+
+func (r *recordResultReq) validate() error {
+	var errs error
+
+	if r.Value == nil && r.Text == "" {
+		errs = multierr.Append(errs, errors.New("value or text is required"))
+	} else if r.Value != nil && r.Text != "" {
+		errs = multierr.Append(errs, errors.New("only one of value or text can be given"))
+	}
+
+	if len(r.Text) > 500 {
+		errs = multierr.Append(errs, errors.New("text must have at most 500 characters"))
+	}
+
+	if len(r.Note) > 2000 {
+		errs = multierr.Append(errs, errors.New("note must have at most 2000 characters"))
+	}
+
+	if r.Attachment != nil {
+		errs = multierr.Append(errs, r.Attachment.validate())
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+func (r *attachmentReq) validate() error {
+	var errs error
+
+	if r.URL == "" {
+		errs = multierr.Append(errs, errors.New("attachment url is required"))
+	} else if !govalidator.IsURL(r.URL) {
+		errs = multierr.Append(errs, errors.New("attachment url must be a valid URL"))
+	} else {
+		u, err := url.Parse(r.URL)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("attachment url must be a valid URL"))
+		} else if !imagerepository.IsUploadURL(u) {
+			errs = multierr.Append(errs, errors.New("attachment url must be a file uploaded to /image"))
+		}
+	}
+
+	if len(r.Name) > 255 {
+		errs = multierr.Append(errs, errors.New("attachment name must have at most 255 characters"))
+	}
+
+	if len(r.ContentType) > 100 {
+		errs = multierr.Append(errs, errors.New("attachment contentType must have at most 100 characters"))
+	}
+
+	return errs
+}
+
+var queryOrderPool = sync.Pool{
+	New: func() any {
+		return new(queryOrder)
+	},
+}
+
+func queryOrderAcquire() *queryOrder {
+	return queryOrderPool.Get().(*queryOrder)
+}
+
+func queryOrderRelease(t *queryOrder) {
+	*t = queryOrder{}
+	queryOrderPool.Put(t)
+}
+
+type queryOrder struct {
+	OrderID        string `query:"orderId"`
+	orderID        ulid.ULID
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	RecordID       string `query:"recordId"`
+	recordID       ulid.ULID
+	Status         string `query:"status"`
+	Priority       string `query:"priority"`
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+}
+
+func (q *queryOrder) validate() {
+	if q.OrderID != "" {
+		q.orderID, _ = ulid.Parse(q.OrderID)
+	}
+
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.RecordID != "" {
+		q.recordID, _ = ulid.Parse(q.RecordID)
+	}
+
+	switch q.Status {
+	case domain.LabOrderOrdered, domain.LabOrderCollected, domain.LabOrderResulted, domain.LabOrderCancelled:
+	default:
+		q.Status = ""
+	}
+
+	if q.Priority != domain.LabPriorityRoutine && q.Priority != domain.LabPriorityUrgent {
+		q.Priority = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+var queryResultPool = sync.Pool{
+	New: func() any {
+		return new(queryResult)
+	},
+}
+
+func queryResultAcquire() *queryResult {
+	return queryResultPool.Get().(*queryResult)
+}
+
+func queryResultRelease(t *queryResult) {
+	*t = queryResult{}
+	queryResultPool.Put(t)
+}
+
+type queryResult struct {
+	TestCode string `query:"testCode"`
+	From     string `query:"from"`
+	from     time.Time
+	To       string `query:"to"`
+	to       time.Time
+	Limit    int `query:"limit"`
+	Offset   int `query:"offset"`
+}
+
+func (q *queryResult) validate() {
+	if from, _, ok := timeparam.Parse(q.From); ok {
+		q.from = from
+	}
+
+	// a bare date includes the whole day
+	if to, dateOnly, ok := timeparam.Parse(q.To); ok {
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		q.to = to
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+type rangeRes struct {
+	Low  *float64 `json:"low"`
+	High *float64 `json:"high"`
+}
+
+type ageRangeRes struct {
+	MinAge      int       `json:"minAge"`
+	MaxAge      int       `json:"maxAge"`
+	Range       *rangeRes `json:"range,omitempty"`
+	MaleRange   *rangeRes `json:"maleRange,omitempty"`
+	FemaleRange *rangeRes `json:"femaleRange,omitempty"`
+}
+
+type testRes struct {
+	Code         string        `json:"code"`
+	Name         string        `json:"name"`
+	Specimen     string        `json:"specimen"`
+	Unit         string        `json:"unit"`
+	ResultType   string        `json:"resultType"`
+	Range        *rangeRes     `json:"range,omitempty"`
+	MaleRange    *rangeRes     `json:"maleRange,omitempty"`
+	FemaleRange  *rangeRes     `json:"femaleRange,omitempty"`
+	AgeRanges    []ageRangeRes `json:"ageRanges,omitempty"`
+	CriticalLow  *float64      `json:"criticalLow,omitempty"`
+	CriticalHigh *float64      `json:"criticalHigh,omitempty"`
+	NormalValues []string      `json:"normalValues,omitempty"`
+}
+
+func newRangeRes(r domain.LabRange) *rangeRes {
+	if r.Low == nil && r.High == nil {
+		return nil
+	}
+
+	return &rangeRes{Low: r.Low, High: r.High}
+}
+
+func newCatalogueRes(tests domain.LabTests) []testRes {
+	res := make([]testRes, 0, len(tests))
+	for i := range tests {
+		var ageRanges []ageRangeRes
+		for _, ageRange := range tests[i].AgeRanges {
+			ageRanges = append(ageRanges, ageRangeRes{
+				MinAge:      ageRange.MinAge,
+				MaxAge:      ageRange.MaxAge,
+				Range:       newRangeRes(ageRange.Range),
+				MaleRange:   newRangeRes(ageRange.MaleRange),
+				FemaleRange: newRangeRes(ageRange.FemaleRange),
+			})
+		}
+
+		res = append(res, testRes{
+			Code:         tests[i].Code,
+			Name:         tests[i].Name,
+			Specimen:     tests[i].Specimen,
+			Unit:         tests[i].Unit,
+			ResultType:   tests[i].ResultType,
+			Range:        newRangeRes(tests[i].Range),
+			MaleRange:    newRangeRes(tests[i].MaleRange),
+			FemaleRange:  newRangeRes(tests[i].FemaleRange),
+			AgeRanges:    ageRanges,
+			CriticalLow:  tests[i].CriticalLow,
+			CriticalHigh: tests[i].CriticalHigh,
+			NormalValues: tests[i].NormalValues,
+		})
+	}
+
+	return res
+}
+
+type patientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+}
+
+type staffRes struct {
+	UserID ulid.ULID `json:"userId"`
+	Name   string    `json:"name"`
+}
+
+type attachmentRes struct {
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+}
+
+type resultRes struct {
+	ResultID   ulid.ULID      `json:"resultId"`
+	OrderID    ulid.ULID      `json:"orderId"`
+	TestCode   string         `json:"testCode"`
+	TestName   string         `json:"testName"`
+	Unit       string         `json:"unit"`
+	ResultType string         `json:"resultType"`
+	Status     string         `json:"status"`
+	Value      *float64       `json:"value,omitempty"`
+	Text       string         `json:"text,omitempty"`
+	Flag       string         `json:"flag,omitempty"`
+	Reference  *rangeRes      `json:"reference,omitempty"`
+	NormalText string         `json:"normalText,omitempty"`
+	Attachment *attachmentRes `json:"attachment,omitempty"`
+	Note       string         `json:"note,omitempty"`
+	ResultedBy *staffRes      `json:"resultedBy"`
+	ResultedAt string         `json:"resultedAt,omitempty"`
+}
+
+func newResultRes(result *domain.LabResult) resultRes {
+	res := resultRes{
+		ResultID:   result.ID,
+		OrderID:    result.OrderID,
+		TestCode:   result.TestCode,
+		TestName:   result.TestName,
+		Unit:       result.Unit,
+		ResultType: result.ResultType,
+		Status:     result.Status,
+		Value:      result.Value,
+		Text:       result.ValueText,
+		Flag:       result.Flag,
+		Reference:  newRangeRes(domain.LabRange{Low: result.ReferenceLow, High: result.ReferenceHigh}),
+		NormalText: result.ReferenceText,
+		Note:       result.Note,
+	}
+	if result.AttachmentURL != "" {
+		res.Attachment = &attachmentRes{
+			URL:         result.AttachmentURL,
+			Name:        result.AttachmentName,
+			ContentType: result.AttachmentContentType,
+		}
+	}
+	if !id.IsZero(result.ResultedBy) {
+		res.ResultedBy = &staffRes{
+			UserID: result.ResultedBy,
+			Name:   result.ResultedByName,
+		}
+		res.ResultedAt = result.ResultedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+type orderRes struct {
+	OrderID      ulid.ULID   `json:"orderId"`
+	Patient      patientRes  `json:"patient"`
+	RecordID     ulid.ULID   `json:"recordId"`
+	Priority     string      `json:"priority"`
+	Status       string      `json:"status"`
+	Notes        string      `json:"notes"`
+	OrderedBy    staffRes    `json:"orderedBy"`
+	OrderedAt    string      `json:"orderedAt"`
+	CollectedBy  *staffRes   `json:"collectedBy"`
+	CollectedAt  string      `json:"collectedAt,omitempty"`
+	CancelledAt  string      `json:"cancelledAt,omitempty"`
+	CancelReason string      `json:"cancelReason,omitempty"`
+	Results      []resultRes `json:"results"`
+}
+
+func newOrderRes(order *domain.LabOrder) orderRes {
+	res := orderRes{
+		OrderID: order.ID,
+		Patient: patientRes{
+			IdentityNumber: idNumber(order.PatientID),
+			Name:           order.PatientName,
+		},
+		RecordID: order.RecordID,
+		Priority: order.Priority,
+		Status:   order.Status,
+		Notes:    order.Notes,
+		OrderedBy: staffRes{
+			UserID: order.OrderedBy,
+			Name:   order.OrderedByName,
+		},
+		OrderedAt:    order.OrderedAt.Format(dateFormat),
+		CancelReason: order.CancelReason,
+		Results:      make([]resultRes, 0, len(order.Results)),
+	}
+	if !id.IsZero(order.CollectedBy) {
+		res.CollectedBy = &staffRes{
+			UserID: order.CollectedBy,
+			Name:   order.CollectedByName,
+		}
+		res.CollectedAt = order.CollectedAt.Format(dateFormat)
+	}
+	if !order.CancelledAt.IsZero() {
+		res.CancelledAt = order.CancelledAt.Format(dateFormat)
+	}
+	for i := range order.Results {
+		res.Results = append(res.Results, newResultRes(&order.Results[i]))
+	}
+
+	return res
+}
+
+const labInitCap = 5
+
+var getOrdersResPool = sync.Pool{
+	New: func() any {
+		return make(getOrdersRes, 0, labInitCap)
+	},
+}
+
+func getOrdersResAcquire() getOrdersRes {
+	return getOrdersResPool.Get().(getOrdersRes)
+}
+
+func getOrdersResRelease(t getOrdersRes) {
+	t = t[:0]
+	getOrdersResPool.Put(t) // nolint:staticcheck
+}
+
+type getOrdersRes []orderRes
+
+// cumulativeRes lines up every result of one test for a patient, oldest
+// first, so trends can be read at a glance.
+type cumulativeRes struct {
+	TestCode string      `json:"testCode"`
+	TestName string      `json:"testName"`
+	Unit     string      `json:"unit"`
+	Results  []resultRes `json:"results"`
+}
+
+// newCumulativeRes groups results that are already ordered by test code.
+func newCumulativeRes(results domain.LabResults) []cumulativeRes {
+	res := make([]cumulativeRes, 0, len(results))
+	for i := range results {
+		if len(res) == 0 || res[len(res)-1].TestCode != results[i].TestCode {
+			res = append(res, cumulativeRes{
+				TestCode: results[i].TestCode,
+				TestName: results[i].TestName,
+				Unit:     results[i].Unit,
+			})
+		}
+
+		last := &res[len(res)-1]
+		last.Results = append(last.Results, newResultRes(&results[i]))
+	}
+
+	return res
+}
@@ -0,0 +1,29 @@
+package lab
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/catalogue"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	// the catalogue is bundled with the binary, a broken one is a build mistake
+	labCatalogue, err := catalogue.Load()
+	if err != nil {
+		panic(fmt.Errorf("[lab.NewModule] failed to load lab catalogue: %v", err))
+	}
+
+	labRepository := repository.NewLabRepository(db)
+	labService := service.NewLabService(ctxTimeout, labCatalogue, labRepository)
+	handler.NewLabHandler(router, jwtMiddleware, labService)
+}
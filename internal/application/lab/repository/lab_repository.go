@@ -0,0 +1,855 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	orderColumns = `o.id, o.patient_id, p.name, o.record_id, o.priority, o.status, o.notes, o.ordered_by, 
+		o.ordered_by_name, o.ordered_at, o.collected_by, o.collected_by_name, o.collected_at, o.cancelled_by, 
+		o.cancelled_at, o.cancel_reason`
+	orderTables = ` FROM lab_orders o JOIN patients p ON p.id = o.patient_id`
+
+	resultColumns = `r.id, r.order_id, o.status, r.patient_id, p.is_male, p.birth_date, r.test_code, r.test_name, r.unit, 
+		r.result_type, r.status, r.value_numeric, r.value_text, r.flag, r.reference_low, r.reference_high, 
+		r.reference_text, r.attachment_url, r.attachment_name, r.attachment_content_type, r.note, r.resulted_by, 
+		r.resulted_by_name, r.resulted_at`
+	resultTables = ` FROM lab_results r 
+		JOIN lab_orders o ON o.id = r.order_id 
+		JOIN patients p ON p.id = r.patient_id`
+)
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type LabRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLabRepository(db *pgxpool.Pool) *LabRepository {
+	return &LabRepository{db: db}
+}
+
+// CreateOrder saves the order with a pending result for every test in
+// order.Results, the record has to belong to the patient.
+func (r LabRepository) CreateOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error {
+	callerInfo := "[LabRepository.CreateOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	order.ID = id.New()
+	order.Status = domain.LabOrderOrdered
+	order.OrderedBy = user.ID
+	order.OrderedByName = user.Name
+	order.OrderedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var patientID string
+	recordQuery := `SELECT patient_id FROM medical_records WHERE id = @id`
+	err = tx.QueryRow(ctx, recordQuery, pgx.NamedArgs{"id": order.RecordID}).Scan(&patientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrMedicalRecordNotFound)
+		}
+
+		l.Error("failed to get medical record", zap.Error(err))
+		return err
+	}
+
+	if patientID != order.PatientID {
+		return new(domain.ErrRecordPatientMismatch)
+	}
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO lab_orders (
+				id, patient_id, record_id, priority, status, notes, ordered_by, ordered_by_name, ordered_at
+			)
+			SELECT @id, p.id, @record_id, @priority, @status, @notes, @ordered_by, @ordered_by_name, @ordered_at
+			FROM patients p WHERE p.id = @patient_id
+			RETURNING patient_id
+		)
+		SELECT p.name FROM inserted i JOIN patients p ON p.id = i.patient_id`
+	args := pgx.NamedArgs{
+		"id":              order.ID,
+		"patient_id":      order.PatientID,
+		"record_id":       order.RecordID,
+		"priority":        order.Priority,
+		"status":          order.Status,
+		"notes":           order.Notes,
+		"ordered_by":      order.OrderedBy,
+		"ordered_by_name": order.OrderedByName,
+		"ordered_at":      order.OrderedAt,
+	}
+
+	if err = tx.QueryRow(ctx, insertQuery, args).Scan(&order.PatientName); err != nil {
+		l.Error("failed to create lab order", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+		return err
+	}
+
+	results := make([][]any, 0, len(order.Results))
+	for i := range order.Results {
+		result := &order.Results[i]
+		result.ID = id.New()
+		result.OrderID = order.ID
+		result.OrderStatus = order.Status
+		result.PatientID = order.PatientID
+		result.Status = domain.LabResultPending
+
+		results = append(results, []any{
+			result.ID.Bytes(),
+			order.ID.Bytes(),
+			order.PatientID,
+			result.TestCode,
+			result.TestName,
+			result.Unit,
+			result.ResultType,
+			result.Status,
+		})
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"lab_results"},
+		[]string{"id", "order_id", "patient_id", "test_code", "test_name", "unit", "result_type", "status"},
+		pgx.CopyFromRows(results),
+	)
+	if err != nil {
+		l.Error("failed to save lab tests", zap.Error(err))
+		return err
+	}
+
+	if err = r.insertEvent(ctx, tx, order, domain.PatientEventLabOrdered, user, nil); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// CollectOrder records that the samples of an ordered order were taken.
+func (r LabRepository) CollectOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error {
+	callerInfo := "[LabRepository.CollectOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.lockOrder(ctx, tx, order); err != nil {
+		l.Error("failed to get lab order", zap.Error(err))
+		return err
+	}
+
+	switch order.Status {
+	case domain.LabOrderOrdered:
+	case domain.LabOrderCollected:
+		return new(domain.ErrLabOrderCollected)
+	default:
+		return new(domain.ErrLabOrderNotOpen)
+	}
+
+	order.Status = domain.LabOrderCollected
+	order.CollectedBy = user.ID
+	order.CollectedByName = user.Name
+	order.CollectedAt = time.Now()
+
+	updateQuery := `UPDATE lab_orders SET status = @status, collected_by = @collected_by, 
+			collected_by_name = @collected_by_name, collected_at = @collected_at 
+		WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":                order.ID,
+		"status":            order.Status,
+		"collected_by":      order.CollectedBy,
+		"collected_by_name": order.CollectedByName,
+		"collected_at":      order.CollectedAt,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to collect lab order", zap.Error(err))
+		return err
+	}
+
+	for i := range order.Results {
+		order.Results[i].OrderStatus = order.Status
+	}
+
+	if err = r.insertEvent(ctx, tx, order, domain.PatientEventLabCollected, user, nil); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// CancelOrder cancels an order that is not resulted yet, tests already
+// resulted keep their result.
+func (r LabRepository) CancelOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error {
+	callerInfo := "[LabRepository.CancelOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	reason := order.CancelReason
+
+	if err = r.lockOrder(ctx, tx, order); err != nil {
+		l.Error("failed to get lab order", zap.Error(err))
+		return err
+	}
+
+	if order.Status != domain.LabOrderOrdered && order.Status != domain.LabOrderCollected {
+		return new(domain.ErrLabOrderNotOpen)
+	}
+
+	order.Status = domain.LabOrderCancelled
+	order.CancelledBy = user.ID
+	order.CancelledAt = time.Now()
+	order.CancelReason = reason
+
+	updateQuery := `UPDATE lab_orders SET status = @status, cancelled_by = @cancelled_by, cancelled_at = @cancelled_at, 
+			cancel_reason = @cancel_reason 
+		WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":            order.ID,
+		"status":        order.Status,
+		"cancelled_by":  order.CancelledBy,
+		"cancelled_at":  order.CancelledAt,
+		"cancel_reason": order.CancelReason,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to cancel lab order", zap.Error(err))
+		return err
+	}
+
+	cancelQuery := `UPDATE lab_results SET status = @cancelled WHERE order_id = @order_id AND status = @pending`
+	cancelArgs := pgx.NamedArgs{
+		"order_id":  order.ID,
+		"cancelled": domain.LabResultCancelled,
+		"pending":   domain.LabResultPending,
+	}
+
+	if _, err = tx.Exec(ctx, cancelQuery, cancelArgs); err != nil {
+		l.Error("failed to cancel lab tests", zap.Error(err))
+		return err
+	}
+
+	for i := range order.Results {
+		order.Results[i].OrderStatus = order.Status
+		if order.Results[i].Status == domain.LabResultPending {
+			order.Results[i].Status = domain.LabResultCancelled
+		}
+	}
+
+	err = r.insertEvent(ctx, tx, order, domain.PatientEventLabCancelled, user, map[string]any{
+		"reason": order.CancelReason,
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r LabRepository) GetOrders(
+	ctx context.Context,
+	filter *domain.FilterLabOrder,
+	orders domain.LabOrders,
+) (domain.LabOrders, error) {
+	callerInfo := "[LabRepository.GetOrders]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterOrder(filter)
+
+	orders, err := r.loadOrders(ctx, r.db, conditions, params, orders)
+	if err != nil {
+		l.Error("failed to get lab orders", zap.Error(err))
+		return orders, err
+	}
+
+	return orders, nil
+}
+
+// RecordResult saves the result of a pending test of a collected order,
+// result carries the value and the flag already computed. The order turns
+// resulted with its last test.
+func (r LabRepository) RecordResult(ctx context.Context, result *domain.LabResult, user *domain.User) error {
+	callerInfo := "[LabRepository.RecordResult]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	recorded := domain.LabResultAcquire()
+	defer domain.LabResultRelease(recorded)
+
+	// the order is locked too, so the last two results of an order can not
+	// both miss that the order is complete
+	selectQuery := `SELECT ` + resultColumns + resultTables + ` WHERE r.id = @id FOR UPDATE OF r, o`
+
+	err = r.scanResult(tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": result.ID}), recorded)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrLabResultNotFound)
+		}
+
+		l.Error("failed to get lab result", zap.Error(err))
+		return err
+	}
+
+	if recorded.Status != domain.LabResultPending {
+		return new(domain.ErrLabResultRecorded)
+	}
+
+	if recorded.OrderStatus != domain.LabOrderCollected {
+		return new(domain.ErrLabOrderNotCollected)
+	}
+
+	recorded.Status = domain.LabResultResulted
+	recorded.Value = result.Value
+	recorded.ValueText = result.ValueText
+	recorded.Flag = result.Flag
+	recorded.ReferenceLow = result.ReferenceLow
+	recorded.ReferenceHigh = result.ReferenceHigh
+	recorded.ReferenceText = result.ReferenceText
+	recorded.AttachmentURL = result.AttachmentURL
+	recorded.AttachmentName = result.AttachmentName
+	recorded.AttachmentContentType = result.AttachmentContentType
+	recorded.Note = result.Note
+	recorded.ResultedBy = user.ID
+	recorded.ResultedByName = user.Name
+	recorded.ResultedAt = time.Now()
+	*result = *recorded
+
+	updateQuery := `UPDATE lab_results SET status = @status, value_numeric = @value_numeric, value_text = @value_text, 
+			flag = @flag, reference_low = @reference_low, reference_high = @reference_high, 
+			reference_text = @reference_text, attachment_url = @attachment_url, attachment_name = @attachment_name, 
+			attachment_content_type = @attachment_content_type, note = @note, resulted_by = @resulted_by, 
+			resulted_by_name = @resulted_by_name, resulted_at = @resulted_at 
+		WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":                      result.ID,
+		"status":                  result.Status,
+		"value_numeric":           result.Value,
+		"value_text":              result.ValueText,
+		"flag":                    result.Flag,
+		"reference_low":           result.ReferenceLow,
+		"reference_high":          result.ReferenceHigh,
+		"reference_text":          result.ReferenceText,
+		"attachment_url":          result.AttachmentURL,
+		"attachment_name":         result.AttachmentName,
+		"attachment_content_type": result.AttachmentContentType,
+		"note":                    result.Note,
+		"resulted_by":             result.ResultedBy,
+		"resulted_by_name":        result.ResultedByName,
+		"resulted_at":             result.ResultedAt,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to record lab result", zap.Error(err))
+		return err
+	}
+
+	completeQuery := `UPDATE lab_orders SET status = @resulted 
+		WHERE id = @id AND NOT EXISTS (SELECT 1 FROM lab_results WHERE order_id = @id AND status = @pending)`
+	completeArgs := pgx.NamedArgs{
+		"id":       result.OrderID,
+		"resulted": domain.LabOrderResulted,
+		"pending":  domain.LabResultPending,
+	}
+
+	tag, err := tx.Exec(ctx, completeQuery, completeArgs)
+	if err != nil {
+		l.Error("failed to complete lab order", zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		result.OrderStatus = domain.LabOrderResulted
+	}
+
+	data := map[string]any{
+		"orderId":  result.OrderID.String(),
+		"resultId": result.ID.String(),
+		"testCode": result.TestCode,
+		"testName": result.TestName,
+		"flag":     result.Flag,
+	}
+	if result.Value != nil {
+		data["value"] = *result.Value
+	} else {
+		data["value"] = result.ValueText
+	}
+
+	event, err := patientevent.New(result.PatientID, domain.PatientEventLabResulted, user, data)
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	if err = patientevent.Insert(ctx, tx, event); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r LabRepository) GetResults(
+	ctx context.Context,
+	filter *domain.FilterLabResult,
+	results domain.LabResults,
+) (domain.LabResults, error) {
+	callerInfo := "[LabRepository.GetResults]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterResult(filter)
+	getQuery := `SELECT ` + resultColumns + resultTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get lab results", zap.Error(err))
+		return results, err
+	}
+	defer rows.Close()
+
+	dResult := domain.LabResultAcquire()
+	defer domain.LabResultRelease(dResult)
+
+	for rows.Next() {
+		if err = r.scanResult(rows, dResult); err != nil {
+			l.Error("failed to scan lab result", zap.Error(err))
+			return results, err
+		}
+		results = append(results, *dResult)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get lab results", zap.Error(err))
+		return results, err
+	}
+
+	return results, nil
+}
+
+// lockOrder reads order.ID with its tests into order and locks it.
+func (r LabRepository) lockOrder(ctx context.Context, tx pgx.Tx, order *domain.LabOrder) error {
+	orders := domain.LabOrdersAcquire()
+	defer domain.LabOrdersRelease(orders)
+
+	conditions := ` WHERE o.id = @id FOR UPDATE OF o`
+
+	orders, err := r.loadOrders(ctx, tx, conditions, pgx.NamedArgs{"id": order.ID}, orders)
+	if err != nil {
+		return err
+	}
+
+	if len(orders) == 0 {
+		return new(domain.ErrLabOrderNotFound)
+	}
+
+	*order = orders[0]
+	return nil
+}
+
+// loadOrders reads the orders matching conditions and then the tests of all
+// of them in one query.
+func (r LabRepository) loadOrders(
+	ctx context.Context,
+	q querier,
+	conditions string,
+	params pgx.NamedArgs,
+	orders domain.LabOrders,
+) (domain.LabOrders, error) {
+	rows, err := q.Query(ctx, `SELECT `+orderColumns+orderTables+conditions, params)
+	if err != nil {
+		return orders, err
+	}
+
+	dOrder := domain.LabOrderAcquire()
+	defer domain.LabOrderRelease(dOrder)
+	var (
+		collectedByName, cancelReason *string
+		collectedAt, cancelledAt      *time.Time
+	)
+
+	start := len(orders)
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dOrder.ID,
+			&dOrder.PatientID,
+			&dOrder.PatientName,
+			&dOrder.RecordID,
+			&dOrder.Priority,
+			&dOrder.Status,
+			&dOrder.Notes,
+			&dOrder.OrderedBy,
+			&dOrder.OrderedByName,
+			&dOrder.OrderedAt,
+			&dOrder.CollectedBy,
+			&collectedByName,
+			&collectedAt,
+			&dOrder.CancelledBy,
+			&cancelledAt,
+			&cancelReason,
+		},
+		func() error {
+			order := *dOrder
+			order.CollectedByName, order.CancelReason = "", ""
+			order.CollectedAt, order.CancelledAt = time.Time{}, time.Time{}
+
+			if collectedByName != nil {
+				order.CollectedByName = *collectedByName
+			}
+			if collectedAt != nil {
+				order.CollectedAt = *collectedAt
+			}
+			if cancelledAt != nil {
+				order.CancelledAt = *cancelledAt
+			}
+			if cancelReason != nil {
+				order.CancelReason = *cancelReason
+			}
+
+			orders = append(orders, order)
+			dOrder.CollectedBy, dOrder.CancelledBy = ulid.ULID{}, ulid.ULID{}
+			return nil
+		},
+	)
+	if err != nil {
+		return orders, err
+	}
+
+	if len(orders) == start {
+		return orders, nil
+	}
+
+	orderIDs := make([][]byte, 0, len(orders)-start)
+	index := make(map[ulid.ULID]int, len(orders)-start)
+	for i := start; i < len(orders); i++ {
+		orderIDs = append(orderIDs, orders[i].ID.Bytes())
+		index[orders[i].ID] = i
+	}
+
+	resultQuery := `SELECT ` + resultColumns + resultTables + ` WHERE r.order_id = ANY(@order_ids) 
+		ORDER BY r.test_code ASC`
+
+	resultRows, err := q.Query(ctx, resultQuery, pgx.NamedArgs{"order_ids": orderIDs})
+	if err != nil {
+		return orders, err
+	}
+	defer resultRows.Close()
+
+	dResult := domain.LabResultAcquire()
+	defer domain.LabResultRelease(dResult)
+
+	for resultRows.Next() {
+		if err = r.scanResult(resultRows, dResult); err != nil {
+			return orders, err
+		}
+
+		i := index[dResult.OrderID]
+		orders[i].Results = append(orders[i].Results, *dResult)
+	}
+
+	return orders, resultRows.Err()
+}
+
+func (r LabRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	order *domain.LabOrder,
+	eventType string,
+	user *domain.User,
+	extra map[string]any,
+) error {
+	tests := make([]string, 0, len(order.Results))
+	for i := range order.Results {
+		tests = append(tests, order.Results[i].TestCode)
+	}
+
+	data := map[string]any{
+		"orderId":  order.ID.String(),
+		"recordId": order.RecordID.String(),
+		"priority": order.Priority,
+		"tests":    tests,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	event, err := patientevent.New(order.PatientID, eventType, user, data)
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+func (r LabRepository) scanResult(row pgx.Row, result *domain.LabResult) error {
+	var (
+		valueText, flag, referenceText, attachmentURL, attachmentName *string
+		attachmentContentType, note, resultedByName                   *string
+		resultedAt                                                    *time.Time
+	)
+
+	// pointers are reset so results scanned before keep their own values
+	result.ResultedBy = ulid.ULID{}
+	result.Value, result.ReferenceLow, result.ReferenceHigh = nil, nil, nil
+
+	err := row.Scan(
+		&result.ID,
+		&result.OrderID,
+		&result.OrderStatus,
+		&result.PatientID,
+		&result.PatientIsMale,
+		&result.PatientBirthDate,
+		&result.TestCode,
+		&result.TestName,
+		&result.Unit,
+		&result.ResultType,
+		&result.Status,
+		&result.Value,
+		&valueText,
+		&flag,
+		&result.ReferenceLow,
+		&result.ReferenceHigh,
+		&referenceText,
+		&attachmentURL,
+		&attachmentName,
+		&attachmentContentType,
+		&note,
+		&result.ResultedBy,
+		&resultedByName,
+		&resultedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	result.ValueText, result.Flag, result.ReferenceText, result.AttachmentURL = "", "", "", ""
+	result.AttachmentName, result.AttachmentContentType, result.Note, result.ResultedByName = "", "", "", ""
+	result.ResultedAt = time.Time{}
+
+	if valueText != nil {
+		result.ValueText = *valueText
+	}
+	if flag != nil {
+		result.Flag = *flag
+	}
+	if referenceText != nil {
+		result.ReferenceText = *referenceText
+	}
+	if attachmentURL != nil {
+		result.AttachmentURL = *attachmentURL
+	}
+	if attachmentName != nil {
+		result.AttachmentName = *attachmentName
+	}
+	if attachmentContentType != nil {
+		result.AttachmentContentType = *attachmentContentType
+	}
+	if note != nil {
+		result.Note = *note
+	}
+	if resultedByName != nil {
+		result.ResultedByName = *resultedByName
+	}
+	if resultedAt != nil {
+		result.ResultedAt = *resultedAt
+	}
+
+	return nil
+}
+
+func (r LabRepository) filterOrder(filter *domain.FilterLabOrder) (string, pgx.NamedArgs) {
+	const totalConditions = 5
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "o.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "o.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.RecordID) {
+		conditions = append(conditions, "o.record_id = @record_id")
+		params["record_id"] = filter.RecordID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "o.status = @status")
+		params["status"] = filter.Status
+	}
+
+	if filter.Priority != "" {
+		conditions = append(conditions, "o.priority = @priority")
+		params["priority"] = filter.Priority
+	}
+
+	// urgent orders still waiting come first
+	order := " ORDER BY (o.priority = 'urgent' AND o.status IN ('ordered', 'collected')) DESC, o.ordered_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+func (r LabRepository) filterResult(filter *domain.FilterLabResult) (string, pgx.NamedArgs) {
+	const totalConditions = 6
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "r.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "r.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if filter.TestCode != "" {
+		conditions = append(conditions, "r.test_code = @test_code")
+		params["test_code"] = filter.TestCode
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "r.status = @status")
+		params["status"] = filter.Status
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "r.resulted_at >= @from")
+		params["from"] = filter.From
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "r.resulted_at < @to")
+		params["to"] = filter.To
+	}
+
+	order := " ORDER BY r.test_code ASC, r.resulted_at ASC NULLS LAST"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ LabRepositoryContract = (*LabRepository)(nil)
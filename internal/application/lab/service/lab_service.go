@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/catalogue"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LabService struct {
+	labRepository  repository.LabRepositoryContract
+	catalogue      *catalogue.Catalogue
+	contextTimeout time.Duration
+}
+
+func NewLabService(
+	timeout time.Duration,
+	catalogue *catalogue.Catalogue,
+	labRepository repository.LabRepositoryContract,
+) *LabService {
+	return &LabService{
+		labRepository:  labRepository,
+		catalogue:      catalogue,
+		contextTimeout: timeout,
+	}
+}
+
+func (s LabService) GetCatalogue() domain.LabTests {
+	return s.catalogue.All()
+}
+
+// CreateOrder orders the tests in testCodes, every code has to be in the
+// catalogue.
+func (s LabService) CreateOrder(
+	ctx context.Context,
+	order *domain.LabOrder,
+	testCodes []string,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabService.CreateOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	order.Results = make(domain.LabResults, 0, len(testCodes))
+	for _, code := range testCodes {
+		test, ok := s.catalogue.Get(code)
+		if !ok {
+			return new(domain.ErrLabTestNotFound)
+		}
+
+		order.Results = append(order.Results, domain.LabResult{
+			TestCode:   test.Code,
+			TestName:   test.Name,
+			Unit:       test.Unit,
+			ResultType: test.ResultType,
+		})
+	}
+
+	err := s.labRepository.CreateOrder(ctx, order, user)
+	if err != nil {
+		l.Error("failed to create lab order", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s LabService) CollectOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabService.CollectOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.labRepository.CollectOrder(ctx, order, user)
+	if err != nil {
+		l.Error("failed to collect lab order", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s LabService) CancelOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabService.CancelOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.labRepository.CancelOrder(ctx, order, user)
+	if err != nil {
+		l.Error("failed to cancel lab order", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s LabService) GetOrders(
+	ctx context.Context,
+	filter *domain.FilterLabOrder,
+	orders domain.LabOrders,
+) (domain.LabOrders, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabService.GetOrders]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	orders, err := s.labRepository.GetOrders(ctx, filter, orders)
+	if err != nil {
+		l.Error("failed to get lab orders", zap.Error(err))
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// RecordResult flags the result against the reference range of the test for
+// the sex and age of the patient before it is saved, tests no longer in the
+// catalogue are saved unflagged.
+func (s LabService) RecordResult(ctx context.Context, result *domain.LabResult, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabService.RecordResult]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterLabResultAcquire()
+	defer domain.FilterLabResultRelease(filter)
+
+	filter.ID = result.ID
+
+	current := domain.LabResultsAcquire()
+	defer domain.LabResultsRelease(current)
+
+	current, err := s.labRepository.GetResults(ctx, filter, current)
+	if err != nil {
+		l.Error("failed to get lab result", zap.Error(err))
+		return err
+	}
+
+	if len(current) == 0 {
+		return new(domain.ErrLabResultNotFound)
+	}
+
+	switch current[0].ResultType {
+	case domain.LabResultNumeric:
+		if result.Value == nil || result.ValueText != "" {
+			return new(domain.ErrLabResultType)
+		}
+	case domain.LabResultText:
+		if result.Value != nil || result.ValueText == "" {
+			return new(domain.ErrLabResultType)
+		}
+	}
+
+	if test, ok := s.catalogue.Get(current[0].TestCode); ok {
+		if result.Value != nil {
+			isMale, age := current[0].PatientIsMale, domain.AgeAt(current[0].PatientBirthDate, time.Now())
+			reference := test.RangeFor(isMale, age)
+			result.Flag = test.FlagNumeric(*result.Value, isMale, age)
+			result.ReferenceLow, result.ReferenceHigh = reference.Low, reference.High
+		} else {
+			result.Flag = test.FlagText(result.ValueText)
+			result.ReferenceText = strings.Join(test.NormalValues, ", ")
+		}
+	}
+
+	err = s.labRepository.RecordResult(ctx, result, user)
+	if err != nil {
+		l.Error("failed to record lab result", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s LabService) GetResults(
+	ctx context.Context,
+	filter *domain.FilterLabResult,
+	results domain.LabResults,
+) (domain.LabResults, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabService.GetResults]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	results, err := s.labRepository.GetResults(ctx, filter, results)
+	if err != nil {
+		l.Error("failed to get lab results", zap.Error(err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+var _ LabServiceContract = (*LabService)(nil)
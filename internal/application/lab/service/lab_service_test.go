@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/catalogue"
+	"github.com/j03hanafi/halo-suster/internal/application/lab/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// fakeRepository returns current as the pending result and keeps the one
+// recorded.
+type fakeRepository struct {
+	repository.LabRepositoryContract
+	current  domain.LabResult
+	recorded *domain.LabResult
+}
+
+func (r *fakeRepository) GetResults(
+	_ context.Context,
+	_ *domain.FilterLabResult,
+	results domain.LabResults,
+) (domain.LabResults, error) {
+	return append(results, r.current), nil
+}
+
+func (r *fakeRepository) RecordResult(_ context.Context, result *domain.LabResult, _ *domain.User) error {
+	recorded := *result
+	r.recorded = &recorded
+	return nil
+}
+
+func value(v float64) *float64 {
+	return &v
+}
+
+// bornYearsAgo is the birth date of a patient who turned years old a month
+// ago.
+func bornYearsAgo(years int) time.Time {
+	return time.Now().AddDate(-years, -1, 0)
+}
+
+func TestRecordResult(t *testing.T) {
+	labCatalogue, err := catalogue.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		testCode   string
+		resultType string
+		isMale     bool
+		birthDate  time.Time
+		value      *float64
+		text       string
+		wrongType  bool
+		flag       string
+		low, high  *float64
+		reference  string
+	}{
+		{
+			name:       "numeric result of an adult",
+			testCode:   "HGB",
+			resultType: domain.LabResultNumeric,
+			isMale:     true,
+			birthDate:  bornYearsAgo(40),
+			value:      value(13.2),
+			flag:       domain.LabFlagLow,
+			low:        value(13.5),
+			high:       value(17.5),
+		},
+		{
+			name:       "numeric result of a child",
+			testCode:   "HGB",
+			resultType: domain.LabResultNumeric,
+			isMale:     true,
+			birthDate:  bornYearsAgo(3),
+			value:      value(13.2),
+			flag:       domain.LabFlagNormal,
+			low:        value(11.0),
+			high:       value(14.0),
+		},
+		{
+			name:       "numeric result at the end of a band",
+			testCode:   "CREA",
+			resultType: domain.LabResultNumeric,
+			birthDate:  bornYearsAgo(18),
+			value:      value(0.9),
+			flag:       domain.LabFlagNormal,
+			low:        value(0.59),
+			high:       value(1.04),
+		},
+		{
+			name:       "critical numeric result",
+			testCode:   "K",
+			resultType: domain.LabResultNumeric,
+			birthDate:  bornYearsAgo(70),
+			value:      value(6.8),
+			flag:       domain.LabFlagCriticalHigh,
+			low:        value(3.5),
+			high:       value(5.1),
+		},
+		{
+			name:       "normal text result",
+			testCode:   "HBSAG",
+			resultType: domain.LabResultText,
+			birthDate:  bornYearsAgo(30),
+			text:       "Non-reactive",
+			flag:       domain.LabFlagNormal,
+			reference:  "negative, non-reactive",
+		},
+		{
+			name:       "abnormal text result",
+			testCode:   "NS1",
+			resultType: domain.LabResultText,
+			birthDate:  bornYearsAgo(8),
+			text:       "positive",
+			flag:       domain.LabFlagAbnormal,
+			reference:  "negative",
+		},
+		{
+			name:       "text result of a numeric test",
+			testCode:   "HGB",
+			resultType: domain.LabResultNumeric,
+			birthDate:  bornYearsAgo(40),
+			text:       "13.2",
+			wrongType:  true,
+		},
+		{
+			name:       "numeric result of a text test",
+			testCode:   "HBSAG",
+			resultType: domain.LabResultText,
+			birthDate:  bornYearsAgo(30),
+			value:      value(0),
+			wrongType:  true,
+		},
+		{
+			name:       "numeric and text result",
+			testCode:   "HGB",
+			resultType: domain.LabResultNumeric,
+			birthDate:  bornYearsAgo(40),
+			value:      value(13.2),
+			text:       "low",
+			wrongType:  true,
+		},
+		{
+			name:       "test no longer in the catalogue",
+			testCode:   "ESR",
+			resultType: domain.LabResultNumeric,
+			birthDate:  bornYearsAgo(40),
+			value:      value(120),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{current: domain.LabResult{
+				ID:               id.New(),
+				PatientIsMale:    tt.isMale,
+				PatientBirthDate: tt.birthDate,
+				TestCode:         tt.testCode,
+				ResultType:       tt.resultType,
+				Status:           domain.LabResultPending,
+			}}
+			s := NewLabService(time.Second, labCatalogue, repo)
+
+			result := &domain.LabResult{ID: repo.current.ID, Value: tt.value, ValueText: tt.text}
+			err := s.RecordResult(context.Background(), result, &domain.User{Name: "Siti"})
+
+			if tt.wrongType {
+				var typeErr *domain.ErrLabResultType
+				if !errors.As(err, &typeErr) {
+					t.Fatalf("RecordResult() error = %v, want %v", err, new(domain.ErrLabResultType))
+				}
+				if repo.recorded != nil {
+					t.Error("result recorded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RecordResult() error = %v", err)
+			}
+			if repo.recorded == nil {
+				t.Fatal("result not recorded")
+			}
+
+			recorded := repo.recorded
+			if recorded.Flag != tt.flag {
+				t.Errorf("Flag = %q, want %q", recorded.Flag, tt.flag)
+			}
+			if !sameBound(recorded.ReferenceLow, tt.low) || !sameBound(recorded.ReferenceHigh, tt.high) {
+				t.Errorf("reference = %v-%v, want %v-%v",
+					recorded.ReferenceLow, recorded.ReferenceHigh, tt.low, tt.high)
+			}
+			if recorded.ReferenceText != tt.reference {
+				t.Errorf("ReferenceText = %q, want %q", recorded.ReferenceText, tt.reference)
+			}
+		})
+	}
+}
+
+func sameBound(got, want *float64) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	return *got == *want
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LabServiceContract interface {
+	GetCatalogue() domain.LabTests
+	CreateOrder(ctx context.Context, order *domain.LabOrder, testCodes []string, user *domain.User) error
+	CollectOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error
+	CancelOrder(ctx context.Context, order *domain.LabOrder, user *domain.User) error
+	GetOrders(ctx context.Context, filter *domain.FilterLabOrder, orders domain.LabOrders) (domain.LabOrders, error)
+	RecordResult(ctx context.Context, result *domain.LabResult, user *domain.User) error
+	GetResults(
+		ctx context.Context,
+		filter *domain.FilterLabResult,
+		results domain.LabResults,
+	) (domain.LabResults, error)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/label/render"
+	"github.com/j03hanafi/halo-suster/internal/application/label/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const identityNumberFromParam = "identityNumber"
+
+var contentTypes = map[string]string{
+	domain.LabelFormatPDF: "application/pdf",
+	domain.LabelFormatPNG: "image/png",
+}
+
+type labelHandler struct {
+	labelService service.LabelServiceContract
+}
+
+func NewLabelHandler(router fiber.Router, jwtMiddleware fiber.Handler, labelService service.LabelServiceContract) {
+	handler := labelHandler{
+		labelService: labelService,
+	}
+
+	labelRouter := router.Group("/label", jwtMiddleware)
+	labelRouter.Get("/scan", handler.Scan)
+	labelRouter.Get("/:"+identityNumberFromParam, handler.GetLabel)
+}
+
+// GetLabel renders a wristband or specimen label of a patient, it is sent
+// inline so the browser can print it straight away.
+func (h labelHandler) GetLabel(c *fiber.Ctx) error {
+	callerInfo := "[labelHandler.GetLabel]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	patientID, err := idNumberFromParam(c.Params(identityNumberFromParam))
+	if err != nil {
+		l.Error("error parsing identityNumberParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query := queryLabelAcquire()
+	defer queryLabelRelease(query)
+
+	if err = c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = query.validate(); err != nil {
+		l.Error("error validating query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	patient := domain.PatientAcquire()
+	defer domain.PatientRelease(patient)
+
+	patient.ID = string(patientID)
+
+	label, err := h.labelService.RenderLabel(userCtx, patient, query.Type, query.Format)
+	if err != nil {
+		l.Error("failed to render label", zap.Error(err))
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentTypes[query.Format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s-%s.%s"`, query.Type, patient.ID, query.Format))
+
+	return c.Send(label)
+}
+
+// Scan resolves a code read from a wristband or label, either barcode or QR
+// code, to its patient.
+func (h labelHandler) Scan(c *fiber.Ctx) error {
+	callerInfo := "[labelHandler.Scan]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	code := c.Query("code")
+	if code == "" {
+		l.Error("missing code")
+		return errBadRequest{err: errors.New("code is required")}
+	}
+
+	patientID, err := render.ParseCode(code)
+	if err != nil {
+		l.Error("error parsing code", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	patient := domain.PatientAcquire()
+	defer domain.PatientRelease(patient)
+
+	patient.ID = patientID
+
+	err = h.labelService.GetPatient(userCtx, patient)
+	if err != nil {
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Patient found"
+
+	patientRes := patientResAcquire()
+	defer patientResRelease(patientRes)
+
+	patientRes.IdentityNumber = idNumber(patient.ID)
+	patientRes.PhoneNumber = patient.PhoneNumber
+	patientRes.Name = patient.Name
+	patientRes.BirthDate = patient.BirthDate.Format(dateFormat)
+	patientRes.Gender = patient.Gender
+	patientRes.ImgURL = patient.ImgURL
+	patientRes.CreatedAt = patient.CreatedAt.Format(dateFormat)
+
+	res.Data = patientRes
+
+	return c.JSON(res)
+}
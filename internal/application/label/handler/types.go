@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+func idNumberFromParam(param string) (idNumber, error) {
+	if _, err := strconv.ParseUint(param, 10, 64); err != nil {
+		return "", errors.New("identityNumber must be a number")
+	}
+
+	n := idNumber(param)
+	return n, n.validate()
+}
+
+var queryLabelPool = sync.Pool{
+	New: func() any {
+		return new(queryLabel)
+	},
+}
+
+func queryLabelAcquire() *queryLabel {
+	return queryLabelPool.Get().(*queryLabel)
+}
+
+func queryLabelRelease(t *queryLabel) {
+	*t = queryLabel{}
+	queryLabelPool.Put(t)
+}
+
+// queryLabel defaults to a wristband as PDF.
+type queryLabel struct {
+	Type   string `query:"type"`
+	Format string `query:"format"`
+}
+
+func (q *queryLabel) validate() error {
+	var errs error
+
+	switch q.Type {
+	case "":
+		q.Type = domain.LabelWristband
+	case domain.LabelWristband, domain.LabelSpecimen:
+	default:
+		errs = multierr.Append(errs, errors.New("type must be one of wristband or specimen"))
+	}
+
+	switch q.Format {
+	case "":
+		q.Format = domain.LabelFormatPDF
+	case domain.LabelFormatPDF, domain.LabelFormatPNG:
+	default:
+		errs = multierr.Append(errs, errors.New("format must be one of pdf or png"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var patientResPool = sync.Pool{
+	New: func() any {
+		return new(patientRes)
+	},
+}
+
+func patientResAcquire() *patientRes {
+	return patientResPool.Get().(*patientRes)
+}
+
+func patientResRelease(t *patientRes) {
+	*t = patientRes{}
+	patientResPool.Put(t)
+}
+
+type patientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	PhoneNumber    string   `json:"phoneNumber"`
+	Name           string   `json:"name"`
+	BirthDate      string   `json:"birthDate"`
+	Gender         string   `json:"gender"`
+	ImgURL         string   `json:"identityCardScanImg"`
+	CreatedAt      string   `json:"createdAt"`
+}
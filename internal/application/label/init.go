@@ -0,0 +1,21 @@
+package label
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/label/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/label/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/label/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	labelRepository := repository.NewLabelRepository(db)
+	labelService := service.NewLabelService(ctxTimeout, labelRepository)
+	handler.NewLabelHandler(router, jwtMiddleware, labelService)
+}
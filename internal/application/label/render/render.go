@@ -0,0 +1,243 @@
+// Package render draws patient wristbands and specimen labels.
+//
+// Both carry the name, birth date, gender and identity number of the patient,
+// a Code128 barcode of the identity number for handheld scanners and a QR code
+// for phone cameras. Labels are drawn at 300 dpi so they print sharp on
+// thermal label printers.
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dpi = 300
+
+type size struct {
+	widthMM  float64
+	heightMM float64
+}
+
+// sizes are the printable areas of the label stock
+var sizes = map[string]size{
+	domain.LabelWristband: {widthMM: 160, heightMM: 25},
+	domain.LabelSpecimen:  {widthMM: 50, heightMM: 25},
+}
+
+var (
+	regularFont = mustParse(goregular.TTF)
+	boldFont    = mustParse(gobold.TTF)
+	monoFont    = mustParse(gomono.TTF)
+)
+
+func mustParse(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(fmt.Errorf("[render] failed to parse bundled font: %v", err))
+	}
+	return f
+}
+
+func px(mm float64) int {
+	return int(mm / 25.4 * dpi)
+}
+
+// QRContent is what the QR code of a patient holds, the application name
+// keeps it apart from codes printed by other systems.
+func QRContent(patientID string) string {
+	return configs.Get().App.Name + ":patient:" + patientID
+}
+
+// ParseCode returns the identity number in a scanned code, which is either
+// the bare number from the barcode or the content of the QR code.
+func ParseCode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	code = strings.TrimPrefix(code, configs.Get().App.Name+":patient:")
+
+	const idNumberLength = 16
+	if len(code) != idNumberLength {
+		return "", errors.New("code is not a patient identity number")
+	}
+	if _, err := strconv.ParseUint(code, 10, 64); err != nil {
+		return "", errors.New("code is not a patient identity number")
+	}
+
+	return code, nil
+}
+
+// Label draws a label of kind for patient and encodes it in format.
+func Label(patient *domain.Patient, kind, format string) ([]byte, error) {
+	s, ok := sizes[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown label kind %q", kind)
+	}
+
+	img, err := drawLabel(patient, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	if format == domain.LabelFormatPNG {
+		return buf.Bytes(), nil
+	}
+
+	// the page is the size of the label so it prints without scaling
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: s.widthMM, Ht: s.heightMM},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle(patient.Name+" "+kind, true)
+	pdf.AddPage()
+	pdf.RegisterImageOptionsReader("label", fpdf.ImageOptions{ImageType: "PNG"}, &buf)
+	pdf.ImageOptions("label", 0, 0, s.widthMM, s.heightMM, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	var out bytes.Buffer
+	if err = pdf.Output(&out); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// drawLabel lays the QR code on the left, and the patient details over the
+// barcode on the right.
+func drawLabel(patient *domain.Patient, s size) (image.Image, error) {
+	width, height := px(s.widthMM), px(s.heightMM)
+	margin := px(1.5)
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// whole pixels per module keep every module the same size, the slack
+	// goes to the quiet zone around the code
+	qrCode, err := qr.Encode(QRContent(patient.ID), qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	qrModules := qrCode.Bounds().Dx()
+	side := qrModules * ((height - 2*margin) / qrModules)
+	qrCode, err = barcode.Scale(qrCode, side, side)
+	if err != nil {
+		return nil, err
+	}
+	qrTop := (height - side) / 2
+	draw.Draw(img, image.Rect(margin, qrTop, margin+side, qrTop+side), qrCode, image.Point{}, draw.Src)
+
+	// the gap doubles as the quiet zone on the left of the barcode
+	left := margin + side + px(3)
+	textWidth := width - left - margin
+
+	gender := "M"
+	if patient.Gender == domain.GenderFemale {
+		gender = "F"
+	}
+
+	lines := []struct {
+		font *opentype.Font
+		size float64
+		text string
+	}{
+		{boldFont, 9, patient.Name},
+		// small enough for the birth date and the whole number to fit a
+		// specimen label, only the name is ever cut
+		{regularFont, 6, "DOB " + patient.BirthDate.Format("02 Jan 2006") + "   " + gender},
+		{monoFont, 6, patient.ID},
+	}
+
+	y := margin
+	for _, line := range lines {
+		face, err := opentype.NewFace(line.font, &opentype.FaceOptions{
+			Size:    line.size,
+			DPI:     dpi,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		metrics := face.Metrics()
+		y += metrics.Ascent.Ceil()
+
+		d := &font.Drawer{
+			Dst:  img,
+			Src:  image.NewUniform(color.Black),
+			Face: face,
+			Dot:  fixed.P(left, y),
+		}
+		d.DrawString(fit(d, line.text, textWidth))
+
+		y += metrics.Descent.Ceil()
+		_ = face.Close()
+	}
+
+	barcodeHeight := height - margin - y - margin
+	bars, err := code128.Encode(patient.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := bars.Bounds().Dx()
+	if textWidth < modules {
+		return nil, errors.New("label is too narrow for the barcode")
+	}
+	barcodeWidth := modules * (textWidth / modules)
+	if maxWidth := px(60); barcodeWidth > maxWidth {
+		barcodeWidth = modules * (maxWidth / modules)
+	}
+
+	scaled, err := barcode.Scale(bars, barcodeWidth, barcodeHeight)
+	if err != nil {
+		return nil, err
+	}
+	top := height - margin - barcodeHeight
+	draw.Draw(img, image.Rect(left, top, left+barcodeWidth, top+barcodeHeight), scaled, image.Point{}, draw.Src)
+
+	return img, nil
+}
+
+// fit cuts text with an ellipsis so it is no wider than width.
+func fit(d *font.Drawer, text string, width int) string {
+	if d.MeasureString(text).Ceil() <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		cut := strings.TrimSpace(string(runes)) + "…"
+		if d.MeasureString(cut).Ceil() <= width {
+			return cut
+		}
+	}
+
+	return ""
+}
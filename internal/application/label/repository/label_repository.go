@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LabelRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLabelRepository(db *pgxpool.Pool) *LabelRepository {
+	return &LabelRepository{db: db}
+}
+
+// GetPatient fills patient from its ID.
+func (r LabelRepository) GetPatient(ctx context.Context, patient *domain.Patient) error {
+	callerInfo := "[LabelRepository.GetPatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var isMale bool
+	getQuery := `SELECT phone_number, name, birth_date, is_male, img_url, created_at FROM patients WHERE id = @id`
+	err := r.db.QueryRow(ctx, getQuery, pgx.NamedArgs{"id": patient.ID}).Scan(
+		&patient.PhoneNumber,
+		&patient.Name,
+		&patient.BirthDate,
+		&isMale,
+		&patient.ImgURL,
+		&patient.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	patient.Gender = domain.GenderMale
+	if !isMale {
+		patient.Gender = domain.GenderFemale
+	}
+
+	return nil
+}
+
+var _ LabelRepositoryContract = (*LabelRepository)(nil)
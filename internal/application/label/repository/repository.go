@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LabelRepositoryContract interface {
+	GetPatient(ctx context.Context, patient *domain.Patient) error
+}
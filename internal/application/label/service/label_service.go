@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/label/render"
+	"github.com/j03hanafi/halo-suster/internal/application/label/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LabelService struct {
+	labelRepository repository.LabelRepositoryContract
+	contextTimeout  time.Duration
+}
+
+func NewLabelService(timeout time.Duration, labelRepository repository.LabelRepositoryContract) *LabelService {
+	return &LabelService{
+		labelRepository: labelRepository,
+		contextTimeout:  timeout,
+	}
+}
+
+// RenderLabel draws a label of kind for the patient in patient.ID, filling
+// in the rest of patient.
+func (s LabelService) RenderLabel(ctx context.Context, patient *domain.Patient, kind, format string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabelService.RenderLabel]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.labelRepository.GetPatient(ctx, patient)
+	if err != nil {
+		l.Error("failed to get patient", zap.Error(err))
+		return nil, err
+	}
+
+	label, err := render.Label(patient, kind, format)
+	if err != nil {
+		l.Error("failed to render label", zap.Error(err))
+		return nil, err
+	}
+
+	return label, nil
+}
+
+func (s LabelService) GetPatient(ctx context.Context, patient *domain.Patient) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LabelService.GetPatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.labelRepository.GetPatient(ctx, patient)
+	if err != nil {
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+var _ LabelServiceContract = (*LabelService)(nil)
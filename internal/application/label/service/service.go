@@ -0,0 +1,12 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LabelServiceContract interface {
+	RenderLabel(ctx context.Context, patient *domain.Patient, kind, format string) ([]byte, error)
+	GetPatient(ctx context.Context, patient *domain.Patient) error
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bufio"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/live/hub"
+	"github.com/j03hanafi/halo-suster/internal/application/live/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	// heartbeatInterval keeps proxies from closing an idle stream, and
+	// finds out when the client is gone.
+	heartbeatInterval = 15 * time.Second
+	// replayLimit caps the events sent again to a client catching up.
+	replayLimit = 100
+	// retryDelay is how long the client waits before connecting again, in
+	// milliseconds.
+	retryDelay = "3000"
+
+	headerLastEventID = "Last-Event-ID"
+)
+
+type liveHandler struct {
+	liveService service.LiveServiceContract
+	hub         *hub.Hub
+}
+
+func NewLiveHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	liveService service.LiveServiceContract,
+	liveHub *hub.Hub,
+) {
+	handler := liveHandler{
+		liveService: liveService,
+		hub:         liveHub,
+	}
+
+	liveRouter := router.Group("/live", jwtMiddleware)
+	liveRouter.Get("/event", handler.StreamEvents)
+}
+
+// StreamEvents pushes the patient and medical record events as server-sent
+// events, about the patient with identityNumber or the patients in wardId
+// when given. The token is sent in the Authorization header as for every
+// other route, so a browser reads the stream with fetch rather than
+// EventSource. A client that connects again with Last-Event-ID gets the
+// events it missed first, an event may then be sent twice and is told apart
+// by its id.
+func (h liveHandler) StreamEvents(c *fiber.Ctx) error {
+	callerInfo := "[liveHandler.StreamEvents]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryEventAcquire()
+	defer queryEventRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := query.validate(); err != nil {
+		l.Error("error validating query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	// subscribed before the missed events are read, so none falls in between
+	subscriber := h.hub.Subscribe(query.patientID, query.wardID)
+
+	var missed domain.LiveEvents
+	if lastEventID, err := ulid.Parse(c.Get(headerLastEventID)); err == nil {
+		filter := domain.FilterLiveEventAcquire()
+		defer domain.FilterLiveEventRelease(filter)
+
+		filter.After = lastEventID
+		filter.PatientID = query.patientID
+		filter.WardID = query.wardID
+		filter.Limit = replayLimit
+
+		missed, err = h.liveService.GetEvents(userCtx, filter, make(domain.LiveEvents, 0, replayLimit))
+		if err != nil {
+			h.hub.Unsubscribe(subscriber)
+			l.Error("failed to get missed events", zap.Error(err))
+			return err
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// nginx would otherwise hold the events back
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.Unsubscribe(subscriber)
+
+		_, _ = w.WriteString("retry: " + retryDelay + "\n\n")
+		for i := range missed {
+			if err := writeEvent(w, &missed[i]); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-subscriber.Events():
+				if !ok {
+					// the server is stopping or the client fell behind, it
+					// connects again and catches up
+					return
+				}
+				if err := writeEvent(w, &event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				_, _ = w.WriteString(": heartbeat\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
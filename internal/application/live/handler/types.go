@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+// idNumber is an identity number, sent as a number as in the rest of the API.
+type idNumber string
+
+func (n idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(n))
+	if err != nil {
+		return json.Marshal(string(n))
+	}
+	return json.Marshal(jsonID)
+}
+
+var queryEventPool = sync.Pool{
+	New: func() any {
+		return new(queryEvent)
+	},
+}
+
+func queryEventAcquire() *queryEvent {
+	return queryEventPool.Get().(*queryEvent)
+}
+
+func queryEventRelease(t *queryEvent) {
+	*t = queryEvent{}
+	queryEventPool.Put(t)
+}
+
+type queryEvent struct {
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	WardID         string `query:"wardId"`
+	wardID         ulid.ULID
+}
+
+// validate turns down a filter it cannot read rather than dropping it, which
+// would follow every patient instead.
+func (q *queryEvent) validate() error {
+	if q.IdentityNumber < 0 {
+		return errors.New("identityNumber must be a number")
+	}
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.WardID != "" {
+		wardID, err := ulid.Parse(q.WardID)
+		if err != nil {
+			return errors.New("wardId must be a valid ward id")
+		}
+		q.wardID = wardID
+	}
+
+	return nil
+}
+
+type eventRes struct {
+	ID             ulid.ULID       `json:"id"`
+	Type           string          `json:"type"`
+	IdentityNumber idNumber        `json:"identityNumber"`
+	WardID         *ulid.ULID      `json:"wardId"`
+	CreatedAt      string          `json:"createdAt"`
+	Data           json.RawMessage `json:"data"`
+}
+
+func newEventRes(event *domain.LiveEvent) eventRes {
+	res := eventRes{
+		ID:             event.Event.ID,
+		Type:           event.Event.Type,
+		IdentityNumber: idNumber(event.Event.PatientID),
+		CreatedAt:      event.Event.CreatedAt.Format(dateFormat),
+		Data:           event.Event.Data,
+	}
+	if !id.IsZero(event.WardID) {
+		wardID := event.WardID
+		res.WardID = &wardID
+	}
+	return res
+}
+
+// writeEvent writes event as a server-sent event and flushes it to the
+// client, an error means the client is gone.
+func writeEvent(w *bufio.Writer, event *domain.LiveEvent) error {
+	data, err := json.Marshal(newEventRes(event))
+	if err != nil {
+		return err
+	}
+
+	_, _ = w.WriteString("id: " + event.Event.ID.String() + "\n")
+	_, _ = w.WriteString("event: " + event.Event.Type + "\n")
+	_, _ = w.WriteString("data: ")
+	_, _ = w.Write(data)
+	_, _ = w.WriteString("\n\n")
+
+	return w.Flush()
+}
@@ -0,0 +1,280 @@
+// Package hub pushes the outbox events about patients to the live
+// subscribers of this process. Every process listens on a connection of its
+// own, so an event committed by any of them reaches the subscribers of every
+// prefork worker.
+package hub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/live/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	// subscriberBuffer is how many events a subscriber can fall behind
+	// before it is dropped.
+	subscriberBuffer = 64
+	// publishBatch is how many notified events are read at once.
+	publishBatch = 100
+	// catchUpLimit caps the events read after listening again.
+	catchUpLimit = 500
+
+	reconnectDelay = 5 * time.Second
+)
+
+// Subscriber receives the events about PatientID, or about the patients in
+// WardID, or every event when both are empty.
+type Subscriber struct {
+	PatientID string
+	WardID    ulid.ULID
+	events    chan domain.LiveEvent
+}
+
+// Events is closed when the hub stops or the subscriber falls too far
+// behind, the client then connects again and catches up with Last-Event-ID.
+func (s *Subscriber) Events() <-chan domain.LiveEvent {
+	return s.events
+}
+
+func (s *Subscriber) matches(event *domain.LiveEvent) bool {
+	if s.PatientID != "" && s.PatientID != event.Event.PatientID {
+		return false
+	}
+	if !id.IsZero(s.WardID) && s.WardID != event.WardID {
+		return false
+	}
+	return true
+}
+
+type Hub struct {
+	liveService service.LiveServiceContract
+	pending     chan ulid.ULID
+	catchUp     chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+	stopped     bool
+
+	// the newest event read, only used by publish
+	lastID ulid.ULID
+}
+
+func NewHub(liveService service.LiveServiceContract) *Hub {
+	return &Hub{
+		liveService: liveService,
+		pending:     make(chan ulid.ULID, publishBatch),
+		catchUp:     make(chan struct{}, 1),
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe starts pushing the events matching patientID and wardID.
+func (h *Hub) Subscribe(patientID string, wardID ulid.ULID) *Subscriber {
+	subscriber := &Subscriber{
+		PatientID: patientID,
+		WardID:    wardID,
+		events:    make(chan domain.LiveEvent, subscriberBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		close(subscriber.events)
+		return subscriber
+	}
+	h.subscribers[subscriber] = struct{}{}
+
+	return subscriber
+}
+
+func (h *Hub) Unsubscribe(subscriber *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[subscriber]; ok {
+		delete(h.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
+
+// Run listens for the outbox events and pushes them to the subscribers until
+// ctx is done, then closes every subscriber. A lost connection is opened
+// again, and the events committed meanwhile are caught up.
+func (h *Hub) Run(ctx context.Context) {
+	callerInfo := "[Hub.Run]"
+	l := zap.L().With(zap.String("caller", callerInfo))
+
+	// a lost connection catches up from here when nothing was read yet
+	_ = h.lastID.SetTime(ulid.Timestamp(time.Now()))
+
+	go h.publish(ctx)
+	defer h.stop()
+
+	l.Info("live hub started")
+
+	for {
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			l.Info("live hub stopped")
+			return
+		}
+
+		l.Error("lost the outbox listener, listening again",
+			zap.Duration("delay", reconnectDelay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			l.Info("live hub stopped")
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// listen hands the notified events to publish until the listener fails.
+func (h *Hub) listen(ctx context.Context) error {
+	callerInfo := "[Hub.listen]"
+	l := zap.L().With(zap.String("caller", callerInfo))
+
+	listener, err := h.liveService.Listen(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = listener.Close(context.WithoutCancel(ctx))
+	}()
+
+	// for the events committed while nothing listened
+	select {
+	case h.catchUp <- struct{}{}:
+	default:
+	}
+
+	for {
+		payload, err := listener.Wait(ctx)
+		if err != nil {
+			return err
+		}
+
+		eventID, err := ulid.Parse(payload)
+		if err != nil {
+			l.Warn("ignoring notification", zap.String("payload", payload), zap.Error(err))
+			continue
+		}
+
+		select {
+		case h.pending <- eventID:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// publish reads the notified events in batches and pushes them.
+func (h *Hub) publish(ctx context.Context) {
+	ids := make([]ulid.ULID, 0, publishBatch)
+
+	for {
+		ids = ids[:0]
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.catchUp:
+		case eventID := <-h.pending:
+			ids = append(ids, eventID)
+		drain:
+			for len(ids) < publishBatch {
+				select {
+				case eventID = <-h.pending:
+					ids = append(ids, eventID)
+				default:
+					break drain
+				}
+			}
+		}
+
+		h.send(ctx, ids)
+	}
+}
+
+// send pushes the events in ids, or the ones after the last event read when
+// ids is empty. An event may be pushed twice around a lost connection.
+func (h *Hub) send(ctx context.Context, ids []ulid.ULID) {
+	if !h.hasSubscribers() {
+		for i := range ids {
+			h.advance(ids[i])
+		}
+		return
+	}
+
+	filter := domain.FilterLiveEventAcquire()
+	defer domain.FilterLiveEventRelease(filter)
+
+	filter.IDs = ids
+	if len(ids) == 0 {
+		filter.After = h.lastID
+		filter.Limit = catchUpLimit
+	}
+
+	events := domain.LiveEventsAcquire()
+	defer domain.LiveEventsRelease(events)
+
+	// the error is already logged by the service, the subscribers miss
+	// these events until they connect again
+	events, _ = h.liveService.GetEvents(ctx, filter, events)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range events {
+		h.advance(events[i].Event.ID)
+
+		for subscriber := range h.subscribers {
+			if !subscriber.matches(&events[i]) {
+				continue
+			}
+
+			select {
+			case subscriber.events <- events[i]:
+			default:
+				delete(h.subscribers, subscriber)
+				close(subscriber.events)
+			}
+		}
+	}
+}
+
+func (h *Hub) advance(eventID ulid.ULID) {
+	if eventID.Compare(h.lastID) > 0 {
+		h.lastID = eventID
+	}
+}
+
+func (h *Hub) hasSubscribers() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers) > 0
+}
+
+func (h *Hub) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stopped = true
+	for subscriber := range h.subscribers {
+		delete(h.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
@@ -0,0 +1,32 @@
+package live
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/live/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/live/hub"
+	"github.com/j03hanafi/halo-suster/internal/application/live/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/live/service"
+)
+
+// NewModule registers the live event stream and, in the process that serves
+// it, listens for the outbox events until ctx is done. With prefork every
+// child listens on a connection of its own.
+func NewModule(ctx context.Context, router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	liveRepository := repository.NewLiveRepository(db)
+	liveService := service.NewLiveService(ctxTimeout, liveRepository)
+	liveHub := hub.NewHub(liveService)
+	handler.NewLiveHandler(router, jwtMiddleware, liveService, liveHub)
+
+	// the parent of the prefork children serves no requests
+	if !configs.Get().App.PreFork || fiber.IsChild() {
+		go liveHub.Run(ctx)
+	}
+}
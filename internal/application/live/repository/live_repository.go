@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/outbox"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LiveRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLiveRepository(db *pgxpool.Pool) *LiveRepository {
+	return &LiveRepository{db: db}
+}
+
+// Listen opens a connection of its own outside the pool, which it holds for
+// as long as it listens, and listens on the outbox channel.
+func (r LiveRepository) Listen(ctx context.Context) (Listener, error) {
+	callerInfo := "[LiveRepository.Listen]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conn, err := pgx.ConnectConfig(ctx, r.db.Config().ConnConfig.Copy())
+	if err != nil {
+		l.Error("failed to connect", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{outbox.Channel}.Sanitize()); err != nil {
+		l.Error("failed to listen", zap.Error(err))
+		_ = conn.Close(ctx)
+		return nil, err
+	}
+
+	return listener{conn: conn}, nil
+}
+
+type listener struct {
+	conn *pgx.Conn
+}
+
+func (l listener) Wait(ctx context.Context) (string, error) {
+	notification, err := l.conn.WaitForNotification(ctx)
+	if err != nil {
+		return "", err
+	}
+	return notification.Payload, nil
+}
+
+func (l listener) Close(ctx context.Context) error {
+	return l.conn.Close(ctx)
+}
+
+// GetEvents returns the events matching filter along with the ward the
+// patient is in now.
+func (r LiveRepository) GetEvents(
+	ctx context.Context,
+	filter *domain.FilterLiveEvent,
+	events domain.LiveEvents,
+) (domain.LiveEvents, error) {
+	callerInfo := "[LiveRepository.GetEvents]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterEvent(filter)
+	getQuery := `SELECT e.id, e.type, e.patient_id, e.data, e.created_at, b.ward_id FROM outbox_events e
+		LEFT JOIN bed_assignments a ON a.patient_id = e.patient_id AND a.ended_at IS NULL
+		LEFT JOIN beds b ON b.id = a.bed_id` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get events", zap.Error(err))
+		return events, err
+	}
+
+	dEvent := domain.LiveEventAcquire()
+	defer domain.LiveEventRelease(dEvent)
+	var wardID *ulid.ULID
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dEvent.Event.ID,
+			&dEvent.Event.Type,
+			&dEvent.Event.PatientID,
+			&dEvent.Event.Data,
+			&dEvent.Event.CreatedAt,
+			&wardID,
+		},
+		func() error {
+			dEvent.WardID = ulid.ULID{}
+			if wardID != nil {
+				dEvent.WardID = *wardID
+			}
+			events = append(events, *dEvent)
+			dEvent.Event.Data = nil
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get events", zap.Error(err))
+		return events, err
+	}
+
+	return events, nil
+}
+
+func (r LiveRepository) filterEvent(filter *domain.FilterLiveEvent) (string, pgx.NamedArgs) {
+	const totalConditions = 4
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	// only the events about a patient are pushed
+	conditions = append(conditions, "e.patient_id IS NOT NULL")
+
+	if len(filter.IDs) > 0 {
+		ids := make([][]byte, 0, len(filter.IDs))
+		for i := range filter.IDs {
+			ids = append(ids, filter.IDs[i].Bytes())
+		}
+		conditions = append(conditions, "e.id = ANY(@ids)")
+		params["ids"] = ids
+	}
+
+	if !id.IsZero(filter.After) {
+		conditions = append(conditions, "e.id > @after")
+		params["after"] = filter.After
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "e.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.WardID) {
+		conditions = append(conditions, "b.ward_id = @ward_id")
+		params["ward_id"] = filter.WardID
+	}
+
+	queryConditions := " WHERE " + strings.Join(conditions, " AND ") + " ORDER BY e.id ASC"
+
+	if filter.Limit != 0 {
+		queryConditions += " LIMIT @limit"
+		params["limit"] = filter.Limit
+	}
+
+	return queryConditions, params
+}
+
+var _ LiveRepositoryContract = (*LiveRepository)(nil)
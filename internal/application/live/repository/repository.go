@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LiveRepositoryContract interface {
+	Listen(ctx context.Context) (Listener, error)
+	GetEvents(ctx context.Context, filter *domain.FilterLiveEvent, events domain.LiveEvents) (domain.LiveEvents, error)
+}
+
+// Listener receives the ids of the outbox events about a patient as they are
+// committed.
+type Listener interface {
+	// Wait blocks until an event is committed and returns its id.
+	Wait(ctx context.Context) (string, error)
+	Close(ctx context.Context) error
+}
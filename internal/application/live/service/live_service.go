@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/live/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LiveService struct {
+	liveRepository repository.LiveRepositoryContract
+	contextTimeout time.Duration
+}
+
+func NewLiveService(timeout time.Duration, liveRepository repository.LiveRepositoryContract) *LiveService {
+	return &LiveService{
+		liveRepository: liveRepository,
+		contextTimeout: timeout,
+	}
+}
+
+// Listen starts listening for the outbox events, the listener is used until
+// ctx is done.
+func (s LiveService) Listen(ctx context.Context) (repository.Listener, error) {
+	callerInfo := "[LiveService.Listen]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	connectCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	listener, err := s.liveRepository.Listen(connectCtx)
+	if err != nil {
+		l.Error("failed to listen", zap.Error(err))
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+func (s LiveService) GetEvents(
+	ctx context.Context,
+	filter *domain.FilterLiveEvent,
+	events domain.LiveEvents,
+) (domain.LiveEvents, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[LiveService.GetEvents]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	events, err := s.liveRepository.GetEvents(ctx, filter, events)
+	if err != nil {
+		l.Error("failed to get events", zap.Error(err))
+		return events, err
+	}
+
+	return events, nil
+}
+
+var _ LiveServiceContract = (*LiveService)(nil)
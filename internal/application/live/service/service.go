@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/application/live/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type LiveServiceContract interface {
+	Listen(ctx context.Context) (repository.Listener, error)
+	GetEvents(ctx context.Context, filter *domain.FilterLiveEvent, events domain.LiveEvents) (domain.LiveEvents, error)
+}
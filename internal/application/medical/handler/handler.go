@@ -1,17 +1,37 @@
 package handler
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
 	"go.uber.org/zap"
 
+	"github.com/j03hanafi/halo-suster/common/id"
 	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/jobqueue"
+	"github.com/j03hanafi/halo-suster/internal/application/medical/patientimport"
 	"github.com/j03hanafi/halo-suster/internal/application/medical/service"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
+const (
+	recordIDFromParam  = "id"
+	patientIDFromParam = "identityNumber"
+	importFileFromForm = "file"
+
+	// exportDateFormat dates the file name of an export
+	exportDateFormat = "20060102"
+)
+
 type medicalHandler struct {
 	medicalService service.MedicalServiceContract
 }
@@ -27,9 +47,19 @@ func NewMedicalHandler(
 
 	medicalRouter := router.Group("/medical", jwtMiddleware)
 	medicalRouter.Post("/patient", handler.RecordPatient)
+	medicalRouter.Post("/patient/import", access.ITStaff, handler.ImportPatients)
+	medicalRouter.Post("/patient/import/job", access.ITStaff, handler.QueuePatientImport)
 	medicalRouter.Get("/patient", handler.GetPatients)
+	medicalRouter.Get("/patient/export", handler.ExportPatients)
+	medicalRouter.Post("/patient/export", handler.QueuePatientExport)
+	medicalRouter.Put("/patient/:"+patientIDFromParam, handler.UpdatePatient)
+	medicalRouter.Get("/patient/:"+patientIDFromParam+"/timeline", handler.GetPatientTimeline)
 	medicalRouter.Post("/record", handler.SaveMedicalRecord)
 	medicalRouter.Get("/record", handler.GetMedicalRecords)
+	medicalRouter.Get("/record/export", handler.ExportMedicalRecords)
+	medicalRouter.Post("/record/export", handler.QueueRecordExport)
+	medicalRouter.Post("/record/:"+recordIDFromParam+"/amendment", handler.AmendMedicalRecord)
+	medicalRouter.Post("/record/:"+recordIDFromParam+"/attachment", handler.AttachToMedicalRecord)
 }
 
 func (h medicalHandler) RecordPatient(c *fiber.Ctx) error {
@@ -61,7 +91,11 @@ func (h medicalHandler) RecordPatient(c *fiber.Ctx) error {
 	patient.Gender = req.Gender
 	patient.ImgURL = req.ImgURL
 
-	err := h.medicalService.RecordPatient(userCtx, patient)
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	err := h.medicalService.RecordPatient(userCtx, patient, user)
 	if err != nil {
 		l.Error("failed to record patient", zap.Error(err))
 		return err
@@ -75,6 +109,244 @@ func (h medicalHandler) RecordPatient(c *fiber.Ctx) error {
 	return c.Status(http.StatusCreated).JSON(res)
 }
 
+// ImportPatients registers the patients of an uploaded CSV or XLSX file and
+// reports the rows that were not imported.
+func (h medicalHandler) ImportPatients(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.ImportPatients]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	fileHeader, err := c.FormFile(importFileFromForm)
+	if err != nil {
+		l.Error("error getting import file", zap.Error(err))
+		return errBadRequest{err: errors.New("file is required")}
+	}
+
+	format := patientimport.Format(fileHeader.Filename, fileHeader.Header.Get(fiber.HeaderContentType))
+	if format == "" {
+		return errBadRequest{err: patientimport.ErrUnsupportedFormat}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		l.Error("error opening import file", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	rows, err := patientimport.Read(file, format)
+	if err != nil {
+		l.Error("error reading import file", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	report := domain.PatientImportAcquire()
+	defer domain.PatientImportRelease(report)
+
+	err = h.medicalService.ImportPatients(userCtx, rows, user, report, nil)
+	if err != nil {
+		l.Error("failed to import patients", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Patients imported successfully"
+	res.Data = newImportPatientsRes(report)
+
+	return c.JSON(res)
+}
+
+// QueuePatientImport queues the import of an uploaded CSV or XLSX file to run
+// in the background, for files too large to import within a request. The
+// file is read once here so a file that cannot be imported is turned down
+// straight away, its rows are checked when the job runs.
+func (h medicalHandler) QueuePatientImport(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.QueuePatientImport]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	fileHeader, err := c.FormFile(importFileFromForm)
+	if err != nil {
+		l.Error("error getting import file", zap.Error(err))
+		return errBadRequest{err: errors.New("file is required")}
+	}
+
+	format := patientimport.Format(fileHeader.Filename, fileHeader.Header.Get(fiber.HeaderContentType))
+	if format == "" {
+		return errBadRequest{err: patientimport.ErrUnsupportedFormat}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		l.Error("error opening import file", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	input, err := io.ReadAll(file)
+	if err != nil {
+		l.Error("error reading import file", zap.Error(err))
+		return err
+	}
+
+	if _, err = patientimport.Read(bytes.NewReader(input), format); err != nil {
+		l.Error("error reading import file", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	params := domain.PatientImportParams{
+		Format:   format,
+		FileName: fileHeader.Filename,
+	}
+
+	return h.queueJob(c, domain.JobPatientImport, params, input)
+}
+
+func (h medicalHandler) UpdatePatient(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.UpdatePatient]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := recordPatientReqAcquire()
+	defer recordPatientReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	identityNumber, err := idNumberFromParam(c.Params(patientIDFromParam))
+	if err != nil {
+		l.Error("error validating identityNumber", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+	req.IdentityNumber = &identityNumber
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	patient := domain.PatientAcquire()
+	defer domain.PatientRelease(patient)
+
+	patient.ID = string(*req.IdentityNumber)
+	patient.PhoneNumber = req.PhoneNumber
+	patient.Name = req.Name
+	patient.BirthDate = req.birthDate
+	patient.Gender = req.Gender
+	patient.ImgURL = req.ImgURL
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	err = h.medicalService.UpdatePatient(userCtx, patient, user)
+	if err != nil {
+		l.Error("failed to update patient", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Patient updated successfully"
+
+	return c.JSON(res)
+}
+
+func (h medicalHandler) GetPatientTimeline(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.GetPatientTimeline]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	identityNumber, err := idNumberFromParam(c.Params(patientIDFromParam))
+	if err != nil {
+		l.Error("error validating identityNumber", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query := queryTimelineAcquire()
+	defer queryTimelineRelease(query)
+
+	if err = c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = query.validate(); err != nil {
+		l.Error("error validating query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	filter := domain.FilterTimelineAcquire()
+	defer domain.FilterTimelineRelease(filter)
+
+	filter.PatientID = string(identityNumber)
+	filter.Limit = query.Limit
+	filter.CursorAt = query.cursor.createdAt
+	filter.CursorID = query.cursor.id
+
+	entries := domain.TimelineEntriesAcquire()
+	defer domain.TimelineEntriesRelease(entries)
+
+	entries, err = h.medicalService.GetPatientTimeline(userCtx, filter, entries)
+	if err != nil {
+		l.Error("failed to get patient timeline", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Patient timeline retrieved successfully"
+
+	timelineRes := getTimelineRes{
+		Items: make([]timelineEntryRes, 0, len(entries)),
+	}
+
+	for _, entry := range entries {
+		entryRes := timelineEntryRes{
+			ID:        entry.ID,
+			Type:      entry.Type,
+			Data:      entry.Data,
+			StaffName: entry.StaffName,
+			CreatedAt: entry.CreatedAt.Format(dateFormat),
+		}
+		if !id.IsZero(entry.RecordID) {
+			entryRes.RecordID = entry.RecordID.String()
+		}
+		if !id.IsZero(entry.StaffID) {
+			entryRes.StaffID = entry.StaffID.String()
+		}
+
+		timelineRes.Items = append(timelineRes.Items, entryRes)
+	}
+
+	if len(entries) == query.Limit {
+		last := entries[len(entries)-1]
+		timelineRes.NextCursor = timelineCursor{createdAt: last.CreatedAt, id: last.ID}.encode()
+	}
+
+	res.Data = timelineRes
+
+	return c.JSON(res)
+}
+
 func (h medicalHandler) GetPatients(c *fiber.Ctx) error {
 	callerInfo := "[medicalHandler.GetPatients]"
 
@@ -94,12 +366,11 @@ func (h medicalHandler) GetPatients(c *fiber.Ctx) error {
 	filter := domain.FilterPatientAcquire()
 	defer domain.FilterPatientRelease(filter)
 
-	filter.ID = string(query.IdentityNumber)
-	filter.Limit = query.Limit
-	filter.Offset = query.Offset
-	filter.Name = query.Name
-	filter.PhoneNumber = query.PhoneNumber
-	filter.CreatedAt = query.CreatedAt
+	query.toFilter(filter)
+
+	var demographic queryDemographic
+	demographic.parse(c)
+	demographic.toFilter(&filter.FilterDemographic)
 
 	patients := domain.PatientsAcquire()
 	defer domain.PatientsRelease(patients)
@@ -134,6 +405,80 @@ func (h medicalHandler) GetPatients(c *fiber.Ctx) error {
 	return c.JSON(res)
 }
 
+// ExportPatients streams the patients matching the same filters as
+// GetPatients as a CSV or XLSX sheet. Every match is exported unless a limit
+// is given.
+func (h medicalHandler) ExportPatients(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.ExportPatients]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	params := new(domain.PatientExportParams)
+	if err := parsePatientExport(c, params); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, sheet.ContentType(params.Format))
+	c.Attachment("patients-" + time.Now().Format(exportDateFormat) + "." + params.Format)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := h.medicalService.ExportPatients(userCtx, &params.Filter, params.Format, w, nil)
+		if err != nil {
+			// the status has been sent, the client sees a cut off sheet
+			l.Error("failed to export patients", zap.Error(err))
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// QueuePatientExport queues the same export as ExportPatients to run in the
+// background, for exports too large to finish within a request.
+func (h medicalHandler) QueuePatientExport(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.QueuePatientExport]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	var params domain.PatientExportParams
+	if err := parsePatientExport(c, &params); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return err
+	}
+
+	return h.queueJob(c, domain.JobPatientExport, params, nil)
+}
+
+func parsePatientExport(c *fiber.Ctx, params *domain.PatientExportParams) error {
+	query := queryPatientAcquire()
+	defer queryPatientRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	format, err := exportFormat(c)
+	if err != nil {
+		return errBadRequest{err: err}
+	}
+
+	params.Format = format
+	query.toFilter(&params.Filter)
+	if params.Filter.Limit == 0 {
+		params.Filter.Limit = domain.LimitAll
+	}
+
+	var demographic queryDemographic
+	demographic.parse(c)
+	demographic.toFilter(&params.Filter.FilterDemographic)
+
+	return nil
+}
+
 func (h medicalHandler) SaveMedicalRecord(c *fiber.Ctx) error {
 	callerInfo := "[medicalHandler.SaveMedicalRecord]"
 
@@ -166,6 +511,12 @@ func (h medicalHandler) SaveMedicalRecord(c *fiber.Ctx) error {
 	record.StaffID = user.ID
 	record.StaffNIP = user.NIP
 	record.StaffName = user.Name
+	record.EncounterID = req.encounterID
+
+	record.Attachments = make(domain.MedicalRecordAttachments, 0, len(req.Attachments))
+	for _, attachment := range req.Attachments {
+		record.Attachments = append(record.Attachments, attachment.toDomain(record.ID))
+	}
 
 	err := h.medicalService.SaveMedicalRecord(userCtx, record, user)
 	if err != nil {
@@ -190,23 +541,17 @@ func (h medicalHandler) GetMedicalRecords(c *fiber.Ctx) error {
 	query := queryRecordAcquire()
 	defer queryRecordRelease(query)
 
-	query.PatientID = c.QueryInt("identityDetail.identityNumber", 0)
-	query.StaffID = c.Query("createdBy.userId", "")
-	query.StaffNIP = c.Query("createdBy.nip", "")
-	query.Limit = c.QueryInt("limit", 0)
-	query.Offset = c.QueryInt("offset", 0)
-	query.CreatedAt = c.Query("createdAt", "")
+	query.parse(c)
 	query.validate()
 
 	filter := domain.FilterMedicalRecordAcquire()
 	defer domain.FilterMedicalRecordRelease(filter)
 
-	filter.PatientID = query.patientID
-	filter.StaffID = query.staffID
-	filter.StaffNIP = query.StaffNIP
-	filter.Limit = query.Limit
-	filter.Offset = query.Offset
-	filter.CreatedAt = query.CreatedAt
+	query.toFilter(filter)
+
+	var demographic queryDemographic
+	demographic.parse(c)
+	demographic.toFilter(&filter.FilterDemographic)
 
 	records := domain.MedicalRecordsAcquire()
 	defer domain.MedicalRecordsRelease(records)
@@ -226,10 +571,13 @@ func (h medicalHandler) GetMedicalRecords(c *fiber.Ctx) error {
 	defer getRecordsResRelease(recordsRes)
 	var nip int
 
-	for _, record := range records {
+	for i := range records {
+		record := &records[i]
 		nip, _ = strconv.Atoi(record.StaffNIP)
+		symptoms, medications := record.Current()
 
-		recordsRes = append(recordsRes, getRecordRes{
+		recordRes := getRecordRes{
+			RecordID: record.ID,
 			IdentityDetail: identityDetail{
 				IdentityNumber:      idNumber(record.PatientID),
 				PhoneNumber:         "+" + record.PatientPhoneNumber,
@@ -238,18 +586,292 @@ func (h medicalHandler) GetMedicalRecords(c *fiber.Ctx) error {
 				Gender:              record.PatientGender,
 				IdentityCardScanImg: record.PatientImgURL,
 			},
-			Symptoms:    record.Symptoms,
-			Medications: record.Medications,
+			Symptoms:    symptoms,
+			Medications: medications,
+			Amended:     len(record.Amendments) > 0,
 			CreatedAt:   record.CreatedAt.Format(dateFormat),
 			CreatedBy: createdBy{
 				Nip:    uint(nip),
 				Name:   record.StaffName,
 				UserId: record.StaffID,
 			},
-		})
+		}
+
+		recordRes.Attachments = make([]attachmentRes, 0, len(record.Attachments))
+		for _, attachment := range record.Attachments {
+			recordRes.Attachments = append(recordRes.Attachments, attachmentRes{
+				AttachmentID: attachment.ID,
+				URL:          attachment.URL,
+				Name:         attachment.Name,
+				ContentType:  attachment.ContentType,
+				Description:  attachment.Description,
+				CreatedAt:    attachment.CreatedAt.Format(dateFormat),
+			})
+		}
+
+		if !id.IsZero(record.EncounterID) {
+			recordRes.EncounterID = record.EncounterID.String()
+		}
+
+		if query.Query != "" {
+			recordRes.Search = &searchRes{
+				Rank:        record.SearchRank,
+				Symptoms:    record.SymptomsHeadline,
+				Medications: record.MedicationsHeadline,
+			}
+		}
+
+		if query.IncludeHistory {
+			recordRes.History = recordHistory(record)
+		}
+
+		recordsRes = append(recordsRes, recordRes)
 	}
 
 	res.Data = recordsRes
+	if query.GroupBy == groupByEncounter {
+		res.Data = groupRecordsByEncounter(recordsRes)
+	}
 
 	return c.JSON(res)
 }
+
+// ExportMedicalRecords streams the medical records matching the same filters
+// as GetMedicalRecords as a CSV or XLSX sheet, with their symptoms and
+// medications as amended. Every match is exported unless a limit is given.
+func (h medicalHandler) ExportMedicalRecords(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.ExportMedicalRecords]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	params := new(domain.RecordExportParams)
+	if err := parseRecordExport(c, params); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, sheet.ContentType(params.Format))
+	c.Attachment("medical-records-" + time.Now().Format(exportDateFormat) + "." + params.Format)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := h.medicalService.ExportMedicalRecords(userCtx, &params.Filter, params.Format, w, nil)
+		if err != nil {
+			// the status has been sent, the client sees a cut off sheet
+			l.Error("failed to export medical records", zap.Error(err))
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// QueueRecordExport queues the same export as ExportMedicalRecords to run in
+// the background, for exports too large to finish within a request.
+func (h medicalHandler) QueueRecordExport(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.QueueRecordExport]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	var params domain.RecordExportParams
+	if err := parseRecordExport(c, &params); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return err
+	}
+
+	return h.queueJob(c, domain.JobRecordExport, params, nil)
+}
+
+func parseRecordExport(c *fiber.Ctx, params *domain.RecordExportParams) error {
+	query := queryRecordAcquire()
+	defer queryRecordRelease(query)
+
+	query.parse(c)
+	query.validate()
+
+	format, err := exportFormat(c)
+	if err != nil {
+		return errBadRequest{err: err}
+	}
+
+	params.Format = format
+	query.toFilter(&params.Filter)
+	if params.Filter.Limit == 0 {
+		params.Filter.Limit = domain.LimitAll
+	}
+
+	var demographic queryDemographic
+	demographic.parse(c)
+	demographic.toFilter(&params.Filter.FilterDemographic)
+
+	return nil
+}
+
+// queueJob queues a job of kind for the user of the request and responds
+// with where to follow it.
+func (h medicalHandler) queueJob(c *fiber.Ctx, kind string, params any, input []byte) error {
+	callerInfo := "[medicalHandler.queueJob]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo), zap.String("kind", kind))
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	job, err := jobqueue.New(kind, user, params)
+	if err != nil {
+		l.Error("failed to encode job params", zap.Error(err))
+		return err
+	}
+	job.Input = input
+
+	if err = h.medicalService.QueueJob(userCtx, job); err != nil {
+		l.Error("failed to queue job", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Job queued successfully"
+	res.Data = newQueuedJobRes(job)
+
+	return c.Status(http.StatusAccepted).JSON(res)
+}
+
+func (h medicalHandler) AmendMedicalRecord(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.AmendMedicalRecord]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	recordID, err := ulid.Parse(c.Params(recordIDFromParam))
+	if err != nil {
+		l.Error("error parsing recordIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := amendRecordReqAcquire()
+	defer amendRecordReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	amendment := domain.MedicalRecordAmendmentAcquire()
+	defer domain.MedicalRecordAmendmentRelease(amendment)
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	amendment.RecordID = recordID
+	amendment.Type = req.Type
+	amendment.Symptoms = req.Symptoms
+	amendment.Medications = req.Medications
+	amendment.Reason = req.Reason
+
+	err = h.medicalService.AmendMedicalRecord(userCtx, amendment, user)
+	if err != nil {
+		l.Error("failed to amend medical record", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Medical record amended successfully"
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h medicalHandler) AttachToMedicalRecord(c *fiber.Ctx) error {
+	callerInfo := "[medicalHandler.AttachToMedicalRecord]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	recordID, err := ulid.Parse(c.Params(recordIDFromParam))
+	if err != nil {
+		l.Error("error parsing recordIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := attachRecordReqAcquire()
+	defer attachRecordReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	attachments := make(domain.MedicalRecordAttachments, 0, len(req.Attachments))
+	for _, attachment := range req.Attachments {
+		attachments = append(attachments, attachment.toDomain(recordID))
+	}
+
+	err = h.medicalService.AttachToMedicalRecord(userCtx, attachments, user)
+	if err != nil {
+		l.Error("failed to attach to medical record", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Attachments saved successfully"
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func recordHistory(record *domain.MedicalRecord) []recordRevision {
+	history := make([]recordRevision, 0, len(record.Amendments)+1)
+	nip, _ := strconv.Atoi(record.StaffNIP)
+
+	history = append(history, recordRevision{
+		Type:        revisionOriginal,
+		Symptoms:    record.Symptoms,
+		Medications: record.Medications,
+		CreatedAt:   record.CreatedAt.Format(dateFormat),
+		CreatedBy: createdBy{
+			Nip:    uint(nip),
+			Name:   record.StaffName,
+			UserId: record.StaffID,
+		},
+	})
+
+	for _, amendment := range record.Amendments {
+		nip, _ = strconv.Atoi(amendment.StaffNIP)
+
+		history = append(history, recordRevision{
+			Type:        amendment.Type,
+			Symptoms:    amendment.Symptoms,
+			Medications: amendment.Medications,
+			Reason:      amendment.Reason,
+			CreatedAt:   amendment.CreatedAt.Format(dateFormat),
+			CreatedBy: createdBy{
+				Nip:    uint(nip),
+				Name:   amendment.StaffName,
+				UserId: amendment.StaffID,
+			},
+		})
+	}
+
+	return history
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -11,13 +13,23 @@ import (
 	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
 	"github.com/oklog/ulid/v2"
 	"go.uber.org/multierr"
 
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/common/timeparam"
+	imagerepository "github.com/j03hanafi/halo-suster/internal/application/image/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/patientrule"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
-const dateFormat = "2006-01-02T15:04:05.999Z"
+const (
+	dateFormat       = "2006-01-02T15:04:05.999Z"
+	revisionOriginal = "original"
+	groupByEncounter = "encounter"
+)
 
 type errBadRequest struct {
 	err error
@@ -74,16 +86,17 @@ func (n *idNumber) MarshalJSON() ([]byte, error) {
 	return json.Marshal(jsonID)
 }
 
-func (n *idNumber) validate() error {
-	var errs error
-	const idNumberLength = 16
-
-	if len(*n) != idNumberLength {
-		errs = multierr.Append(errs, errors.New("identityNumber must have 16 characters"))
-		return errs
+func idNumberFromParam(param string) (idNumber, error) {
+	if _, err := strconv.ParseUint(param, 10, 64); err != nil {
+		return "", errors.New("identityNumber must be a number")
 	}
 
-	return nil
+	n := idNumber(param)
+	return n, n.validate()
+}
+
+func (n *idNumber) validate() error {
+	return patientrule.IdentityNumberLength(string(*n))
 }
 
 var recordPatientReqPool = sync.Pool{
@@ -120,51 +133,22 @@ func (r *recordPatientReq) validate() error {
 		errs = multierr.Append(errs, r.IdentityNumber.validate())
 	}
 
-	if r.PhoneNumber == "" {
-		errs = multierr.Append(errs, errors.New("phoneNumber is required"))
-	} else if len(r.PhoneNumber) < 10 || len(r.PhoneNumber) > 15 {
-		errs = multierr.Append(errs, errors.New("phoneNumber must have 10 to 15 characters"))
-	} else if !strings.HasPrefix(r.PhoneNumber, "+62") {
-		errs = multierr.Append(errs, errors.New("phoneNumber must start with +62"))
+	if phoneNumber, err := patientrule.PhoneNumber(r.PhoneNumber); err != nil {
+		errs = multierr.Append(errs, err)
 	} else {
-		r.PhoneNumber = strings.TrimPrefix(r.PhoneNumber, "+")
+		r.PhoneNumber = phoneNumber
 	}
 
-	if r.Name == "" {
-		errs = multierr.Append(errs, errors.New("name is required"))
-	} else if len(r.Name) < 3 || len(r.Name) > 30 {
-		errs = multierr.Append(errs, errors.New("name must have 3 to 30 characters"))
-	}
+	errs = multierr.Append(errs, patientrule.Name(r.Name))
 
-	if r.BirthDate == "" {
-		errs = multierr.Append(errs, errors.New("birthDate is required"))
+	if birthDate, err := patientrule.BirthDate(r.BirthDate); err != nil {
+		errs = multierr.Append(errs, err)
 	} else {
-		if birthDate, err := time.Parse(dateFormat, r.BirthDate); err != nil {
-			errs = multierr.Append(errs, errors.New("birthDate must be in format yyyy-mm-dd"))
-		} else {
-			r.birthDate = birthDate
-		}
-	}
-
-	if r.Gender == "" {
-		errs = multierr.Append(errs, errors.New("gender is required"))
-	} else if r.Gender != domain.GenderMale && r.Gender != domain.GenderFemale {
-		errs = multierr.Append(errs, errors.New("gender must be either male or female"))
+		r.birthDate = birthDate
 	}
 
-	if r.ImgURL == "" {
-		errs = multierr.Append(errs, errors.New("identity card scan image URL is required"))
-	} else if !govalidator.IsURL(r.ImgURL) {
-		errs = multierr.Append(errs, errors.New("identity card scan image URL must be a valid URL"))
-	} else {
-		u, err := url.Parse(r.ImgURL)
-		if err != nil {
-			errs = multierr.Append(errs, errors.New("identity card scan image URL must be a valid URL"))
-		}
-		if u != nil && !strings.Contains(u.Host, ".") {
-			errs = multierr.Append(errs, errors.New("identity card scan image URL must be a valid URL"))
-		}
-	}
+	errs = multierr.Append(errs, patientrule.Gender(r.Gender))
+	errs = multierr.Append(errs, patientrule.IdentityCardScanImg(r.ImgURL))
 
 	if errs != nil {
 		return errs
@@ -198,11 +182,88 @@ type queryPatient struct {
 }
 
 func (r *queryPatient) validate() {
+	if r.Limit < 0 {
+		r.Limit = 0
+	}
+
 	if r.CreatedAt != "" && r.CreatedAt != "asc" && r.CreatedAt != "desc" {
 		r.CreatedAt = ""
 	}
 }
 
+func (r *queryPatient) toFilter(filter *domain.FilterPatient) {
+	filter.ID = string(r.IdentityNumber)
+	filter.Limit = r.Limit
+	filter.Offset = r.Offset
+	filter.Name = r.Name
+	filter.PhoneNumber = r.PhoneNumber
+	filter.CreatedAt = r.CreatedAt
+}
+
+// exportFormat is the sheet format asked for in the format query param, CSV
+// when there is none.
+func exportFormat(c *fiber.Ctx) (string, error) {
+	format := strings.ToLower(c.Query("format", sheet.FormatCSV))
+	if format != sheet.FormatCSV && format != sheet.FormatXLSX {
+		return "", sheet.ErrUnsupportedFormat
+	}
+
+	return format, nil
+}
+
+// queuedJobRes is a job just queued, StatusURL is where it is followed.
+type queuedJobRes struct {
+	JobID     ulid.ULID `json:"jobId"`
+	Kind      string    `json:"kind"`
+	Status    string    `json:"status"`
+	StatusURL string    `json:"statusUrl"`
+	CreatedAt string    `json:"createdAt"`
+}
+
+func newQueuedJobRes(job *domain.Job) queuedJobRes {
+	return queuedJobRes{
+		JobID:     job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		StatusURL: configs.Get().API.BaseURL + "/job/" + job.ID.String(),
+		CreatedAt: job.CreatedAt.Format(dateFormat),
+	}
+}
+
+type importPatientsRes struct {
+	Total    int               `json:"total"`
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Errors   []importRowErrRes `json:"errors"`
+}
+
+// importRowErrRes keeps the identity number as written in the file, it may
+// not be a number.
+type importRowErrRes struct {
+	Row            int      `json:"row"`
+	IdentityNumber string   `json:"identityNumber"`
+	Errors         []string `json:"errors"`
+}
+
+func newImportPatientsRes(report *domain.PatientImport) importPatientsRes {
+	res := importPatientsRes{
+		Total:    report.Total,
+		Imported: report.Imported,
+		Failed:   report.Failed,
+		Errors:   make([]importRowErrRes, 0, len(report.Errors)),
+	}
+
+	for _, rowErr := range report.Errors {
+		res.Errors = append(res.Errors, importRowErrRes{
+			Row:            rowErr.Row,
+			IdentityNumber: rowErr.IdentityNumber,
+			Errors:         rowErr.Errors,
+		})
+	}
+
+	return res
+}
+
 type getPatientRes struct {
 	IdentityNumber idNumber `json:"identityNumber"`
 	PhoneNumber    string   `json:"phoneNumber"`
@@ -247,12 +308,15 @@ func medicalRecordRelease(t *medicalRecord) {
 }
 
 type medicalRecord struct {
-	IdentityNumber *idNumber `json:"identityNumber"`
-	Symptoms       string    `json:"symptoms"`
-	Medications    string    `json:"medications"`
+	IdentityNumber *idNumber       `json:"identityNumber"`
+	Symptoms       string          `json:"symptoms"`
+	Medications    string          `json:"medications"`
+	Attachments    []attachmentReq `json:"attachments"`
+	EncounterID    string          `json:"encounterId"`
+	encounterID    ulid.ULID
 }
 
-func (r medicalRecord) validate() error {
+func (r *medicalRecord) validate() error {
 	var errs error
 
 	if r.IdentityNumber == nil {
@@ -273,6 +337,16 @@ func (r medicalRecord) validate() error {
 		errs = multierr.Append(errs, errors.New("medications must have 1 to 2000 characters"))
 	}
 
+	errs = multierr.Append(errs, validateAttachments(r.Attachments))
+
+	if r.EncounterID != "" {
+		encounterID, err := ulid.Parse(r.EncounterID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("encounterId must be a valid encounter id"))
+		}
+		r.encounterID = encounterID
+	}
+
 	if errs != nil {
 		return errs
 	}
@@ -280,6 +354,111 @@ func (r medicalRecord) validate() error {
 	return nil
 }
 
+const maxAttachments = 10
+
+type attachmentReq struct {
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Description string `json:"description"`
+}
+
+func (r attachmentReq) validate() error {
+	var errs error
+
+	if r.URL == "" {
+		errs = multierr.Append(errs, errors.New("attachment url is required"))
+	} else if !govalidator.IsURL(r.URL) {
+		errs = multierr.Append(errs, errors.New("attachment url must be a valid URL"))
+	} else {
+		u, err := url.Parse(r.URL)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("attachment url must be a valid URL"))
+		} else if !imagerepository.IsUploadURL(u) {
+			errs = multierr.Append(errs, errors.New("attachment url must be a file uploaded to /image"))
+		}
+	}
+
+	if len(r.Name) > 255 {
+		errs = multierr.Append(errs, errors.New("attachment name must have at most 255 characters"))
+	}
+
+	if len(r.ContentType) > 100 {
+		errs = multierr.Append(errs, errors.New("attachment contentType must have at most 100 characters"))
+	}
+
+	if len(r.Description) > 500 {
+		errs = multierr.Append(errs, errors.New("attachment description must have at most 500 characters"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+func validateAttachments(attachments []attachmentReq) error {
+	var errs error
+
+	if len(attachments) > maxAttachments {
+		errs = multierr.Append(errs, fmt.Errorf("attachments must have at most %d items", maxAttachments))
+		return errs
+	}
+
+	for _, attachment := range attachments {
+		errs = multierr.Append(errs, attachment.validate())
+	}
+
+	return errs
+}
+
+var attachRecordReqPool = sync.Pool{
+	New: func() any {
+		return new(attachRecordReq)
+	},
+}
+
+func attachRecordReqAcquire() *attachRecordReq {
+	return attachRecordReqPool.Get().(*attachRecordReq)
+}
+
+func attachRecordReqRelease(t *attachRecordReq) {
+	*t = attachRecordReq{}
+	attachRecordReqPool.Put(t)
+}
+
+type attachRecordReq struct {
+	Attachments []attachmentReq `json:"attachments"`
+}
+
+func (r attachRecordReq) validate() error {
+	if len(r.Attachments) == 0 {
+		return errors.New("attachments is required")
+	}
+
+	return validateAttachments(r.Attachments)
+}
+
+func (r attachmentReq) toDomain(recordID ulid.ULID) domain.MedicalRecordAttachment {
+	return domain.MedicalRecordAttachment{
+		RecordID:    recordID,
+		URL:         r.URL,
+		Name:        r.Name,
+		ContentType: r.ContentType,
+		Description: r.Description,
+	}
+}
+
+type attachmentRes struct {
+	AttachmentID ulid.ULID `json:"attachmentId"`
+	URL          string    `json:"url"`
+	Name         string    `json:"name"`
+	ContentType  string    `json:"contentType"`
+	Description  string    `json:"description"`
+	CreatedAt    string    `json:"createdAt"`
+}
+
 var queryRecordPool = sync.Pool{
 	New: func() any {
 		return new(queryRecord)
@@ -296,14 +475,32 @@ func queryRecordRelease(t *queryRecord) {
 }
 
 type queryRecord struct {
-	PatientID int `query:"identityDetail.identityNumber"`
-	patientID string
-	StaffID   string `query:"createdBy.userId"`
-	staffID   ulid.ULID
-	StaffNIP  string `query:"createdBy.nip"`
-	Limit     int    `query:"limit"`
-	Offset    int    `query:"offset"`
-	CreatedAt string `query:"createdAt"`
+	PatientID      int `query:"identityDetail.identityNumber"`
+	patientID      string
+	StaffID        string `query:"createdBy.userId"`
+	staffID        ulid.ULID
+	StaffNIP       string `query:"createdBy.nip"`
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+	CreatedAt      string `query:"createdAt"`
+	IncludeHistory bool   `query:"includeHistory"`
+	Query          string `query:"q"`
+	EncounterID    string `query:"encounterId"`
+	encounterID    ulid.ULID
+	GroupBy        string `query:"groupBy"`
+}
+
+func (r *queryRecord) parse(c *fiber.Ctx) {
+	r.PatientID = c.QueryInt("identityDetail.identityNumber", 0)
+	r.StaffID = c.Query("createdBy.userId", "")
+	r.StaffNIP = c.Query("createdBy.nip", "")
+	r.Limit = c.QueryInt("limit", 0)
+	r.Offset = c.QueryInt("offset", 0)
+	r.CreatedAt = c.Query("createdAt", "")
+	r.IncludeHistory = c.QueryBool("includeHistory", false)
+	r.Query = c.Query("q", "")
+	r.EncounterID = c.Query("encounterId", "")
+	r.GroupBy = c.Query("groupBy", "")
 }
 
 func (r *queryRecord) validate() {
@@ -315,9 +512,39 @@ func (r *queryRecord) validate() {
 		r.staffID, _ = ulid.Parse(r.StaffID)
 	}
 
+	if r.EncounterID != "" {
+		r.encounterID, _ = ulid.Parse(r.EncounterID)
+	}
+
+	if r.GroupBy != groupByEncounter {
+		r.GroupBy = ""
+	}
+
+	if r.Limit < 0 {
+		r.Limit = 0
+	}
+
 	if r.CreatedAt != "" && r.CreatedAt != "asc" && r.CreatedAt != "desc" {
 		r.CreatedAt = ""
 	}
+
+	const maxQueryLength = 200
+	r.Query = strings.TrimSpace(r.Query)
+	if query := []rune(r.Query); len(query) > maxQueryLength {
+		r.Query = string(query[:maxQueryLength])
+	}
+}
+
+func (r *queryRecord) toFilter(filter *domain.FilterMedicalRecord) {
+	filter.PatientID = r.patientID
+	filter.StaffID = r.staffID
+	filter.StaffNIP = r.StaffNIP
+	filter.Limit = r.Limit
+	filter.Offset = r.Offset
+	filter.CreatedAt = r.CreatedAt
+	filter.Query = r.Query
+	filter.EncounterID = r.encounterID
+	filter.GroupByEncounter = r.GroupBy == groupByEncounter
 }
 
 type identityDetail struct {
@@ -335,12 +562,61 @@ type createdBy struct {
 	UserId ulid.ULID `json:"userId"`
 }
 
+type recordRevision struct {
+	Type        string    `json:"type"`
+	Symptoms    string    `json:"symptoms,omitempty"`
+	Medications string    `json:"medications,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   string    `json:"createdAt"`
+	CreatedBy   createdBy `json:"createdBy"`
+}
+
 type getRecordRes struct {
-	IdentityDetail identityDetail `json:"identityDetail"`
-	Symptoms       string         `json:"symptoms"`
-	Medications    string         `json:"medications"`
-	CreatedAt      string         `json:"createdAt"`
-	CreatedBy      createdBy      `json:"createdBy"`
+	RecordID       ulid.ULID        `json:"recordId"`
+	EncounterID    string           `json:"encounterId,omitempty"`
+	IdentityDetail identityDetail   `json:"identityDetail"`
+	Symptoms       string           `json:"symptoms"`
+	Medications    string           `json:"medications"`
+	Amended        bool             `json:"amended"`
+	CreatedAt      string           `json:"createdAt"`
+	CreatedBy      createdBy        `json:"createdBy"`
+	Attachments    []attachmentRes  `json:"attachments"`
+	History        []recordRevision `json:"history,omitempty"`
+	Search         *searchRes       `json:"search,omitempty"`
+}
+
+// searchRes carries the relevance of a record to the full-text query. The
+// headlines are HTML escaped, matched terms are wrapped in <mark> tags.
+type searchRes struct {
+	Rank        float32 `json:"rank"`
+	Symptoms    string  `json:"symptoms"`
+	Medications string  `json:"medications"`
+}
+
+// recordGroupRes holds the records of one encounter, records saved outside
+// any encounter are grouped under an empty encounterId. The page is one of
+// encounters then, limit and offset count encounters and a record outside
+// any of them counts as one.
+type recordGroupRes struct {
+	EncounterID string        `json:"encounterId,omitempty"`
+	Records     getRecordsRes `json:"records"`
+}
+
+func groupRecordsByEncounter(records getRecordsRes) []recordGroupRes {
+	groups := make([]recordGroupRes, 0, len(records))
+	groupIndex := make(map[string]int, len(records))
+
+	for _, record := range records {
+		i, found := groupIndex[record.EncounterID]
+		if !found {
+			i = len(groups)
+			groupIndex[record.EncounterID] = i
+			groups = append(groups, recordGroupRes{EncounterID: record.EncounterID})
+		}
+		groups[i].Records = append(groups[i].Records, record)
+	}
+
+	return groups
 }
 
 const recordsInitCap = 5
@@ -361,3 +637,209 @@ func getRecordsResRelease(t getRecordsRes) {
 }
 
 type getRecordsRes []getRecordRes
+
+var amendRecordReqPool = sync.Pool{
+	New: func() any {
+		return new(amendRecordReq)
+	},
+}
+
+func amendRecordReqAcquire() *amendRecordReq {
+	return amendRecordReqPool.Get().(*amendRecordReq)
+}
+
+func amendRecordReqRelease(t *amendRecordReq) {
+	*t = amendRecordReq{}
+	amendRecordReqPool.Put(t)
+}
+
+type amendRecordReq struct {
+	Type        string `json:"type"`
+	Symptoms    string `json:"symptoms"`
+	Medications string `json:"medications"`
+	Reason      string `json:"reason"`
+}
+
+func (r amendRecordReq) validate() error {
+	var errs error
+
+	if r.Type == "" {
+		errs = multierr.Append(errs, errors.New("type is required"))
+	} else if r.Type != domain.AmendmentAddendum && r.Type != domain.AmendmentCorrection {
+		errs = multierr.Append(errs, errors.New("type must be either addendum or correction"))
+	}
+
+	if r.Symptoms == "" && r.Medications == "" {
+		errs = multierr.Append(errs, errors.New("symptoms or medications is required"))
+	}
+
+	if len(r.Symptoms) > 2000 {
+		errs = multierr.Append(errs, errors.New("symptoms must have at most 2000 characters"))
+	}
+
+	if len(r.Medications) > 2000 {
+		errs = multierr.Append(errs, errors.New("medications must have at most 2000 characters"))
+	}
+
+	if r.Reason == "" {
+		errs = multierr.Append(errs, errors.New("reason is required"))
+	} else if len(r.Reason) > 500 {
+		errs = multierr.Append(errs, errors.New("reason must have 1 to 500 characters"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+const (
+	timelineDefaultLimit = 20
+	timelineMaxLimit     = 100
+)
+
+// timelineCursor points at the last entry of a timeline page, it is
+// handed to clients as an opaque base64 string.
+type timelineCursor struct {
+	createdAt time.Time
+	id        ulid.ULID
+}
+
+func (c timelineCursor) encode() string {
+	raw := strconv.FormatInt(c.createdAt.UnixNano(), 10) + "." + c.id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func (c *timelineCursor) decode(s string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return errors.New("cursor is invalid")
+	}
+
+	createdAt, cursorID, found := strings.Cut(string(raw), ".")
+	if !found {
+		return errors.New("cursor is invalid")
+	}
+
+	nano, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return errors.New("cursor is invalid")
+	}
+
+	c.id, err = ulid.Parse(cursorID)
+	if err != nil {
+		return errors.New("cursor is invalid")
+	}
+	c.createdAt = time.Unix(0, nano).UTC()
+
+	return nil
+}
+
+var queryTimelinePool = sync.Pool{
+	New: func() any {
+		return new(queryTimeline)
+	},
+}
+
+func queryTimelineAcquire() *queryTimeline {
+	return queryTimelinePool.Get().(*queryTimeline)
+}
+
+func queryTimelineRelease(t *queryTimeline) {
+	*t = queryTimeline{}
+	queryTimelinePool.Put(t)
+}
+
+type queryTimeline struct {
+	Limit  int    `query:"limit"`
+	Cursor string `query:"cursor"`
+	cursor timelineCursor
+}
+
+func (r *queryTimeline) validate() error {
+	if r.Limit <= 0 {
+		r.Limit = timelineDefaultLimit
+	} else if r.Limit > timelineMaxLimit {
+		r.Limit = timelineMaxLimit
+	}
+
+	if r.Cursor != "" {
+		return r.cursor.decode(r.Cursor)
+	}
+
+	return nil
+}
+
+type timelineEntryRes struct {
+	ID        ulid.ULID       `json:"id"`
+	Type      string          `json:"type"`
+	RecordID  string          `json:"recordId,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	StaffID   string          `json:"staffId,omitempty"`
+	StaffName string          `json:"staffName,omitempty"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+type getTimelineRes struct {
+	Items      []timelineEntryRes `json:"items"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// queryDemographic holds the range and demographic filters shared by the
+// patient and medical record listings. Invalid values are ignored, the same
+// way an unknown createdAt sort direction is.
+type queryDemographic struct {
+	CreatedFrom   string
+	CreatedTo     string
+	AgeMin        int
+	AgeMax        int
+	Gender        string
+	BirthDateFrom string
+	BirthDateTo   string
+}
+
+func (q *queryDemographic) parse(c *fiber.Ctx) {
+	q.CreatedFrom = c.Query("createdFrom", "")
+	q.CreatedTo = c.Query("createdTo", "")
+	q.AgeMin = c.QueryInt("ageMin", 0)
+	q.AgeMax = c.QueryInt("ageMax", 0)
+	q.Gender = c.Query("gender", "")
+	q.BirthDateFrom = c.Query("birthDateFrom", "")
+	q.BirthDateTo = c.Query("birthDateTo", "")
+}
+
+func (q *queryDemographic) toFilter(filter *domain.FilterDemographic) {
+	if createdFrom, _, ok := timeparam.Parse(q.CreatedFrom); ok {
+		filter.CreatedFrom = createdFrom
+	}
+
+	// a bare date includes the whole day
+	if createdTo, dateOnly, ok := timeparam.Parse(q.CreatedTo); ok {
+		if dateOnly {
+			filter.CreatedTo = createdTo.AddDate(0, 0, 1)
+		} else {
+			filter.CreatedTo = createdTo.Add(time.Microsecond)
+		}
+	}
+
+	if q.AgeMin > 0 {
+		filter.AgeMin = q.AgeMin
+	}
+
+	if q.AgeMax > 0 && (q.AgeMin == 0 || q.AgeMax >= q.AgeMin) {
+		filter.AgeMax = q.AgeMax
+	}
+
+	if q.Gender == domain.GenderMale || q.Gender == domain.GenderFemale {
+		filter.Gender = q.Gender
+	}
+
+	if birthDateFrom, _, ok := timeparam.Parse(q.BirthDateFrom); ok {
+		filter.BirthDateFrom = birthDateFrom
+	}
+
+	if birthDateTo, _, ok := timeparam.Parse(q.BirthDateTo); ok {
+		filter.BirthDateTo = birthDateTo
+	}
+}
@@ -0,0 +1,308 @@
+// Package patientimport reads patients to register in bulk from a CSV or XLSX
+// file.
+//
+// The first row is a header naming the columns the way the registration
+// endpoint names its fields: identityNumber, phoneNumber, name, birthDate,
+// gender and identityCardScanImg, in any order and case. Other columns are
+// ignored. Of an XLSX file only the first sheet is read.
+package patientimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/internal/application/patientrule"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const (
+	columnIdentityNumber      = "identitynumber"
+	columnPhoneNumber         = "phonenumber"
+	columnName                = "name"
+	columnBirthDate           = "birthdate"
+	columnGender              = "gender"
+	columnIdentityCardScanImg = "identitycardscanimg"
+)
+
+// columns are the header names, lower cased, in the order they are reported
+// when missing.
+var columns = []string{
+	columnIdentityNumber,
+	columnPhoneNumber,
+	columnName,
+	columnBirthDate,
+	columnGender,
+	columnIdentityCardScanImg,
+}
+
+var columnNames = map[string]string{
+	columnIdentityNumber:      "identityNumber",
+	columnPhoneNumber:         "phoneNumber",
+	columnName:                "name",
+	columnBirthDate:           "birthDate",
+	columnGender:              "gender",
+	columnIdentityCardScanImg: "identityCardScanImg",
+}
+
+var (
+	ErrUnsupportedFormat = errors.New("file must be a CSV or XLSX file")
+	ErrEmptyFile         = errors.New("file has no header row")
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// Row is a patient as written in the file, Number is the row it is on
+// counting the header as row 1.
+type Row struct {
+	Number              int
+	IdentityNumber      string
+	PhoneNumber         string
+	Name                string
+	BirthDate           string
+	Gender              string
+	IdentityCardScanImg string
+}
+
+// Patient validates the row with the rules of the registration endpoint and
+// fills patient from it. Every rule the row breaks is returned, combined with
+// multierr.
+//
+// Spreadsheets rarely hold timestamps, so a birth date is also taken as a
+// plain yyyy-mm-dd date.
+func (r *Row) Patient(patient *domain.Patient) error {
+	var errs error
+
+	errs = multierr.Append(errs, patientrule.IdentityNumber(r.IdentityNumber))
+
+	phoneNumber, err := patientrule.PhoneNumber(r.PhoneNumber)
+	errs = multierr.Append(errs, err)
+
+	errs = multierr.Append(errs, patientrule.Name(r.Name))
+
+	birthDate := r.BirthDate
+	if date, err := time.Parse(time.DateOnly, birthDate); err == nil {
+		birthDate = date.Format(patientrule.DateFormat)
+	}
+	parsedBirthDate, err := patientrule.BirthDate(birthDate)
+	errs = multierr.Append(errs, err)
+
+	errs = multierr.Append(errs, patientrule.Gender(r.Gender))
+	errs = multierr.Append(errs, patientrule.IdentityCardScanImg(r.IdentityCardScanImg))
+
+	if errs != nil {
+		return errs
+	}
+
+	patient.ID = r.IdentityNumber
+	patient.PhoneNumber = phoneNumber
+	patient.Name = r.Name
+	patient.BirthDate = parsedBirthDate
+	patient.Gender = r.Gender
+	patient.ImgURL = r.IdentityCardScanImg
+
+	return nil
+}
+
+// Format tells the format of an uploaded file from its name, or from its
+// content type when the name has no known extension. It is empty when the
+// format is neither.
+func Format(filename, contentType string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".xlsx":
+		return FormatXLSX
+	}
+
+	contentType, _, _ = strings.Cut(contentType, ";")
+	switch strings.TrimSpace(contentType) {
+	case "text/csv", "application/csv":
+		return FormatCSV
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return FormatXLSX
+	}
+
+	return ""
+}
+
+// Read reads the rows of a file in format. Blank rows are skipped. It fails
+// when the file cannot be read or its header misses a column, a row with
+// invalid values is left for Row.Patient to report.
+func Read(r io.Reader, format string) ([]Row, error) {
+	var (
+		records [][]string
+		err     error
+	)
+
+	switch format {
+	case FormatCSV:
+		records, err = readCSV(r)
+	case FormatXLSX:
+		records, err = readXLSX(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	index, err := headerIndex(records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	cell := func(record []string, column string) string {
+		i := index[column]
+		if i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+
+		row := Row{
+			Number:              i + 2,
+			IdentityNumber:      cell(record, columnIdentityNumber),
+			PhoneNumber:         cell(record, columnPhoneNumber),
+			Name:                cell(record, columnName),
+			BirthDate:           cell(record, columnBirthDate),
+			Gender:              cell(record, columnGender),
+			IdentityCardScanImg: cell(record, columnIdentityCardScanImg),
+		}
+		if format == FormatXLSX {
+			fromSpreadsheet(&row)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// headerIndex maps each column to its position in the header.
+func headerIndex(header []string) (map[string]int, error) {
+	index := make(map[string]int, len(columns))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columnNames[name]; ok {
+			if _, seen := index[name]; !seen {
+				index[name] = i
+			}
+		}
+	}
+
+	missing := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, columnNames[column])
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.New("file header is missing the columns: " + strings.Join(missing, ", "))
+	}
+
+	return index, nil
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readCSV reads a CSV file separated by commas, or by semicolons as
+// spreadsheets set to an Indonesian locale export them.
+func readCSV(r io.Reader) ([][]string, error) {
+	br := bufio.NewReader(r)
+
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	// the buffer is all there is when the file is shorter than it
+	head, _ := br.Peek(br.Size())
+	header, _, _ := bytes.Cut(head, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		cr.Comma = ';'
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.New("file is not a valid CSV file: " + err.Error())
+	}
+
+	return records, nil
+}
+
+// readXLSX reads the first sheet with the raw cell values, so numbers and
+// dates are not formatted by the locale the file was saved in.
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, errors.New("file is not a valid XLSX file")
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	records, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, errors.New("file is not a valid XLSX file")
+	}
+
+	return records, nil
+}
+
+// fromSpreadsheet turns the values of cells typed as numbers back into
+// text: identity numbers saved in scientific notation, and birth dates
+// saved as date serial numbers.
+func fromSpreadsheet(row *Row) {
+	if strings.ContainsAny(row.IdentityNumber, ".Ee") {
+		if n, err := strconv.ParseFloat(row.IdentityNumber, 64); err == nil {
+			row.IdentityNumber = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(row.BirthDate, 64); err == nil {
+		if date, err := excelize.ExcelDateToTime(serial, false); err == nil {
+			row.BirthDate = date.Format(time.DateOnly)
+		}
+	}
+}
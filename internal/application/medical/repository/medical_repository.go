@@ -10,10 +10,14 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
 	"go.uber.org/zap"
 
 	"github.com/j03hanafi/halo-suster/common/id"
 	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/jobqueue"
+	"github.com/j03hanafi/halo-suster/internal/application/outbox"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
@@ -25,7 +29,7 @@ func NewMedicalRepository(db *pgxpool.Pool) *MedicalRepository {
 	return &MedicalRepository{db: db}
 }
 
-func (r MedicalRepository) RecordPatient(ctx context.Context, patient *domain.Patient) error {
+func (r MedicalRepository) RecordPatient(ctx context.Context, patient *domain.Patient, user *domain.User) error {
 	callerInfo := "[MedicalRepository.RecordPatient]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
 
@@ -43,7 +47,16 @@ func (r MedicalRepository) RecordPatient(ctx context.Context, patient *domain.Pa
 		"created_at":   patient.CreatedAt,
 	}
 
-	_, err := r.db.Exec(ctx, insertQuery, args)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, insertQuery, args)
 	if err != nil {
 		l.Error("failed to register user", zap.Error(err))
 
@@ -55,9 +68,339 @@ func (r MedicalRepository) RecordPatient(ctx context.Context, patient *domain.Pa
 		return err
 	}
 
+	event, err := patientevent.New(patient.ID, domain.PatientEventRegistered, user, map[string]string{
+		"phoneNumber": patient.PhoneNumber,
+		"name":        patient.Name,
+		"birthDate":   patient.BirthDate.Format(time.DateOnly),
+		"gender":      patient.Gender,
+	})
+	if err != nil {
+		l.Error("failed to encode patient event", zap.Error(err))
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	event.CreatedAt = patient.CreatedAt
+	if err = patientevent.Insert(ctx, tx, event); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	err = outbox.Write(ctx, tx, domain.OutboxPatientRegistered, patient.ID, outbox.Patient(patient, user))
+	if err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r MedicalRepository) UpdatePatient(ctx context.Context, patient *domain.Patient, user *domain.User) error {
+	callerInfo := "[MedicalRepository.UpdatePatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	old := domain.PatientAcquire()
+	defer domain.PatientRelease(old)
+	var isMale bool
+
+	selectQuery := `SELECT phone_number, name, birth_date, is_male, img_url FROM patients WHERE id = @id FOR UPDATE`
+	err = tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": patient.ID}).
+		Scan(&old.PhoneNumber, &old.Name, &old.BirthDate, &isMale, &old.ImgURL)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+
+		l.Error("failed to get patient", zap.Error(err))
+		return err
+	}
+
+	old.Gender = domain.GenderMale
+	if !isMale {
+		old.Gender = domain.GenderFemale
+	}
+
+	updateQuery := `UPDATE patients SET phone_number = @phone_number, name = @name, birth_date = @birth_date, 
+		is_male = @is_male, img_url = @img_url WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":           patient.ID,
+		"phone_number": patient.PhoneNumber,
+		"name":         patient.Name,
+		"birth_date":   patient.BirthDate,
+		"is_male":      patient.Gender == domain.GenderMale,
+		"img_url":      patient.ImgURL,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to update patient", zap.Error(err))
+		return err
+	}
+
+	changes := patientevent.PatientChanges(old, patient)
+	if len(changes) > 0 {
+		event, err := patientevent.New(patient.ID, domain.PatientEventUpdated, user, changes)
+		if err != nil {
+			l.Error("failed to encode patient event", zap.Error(err))
+			return err
+		}
+		defer domain.PatientEventRelease(event)
+
+		if err = patientevent.Insert(ctx, tx, event); err != nil {
+			l.Error("failed to save patient event", zap.Error(err))
+			return err
+		}
+
+		data := outbox.Patient(patient, user)
+		data.Changes = changes
+		if err = outbox.Write(ctx, tx, domain.OutboxPatientUpdated, patient.ID, data); err != nil {
+			l.Error("failed to save outbox event", zap.Error(err))
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ImportPatients registers patients in one transaction with COPY and returns
+// the identity numbers of those already registered, which are left as they
+// are. The patients are copied into a temporary table first so that a
+// registered patient skips its row instead of failing the whole COPY.
+func (r MedicalRepository) ImportPatients(
+	ctx context.Context,
+	patients domain.Patients,
+	user *domain.User,
+) ([]string, error) {
+	callerInfo := "[MedicalRepository.ImportPatients]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	createQuery := `CREATE TEMPORARY TABLE patients_import (LIKE patients) ON COMMIT DROP`
+	if _, err = tx.Exec(ctx, createQuery); err != nil {
+		l.Error("failed to create import table", zap.Error(err))
+		return nil, err
+	}
+
+	createdAt := time.Now()
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"patients_import"},
+		[]string{"id", "phone_number", "name", "birth_date", "is_male", "img_url", "created_at"},
+		pgx.CopyFromSlice(len(patients), func(i int) ([]any, error) {
+			patients[i].CreatedAt = createdAt
+			return []any{
+				patients[i].ID,
+				patients[i].PhoneNumber,
+				patients[i].Name,
+				patients[i].BirthDate,
+				patients[i].Gender == domain.GenderMale,
+				patients[i].ImgURL,
+				patients[i].CreatedAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		l.Error("failed to copy patients", zap.Error(err))
+		return nil, err
+	}
+
+	insertQuery := `INSERT INTO patients (id, phone_number, name, birth_date, is_male, img_url, created_at)
+		SELECT id, phone_number, name, birth_date, is_male, img_url, created_at FROM patients_import
+		ON CONFLICT (id) DO NOTHING RETURNING id`
+	rows, err := tx.Query(ctx, insertQuery)
+	if err != nil {
+		l.Error("failed to import patients", zap.Error(err))
+		return nil, err
+	}
+
+	imported := make(map[string]bool, len(patients))
+	var patientID string
+
+	_, err = pgx.ForEachRow(rows, []any{&patientID}, func() error {
+		imported[patientID] = true
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to import patients", zap.Error(err))
+		return nil, err
+	}
+
+	registered := make([]string, 0, len(patients)-len(imported))
+	events := make([]*domain.PatientEvent, 0, len(imported))
+	outboxEvents := make([]*domain.OutboxEvent, 0, len(imported))
+	defer func() {
+		for _, event := range events {
+			domain.PatientEventRelease(event)
+		}
+		for _, event := range outboxEvents {
+			domain.OutboxEventRelease(event)
+		}
+	}()
+
+	for i := range patients {
+		patient := &patients[i]
+		if !imported[patient.ID] {
+			registered = append(registered, patient.ID)
+			continue
+		}
+
+		event, err := patientevent.New(patient.ID, domain.PatientEventRegistered, user, map[string]string{
+			"phoneNumber": patient.PhoneNumber,
+			"name":        patient.Name,
+			"birthDate":   patient.BirthDate.Format(time.DateOnly),
+			"gender":      patient.Gender,
+		})
+		if err != nil {
+			l.Error("failed to encode patient event", zap.Error(err))
+			return nil, err
+		}
+		event.CreatedAt = patient.CreatedAt
+		events = append(events, event)
+
+		outboxEvent, err := outbox.New(domain.OutboxPatientRegistered, patient.ID, outbox.Patient(patient, user))
+		if err != nil {
+			l.Error("failed to encode outbox event", zap.Error(err))
+			return nil, err
+		}
+		outboxEvent.CreatedAt = patient.CreatedAt
+		outboxEvents = append(outboxEvents, outboxEvent)
+	}
+
+	if err = patientevent.CopyFrom(ctx, tx, events); err != nil {
+		l.Error("failed to save patient events", zap.Error(err))
+		return nil, err
+	}
+
+	if err = outbox.CopyFrom(ctx, tx, outboxEvents); err != nil {
+		l.Error("failed to save outbox events", zap.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return registered, nil
+}
+
+func (r MedicalRepository) QueueJob(ctx context.Context, job *domain.Job) error {
+	callerInfo := "[MedicalRepository.QueueJob]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := jobqueue.Insert(ctx, r.db, job); err != nil {
+		l.Error("failed to queue job", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
+func (r MedicalRepository) GetPatientTimeline(
+	ctx context.Context,
+	filter *domain.FilterTimeline,
+	entries domain.TimelineEntries,
+) (domain.TimelineEntries, error) {
+	callerInfo := "[MedicalRepository.GetPatientTimeline]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM patients WHERE id = @id)`, pgx.NamedArgs{
+		"id": filter.PatientID,
+	}).Scan(&exists)
+	if err != nil {
+		l.Error("failed to check patient", zap.Error(err))
+		return entries, err
+	}
+	if !exists {
+		return entries, new(domain.ErrPatientNotFound)
+	}
+
+	getQuery := `SELECT type, id, record_id, data, staff_id, staff_name, created_at FROM (
+			SELECT 'medical_record' AS type, m.id, m.id AS record_id,
+				jsonb_build_object('symptoms', m.symptoms, 'medications', m.medications) AS data,
+				m.staff_id, m.staff_name, m.created_at
+			FROM medical_records m WHERE m.patient_id = @patient_id
+			UNION ALL
+			SELECT 'medical_record.' || a.type, a.id, a.record_id,
+				jsonb_build_object('symptoms', a.symptoms, 'medications', a.medications, 'reason', a.reason),
+				a.staff_id, a.staff_name, a.created_at
+			FROM medical_record_amendments a JOIN medical_records m ON m.id = a.record_id 
+			WHERE m.patient_id = @patient_id
+			UNION ALL
+			SELECT e.type, e.id, NULL, e.data, e.staff_id, e.staff_name, e.created_at
+			FROM patient_events e WHERE e.patient_id = @patient_id
+		) t`
+	args := pgx.NamedArgs{"patient_id": filter.PatientID}
+
+	if !filter.CursorAt.IsZero() {
+		getQuery += ` WHERE (created_at, id) < (@cursor_at, @cursor_id)`
+		args["cursor_at"] = filter.CursorAt
+		args["cursor_id"] = filter.CursorID
+	}
+
+	getQuery += ` ORDER BY created_at DESC, id DESC LIMIT @limit`
+	args["limit"] = filter.Limit
+
+	rows, err := r.db.Query(ctx, getQuery, args)
+	if err != nil {
+		l.Error("failed to get patient timeline", zap.Error(err))
+		return entries, err
+	}
+
+	var entry domain.TimelineEntry
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&entry.Type,
+			&entry.ID,
+			&entry.RecordID,
+			&entry.Data,
+			&entry.StaffID,
+			&entry.StaffName,
+			&entry.CreatedAt,
+		},
+		func() error {
+			entries = append(entries, entry)
+			entry = domain.TimelineEntry{}
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get patient timeline", zap.Error(err))
+		return entries, err
+	}
+
+	return entries, nil
+}
+
 func (r MedicalRepository) GetPatients(
 	ctx context.Context,
 	filter *domain.FilterPatient,
@@ -99,8 +442,58 @@ func (r MedicalRepository) GetPatients(
 	return patients, nil
 }
 
+// ExportPatients hands every patient matching filter to writer as it is read
+// from the database, so an export is never held in memory as a whole.
+func (r MedicalRepository) ExportPatients(
+	ctx context.Context,
+	filter *domain.FilterPatient,
+	writer PatientWriter,
+) error {
+	callerInfo := "[MedicalRepository.ExportPatients]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterPatient(filter)
+	getQuery := `SELECT id, phone_number, name, birth_date, is_male, img_url, created_at FROM patients` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to export patients", zap.Error(err))
+		return err
+	}
+
+	dPatient := domain.PatientAcquire()
+	defer domain.PatientRelease(dPatient)
+	var isMale bool
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dPatient.ID,
+			&dPatient.PhoneNumber,
+			&dPatient.Name,
+			&dPatient.BirthDate,
+			&isMale,
+			&dPatient.ImgURL,
+			&dPatient.CreatedAt,
+		},
+		func() error {
+			dPatient.Gender = domain.GenderMale
+			if !isMale {
+				dPatient.Gender = domain.GenderFemale
+			}
+			return writer.Write(dPatient)
+		},
+	)
+	if err != nil {
+		l.Error("failed to export patients", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (r MedicalRepository) filterPatient(filter *domain.FilterPatient) (string, pgx.NamedArgs) {
-	const totalConditions = 3
+	const totalConditions = 10
 	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
 
 	if filter.ID != "" {
@@ -118,6 +511,12 @@ func (r MedicalRepository) filterPatient(filter *domain.FilterPatient) (string,
 		params["phone_number"] = filter.PhoneNumber + "%"
 	}
 
+	conditions = r.filterDemographic(&filter.FilterDemographic, demographicColumns{
+		createdAt: "created_at",
+		birthDate: "birth_date",
+		isMale:    "is_male",
+	}, conditions, params)
+
 	order := " ORDER BY created_at DESC"
 	if filter.CreatedAt != "" && (filter.CreatedAt == "asc" || filter.CreatedAt == "desc") {
 		order = " ORDER BY created_at " + filter.CreatedAt
@@ -126,10 +525,12 @@ func (r MedicalRepository) filterPatient(filter *domain.FilterPatient) (string,
 	const totalLimitOffset = 2
 	limitOffset := make([]string, 0, totalLimitOffset)
 
-	limitOffset = append(limitOffset, "LIMIT @limit")
-	params["limit"] = 5
-	if filter.Limit != 0 {
-		params["limit"] = filter.Limit
+	if filter.Limit != domain.LimitAll {
+		limitOffset = append(limitOffset, "LIMIT @limit")
+		params["limit"] = 5
+		if filter.Limit != 0 {
+			params["limit"] = filter.Limit
+		}
 	}
 
 	if filter.Offset != 0 {
@@ -158,26 +559,48 @@ func (r MedicalRepository) SaveMedicalRecord(ctx context.Context, record *domain
 	record.ID = id.New()
 	record.CreatedAt = time.Now()
 
+	var encounterID any
+	if !id.IsZero(record.EncounterID) {
+		encounterID = record.EncounterID
+	}
+
 	insertQuery := `INSERT INTO medical_records (
 		id, patient_id, patient_phone_number, patient_name, patient_birth_date, patient_is_male, patient_img_url, 
-                             symptoms, medications, staff_id, staff_nip, staff_name, created_at
+                             symptoms, medications, staff_id, staff_nip, staff_name, encounter_id, created_at
 			)
 		SELECT 
     		@id, p.id, p.phone_number, p.name, p.birth_date, p.is_male, p.img_url, @symptoms, @medications, @staff_id, 
-    		@staff_nip, @staff_name, @created_at
+    		@staff_nip, @staff_name, @encounter_id, @created_at
 		FROM patients p WHERE p.id = @patient_id`
 	args := pgx.NamedArgs{
-		"id":          record.ID,
-		"patient_id":  record.PatientID,
-		"symptoms":    record.Symptoms,
-		"medications": record.Medications,
-		"staff_id":    record.StaffID,
-		"staff_nip":   record.StaffNIP,
-		"staff_name":  record.StaffName,
-		"created_at":  record.CreatedAt,
+		"id":           record.ID,
+		"patient_id":   record.PatientID,
+		"symptoms":     record.Symptoms,
+		"medications":  record.Medications,
+		"staff_id":     record.StaffID,
+		"staff_nip":    record.StaffNIP,
+		"staff_name":   record.StaffName,
+		"encounter_id": encounterID,
+		"created_at":   record.CreatedAt,
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
-	result, err := r.db.Exec(ctx, insertQuery, args)
+	if encounterID != nil {
+		if err = r.checkOpenEncounter(ctx, tx, record); err != nil {
+			l.Error("failed to attach medical record to encounter", zap.Error(err))
+			return err
+		}
+	}
+
+	result, err := tx.Exec(ctx, insertQuery, args)
 	if err != nil {
 		l.Error("failed to save medical record", zap.Error(err))
 		return err
@@ -187,6 +610,118 @@ func (r MedicalRepository) SaveMedicalRecord(ctx context.Context, record *domain
 		return new(domain.ErrPatientNotFound)
 	}
 
+	for i := range record.Attachments {
+		record.Attachments[i].RecordID = record.ID
+		record.Attachments[i].StaffID = record.StaffID
+		if err = r.insertAttachment(ctx, tx, &record.Attachments[i]); err != nil {
+			l.Error("failed to save medical record attachment", zap.Error(err))
+			return err
+		}
+	}
+
+	err = outbox.Write(ctx, tx, domain.OutboxRecordCreated, record.PatientID, outbox.Record(record))
+	if err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// checkOpenEncounter locks the encounter against being closed until the
+// record referencing it is committed.
+func (r MedicalRepository) checkOpenEncounter(ctx context.Context, tx pgx.Tx, record *domain.MedicalRecord) error {
+	var patientID, status string
+
+	selectQuery := `SELECT patient_id, status FROM encounters WHERE id = @id FOR SHARE`
+	err := tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": record.EncounterID}).Scan(&patientID, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrEncounterNotFound)
+		}
+		return err
+	}
+
+	if patientID != record.PatientID {
+		return new(domain.ErrEncounterPatientMismatch)
+	}
+
+	if status != domain.EncounterOpen {
+		return new(domain.ErrEncounterClosed)
+	}
+
+	return nil
+}
+
+func (r MedicalRepository) insertAttachment(
+	ctx context.Context,
+	tx pgx.Tx,
+	attachment *domain.MedicalRecordAttachment,
+) error {
+	attachment.ID = id.New()
+	attachment.CreatedAt = time.Now()
+
+	insertQuery := `INSERT INTO medical_record_attachments (
+		id, record_id, url, name, content_type, description, staff_id, created_at
+			)
+		SELECT @id, m.id, @url, @name, @content_type, @description, @staff_id, @created_at
+		FROM medical_records m WHERE m.id = @record_id`
+	args := pgx.NamedArgs{
+		"id":           attachment.ID,
+		"record_id":    attachment.RecordID,
+		"url":          attachment.URL,
+		"name":         attachment.Name,
+		"content_type": attachment.ContentType,
+		"description":  attachment.Description,
+		"staff_id":     attachment.StaffID,
+		"created_at":   attachment.CreatedAt,
+	}
+
+	result, err := tx.Exec(ctx, insertQuery, args)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return new(domain.ErrMedicalRecordNotFound)
+	}
+
+	return nil
+}
+
+func (r MedicalRepository) AttachToMedicalRecord(
+	ctx context.Context,
+	attachments domain.MedicalRecordAttachments,
+) error {
+	callerInfo := "[MedicalRepository.AttachToMedicalRecord]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for i := range attachments {
+		if err = r.insertAttachment(ctx, tx, &attachments[i]); err != nil {
+			l.Error("failed to save medical record attachment", zap.Error(err))
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
@@ -198,9 +733,19 @@ func (r MedicalRepository) GetMedicalRecords(
 	callerInfo := "[MedicalRepository.GetMedicalRecords]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
 
-	conditions, params := r.filterMedicalRecord(filter)
-	getQuery := `SELECT id, patient_id, patient_phone_number, patient_name, patient_birth_date, patient_is_male, patient_img_url, symptoms, medications, staff_id, staff_nip, staff_name, created_at FROM medical_records
+	var (
+		getQuery string
+		params   pgx.NamedArgs
+	)
+	if filter.GroupByEncounter {
+		getQuery, params = r.encounterPage(filter)
+	} else {
+		var conditions string
+		conditions, params = r.filterMedicalRecord(filter)
+		getQuery = `SELECT ` + recordColumns + `, ` +
+			r.searchColumns(filter) + ` FROM medical_records` + r.searchJoin(filter) + `
 		` + conditions
+	}
 
 	rows, err := r.db.Query(ctx, getQuery, params)
 	if err != nil {
@@ -227,7 +772,11 @@ func (r MedicalRepository) GetMedicalRecords(
 			&dRecord.StaffID,
 			&dRecord.StaffNIP,
 			&dRecord.StaffName,
+			&dRecord.EncounterID,
 			&dRecord.CreatedAt,
+			&dRecord.SearchRank,
+			&dRecord.SymptomsHeadline,
+			&dRecord.MedicationsHeadline,
 		},
 		func() error {
 			dRecord.PatientGender = domain.GenderMale
@@ -235,6 +784,7 @@ func (r MedicalRepository) GetMedicalRecords(
 				dRecord.PatientGender = domain.GenderFemale
 			}
 			records = append(records, *dRecord)
+			dRecord.EncounterID = ulid.ULID{}
 			return nil
 		},
 	)
@@ -243,11 +793,291 @@ func (r MedicalRepository) GetMedicalRecords(
 		return records, err
 	}
 
+	records, err = r.getAmendments(ctx, records)
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return records, err
+	}
+
+	records, err = r.getAttachments(ctx, records)
+	if err != nil {
+		l.Error("failed to get medical record attachments", zap.Error(err))
+		return records, err
+	}
+
 	return records, nil
 }
 
+// ExportMedicalRecords hands every medical record matching filter to writer as
+// it is read from the database. The amendments of a record come with it,
+// aggregated oldest first, so they need no second query per record.
+func (r MedicalRepository) ExportMedicalRecords(
+	ctx context.Context,
+	filter *domain.FilterMedicalRecord,
+	writer RecordWriter,
+) error {
+	callerInfo := "[MedicalRepository.ExportMedicalRecords]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterMedicalRecord(filter)
+	getQuery := `SELECT id, patient_id, patient_phone_number, patient_name, patient_birth_date, patient_is_male, patient_img_url, symptoms, medications, staff_id, staff_nip, staff_name, encounter_id, created_at, 
+		amendment_types, amendment_symptoms, amendment_medications, ` +
+		r.searchColumns(filter) + ` FROM medical_records 
+		LEFT JOIN LATERAL (
+			SELECT array_agg(a.type ORDER BY a.created_at) AS amendment_types, 
+				array_agg(a.symptoms ORDER BY a.created_at) AS amendment_symptoms, 
+				array_agg(a.medications ORDER BY a.created_at) AS amendment_medications 
+			FROM medical_record_amendments a WHERE a.record_id = medical_records.id
+		) amendments ON true` + r.searchJoin(filter) + `
+		` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to export medical records", zap.Error(err))
+		return err
+	}
+
+	dRecord := domain.MedicalRecordAcquire()
+	defer domain.MedicalRecordRelease(dRecord)
+	var (
+		isMale                                     bool
+		types, amendedSymptoms, amendedMedications []string
+	)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dRecord.ID,
+			&dRecord.PatientID,
+			&dRecord.PatientPhoneNumber,
+			&dRecord.PatientName,
+			&dRecord.PatientBirthDate,
+			&isMale,
+			&dRecord.PatientImgURL,
+			&dRecord.Symptoms,
+			&dRecord.Medications,
+			&dRecord.StaffID,
+			&dRecord.StaffNIP,
+			&dRecord.StaffName,
+			&dRecord.EncounterID,
+			&dRecord.CreatedAt,
+			&types,
+			&amendedSymptoms,
+			&amendedMedications,
+			&dRecord.SearchRank,
+			&dRecord.SymptomsHeadline,
+			&dRecord.MedicationsHeadline,
+		},
+		func() error {
+			dRecord.PatientGender = domain.GenderMale
+			if !isMale {
+				dRecord.PatientGender = domain.GenderFemale
+			}
+
+			dRecord.Amendments = dRecord.Amendments[:0]
+			for i := range types {
+				dRecord.Amendments = append(dRecord.Amendments, domain.MedicalRecordAmendment{
+					RecordID:    dRecord.ID,
+					Type:        types[i],
+					Symptoms:    amendedSymptoms[i],
+					Medications: amendedMedications[i],
+				})
+			}
+
+			err := writer.Write(dRecord)
+			dRecord.EncounterID = ulid.ULID{}
+			return err
+		},
+	)
+	if err != nil {
+		l.Error("failed to export medical records", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r MedicalRepository) getAmendments(
+	ctx context.Context,
+	records domain.MedicalRecords,
+) (domain.MedicalRecords, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+
+	recordIndex := make(map[ulid.ULID]int, len(records))
+	recordIDs := make([][]byte, 0, len(records))
+	for i := range records {
+		recordIndex[records[i].ID] = i
+		recordIDs = append(recordIDs, records[i].ID[:])
+	}
+
+	getQuery := `SELECT id, record_id, type, symptoms, medications, reason, staff_id, staff_nip, staff_name, created_at 
+		FROM medical_record_amendments WHERE record_id = ANY(@record_ids) ORDER BY created_at ASC`
+	args := pgx.NamedArgs{"record_ids": recordIDs}
+
+	rows, err := r.db.Query(ctx, getQuery, args)
+	if err != nil {
+		return records, err
+	}
+
+	dAmendment := domain.MedicalRecordAmendmentAcquire()
+	defer domain.MedicalRecordAmendmentRelease(dAmendment)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dAmendment.ID,
+			&dAmendment.RecordID,
+			&dAmendment.Type,
+			&dAmendment.Symptoms,
+			&dAmendment.Medications,
+			&dAmendment.Reason,
+			&dAmendment.StaffID,
+			&dAmendment.StaffNIP,
+			&dAmendment.StaffName,
+			&dAmendment.CreatedAt,
+		},
+		func() error {
+			i := recordIndex[dAmendment.RecordID]
+			records[i].Amendments = append(records[i].Amendments, *dAmendment)
+			return nil
+		},
+	)
+
+	return records, err
+}
+
+func (r MedicalRepository) getAttachments(
+	ctx context.Context,
+	records domain.MedicalRecords,
+) (domain.MedicalRecords, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+
+	recordIndex := make(map[ulid.ULID]int, len(records))
+	recordIDs := make([][]byte, 0, len(records))
+	for i := range records {
+		recordIndex[records[i].ID] = i
+		recordIDs = append(recordIDs, records[i].ID[:])
+	}
+
+	getQuery := `SELECT id, record_id, url, name, content_type, description, staff_id, created_at 
+		FROM medical_record_attachments WHERE record_id = ANY(@record_ids) ORDER BY created_at ASC`
+	args := pgx.NamedArgs{"record_ids": recordIDs}
+
+	rows, err := r.db.Query(ctx, getQuery, args)
+	if err != nil {
+		return records, err
+	}
+
+	dAttachment := domain.MedicalRecordAttachmentAcquire()
+	defer domain.MedicalRecordAttachmentRelease(dAttachment)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dAttachment.ID,
+			&dAttachment.RecordID,
+			&dAttachment.URL,
+			&dAttachment.Name,
+			&dAttachment.ContentType,
+			&dAttachment.Description,
+			&dAttachment.StaffID,
+			&dAttachment.CreatedAt,
+		},
+		func() error {
+			i := recordIndex[dAttachment.RecordID]
+			records[i].Attachments = append(records[i].Attachments, *dAttachment)
+			return nil
+		},
+	)
+
+	return records, err
+}
+
+func (r MedicalRepository) AmendMedicalRecord(ctx context.Context, amendment *domain.MedicalRecordAmendment) error {
+	callerInfo := "[MedicalRepository.AmendMedicalRecord]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	amendment.ID = id.New()
+	amendment.CreatedAt = time.Now()
+
+	insertQuery := `INSERT INTO medical_record_amendments (
+		id, record_id, type, symptoms, medications, reason, staff_id, staff_nip, staff_name, created_at
+			)
+		SELECT @id, m.id, @type, @symptoms, @medications, @reason, @staff_id, @staff_nip, @staff_name, @created_at
+		FROM medical_records m WHERE m.id = @record_id`
+	args := pgx.NamedArgs{
+		"id":          amendment.ID,
+		"record_id":   amendment.RecordID,
+		"type":        amendment.Type,
+		"symptoms":    amendment.Symptoms,
+		"medications": amendment.Medications,
+		"reason":      amendment.Reason,
+		"staff_id":    amendment.StaffID,
+		"staff_nip":   amendment.StaffNIP,
+		"staff_name":  amendment.StaffName,
+		"created_at":  amendment.CreatedAt,
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var patientID string
+	selectQuery := `SELECT patient_id FROM medical_records WHERE id = @id`
+	err = tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": amendment.RecordID}).Scan(&patientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrMedicalRecordNotFound)
+		}
+
+		l.Error("failed to get medical record", zap.Error(err))
+		return err
+	}
+
+	result, err := tx.Exec(ctx, insertQuery, args)
+	if err != nil {
+		l.Error("failed to amend medical record", zap.Error(err))
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return new(domain.ErrMedicalRecordNotFound)
+	}
+
+	err = outbox.Write(ctx, tx, domain.OutboxRecordAmended, patientID, outbox.Amendment(amendment, patientID))
+	if err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (r MedicalRepository) filterMedicalRecord(filter *domain.FilterMedicalRecord) (string, pgx.NamedArgs) {
-	const totalConditions = 3
+	queryConditions, params := r.whereMedicalRecord(filter)
+
+	queryConditions += " ORDER BY " + r.orderMedicalRecord(filter)
+	queryConditions += r.limitOffset(filter, params)
+
+	return queryConditions, params
+}
+
+func (r MedicalRepository) whereMedicalRecord(filter *domain.FilterMedicalRecord) (string, pgx.NamedArgs) {
+	const totalConditions = 12
 	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
 
 	if filter.PatientID != "" {
@@ -265,18 +1095,58 @@ func (r MedicalRepository) filterMedicalRecord(filter *domain.FilterMedicalRecor
 		params["staff_nip"] = filter.StaffNIP
 	}
 
-	order := " ORDER BY created_at DESC"
-	if filter.CreatedAt != "" && (filter.CreatedAt == "asc" || filter.CreatedAt == "desc") {
-		order = " ORDER BY created_at " + filter.CreatedAt
+	if !id.IsZero(filter.EncounterID) {
+		conditions = append(conditions, "encounter_id = @encounter_id")
+		params["encounter_id"] = filter.EncounterID
+	}
+
+	conditions = r.filterDemographic(&filter.FilterDemographic, demographicColumns{
+		createdAt: "created_at",
+		birthDate: "patient_birth_date",
+		isMale:    "patient_is_male",
+	}, conditions, params)
+
+	if filter.Query != "" {
+		conditions = append(conditions, `(search_vector @@ `+searchQuery+` OR EXISTS(
+			SELECT 1 FROM medical_record_amendments a 
+			WHERE a.record_id = medical_records.id AND a.search_vector @@ `+searchQuery+`))`)
+		params["query"] = filter.Query
+		params["correction"] = domain.AmendmentCorrection
+		params["addendum"] = domain.AmendmentAddendum
+	}
+
+	if len(conditions) == 0 {
+		return "", params
 	}
 
+	return " WHERE " + strings.Join(conditions, " AND "), params
+}
+
+// orderMedicalRecord orders records by relevance to the query first, if any,
+// then by creation time.
+func (r MedicalRepository) orderMedicalRecord(filter *domain.FilterMedicalRecord) string {
+	order := ""
+	if filter.Query != "" {
+		order = "search_rank DESC, "
+	}
+
+	if filter.CreatedAt == "asc" || filter.CreatedAt == "desc" {
+		return order + "created_at " + filter.CreatedAt
+	}
+
+	return order + "created_at DESC"
+}
+
+func (r MedicalRepository) limitOffset(filter *domain.FilterMedicalRecord, params pgx.NamedArgs) string {
 	const totalLimitOffset = 2
 	limitOffset := make([]string, 0, totalLimitOffset)
 
-	limitOffset = append(limitOffset, "LIMIT @limit")
-	params["limit"] = 5
-	if filter.Limit != 0 {
-		params["limit"] = filter.Limit
+	if filter.Limit != domain.LimitAll {
+		limitOffset = append(limitOffset, "LIMIT @limit")
+		params["limit"] = 5
+		if filter.Limit != 0 {
+			params["limit"] = filter.Limit
+		}
 	}
 
 	if filter.Offset != 0 {
@@ -284,18 +1154,164 @@ func (r MedicalRepository) filterMedicalRecord(filter *domain.FilterMedicalRecor
 		params["offset"] = filter.Offset
 	}
 
-	queryConditions := ""
-	if len(conditions) > 0 {
-		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	if len(limitOffset) == 0 {
+		return ""
 	}
 
-	queryConditions += order
+	return " " + strings.Join(limitOffset, " ")
+}
 
-	if len(limitOffset) > 0 {
-		queryConditions += " " + strings.Join(limitOffset, " ")
+const recordColumns = `id, patient_id, patient_phone_number, patient_name, patient_birth_date, patient_is_male, 
+	patient_img_url, symptoms, medications, staff_id, staff_nip, staff_name, encounter_id, created_at`
+
+// encounterPage pages the records matching filter by encounter rather than
+// by record. Limit and offset count encounters, with a record outside any
+// encounter counting as one, and every matching record of the encounters in
+// the page is returned. Encounters are ordered by their newest record, or
+// their oldest when ascending, and their records follow one another.
+func (r MedicalRepository) encounterPage(filter *domain.FilterMedicalRecord) (string, pgx.NamedArgs) {
+	where, params := r.whereMedicalRecord(filter)
+
+	groupOrder := "MAX(created_at) DESC"
+	if filter.CreatedAt == "asc" {
+		groupOrder = "MIN(created_at) ASC"
+	}
+	if filter.Query != "" {
+		groupOrder = "MAX(search_rank) DESC, " + groupOrder
 	}
 
-	return queryConditions, params
+	query := `WITH matched AS (
+			SELECT ` + recordColumns + `, ` + r.searchColumns(filter) + `, COALESCE(encounter_id, id) AS group_id 
+			FROM medical_records` + r.searchJoin(filter) + where + `
+		), page AS (
+			SELECT group_id, ROW_NUMBER() OVER (ORDER BY ` + groupOrder + `, group_id) AS group_position 
+			FROM matched GROUP BY group_id 
+			ORDER BY group_position` + r.limitOffset(filter, params) + `
+		)
+		SELECT ` + recordColumns + `, search_rank, symptoms_headline, medications_headline 
+		FROM matched JOIN page USING (group_id) 
+		ORDER BY group_position, ` + r.orderMedicalRecord(filter)
+
+	return query, params
+}
+
+type demographicColumns struct {
+	createdAt string
+	birthDate string
+	isMale    string
+}
+
+func (r MedicalRepository) filterDemographic(
+	filter *domain.FilterDemographic,
+	columns demographicColumns,
+	conditions []string,
+	params pgx.NamedArgs,
+) []string {
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, columns.createdAt+" >= @created_from")
+		params["created_from"] = filter.CreatedFrom
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, columns.createdAt+" < @created_to")
+		params["created_to"] = filter.CreatedTo
+	}
+
+	if filter.Gender != "" {
+		conditions = append(conditions, columns.isMale+" = @is_male")
+		params["is_male"] = filter.Gender == domain.GenderMale
+	}
+
+	// an age range is a birth date range relative to today
+	year, month, day := time.Now().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	if filter.AgeMin > 0 {
+		conditions = append(conditions, columns.birthDate+" <= @age_min_birth_date")
+		params["age_min_birth_date"] = today.AddDate(-filter.AgeMin, 0, 0)
+	}
+
+	if filter.AgeMax > 0 {
+		conditions = append(conditions, columns.birthDate+" > @age_max_birth_date")
+		params["age_max_birth_date"] = today.AddDate(-filter.AgeMax-1, 0, 0)
+	}
+
+	if !filter.BirthDateFrom.IsZero() {
+		conditions = append(conditions, columns.birthDate+" >= @birth_date_from")
+		params["birth_date_from"] = filter.BirthDateFrom
+	}
+
+	if !filter.BirthDateTo.IsZero() {
+		conditions = append(conditions, columns.birthDate+" <= @birth_date_to")
+		params["birth_date_to"] = filter.BirthDateTo
+	}
+
+	return conditions
+}
+
+// searchQuery matches the search terms in either language, records are
+// indexed with both the indonesian and english configurations.
+const searchQuery = `(websearch_to_tsquery('indonesian', @query) || websearch_to_tsquery('english', @query))`
+
+const searchHeadlineOptions = `'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5'`
+
+// currentText folds the amendments of a record into column the way
+// MedicalRecord.Current does: the last correction of column replaces it, and
+// the addenda after that correction are appended a line each.
+func currentText(column string) string {
+	corrections := ` FROM medical_record_amendments a 
+		WHERE a.record_id = medical_records.id AND a.type = @correction AND a.` + column + ` <> ''`
+
+	return `COALESCE((SELECT a.` + column + corrections + ` ORDER BY a.created_at DESC LIMIT 1), medical_records.` + column + `) || 
+		COALESCE((SELECT string_agg(E'\n' || a.` + column + `, '' ORDER BY a.created_at) FROM medical_record_amendments a 
+			WHERE a.record_id = medical_records.id AND a.type = @addendum AND a.` + column + ` <> '' 
+			AND a.created_at > COALESCE((SELECT max(a.created_at)` + corrections + `), '-infinity')), '')`
+}
+
+// searchJoin adds the symptoms and medications of the records as amended,
+// the matches are ranked and highlighted in what the records read now.
+func (r MedicalRepository) searchJoin(filter *domain.FilterMedicalRecord) string {
+	if filter.Query == "" {
+		return ""
+	}
+
+	return ` 
+		CROSS JOIN LATERAL (
+			SELECT ` + currentText("symptoms") + ` AS current_symptoms, 
+				` + currentText("medications") + ` AS current_medications
+		) amended`
+}
+
+// htmlEscaped escapes text for HTML, ts_headline keeps any markup in the text
+// it is given as it is.
+func htmlEscaped(text string) string {
+	return `replace(replace(replace(replace(replace(` + text + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// searchHeadline highlights the terms in text with the configuration they
+// match in, indonesian first. The text is escaped, so the <mark> tags are the
+// only markup of the headline.
+func searchHeadline(text string) string {
+	escaped := htmlEscaped(text)
+
+	return `CASE WHEN to_tsvector('indonesian', ` + text + `) @@ websearch_to_tsquery('indonesian', @query)
+		THEN ts_headline('indonesian', ` + escaped + `, websearch_to_tsquery('indonesian', @query), ` + searchHeadlineOptions + `)
+		ELSE ts_headline('english', ` + escaped + `, websearch_to_tsquery('english', @query), ` + searchHeadlineOptions + `) END`
+}
+
+func (r MedicalRepository) searchColumns(filter *domain.FilterMedicalRecord) string {
+	if filter.Query == "" {
+		return `0::real AS search_rank, '' AS symptoms_headline, '' AS medications_headline`
+	}
+
+	// weighted as search_vector is, over the amended text
+	vector := `setweight(to_tsvector('indonesian', amended.current_symptoms), 'A') || 
+		setweight(to_tsvector('english', amended.current_symptoms), 'A') || 
+		setweight(to_tsvector('indonesian', amended.current_medications), 'B') || 
+		setweight(to_tsvector('english', amended.current_medications), 'B')`
+
+	return `ts_rank(` + vector + `, ` + searchQuery + `) AS search_rank, 
+		` + searchHeadline("amended.current_symptoms") + ` AS symptoms_headline, 
+		` + searchHeadline("amended.current_medications") + ` AS medications_headline`
 }
 
 var _ MedicalRepositoryContract = (*MedicalRepository)(nil)
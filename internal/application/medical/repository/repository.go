@@ -7,12 +7,36 @@ import (
 )
 
 type MedicalRepositoryContract interface {
-	RecordPatient(ctx context.Context, patient *domain.Patient) error
+	RecordPatient(ctx context.Context, patient *domain.Patient, user *domain.User) error
+	UpdatePatient(ctx context.Context, patient *domain.Patient, user *domain.User) error
+	ImportPatients(ctx context.Context, patients domain.Patients, user *domain.User) ([]string, error)
+	QueueJob(ctx context.Context, job *domain.Job) error
 	GetPatients(ctx context.Context, filter *domain.FilterPatient, patients domain.Patients) (domain.Patients, error)
+	ExportPatients(ctx context.Context, filter *domain.FilterPatient, writer PatientWriter) error
 	SaveMedicalRecord(ctx context.Context, record *domain.MedicalRecord) error
 	GetMedicalRecords(
 		ctx context.Context,
 		filter *domain.FilterMedicalRecord,
 		records domain.MedicalRecords,
 	) (domain.MedicalRecords, error)
+	ExportMedicalRecords(ctx context.Context, filter *domain.FilterMedicalRecord, writer RecordWriter) error
+	AmendMedicalRecord(ctx context.Context, amendment *domain.MedicalRecordAmendment) error
+	AttachToMedicalRecord(ctx context.Context, attachments domain.MedicalRecordAttachments) error
+	GetPatientTimeline(
+		ctx context.Context,
+		filter *domain.FilterTimeline,
+		entries domain.TimelineEntries,
+	) (domain.TimelineEntries, error)
+}
+
+// PatientWriter receives the patients of an export one at a time, the patient
+// is reused for the next one once Write returns.
+type PatientWriter interface {
+	Write(patient *domain.Patient) error
+}
+
+// RecordWriter receives the medical records of an export one at a time, with
+// their amendments, the record is reused for the next one once Write returns.
+type RecordWriter interface {
+	Write(record *domain.MedicalRecord) error
 }
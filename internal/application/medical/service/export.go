@@ -0,0 +1,116 @@
+package service
+
+import (
+	"time"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// the birth date is written the way an import file may have it, so an
+// exported sheet of patients can be imported again
+const (
+	exportDateFormat = time.DateOnly
+	exportTimeFormat = time.RFC3339
+)
+
+var patientColumns = []string{
+	"identityNumber",
+	"phoneNumber",
+	"name",
+	"birthDate",
+	"gender",
+	"identityCardScanImg",
+	"createdAt",
+}
+
+// patientExport writes the patients of an export as rows of a sheet.
+type patientExport struct {
+	sheet    *sheet.Writer
+	progress func(rows int)
+	rows     int
+}
+
+func (e *patientExport) Write(patient *domain.Patient) error {
+	err := e.sheet.Write(
+		patient.ID,
+		"+"+patient.PhoneNumber,
+		patient.Name,
+		patient.BirthDate.Format(exportDateFormat),
+		patient.Gender,
+		patient.ImgURL,
+		patient.CreatedAt.Format(exportTimeFormat),
+	)
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.progress != nil {
+		e.progress(e.rows)
+	}
+
+	return nil
+}
+
+var recordColumns = []string{
+	"recordId",
+	"identityNumber",
+	"patientName",
+	"patientPhoneNumber",
+	"patientBirthDate",
+	"patientGender",
+	"symptoms",
+	"medications",
+	"amended",
+	"encounterId",
+	"createdByNip",
+	"createdByName",
+	"createdByUserId",
+	"createdAt",
+}
+
+// recordExport writes the medical records of an export as rows of a sheet,
+// with their symptoms and medications as they read after every amendment.
+type recordExport struct {
+	sheet    *sheet.Writer
+	progress func(rows int)
+	rows     int
+}
+
+func (e *recordExport) Write(record *domain.MedicalRecord) error {
+	symptoms, medications := record.Current()
+
+	encounterID := ""
+	if !id.IsZero(record.EncounterID) {
+		encounterID = record.EncounterID.String()
+	}
+
+	err := e.sheet.Write(
+		record.ID.String(),
+		record.PatientID,
+		record.PatientName,
+		"+"+record.PatientPhoneNumber,
+		record.PatientBirthDate.Format(exportDateFormat),
+		record.PatientGender,
+		symptoms,
+		medications,
+		len(record.Amendments) > 0,
+		encounterID,
+		record.StaffNIP,
+		record.StaffName,
+		record.StaffID.String(),
+		record.CreatedAt.Format(exportTimeFormat),
+	)
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.progress != nil {
+		e.progress(e.rows)
+	}
+
+	return nil
+}
@@ -2,15 +2,25 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"slices"
 	"time"
 
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
 	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/medical/patientimport"
 	"github.com/j03hanafi/halo-suster/internal/application/medical/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
+// importBatchSize is how many imported patients are saved in one
+// transaction.
+const importBatchSize = 1000
+
 type MedicalService struct {
 	medicalRepository repository.MedicalRepositoryContract
 	contextTimeout    time.Duration
@@ -23,14 +33,14 @@ func NewMedicalService(timeout time.Duration, medicalRepository repository.Medic
 	}
 }
 
-func (s MedicalService) RecordPatient(ctx context.Context, patient *domain.Patient) error {
+func (s MedicalService) RecordPatient(ctx context.Context, patient *domain.Patient, user *domain.User) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
 	callerInfo := "[MedicalService.RecordPatient]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
 
-	err := s.medicalRepository.RecordPatient(ctx, patient)
+	err := s.medicalRepository.RecordPatient(ctx, patient, user)
 	if err != nil {
 		l.Error("failed to record patient", zap.Error(err))
 		return err
@@ -39,6 +49,150 @@ func (s MedicalService) RecordPatient(ctx context.Context, patient *domain.Patie
 	return nil
 }
 
+func (s MedicalService) UpdatePatient(ctx context.Context, patient *domain.Patient, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.UpdatePatient]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.medicalRepository.UpdatePatient(ctx, patient, user)
+	if err != nil {
+		l.Error("failed to update patient", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ImportPatients registers the patients of an import file. A row that breaks
+// a rule, repeats an earlier row or is already registered is reported and
+// the rest are imported anyway. The rows are saved in batches of
+// importBatchSize, each with its own timeout, so a large file is not bound
+// by the timeout of a single request. progress, when given, is told how many
+// rows are done after every batch.
+func (s MedicalService) ImportPatients(
+	ctx context.Context,
+	rows []patientimport.Row,
+	user *domain.User,
+	report *domain.PatientImport,
+	progress func(rows int),
+) error {
+	callerInfo := "[MedicalService.ImportPatients]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	report.Total = len(rows)
+
+	batch := make(domain.Patients, 0, min(len(rows), importBatchSize))
+	batchRows := make(map[string]int, cap(batch))
+	seen := make(map[string]int, len(rows))
+
+	save := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		batchCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+		defer cancel()
+
+		registered, err := s.medicalRepository.ImportPatients(batchCtx, batch, user)
+		switch {
+		case err == nil:
+			report.Imported += len(batch) - len(registered)
+			for _, patientID := range registered {
+				report.Fail(batchRows[patientID], patientID, domain.ErrDuplicatePatient{}.Error())
+			}
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			// the batch is rolled back on its own, the next one may still go through
+			l.Error("failed to import patients", zap.Int("batchSize", len(batch)), zap.Error(err))
+			for i := range batch {
+				report.Fail(batchRows[batch[i].ID], batch[i].ID, "Patient could not be saved, import the row again")
+			}
+		}
+
+		batch = batch[:0]
+		clear(batchRows)
+		return nil
+	}
+
+	patient := domain.PatientAcquire()
+	defer domain.PatientRelease(patient)
+
+	for i := range rows {
+		row := &rows[i]
+
+		*patient = domain.Patient{}
+		if err := row.Patient(patient); err != nil {
+			errs := multierr.Errors(err)
+			messages := make([]string, 0, len(errs))
+			for _, err = range errs {
+				messages = append(messages, err.Error())
+			}
+			report.Fail(row.Number, row.IdentityNumber, messages...)
+			continue
+		}
+
+		if first, ok := seen[patient.ID]; ok {
+			report.Fail(row.Number, patient.ID, fmt.Sprintf("identityNumber is already on row %d", first))
+			continue
+		}
+		seen[patient.ID] = row.Number
+
+		batch = append(batch, *patient)
+		batchRows[patient.ID] = row.Number
+
+		if len(batch) == importBatchSize {
+			if err := save(); err != nil {
+				return err
+			}
+
+			if progress != nil {
+				progress(i + 1)
+			}
+		}
+	}
+
+	if err := save(); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress(len(rows))
+	}
+
+	// failures are reported in the order of the file
+	slices.SortFunc(report.Errors, func(a, b domain.PatientImportError) int {
+		return a.Row - b.Row
+	})
+
+	l.Info("patients imported",
+		zap.Int("total", report.Total),
+		zap.Int("imported", report.Imported),
+		zap.Int("failed", report.Failed),
+	)
+
+	return nil
+}
+
+// QueueJob queues job to be run in the background by the job module.
+func (s MedicalService) QueueJob(ctx context.Context, job *domain.Job) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.QueueJob]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo), zap.String("kind", job.Kind))
+
+	err := s.medicalRepository.QueueJob(ctx, job)
+	if err != nil {
+		l.Error("failed to queue job", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (s MedicalService) GetPatients(
 	ctx context.Context,
 	filter *domain.FilterPatient,
@@ -59,6 +213,43 @@ func (s MedicalService) GetPatients(
 	return patients, nil
 }
 
+// ExportPatients writes the patients matching filter to w as a sheet in
+// format. Rows are written as they are read, so a failure comes after part of
+// the sheet has been written. progress, when given, is told how many rows
+// have been written.
+func (s MedicalService) ExportPatients(
+	ctx context.Context,
+	filter *domain.FilterPatient,
+	format string,
+	w io.Writer,
+	progress func(rows int),
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.ExportPatients]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	writer, err := sheet.NewWriter(w, format, "Patients", patientColumns)
+	if err != nil {
+		l.Error("failed to create sheet", zap.Error(err))
+		return err
+	}
+
+	err = s.medicalRepository.ExportPatients(ctx, filter, &patientExport{sheet: writer, progress: progress})
+	if err != nil {
+		l.Error("failed to export patients", zap.Error(err))
+		return err
+	}
+
+	if err = writer.Close(); err != nil {
+		l.Error("failed to close sheet", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (s MedicalService) SaveMedicalRecord(ctx context.Context, record *domain.MedicalRecord, user *domain.User) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
@@ -98,4 +289,109 @@ func (s MedicalService) GetMedicalRecords(
 	return records, nil
 }
 
+// ExportMedicalRecords writes the medical records matching filter to w as a
+// sheet in format. Rows are written as they are read, so a failure comes after
+// part of the sheet has been written. progress, when given, is told how many
+// rows have been written.
+func (s MedicalService) ExportMedicalRecords(
+	ctx context.Context,
+	filter *domain.FilterMedicalRecord,
+	format string,
+	w io.Writer,
+	progress func(rows int),
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.ExportMedicalRecords]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	writer, err := sheet.NewWriter(w, format, "Medical Records", recordColumns)
+	if err != nil {
+		l.Error("failed to create sheet", zap.Error(err))
+		return err
+	}
+
+	err = s.medicalRepository.ExportMedicalRecords(ctx, filter, &recordExport{sheet: writer, progress: progress})
+	if err != nil {
+		l.Error("failed to export medical records", zap.Error(err))
+		return err
+	}
+
+	if err = writer.Close(); err != nil {
+		l.Error("failed to close sheet", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s MedicalService) AmendMedicalRecord(
+	ctx context.Context,
+	amendment *domain.MedicalRecordAmendment,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.AmendMedicalRecord]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	amendment.StaffID = user.ID
+	amendment.StaffNIP = user.NIP
+	amendment.StaffName = user.Name
+
+	err := s.medicalRepository.AmendMedicalRecord(ctx, amendment)
+	if err != nil {
+		l.Error("failed to amend medical record", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s MedicalService) AttachToMedicalRecord(
+	ctx context.Context,
+	attachments domain.MedicalRecordAttachments,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.AttachToMedicalRecord]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	for i := range attachments {
+		attachments[i].StaffID = user.ID
+	}
+
+	err := s.medicalRepository.AttachToMedicalRecord(ctx, attachments)
+	if err != nil {
+		l.Error("failed to attach to medical record", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s MedicalService) GetPatientTimeline(
+	ctx context.Context,
+	filter *domain.FilterTimeline,
+	entries domain.TimelineEntries,
+) (domain.TimelineEntries, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicalService.GetPatientTimeline]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	entries, err := s.medicalRepository.GetPatientTimeline(ctx, filter, entries)
+	if err != nil {
+		l.Error("failed to get patient timeline", zap.Error(err))
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 var _ MedicalServiceContract = (*MedicalService)(nil)
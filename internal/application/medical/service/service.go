@@ -2,17 +2,54 @@ package service
 
 import (
 	"context"
+	"io"
+
+	"github.com/j03hanafi/halo-suster/internal/application/medical/patientimport"
 
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
 type MedicalServiceContract interface {
-	RecordPatient(ctx context.Context, patient *domain.Patient) error
+	RecordPatient(ctx context.Context, patient *domain.Patient, user *domain.User) error
+	UpdatePatient(ctx context.Context, patient *domain.Patient, user *domain.User) error
+	ImportPatients(
+		ctx context.Context,
+		rows []patientimport.Row,
+		user *domain.User,
+		report *domain.PatientImport,
+		progress func(rows int),
+	) error
+	QueueJob(ctx context.Context, job *domain.Job) error
 	GetPatients(ctx context.Context, filter *domain.FilterPatient, patients domain.Patients) (domain.Patients, error)
+	ExportPatients(
+		ctx context.Context,
+		filter *domain.FilterPatient,
+		format string,
+		w io.Writer,
+		progress func(rows int),
+	) error
 	SaveMedicalRecord(ctx context.Context, record *domain.MedicalRecord, user *domain.User) error
 	GetMedicalRecords(
 		ctx context.Context,
 		filter *domain.FilterMedicalRecord,
 		records domain.MedicalRecords,
 	) (domain.MedicalRecords, error)
+	ExportMedicalRecords(
+		ctx context.Context,
+		filter *domain.FilterMedicalRecord,
+		format string,
+		w io.Writer,
+		progress func(rows int),
+	) error
+	AmendMedicalRecord(ctx context.Context, amendment *domain.MedicalRecordAmendment, user *domain.User) error
+	AttachToMedicalRecord(
+		ctx context.Context,
+		attachments domain.MedicalRecordAttachments,
+		user *domain.User,
+	) error
+	GetPatientTimeline(
+		ctx context.Context,
+		filter *domain.FilterTimeline,
+		entries domain.TimelineEntries,
+	) (domain.TimelineEntries, error)
 }
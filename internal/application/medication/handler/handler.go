@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/medication/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	orderIDFromParam = "id"
+	doseIDFromParam  = "id"
+)
+
+type medicationHandler struct {
+	medicationService service.MedicationServiceContract
+}
+
+func NewMedicationHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	medicationService service.MedicationServiceContract,
+) {
+	handler := medicationHandler{
+		medicationService: medicationService,
+	}
+
+	medicationRouter := router.Group("/medication", jwtMiddleware)
+	medicationRouter.Post("/order", handler.CreateOrder)
+	medicationRouter.Get("/order", handler.GetOrders)
+	medicationRouter.Post("/order/:"+orderIDFromParam+"/discontinue", handler.DiscontinueOrder)
+	medicationRouter.Get("/dose", handler.GetDoses)
+	medicationRouter.Get("/dose/overdue", handler.GetOverdueDoses)
+	medicationRouter.Post("/dose/:"+doseIDFromParam+"/record", handler.RecordDose)
+}
+
+func (h medicationHandler) CreateOrder(c *fiber.Ctx) error {
+	callerInfo := "[medicationHandler.CreateOrder]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createOrderReqAcquire()
+	defer createOrderReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	order := domain.MedicationOrderAcquire()
+	defer domain.MedicationOrderRelease(order)
+
+	order.PatientID = string(*req.IdentityNumber)
+	order.Medication = req.Medication
+	order.Dose = req.Dose
+	order.Route = req.Route
+	order.Instructions = req.Instructions
+	order.StartAt = req.startAt
+	order.EndAt = req.endAt
+	order.IntervalHours = req.IntervalHours
+
+	err := h.medicationService.CreateOrder(userCtx, order, user)
+	if err != nil {
+		l.Error("failed to create medication order", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Medication order created successfully"
+	res.Data = fiber.Map{
+		"order": newOrderRes(order),
+		"doses": order.DoseCount(),
+	}
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h medicationHandler) DiscontinueOrder(c *fiber.Ctx) error {
+	callerInfo := "[medicationHandler.DiscontinueOrder]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	orderID, err := ulid.Parse(c.Params(orderIDFromParam))
+	if err != nil {
+		l.Error("error parsing orderIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	order := domain.MedicationOrderAcquire()
+	defer domain.MedicationOrderRelease(order)
+
+	order.ID = orderID
+
+	err = h.medicationService.DiscontinueOrder(userCtx, order, user)
+	if err != nil {
+		l.Error("failed to discontinue medication order", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Medication order discontinued successfully"
+	res.Data = newOrderRes(order)
+
+	return c.JSON(res)
+}
+
+func (h medicationHandler) GetOrders(c *fiber.Ctx) error {
+	callerInfo := "[medicationHandler.GetOrders]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryOrderAcquire()
+	defer queryOrderRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterMedicationOrderAcquire()
+	defer domain.FilterMedicationOrderRelease(filter)
+
+	filter.ID = query.orderID
+	filter.PatientID = query.patientID
+	filter.Status = query.Status
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	orders := domain.MedicationOrdersAcquire()
+	defer domain.MedicationOrdersRelease(orders)
+
+	orders, err := h.medicationService.GetOrders(userCtx, filter, orders)
+	if err != nil {
+		l.Error("failed to get medication orders", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Medication orders retrieved successfully"
+
+	ordersRes := getOrdersResAcquire()
+	defer getOrdersResRelease(ordersRes)
+
+	for i := range orders {
+		ordersRes = append(ordersRes, newOrderRes(&orders[i]))
+	}
+
+	res.Data = ordersRes
+
+	return c.JSON(res)
+}
+
+func (h medicationHandler) RecordDose(c *fiber.Ctx) error {
+	callerInfo := "[medicationHandler.RecordDose]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	doseID, err := ulid.Parse(c.Params(doseIDFromParam))
+	if err != nil {
+		l.Error("error parsing doseIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := recordDoseReqAcquire()
+	defer recordDoseReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	dose := domain.MedicationDoseAcquire()
+	defer domain.MedicationDoseRelease(dose)
+
+	dose.ID = doseID
+	dose.Status = req.Status
+	dose.AdministeredAt = req.administeredAt
+	dose.Reason = req.Reason
+	dose.Note = req.Note
+
+	err = h.medicationService.RecordDose(userCtx, dose, user)
+	if err != nil {
+		l.Error("failed to record medication dose", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Medication dose recorded successfully"
+	res.Data = newDoseRes(dose, time.Now().Add(-domain.DoseGracePeriod))
+
+	return c.JSON(res)
+}
+
+func (h medicationHandler) GetDoses(c *fiber.Ctx) error {
+	callerInfo := "[medicationHandler.GetDoses]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryDoseAcquire()
+	defer queryDoseRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterMedicationDoseAcquire()
+	defer domain.FilterMedicationDoseRelease(filter)
+
+	query.toFilter(filter)
+
+	return h.getDoses(c, l, filter, "Medication doses retrieved successfully")
+}
+
+// GetOverdueDoses lists doses still due past the grace period, a ward can be
+// picked with wardId to see only the patients lying in it.
+func (h medicationHandler) GetOverdueDoses(c *fiber.Ctx) error {
+	callerInfo := "[medicationHandler.GetOverdueDoses]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryDoseAcquire()
+	defer queryDoseRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+	query.Overdue = true
+
+	filter := domain.FilterMedicationDoseAcquire()
+	defer domain.FilterMedicationDoseRelease(filter)
+
+	query.toFilter(filter)
+
+	return h.getDoses(c, l, filter, "Overdue medication doses retrieved successfully")
+}
+
+func (h medicationHandler) getDoses(
+	c *fiber.Ctx,
+	l *zap.Logger,
+	filter *domain.FilterMedicationDose,
+	message string,
+) error {
+	doses := domain.MedicationDosesAcquire()
+	defer domain.MedicationDosesRelease(doses)
+
+	doses, err := h.medicationService.GetDoses(c.UserContext(), filter, doses)
+	if err != nil {
+		l.Error("failed to get medication doses", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+
+	overdueBefore := time.Now().Add(-domain.DoseGracePeriod)
+	dosesRes := getDosesResAcquire()
+	defer getDosesResRelease(dosesRes)
+
+	for i := range doses {
+		dosesRes = append(dosesRes, newDoseRes(&doses[i], overdueBefore))
+	}
+
+	res.Data = dosesRes
+
+	return c.JSON(res)
+}
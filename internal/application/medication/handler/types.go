@@ -0,0 +1,518 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/timeparam"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	dateFormat = "2006-01-02T15:04:05.999Z"
+
+	medicationInitCap = 5
+)
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+var createOrderReqPool = sync.Pool{
+	New: func() any {
+		return new(createOrderReq)
+	},
+}
+
+func createOrderReqAcquire() *createOrderReq {
+	return createOrderReqPool.Get().(*createOrderReq)
+}
+
+func createOrderReqRelease(t *createOrderReq) {
+	*t = createOrderReq{}
+	createOrderReqPool.Put(t)
+}
+
+// createOrderReq schedules a dose every IntervalHours from StartAt, an
+// order without EndAt is a single dose.
+type createOrderReq struct {
+	IdentityNumber *idNumber `json:"identityNumber"`
+	Medication     string    `json:"medication"`
+	Dose           string    `json:"dose"`
+	Route          string    `json:"route"`
+	Instructions   string    `json:"instructions"`
+	StartAt        string    `json:"startAt"`
+	startAt        time.Time
+	EndAt          string `json:"endAt"`
+	endAt          time.Time
+	IntervalHours  int `json:"intervalHours"`
+}
+
+func (r *createOrderReq) validate() error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	if r.Medication == "" {
+		errs = multierr.Append(errs, errors.New("medication is required"))
+	} else if len(r.Medication) > 200 {
+		errs = multierr.Append(errs, errors.New("medication must have 1 to 200 characters"))
+	}
+
+	if r.Dose == "" {
+		errs = multierr.Append(errs, errors.New("dose is required"))
+	} else if len(r.Dose) > 100 {
+		errs = multierr.Append(errs, errors.New("dose must have 1 to 100 characters"))
+	}
+
+	if !slices.Contains(domain.MedicationRoutes, r.Route) {
+		errs = multierr.Append(errs, errors.New("route must be one of oral, iv, im, sc, topical, inhalation or other"))
+	}
+
+	if len(r.Instructions) > 2000 {
+		errs = multierr.Append(errs, errors.New("instructions must have at most 2000 characters"))
+	}
+
+	if r.IntervalHours < 1 || r.IntervalHours > 168 {
+		errs = multierr.Append(errs, errors.New("intervalHours must be between 1 and 168"))
+	}
+
+	startAt, err := time.Parse(time.RFC3339Nano, r.StartAt)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("startAt must be in ISO 8601 format"))
+	}
+	r.startAt = startAt.In(time.Local)
+
+	r.endAt = r.startAt
+	if r.EndAt != "" {
+		endAt, err := time.Parse(time.RFC3339Nano, r.EndAt)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("endAt must be in ISO 8601 format"))
+		} else if endAt.Before(startAt) {
+			errs = multierr.Append(errs, errors.New("endAt must not be before startAt"))
+		}
+		r.endAt = endAt.In(time.Local)
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var recordDoseReqPool = sync.Pool{
+	New: func() any {
+		return new(recordDoseReq)
+	},
+}
+
+func recordDoseReqAcquire() *recordDoseReq {
+	return recordDoseReqPool.Get().(*recordDoseReq)
+}
+
+func recordDoseReqRelease(t *recordDoseReq) {
+	*t = recordDoseReq{}
+	recordDoseReqPool.Put(t)
+}
+
+// recordDoseReq needs a reason whenever the dose was not given.
+type recordDoseReq struct {
+	Status         string `json:"status"`
+	AdministeredAt string `json:"administeredAt"`
+	administeredAt time.Time
+	Reason         string `json:"reason"`
+	Note           string `json:"note"`
+}
+
+func (r *recordDoseReq) validate() error {
+	var errs error
+
+	switch r.Status {
+	case domain.DoseGiven:
+	case domain.DoseHeld, domain.DoseRefused:
+		if r.Reason == "" {
+			errs = multierr.Append(errs, errors.New("reason is required when a dose is held or refused"))
+		}
+	default:
+		errs = multierr.Append(errs, errors.New("status must be one of given, held or refused"))
+	}
+
+	if len(r.Reason) > 200 {
+		errs = multierr.Append(errs, errors.New("reason must have at most 200 characters"))
+	}
+
+	if len(r.Note) > 2000 {
+		errs = multierr.Append(errs, errors.New("note must have at most 2000 characters"))
+	}
+
+	if r.AdministeredAt != "" {
+		administeredAt, err := time.Parse(time.RFC3339Nano, r.AdministeredAt)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("administeredAt must be in ISO 8601 format"))
+		} else if administeredAt.After(time.Now()) {
+			errs = multierr.Append(errs, errors.New("administeredAt must not be in the future"))
+		}
+		r.administeredAt = administeredAt.In(time.Local)
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var queryOrderPool = sync.Pool{
+	New: func() any {
+		return new(queryOrder)
+	},
+}
+
+func queryOrderAcquire() *queryOrder {
+	return queryOrderPool.Get().(*queryOrder)
+}
+
+func queryOrderRelease(t *queryOrder) {
+	*t = queryOrder{}
+	queryOrderPool.Put(t)
+}
+
+type queryOrder struct {
+	OrderID        string `query:"orderId"`
+	orderID        ulid.ULID
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	Status         string `query:"status"`
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+}
+
+func (q *queryOrder) validate() {
+	if q.OrderID != "" {
+		q.orderID, _ = ulid.Parse(q.OrderID)
+	}
+
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.Status != domain.MedicationOrderActive && q.Status != domain.MedicationOrderDiscontinued {
+		q.Status = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+var queryDosePool = sync.Pool{
+	New: func() any {
+		return new(queryDose)
+	},
+}
+
+func queryDoseAcquire() *queryDose {
+	return queryDosePool.Get().(*queryDose)
+}
+
+func queryDoseRelease(t *queryDose) {
+	*t = queryDose{}
+	queryDosePool.Put(t)
+}
+
+type queryDose struct {
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	OrderID        string `query:"orderId"`
+	orderID        ulid.ULID
+	WardID         string `query:"wardId"`
+	wardID         ulid.ULID
+	Status         string `query:"status"`
+	DueFrom        string `query:"dueFrom"`
+	dueFrom        time.Time
+	DueTo          string `query:"dueTo"`
+	dueTo          time.Time
+	Overdue        bool `query:"overdue"`
+	Limit          int  `query:"limit"`
+	Offset         int  `query:"offset"`
+}
+
+func (q *queryDose) validate() {
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.OrderID != "" {
+		q.orderID, _ = ulid.Parse(q.OrderID)
+	}
+
+	if q.WardID != "" {
+		q.wardID, _ = ulid.Parse(q.WardID)
+	}
+
+	switch q.Status {
+	case domain.DoseDue, domain.DoseGiven, domain.DoseHeld, domain.DoseRefused, domain.DoseCancelled:
+	default:
+		q.Status = ""
+	}
+
+	if dueFrom, _, ok := timeparam.Parse(q.DueFrom); ok {
+		q.dueFrom = dueFrom
+	}
+
+	// a bare date includes the whole day
+	if dueTo, dateOnly, ok := timeparam.Parse(q.DueTo); ok {
+		if dateOnly {
+			dueTo = dueTo.AddDate(0, 0, 1)
+		}
+		q.dueTo = dueTo
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *queryDose) toFilter(filter *domain.FilterMedicationDose) {
+	filter.PatientID = q.patientID
+	filter.OrderID = q.orderID
+	filter.WardID = q.wardID
+	filter.Status = q.Status
+	filter.DueFrom = q.dueFrom
+	filter.DueTo = q.dueTo
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+
+	if q.Overdue {
+		filter.OverdueBefore = time.Now().Add(-domain.DoseGracePeriod)
+	}
+}
+
+type patientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+}
+
+type staffRes struct {
+	UserID ulid.ULID `json:"userId"`
+	Name   string    `json:"name"`
+}
+
+type orderRes struct {
+	OrderID        ulid.ULID  `json:"orderId"`
+	Patient        patientRes `json:"patient"`
+	Medication     string     `json:"medication"`
+	Dose           string     `json:"dose"`
+	Route          string     `json:"route"`
+	Instructions   string     `json:"instructions"`
+	StartAt        string     `json:"startAt"`
+	EndAt          string     `json:"endAt"`
+	IntervalHours  int        `json:"intervalHours"`
+	Status         string     `json:"status"`
+	OrderedBy      staffRes   `json:"orderedBy"`
+	OrderedAt      string     `json:"orderedAt"`
+	DiscontinuedAt string     `json:"discontinuedAt,omitempty"`
+}
+
+func newOrderRes(order *domain.MedicationOrder) orderRes {
+	res := orderRes{
+		OrderID: order.ID,
+		Patient: patientRes{
+			IdentityNumber: idNumber(order.PatientID),
+			Name:           order.PatientName,
+		},
+		Medication:    order.Medication,
+		Dose:          order.Dose,
+		Route:         order.Route,
+		Instructions:  order.Instructions,
+		StartAt:       order.StartAt.Format(dateFormat),
+		EndAt:         order.EndAt.Format(dateFormat),
+		IntervalHours: order.IntervalHours,
+		Status:        order.Status,
+		OrderedBy: staffRes{
+			UserID: order.OrderedBy,
+			Name:   order.OrderedByName,
+		},
+		OrderedAt: order.OrderedAt.Format(dateFormat),
+	}
+	if !order.DiscontinuedAt.IsZero() {
+		res.DiscontinuedAt = order.DiscontinuedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+type doseLocationRes struct {
+	WardID   ulid.ULID `json:"wardId"`
+	WardCode string    `json:"wardCode"`
+	BedCode  string    `json:"bedCode"`
+}
+
+type doseRes struct {
+	DoseID         ulid.ULID        `json:"doseId"`
+	OrderID        ulid.ULID        `json:"orderId"`
+	Patient        patientRes       `json:"patient"`
+	Location       *doseLocationRes `json:"location"`
+	Medication     string           `json:"medication"`
+	Dose           string           `json:"dose"`
+	Route          string           `json:"route"`
+	DueAt          string           `json:"dueAt"`
+	Status         string           `json:"status"`
+	Overdue        bool             `json:"overdue"`
+	RecordedBy     *staffRes        `json:"recordedBy"`
+	AdministeredAt string           `json:"administeredAt,omitempty"`
+	Reason         string           `json:"reason,omitempty"`
+	Note           string           `json:"note,omitempty"`
+}
+
+func newDoseRes(dose *domain.MedicationDose, overdueBefore time.Time) doseRes {
+	res := doseRes{
+		DoseID:  dose.ID,
+		OrderID: dose.OrderID,
+		Patient: patientRes{
+			IdentityNumber: idNumber(dose.PatientID),
+			Name:           dose.PatientName,
+		},
+		Medication: dose.Medication,
+		Dose:       dose.Dose,
+		Route:      dose.Route,
+		DueAt:      dose.DueAt.Format(dateFormat),
+		Status:     dose.Status,
+		Overdue:    dose.Status == domain.DoseDue && dose.DueAt.Before(overdueBefore),
+		Reason:     dose.Reason,
+		Note:       dose.Note,
+	}
+	if !id.IsZero(dose.WardID) {
+		res.Location = &doseLocationRes{
+			WardID:   dose.WardID,
+			WardCode: dose.WardCode,
+			BedCode:  dose.BedCode,
+		}
+	}
+	if !id.IsZero(dose.RecordedBy) {
+		res.RecordedBy = &staffRes{
+			UserID: dose.RecordedBy,
+			Name:   dose.RecordedByName,
+		}
+		res.AdministeredAt = dose.AdministeredAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+var getOrdersResPool = sync.Pool{
+	New: func() any {
+		return make(getOrdersRes, 0, medicationInitCap)
+	},
+}
+
+func getOrdersResAcquire() getOrdersRes {
+	return getOrdersResPool.Get().(getOrdersRes)
+}
+
+func getOrdersResRelease(t getOrdersRes) {
+	t = t[:0]
+	getOrdersResPool.Put(t) // nolint:staticcheck
+}
+
+type getOrdersRes []orderRes
+
+var getDosesResPool = sync.Pool{
+	New: func() any {
+		return make(getDosesRes, 0, medicationInitCap)
+	},
+}
+
+func getDosesResAcquire() getDosesRes {
+	return getDosesResPool.Get().(getDosesRes)
+}
+
+func getDosesResRelease(t getDosesRes) {
+	t = t[:0]
+	getDosesResPool.Put(t) // nolint:staticcheck
+}
+
+type getDosesRes []doseRes
@@ -0,0 +1,21 @@
+package medication
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/medication/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/medication/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/medication/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	medicationRepository := repository.NewMedicationRepository(db)
+	medicationService := service.NewMedicationService(ctxTimeout, medicationRepository)
+	handler.NewMedicationHandler(router, jwtMiddleware, medicationService)
+}
@@ -0,0 +1,615 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	orderColumns = `o.id, o.patient_id, p.name, o.medication, o.dose, o.route, o.instructions, o.start_at, o.end_at, 
+		o.interval_hours, o.status, o.ordered_by, o.ordered_by_name, o.ordered_at, o.discontinued_by, o.discontinued_at`
+	orderTables = ` FROM medication_orders o JOIN patients p ON p.id = o.patient_id`
+
+	doseColumns = `d.id, d.order_id, d.patient_id, p.name, o.medication, o.dose, o.route, d.due_at, d.status, 
+		d.recorded_by, d.recorded_by_name, d.administered_at, d.reason, d.note, w.id, w.code, b.code`
+	// doses are shown in the bed the patient lies in now, not where they were
+	// when the dose was due
+	doseTables = ` FROM medication_doses d 
+		JOIN medication_orders o ON o.id = d.order_id 
+		JOIN patients p ON p.id = d.patient_id
+		LEFT JOIN bed_assignments a ON a.patient_id = d.patient_id AND a.ended_at IS NULL
+		LEFT JOIN beds b ON b.id = a.bed_id
+		LEFT JOIN wards w ON w.id = b.ward_id`
+)
+
+type MedicationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMedicationRepository(db *pgxpool.Pool) *MedicationRepository {
+	return &MedicationRepository{db: db}
+}
+
+// CreateOrder saves the order together with a due dose for every time in
+// schedule.
+func (r MedicationRepository) CreateOrder(
+	ctx context.Context,
+	order *domain.MedicationOrder,
+	schedule []time.Time,
+	user *domain.User,
+) error {
+	callerInfo := "[MedicationRepository.CreateOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	order.ID = id.New()
+	order.Status = domain.MedicationOrderActive
+	order.OrderedBy = user.ID
+	order.OrderedByName = user.Name
+	order.OrderedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO medication_orders (
+				id, patient_id, medication, dose, route, instructions, start_at, end_at, interval_hours, status, 
+				ordered_by, ordered_by_name, ordered_at
+			)
+			SELECT @id, p.id, @medication, @dose, @route, @instructions, @start_at, @end_at, @interval_hours, @status, 
+				@ordered_by, @ordered_by_name, @ordered_at
+			FROM patients p WHERE p.id = @patient_id
+			RETURNING patient_id
+		)
+		SELECT p.name FROM inserted i JOIN patients p ON p.id = i.patient_id`
+	args := pgx.NamedArgs{
+		"id":              order.ID,
+		"patient_id":      order.PatientID,
+		"medication":      order.Medication,
+		"dose":            order.Dose,
+		"route":           order.Route,
+		"instructions":    order.Instructions,
+		"start_at":        order.StartAt,
+		"end_at":          order.EndAt,
+		"interval_hours":  order.IntervalHours,
+		"status":          order.Status,
+		"ordered_by":      order.OrderedBy,
+		"ordered_by_name": order.OrderedByName,
+		"ordered_at":      order.OrderedAt,
+	}
+
+	if err = tx.QueryRow(ctx, insertQuery, args).Scan(&order.PatientName); err != nil {
+		l.Error("failed to create medication order", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+		return err
+	}
+
+	doses := make([][]any, 0, len(schedule))
+	for _, dueAt := range schedule {
+		doses = append(doses, []any{id.New().Bytes(), order.ID.Bytes(), order.PatientID, dueAt, domain.DoseDue})
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"medication_doses"},
+		[]string{"id", "order_id", "patient_id", "due_at", "status"},
+		pgx.CopyFromRows(doses),
+	)
+	if err != nil {
+		l.Error("failed to schedule medication doses", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, order.PatientID, domain.PatientEventMedicationOrdered, user, map[string]any{
+		"orderId":       order.ID.String(),
+		"medication":    order.Medication,
+		"dose":          order.Dose,
+		"route":         order.Route,
+		"intervalHours": order.IntervalHours,
+		"doses":         len(schedule),
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DiscontinueOrder stops the order and cancels its doses that are not due
+// yet, doses already due stay open so missed administrations remain visible.
+func (r MedicationRepository) DiscontinueOrder(
+	ctx context.Context,
+	order *domain.MedicationOrder,
+	user *domain.User,
+) error {
+	callerInfo := "[MedicationRepository.DiscontinueOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	order.Status = domain.MedicationOrderDiscontinued
+	order.DiscontinuedBy = user.ID
+	order.DiscontinuedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	updateQuery := `UPDATE medication_orders o 
+		SET status = @discontinued, discontinued_by = @discontinued_by, discontinued_at = @discontinued_at 
+		FROM patients p WHERE p.id = o.patient_id AND o.id = @id AND o.status = @active
+		RETURNING o.patient_id, p.name, o.medication, o.dose, o.route, o.instructions, o.start_at, o.end_at, 
+			o.interval_hours, o.ordered_by, o.ordered_by_name, o.ordered_at`
+	args := pgx.NamedArgs{
+		"id":              order.ID,
+		"active":          domain.MedicationOrderActive,
+		"discontinued":    order.Status,
+		"discontinued_by": order.DiscontinuedBy,
+		"discontinued_at": order.DiscontinuedAt,
+	}
+
+	err = tx.QueryRow(ctx, updateQuery, args).Scan(
+		&order.PatientID,
+		&order.PatientName,
+		&order.Medication,
+		&order.Dose,
+		&order.Route,
+		&order.Instructions,
+		&order.StartAt,
+		&order.EndAt,
+		&order.IntervalHours,
+		&order.OrderedBy,
+		&order.OrderedByName,
+		&order.OrderedAt,
+	)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			l.Error("failed to discontinue medication order", zap.Error(err))
+			return err
+		}
+
+		var exists bool
+		existsQuery := `SELECT EXISTS (SELECT 1 FROM medication_orders WHERE id = @id)`
+		if err = tx.QueryRow(ctx, existsQuery, pgx.NamedArgs{"id": order.ID}).Scan(&exists); err != nil {
+			l.Error("failed to check medication order", zap.Error(err))
+			return err
+		}
+
+		if exists {
+			return new(domain.ErrMedicationOrderDiscontinued)
+		}
+		return new(domain.ErrMedicationOrderNotFound)
+	}
+
+	cancelQuery := `UPDATE medication_doses SET status = @cancelled 
+		WHERE order_id = @order_id AND status = @due AND due_at > @now`
+	cancelArgs := pgx.NamedArgs{
+		"order_id":  order.ID,
+		"cancelled": domain.DoseCancelled,
+		"due":       domain.DoseDue,
+		"now":       order.DiscontinuedAt,
+	}
+
+	if _, err = tx.Exec(ctx, cancelQuery, cancelArgs); err != nil {
+		l.Error("failed to cancel medication doses", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, order.PatientID, domain.PatientEventMedicationDiscontinued, user, map[string]any{
+		"orderId":    order.ID.String(),
+		"medication": order.Medication,
+		"dose":       order.Dose,
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r MedicationRepository) GetOrders(
+	ctx context.Context,
+	filter *domain.FilterMedicationOrder,
+	orders domain.MedicationOrders,
+) (domain.MedicationOrders, error) {
+	callerInfo := "[MedicationRepository.GetOrders]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterOrder(filter)
+	getQuery := `SELECT ` + orderColumns + orderTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get medication orders", zap.Error(err))
+		return orders, err
+	}
+
+	dOrder := domain.MedicationOrderAcquire()
+	defer domain.MedicationOrderRelease(dOrder)
+	var discontinuedAt *time.Time
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dOrder.ID,
+			&dOrder.PatientID,
+			&dOrder.PatientName,
+			&dOrder.Medication,
+			&dOrder.Dose,
+			&dOrder.Route,
+			&dOrder.Instructions,
+			&dOrder.StartAt,
+			&dOrder.EndAt,
+			&dOrder.IntervalHours,
+			&dOrder.Status,
+			&dOrder.OrderedBy,
+			&dOrder.OrderedByName,
+			&dOrder.OrderedAt,
+			&dOrder.DiscontinuedBy,
+			&discontinuedAt,
+		},
+		func() error {
+			dOrder.DiscontinuedAt = time.Time{}
+			if discontinuedAt != nil {
+				dOrder.DiscontinuedAt = *discontinuedAt
+			}
+			orders = append(orders, *dOrder)
+			dOrder.DiscontinuedBy = ulid.ULID{}
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get medication orders", zap.Error(err))
+		return orders, err
+	}
+
+	return orders, nil
+}
+
+// RecordDose records whether a due dose was given, held or refused, a dose
+// can only be recorded once.
+func (r MedicationRepository) RecordDose(ctx context.Context, dose *domain.MedicationDose, user *domain.User) error {
+	callerInfo := "[MedicationRepository.RecordDose]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	recorded := domain.MedicationDoseAcquire()
+	defer domain.MedicationDoseRelease(recorded)
+
+	selectQuery := `SELECT ` + doseColumns + doseTables + ` WHERE d.id = @id FOR UPDATE OF d`
+
+	err = r.scanDose(tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": dose.ID}), recorded)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrMedicationDoseNotFound)
+		}
+
+		l.Error("failed to get medication dose", zap.Error(err))
+		return err
+	}
+
+	if recorded.Status != domain.DoseDue {
+		return new(domain.ErrMedicationDoseRecorded)
+	}
+
+	recorded.Status = dose.Status
+	recorded.RecordedBy = user.ID
+	recorded.RecordedByName = user.Name
+	recorded.AdministeredAt = dose.AdministeredAt
+	recorded.Reason = dose.Reason
+	recorded.Note = dose.Note
+	*dose = *recorded
+
+	updateQuery := `UPDATE medication_doses SET status = @status, recorded_by = @recorded_by, 
+		recorded_by_name = @recorded_by_name, administered_at = @administered_at, reason = @reason, note = @note 
+		WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":               dose.ID,
+		"status":           dose.Status,
+		"recorded_by":      dose.RecordedBy,
+		"recorded_by_name": dose.RecordedByName,
+		"administered_at":  dose.AdministeredAt,
+		"reason":           dose.Reason,
+		"note":             dose.Note,
+	}
+
+	if _, err = tx.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to record medication dose", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, dose.PatientID, domain.PatientEventMedicationAdministered, user, map[string]any{
+		"doseId":         dose.ID.String(),
+		"orderId":        dose.OrderID.String(),
+		"medication":     dose.Medication,
+		"dose":           dose.Dose,
+		"status":         dose.Status,
+		"dueAt":          dose.DueAt,
+		"administeredAt": dose.AdministeredAt,
+		"reason":         dose.Reason,
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r MedicationRepository) GetDoses(
+	ctx context.Context,
+	filter *domain.FilterMedicationDose,
+	doses domain.MedicationDoses,
+) (domain.MedicationDoses, error) {
+	callerInfo := "[MedicationRepository.GetDoses]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterDose(filter)
+	getQuery := `SELECT ` + doseColumns + doseTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get medication doses", zap.Error(err))
+		return doses, err
+	}
+	defer rows.Close()
+
+	dDose := domain.MedicationDoseAcquire()
+	defer domain.MedicationDoseRelease(dDose)
+
+	for rows.Next() {
+		if err = r.scanDose(rows, dDose); err != nil {
+			l.Error("failed to scan medication dose", zap.Error(err))
+			return doses, err
+		}
+		doses = append(doses, *dDose)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get medication doses", zap.Error(err))
+		return doses, err
+	}
+
+	return doses, nil
+}
+
+func (r MedicationRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	patientID, eventType string,
+	user *domain.User,
+	data map[string]any,
+) error {
+	event, err := patientevent.New(patientID, eventType, user, data)
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+func (r MedicationRepository) scanDose(row pgx.Row, dose *domain.MedicationDose) error {
+	var (
+		recordedByName, reason, note, wardCode, bedCode *string
+		administeredAt                                  *time.Time
+	)
+
+	dose.RecordedBy, dose.WardID = ulid.ULID{}, ulid.ULID{}
+
+	err := row.Scan(
+		&dose.ID,
+		&dose.OrderID,
+		&dose.PatientID,
+		&dose.PatientName,
+		&dose.Medication,
+		&dose.Dose,
+		&dose.Route,
+		&dose.DueAt,
+		&dose.Status,
+		&dose.RecordedBy,
+		&recordedByName,
+		&administeredAt,
+		&reason,
+		&note,
+		&dose.WardID,
+		&wardCode,
+		&bedCode,
+	)
+	if err != nil {
+		return err
+	}
+
+	dose.RecordedByName, dose.Reason, dose.Note, dose.WardCode, dose.BedCode = "", "", "", "", ""
+	dose.AdministeredAt = time.Time{}
+
+	if recordedByName != nil {
+		dose.RecordedByName = *recordedByName
+	}
+	if administeredAt != nil {
+		dose.AdministeredAt = *administeredAt
+	}
+	if reason != nil {
+		dose.Reason = *reason
+	}
+	if note != nil {
+		dose.Note = *note
+	}
+	if wardCode != nil {
+		dose.WardCode = *wardCode
+	}
+	if bedCode != nil {
+		dose.BedCode = *bedCode
+	}
+
+	return nil
+}
+
+func (r MedicationRepository) filterOrder(filter *domain.FilterMedicationOrder) (string, pgx.NamedArgs) {
+	const totalConditions = 3
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "o.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "o.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "o.status = @status")
+		params["status"] = filter.Status
+	}
+
+	order := " ORDER BY o.ordered_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+func (r MedicationRepository) filterDose(filter *domain.FilterMedicationDose) (string, pgx.NamedArgs) {
+	const totalConditions = 7
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "d.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.OrderID) {
+		conditions = append(conditions, "d.order_id = @order_id")
+		params["order_id"] = filter.OrderID
+	}
+
+	if !id.IsZero(filter.WardID) {
+		conditions = append(conditions, "b.ward_id = @ward_id")
+		params["ward_id"] = filter.WardID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "d.status = @status")
+		params["status"] = filter.Status
+	}
+
+	if !filter.DueFrom.IsZero() {
+		conditions = append(conditions, "d.due_at >= @due_from")
+		params["due_from"] = filter.DueFrom
+	}
+
+	if !filter.DueTo.IsZero() {
+		conditions = append(conditions, "d.due_at < @due_to")
+		params["due_to"] = filter.DueTo
+	}
+
+	if !filter.OverdueBefore.IsZero() {
+		conditions = append(conditions, "d.status = @overdue_status AND d.due_at < @overdue_before")
+		params["overdue_status"] = domain.DoseDue
+		params["overdue_before"] = filter.OverdueBefore
+	}
+
+	order := " ORDER BY d.due_at ASC, p.name ASC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ MedicationRepositoryContract = (*MedicationRepository)(nil)
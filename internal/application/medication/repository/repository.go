@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type MedicationRepositoryContract interface {
+	CreateOrder(ctx context.Context, order *domain.MedicationOrder, schedule []time.Time, user *domain.User) error
+	DiscontinueOrder(ctx context.Context, order *domain.MedicationOrder, user *domain.User) error
+	GetOrders(
+		ctx context.Context,
+		filter *domain.FilterMedicationOrder,
+		orders domain.MedicationOrders,
+	) (domain.MedicationOrders, error)
+	RecordDose(ctx context.Context, dose *domain.MedicationDose, user *domain.User) error
+	GetDoses(
+		ctx context.Context,
+		filter *domain.FilterMedicationDose,
+		doses domain.MedicationDoses,
+	) (domain.MedicationDoses, error)
+}
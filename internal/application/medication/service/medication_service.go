@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/medication/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type MedicationService struct {
+	medicationRepository repository.MedicationRepositoryContract
+	contextTimeout       time.Duration
+}
+
+func NewMedicationService(
+	timeout time.Duration,
+	medicationRepository repository.MedicationRepositoryContract,
+) *MedicationService {
+	return &MedicationService{
+		medicationRepository: medicationRepository,
+		contextTimeout:       timeout,
+	}
+}
+
+func (s MedicationService) CreateOrder(ctx context.Context, order *domain.MedicationOrder, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicationService.CreateOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if order.DoseCount() > domain.MaxDosesPerOrder {
+		return new(domain.ErrTooManyDoses)
+	}
+
+	err := s.medicationRepository.CreateOrder(ctx, order, order.Schedule(), user)
+	if err != nil {
+		l.Error("failed to create medication order", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s MedicationService) DiscontinueOrder(
+	ctx context.Context,
+	order *domain.MedicationOrder,
+	user *domain.User,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicationService.DiscontinueOrder]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.medicationRepository.DiscontinueOrder(ctx, order, user)
+	if err != nil {
+		l.Error("failed to discontinue medication order", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s MedicationService) GetOrders(
+	ctx context.Context,
+	filter *domain.FilterMedicationOrder,
+	orders domain.MedicationOrders,
+) (domain.MedicationOrders, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicationService.GetOrders]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	orders, err := s.medicationRepository.GetOrders(ctx, filter, orders)
+	if err != nil {
+		l.Error("failed to get medication orders", zap.Error(err))
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (s MedicationService) RecordDose(ctx context.Context, dose *domain.MedicationDose, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicationService.RecordDose]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if dose.AdministeredAt.IsZero() {
+		dose.AdministeredAt = time.Now()
+	}
+
+	err := s.medicationRepository.RecordDose(ctx, dose, user)
+	if err != nil {
+		l.Error("failed to record medication dose", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s MedicationService) GetDoses(
+	ctx context.Context,
+	filter *domain.FilterMedicationDose,
+	doses domain.MedicationDoses,
+) (domain.MedicationDoses, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[MedicationService.GetDoses]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	doses, err := s.medicationRepository.GetDoses(ctx, filter, doses)
+	if err != nil {
+		l.Error("failed to get medication doses", zap.Error(err))
+		return nil, err
+	}
+
+	return doses, nil
+}
+
+var _ MedicationServiceContract = (*MedicationService)(nil)
@@ -0,0 +1,266 @@
+// Package outbox writes the events sent to webhooks. An event is written in
+// the transaction of the change it describes, the webhook module sends it
+// once the change is committed.
+//
+// An event about a patient is also announced on Channel with its id, so the
+// live module can push it to the screens that follow the patient as soon as
+// it is committed.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+// Channel is where the ids of the events about a patient are notified.
+const Channel = "outbox_events"
+
+// New builds an event with data encoded as JSON, patientID is empty for an
+// event that is not about a patient.
+func New(eventType, patientID string, data any) (*domain.OutboxEvent, error) {
+	event := domain.OutboxEventAcquire()
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		domain.OutboxEventRelease(event)
+		return nil, err
+	}
+
+	event.Type = eventType
+	event.PatientID = patientID
+	event.Data = encoded
+
+	return event, nil
+}
+
+// Insert writes event to the outbox within tx.
+func Insert(ctx context.Context, tx pgx.Tx, event *domain.OutboxEvent) error {
+	event.ID = id.New()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	var patientID any
+	if event.PatientID != "" {
+		patientID = event.PatientID
+	}
+
+	insertQuery := `INSERT INTO outbox_events (id, type, patient_id, data, created_at)
+		VALUES (@id, @type, @patient_id, @data, @created_at)`
+	args := pgx.NamedArgs{
+		"id":         event.ID,
+		"type":       event.Type,
+		"patient_id": patientID,
+		"data":       event.Data,
+		"created_at": event.CreatedAt,
+	}
+
+	if _, err := tx.Exec(ctx, insertQuery, args); err != nil {
+		return err
+	}
+
+	if event.PatientID == "" {
+		return nil
+	}
+
+	// delivered to the listeners when tx commits, and dropped if it does not
+	notifyQuery := `SELECT pg_notify(@channel, @payload)`
+	_, err := tx.Exec(ctx, notifyQuery, pgx.NamedArgs{"channel": Channel, "payload": event.ID.String()})
+	return err
+}
+
+// Write builds an event of eventType with data and writes it within tx.
+func Write(ctx context.Context, tx pgx.Tx, eventType, patientID string, data any) error {
+	event, err := New(eventType, patientID, data)
+	if err != nil {
+		return err
+	}
+	defer domain.OutboxEventRelease(event)
+
+	return Insert(ctx, tx, event)
+}
+
+// CopyFrom writes many events to the outbox at once with COPY, for bulk
+// changes.
+func CopyFrom(ctx context.Context, tx pgx.Tx, events []*domain.OutboxEvent) error {
+	now := time.Now()
+	payloads := make([]string, 0, len(events))
+
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"outbox_events"},
+		[]string{"id", "type", "patient_id", "data", "created_at"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			event := events[i]
+			event.ID = id.New()
+			if event.CreatedAt.IsZero() {
+				event.CreatedAt = now
+			}
+
+			var patientID any
+			if event.PatientID != "" {
+				patientID = event.PatientID
+				payloads = append(payloads, event.ID.String())
+			}
+
+			return []any{
+				event.ID,
+				event.Type,
+				patientID,
+				event.Data,
+				event.CreatedAt,
+			}, nil
+		}),
+	)
+	if err != nil || len(payloads) == 0 {
+		return err
+	}
+
+	notifyQuery := `SELECT pg_notify(@channel, payload) FROM unnest(@payloads::text[]) AS payload`
+	_, err = tx.Exec(ctx, notifyQuery, pgx.NamedArgs{"channel": Channel, "payloads": payloads})
+	return err
+}
+
+// number is an identity number or NIP, sent as a number as in the API.
+type number string
+
+func (n number) MarshalJSON() ([]byte, error) {
+	value, err := strconv.Atoi(string(n))
+	if err != nil {
+		return json.Marshal(string(n))
+	}
+	return json.Marshal(value)
+}
+
+// Staff is who made a change.
+type Staff struct {
+	NIP    number    `json:"nip"`
+	Name   string    `json:"name"`
+	UserID ulid.ULID `json:"userId"`
+}
+
+// PatientData is the data of the patient events.
+type PatientData struct {
+	IdentityNumber      number `json:"identityNumber"`
+	PhoneNumber         string `json:"phoneNumber"`
+	Name                string `json:"name"`
+	BirthDate           string `json:"birthDate"`
+	Gender              string `json:"gender"`
+	IdentityCardScanImg string `json:"identityCardScanImg"`
+	CreatedAt           string `json:"createdAt,omitempty"`
+	By                  *Staff `json:"by,omitempty"`
+	// set on patient.updated, keyed by the API name of the field
+	Changes map[string]patientevent.Change `json:"changes,omitempty"`
+}
+
+// Patient returns the data of an event about patient, changed by user when
+// given.
+func Patient(patient *domain.Patient, user *domain.User) PatientData {
+	data := PatientData{
+		IdentityNumber:      number(patient.ID),
+		PhoneNumber:         patient.PhoneNumber,
+		Name:                patient.Name,
+		BirthDate:           patient.BirthDate.Format(time.DateOnly),
+		Gender:              patient.Gender,
+		IdentityCardScanImg: patient.ImgURL,
+	}
+	if !patient.CreatedAt.IsZero() {
+		data.CreatedAt = patient.CreatedAt.Format(dateFormat)
+	}
+	if user != nil && user.NIP != "" {
+		data.By = &Staff{NIP: number(user.NIP), Name: user.Name, UserID: user.ID}
+	}
+	return data
+}
+
+// RecordData is the data of a medical-record.created event.
+type RecordData struct {
+	RecordID       ulid.ULID `json:"recordId"`
+	IdentityNumber number    `json:"identityNumber"`
+	EncounterID    string    `json:"encounterId,omitempty"`
+	Symptoms       string    `json:"symptoms"`
+	Medications    string    `json:"medications"`
+	Attachments    int       `json:"attachments"`
+	CreatedAt      string    `json:"createdAt"`
+	CreatedBy      Staff     `json:"createdBy"`
+}
+
+func Record(record *domain.MedicalRecord) RecordData {
+	data := RecordData{
+		RecordID:       record.ID,
+		IdentityNumber: number(record.PatientID),
+		Symptoms:       record.Symptoms,
+		Medications:    record.Medications,
+		Attachments:    len(record.Attachments),
+		CreatedAt:      record.CreatedAt.Format(dateFormat),
+		CreatedBy:      Staff{NIP: number(record.StaffNIP), Name: record.StaffName, UserID: record.StaffID},
+	}
+	if !id.IsZero(record.EncounterID) {
+		data.EncounterID = record.EncounterID.String()
+	}
+	return data
+}
+
+// AmendmentData is the data of a medical-record.amended event.
+type AmendmentData struct {
+	AmendmentID    ulid.ULID `json:"amendmentId"`
+	RecordID       ulid.ULID `json:"recordId"`
+	IdentityNumber number    `json:"identityNumber"`
+	Type           string    `json:"type"`
+	Symptoms       string    `json:"symptoms,omitempty"`
+	Medications    string    `json:"medications,omitempty"`
+	Reason         string    `json:"reason"`
+	CreatedAt      string    `json:"createdAt"`
+	CreatedBy      Staff     `json:"createdBy"`
+}
+
+func Amendment(amendment *domain.MedicalRecordAmendment, patientID string) AmendmentData {
+	return AmendmentData{
+		AmendmentID:    amendment.ID,
+		RecordID:       amendment.RecordID,
+		IdentityNumber: number(patientID),
+		Type:           amendment.Type,
+		Symptoms:       amendment.Symptoms,
+		Medications:    amendment.Medications,
+		Reason:         amendment.Reason,
+		CreatedAt:      amendment.CreatedAt.Format(dateFormat),
+		CreatedBy: Staff{
+			NIP:    number(amendment.StaffNIP),
+			Name:   amendment.StaffName,
+			UserID: amendment.StaffID,
+		},
+	}
+}
+
+// UserData is the data of the user events, it never carries a password.
+type UserData struct {
+	UserID    ulid.ULID `json:"userId"`
+	NIP       number    `json:"nip,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt string    `json:"createdAt,omitempty"`
+}
+
+func User(user *domain.User) UserData {
+	data := UserData{
+		UserID: user.ID,
+		NIP:    number(user.NIP),
+		Name:   user.Name,
+		Role:   user.Role,
+	}
+	if !user.CreatedAt.IsZero() {
+		data.CreatedAt = user.CreatedAt.Format(dateFormat)
+	}
+	return data
+}
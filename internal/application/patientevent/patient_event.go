@@ -0,0 +1,123 @@
+package patientevent
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// Insert appends an event to the patient timeline. It takes a transaction
+// so the event is only visible when the change it describes is committed.
+func Insert(ctx context.Context, tx pgx.Tx, event *domain.PatientEvent) error {
+	event.ID = id.New()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	var staffID any
+	if !id.IsZero(event.StaffID) {
+		staffID = event.StaffID
+	}
+
+	insertQuery := `INSERT INTO patient_events (id, patient_id, type, data, staff_id, staff_name, created_at) 
+		VALUES (@id, @patient_id, @type, @data, @staff_id, @staff_name, @created_at)`
+	args := pgx.NamedArgs{
+		"id":         event.ID,
+		"patient_id": event.PatientID,
+		"type":       event.Type,
+		"data":       event.Data,
+		"staff_id":   staffID,
+		"staff_name": event.StaffName,
+		"created_at": event.CreatedAt,
+	}
+
+	_, err := tx.Exec(ctx, insertQuery, args)
+	return err
+}
+
+// CopyFrom appends many events to the patient timelines at once with COPY,
+// for bulk changes where inserting them one by one would dominate.
+func CopyFrom(ctx context.Context, tx pgx.Tx, events []*domain.PatientEvent) error {
+	now := time.Now()
+
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"patient_events"},
+		[]string{"id", "patient_id", "type", "data", "staff_id", "staff_name", "created_at"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			event := events[i]
+			event.ID = id.New()
+			if event.CreatedAt.IsZero() {
+				event.CreatedAt = now
+			}
+
+			var staffID any
+			if !id.IsZero(event.StaffID) {
+				staffID = event.StaffID
+			}
+
+			return []any{
+				event.ID,
+				event.PatientID,
+				event.Type,
+				event.Data,
+				staffID,
+				event.StaffName,
+				event.CreatedAt,
+			}, nil
+		}),
+	)
+	return err
+}
+
+// New builds an event authored by user with data encoded as JSON.
+func New(patientID, eventType string, user *domain.User, data any) (*domain.PatientEvent, error) {
+	event := domain.PatientEventAcquire()
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		domain.PatientEventRelease(event)
+		return nil, err
+	}
+
+	event.PatientID = patientID
+	event.Type = eventType
+	event.Data = encoded
+	if user != nil {
+		event.StaffID = user.ID
+		event.StaffName = user.Name
+	}
+
+	return event, nil
+}
+
+// Change is how a field of a patient.updated event changed.
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PatientChanges returns the fields that differ between old and updated,
+// keyed by their API name.
+func PatientChanges(old, updated *domain.Patient) map[string]Change {
+	changes := make(map[string]Change)
+
+	compare := func(field, from, to string) {
+		if from != to {
+			changes[field] = Change{From: from, To: to}
+		}
+	}
+
+	compare("phoneNumber", old.PhoneNumber, updated.PhoneNumber)
+	compare("name", old.Name, updated.Name)
+	compare("birthDate", old.BirthDate.Format(time.DateOnly), updated.BirthDate.Format(time.DateOnly))
+	compare("gender", old.Gender, updated.Gender)
+	compare("identityCardScanImg", old.ImgURL, updated.ImgURL)
+
+	return changes
+}
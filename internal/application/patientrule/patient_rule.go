@@ -0,0 +1,117 @@
+// Package patientrule holds the rules a patient is validated with when they
+// are registered or updated, so the registration endpoint and the bulk
+// import turn down the same values with the same messages.
+package patientrule
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/asaskevich/govalidator"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// DateFormat is the format of birthDate in requests.
+const DateFormat = "2006-01-02T15:04:05.999Z"
+
+const identityNumberLength = 16
+
+// IdentityNumber checks an identity number given as text, it must be a
+// number of 16 digits.
+func IdentityNumber(value string) error {
+	if value == "" {
+		return errors.New("identityNumber is required")
+	}
+
+	if strings.IndexFunc(value, func(r rune) bool { return !unicode.IsDigit(r) }) >= 0 {
+		return errors.New("identityNumber must be a number")
+	}
+
+	return IdentityNumberLength(value)
+}
+
+// IdentityNumberLength checks the length of an identity number already known
+// to be a number.
+func IdentityNumberLength(value string) error {
+	if len(value) != identityNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+// PhoneNumber checks an Indonesian phone number and returns it the way it is
+// stored, without the leading +.
+func PhoneNumber(value string) (string, error) {
+	switch {
+	case value == "":
+		return "", errors.New("phoneNumber is required")
+	case len(value) < 10 || len(value) > 15:
+		return "", errors.New("phoneNumber must have 10 to 15 characters")
+	case !strings.HasPrefix(value, "+62"):
+		return "", errors.New("phoneNumber must start with +62")
+	}
+
+	return strings.TrimPrefix(value, "+"), nil
+}
+
+func Name(value string) error {
+	if value == "" {
+		return errors.New("name is required")
+	}
+
+	if len(value) < 3 || len(value) > 30 {
+		return errors.New("name must have 3 to 30 characters")
+	}
+
+	return nil
+}
+
+// BirthDate parses a birth date in DateFormat.
+func BirthDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("birthDate is required")
+	}
+
+	birthDate, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return time.Time{}, errors.New("birthDate must be in format yyyy-mm-dd")
+	}
+
+	return birthDate, nil
+}
+
+func Gender(value string) error {
+	if value == "" {
+		return errors.New("gender is required")
+	}
+
+	if value != domain.GenderMale && value != domain.GenderFemale {
+		return errors.New("gender must be either male or female")
+	}
+
+	return nil
+}
+
+// IdentityCardScanImg checks the URL of the identity card scan, its host must
+// be a domain name.
+func IdentityCardScanImg(value string) error {
+	if value == "" {
+		return errors.New("identity card scan image URL is required")
+	}
+
+	if !govalidator.IsURL(value) {
+		return errors.New("identity card scan image URL must be a valid URL")
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || !strings.Contains(u.Host, ".") {
+		return errors.New("identity card scan image URL must be a valid URL")
+	}
+
+	return nil
+}
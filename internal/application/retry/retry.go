@@ -0,0 +1,92 @@
+// Package retry holds how the background workers send a queue and try again
+// what failed, so SATUSEHAT syncs, webhook deliveries and jobs are claimed,
+// backed off and given up on the same way.
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// maxLastError caps the error kept on what failed.
+const maxLastError = 2048
+
+// Options configures how a queue is sent.
+type Options struct {
+	BatchSize      int
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Lease is how long a claimed batch is kept from other workers.
+	Lease time.Duration
+}
+
+// Next returns when something that failed its attempts-th attempt at now is
+// tried again. It reports false once it has run out of attempts, or when the
+// failure is not retryable and trying again cannot succeed.
+func (o Options) Next(attempts int, retryable bool, now time.Time) (time.Time, bool) {
+	if !retryable || attempts >= o.MaxAttempts {
+		return time.Time{}, false
+	}
+
+	return now.Add(Backoff(o.RetryBaseDelay, o.RetryMaxDelay, attempts)), true
+}
+
+// Send sends the claimed items in turn and saves the outcome send records on
+// each, and returns how many items ended in each status. It stops at the
+// first item it fails to save.
+func Send[T any](
+	ctx context.Context,
+	items []T,
+	send func(context.Context, *T),
+	save func(context.Context, *T) error,
+	status func(*T) string,
+) (map[string]int, error) {
+	counts := map[string]int{}
+	for i := range items {
+		if ctx.Err() != nil {
+			// the lease runs out and the rest is claimed again
+			break
+		}
+
+		send(ctx, &items[i])
+
+		if err := save(ctx, &items[i]); err != nil {
+			return counts, err
+		}
+		counts[status(&items[i])]++
+	}
+
+	return counts, nil
+}
+
+// LastError returns the message of err to keep on what failed, capped so a
+// long response does not bloat the queue.
+func LastError(err error) string {
+	message := err.Error()
+	if len(message) > maxLastError {
+		message = message[:maxLastError]
+	}
+
+	return message
+}
+
+// Backoff returns how long to wait after attempts failed attempts. The delay
+// doubles from base with every attempt up to maxDelay, and is spread by up to
+// half so what failed together is not tried again in lockstep.
+func Backoff(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := maxDelay
+	if attempts-1 < 32 {
+		if d := base << (attempts - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + rand.N(half) // #nosec G404
+}
@@ -0,0 +1,227 @@
+// Package client talks to the SATUSEHAT FHIR API.
+//
+// Requests are authorised with an access token from the OAuth client
+// credentials grant, the token is kept until shortly before it expires and
+// fetched again when the API turns it down.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+)
+
+// tokenLeeway is how long before it expires a token is replaced.
+const tokenLeeway = time.Minute
+
+// maxErrorBody caps how much of an error response is kept for the queue.
+const maxErrorBody = 1024
+
+var ErrPatientNotFound = errors.New("patient is not registered in SATUSEHAT")
+
+// StatusError is a response outside 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("SATUSEHAT responded %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether sending the same request later can succeed.
+// Other client errors mean the resource itself was turned down.
+func (e StatusError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+type Client struct {
+	authURL      string
+	baseURL      string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func New(authURL, baseURL, clientID, clientSecret string, timeout time.Duration) *Client {
+	return &Client{
+		authURL:      strings.TrimSuffix(authURL, "/"),
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: timeout},
+	}
+}
+
+// expiresIn is a number of seconds, SATUSEHAT sends it as a string.
+type expiresIn int
+
+func (e *expiresIn) UnmarshalJSON(b []byte) error {
+	seconds, err := strconv.Atoi(strings.Trim(string(b), `"`))
+	if err != nil {
+		return fmt.Errorf("expires_in is not a number: %s", b)
+	}
+	*e = expiresIn(seconds)
+	return nil
+}
+
+type tokenResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   expiresIn `json:"expires_in"`
+}
+
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.authURL+"/accesstoken?grant_type=client_credentials",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token tokenResponse
+	if err = c.do(req, &token); err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("failed to get access token: response has no access_token")
+	}
+
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenLeeway)
+
+	return c.token, nil
+}
+
+func (c *Client) forgetToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = ""
+}
+
+// do sends req and decodes a 2xx response into out.
+func (c *Client) do(req *http.Request, out any) error {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		return StatusError{StatusCode: res.StatusCode, Body: string(body)}
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// fhir sends an authorised request to the FHIR API, once more with a new
+// token when the current one is turned down.
+func (c *Client) fhir(ctx context.Context, method, path string, body []byte, out any) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/fhir+json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/fhir+json")
+		}
+
+		err = c.do(req, out)
+
+		var statusErr StatusError
+		if attempt == 0 && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+			c.forgetToken()
+			continue
+		}
+
+		return err
+	}
+}
+
+type resourceID struct {
+	ID string `json:"id"`
+}
+
+// Create posts payload as a new resource and returns the id SATUSEHAT gave
+// it.
+func (c *Client) Create(ctx context.Context, resourceType string, payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	var created resourceID
+	if err = c.fhir(ctx, http.MethodPost, "/"+resourceType, body, &created); err != nil {
+		return "", err
+	}
+	if created.ID == "" {
+		return "", fmt.Errorf("created %s has no id", resourceType)
+	}
+
+	return created.ID, nil
+}
+
+type searchBundle struct {
+	Entry []struct {
+		Resource resourceID `json:"resource"`
+	} `json:"entry"`
+}
+
+// FindPatient returns the SATUSEHAT id of the patient with the national
+// identity number nik.
+func (c *Client) FindPatient(ctx context.Context, nik string) (string, error) {
+	query := url.Values{}
+	query.Set("identifier", resource.NIKSystem+"|"+nik)
+
+	var bundle searchBundle
+	if err := c.fhir(ctx, http.MethodGet, "/Patient?"+query.Encode(), nil, &bundle); err != nil {
+		return "", err
+	}
+	if len(bundle.Entry) == 0 || bundle.Entry[0].Resource.ID == "" {
+		return "", ErrPatientNotFound
+	}
+
+	return bundle.Entry[0].Resource.ID, nil
+}
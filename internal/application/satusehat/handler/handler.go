@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const syncIDFromParam = "id"
+
+type satuSehatHandler struct {
+	satuSehatService service.SatuSehatServiceContract
+}
+
+func NewSatuSehatHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	satuSehatService service.SatuSehatServiceContract,
+) {
+	handler := satuSehatHandler{
+		satuSehatService: satuSehatService,
+	}
+
+	satuSehatRouter := router.Group("/satusehat", jwtMiddleware, access.ITStaff)
+	satuSehatRouter.Get("/sync", handler.GetSyncs)
+	satuSehatRouter.Post("/sync/:"+syncIDFromParam+"/retry", handler.RetrySync)
+}
+
+// GetSyncs returns the SATUSEHAT queue, most recently updated first.
+func (h satuSehatHandler) GetSyncs(c *fiber.Ctx) error {
+	callerInfo := "[satuSehatHandler.GetSyncs]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := querySyncAcquire()
+	defer querySyncRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterSatuSehatSyncAcquire()
+	defer domain.FilterSatuSehatSyncRelease(filter)
+
+	query.toFilter(filter)
+
+	syncs := domain.SatuSehatSyncsAcquire()
+	defer domain.SatuSehatSyncsRelease(syncs)
+
+	syncs, err := h.satuSehatService.GetSyncs(userCtx, filter, syncs)
+	if err != nil {
+		l.Error("failed to get syncs", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "SATUSEHAT sync items retrieved successfully"
+
+	syncsRes := getSyncsResAcquire()
+	defer getSyncsResRelease(syncsRes)
+
+	for i := range syncs {
+		syncsRes = append(syncsRes, newSyncRes(&syncs[i]))
+	}
+
+	res.Data = syncsRes
+
+	return c.JSON(res)
+}
+
+// RetrySync queues a failed item again with its attempts reset, along with
+// the items that failed because they refer to it.
+func (h satuSehatHandler) RetrySync(c *fiber.Ctx) error {
+	callerInfo := "[satuSehatHandler.RetrySync]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	syncID, err := ulid.Parse(c.Params(syncIDFromParam))
+	if err != nil {
+		l.Error("error parsing syncIDParam", zap.Error(err))
+		return new(domain.ErrSatuSehatSyncNotFound)
+	}
+
+	sync := domain.SatuSehatSyncAcquire()
+	defer domain.SatuSehatSyncRelease(sync)
+
+	sync.ID = syncID
+
+	err = h.satuSehatService.RetrySync(userCtx, sync)
+	if err != nil {
+		l.Error("failed to retry sync", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "SATUSEHAT sync item queued again"
+	res.Data = newSyncRes(sync)
+
+	return c.JSON(res)
+}
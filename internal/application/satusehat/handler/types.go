@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/mapping"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+var querySyncPool = sync.Pool{
+	New: func() any {
+		return new(querySync)
+	},
+}
+
+func querySyncAcquire() *querySync {
+	return querySyncPool.Get().(*querySync)
+}
+
+func querySyncRelease(t *querySync) {
+	*t = querySync{}
+	querySyncPool.Put(t)
+}
+
+type querySync struct {
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	EncounterID    string `query:"encounterId"`
+	encounterID    ulid.ULID
+	ResourceType   string `query:"resourceType"`
+	Status         string `query:"status"`
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+}
+
+func (q *querySync) validate() {
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.EncounterID != "" {
+		q.encounterID, _ = ulid.Parse(q.EncounterID)
+	}
+
+	switch q.ResourceType {
+	case resource.TypePatient, mapping.TypeEncounter, resource.TypeCondition,
+		resource.TypeObservation, resource.TypeMedicationStatement:
+	default:
+		q.ResourceType = ""
+	}
+
+	switch q.Status {
+	case domain.SatuSehatPending, domain.SatuSehatSynced, domain.SatuSehatFailed:
+	default:
+		q.Status = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *querySync) toFilter(filter *domain.FilterSatuSehatSync) {
+	filter.PatientID = q.patientID
+	filter.EncounterID = q.encounterID
+	filter.ResourceType = q.ResourceType
+	filter.Status = q.Status
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+}
+
+type syncRes struct {
+	SyncID         ulid.ULID  `json:"syncId"`
+	ResourceType   string     `json:"resourceType"`
+	IdentityNumber idNumber   `json:"identityNumber"`
+	EncounterID    *ulid.ULID `json:"encounterId"`
+	RecordID       *ulid.ULID `json:"recordId"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  string     `json:"nextAttemptAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	RemoteID       string     `json:"remoteId,omitempty"`
+	CreatedAt      string     `json:"createdAt"`
+	UpdatedAt      string     `json:"updatedAt"`
+	SyncedAt       string     `json:"syncedAt,omitempty"`
+}
+
+func newSyncRes(sync *domain.SatuSehatSync) syncRes {
+	res := syncRes{
+		SyncID:         sync.ID,
+		ResourceType:   sync.ResourceType,
+		IdentityNumber: idNumber(sync.PatientID),
+		Status:         sync.Status,
+		Attempts:       sync.Attempts,
+		LastError:      sync.LastError,
+		RemoteID:       sync.RemoteID,
+		CreatedAt:      sync.CreatedAt.Format(dateFormat),
+		UpdatedAt:      sync.UpdatedAt.Format(dateFormat),
+	}
+	if !id.IsZero(sync.EncounterID) {
+		encounterID := sync.EncounterID
+		res.EncounterID = &encounterID
+	}
+	if !id.IsZero(sync.RecordID) {
+		recordID := sync.RecordID
+		res.RecordID = &recordID
+	}
+	// only pending items have another attempt coming
+	if sync.Status == domain.SatuSehatPending {
+		res.NextAttemptAt = sync.NextAttemptAt.Format(dateFormat)
+	}
+	if !sync.SyncedAt.IsZero() {
+		res.SyncedAt = sync.SyncedAt.Format(dateFormat)
+	}
+	return res
+}
+
+const syncInitCap = 5
+
+var getSyncsResPool = sync.Pool{
+	New: func() any {
+		return make(getSyncsRes, 0, syncInitCap)
+	},
+}
+
+func getSyncsResAcquire() getSyncsRes {
+	return getSyncsResPool.Get().(getSyncsRes)
+}
+
+func getSyncsResRelease(t getSyncsRes) {
+	t = t[:0]
+	getSyncsResPool.Put(t) // nolint:staticcheck
+}
+
+type getSyncsRes []syncRes
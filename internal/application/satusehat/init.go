@@ -0,0 +1,49 @@
+package satusehat
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/retry"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/client"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/scheduler"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/service"
+)
+
+// NewModule registers the SATUSEHAT queue routes and, when the integration is
+// enabled, syncs until ctx is done. With prefork only the parent process
+// syncs.
+func NewModule(ctx context.Context, router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	cfg := configs.Get().SatuSehat
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	requestTimeout := time.Duration(cfg.RequestTimeout) * time.Second
+	syncInterval := time.Duration(cfg.SyncInterval) * time.Second
+
+	options := service.Options{
+		OrganizationID: cfg.OrganizationID,
+		LocationID:     cfg.LocationID,
+		Options: retry.Options{
+			BatchSize:      cfg.BatchSize,
+			MaxAttempts:    cfg.MaxAttempts,
+			RetryBaseDelay: time.Duration(cfg.RetryBaseDelay) * time.Second,
+			RetryMaxDelay:  time.Duration(cfg.RetryMaxDelay) * time.Second,
+			// long enough for every request of a batch to time out
+			Lease: time.Duration(cfg.BatchSize+1) * requestTimeout,
+		},
+	}
+
+	satuSehatClient := client.New(cfg.AuthURL, cfg.BaseURL, cfg.ClientID, cfg.ClientSecret, requestTimeout)
+	satuSehatRepository := repository.NewSatuSehatRepository(db)
+	satuSehatService := service.NewSatuSehatService(ctxTimeout, options, satuSehatRepository, satuSehatClient)
+	handler.NewSatuSehatHandler(router, jwtMiddleware, satuSehatService)
+
+	if cfg.Enabled && !fiber.IsChild() && syncInterval > 0 {
+		go scheduler.NewScheduler(syncInterval, satuSehatService).Run(ctx)
+	}
+}
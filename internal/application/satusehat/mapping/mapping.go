@@ -0,0 +1,148 @@
+// Package mapping builds the resources SATUSEHAT takes from encounters and
+// medical records.
+//
+// Records are mapped the same way the FHIR API serves them, then pointed at
+// the SATUSEHAT ids of their patient and encounter. Every resource carries
+// the local id under the identifier system of the organization, so a resource
+// sent twice can be told apart on the SATUSEHAT side.
+package mapping
+
+import (
+	"strings"
+
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const TypeEncounter = "Encounter"
+
+// Remote holds the SATUSEHAT ids a resource refers to.
+type Remote struct {
+	OrganizationID string
+	LocationID     string
+	PatientID      string
+	EncounterID    string
+}
+
+func (r Remote) identifier(resourceType, localID string) []resource.Identifier {
+	return []resource.Identifier{{
+		System: "http://sys-ids.kemkes.go.id/" + strings.ToLower(resourceType) + "/" + r.OrganizationID,
+		Value:  localID,
+	}}
+}
+
+func (r Remote) patient() resource.Reference {
+	return resource.Reference{Reference: resource.TypePatient + "/" + r.PatientID}
+}
+
+func (r Remote) encounter() *resource.Reference {
+	return &resource.Reference{Reference: TypeEncounter + "/" + r.EncounterID}
+}
+
+type Period struct {
+	Start string `json:"start"`
+	End   string `json:"end,omitempty"`
+}
+
+type EncounterStatusHistory struct {
+	Status string `json:"status"`
+	Period Period `json:"period"`
+}
+
+type EncounterParticipant struct {
+	Type       []resource.CodeableConcept `json:"type"`
+	Individual resource.Reference         `json:"individual"`
+}
+
+type EncounterLocation struct {
+	Location resource.Reference `json:"location"`
+}
+
+type Encounter struct {
+	ResourceType    string                   `json:"resourceType"`
+	Identifier      []resource.Identifier    `json:"identifier"`
+	Status          string                   `json:"status"`
+	Class           resource.Coding          `json:"class"`
+	Subject         resource.Reference       `json:"subject"`
+	Participant     []EncounterParticipant   `json:"participant"`
+	Period          Period                   `json:"period"`
+	Location        []EncounterLocation      `json:"location"`
+	StatusHistory   []EncounterStatusHistory `json:"statusHistory"`
+	ServiceProvider resource.Reference       `json:"serviceProvider"`
+}
+
+// encounterClasses are the ActCode classes of the encounter types.
+var encounterClasses = map[string]resource.Coding{
+	domain.EncounterOutpatient: {Code: "AMB", Display: "ambulatory"},
+	domain.EncounterInpatient:  {Code: "IMP", Display: "inpatient encounter"},
+	domain.EncounterEmergency:  {Code: "EMER", Display: "emergency"},
+}
+
+// NewEncounter maps a closed encounter. Staff have no SATUSEHAT practitioner
+// id here, so the attending nurse is named rather than referenced, and the
+// location is the configured one when there is one.
+func NewEncounter(encounter *domain.Encounter, remote Remote) Encounter {
+	class := encounterClasses[encounter.Type]
+	class.System = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+
+	openedAt, closedAt := resource.Instant(encounter.OpenedAt), resource.Instant(encounter.ClosedAt)
+
+	location := resource.Reference{Display: encounter.Location}
+	if remote.LocationID != "" {
+		location.Reference = "Location/" + remote.LocationID
+	}
+
+	return Encounter{
+		ResourceType: TypeEncounter,
+		Identifier:   remote.identifier(TypeEncounter, encounter.ID.String()),
+		Status:       "finished",
+		Class:        class,
+		Subject:      remote.patient(),
+		Participant: []EncounterParticipant{{
+			Type: []resource.CodeableConcept{{Coding: []resource.Coding{{
+				System:  "http://terminology.hl7.org/CodeSystem/v3-ParticipationType",
+				Code:    "ATND",
+				Display: "attender",
+			}}}},
+			Individual: resource.Reference{
+				Identifier: &resource.Identifier{System: resource.NIPSystem(), Value: encounter.AttendingStaffNIP},
+				Display:    encounter.AttendingStaffName,
+			},
+		}},
+		Period:   Period{Start: openedAt, End: closedAt},
+		Location: []EncounterLocation{{Location: location}},
+		StatusHistory: []EncounterStatusHistory{
+			{Status: "arrived", Period: Period{Start: openedAt, End: openedAt}},
+			{Status: "in-progress", Period: Period{Start: openedAt, End: closedAt}},
+			{Status: "finished", Period: Period{Start: closedAt, End: closedAt}},
+		},
+		ServiceProvider: resource.Reference{Reference: "Organization/" + remote.OrganizationID},
+	}
+}
+
+func NewCondition(record *domain.MedicalRecord, remote Remote) resource.Condition {
+	condition := resource.NewCondition(record)
+	condition.ID, condition.Meta = "", nil
+	condition.Identifier = remote.identifier(resource.TypeCondition, record.ID.String())
+	condition.Subject = remote.patient()
+	condition.Encounter = remote.encounter()
+	return condition
+}
+
+func NewObservation(record *domain.MedicalRecord, remote Remote) resource.Observation {
+	observation := resource.NewObservation(record)
+	observation.ID, observation.Meta = "", nil
+	observation.Identifier = remote.identifier(resource.TypeObservation, record.ID.String())
+	observation.Subject = remote.patient()
+	observation.Encounter = remote.encounter()
+	return observation
+}
+
+func NewMedicationStatement(record *domain.MedicalRecord, remote Remote) resource.MedicationStatement {
+	statement := resource.NewMedicationStatement(record)
+	statement.ID, statement.Meta = "", nil
+	statement.Identifier = remote.identifier(resource.TypeMedicationStatement, record.ID.String())
+	statement.Subject = remote.patient()
+	statement.Context = remote.encounter()
+	return statement
+}
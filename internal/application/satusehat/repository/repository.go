@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type SatuSehatRepositoryContract interface {
+	Enqueue(ctx context.Context, now time.Time, limit int) (int, error)
+	Claim(
+		ctx context.Context,
+		now, leaseUntil time.Time,
+		limit int,
+		syncs domain.SatuSehatSyncs,
+	) (domain.SatuSehatSyncs, error)
+	SaveResult(ctx context.Context, sync *domain.SatuSehatSync) error
+	Retry(ctx context.Context, sync *domain.SatuSehatSync, now time.Time) error
+	GetSyncs(
+		ctx context.Context,
+		filter *domain.FilterSatuSehatSync,
+		syncs domain.SatuSehatSyncs,
+	) (domain.SatuSehatSyncs, error)
+	GetEncounter(ctx context.Context, encounter *domain.Encounter) error
+	GetRecord(ctx context.Context, record *domain.MedicalRecord) error
+}
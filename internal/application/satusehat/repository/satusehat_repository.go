@@ -0,0 +1,545 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/mapping"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const syncColumns = `id, resource_type, patient_id, encounter_id, record_id, priority, status, attempts,
+	next_attempt_at, last_error, remote_id, created_at, updated_at, synced_at`
+
+// priorities send a patient before its encounter, and the encounter before
+// the resources of its records.
+var priorities = map[string]int{
+	resource.TypePatient:             0,
+	mapping.TypeEncounter:            1,
+	resource.TypeCondition:           2,
+	resource.TypeObservation:         2,
+	resource.TypeMedicationStatement: 2,
+}
+
+// recordResourceTypes are sent for every record of an encounter.
+var recordResourceTypes = []string{
+	resource.TypeCondition,
+	resource.TypeObservation,
+	resource.TypeMedicationStatement,
+}
+
+type SatuSehatRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSatuSehatRepository(db *pgxpool.Pool) *SatuSehatRepository {
+	return &SatuSehatRepository{db: db}
+}
+
+// Enqueue queues up to limit closed encounters that are not queued yet, with
+// their patient and the resources of their records. It returns how many
+// encounters were queued.
+func (r SatuSehatRepository) Enqueue(ctx context.Context, now time.Time, limit int) (int, error) {
+	callerInfo := "[SatuSehatRepository.Enqueue]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	encounterQuery := `SELECT e.id, e.patient_id FROM encounters e
+		WHERE e.status = @status AND NOT EXISTS (
+			SELECT 1 FROM satusehat_sync s WHERE s.resource_type = @resource_type AND s.encounter_id = e.id
+		)
+		ORDER BY e.closed_at ASC LIMIT @limit`
+	args := pgx.NamedArgs{
+		"status":        domain.EncounterClosed,
+		"resource_type": mapping.TypeEncounter,
+		"limit":         limit,
+	}
+
+	rows, err := tx.Query(ctx, encounterQuery, args)
+	if err != nil {
+		l.Error("failed to get closed encounters", zap.Error(err))
+		return 0, err
+	}
+
+	encounters := make(domain.SatuSehatSyncs, 0, limit)
+	var encounterID ulid.ULID
+	var patientID string
+
+	_, err = pgx.ForEachRow(rows, []any{&encounterID, &patientID}, func() error {
+		encounters = append(encounters, domain.SatuSehatSync{
+			ResourceType: mapping.TypeEncounter,
+			PatientID:    patientID,
+			EncounterID:  encounterID,
+		})
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to get closed encounters", zap.Error(err))
+		return 0, err
+	}
+
+	if len(encounters) == 0 {
+		return 0, nil
+	}
+
+	encounterIDs := make([][]byte, 0, len(encounters))
+	for i := range encounters {
+		encounterIDs = append(encounterIDs, encounters[i].EncounterID.Bytes())
+	}
+
+	recordQuery := `SELECT id, encounter_id, patient_id FROM medical_records
+		WHERE encounter_id = ANY(@encounter_ids) ORDER BY created_at ASC`
+
+	rows, err = tx.Query(ctx, recordQuery, pgx.NamedArgs{"encounter_ids": encounterIDs})
+	if err != nil {
+		l.Error("failed to get encounter records", zap.Error(err))
+		return 0, err
+	}
+
+	syncs := make(domain.SatuSehatSyncs, 0, len(encounters)*2)
+	for i := range encounters {
+		syncs = append(syncs, domain.SatuSehatSync{
+			ResourceType: resource.TypePatient,
+			PatientID:    encounters[i].PatientID,
+		}, encounters[i])
+	}
+
+	var recordID ulid.ULID
+	_, err = pgx.ForEachRow(rows, []any{&recordID, &encounterID, &patientID}, func() error {
+		for _, resourceType := range recordResourceTypes {
+			syncs = append(syncs, domain.SatuSehatSync{
+				ResourceType: resourceType,
+				PatientID:    patientID,
+				EncounterID:  encounterID,
+				RecordID:     recordID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to get encounter records", zap.Error(err))
+		return 0, err
+	}
+
+	// a patient with an earlier encounter is already queued
+	insertQuery := `INSERT INTO satusehat_sync (id, resource_type, patient_id, encounter_id, record_id, priority,
+		status, next_attempt_at, created_at, updated_at)
+		VALUES (@id, @resource_type, @patient_id, @encounter_id, @record_id, @priority,
+		@status, @now, @now, @now) ON CONFLICT DO NOTHING`
+
+	for i := range syncs {
+		var syncEncounterID, syncRecordID any
+		if !id.IsZero(syncs[i].EncounterID) {
+			syncEncounterID = syncs[i].EncounterID
+		}
+		if !id.IsZero(syncs[i].RecordID) {
+			syncRecordID = syncs[i].RecordID
+		}
+
+		_, err = tx.Exec(ctx, insertQuery, pgx.NamedArgs{
+			"id":            id.New(),
+			"resource_type": syncs[i].ResourceType,
+			"patient_id":    syncs[i].PatientID,
+			"encounter_id":  syncEncounterID,
+			"record_id":     syncRecordID,
+			"priority":      priorities[syncs[i].ResourceType],
+			"status":        domain.SatuSehatPending,
+			"now":           now,
+		})
+		if err != nil {
+			l.Error("failed to queue resource", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return 0, err
+	}
+
+	return len(encounters), nil
+}
+
+// Claim leases up to limit pending syncs that are due until leaseUntil, other
+// workers skip them meanwhile. A sync whose worker dies is due again once its
+// lease runs out.
+func (r SatuSehatRepository) Claim(
+	ctx context.Context,
+	now, leaseUntil time.Time,
+	limit int,
+	syncs domain.SatuSehatSyncs,
+) (domain.SatuSehatSyncs, error) {
+	callerInfo := "[SatuSehatRepository.Claim]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	claimQuery := `UPDATE satusehat_sync SET next_attempt_at = @lease_until, updated_at = @now
+		WHERE id IN (
+			SELECT id FROM satusehat_sync WHERE status = @status AND next_attempt_at <= @now
+			ORDER BY priority ASC, next_attempt_at ASC LIMIT @limit FOR UPDATE SKIP LOCKED
+		) RETURNING ` + syncColumns
+	args := pgx.NamedArgs{
+		"status":      domain.SatuSehatPending,
+		"now":         now,
+		"lease_until": leaseUntil,
+		"limit":       limit,
+	}
+
+	rows, err := r.db.Query(ctx, claimQuery, args)
+	if err != nil {
+		l.Error("failed to claim syncs", zap.Error(err))
+		return syncs, err
+	}
+
+	syncs, err = r.collectSyncs(rows, syncs)
+	if err != nil {
+		l.Error("failed to claim syncs", zap.Error(err))
+		return syncs, err
+	}
+
+	return syncs, nil
+}
+
+// SaveResult stores the outcome of sending sync.
+func (r SatuSehatRepository) SaveResult(ctx context.Context, sync *domain.SatuSehatSync) error {
+	callerInfo := "[SatuSehatRepository.SaveResult]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var remoteID, syncedAt any
+	if sync.RemoteID != "" {
+		remoteID = sync.RemoteID
+	}
+	if !sync.SyncedAt.IsZero() {
+		syncedAt = sync.SyncedAt
+	}
+
+	updateQuery := `UPDATE satusehat_sync SET status = @status, attempts = @attempts,
+		next_attempt_at = @next_attempt_at, last_error = @last_error, remote_id = @remote_id,
+		updated_at = @updated_at, synced_at = @synced_at WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":              sync.ID,
+		"status":          sync.Status,
+		"attempts":        sync.Attempts,
+		"next_attempt_at": sync.NextAttemptAt,
+		"last_error":      sync.LastError,
+		"remote_id":       remoteID,
+		"updated_at":      sync.UpdatedAt,
+		"synced_at":       syncedAt,
+	}
+
+	if _, err := r.db.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to save sync result", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Retry queues the failed sync in sync.ID again, along with the syncs that
+// failed because they refer to it, and fills sync in.
+func (r SatuSehatRepository) Retry(ctx context.Context, sync *domain.SatuSehatSync, now time.Time) error {
+	callerInfo := "[SatuSehatRepository.Retry]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	selectQuery := `SELECT ` + syncColumns + ` FROM satusehat_sync WHERE id = @id FOR UPDATE`
+	rows, err := tx.Query(ctx, selectQuery, pgx.NamedArgs{"id": sync.ID})
+	if err != nil {
+		l.Error("failed to get sync", zap.Error(err))
+		return err
+	}
+
+	syncs, err := r.collectSyncs(rows, make(domain.SatuSehatSyncs, 0, 1))
+	if err != nil {
+		l.Error("failed to get sync", zap.Error(err))
+		return err
+	}
+	if len(syncs) == 0 {
+		return new(domain.ErrSatuSehatSyncNotFound)
+	}
+	if syncs[0].Status != domain.SatuSehatFailed {
+		return new(domain.ErrSatuSehatSyncNotFailed)
+	}
+	*sync = syncs[0]
+
+	dependents := `FALSE`
+	switch sync.ResourceType {
+	case resource.TypePatient:
+		dependents = `patient_id = @patient_id`
+	case mapping.TypeEncounter:
+		dependents = `encounter_id = @encounter_id`
+	}
+
+	retryQuery := `UPDATE satusehat_sync SET status = @pending, attempts = 0, next_attempt_at = @now,
+		last_error = '', updated_at = @now WHERE status = @failed AND (id = @id OR ` + dependents + `)`
+	args := pgx.NamedArgs{
+		"id":           sync.ID,
+		"patient_id":   sync.PatientID,
+		"encounter_id": sync.EncounterID,
+		"pending":      domain.SatuSehatPending,
+		"failed":       domain.SatuSehatFailed,
+		"now":          now,
+	}
+
+	if _, err = tx.Exec(ctx, retryQuery, args); err != nil {
+		l.Error("failed to retry syncs", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	sync.Status = domain.SatuSehatPending
+	sync.Attempts = 0
+	sync.NextAttemptAt = now
+	sync.LastError = ""
+	sync.UpdatedAt = now
+
+	return nil
+}
+
+func (r SatuSehatRepository) GetSyncs(
+	ctx context.Context,
+	filter *domain.FilterSatuSehatSync,
+	syncs domain.SatuSehatSyncs,
+) (domain.SatuSehatSyncs, error) {
+	callerInfo := "[SatuSehatRepository.GetSyncs]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterSync(filter)
+	getQuery := `SELECT ` + syncColumns + ` FROM satusehat_sync` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get syncs", zap.Error(err))
+		return syncs, err
+	}
+
+	syncs, err = r.collectSyncs(rows, syncs)
+	if err != nil {
+		l.Error("failed to get syncs", zap.Error(err))
+		return syncs, err
+	}
+
+	return syncs, nil
+}
+
+func (r SatuSehatRepository) collectSyncs(rows pgx.Rows, syncs domain.SatuSehatSyncs) (domain.SatuSehatSyncs, error) {
+	dSync := domain.SatuSehatSyncAcquire()
+	defer domain.SatuSehatSyncRelease(dSync)
+	var remoteID *string
+	var syncedAt *time.Time
+
+	_, err := pgx.ForEachRow(
+		rows,
+		[]any{
+			&dSync.ID,
+			&dSync.ResourceType,
+			&dSync.PatientID,
+			&dSync.EncounterID,
+			&dSync.RecordID,
+			&dSync.Priority,
+			&dSync.Status,
+			&dSync.Attempts,
+			&dSync.NextAttemptAt,
+			&dSync.LastError,
+			&remoteID,
+			&dSync.CreatedAt,
+			&dSync.UpdatedAt,
+			&syncedAt,
+		},
+		func() error {
+			dSync.RemoteID = ""
+			if remoteID != nil {
+				dSync.RemoteID = *remoteID
+			}
+			dSync.SyncedAt = time.Time{}
+			if syncedAt != nil {
+				dSync.SyncedAt = *syncedAt
+			}
+			syncs = append(syncs, *dSync)
+			dSync.EncounterID, dSync.RecordID = ulid.ULID{}, ulid.ULID{}
+			return nil
+		},
+	)
+
+	return syncs, err
+}
+
+func (r SatuSehatRepository) filterSync(filter *domain.FilterSatuSehatSync) (string, pgx.NamedArgs) {
+	const totalConditions = 5
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "id = @id")
+		params["id"] = filter.ID
+	}
+
+	if filter.ResourceType != "" {
+		conditions = append(conditions, "resource_type = @resource_type")
+		params["resource_type"] = filter.ResourceType
+	}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.EncounterID) {
+		conditions = append(conditions, "encounter_id = @encounter_id")
+		params["encounter_id"] = filter.EncounterID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = @status")
+		params["status"] = filter.Status
+	}
+
+	order := " ORDER BY updated_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+// GetEncounter fills encounter from its ID.
+func (r SatuSehatRepository) GetEncounter(ctx context.Context, encounter *domain.Encounter) error {
+	callerInfo := "[SatuSehatRepository.GetEncounter]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var closedAt *time.Time
+	getQuery := `SELECT patient_id, type, status, location, attending_staff_id, attending_staff_nip,
+		attending_staff_name, opened_at, closed_at FROM encounters WHERE id = @id`
+	err := r.db.QueryRow(ctx, getQuery, pgx.NamedArgs{"id": encounter.ID}).Scan(
+		&encounter.PatientID,
+		&encounter.Type,
+		&encounter.Status,
+		&encounter.Location,
+		&encounter.AttendingStaffID,
+		&encounter.AttendingStaffNIP,
+		&encounter.AttendingStaffName,
+		&encounter.OpenedAt,
+		&closedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrEncounterNotFound)
+		}
+
+		l.Error("failed to get encounter", zap.Error(err))
+		return err
+	}
+
+	if closedAt != nil {
+		encounter.ClosedAt = *closedAt
+	}
+
+	return nil
+}
+
+// GetRecord fills record and its amendments from its ID.
+func (r SatuSehatRepository) GetRecord(ctx context.Context, record *domain.MedicalRecord) error {
+	callerInfo := "[SatuSehatRepository.GetRecord]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	getQuery := `SELECT patient_id, symptoms, medications, staff_id, staff_nip, staff_name, encounter_id, created_at
+		FROM medical_records WHERE id = @id`
+	err := r.db.QueryRow(ctx, getQuery, pgx.NamedArgs{"id": record.ID}).Scan(
+		&record.PatientID,
+		&record.Symptoms,
+		&record.Medications,
+		&record.StaffID,
+		&record.StaffNIP,
+		&record.StaffName,
+		&record.EncounterID,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrMedicalRecordNotFound)
+		}
+
+		l.Error("failed to get medical record", zap.Error(err))
+		return err
+	}
+
+	amendmentQuery := `SELECT type, symptoms, medications, created_at
+		FROM medical_record_amendments WHERE record_id = @record_id ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, amendmentQuery, pgx.NamedArgs{"record_id": record.ID})
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return err
+	}
+
+	dAmendment := domain.MedicalRecordAmendmentAcquire()
+	defer domain.MedicalRecordAmendmentRelease(dAmendment)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{&dAmendment.Type, &dAmendment.Symptoms, &dAmendment.Medications, &dAmendment.CreatedAt},
+		func() error {
+			dAmendment.RecordID = record.ID
+			record.Amendments = append(record.Amendments, *dAmendment)
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get medical record amendments", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+var _ SatuSehatRepositoryContract = (*SatuSehatRepository)(nil)
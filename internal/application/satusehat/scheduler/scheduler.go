@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/service"
+)
+
+// Scheduler syncs with SATUSEHAT every interval until its context is done.
+// Batches are claimed with a lease, so running it in more than one process is
+// safe.
+type Scheduler struct {
+	satuSehatService service.SatuSehatServiceContract
+	interval         time.Duration
+}
+
+func NewScheduler(interval time.Duration, satuSehatService service.SatuSehatServiceContract) *Scheduler {
+	return &Scheduler{
+		satuSehatService: satuSehatService,
+		interval:         interval,
+	}
+}
+
+func (s Scheduler) Run(ctx context.Context) {
+	callerInfo := "[Scheduler.Run]"
+	l := zap.L().With(zap.String("caller", callerInfo))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	l.Info("SATUSEHAT scheduler started", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Info("SATUSEHAT scheduler stopped")
+			return
+		case now := <-ticker.C:
+			// the error is already logged by the service, the next tick retries
+			_ = s.satuSehatService.Sync(ctx, now)
+		}
+	}
+}
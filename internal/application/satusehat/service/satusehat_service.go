@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/fhir/resource"
+	"github.com/j03hanafi/halo-suster/internal/application/retry"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/client"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/mapping"
+	"github.com/j03hanafi/halo-suster/internal/application/satusehat/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// Options configures how the queue is sent and who it is sent for.
+type Options struct {
+	OrganizationID string
+	LocationID     string
+	retry.Options
+}
+
+type SatuSehatService struct {
+	satuSehatRepository repository.SatuSehatRepositoryContract
+	client              *client.Client
+	contextTimeout      time.Duration
+	options             Options
+}
+
+func NewSatuSehatService(
+	timeout time.Duration,
+	options Options,
+	satuSehatRepository repository.SatuSehatRepositoryContract,
+	client *client.Client,
+) *SatuSehatService {
+	return &SatuSehatService{
+		satuSehatRepository: satuSehatRepository,
+		client:              client,
+		contextTimeout:      timeout,
+		options:             options,
+	}
+}
+
+// Sync queues the encounters closed since the last run and sends a batch of
+// the queue that is due. A resource is sent after the ones it refers to, one
+// that fails is tried again later with an exponential backoff until it runs
+// out of attempts.
+func (s SatuSehatService) Sync(ctx context.Context, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.options.Lease)
+	defer cancel()
+
+	callerInfo := "[SatuSehatService.Sync]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	queued, err := s.satuSehatRepository.Enqueue(ctx, now, s.options.BatchSize)
+	if err != nil {
+		l.Error("failed to queue encounters", zap.Error(err))
+		return err
+	}
+
+	syncs := domain.SatuSehatSyncsAcquire()
+	defer domain.SatuSehatSyncsRelease(syncs)
+
+	syncs, err = s.satuSehatRepository.Claim(ctx, now, now.Add(s.options.Lease), s.options.BatchSize, syncs)
+	if err != nil {
+		l.Error("failed to claim syncs", zap.Error(err))
+		return err
+	}
+
+	sort.SliceStable(syncs, func(i, j int) bool {
+		return syncs[i].Priority < syncs[j].Priority
+	})
+
+	counts, err := retry.Send(ctx, syncs, s.send, s.satuSehatRepository.SaveResult,
+		func(sync *domain.SatuSehatSync) string { return sync.Status })
+	if err != nil {
+		l.Error("failed to save sync result", zap.Error(err))
+		return err
+	}
+
+	if queued > 0 || len(syncs) > 0 {
+		l.Info("SATUSEHAT synced",
+			zap.Int("encounters queued", queued),
+			zap.Int(domain.SatuSehatSynced, counts[domain.SatuSehatSynced]),
+			zap.Int(domain.SatuSehatPending, counts[domain.SatuSehatPending]),
+			zap.Int(domain.SatuSehatFailed, counts[domain.SatuSehatFailed]),
+		)
+	}
+
+	return nil
+}
+
+// errWaiting reports a resource that refers to one not synced yet.
+type errWaiting struct {
+	resourceType string
+}
+
+func (e errWaiting) Error() string {
+	return "waiting for the " + e.resourceType + " to be synced"
+}
+
+// errDependencyFailed reports a resource that refers to one that failed.
+type errDependencyFailed struct {
+	resourceType string
+}
+
+func (e errDependencyFailed) Error() string {
+	return "the " + e.resourceType + " failed to sync, retry it to send this one"
+}
+
+// send sends sync and records the outcome on it.
+func (s SatuSehatService) send(ctx context.Context, sync *domain.SatuSehatSync) {
+	callerInfo := "[SatuSehatService.send]"
+	l := logger.FromCtx(ctx).With(
+		zap.String("caller", callerInfo),
+		zap.String("id", sync.ID.String()),
+		zap.String("type", sync.ResourceType),
+	)
+
+	now := time.Now()
+	sync.UpdatedAt = now
+
+	remoteID, err := s.create(ctx, sync)
+
+	var waiting errWaiting
+	var dependencyFailed errDependencyFailed
+
+	switch {
+	case err == nil:
+		sync.Status = domain.SatuSehatSynced
+		sync.RemoteID = remoteID
+		sync.LastError = ""
+		sync.SyncedAt = now
+		sync.Attempts++
+	case errors.As(err, &waiting):
+		// not an attempt of this resource, it is tried again shortly
+		sync.LastError = err.Error()
+		sync.NextAttemptAt = now.Add(s.options.RetryBaseDelay)
+	case errors.As(err, &dependencyFailed):
+		sync.Status = domain.SatuSehatFailed
+		sync.LastError = err.Error()
+	default:
+		sync.Attempts++
+		sync.LastError = retry.LastError(err)
+
+		next, ok := s.options.Next(sync.Attempts, retryable(err), now)
+		if !ok {
+			sync.Status = domain.SatuSehatFailed
+			l.Error("failed to sync resource", zap.Int("attempts", sync.Attempts), zap.Error(err))
+			return
+		}
+
+		sync.NextAttemptAt = next
+		l.Warn("failed to sync resource, retrying",
+			zap.Int("attempts", sync.Attempts),
+			zap.Time("next attempt", sync.NextAttemptAt),
+			zap.Error(err),
+		)
+	}
+}
+
+// create sends the resource of sync and returns its SATUSEHAT id. A patient
+// is registered in SATUSEHAT by the national registry, so it is looked up by
+// identity number rather than created.
+func (s SatuSehatService) create(ctx context.Context, sync *domain.SatuSehatSync) (string, error) {
+	remote := mapping.Remote{
+		OrganizationID: s.options.OrganizationID,
+		LocationID:     s.options.LocationID,
+	}
+
+	if sync.ResourceType == resource.TypePatient {
+		return s.client.FindPatient(ctx, sync.PatientID)
+	}
+
+	var err error
+	remote.PatientID, err = s.remoteID(ctx, resource.TypePatient, sync.PatientID, ulid.ULID{})
+	if err != nil {
+		return "", err
+	}
+
+	if sync.ResourceType == mapping.TypeEncounter {
+		encounter := domain.EncounterAcquire()
+		defer domain.EncounterRelease(encounter)
+
+		encounter.ID = sync.EncounterID
+		if err = s.satuSehatRepository.GetEncounter(ctx, encounter); err != nil {
+			return "", err
+		}
+
+		return s.client.Create(ctx, mapping.TypeEncounter, mapping.NewEncounter(encounter, remote))
+	}
+
+	remote.EncounterID, err = s.remoteID(ctx, mapping.TypeEncounter, sync.PatientID, sync.EncounterID)
+	if err != nil {
+		return "", err
+	}
+
+	record := domain.MedicalRecordAcquire()
+	defer domain.MedicalRecordRelease(record)
+
+	record.ID = sync.RecordID
+	if err = s.satuSehatRepository.GetRecord(ctx, record); err != nil {
+		return "", err
+	}
+
+	var payload any
+	switch sync.ResourceType {
+	case resource.TypeCondition:
+		payload = mapping.NewCondition(record, remote)
+	case resource.TypeObservation:
+		payload = mapping.NewObservation(record, remote)
+	case resource.TypeMedicationStatement:
+		payload = mapping.NewMedicationStatement(record, remote)
+	}
+
+	return s.client.Create(ctx, sync.ResourceType, payload)
+}
+
+// remoteID returns the SATUSEHAT id of the patient or encounter a resource
+// refers to.
+func (s SatuSehatService) remoteID(
+	ctx context.Context,
+	resourceType, patientID string,
+	encounterID ulid.ULID,
+) (string, error) {
+	filter := domain.FilterSatuSehatSyncAcquire()
+	defer domain.FilterSatuSehatSyncRelease(filter)
+
+	filter.ResourceType = resourceType
+	filter.PatientID = patientID
+	filter.EncounterID = encounterID
+	filter.Limit = 1
+
+	syncs := domain.SatuSehatSyncsAcquire()
+	defer domain.SatuSehatSyncsRelease(syncs)
+
+	syncs, err := s.satuSehatRepository.GetSyncs(ctx, filter, syncs)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case len(syncs) == 0 || syncs[0].Status == domain.SatuSehatPending:
+		return "", errWaiting{resourceType: resourceType}
+	case syncs[0].Status == domain.SatuSehatFailed:
+		return "", errDependencyFailed{resourceType: resourceType}
+	}
+
+	return syncs[0].RemoteID, nil
+}
+
+// retryable reports whether sending the resource again can succeed, network
+// errors and timeouts can, a resource SATUSEHAT turned down cannot.
+func retryable(err error) bool {
+	var statusErr client.StatusError
+	var handlerErr interface{ Status() int }
+
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.Retryable()
+	case errors.Is(err, client.ErrPatientNotFound), errors.As(err, &handlerErr):
+		return false
+	}
+
+	return true
+}
+
+func (s SatuSehatService) GetSyncs(
+	ctx context.Context,
+	filter *domain.FilterSatuSehatSync,
+	syncs domain.SatuSehatSyncs,
+) (domain.SatuSehatSyncs, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[SatuSehatService.GetSyncs]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	syncs, err := s.satuSehatRepository.GetSyncs(ctx, filter, syncs)
+	if err != nil {
+		l.Error("failed to get syncs", zap.Error(err))
+		return syncs, err
+	}
+
+	return syncs, nil
+}
+
+// RetrySync queues a failed sync again, along with the ones that failed
+// because they refer to it.
+func (s SatuSehatService) RetrySync(ctx context.Context, sync *domain.SatuSehatSync) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[SatuSehatService.RetrySync]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.satuSehatRepository.Retry(ctx, sync, time.Now())
+	if err != nil {
+		l.Error("failed to retry sync", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+var _ SatuSehatServiceContract = (*SatuSehatService)(nil)
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type SatuSehatServiceContract interface {
+	Sync(ctx context.Context, now time.Time) error
+	GetSyncs(
+		ctx context.Context,
+		filter *domain.FilterSatuSehatSync,
+		syncs domain.SatuSehatSyncs,
+	) (domain.SatuSehatSyncs, error)
+	RetrySync(ctx context.Context, sync *domain.SatuSehatSync) error
+}
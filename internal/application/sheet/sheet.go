@@ -0,0 +1,183 @@
+// Package sheet writes rows as a CSV or XLSX spreadsheet, for exports that
+// are read row by row from the database.
+//
+// A CSV file reaches the writer as its rows are written. An XLSX file is a
+// zip archive that can only be written once it is complete, so its rows are
+// kept by excelize, on disk once they outgrow its memory buffer, and the
+// file is written on Close.
+package sheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// flushEvery is how many rows of a CSV file are written between flushes, so
+// a large export reaches the client as it is read.
+const flushEvery = 100
+
+var ErrUnsupportedFormat = errors.New("format must be either csv or xlsx")
+
+// ContentType is the media type of a spreadsheet in format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return ContentTypeXLSX
+	}
+	return ContentTypeCSV
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// Writer writes the rows of one sheet. Values are strings, ints or bools.
+type Writer struct {
+	w      io.Writer
+	format string
+
+	csv  *csv.Writer
+	rows int
+
+	file   *excelize.File
+	stream *excelize.StreamWriter
+}
+
+// NewWriter starts a spreadsheet in format with a header row, name is the
+// name of the sheet of an XLSX file.
+func NewWriter(w io.Writer, format, name string, header []string) (*Writer, error) {
+	sw := &Writer{w: w, format: format}
+
+	switch format {
+	case FormatCSV:
+		sw.csv = csv.NewWriter(w)
+	case FormatXLSX:
+		sw.file = excelize.NewFile()
+		if err := sw.file.SetSheetName(sw.file.GetSheetName(0), name); err != nil {
+			return nil, err
+		}
+
+		stream, err := sw.file.NewStreamWriter(name)
+		if err != nil {
+			return nil, err
+		}
+		sw.stream = stream
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	values := make([]any, 0, len(header))
+	for _, column := range header {
+		values = append(values, column)
+	}
+
+	if err := sw.Write(values...); err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+// Write adds a row.
+func (sw *Writer) Write(values ...any) error {
+	if sw.format == FormatCSV {
+		record := make([]string, 0, len(values))
+		for _, value := range values {
+			record = append(record, csvValue(value))
+		}
+		if err := sw.csv.Write(record); err != nil {
+			return err
+		}
+
+		sw.rows++
+		if sw.rows%flushEvery == 0 {
+			return sw.flush()
+		}
+		return nil
+	}
+
+	sw.rows++
+	cell, err := excelize.CoordinatesToCellName(1, sw.rows)
+	if err != nil {
+		return err
+	}
+
+	return sw.stream.SetRow(cell, values)
+}
+
+// flush sends the rows of a CSV file written so far on to the client.
+func (sw *Writer) flush() error {
+	sw.csv.Flush()
+	if err := sw.csv.Error(); err != nil {
+		return err
+	}
+
+	if f, ok := sw.w.(flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// Close finishes the spreadsheet, an XLSX file is written as a whole here.
+func (sw *Writer) Close() error {
+	if sw.format == FormatCSV {
+		sw.csv.Flush()
+		return sw.csv.Error()
+	}
+
+	defer func() {
+		_ = sw.file.Close()
+	}()
+
+	if err := sw.stream.Flush(); err != nil {
+		return err
+	}
+
+	_, err := sw.file.WriteTo(sw.w)
+	return err
+}
+
+// csvValue formats value for a CSV cell. Text that a spreadsheet would take
+// for a formula is prefixed with a quote, a phone number such as +62812 is
+// left as it is.
+func csvValue(value any) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+
+	if text == "" {
+		return text
+	}
+
+	switch text[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + text
+	case '+', '-':
+		if strings.IndexFunc(text[1:], func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+			return "'" + text
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/shift/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	shiftIDFromParam = "id"
+	swapIDFromParam  = "id"
+)
+
+type shiftHandler struct {
+	shiftService service.ShiftServiceContract
+}
+
+func NewShiftHandler(router fiber.Router, jwtMiddleware fiber.Handler, shiftService service.ShiftServiceContract) {
+	handler := shiftHandler{
+		shiftService: shiftService,
+	}
+
+	shiftRouter := router.Group("/shift", jwtMiddleware)
+	shiftRouter.Post("", handler.CreateShift)
+	shiftRouter.Get("", handler.GetShifts)
+	shiftRouter.Post("/publish", handler.PublishShifts)
+	shiftRouter.Get("/swap", handler.GetSwaps)
+	shiftRouter.Post("/swap/:"+swapIDFromParam+"/approve", handler.ApproveSwap)
+	shiftRouter.Post("/swap/:"+swapIDFromParam+"/reject", handler.RejectSwap)
+	shiftRouter.Delete("/:"+shiftIDFromParam, handler.DeleteShift)
+	shiftRouter.Post("/:"+shiftIDFromParam+"/swap", handler.RequestSwap)
+
+	router.Get("/user/me/shifts", jwtMiddleware, handler.GetMyShifts)
+}
+
+func (h shiftHandler) CreateShift(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.CreateShift]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createShiftReqAcquire()
+	defer createShiftReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	shift := domain.ShiftAcquire()
+	defer domain.ShiftRelease(shift)
+
+	shift.NurseID = req.nurseID
+	shift.WardID = req.wardID
+	shift.StartAt = req.startAt
+	shift.EndAt = req.endAt
+
+	err := h.shiftService.CreateShift(userCtx, shift, user)
+	if err != nil {
+		l.Error("failed to create shift", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Shift created successfully"
+	res.Data = newShiftRes(shift)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h shiftHandler) DeleteShift(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.DeleteShift]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	shiftID, err := ulid.Parse(c.Params(shiftIDFromParam))
+	if err != nil {
+		l.Error("error parsing shiftIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	shift := domain.ShiftAcquire()
+	defer domain.ShiftRelease(shift)
+
+	shift.ID = shiftID
+
+	err = h.shiftService.DeleteShift(userCtx, shift, user)
+	if err != nil {
+		l.Error("failed to delete shift", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Shift deleted successfully"
+
+	return c.JSON(res)
+}
+
+func (h shiftHandler) PublishShifts(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.PublishShifts]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := publishShiftsReqAcquire()
+	defer publishShiftsReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	filter := domain.FilterShiftAcquire()
+	defer domain.FilterShiftRelease(filter)
+
+	filter.WardID = req.wardID
+	filter.From = req.from
+	filter.To = req.to
+
+	published, err := h.shiftService.PublishShifts(userCtx, filter, user)
+	if err != nil {
+		l.Error("failed to publish shifts", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Roster published successfully"
+	res.Data = fiber.Map{
+		"wardId":    req.wardID,
+		"published": published,
+	}
+
+	return c.JSON(res)
+}
+
+func (h shiftHandler) GetShifts(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.GetShifts]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryShiftAcquire()
+	defer queryShiftRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterShiftAcquire()
+	defer domain.FilterShiftRelease(filter)
+
+	query.toFilter(filter)
+
+	return h.getShifts(c, l, filter, "Shifts retrieved successfully")
+}
+
+// GetMyShifts lists the published shifts of the logged-in nurse, from today
+// for the next two weeks unless told otherwise.
+func (h shiftHandler) GetMyShifts(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.GetMyShifts]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryShiftAcquire()
+	defer queryShiftRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterShiftAcquire()
+	defer domain.FilterShiftRelease(filter)
+
+	query.toFilter(filter)
+
+	filter.WardID = ulid.ULID{}
+	filter.NurseID = c.Locals(domain.UserFromToken).(domain.User).ID
+	filter.Status = domain.ShiftPublished
+
+	if filter.From.IsZero() {
+		now := time.Now()
+		filter.From = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+
+	if filter.To.IsZero() {
+		filter.To = filter.From.AddDate(0, 0, myShiftsDays)
+	}
+
+	return h.getShifts(c, l, filter, "Shifts retrieved successfully")
+}
+
+func (h shiftHandler) getShifts(c *fiber.Ctx, l *zap.Logger, filter *domain.FilterShift, message string) error {
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	shifts := domain.ShiftsAcquire()
+	defer domain.ShiftsRelease(shifts)
+
+	shifts, err := h.shiftService.GetShifts(c.UserContext(), filter, user, shifts)
+	if err != nil {
+		l.Error("failed to get shifts", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+
+	shiftsRes := getShiftsResAcquire()
+	defer getShiftsResRelease(shiftsRes)
+
+	for i := range shifts {
+		shiftsRes = append(shiftsRes, newShiftRes(&shifts[i]))
+	}
+
+	res.Data = shiftsRes
+
+	return c.JSON(res)
+}
+
+func (h shiftHandler) RequestSwap(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.RequestSwap]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	shiftID, err := ulid.Parse(c.Params(shiftIDFromParam))
+	if err != nil {
+		l.Error("error parsing shiftIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := swapShiftReqAcquire()
+	defer swapShiftReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	swap := domain.ShiftSwapAcquire()
+	defer domain.ShiftSwapRelease(swap)
+
+	swap.Shift.ID = shiftID
+	swap.TargetNurseID = req.targetNurseID
+	swap.TargetShift.ID = req.targetShiftID
+	swap.Reason = req.Reason
+
+	err = h.shiftService.RequestSwap(userCtx, swap, user)
+	if err != nil {
+		l.Error("failed to request shift swap", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Shift swap requested successfully"
+	res.Data = newShiftSwapRes(swap)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h shiftHandler) ApproveSwap(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.ApproveSwap]"
+	return h.decideSwap(c, callerInfo, h.shiftService.ApproveSwap, "Shift swap approved successfully")
+}
+
+func (h shiftHandler) RejectSwap(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.RejectSwap]"
+	return h.decideSwap(c, callerInfo, h.shiftService.RejectSwap, "Shift swap rejected successfully")
+}
+
+func (h shiftHandler) decideSwap(
+	c *fiber.Ctx,
+	callerInfo string,
+	decide func(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error,
+	message string,
+) error {
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	swapID, err := ulid.Parse(c.Params(swapIDFromParam))
+	if err != nil {
+		l.Error("error parsing swapIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	swap := domain.ShiftSwapAcquire()
+	defer domain.ShiftSwapRelease(swap)
+
+	swap.ID = swapID
+
+	err = decide(userCtx, swap, user)
+	if err != nil {
+		l.Error("failed to decide shift swap", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+	res.Data = newShiftSwapRes(swap)
+
+	return c.JSON(res)
+}
+
+func (h shiftHandler) GetSwaps(c *fiber.Ctx) error {
+	callerInfo := "[shiftHandler.GetSwaps]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryShiftSwapAcquire()
+	defer queryShiftSwapRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	filter := domain.FilterShiftSwapAcquire()
+	defer domain.FilterShiftSwapRelease(filter)
+
+	filter.WardID = query.wardID
+	filter.Status = query.Status
+	filter.Limit = query.Limit
+	filter.Offset = query.Offset
+
+	swaps := domain.ShiftSwapsAcquire()
+	defer domain.ShiftSwapsRelease(swaps)
+
+	swaps, err := h.shiftService.GetSwaps(userCtx, filter, user, swaps)
+	if err != nil {
+		l.Error("failed to get shift swaps", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Shift swaps retrieved successfully"
+
+	swapsRes := make([]shiftSwapRes, 0, len(swaps))
+	for i := range swaps {
+		swapsRes = append(swapsRes, newShiftSwapRes(&swaps[i]))
+	}
+
+	res.Data = swapsRes
+
+	return c.JSON(res)
+}
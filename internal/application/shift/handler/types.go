@@ -0,0 +1,461 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/timeparam"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	dateFormat = "2006-01-02T15:04:05.999Z"
+
+	shiftsInitCap = 5
+
+	// myShiftsDays is how far ahead /user/me/shifts looks without a "to"
+	myShiftsDays = 14
+)
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+var createShiftReqPool = sync.Pool{
+	New: func() any {
+		return new(createShiftReq)
+	},
+}
+
+func createShiftReqAcquire() *createShiftReq {
+	return createShiftReqPool.Get().(*createShiftReq)
+}
+
+func createShiftReqRelease(t *createShiftReq) {
+	*t = createShiftReq{}
+	createShiftReqPool.Put(t)
+}
+
+type createShiftReq struct {
+	NurseID string `json:"nurseId"`
+	nurseID ulid.ULID
+	WardID  string `json:"wardId"`
+	wardID  ulid.ULID
+	StartAt string `json:"startAt"`
+	startAt time.Time
+	EndAt   string `json:"endAt"`
+	endAt   time.Time
+}
+
+func (r *createShiftReq) validate() error {
+	var errs error
+
+	nurseID, err := ulid.Parse(r.NurseID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("nurseId must be a valid user id"))
+	}
+	r.nurseID = nurseID
+
+	wardID, err := ulid.Parse(r.WardID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("wardId must be a valid ward id"))
+	}
+	r.wardID = wardID
+
+	startAt, err := time.Parse(time.RFC3339Nano, r.StartAt)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("startAt must be in ISO 8601 format"))
+	}
+	r.startAt = startAt.In(time.Local)
+
+	endAt, err := time.Parse(time.RFC3339Nano, r.EndAt)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("endAt must be in ISO 8601 format"))
+	}
+	r.endAt = endAt.In(time.Local)
+
+	if errs == nil && !r.endAt.After(r.startAt) {
+		errs = multierr.Append(errs, errors.New("endAt must be after startAt"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var publishShiftsReqPool = sync.Pool{
+	New: func() any {
+		return new(publishShiftsReq)
+	},
+}
+
+func publishShiftsReqAcquire() *publishShiftsReq {
+	return publishShiftsReqPool.Get().(*publishShiftsReq)
+}
+
+func publishShiftsReqRelease(t *publishShiftsReq) {
+	*t = publishShiftsReq{}
+	publishShiftsReqPool.Put(t)
+}
+
+// publishShiftsReq publishes the draft shifts of a ward starting between
+// From and To, a bare To date includes the whole day.
+type publishShiftsReq struct {
+	WardID string `json:"wardId"`
+	wardID ulid.ULID
+	From   string `json:"from"`
+	from   time.Time
+	To     string `json:"to"`
+	to     time.Time
+}
+
+func (r *publishShiftsReq) validate() error {
+	var errs error
+
+	wardID, err := ulid.Parse(r.WardID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("wardId must be a valid ward id"))
+	}
+	r.wardID = wardID
+
+	from, _, ok := timeparam.Parse(r.From)
+	if !ok {
+		errs = multierr.Append(errs, errors.New("from must be a date or in ISO 8601 format"))
+	}
+	r.from = from
+
+	to, dateOnly, ok := timeparam.Parse(r.To)
+	if !ok {
+		errs = multierr.Append(errs, errors.New("to must be a date or in ISO 8601 format"))
+	}
+	if dateOnly {
+		to = to.AddDate(0, 0, 1)
+	}
+	r.to = to
+
+	if errs == nil && !r.to.After(r.from) {
+		errs = multierr.Append(errs, errors.New("to must be after from"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var swapShiftReqPool = sync.Pool{
+	New: func() any {
+		return new(swapShiftReq)
+	},
+}
+
+func swapShiftReqAcquire() *swapShiftReq {
+	return swapShiftReqPool.Get().(*swapShiftReq)
+}
+
+func swapShiftReqRelease(t *swapShiftReq) {
+	*t = swapShiftReq{}
+	swapShiftReqPool.Put(t)
+}
+
+// swapShiftReq hands the shift over to TargetNurseID, or exchanges it for
+// TargetShiftID when given.
+type swapShiftReq struct {
+	TargetNurseID string `json:"targetNurseId"`
+	targetNurseID ulid.ULID
+	TargetShiftID string `json:"targetShiftId"`
+	targetShiftID ulid.ULID
+	Reason        string `json:"reason"`
+}
+
+func (r *swapShiftReq) validate() error {
+	var errs error
+
+	targetNurseID, err := ulid.Parse(r.TargetNurseID)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("targetNurseId must be a valid user id"))
+	}
+	r.targetNurseID = targetNurseID
+
+	if r.TargetShiftID != "" {
+		targetShiftID, err := ulid.Parse(r.TargetShiftID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("targetShiftId must be a valid shift id"))
+		}
+		r.targetShiftID = targetShiftID
+	}
+
+	if r.Reason == "" {
+		errs = multierr.Append(errs, errors.New("reason is required"))
+	} else if utf8.RuneCountInString(r.Reason) > 200 {
+		errs = multierr.Append(errs, errors.New("reason must have at most 200 characters"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var queryShiftPool = sync.Pool{
+	New: func() any {
+		return new(queryShift)
+	},
+}
+
+func queryShiftAcquire() *queryShift {
+	return queryShiftPool.Get().(*queryShift)
+}
+
+func queryShiftRelease(t *queryShift) {
+	*t = queryShift{}
+	queryShiftPool.Put(t)
+}
+
+type queryShift struct {
+	WardID  string `query:"wardId"`
+	wardID  ulid.ULID
+	NurseID string `query:"nurseId"`
+	nurseID ulid.ULID
+	Status  string `query:"status"`
+	From    string `query:"from"`
+	from    time.Time
+	To      string `query:"to"`
+	to      time.Time
+	Limit   int `query:"limit"`
+	Offset  int `query:"offset"`
+}
+
+func (q *queryShift) validate() {
+	if q.WardID != "" {
+		q.wardID, _ = ulid.Parse(q.WardID)
+	}
+
+	if q.NurseID != "" {
+		q.nurseID, _ = ulid.Parse(q.NurseID)
+	}
+
+	if q.Status != domain.ShiftDraft && q.Status != domain.ShiftPublished {
+		q.Status = ""
+	}
+
+	if from, _, ok := timeparam.Parse(q.From); ok {
+		q.from = from
+	}
+
+	// a bare date includes the whole day
+	if to, dateOnly, ok := timeparam.Parse(q.To); ok {
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		q.to = to
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *queryShift) toFilter(filter *domain.FilterShift) {
+	filter.WardID = q.wardID
+	filter.NurseID = q.nurseID
+	filter.Status = q.Status
+	filter.From = q.from
+	filter.To = q.to
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+}
+
+var queryShiftSwapPool = sync.Pool{
+	New: func() any {
+		return new(queryShiftSwap)
+	},
+}
+
+func queryShiftSwapAcquire() *queryShiftSwap {
+	return queryShiftSwapPool.Get().(*queryShiftSwap)
+}
+
+func queryShiftSwapRelease(t *queryShiftSwap) {
+	*t = queryShiftSwap{}
+	queryShiftSwapPool.Put(t)
+}
+
+type queryShiftSwap struct {
+	WardID string `query:"wardId"`
+	wardID ulid.ULID
+	Status string `query:"status"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+func (q *queryShiftSwap) validate() {
+	if q.WardID != "" {
+		q.wardID, _ = ulid.Parse(q.WardID)
+	}
+
+	if q.Status != domain.ShiftSwapPending && q.Status != domain.ShiftSwapApproved &&
+		q.Status != domain.ShiftSwapRejected {
+		q.Status = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+type shiftWardRes struct {
+	WardID ulid.ULID `json:"wardId"`
+	Code   string    `json:"code"`
+	Name   string    `json:"name"`
+}
+
+type shiftNurseRes struct {
+	UserID ulid.ULID `json:"userId"`
+	NIP    uint      `json:"nip,omitempty"`
+	Name   string    `json:"name"`
+}
+
+type shiftRes struct {
+	ShiftID     ulid.ULID     `json:"shiftId"`
+	Ward        shiftWardRes  `json:"ward"`
+	Nurse       shiftNurseRes `json:"nurse"`
+	StartAt     string        `json:"startAt"`
+	EndAt       string        `json:"endAt"`
+	Status      string        `json:"status"`
+	PublishedAt string        `json:"publishedAt,omitempty"`
+	CreatedAt   string        `json:"createdAt,omitempty"`
+}
+
+func newShiftRes(shift *domain.Shift) shiftRes {
+	nip, _ := strconv.Atoi(shift.NurseNIP)
+
+	res := shiftRes{
+		ShiftID: shift.ID,
+		Ward: shiftWardRes{
+			WardID: shift.WardID,
+			Code:   shift.WardCode,
+			Name:   shift.WardName,
+		},
+		Nurse: shiftNurseRes{
+			UserID: shift.NurseID,
+			NIP:    uint(nip),
+			Name:   shift.NurseName,
+		},
+		StartAt: shift.StartAt.Format(dateFormat),
+		EndAt:   shift.EndAt.Format(dateFormat),
+		Status:  shift.Status,
+	}
+	if !shift.PublishedAt.IsZero() {
+		res.PublishedAt = shift.PublishedAt.Format(dateFormat)
+	}
+	if !shift.CreatedAt.IsZero() {
+		res.CreatedAt = shift.CreatedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+var getShiftsResPool = sync.Pool{
+	New: func() any {
+		return make(getShiftsRes, 0, shiftsInitCap)
+	},
+}
+
+func getShiftsResAcquire() getShiftsRes {
+	return getShiftsResPool.Get().(getShiftsRes)
+}
+
+func getShiftsResRelease(t getShiftsRes) {
+	t = t[:0]
+	getShiftsResPool.Put(t) // nolint:staticcheck
+}
+
+type getShiftsRes []shiftRes
+
+type shiftSwapRes struct {
+	SwapID      ulid.ULID     `json:"swapId"`
+	Shift       shiftRes      `json:"shift"`
+	RequestedBy shiftNurseRes `json:"requestedBy"`
+	TargetNurse shiftNurseRes `json:"targetNurse"`
+	TargetShift *shiftRes     `json:"targetShift"`
+	Status      string        `json:"status"`
+	Reason      string        `json:"reason"`
+	DecidedAt   string        `json:"decidedAt,omitempty"`
+	CreatedAt   string        `json:"createdAt"`
+}
+
+func newShiftSwapRes(swap *domain.ShiftSwap) shiftSwapRes {
+	res := shiftSwapRes{
+		SwapID: swap.ID,
+		Shift:  newShiftRes(&swap.Shift),
+		RequestedBy: shiftNurseRes{
+			UserID: swap.RequestedBy,
+			Name:   swap.RequestedByName,
+		},
+		TargetNurse: shiftNurseRes{
+			UserID: swap.TargetNurseID,
+			Name:   swap.TargetNurseName,
+		},
+		Status:    swap.Status,
+		Reason:    swap.Reason,
+		CreatedAt: swap.CreatedAt.Format(dateFormat),
+	}
+	if !id.IsZero(swap.TargetShift.ID) {
+		targetShift := newShiftRes(&swap.TargetShift)
+		res.TargetShift = &targetShift
+	}
+	if !swap.DecidedAt.IsZero() {
+		res.DecidedAt = swap.DecidedAt.Format(dateFormat)
+	}
+
+	return res
+}
@@ -0,0 +1,36 @@
+package shift
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/shift/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/shift/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/shift/service"
+)
+
+const (
+	defaultMinRestHours   = 11
+	defaultMaxLengthHours = 16
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	minRestHours := configs.Get().Shift.MinRestHours
+	if minRestHours == 0 {
+		minRestHours = defaultMinRestHours
+	}
+
+	maxLengthHours := configs.Get().Shift.MaxLengthHours
+	if maxLengthHours == 0 {
+		maxLengthHours = defaultMaxLengthHours
+	}
+
+	shiftRepository := repository.NewShiftRepository(db, time.Duration(minRestHours)*time.Hour)
+	shiftService := service.NewShiftService(ctxTimeout, time.Duration(maxLengthHours)*time.Hour, shiftRepository)
+	handler.NewShiftHandler(router, jwtMiddleware, shiftService)
+}
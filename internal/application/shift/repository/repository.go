@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type ShiftRepositoryContract interface {
+	IsHeadNurse(ctx context.Context, wardID, userID ulid.ULID) (bool, error)
+	CreateShift(ctx context.Context, shift *domain.Shift) error
+	GetShift(ctx context.Context, shift *domain.Shift) error
+	DeleteShift(ctx context.Context, shift *domain.Shift) error
+	PublishShifts(ctx context.Context, filter *domain.FilterShift, user *domain.User) (int64, error)
+	GetShifts(ctx context.Context, filter *domain.FilterShift, shifts domain.Shifts) (domain.Shifts, error)
+	RequestSwap(ctx context.Context, swap *domain.ShiftSwap) error
+	GetSwap(ctx context.Context, swap *domain.ShiftSwap) error
+	ApproveSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error
+	RejectSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error
+	GetSwaps(
+		ctx context.Context,
+		filter *domain.FilterShiftSwap,
+		swaps domain.ShiftSwaps,
+	) (domain.ShiftSwaps, error)
+}
@@ -0,0 +1,855 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	shiftColumns = `s.id, s.ward_id, w.code, w.name, s.nurse_id, u.nip, u.name, s.start_at, s.end_at, s.status, 
+		s.created_by, s.created_at, s.published_at`
+	shiftTables = ` FROM shifts s JOIN wards w ON w.id = s.ward_id JOIN users u ON u.id = s.nurse_id`
+
+	swapColumns = `sw.id, sw.requested_by, rn.name, sw.target_nurse_id, tn.name, sw.status, sw.reason, 
+		sw.decided_by, sw.decided_at, sw.created_at, ` + shiftColumns + `, 
+		t.id, t.nurse_id, tu.nip, tu.name, t.start_at, t.end_at, t.status`
+	swapTables = ` FROM shift_swaps sw 
+		JOIN shifts s ON s.id = sw.shift_id 
+		JOIN wards w ON w.id = s.ward_id 
+		JOIN users u ON u.id = s.nurse_id
+		JOIN users rn ON rn.id = sw.requested_by
+		JOIN users tn ON tn.id = sw.target_nurse_id
+		LEFT JOIN shifts t ON t.id = sw.target_shift_id
+		LEFT JOIN users tu ON tu.id = t.nurse_id`
+
+	pendingSwapIndex = "idx_shift_swaps_shift_id_pending"
+)
+
+type ShiftRepository struct {
+	db      *pgxpool.Pool
+	minRest time.Duration
+}
+
+func NewShiftRepository(db *pgxpool.Pool, minRest time.Duration) *ShiftRepository {
+	return &ShiftRepository{db: db, minRest: minRest}
+}
+
+func (r ShiftRepository) IsHeadNurse(ctx context.Context, wardID, userID ulid.ULID) (bool, error) {
+	callerInfo := "[ShiftRepository.IsHeadNurse]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var isHeadNurse bool
+	selectQuery := `SELECT EXISTS (SELECT 1 FROM wards WHERE id = @ward_id AND head_nurse_id = @user_id)`
+	args := pgx.NamedArgs{
+		"ward_id": wardID,
+		"user_id": userID,
+	}
+
+	if err := r.db.QueryRow(ctx, selectQuery, args).Scan(&isHeadNurse); err != nil {
+		l.Error("failed to check head nurse", zap.Error(err))
+		return false, err
+	}
+
+	return isHeadNurse, nil
+}
+
+func (r ShiftRepository) CreateShift(ctx context.Context, shift *domain.Shift) error {
+	callerInfo := "[ShiftRepository.CreateShift]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	shift.ID = id.New()
+	shift.Status = domain.ShiftDraft
+	shift.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.lockNurses(ctx, tx, shift.NurseID); err != nil {
+		l.Error("failed to lock nurse", zap.Error(err))
+		return err
+	}
+
+	if err = r.checkSchedule(ctx, tx, shift.NurseID, shift.StartAt, shift.EndAt); err != nil {
+		l.Error("shift does not fit the schedule of the nurse", zap.Error(err))
+		return err
+	}
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO shifts (id, ward_id, nurse_id, start_at, end_at, status, created_by, created_at) 
+			VALUES (@id, @ward_id, @nurse_id, @start_at, @end_at, @status, @created_by, @created_at)
+			RETURNING ward_id, nurse_id
+		)
+		SELECT w.code, w.name, u.nip, u.name FROM inserted i 
+		JOIN wards w ON w.id = i.ward_id JOIN users u ON u.id = i.nurse_id`
+	args := pgx.NamedArgs{
+		"id":         shift.ID,
+		"ward_id":    shift.WardID,
+		"nurse_id":   shift.NurseID,
+		"start_at":   shift.StartAt,
+		"end_at":     shift.EndAt,
+		"status":     shift.Status,
+		"created_by": shift.CreatedBy,
+		"created_at": shift.CreatedAt,
+	}
+
+	err = tx.QueryRow(ctx, insertQuery, args).Scan(&shift.WardCode, &shift.WardName, &shift.NurseNIP, &shift.NurseName)
+	if err != nil {
+		l.Error("failed to create shift", zap.Error(err))
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return new(domain.ErrWardNotFound)
+		}
+
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r ShiftRepository) GetShift(ctx context.Context, shift *domain.Shift) error {
+	callerInfo := "[ShiftRepository.GetShift]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	selectQuery := `SELECT ` + shiftColumns + shiftTables + ` WHERE s.id = @id`
+
+	err := r.scanShift(r.db.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": shift.ID}), shift)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrShiftNotFound)
+		}
+
+		l.Error("failed to get shift", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteShift removes a draft shift, published shifts are part of a roster
+// nurses have already seen and can only change hands through a swap.
+func (r ShiftRepository) DeleteShift(ctx context.Context, shift *domain.Shift) error {
+	callerInfo := "[ShiftRepository.DeleteShift]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	deleteQuery := `DELETE FROM shifts WHERE id = @id AND status = @status`
+	args := pgx.NamedArgs{
+		"id":     shift.ID,
+		"status": domain.ShiftDraft,
+	}
+
+	cmdTag, err := r.db.Exec(ctx, deleteQuery, args)
+	if err != nil {
+		l.Error("failed to delete shift", zap.Error(err))
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return new(domain.ErrShiftPublished)
+	}
+
+	return nil
+}
+
+// PublishShifts publishes the draft shifts of filter.WardID starting in
+// [filter.From, filter.To) and returns how many were published.
+func (r ShiftRepository) PublishShifts(
+	ctx context.Context,
+	filter *domain.FilterShift,
+	user *domain.User,
+) (int64, error) {
+	callerInfo := "[ShiftRepository.PublishShifts]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	updateQuery := `UPDATE shifts SET status = @published, published_by = @published_by, published_at = @published_at 
+		WHERE ward_id = @ward_id AND status = @draft AND start_at >= @from AND start_at < @to`
+	args := pgx.NamedArgs{
+		"published":    domain.ShiftPublished,
+		"draft":        domain.ShiftDraft,
+		"published_by": user.ID,
+		"published_at": time.Now(),
+		"ward_id":      filter.WardID,
+		"from":         filter.From,
+		"to":           filter.To,
+	}
+
+	cmdTag, err := r.db.Exec(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to publish shifts", zap.Error(err))
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r ShiftRepository) GetShifts(
+	ctx context.Context,
+	filter *domain.FilterShift,
+	shifts domain.Shifts,
+) (domain.Shifts, error) {
+	callerInfo := "[ShiftRepository.GetShifts]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterShift(filter)
+	getQuery := `SELECT ` + shiftColumns + shiftTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get shifts", zap.Error(err))
+		return shifts, err
+	}
+	defer rows.Close()
+
+	dShift := domain.ShiftAcquire()
+	defer domain.ShiftRelease(dShift)
+
+	for rows.Next() {
+		if err = r.scanShift(rows, dShift); err != nil {
+			l.Error("failed to scan shift", zap.Error(err))
+			return shifts, err
+		}
+		shifts = append(shifts, *dShift)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get shifts", zap.Error(err))
+		return shifts, err
+	}
+
+	return shifts, nil
+}
+
+func (r ShiftRepository) RequestSwap(ctx context.Context, swap *domain.ShiftSwap) error {
+	callerInfo := "[ShiftRepository.RequestSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	swap.ID = id.New()
+	swap.Status = domain.ShiftSwapPending
+	swap.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.lockShift(ctx, tx, &swap.Shift); err != nil {
+		l.Error("failed to lock shift", zap.Error(err))
+		return err
+	}
+
+	if !swappable(&swap.Shift, swap.RequestedBy, swap.CreatedAt) || swap.TargetNurseID == swap.RequestedBy {
+		return new(domain.ErrShiftNotSwappable)
+	}
+
+	var targetShiftID any
+	if !id.IsZero(swap.TargetShift.ID) {
+		if err = r.lockShift(ctx, tx, &swap.TargetShift); err != nil {
+			l.Error("failed to lock target shift", zap.Error(err))
+			return err
+		}
+
+		if !swappable(&swap.TargetShift, swap.TargetNurseID, swap.CreatedAt) ||
+			swap.TargetShift.WardID != swap.Shift.WardID {
+			return new(domain.ErrShiftNotSwappable)
+		}
+		targetShiftID = swap.TargetShift.ID
+	}
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO shift_swaps (id, shift_id, requested_by, target_nurse_id, target_shift_id, status, reason, created_at) 
+			SELECT @id, @shift_id, @requested_by, u.id, @target_shift_id, @status, @reason, @created_at 
+			FROM users u WHERE u.id = @target_nurse_id AND u.nip LIKE '303%'
+			RETURNING requested_by, target_nurse_id
+		)
+		SELECT rn.name, tn.name FROM inserted i 
+		JOIN users rn ON rn.id = i.requested_by JOIN users tn ON tn.id = i.target_nurse_id`
+	args := pgx.NamedArgs{
+		"id":              swap.ID,
+		"shift_id":        swap.Shift.ID,
+		"requested_by":    swap.RequestedBy,
+		"target_nurse_id": swap.TargetNurseID,
+		"target_shift_id": targetShiftID,
+		"status":          swap.Status,
+		"reason":          swap.Reason,
+		"created_at":      swap.CreatedAt,
+	}
+
+	err = tx.QueryRow(ctx, insertQuery, args).Scan(&swap.RequestedByName, &swap.TargetNurseName)
+	if err != nil {
+		l.Error("failed to request shift swap", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrUserNotFound)
+		}
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == pendingSwapIndex {
+			return new(domain.ErrShiftSwapPending)
+		}
+
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r ShiftRepository) GetSwap(ctx context.Context, swap *domain.ShiftSwap) error {
+	callerInfo := "[ShiftRepository.GetSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	selectQuery := `SELECT ` + swapColumns + swapTables + ` WHERE sw.id = @id`
+
+	err := r.scanSwap(r.db.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": swap.ID}), swap)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrShiftSwapNotFound)
+		}
+
+		l.Error("failed to get shift swap", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ApproveSwap hands the shift to the target nurse, and the target shift to
+// the requester for an exchange, after checking both nurses still own their
+// shifts and keep clear of overlaps and the minimum rest period.
+func (r ShiftRepository) ApproveSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error {
+	callerInfo := "[ShiftRepository.ApproveSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var targetShiftID *ulid.ULID
+	selectQuery := `SELECT shift_id, requested_by, target_nurse_id, target_shift_id, status 
+		FROM shift_swaps WHERE id = @id FOR UPDATE`
+	err = tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": swap.ID}).Scan(
+		&swap.Shift.ID,
+		&swap.RequestedBy,
+		&swap.TargetNurseID,
+		&targetShiftID,
+		&swap.Status,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrShiftSwapNotFound)
+		}
+
+		l.Error("failed to get shift swap", zap.Error(err))
+		return err
+	}
+
+	if swap.Status != domain.ShiftSwapPending {
+		return new(domain.ErrShiftSwapDecided)
+	}
+
+	now := time.Now()
+	if err = r.lockNurses(ctx, tx, swap.RequestedBy, swap.TargetNurseID); err != nil {
+		l.Error("failed to lock nurses", zap.Error(err))
+		return err
+	}
+
+	if err = r.lockShift(ctx, tx, &swap.Shift); err != nil {
+		l.Error("failed to lock shift", zap.Error(err))
+		return err
+	}
+
+	if !swappable(&swap.Shift, swap.RequestedBy, now) {
+		return new(domain.ErrShiftNotSwappable)
+	}
+
+	excludeIDs := []ulid.ULID{swap.Shift.ID}
+	swap.TargetShift = domain.Shift{}
+	if targetShiftID != nil {
+		swap.TargetShift.ID = *targetShiftID
+		if err = r.lockShift(ctx, tx, &swap.TargetShift); err != nil {
+			l.Error("failed to lock target shift", zap.Error(err))
+			return err
+		}
+
+		if !swappable(&swap.TargetShift, swap.TargetNurseID, now) {
+			return new(domain.ErrShiftNotSwappable)
+		}
+		excludeIDs = append(excludeIDs, swap.TargetShift.ID)
+	}
+
+	err = r.checkSchedule(ctx, tx, swap.TargetNurseID, swap.Shift.StartAt, swap.Shift.EndAt, excludeIDs...)
+	if err != nil {
+		l.Error("shift does not fit the schedule of the target nurse", zap.Error(err))
+		return err
+	}
+
+	if targetShiftID != nil {
+		err = r.checkSchedule(
+			ctx, tx, swap.RequestedBy, swap.TargetShift.StartAt, swap.TargetShift.EndAt, excludeIDs...,
+		)
+		if err != nil {
+			l.Error("target shift does not fit the schedule of the requester", zap.Error(err))
+			return err
+		}
+	}
+
+	updateShiftQuery := `UPDATE shifts SET nurse_id = @nurse_id WHERE id = @id`
+	_, err = tx.Exec(ctx, updateShiftQuery, pgx.NamedArgs{"id": swap.Shift.ID, "nurse_id": swap.TargetNurseID})
+	if err != nil {
+		l.Error("failed to reassign shift", zap.Error(err))
+		return err
+	}
+
+	if targetShiftID != nil {
+		_, err = tx.Exec(ctx, updateShiftQuery, pgx.NamedArgs{"id": swap.TargetShift.ID, "nurse_id": swap.RequestedBy})
+		if err != nil {
+			l.Error("failed to reassign target shift", zap.Error(err))
+			return err
+		}
+	}
+
+	if err = r.decideSwap(ctx, tx, swap, domain.ShiftSwapApproved, user, now); err != nil {
+		l.Error("failed to approve shift swap", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r ShiftRepository) RejectSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error {
+	callerInfo := "[ShiftRepository.RejectSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := r.decideSwap(ctx, r.db, swap, domain.ShiftSwapRejected, user, time.Now()); err != nil {
+		l.Error("failed to reject shift swap", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func (r ShiftRepository) decideSwap(
+	ctx context.Context,
+	db execer,
+	swap *domain.ShiftSwap,
+	status string,
+	user *domain.User,
+	decidedAt time.Time,
+) error {
+	updateQuery := `UPDATE shift_swaps SET status = @status, decided_by = @decided_by, decided_at = @decided_at 
+		WHERE id = @id AND status = @pending`
+	args := pgx.NamedArgs{
+		"id":         swap.ID,
+		"status":     status,
+		"pending":    domain.ShiftSwapPending,
+		"decided_by": user.ID,
+		"decided_at": decidedAt,
+	}
+
+	cmdTag, err := db.Exec(ctx, updateQuery, args)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return new(domain.ErrShiftSwapDecided)
+	}
+
+	swap.Status = status
+	swap.DecidedBy = user.ID
+	swap.DecidedAt = decidedAt
+
+	return nil
+}
+
+func (r ShiftRepository) GetSwaps(
+	ctx context.Context,
+	filter *domain.FilterShiftSwap,
+	swaps domain.ShiftSwaps,
+) (domain.ShiftSwaps, error) {
+	callerInfo := "[ShiftRepository.GetSwaps]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterShiftSwap(filter)
+	getQuery := `SELECT ` + swapColumns + swapTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get shift swaps", zap.Error(err))
+		return swaps, err
+	}
+	defer rows.Close()
+
+	dSwap := domain.ShiftSwapAcquire()
+	defer domain.ShiftSwapRelease(dSwap)
+
+	for rows.Next() {
+		if err = r.scanSwap(rows, dSwap); err != nil {
+			l.Error("failed to scan shift swap", zap.Error(err))
+			return swaps, err
+		}
+		swaps = append(swaps, *dSwap)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get shift swaps", zap.Error(err))
+		return swaps, err
+	}
+
+	return swaps, nil
+}
+
+// lockNurses serializes schedule changes per nurse so two concurrent writes
+// cannot both pass the overlap and rest period checks, rows are locked in id
+// order to keep swaps between the same nurses from deadlocking.
+func (r ShiftRepository) lockNurses(ctx context.Context, tx pgx.Tx, nurseIDs ...ulid.ULID) error {
+	ids := make([][]byte, 0, len(nurseIDs))
+	for _, nurseID := range nurseIDs {
+		ids = append(ids, nurseID.Bytes())
+	}
+
+	lockQuery := `SELECT id FROM users WHERE id = ANY(@ids) AND nip LIKE '303%' ORDER BY id FOR NO KEY UPDATE`
+	rows, err := tx.Query(ctx, lockQuery, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return err
+	}
+
+	var locked ulid.ULID
+	count, err := pgx.ForEachRow(rows, []any{&locked}, func() error { return nil })
+	if err != nil {
+		return err
+	}
+
+	if int(count.RowsAffected()) != len(nurseIDs) {
+		return new(domain.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// checkSchedule rejects [startAt, endAt) for nurseID when it overlaps another
+// of their shifts or leaves less than the minimum rest period around it.
+func (r ShiftRepository) checkSchedule(
+	ctx context.Context,
+	tx pgx.Tx,
+	nurseID ulid.ULID,
+	startAt, endAt time.Time,
+	excludeIDs ...ulid.ULID,
+) error {
+	exclude := make([][]byte, 0, len(excludeIDs))
+	for _, excludeID := range excludeIDs {
+		exclude = append(exclude, excludeID.Bytes())
+	}
+
+	var overlaps bool
+	selectQuery := `SELECT start_at < @end_at AND end_at > @start_at FROM shifts 
+		WHERE nurse_id = @nurse_id AND NOT (id = ANY(@exclude_ids)) AND start_at < @rest_end AND end_at > @rest_start
+		ORDER BY 1 DESC LIMIT 1`
+	args := pgx.NamedArgs{
+		"nurse_id":    nurseID,
+		"start_at":    startAt,
+		"end_at":      endAt,
+		"rest_start":  startAt.Add(-r.minRest),
+		"rest_end":    endAt.Add(r.minRest),
+		"exclude_ids": exclude,
+	}
+
+	err := tx.QueryRow(ctx, selectQuery, args).Scan(&overlaps)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if overlaps {
+		return new(domain.ErrShiftOverlap)
+	}
+	return new(domain.ErrShiftRestPeriod)
+}
+
+func (r ShiftRepository) lockShift(ctx context.Context, tx pgx.Tx, shift *domain.Shift) error {
+	selectQuery := `SELECT ` + shiftColumns + shiftTables + ` WHERE s.id = @id FOR UPDATE OF s`
+
+	err := r.scanShift(tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": shift.ID}), shift)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrShiftNotFound)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// swappable reports whether shift is an upcoming published shift of nurseID.
+func swappable(shift *domain.Shift, nurseID ulid.ULID, now time.Time) bool {
+	return shift.NurseID == nurseID && shift.Status == domain.ShiftPublished && shift.StartAt.After(now)
+}
+
+func (r ShiftRepository) scanShift(row pgx.Row, shift *domain.Shift) error {
+	var publishedAt *time.Time
+
+	err := row.Scan(
+		&shift.ID,
+		&shift.WardID,
+		&shift.WardCode,
+		&shift.WardName,
+		&shift.NurseID,
+		&shift.NurseNIP,
+		&shift.NurseName,
+		&shift.StartAt,
+		&shift.EndAt,
+		&shift.Status,
+		&shift.CreatedBy,
+		&shift.CreatedAt,
+		&publishedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	shift.PublishedAt = time.Time{}
+	if publishedAt != nil {
+		shift.PublishedAt = *publishedAt
+	}
+
+	return nil
+}
+
+func (r ShiftRepository) scanSwap(row pgx.Row, swap *domain.ShiftSwap) error {
+	var (
+		decidedAt, publishedAt                        *time.Time
+		targetStartAt, targetEndAt                    *time.Time
+		targetNurseNIP, targetNurseName, targetStatus *string
+	)
+
+	swap.DecidedBy, swap.TargetShift = ulid.ULID{}, domain.Shift{}
+
+	err := row.Scan(
+		&swap.ID,
+		&swap.RequestedBy,
+		&swap.RequestedByName,
+		&swap.TargetNurseID,
+		&swap.TargetNurseName,
+		&swap.Status,
+		&swap.Reason,
+		&swap.DecidedBy,
+		&decidedAt,
+		&swap.CreatedAt,
+		&swap.Shift.ID,
+		&swap.Shift.WardID,
+		&swap.Shift.WardCode,
+		&swap.Shift.WardName,
+		&swap.Shift.NurseID,
+		&swap.Shift.NurseNIP,
+		&swap.Shift.NurseName,
+		&swap.Shift.StartAt,
+		&swap.Shift.EndAt,
+		&swap.Shift.Status,
+		&swap.Shift.CreatedBy,
+		&swap.Shift.CreatedAt,
+		&publishedAt,
+		&swap.TargetShift.ID,
+		&swap.TargetShift.NurseID,
+		&targetNurseNIP,
+		&targetNurseName,
+		&targetStartAt,
+		&targetEndAt,
+		&targetStatus,
+	)
+	if err != nil {
+		return err
+	}
+
+	swap.DecidedAt, swap.Shift.PublishedAt = time.Time{}, time.Time{}
+	if decidedAt != nil {
+		swap.DecidedAt = *decidedAt
+	}
+	if publishedAt != nil {
+		swap.Shift.PublishedAt = *publishedAt
+	}
+
+	if targetStartAt != nil {
+		swap.TargetShift.WardID = swap.Shift.WardID
+		swap.TargetShift.WardCode = swap.Shift.WardCode
+		swap.TargetShift.WardName = swap.Shift.WardName
+		swap.TargetShift.NurseNIP = *targetNurseNIP
+		swap.TargetShift.NurseName = *targetNurseName
+		swap.TargetShift.StartAt = *targetStartAt
+		swap.TargetShift.EndAt = *targetEndAt
+		swap.TargetShift.Status = *targetStatus
+	}
+
+	return nil
+}
+
+func (r ShiftRepository) filterShift(filter *domain.FilterShift) (string, pgx.NamedArgs) {
+	const totalConditions = 7
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "s.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if !id.IsZero(filter.WardID) {
+		conditions = append(conditions, "s.ward_id = @ward_id")
+		params["ward_id"] = filter.WardID
+	}
+
+	if !id.IsZero(filter.NurseID) {
+		conditions = append(conditions, "s.nurse_id = @nurse_id")
+		params["nurse_id"] = filter.NurseID
+	}
+
+	if !id.IsZero(filter.ViewerID) {
+		conditions = append(conditions, "(s.status = @viewer_status OR w.head_nurse_id = @viewer_id)")
+		params["viewer_status"] = domain.ShiftPublished
+		params["viewer_id"] = filter.ViewerID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "s.status = @status")
+		params["status"] = filter.Status
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "s.start_at >= @from")
+		params["from"] = filter.From
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "s.start_at < @to")
+		params["to"] = filter.To
+	}
+
+	order := " ORDER BY s.start_at ASC, w.code ASC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+func (r ShiftRepository) filterShiftSwap(filter *domain.FilterShiftSwap) (string, pgx.NamedArgs) {
+	const totalConditions = 3
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.WardID) {
+		conditions = append(conditions, "s.ward_id = @ward_id")
+		params["ward_id"] = filter.WardID
+	}
+
+	if !id.IsZero(filter.ViewerID) {
+		conditions = append(
+			conditions,
+			"(sw.requested_by = @viewer_id OR sw.target_nurse_id = @viewer_id OR w.head_nurse_id = @viewer_id)",
+		)
+		params["viewer_id"] = filter.ViewerID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "sw.status = @status")
+		params["status"] = filter.Status
+	}
+
+	order := " ORDER BY sw.created_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ ShiftRepositoryContract = (*ShiftRepository)(nil)
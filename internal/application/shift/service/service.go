@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type ShiftServiceContract interface {
+	CreateShift(ctx context.Context, shift *domain.Shift, user *domain.User) error
+	DeleteShift(ctx context.Context, shift *domain.Shift, user *domain.User) error
+	PublishShifts(ctx context.Context, filter *domain.FilterShift, user *domain.User) (int64, error)
+	GetShifts(
+		ctx context.Context,
+		filter *domain.FilterShift,
+		user *domain.User,
+		shifts domain.Shifts,
+	) (domain.Shifts, error)
+	RequestSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error
+	ApproveSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error
+	RejectSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error
+	GetSwaps(
+		ctx context.Context,
+		filter *domain.FilterShiftSwap,
+		user *domain.User,
+		swaps domain.ShiftSwaps,
+	) (domain.ShiftSwaps, error)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/shift/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type ShiftService struct {
+	shiftRepository repository.ShiftRepositoryContract
+	contextTimeout  time.Duration
+	maxShiftLength  time.Duration
+}
+
+func NewShiftService(
+	timeout, maxShiftLength time.Duration,
+	shiftRepository repository.ShiftRepositoryContract,
+) *ShiftService {
+	return &ShiftService{
+		shiftRepository: shiftRepository,
+		contextTimeout:  timeout,
+		maxShiftLength:  maxShiftLength,
+	}
+}
+
+func (s ShiftService) CreateShift(ctx context.Context, shift *domain.Shift, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.CreateShift]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if shift.EndAt.Sub(shift.StartAt) > s.maxShiftLength {
+		return new(domain.ErrShiftTooLong)
+	}
+
+	if err := s.authorizeRoster(ctx, shift.WardID, user); err != nil {
+		l.Error("user cannot manage the roster", zap.Error(err))
+		return err
+	}
+
+	shift.CreatedBy = user.ID
+
+	err := s.shiftRepository.CreateShift(ctx, shift)
+	if err != nil {
+		l.Error("failed to create shift", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s ShiftService) DeleteShift(ctx context.Context, shift *domain.Shift, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.DeleteShift]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.shiftRepository.GetShift(ctx, shift)
+	if err != nil {
+		l.Error("failed to get shift", zap.Error(err))
+		return err
+	}
+
+	if err = s.authorizeRoster(ctx, shift.WardID, user); err != nil {
+		l.Error("user cannot manage the roster", zap.Error(err))
+		return err
+	}
+
+	err = s.shiftRepository.DeleteShift(ctx, shift)
+	if err != nil {
+		l.Error("failed to delete shift", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s ShiftService) PublishShifts(ctx context.Context, filter *domain.FilterShift, user *domain.User) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.PublishShifts]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := s.authorizeRoster(ctx, filter.WardID, user); err != nil {
+		l.Error("user cannot manage the roster", zap.Error(err))
+		return 0, err
+	}
+
+	published, err := s.shiftRepository.PublishShifts(ctx, filter, user)
+	if err != nil {
+		l.Error("failed to publish shifts", zap.Error(err))
+		return 0, err
+	}
+
+	return published, nil
+}
+
+func (s ShiftService) GetShifts(
+	ctx context.Context,
+	filter *domain.FilterShift,
+	user *domain.User,
+	shifts domain.Shifts,
+) (domain.Shifts, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.GetShifts]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	// nurses only see drafts of the wards they head
+	if user.Role != domain.RoleIT {
+		filter.ViewerID = user.ID
+	}
+
+	shifts, err := s.shiftRepository.GetShifts(ctx, filter, shifts)
+	if err != nil {
+		l.Error("failed to get shifts", zap.Error(err))
+		return nil, err
+	}
+
+	return shifts, nil
+}
+
+func (s ShiftService) RequestSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.RequestSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	swap.RequestedBy = user.ID
+
+	err := s.shiftRepository.RequestSwap(ctx, swap)
+	if err != nil {
+		l.Error("failed to request shift swap", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s ShiftService) ApproveSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.ApproveSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.shiftRepository.GetSwap(ctx, swap)
+	if err != nil {
+		l.Error("failed to get shift swap", zap.Error(err))
+		return err
+	}
+
+	if err = s.authorizeRoster(ctx, swap.Shift.WardID, user); err != nil {
+		l.Error("user cannot manage the roster", zap.Error(err))
+		return err
+	}
+
+	if err = s.shiftRepository.ApproveSwap(ctx, swap, user); err != nil {
+		l.Error("failed to approve shift swap", zap.Error(err))
+		return err
+	}
+
+	err = s.shiftRepository.GetSwap(ctx, swap)
+	if err != nil {
+		l.Error("failed to get shift swap", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s ShiftService) RejectSwap(ctx context.Context, swap *domain.ShiftSwap, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.RejectSwap]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.shiftRepository.GetSwap(ctx, swap)
+	if err != nil {
+		l.Error("failed to get shift swap", zap.Error(err))
+		return err
+	}
+
+	// the nurse asked to take the shift may decline it themselves
+	if user.ID != swap.TargetNurseID {
+		if err = s.authorizeRoster(ctx, swap.Shift.WardID, user); err != nil {
+			l.Error("user cannot manage the roster", zap.Error(err))
+			return err
+		}
+	}
+
+	if err = s.shiftRepository.RejectSwap(ctx, swap, user); err != nil {
+		l.Error("failed to reject shift swap", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s ShiftService) GetSwaps(
+	ctx context.Context,
+	filter *domain.FilterShiftSwap,
+	user *domain.User,
+	swaps domain.ShiftSwaps,
+) (domain.ShiftSwaps, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[ShiftService.GetSwaps]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if user.Role != domain.RoleIT {
+		filter.ViewerID = user.ID
+	}
+
+	swaps, err := s.shiftRepository.GetSwaps(ctx, filter, swaps)
+	if err != nil {
+		l.Error("failed to get shift swaps", zap.Error(err))
+		return nil, err
+	}
+
+	return swaps, nil
+}
+
+// authorizeRoster lets IT staff manage every roster and nurses only the
+// roster of the ward they head.
+func (s ShiftService) authorizeRoster(ctx context.Context, wardID ulid.ULID, user *domain.User) error {
+	if user.Role == domain.RoleIT {
+		return nil
+	}
+
+	isHeadNurse, err := s.shiftRepository.IsHeadNurse(ctx, wardID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !isHeadNurse {
+		return new(domain.ErrRosterForbidden)
+	}
+
+	return nil
+}
+
+var _ ShiftServiceContract = (*ShiftService)(nil)
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/task/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const taskIDFromParam = "id"
+
+type taskHandler struct {
+	taskService service.TaskServiceContract
+}
+
+func NewTaskHandler(router fiber.Router, jwtMiddleware fiber.Handler, taskService service.TaskServiceContract) {
+	handler := taskHandler{
+		taskService: taskService,
+	}
+
+	taskRouter := router.Group("/task", jwtMiddleware)
+	taskRouter.Post("", handler.CreateTask)
+	taskRouter.Get("", handler.GetTasks)
+	taskRouter.Get("/mine", handler.GetMyTasks)
+	taskRouter.Get("/events", handler.GetTaskEvents)
+	taskRouter.Post("/:"+taskIDFromParam+"/complete", handler.CompleteTask)
+	taskRouter.Post("/:"+taskIDFromParam+"/cancel", handler.CancelTask)
+}
+
+func (h taskHandler) CreateTask(c *fiber.Ctx) error {
+	callerInfo := "[taskHandler.CreateTask]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createTaskReqAcquire()
+	defer createTaskReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	task := domain.TaskAcquire()
+	defer domain.TaskRelease(task)
+
+	task.PatientID = string(*req.IdentityNumber)
+	task.RecordID = req.recordID
+	task.WardID = req.wardID
+	task.AssigneeID = req.assigneeID
+	task.Title = req.Title
+	task.Description = req.Description
+	task.DueAt = req.dueAt
+	task.RemindAt = req.remindAt
+
+	err := h.taskService.CreateTask(userCtx, task, user)
+	if err != nil {
+		l.Error("failed to create task", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Task created successfully"
+	res.Data = newTaskRes(task, time.Now())
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h taskHandler) CompleteTask(c *fiber.Ctx) error {
+	return h.closeTask(c, "[taskHandler.CompleteTask]", domain.TaskCompleted, "Task completed successfully")
+}
+
+func (h taskHandler) CancelTask(c *fiber.Ctx) error {
+	return h.closeTask(c, "[taskHandler.CancelTask]", domain.TaskCancelled, "Task cancelled successfully")
+}
+
+func (h taskHandler) closeTask(c *fiber.Ctx, callerInfo, status, message string) error {
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	taskID, err := ulid.Parse(c.Params(taskIDFromParam))
+	if err != nil {
+		l.Error("error parsing taskIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := closeTaskReqAcquire()
+	defer closeTaskReqRelease(req)
+
+	// the note is optional when completing, so an empty body is fine
+	if len(c.Body()) > 0 {
+		if err = c.BodyParser(req); err != nil {
+			l.Error("error parsing request body", zap.Error(err))
+			return errBadRequest{err: err}
+		}
+	}
+
+	if err = req.validate(status); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	task := domain.TaskAcquire()
+	defer domain.TaskRelease(task)
+
+	task.ID = taskID
+	task.Status = status
+	task.ClosingNote = req.Note
+
+	err = h.taskService.CloseTask(userCtx, task, user)
+	if err != nil {
+		l.Error("failed to close task", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+	res.Data = newTaskRes(task, time.Now())
+
+	return c.JSON(res)
+}
+
+func (h taskHandler) GetTasks(c *fiber.Ctx) error {
+	callerInfo := "[taskHandler.GetTasks]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryTaskAcquire()
+	defer queryTaskRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterTaskAcquire()
+	defer domain.FilterTaskRelease(filter)
+
+	query.toFilter(filter)
+
+	return h.getTasks(c, l, filter, "Tasks retrieved successfully")
+}
+
+// GetMyTasks is the work queue of the logged-in nurse: tasks assigned to them
+// and tasks of the wards they are on shift in now, open ones unless a status
+// is given.
+func (h taskHandler) GetMyTasks(c *fiber.Ctx) error {
+	callerInfo := "[taskHandler.GetMyTasks]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryTaskAcquire()
+	defer queryTaskRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+	if query.Status == "" {
+		query.Status = domain.TaskOpen
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	filter := domain.FilterTaskAcquire()
+	defer domain.FilterTaskRelease(filter)
+
+	query.toFilter(filter)
+	filter.ForNurseID = user.ID
+	filter.CoveredAt = time.Now()
+
+	return h.getTasks(c, l, filter, "Tasks retrieved successfully")
+}
+
+func (h taskHandler) getTasks(c *fiber.Ctx, l *zap.Logger, filter *domain.FilterTask, message string) error {
+	tasks := domain.TasksAcquire()
+	defer domain.TasksRelease(tasks)
+
+	tasks, err := h.taskService.GetTasks(c.UserContext(), filter, tasks)
+	if err != nil {
+		l.Error("failed to get tasks", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+
+	now := time.Now()
+	tasksRes := getTasksResAcquire()
+	defer getTasksResRelease(tasksRes)
+
+	for i := range tasks {
+		tasksRes = append(tasksRes, newTaskRes(&tasks[i], now))
+	}
+
+	res.Data = tasksRes
+
+	return c.JSON(res)
+}
+
+// GetTaskEvents returns the reminders and overdue notices for the work queue
+// of the logged-in nurse, oldest first. Clients poll with after set to the
+// last eventId they received.
+func (h taskHandler) GetTaskEvents(c *fiber.Ctx) error {
+	callerInfo := "[taskHandler.GetTaskEvents]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryTaskEventAcquire()
+	defer queryTaskEventRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	filter := domain.FilterTaskEventAcquire()
+	defer domain.FilterTaskEventRelease(filter)
+
+	filter.After = query.after
+	filter.Type = query.Type
+	filter.Limit = query.Limit
+	filter.ForNurseID = user.ID
+	filter.CoveredAt = time.Now()
+
+	events := domain.TaskEventsAcquire()
+	defer domain.TaskEventsRelease(events)
+
+	events, err := h.taskService.GetTaskEvents(userCtx, filter, events)
+	if err != nil {
+		l.Error("failed to get task events", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Task events retrieved successfully"
+
+	now := time.Now()
+	eventsRes := getTaskEventsResAcquire()
+	defer getTaskEventsResRelease(eventsRes)
+
+	for i := range events {
+		eventsRes = append(eventsRes, taskEventRes{
+			EventID:   events[i].ID,
+			Type:      events[i].Type,
+			CreatedAt: events[i].CreatedAt.Format(dateFormat),
+			Task:      newTaskRes(&events[i].Task, now),
+		})
+	}
+
+	res.Data = eventsRes
+
+	return c.JSON(res)
+}
@@ -0,0 +1,471 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/timeparam"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+var createTaskReqPool = sync.Pool{
+	New: func() any {
+		return new(createTaskReq)
+	},
+}
+
+func createTaskReqAcquire() *createTaskReq {
+	return createTaskReqPool.Get().(*createTaskReq)
+}
+
+func createTaskReqRelease(t *createTaskReq) {
+	*t = createTaskReq{}
+	createTaskReqPool.Put(t)
+}
+
+// createTaskReq needs a ward, an assignee or both, RemindAt defaults to the
+// configured lead before DueAt.
+type createTaskReq struct {
+	IdentityNumber *idNumber `json:"identityNumber"`
+	RecordID       string    `json:"recordId"`
+	recordID       ulid.ULID
+	WardID         string `json:"wardId"`
+	wardID         ulid.ULID
+	AssigneeID     string `json:"assigneeId"`
+	assigneeID     ulid.ULID
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	DueAt          string `json:"dueAt"`
+	dueAt          time.Time
+	RemindAt       string `json:"remindAt"`
+	remindAt       time.Time
+}
+
+func (r *createTaskReq) validate() error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	if r.RecordID != "" {
+		recordID, err := ulid.Parse(r.RecordID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("recordId is invalid"))
+		}
+		r.recordID = recordID
+	}
+
+	if r.WardID != "" {
+		wardID, err := ulid.Parse(r.WardID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("wardId is invalid"))
+		}
+		r.wardID = wardID
+	}
+
+	if r.AssigneeID != "" {
+		assigneeID, err := ulid.Parse(r.AssigneeID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("assigneeId is invalid"))
+		}
+		r.assigneeID = assigneeID
+	}
+
+	if r.WardID == "" && r.AssigneeID == "" {
+		errs = multierr.Append(errs, errors.New("wardId or assigneeId is required"))
+	}
+
+	if r.Title == "" {
+		errs = multierr.Append(errs, errors.New("title is required"))
+	} else if len(r.Title) > 100 {
+		errs = multierr.Append(errs, errors.New("title must have 1 to 100 characters"))
+	}
+
+	if len(r.Description) > 2000 {
+		errs = multierr.Append(errs, errors.New("description must have at most 2000 characters"))
+	}
+
+	dueAt, err := time.Parse(time.RFC3339Nano, r.DueAt)
+	if err != nil {
+		errs = multierr.Append(errs, errors.New("dueAt must be in ISO 8601 format"))
+	}
+	r.dueAt = dueAt.In(time.Local)
+
+	if r.RemindAt != "" {
+		remindAt, err := time.Parse(time.RFC3339Nano, r.RemindAt)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("remindAt must be in ISO 8601 format"))
+		} else if remindAt.After(dueAt) {
+			errs = multierr.Append(errs, errors.New("remindAt must not be after dueAt"))
+		}
+		r.remindAt = remindAt.In(time.Local)
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var closeTaskReqPool = sync.Pool{
+	New: func() any {
+		return new(closeTaskReq)
+	},
+}
+
+func closeTaskReqAcquire() *closeTaskReq {
+	return closeTaskReqPool.Get().(*closeTaskReq)
+}
+
+func closeTaskReqRelease(t *closeTaskReq) {
+	*t = closeTaskReq{}
+	closeTaskReqPool.Put(t)
+}
+
+// closeTaskReq needs a note when the task is cancelled, to tell why.
+type closeTaskReq struct {
+	Note string `json:"note"`
+}
+
+func (r *closeTaskReq) validate(status string) error {
+	if status == domain.TaskCancelled && r.Note == "" {
+		return errors.New("note is required when a task is cancelled")
+	}
+
+	if len(r.Note) > 2000 {
+		return errors.New("note must have at most 2000 characters")
+	}
+
+	return nil
+}
+
+var queryTaskPool = sync.Pool{
+	New: func() any {
+		return new(queryTask)
+	},
+}
+
+func queryTaskAcquire() *queryTask {
+	return queryTaskPool.Get().(*queryTask)
+}
+
+func queryTaskRelease(t *queryTask) {
+	*t = queryTask{}
+	queryTaskPool.Put(t)
+}
+
+type queryTask struct {
+	IdentityNumber int `query:"identityNumber"`
+	patientID      string
+	AssigneeID     string `query:"assigneeId"`
+	assigneeID     ulid.ULID
+	WardID         string `query:"wardId"`
+	wardID         ulid.ULID
+	Status         string `query:"status"`
+	DueFrom        string `query:"dueFrom"`
+	dueFrom        time.Time
+	DueTo          string `query:"dueTo"`
+	dueTo          time.Time
+	Overdue        bool `query:"overdue"`
+	Limit          int  `query:"limit"`
+	Offset         int  `query:"offset"`
+}
+
+func (q *queryTask) validate() {
+	if q.IdentityNumber != 0 {
+		q.patientID = strconv.Itoa(q.IdentityNumber)
+	}
+
+	if q.AssigneeID != "" {
+		q.assigneeID, _ = ulid.Parse(q.AssigneeID)
+	}
+
+	if q.WardID != "" {
+		q.wardID, _ = ulid.Parse(q.WardID)
+	}
+
+	switch q.Status {
+	case domain.TaskOpen, domain.TaskCompleted, domain.TaskCancelled:
+	default:
+		q.Status = ""
+	}
+
+	if dueFrom, _, ok := timeparam.Parse(q.DueFrom); ok {
+		q.dueFrom = dueFrom
+	}
+
+	// a bare date includes the whole day
+	if dueTo, dateOnly, ok := timeparam.Parse(q.DueTo); ok {
+		if dateOnly {
+			dueTo = dueTo.AddDate(0, 0, 1)
+		}
+		q.dueTo = dueTo
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *queryTask) toFilter(filter *domain.FilterTask) {
+	filter.PatientID = q.patientID
+	filter.AssigneeID = q.assigneeID
+	filter.WardID = q.wardID
+	filter.Status = q.Status
+	filter.DueFrom = q.dueFrom
+	filter.DueTo = q.dueTo
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+
+	if q.Overdue {
+		filter.OverdueBefore = time.Now()
+	}
+}
+
+var queryTaskEventPool = sync.Pool{
+	New: func() any {
+		return new(queryTaskEvent)
+	},
+}
+
+func queryTaskEventAcquire() *queryTaskEvent {
+	return queryTaskEventPool.Get().(*queryTaskEvent)
+}
+
+func queryTaskEventRelease(t *queryTaskEvent) {
+	*t = queryTaskEvent{}
+	queryTaskEventPool.Put(t)
+}
+
+// queryTaskEvent pages with After, the eventId of the last event seen.
+type queryTaskEvent struct {
+	After string `query:"after"`
+	after ulid.ULID
+	Type  string `query:"type"`
+	Limit int    `query:"limit"`
+}
+
+func (q *queryTaskEvent) validate() {
+	if q.After != "" {
+		q.after, _ = ulid.Parse(q.After)
+	}
+
+	if q.Type != domain.TaskEventReminder && q.Type != domain.TaskEventOverdue {
+		q.Type = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+}
+
+type patientRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+}
+
+type staffRes struct {
+	UserID ulid.ULID `json:"userId"`
+	Name   string    `json:"name"`
+}
+
+type wardRes struct {
+	WardID ulid.ULID `json:"wardId"`
+	Code   string    `json:"code"`
+}
+
+type taskRes struct {
+	TaskID      ulid.ULID  `json:"taskId"`
+	Patient     patientRes `json:"patient"`
+	RecordID    *ulid.ULID `json:"recordId"`
+	Ward        *wardRes   `json:"ward"`
+	Assignee    *staffRes  `json:"assignee"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueAt       string     `json:"dueAt"`
+	RemindAt    string     `json:"remindAt"`
+	Status      string     `json:"status"`
+	Overdue     bool       `json:"overdue"`
+	CreatedBy   staffRes   `json:"createdBy"`
+	CreatedAt   string     `json:"createdAt"`
+	RemindedAt  string     `json:"remindedAt,omitempty"`
+	ClosedBy    *staffRes  `json:"closedBy"`
+	ClosedAt    string     `json:"closedAt,omitempty"`
+	Note        string     `json:"note,omitempty"`
+}
+
+func newTaskRes(task *domain.Task, now time.Time) taskRes {
+	res := taskRes{
+		TaskID: task.ID,
+		Patient: patientRes{
+			IdentityNumber: idNumber(task.PatientID),
+			Name:           task.PatientName,
+		},
+		Title:       task.Title,
+		Description: task.Description,
+		DueAt:       task.DueAt.Format(dateFormat),
+		RemindAt:    task.RemindAt.Format(dateFormat),
+		Status:      task.Status,
+		Overdue:     task.Status == domain.TaskOpen && task.DueAt.Before(now),
+		CreatedBy: staffRes{
+			UserID: task.CreatedBy,
+			Name:   task.CreatedByName,
+		},
+		CreatedAt: task.CreatedAt.Format(dateFormat),
+		Note:      task.ClosingNote,
+	}
+	if !id.IsZero(task.RecordID) {
+		recordID := task.RecordID
+		res.RecordID = &recordID
+	}
+	if !id.IsZero(task.WardID) {
+		res.Ward = &wardRes{
+			WardID: task.WardID,
+			Code:   task.WardCode,
+		}
+	}
+	if !id.IsZero(task.AssigneeID) {
+		res.Assignee = &staffRes{
+			UserID: task.AssigneeID,
+			Name:   task.AssigneeName,
+		}
+	}
+	if !task.RemindedAt.IsZero() {
+		res.RemindedAt = task.RemindedAt.Format(dateFormat)
+	}
+	if !id.IsZero(task.ClosedBy) {
+		res.ClosedBy = &staffRes{
+			UserID: task.ClosedBy,
+			Name:   task.ClosedByName,
+		}
+		res.ClosedAt = task.ClosedAt.Format(dateFormat)
+	}
+
+	return res
+}
+
+const taskInitCap = 5
+
+var getTasksResPool = sync.Pool{
+	New: func() any {
+		return make(getTasksRes, 0, taskInitCap)
+	},
+}
+
+func getTasksResAcquire() getTasksRes {
+	return getTasksResPool.Get().(getTasksRes)
+}
+
+func getTasksResRelease(t getTasksRes) {
+	t = t[:0]
+	getTasksResPool.Put(t) // nolint:staticcheck
+}
+
+type getTasksRes []taskRes
+
+type taskEventRes struct {
+	EventID   ulid.ULID `json:"eventId"`
+	Type      string    `json:"type"`
+	CreatedAt string    `json:"createdAt"`
+	Task      taskRes   `json:"task"`
+}
+
+var getTaskEventsResPool = sync.Pool{
+	New: func() any {
+		return make(getTaskEventsRes, 0, taskInitCap)
+	},
+}
+
+func getTaskEventsResAcquire() getTaskEventsRes {
+	return getTaskEventsResPool.Get().(getTaskEventsRes)
+}
+
+func getTaskEventsResRelease(t getTaskEventsRes) {
+	t = t[:0]
+	getTaskEventsResPool.Put(t) // nolint:staticcheck
+}
+
+type getTaskEventsRes []taskEventRes
@@ -0,0 +1,31 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/task/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/task/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/task/scheduler"
+	"github.com/j03hanafi/halo-suster/internal/application/task/service"
+)
+
+// NewModule registers the task routes and starts the scheduler until ctx is
+// done. With prefork only the parent process runs the scheduler.
+func NewModule(ctx context.Context, router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	reminderLead := time.Duration(configs.Get().Task.ReminderLeadMinutes) * time.Minute
+	schedulerInterval := time.Duration(configs.Get().Task.SchedulerInterval) * time.Second
+
+	taskRepository := repository.NewTaskRepository(db)
+	taskService := service.NewTaskService(ctxTimeout, reminderLead, taskRepository)
+	handler.NewTaskHandler(router, jwtMiddleware, taskService)
+
+	if !fiber.IsChild() && schedulerInterval > 0 {
+		go scheduler.NewScheduler(schedulerInterval, taskService).Run(ctx)
+	}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type TaskRepositoryContract interface {
+	CreateTask(ctx context.Context, task *domain.Task, user *domain.User) error
+	CloseTask(ctx context.Context, task *domain.Task, user *domain.User) error
+	GetTasks(ctx context.Context, filter *domain.FilterTask, tasks domain.Tasks) (domain.Tasks, error)
+	GetTaskEvents(
+		ctx context.Context,
+		filter *domain.FilterTaskEvent,
+		events domain.TaskEvents,
+	) (domain.TaskEvents, error)
+	RemindDueTasks(ctx context.Context, now time.Time) (int, error)
+	MarkOverdueTasks(ctx context.Context, now time.Time) (int, error)
+}
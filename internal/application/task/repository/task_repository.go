@@ -0,0 +1,676 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	taskColumns = `t.id, t.patient_id, p.name, t.record_id, t.ward_id, w.code, t.assignee_id, a.name, t.title, 
+		t.description, t.due_at, t.remind_at, t.status, t.created_by, t.created_by_name, t.created_at, t.reminded_at, 
+		t.overdue_at, t.closed_by, t.closed_by_name, t.closed_at, t.closing_note`
+	taskTables = ` FROM tasks t 
+		JOIN patients p ON p.id = t.patient_id
+		LEFT JOIN wards w ON w.id = t.ward_id
+		LEFT JOIN users a ON a.id = t.assignee_id`
+
+	// a nurse works the tasks assigned to them and those of every ward they
+	// are on a published shift for
+	nurseCondition = `(t.assignee_id = @nurse_id OR t.ward_id IN (
+			SELECT s.ward_id FROM shifts s 
+			WHERE s.nurse_id = @nurse_id AND s.status = @published AND s.start_at <= @covered_at AND s.end_at > @covered_at
+		))`
+)
+
+type TaskRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTaskRepository(db *pgxpool.Pool) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+// CreateTask saves an open task, the record has to belong to the patient and
+// the assignee has to be a nurse.
+func (r TaskRepository) CreateTask(ctx context.Context, task *domain.Task, user *domain.User) error {
+	callerInfo := "[TaskRepository.CreateTask]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	task.ID = id.New()
+	task.Status = domain.TaskOpen
+	task.CreatedBy = user.ID
+	task.CreatedByName = user.Name
+	task.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var recordID, wardID, assigneeID any
+
+	if !id.IsZero(task.RecordID) {
+		recordID = task.RecordID
+
+		var patientID string
+		recordQuery := `SELECT patient_id FROM medical_records WHERE id = @id`
+		err = tx.QueryRow(ctx, recordQuery, pgx.NamedArgs{"id": task.RecordID}).Scan(&patientID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return new(domain.ErrMedicalRecordNotFound)
+			}
+
+			l.Error("failed to get medical record", zap.Error(err))
+			return err
+		}
+
+		if patientID != task.PatientID {
+			return new(domain.ErrRecordPatientMismatch)
+		}
+	}
+
+	if !id.IsZero(task.WardID) {
+		wardID = task.WardID
+
+		wardQuery := `SELECT code FROM wards WHERE id = @id`
+		err = tx.QueryRow(ctx, wardQuery, pgx.NamedArgs{"id": task.WardID}).Scan(&task.WardCode)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return new(domain.ErrWardNotFound)
+			}
+
+			l.Error("failed to get ward", zap.Error(err))
+			return err
+		}
+	}
+
+	if !id.IsZero(task.AssigneeID) {
+		assigneeID = task.AssigneeID
+
+		nurseQuery := `SELECT name FROM users WHERE id = @id AND nip LIKE '303%'`
+		err = tx.QueryRow(ctx, nurseQuery, pgx.NamedArgs{"id": task.AssigneeID}).Scan(&task.AssigneeName)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return new(domain.ErrNotFoundOrNotNurse)
+			}
+
+			l.Error("failed to get assignee", zap.Error(err))
+			return err
+		}
+	}
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO tasks (
+				id, patient_id, record_id, ward_id, assignee_id, title, description, due_at, remind_at, status, 
+				created_by, created_by_name, created_at
+			)
+			SELECT @id, p.id, @record_id, @ward_id, @assignee_id, @title, @description, @due_at, @remind_at, @status, 
+				@created_by, @created_by_name, @created_at
+			FROM patients p WHERE p.id = @patient_id
+			RETURNING patient_id
+		)
+		SELECT p.name FROM inserted i JOIN patients p ON p.id = i.patient_id`
+	args := pgx.NamedArgs{
+		"id":              task.ID,
+		"patient_id":      task.PatientID,
+		"record_id":       recordID,
+		"ward_id":         wardID,
+		"assignee_id":     assigneeID,
+		"title":           task.Title,
+		"description":     task.Description,
+		"due_at":          task.DueAt,
+		"remind_at":       task.RemindAt,
+		"status":          task.Status,
+		"created_by":      task.CreatedBy,
+		"created_by_name": task.CreatedByName,
+		"created_at":      task.CreatedAt,
+	}
+
+	if err = tx.QueryRow(ctx, insertQuery, args).Scan(&task.PatientName); err != nil {
+		l.Error("failed to create task", zap.Error(err))
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotFound)
+		}
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, task, domain.PatientEventTaskCreated, user)
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// CloseTask completes or cancels an open task, task.Status holds the status
+// to close it with and task is filled with the closed task.
+func (r TaskRepository) CloseTask(ctx context.Context, task *domain.Task, user *domain.User) error {
+	callerInfo := "[TaskRepository.CloseTask]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	updateQuery := `UPDATE tasks SET status = @status, closed_by = @closed_by, closed_by_name = @closed_by_name, 
+			closed_at = @closed_at, closing_note = @closing_note 
+		WHERE id = @id AND status = @open`
+	args := pgx.NamedArgs{
+		"id":             task.ID,
+		"open":           domain.TaskOpen,
+		"status":         task.Status,
+		"closed_by":      user.ID,
+		"closed_by_name": user.Name,
+		"closed_at":      time.Now(),
+		"closing_note":   task.ClosingNote,
+	}
+
+	tag, err := tx.Exec(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to close task", zap.Error(err))
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		existsQuery := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = @id)`
+		if err = tx.QueryRow(ctx, existsQuery, pgx.NamedArgs{"id": task.ID}).Scan(&exists); err != nil {
+			l.Error("failed to check task", zap.Error(err))
+			return err
+		}
+
+		if exists {
+			return new(domain.ErrTaskClosed)
+		}
+		return new(domain.ErrTaskNotFound)
+	}
+
+	selectQuery := `SELECT ` + taskColumns + taskTables + ` WHERE t.id = @id`
+	if err = r.scanTask(tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"id": task.ID}), task); err != nil {
+		l.Error("failed to get task", zap.Error(err))
+		return err
+	}
+
+	eventType := domain.PatientEventTaskCompleted
+	if task.Status == domain.TaskCancelled {
+		eventType = domain.PatientEventTaskCancelled
+	}
+
+	if err = r.insertEvent(ctx, tx, task, eventType, user); err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r TaskRepository) GetTasks(
+	ctx context.Context,
+	filter *domain.FilterTask,
+	tasks domain.Tasks,
+) (domain.Tasks, error) {
+	callerInfo := "[TaskRepository.GetTasks]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterTask(filter)
+	getQuery := `SELECT ` + taskColumns + taskTables + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get tasks", zap.Error(err))
+		return tasks, err
+	}
+	defer rows.Close()
+
+	dTask := domain.TaskAcquire()
+	defer domain.TaskRelease(dTask)
+
+	for rows.Next() {
+		if err = r.scanTask(rows, dTask); err != nil {
+			l.Error("failed to scan task", zap.Error(err))
+			return tasks, err
+		}
+		tasks = append(tasks, *dTask)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get tasks", zap.Error(err))
+		return tasks, err
+	}
+
+	return tasks, nil
+}
+
+// GetTaskEvents lists events oldest first so a client can keep polling with
+// the id of the last event it has seen.
+func (r TaskRepository) GetTaskEvents(
+	ctx context.Context,
+	filter *domain.FilterTaskEvent,
+	events domain.TaskEvents,
+) (domain.TaskEvents, error) {
+	callerInfo := "[TaskRepository.GetTaskEvents]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterTaskEvent(filter)
+	getQuery := `SELECT e.id, e.type, e.created_at, ` + taskColumns + ` FROM task_events e 
+		JOIN tasks t ON t.id = e.task_id
+		JOIN patients p ON p.id = t.patient_id
+		LEFT JOIN wards w ON w.id = t.ward_id
+		LEFT JOIN users a ON a.id = t.assignee_id` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get task events", zap.Error(err))
+		return events, err
+	}
+	defer rows.Close()
+
+	dEvent := domain.TaskEventAcquire()
+	defer domain.TaskEventRelease(dEvent)
+
+	for rows.Next() {
+		if err = r.scanTask(eventRow{row: rows, event: dEvent}, &dEvent.Task); err != nil {
+			l.Error("failed to scan task event", zap.Error(err))
+			return events, err
+		}
+		events = append(events, *dEvent)
+	}
+
+	if err = rows.Err(); err != nil {
+		l.Error("failed to get task events", zap.Error(err))
+		return events, err
+	}
+
+	return events, nil
+}
+
+// RemindDueTasks emits a reminder for every open task whose reminder time has
+// passed. The update only matches tasks not reminded yet, so concurrent runs
+// never emit the same reminder twice.
+func (r TaskRepository) RemindDueTasks(ctx context.Context, now time.Time) (int, error) {
+	callerInfo := "[TaskRepository.RemindDueTasks]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	updateQuery := `UPDATE tasks SET reminded_at = @now 
+		WHERE status = @open AND reminded_at IS NULL AND remind_at <= @now 
+		RETURNING id`
+	args := pgx.NamedArgs{
+		"now":  now,
+		"open": domain.TaskOpen,
+	}
+
+	rows, err := tx.Query(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to remind tasks", zap.Error(err))
+		return 0, err
+	}
+
+	taskIDs, err := pgx.CollectRows(rows, pgx.RowTo[ulid.ULID])
+	if err != nil {
+		l.Error("failed to remind tasks", zap.Error(err))
+		return 0, err
+	}
+
+	if err = r.insertTaskEvents(ctx, tx, taskIDs, domain.TaskEventReminder, now); err != nil {
+		l.Error("failed to save task events", zap.Error(err))
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return 0, err
+	}
+
+	return len(taskIDs), nil
+}
+
+// MarkOverdueTasks flags every open task whose due time has passed and emits
+// an overdue event for it, both to the task feed and the patient timeline.
+func (r TaskRepository) MarkOverdueTasks(ctx context.Context, now time.Time) (int, error) {
+	callerInfo := "[TaskRepository.MarkOverdueTasks]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	updateQuery := `UPDATE tasks SET overdue_at = @now 
+		WHERE status = @open AND overdue_at IS NULL AND due_at <= @now 
+		RETURNING id, patient_id, title, due_at`
+	args := pgx.NamedArgs{
+		"now":  now,
+		"open": domain.TaskOpen,
+	}
+
+	rows, err := tx.Query(ctx, updateQuery, args)
+	if err != nil {
+		l.Error("failed to mark overdue tasks", zap.Error(err))
+		return 0, err
+	}
+
+	tasks := domain.TasksAcquire()
+	defer domain.TasksRelease(tasks)
+
+	dTask := domain.TaskAcquire()
+	defer domain.TaskRelease(dTask)
+
+	_, err = pgx.ForEachRow(rows, []any{&dTask.ID, &dTask.PatientID, &dTask.Title, &dTask.DueAt}, func() error {
+		tasks = append(tasks, *dTask)
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to mark overdue tasks", zap.Error(err))
+		return 0, err
+	}
+
+	taskIDs := make([]ulid.ULID, 0, len(tasks))
+	for i := range tasks {
+		taskIDs = append(taskIDs, tasks[i].ID)
+
+		if err = r.insertEvent(ctx, tx, &tasks[i], domain.PatientEventTaskOverdue, nil); err != nil {
+			l.Error("failed to save patient event", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	if err = r.insertTaskEvents(ctx, tx, taskIDs, domain.TaskEventOverdue, now); err != nil {
+		l.Error("failed to save task events", zap.Error(err))
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return 0, err
+	}
+
+	return len(taskIDs), nil
+}
+
+func (r TaskRepository) insertTaskEvents(
+	ctx context.Context,
+	tx pgx.Tx,
+	taskIDs []ulid.ULID,
+	eventType string,
+	now time.Time,
+) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	events := make([][]any, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		events = append(events, []any{id.New().Bytes(), taskID.Bytes(), eventType, now})
+	}
+
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"task_events"},
+		[]string{"id", "task_id", "type", "created_at"},
+		pgx.CopyFromRows(events),
+	)
+	return err
+}
+
+// insertEvent writes to the patient timeline, a nil user marks an event
+// raised by the scheduler.
+func (r TaskRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	task *domain.Task,
+	eventType string,
+	user *domain.User,
+) error {
+	data := map[string]any{
+		"taskId": task.ID.String(),
+		"title":  task.Title,
+		"dueAt":  task.DueAt,
+	}
+	if task.ClosingNote != "" {
+		data["note"] = task.ClosingNote
+	}
+
+	event, err := patientevent.New(task.PatientID, eventType, user, data)
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+// eventRow scans the event columns in front of the task columns so scanTask
+// can be shared with GetTaskEvents.
+type eventRow struct {
+	row   pgx.Row
+	event *domain.TaskEvent
+}
+
+func (e eventRow) Scan(dest ...any) error {
+	return e.row.Scan(append([]any{&e.event.ID, &e.event.Type, &e.event.CreatedAt}, dest...)...)
+}
+
+func (r TaskRepository) scanTask(row pgx.Row, task *domain.Task) error {
+	var (
+		wardCode, assigneeName, closedByName, closingNote *string
+		remindedAt, overdueAt, closedAt                   *time.Time
+	)
+
+	task.RecordID, task.WardID, task.AssigneeID, task.ClosedBy = ulid.ULID{}, ulid.ULID{}, ulid.ULID{}, ulid.ULID{}
+
+	err := row.Scan(
+		&task.ID,
+		&task.PatientID,
+		&task.PatientName,
+		&task.RecordID,
+		&task.WardID,
+		&wardCode,
+		&task.AssigneeID,
+		&assigneeName,
+		&task.Title,
+		&task.Description,
+		&task.DueAt,
+		&task.RemindAt,
+		&task.Status,
+		&task.CreatedBy,
+		&task.CreatedByName,
+		&task.CreatedAt,
+		&remindedAt,
+		&overdueAt,
+		&task.ClosedBy,
+		&closedByName,
+		&closedAt,
+		&closingNote,
+	)
+	if err != nil {
+		return err
+	}
+
+	task.WardCode, task.AssigneeName, task.ClosedByName, task.ClosingNote = "", "", "", ""
+	task.RemindedAt, task.OverdueAt, task.ClosedAt = time.Time{}, time.Time{}, time.Time{}
+
+	if wardCode != nil {
+		task.WardCode = *wardCode
+	}
+	if assigneeName != nil {
+		task.AssigneeName = *assigneeName
+	}
+	if closedByName != nil {
+		task.ClosedByName = *closedByName
+	}
+	if closingNote != nil {
+		task.ClosingNote = *closingNote
+	}
+	if remindedAt != nil {
+		task.RemindedAt = *remindedAt
+	}
+	if overdueAt != nil {
+		task.OverdueAt = *overdueAt
+	}
+	if closedAt != nil {
+		task.ClosedAt = *closedAt
+	}
+
+	return nil
+}
+
+func (r TaskRepository) filterTask(filter *domain.FilterTask) (string, pgx.NamedArgs) {
+	const totalConditions = 8
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if filter.PatientID != "" {
+		conditions = append(conditions, "t.patient_id = @patient_id")
+		params["patient_id"] = filter.PatientID
+	}
+
+	if !id.IsZero(filter.AssigneeID) {
+		conditions = append(conditions, "t.assignee_id = @assignee_id")
+		params["assignee_id"] = filter.AssigneeID
+	}
+
+	if !id.IsZero(filter.WardID) {
+		conditions = append(conditions, "t.ward_id = @ward_id")
+		params["ward_id"] = filter.WardID
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "t.status = @status")
+		params["status"] = filter.Status
+	}
+
+	if !filter.DueFrom.IsZero() {
+		conditions = append(conditions, "t.due_at >= @due_from")
+		params["due_from"] = filter.DueFrom
+	}
+
+	if !filter.DueTo.IsZero() {
+		conditions = append(conditions, "t.due_at < @due_to")
+		params["due_to"] = filter.DueTo
+	}
+
+	if !filter.OverdueBefore.IsZero() {
+		conditions = append(conditions, "t.status = @overdue_status AND t.due_at < @overdue_before")
+		params["overdue_status"] = domain.TaskOpen
+		params["overdue_before"] = filter.OverdueBefore
+	}
+
+	if !id.IsZero(filter.ForNurseID) {
+		conditions = append(conditions, nurseCondition)
+		params["nurse_id"] = filter.ForNurseID
+		params["published"] = domain.ShiftPublished
+		params["covered_at"] = filter.CoveredAt
+	}
+
+	order := " ORDER BY t.due_at ASC, p.name ASC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+func (r TaskRepository) filterTaskEvent(filter *domain.FilterTaskEvent) (string, pgx.NamedArgs) {
+	const totalConditions = 3
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.After) {
+		conditions = append(conditions, "e.id > @after")
+		params["after"] = filter.After
+	}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "e.type = @type")
+		params["type"] = filter.Type
+	}
+
+	if !id.IsZero(filter.ForNurseID) {
+		conditions = append(conditions, nurseCondition)
+		params["nurse_id"] = filter.ForNurseID
+		params["published"] = domain.ShiftPublished
+		params["covered_at"] = filter.CoveredAt
+	}
+
+	order := " ORDER BY e.id ASC"
+
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return queryConditions + order + " LIMIT @limit", params
+}
+
+var _ TaskRepositoryContract = (*TaskRepository)(nil)
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/internal/application/task/service"
+)
+
+// Scheduler sweeps tasks every interval until its context is done. Sweeps
+// are idempotent, so running it in more than one process is safe.
+type Scheduler struct {
+	taskService service.TaskServiceContract
+	interval    time.Duration
+}
+
+func NewScheduler(interval time.Duration, taskService service.TaskServiceContract) *Scheduler {
+	return &Scheduler{
+		taskService: taskService,
+		interval:    interval,
+	}
+}
+
+func (s Scheduler) Run(ctx context.Context) {
+	callerInfo := "[Scheduler.Run]"
+	l := zap.L().With(zap.String("caller", callerInfo))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	l.Info("task scheduler started", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Info("task scheduler stopped")
+			return
+		case now := <-ticker.C:
+			// the error is already logged by the service, the next tick retries
+			_ = s.taskService.Sweep(ctx, now)
+		}
+	}
+}
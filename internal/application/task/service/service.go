@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type TaskServiceContract interface {
+	CreateTask(ctx context.Context, task *domain.Task, user *domain.User) error
+	CloseTask(ctx context.Context, task *domain.Task, user *domain.User) error
+	GetTasks(ctx context.Context, filter *domain.FilterTask, tasks domain.Tasks) (domain.Tasks, error)
+	GetTaskEvents(
+		ctx context.Context,
+		filter *domain.FilterTaskEvent,
+		events domain.TaskEvents,
+	) (domain.TaskEvents, error)
+	Sweep(ctx context.Context, now time.Time) error
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/task/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type TaskService struct {
+	taskRepository repository.TaskRepositoryContract
+	contextTimeout time.Duration
+	reminderLead   time.Duration
+}
+
+func NewTaskService(
+	timeout, reminderLead time.Duration,
+	taskRepository repository.TaskRepositoryContract,
+) *TaskService {
+	return &TaskService{
+		taskRepository: taskRepository,
+		contextTimeout: timeout,
+		reminderLead:   reminderLead,
+	}
+}
+
+// CreateTask saves the task, a task without a reminder time is reminded
+// reminderLead before it is due.
+func (s TaskService) CreateTask(ctx context.Context, task *domain.Task, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[TaskService.CreateTask]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if task.RemindAt.IsZero() {
+		task.RemindAt = task.DueAt.Add(-s.reminderLead)
+	}
+
+	err := s.taskRepository.CreateTask(ctx, task, user)
+	if err != nil {
+		l.Error("failed to create task", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s TaskService) CloseTask(ctx context.Context, task *domain.Task, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[TaskService.CloseTask]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.taskRepository.CloseTask(ctx, task, user)
+	if err != nil {
+		l.Error("failed to close task", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s TaskService) GetTasks(
+	ctx context.Context,
+	filter *domain.FilterTask,
+	tasks domain.Tasks,
+) (domain.Tasks, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[TaskService.GetTasks]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tasks, err := s.taskRepository.GetTasks(ctx, filter, tasks)
+	if err != nil {
+		l.Error("failed to get tasks", zap.Error(err))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (s TaskService) GetTaskEvents(
+	ctx context.Context,
+	filter *domain.FilterTaskEvent,
+	events domain.TaskEvents,
+) (domain.TaskEvents, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[TaskService.GetTaskEvents]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	events, err := s.taskRepository.GetTaskEvents(ctx, filter, events)
+	if err != nil {
+		l.Error("failed to get task events", zap.Error(err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Sweep emits the reminders that have come up and marks the tasks that went
+// overdue as of now.
+func (s TaskService) Sweep(ctx context.Context, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[TaskService.Sweep]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	reminded, err := s.taskRepository.RemindDueTasks(ctx, now)
+	if err != nil {
+		l.Error("failed to remind tasks", zap.Error(err))
+		return err
+	}
+
+	overdue, err := s.taskRepository.MarkOverdueTasks(ctx, now)
+	if err != nil {
+		l.Error("failed to mark overdue tasks", zap.Error(err))
+		return err
+	}
+
+	if reminded > 0 || overdue > 0 {
+		l.Info("tasks swept", zap.Int("reminded", reminded), zap.Int("overdue", overdue))
+	}
+
+	return nil
+}
+
+var _ TaskServiceContract = (*TaskService)(nil)
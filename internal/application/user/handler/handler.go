@@ -1,19 +1,32 @@
 package handler
 
 import (
+	"bufio"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
 	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/jobqueue"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
 	"github.com/j03hanafi/halo-suster/internal/application/user/service"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
-const userIDFromParam = "id"
+const (
+	userIDFromParam    = "id"
+	rosterFileFromForm = "file"
+
+	// exportDateFormat dates the file name of an export
+	exportDateFormat = "20060102"
+)
 
 type userHandler struct {
 	userService service.UserServiceContract
@@ -26,10 +39,13 @@ func NewUserHandler(router fiber.Router, jwtMiddleware fiber.Handler, userServic
 	authRouter.Post("/it/register", handler.RegisterIT)
 	authRouter.Post("/it/login", handler.LoginIT)
 	authRouter.Post("/nurse/login", handler.LoginNurse)
-	authRouter.Get("", jwtMiddleware, itStaffAccess, handler.GetUsers)
+	authRouter.Get("", jwtMiddleware, access.ITStaff, handler.GetUsers)
+	authRouter.Get("/export", jwtMiddleware, access.ITStaff, handler.ExportUsers)
+	authRouter.Post("/export", jwtMiddleware, access.ITStaff, handler.QueueUserExport)
 
-	nurseRouter := router.Group("/user/nurse", jwtMiddleware, itStaffAccess)
+	nurseRouter := router.Group("/user/nurse", jwtMiddleware, access.ITStaff)
 	nurseRouter.Post("/register", handler.RegisterNurse)
+	nurseRouter.Post("/import", handler.ImportNurses)
 	nurseRouter.Put("/:"+userIDFromParam, handler.UpdateNurse)
 	nurseRouter.Delete("/:"+userIDFromParam, handler.DeleteNurse)
 	nurseRouter.Post("/:"+userIDFromParam+"/access", handler.UpdateAccess)
@@ -262,6 +278,81 @@ func (h userHandler) RegisterNurse(c *fiber.Ctx) error {
 	return c.Status(http.StatusCreated).JSON(res)
 }
 
+// ImportNurses registers the nurses of an uploaded roster CSV file and
+// reports the rows that were not imported. Nurses with a password in their
+// row are given access in the same run.
+func (h userHandler) ImportNurses(c *fiber.Ctx) error {
+	callerInfo := "[userHandler.ImportNurses]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	fileHeader, err := c.FormFile(rosterFileFromForm)
+	if err != nil {
+		l.Error("error getting roster file", zap.Error(err))
+		return errBadRequest{err: errors.New("file is required")}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		l.Error("error opening roster file", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	roster, err := readRoster(file)
+	if err != nil {
+		l.Error("error reading roster file", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	report := domain.NurseImportAcquire()
+	defer domain.NurseImportRelease(report)
+
+	report.Total = len(roster)
+
+	rows := make([]domain.NurseImportRow, 0, len(roster))
+	seen := make(map[string]int, len(roster))
+
+	for _, entry := range roster {
+		row := domain.NurseImportRow{Row: entry.number}
+
+		if err = entry.nurse(&row.Nurse); err != nil {
+			errs := multierr.Errors(err)
+			messages := make([]string, 0, len(errs))
+			for _, err = range errs {
+				messages = append(messages, err.Error())
+			}
+			report.Fail(entry.number, entry.nip, messages...)
+			continue
+		}
+
+		if _, ok := seen[row.Nurse.NIP]; ok {
+			report.Fail(entry.number, entry.nip, domain.ErrDuplicateNIP{}.Error())
+			continue
+		}
+		seen[row.Nurse.NIP] = entry.number
+
+		rows = append(rows, row)
+	}
+
+	err = h.userService.ImportNurses(userCtx, rows, report)
+	if err != nil {
+		l.Error("error importing nurses", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Nurses imported successfully"
+	res.Data = newImportNursesRes(report)
+
+	return c.JSON(res)
+}
+
 func (h userHandler) UpdateNurse(c *fiber.Ctx) error {
 	callerInfo := "[userHandler.UpdateNurse]"
 
@@ -406,13 +497,7 @@ func (h userHandler) GetUsers(c *fiber.Ctx) error {
 	filter := domain.FilterUserAcquire()
 	defer domain.FilterUserRelease(filter)
 
-	filter.UserID = query.uid
-	filter.Limit = int(query.Limit)
-	filter.Offset = int(query.Offset)
-	filter.Name = query.Name
-	filter.NIP = query.nip
-	filter.Role = query.Role
-	filter.CreatedAt = query.CreatedAt
+	query.toFilter(filter)
 
 	users := domain.UsersAcquire()
 	defer domain.UsersRelease(users)
@@ -448,23 +533,102 @@ func (h userHandler) GetUsers(c *fiber.Ctx) error {
 	return c.JSON(res)
 }
 
-func itStaffAccess(c *fiber.Ctx) error {
+// ExportUsers streams the users matching the same filters as GetUsers as a
+// CSV or XLSX sheet. Every match is exported unless a limit is given.
+func (h userHandler) ExportUsers(c *fiber.Ctx) error {
+	callerInfo := "[userHandler.ExportUsers]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	params := new(domain.UserExportParams)
+	if err := parseUserExport(c, params); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, sheet.ContentType(params.Format))
+	c.Attachment("users-" + time.Now().Format(exportDateFormat) + "." + params.Format)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.userService.ExportUsers(userCtx, &params.Filter, params.Format, w, nil); err != nil {
+			// the status has been sent, the client sees a cut off sheet
+			l.Error("failed to export users", zap.Error(err))
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// QueueUserExport queues the same export as ExportUsers to run in the
+// background, and responds with where to follow it.
+func (h userHandler) QueueUserExport(c *fiber.Ctx) error {
+	callerInfo := "[userHandler.QueueUserExport]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	var params domain.UserExportParams
+	if err := parseUserExport(c, &params); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return err
+	}
+
 	user := domain.UserAcquire()
 	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	job, err := jobqueue.New(domain.JobUserExport, user, params)
+	if err != nil {
+		l.Error("failed to encode job params", zap.Error(err))
+		return err
+	}
 
-	userFromToken := c.Locals(domain.UserFromToken)
-	if userFromToken == nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"message": "Unauthorized access",
-		})
+	if err = h.userService.QueueJob(userCtx, job); err != nil {
+		l.Error("failed to queue job", zap.Error(err))
+		return err
 	}
 
-	*user = userFromToken.(domain.User)
-	if user.Role != domain.RoleIT {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"message": "Unauthorized access",
-		})
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Job queued successfully"
+	res.Data = newQueuedJobRes(job)
+
+	return c.Status(http.StatusAccepted).JSON(res)
+}
+
+func parseUserExport(c *fiber.Ctx, params *domain.UserExportParams) error {
+	query := queryParamAcquire()
+	defer queryParamRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	format, err := exportFormat(c)
+	if err != nil {
+		return errBadRequest{err: err}
+	}
+
+	params.Format = format
+	query.toFilter(&params.Filter)
+	if params.Filter.Limit == 0 {
+		params.Filter.Limit = domain.LimitAll
+	}
+
+	return nil
+}
+
+// exportFormat is the sheet format asked for in the format query param, CSV
+// when there is none.
+func exportFormat(c *fiber.Ctx) (string, error) {
+	format := strings.ToLower(c.Query("format", sheet.FormatCSV))
+	if format != sheet.FormatCSV && format != sheet.FormatXLSX {
+		return "", sheet.ErrUnsupportedFormat
 	}
 
-	return c.Next()
+	return format, nil
 }
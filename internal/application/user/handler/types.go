@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,6 +20,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	"go.uber.org/multierr"
 
+	"github.com/j03hanafi/halo-suster/common/configs"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
@@ -81,13 +87,22 @@ func (n *nip) MarshalJSON() ([]byte, error) {
 
 func (n *nip) validate() error {
 	var errs error
-	const nipLength = 15
+	const (
+		nipLength    = 15
+		nipMinLength = 10
+	)
 
 	if len(*n) > nipLength {
 		errs = multierr.Append(errs, fmt.Errorf("nip is more than %d characters", nipLength))
 		return errs
 	}
 
+	// the checks below read up to the month
+	if len(*n) < nipMinLength {
+		errs = multierr.Append(errs, fmt.Errorf("nip is less than %d characters", nipMinLength))
+		return errs
+	}
+
 	if string(*n)[:3] != nipITFirstDigit && string(*n)[:3] != nipNurseFirstDigit {
 		errs = multierr.Append(
 			errs,
@@ -268,6 +283,19 @@ func (r registerNurseReq) validate() error {
 		}
 	}
 
+	errs = multierr.Append(errs, r.validateProfile())
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+// validateProfile checks the fields of a nurse other than the NIP.
+func (r registerNurseReq) validateProfile() error {
+	var errs error
+
 	if r.Name == "" {
 		errs = multierr.Append(errs, errors.New("name is required"))
 	} else if len(r.Name) < 5 || len(r.Name) > 50 {
@@ -295,6 +323,177 @@ func (r registerNurseReq) validate() error {
 	return nil
 }
 
+const (
+	rosterColumnNIP      = "nip"
+	rosterColumnName     = "name"
+	rosterColumnImgURL   = "identitycardscanimg"
+	rosterColumnPassword = "password"
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// rosterRow is a nurse as written in a roster file, number is the row they
+// are on counting the header as row 1.
+type rosterRow struct {
+	number   int
+	nip      string
+	name     string
+	imgURL   string
+	password string
+}
+
+// readRoster reads a roster CSV file with a header naming its columns nip,
+// name, identityCardScanImg and, optionally, password, in any order and case.
+// It is separated by commas, or by semicolons as spreadsheets set to an
+// Indonesian locale export it. Blank rows are skipped.
+func readRoster(r io.Reader) ([]rosterRow, error) {
+	br := bufio.NewReader(r)
+
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	// the buffer is all there is when the file is shorter than it
+	head, _ := br.Peek(br.Size())
+	header, _, _ := bytes.Cut(head, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		cr.Comma = ';'
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.New("file is not a valid CSV file: " + err.Error())
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file has no header row")
+	}
+
+	index := make(map[string]int, 4)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, seen := index[name]; !seen {
+			index[name] = i
+		}
+	}
+
+	missing := make([]string, 0, 3)
+	for column, name := range map[string]string{
+		rosterColumnNIP:    "nip",
+		rosterColumnName:   "name",
+		rosterColumnImgURL: "identityCardScanImg",
+	} {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, errors.New("file header is missing the columns: " + strings.Join(missing, ", "))
+	}
+
+	cell := func(record []string, column string) string {
+		i, ok := index[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]rosterRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		rows = append(rows, rosterRow{
+			number:   i + 2,
+			nip:      cell(record, rosterColumnNIP),
+			name:     cell(record, rosterColumnName),
+			imgURL:   cell(record, rosterColumnImgURL),
+			password: cell(record, rosterColumnPassword),
+		})
+	}
+
+	return rows, nil
+}
+
+// nurse validates the row with the rules of registering a nurse and, when it
+// has a password, of giving them access.
+func (r rosterRow) nurse(nurse *domain.User) error {
+	var errs error
+
+	switch {
+	case r.nip == "":
+		errs = multierr.Append(errs, errors.New("nip is required"))
+	case strings.IndexFunc(r.nip, func(c rune) bool { return c < '0' || c > '9' }) >= 0:
+		errs = multierr.Append(errs, errors.New("nip must be a number"))
+	default:
+		n := nip(r.nip)
+		if err := n.validate(); err != nil {
+			errs = multierr.Append(errs, err)
+		} else if !strings.HasPrefix(r.nip, nipNurseFirstDigit) {
+			errs = multierr.Append(errs, new(domain.ErrInvalidNIP))
+		}
+	}
+
+	errs = multierr.Append(errs, registerNurseReq{Name: r.name, ImgURL: r.imgURL}.validateProfile())
+
+	if r.password != "" {
+		errs = multierr.Append(errs, updateAccessReq{Password: r.password}.validate())
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	nurse.NIP = r.nip
+	nurse.Name = r.name
+	nurse.ImgURL = r.imgURL
+	nurse.Password = r.password
+
+	return nil
+}
+
+type importNursesRes struct {
+	Total    int               `json:"total"`
+	Imported int               `json:"imported"`
+	Granted  int               `json:"granted"`
+	Failed   int               `json:"failed"`
+	Errors   []importRowErrRes `json:"errors"`
+}
+
+// importRowErrRes keeps the NIP as written in the file, it may not be a
+// number.
+type importRowErrRes struct {
+	Row    int      `json:"row"`
+	NIP    string   `json:"nip"`
+	Errors []string `json:"errors"`
+}
+
+func newImportNursesRes(report *domain.NurseImport) importNursesRes {
+	res := importNursesRes{
+		Total:    report.Total,
+		Imported: report.Imported,
+		Granted:  report.Granted,
+		Failed:   report.Failed,
+		Errors:   make([]importRowErrRes, 0, len(report.Errors)),
+	}
+
+	for _, rowErr := range report.Errors {
+		res.Errors = append(res.Errors, importRowErrRes{
+			Row:    rowErr.Row,
+			NIP:    rowErr.NIP,
+			Errors: rowErr.Errors,
+		})
+	}
+
+	return res
+}
+
 var updateNurseReqPool = sync.Pool{
 	New: func() any {
 		return new(updateNurseReq)
@@ -420,6 +619,16 @@ func (r *queryParam) validate() {
 	}
 }
 
+func (r *queryParam) toFilter(filter *domain.FilterUser) {
+	filter.UserID = r.uid
+	filter.Limit = int(r.Limit)
+	filter.Offset = int(r.Offset)
+	filter.Name = r.Name
+	filter.NIP = r.nip
+	filter.Role = r.Role
+	filter.CreatedAt = r.CreatedAt
+}
+
 var getUserResPool = sync.Pool{
 	New: func() any {
 		return new(getUserRes)
@@ -460,3 +669,22 @@ func getUsersResRelease(t getUsersRes) {
 }
 
 type getUsersRes []getUserRes
+
+// queuedJobRes is a job just queued, StatusURL is where it is followed.
+type queuedJobRes struct {
+	JobID     ulid.ULID `json:"jobId"`
+	Kind      string    `json:"kind"`
+	Status    string    `json:"status"`
+	StatusURL string    `json:"statusUrl"`
+	CreatedAt string    `json:"createdAt"`
+}
+
+func newQueuedJobRes(job *domain.Job) queuedJobRes {
+	return queuedJobRes{
+		JobID:     job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		StatusURL: configs.Get().API.BaseURL + "/job/" + job.ID.String(),
+		CreatedAt: job.CreatedAt.Format(dateFormat),
+	}
+}
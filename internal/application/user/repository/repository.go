@@ -8,10 +8,19 @@ import (
 
 type UserRepositoryContract interface {
 	Register(ctx context.Context, user *domain.User) error
+	ImportNurses(ctx context.Context, nurses domain.Users) ([]string, error)
 	GetByNIP(ctx context.Context, user *domain.User) (*domain.User, error)
 	UpdateNurse(ctx context.Context, user *domain.User) error
 	DeleteNurse(ctx context.Context, user *domain.User) error
 	UpdateAccess(ctx context.Context, user *domain.User) error
 	GetUsers(ctx context.Context, filter *domain.FilterUser, users domain.Users) (domain.Users, error)
+	ExportUsers(ctx context.Context, filter *domain.FilterUser, writer UserWriter) error
+	QueueJob(ctx context.Context, job *domain.Job) error
 	SaveJWTCache(ctx context.Context, token string, user *domain.User)
 }
+
+// UserWriter receives the users of an export one at a time, the user is
+// reused for the next one once Write returns.
+type UserWriter interface {
+	Write(user *domain.User) error
+}
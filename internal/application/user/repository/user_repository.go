@@ -15,6 +15,8 @@ import (
 
 	"github.com/j03hanafi/halo-suster/common/id"
 	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/jobqueue"
+	"github.com/j03hanafi/halo-suster/internal/application/outbox"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
 
@@ -46,7 +48,16 @@ func (r UserRepository) Register(ctx context.Context, user *domain.User) error {
 		"created_at": user.CreatedAt,
 	}
 
-	_, err := r.db.Exec(ctx, insertQuery, args)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, insertQuery, args)
 	if err != nil {
 		l.Error("failed to register user", zap.Error(err))
 
@@ -58,9 +69,124 @@ func (r UserRepository) Register(ctx context.Context, user *domain.User) error {
 		return err
 	}
 
+	if err = outbox.Write(ctx, tx, domain.OutboxUserRegistered, "", outbox.User(user)); err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
+// ImportNurses registers nurses in one transaction with COPY and returns the
+// NIPs already registered, which are left as they are. The nurses are copied
+// into a temporary table first so that a registered NIP skips its row
+// instead of failing the whole COPY.
+func (r UserRepository) ImportNurses(ctx context.Context, nurses domain.Users) ([]string, error) {
+	callerInfo := "[UserRepository.ImportNurses]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	createQuery := `CREATE TEMPORARY TABLE users_import (LIKE users) ON COMMIT DROP`
+	if _, err = tx.Exec(ctx, createQuery); err != nil {
+		l.Error("failed to create import table", zap.Error(err))
+		return nil, err
+	}
+
+	createdAt := time.Now()
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"users_import"},
+		[]string{"id", "nip", "name", "password", "is_it", "img_url", "created_at"},
+		pgx.CopyFromSlice(len(nurses), func(i int) ([]any, error) {
+			nurses[i].ID = id.New()
+			nurses[i].CreatedAt = createdAt
+			return []any{
+				nurses[i].ID,
+				nurses[i].NIP,
+				nurses[i].Name,
+				nurses[i].Password,
+				false,
+				nurses[i].ImgURL,
+				nurses[i].CreatedAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		l.Error("failed to copy nurses", zap.Error(err))
+		return nil, err
+	}
+
+	insertQuery := `INSERT INTO users (id, nip, name, password, is_it, img_url, created_at)
+		SELECT id, nip, name, password, is_it, img_url, created_at FROM users_import
+		ON CONFLICT (nip) DO NOTHING RETURNING nip`
+	rows, err := tx.Query(ctx, insertQuery)
+	if err != nil {
+		l.Error("failed to import nurses", zap.Error(err))
+		return nil, err
+	}
+
+	imported := make(map[string]bool, len(nurses))
+	var nip string
+
+	_, err = pgx.ForEachRow(rows, []any{&nip}, func() error {
+		imported[nip] = true
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to import nurses", zap.Error(err))
+		return nil, err
+	}
+
+	registered := make([]string, 0, len(nurses)-len(imported))
+	events := make([]*domain.OutboxEvent, 0, len(imported))
+	defer func() {
+		for _, event := range events {
+			domain.OutboxEventRelease(event)
+		}
+	}()
+
+	for i := range nurses {
+		nurse := &nurses[i]
+		if !imported[nurse.NIP] {
+			registered = append(registered, nurse.NIP)
+			continue
+		}
+
+		event, err := outbox.New(domain.OutboxUserRegistered, "", outbox.User(nurse))
+		if err != nil {
+			l.Error("failed to encode outbox event", zap.Error(err))
+			return nil, err
+		}
+		event.CreatedAt = nurse.CreatedAt
+		events = append(events, event)
+	}
+
+	if err = outbox.CopyFrom(ctx, tx, events); err != nil {
+		l.Error("failed to save outbox events", zap.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return registered, nil
+}
+
 func (r UserRepository) GetByNIP(ctx context.Context, dUser *domain.User) (*domain.User, error) {
 	callerInfo := "[UserRepository.GetByNIP]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
@@ -110,7 +236,16 @@ func (r UserRepository) UpdateNurse(ctx context.Context, user *domain.User) erro
 		"name": user.Name,
 	}
 
-	result, err := r.db.Exec(ctx, updateQuery, args)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	result, err := tx.Exec(ctx, updateQuery, args)
 	if err != nil {
 		l.Error("failed to update user", zap.Error(err))
 
@@ -127,9 +262,24 @@ func (r UserRepository) UpdateNurse(ctx context.Context, user *domain.User) erro
 		return new(domain.ErrUserNotFound)
 	}
 
+	data := outbox.User(user)
+	data.Role = domain.RoleNurse
+	if err = outbox.Write(ctx, tx, domain.OutboxUserUpdated, "", data); err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
+// DeleteNurse deletes a nurse who has no appointments, handovers or shifts,
+// deleting those would erase the history of the patients and wards they were
+// with.
 func (r UserRepository) DeleteNurse(ctx context.Context, user *domain.User) error {
 	callerInfo := "[UserRepository.DeleteNurse]"
 	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
@@ -137,9 +287,24 @@ func (r UserRepository) DeleteNurse(ctx context.Context, user *domain.User) erro
 	deleteQuery := `DELETE FROM users WHERE id = @id AND nip LIKE '303%'`
 	args := pgx.NamedArgs{"id": user.ID}
 
-	result, err := r.db.Exec(ctx, deleteQuery, args)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	result, err := tx.Exec(ctx, deleteQuery, args)
 	if err != nil {
 		l.Error("failed to delete user", zap.Error(err))
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return new(domain.ErrNurseHasHistory)
+		}
+
 		return err
 	}
 
@@ -148,6 +313,17 @@ func (r UserRepository) DeleteNurse(ctx context.Context, user *domain.User) erro
 		return new(domain.ErrNotFoundOrNotNurse)
 	}
 
+	err = outbox.Write(ctx, tx, domain.OutboxUserDeleted, "", outbox.UserData{UserID: user.ID})
+	if err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
@@ -161,7 +337,16 @@ func (r UserRepository) UpdateAccess(ctx context.Context, user *domain.User) err
 		"password": user.Password,
 	}
 
-	result, err := r.db.Exec(ctx, updateQuery, args)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	result, err := tx.Exec(ctx, updateQuery, args)
 	if err != nil {
 		l.Error("failed to update user access", zap.Error(err))
 		return err
@@ -172,6 +357,17 @@ func (r UserRepository) UpdateAccess(ctx context.Context, user *domain.User) err
 		return new(domain.ErrNotFoundOrNotNurse)
 	}
 
+	err = outbox.Write(ctx, tx, domain.OutboxUserAccessGranted, "", outbox.UserData{UserID: user.ID})
+	if err != nil {
+		l.Error("failed to save outbox event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
@@ -207,6 +403,52 @@ func (r UserRepository) GetUsers(
 	return users, nil
 }
 
+// ExportUsers hands every user matching filter to writer as it is read from
+// the database, so an export is never held in memory as a whole.
+func (r UserRepository) ExportUsers(ctx context.Context, filter *domain.FilterUser, writer UserWriter) error {
+	callerInfo := "[UserRepository.ExportUsers]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterUser(filter)
+	getQuery := `SELECT id, nip, name, is_it, created_at FROM users` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to export users", zap.Error(err))
+		return err
+	}
+
+	dUser := domain.UserAcquire()
+	defer domain.UserRelease(dUser)
+	var isIT bool
+
+	_, err = pgx.ForEachRow(rows, []any{&dUser.ID, &dUser.NIP, &dUser.Name, &isIT, &dUser.CreatedAt}, func() error {
+		dUser.Role = domain.RoleNurse
+		if isIT {
+			dUser.Role = domain.RoleIT
+		}
+		return writer.Write(dUser)
+	})
+	if err != nil {
+		l.Error("failed to export users", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r UserRepository) QueueJob(ctx context.Context, job *domain.Job) error {
+	callerInfo := "[UserRepository.QueueJob]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := jobqueue.Insert(ctx, r.db, job); err != nil {
+		l.Error("failed to queue job", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (r UserRepository) filterUser(filter *domain.FilterUser) (string, pgx.NamedArgs) {
 	const totalConditions = 4
 	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
@@ -239,10 +481,12 @@ func (r UserRepository) filterUser(filter *domain.FilterUser) (string, pgx.Named
 	const totalLimitOffset = 2
 	limitOffset := make([]string, 0, totalLimitOffset)
 
-	limitOffset = append(limitOffset, "LIMIT @limit")
-	params["limit"] = 5
-	if filter.Limit != 0 {
-		params["limit"] = filter.Limit
+	if filter.Limit != domain.LimitAll {
+		limitOffset = append(limitOffset, "LIMIT @limit")
+		params["limit"] = 5
+		if filter.Limit != 0 {
+			params["limit"] = filter.Limit
+		}
 	}
 
 	if filter.Offset != 0 {
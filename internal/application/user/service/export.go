@@ -0,0 +1,37 @@
+package service
+
+import (
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+var userColumns = []string{"userId", "nip", "name", "role", "createdAt"}
+
+// userExport writes the users of an export as rows of a sheet.
+type userExport struct {
+	sheet    *sheet.Writer
+	progress func(rows int)
+	rows     int
+}
+
+func (e *userExport) Write(user *domain.User) error {
+	err := e.sheet.Write(
+		user.ID.String(),
+		user.NIP,
+		user.Name,
+		user.Role,
+		user.CreatedAt.Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.progress != nil {
+		e.progress(e.rows)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"io"
 
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
@@ -13,8 +14,17 @@ type UserServiceContract interface {
 	LoginNurse(ctx context.Context, user *domain.User) (*domain.User, error)
 
 	RegisterNurse(ctx context.Context, user *domain.User) error
+	ImportNurses(ctx context.Context, rows []domain.NurseImportRow, report *domain.NurseImport) error
 	UpdateNurse(ctx context.Context, user *domain.User) error
 	DeleteNurse(ctx context.Context, user *domain.User) error
 	UpdateAccess(ctx context.Context, user *domain.User) error
 	GetUsers(ctx context.Context, filter *domain.FilterUser, users domain.Users) (domain.Users, error)
+	ExportUsers(
+		ctx context.Context,
+		filter *domain.FilterUser,
+		format string,
+		w io.Writer,
+		progress func(rows int),
+	) error
+	QueueJob(ctx context.Context, job *domain.Job) error
 }
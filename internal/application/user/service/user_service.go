@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"io"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/j03hanafi/halo-suster/common/logger"
 	"github.com/j03hanafi/halo-suster/common/security"
+	"github.com/j03hanafi/halo-suster/internal/application/sheet"
 	"github.com/j03hanafi/halo-suster/internal/application/user/repository"
 	"github.com/j03hanafi/halo-suster/internal/domain"
 )
@@ -132,6 +135,86 @@ func (s UserService) RegisterNurse(ctx context.Context, user *domain.User) error
 	return nil
 }
 
+// ImportNurses registers the valid nurses of a roster, hashing the password
+// of those given access. A nurse whose NIP is already registered is reported
+// and the rest are imported anyway.
+func (s UserService) ImportNurses(
+	ctx context.Context,
+	rows []domain.NurseImportRow,
+	report *domain.NurseImport,
+) error {
+	callerInfo := "[UserService.ImportNurses]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	nurses := make(domain.Users, 0, len(rows))
+	nurseRows := make(map[string]int, len(rows))
+
+	// hashing is slow on purpose, so it is kept out of the timeout of the
+	// transaction
+	for i := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		nurse := rows[i].Nurse
+		nurse.Role = domain.RoleNurse
+
+		if nurse.Password != "" {
+			password, err := security.HashPassword(nurse.Password)
+			if err != nil {
+				l.Error("failed to hash password", zap.Error(err))
+				return err
+			}
+			nurse.Password = password
+		}
+
+		nurses = append(nurses, nurse)
+		nurseRows[nurse.NIP] = rows[i].Row
+	}
+
+	if len(nurses) > 0 {
+		importCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+		defer cancel()
+
+		registered, err := s.userRepository.ImportNurses(importCtx, nurses)
+		if err != nil {
+			l.Error("failed to import nurses", zap.Error(err))
+			return err
+		}
+
+		duplicates := make(map[string]bool, len(registered))
+		for _, nip := range registered {
+			duplicates[nip] = true
+			report.Fail(nurseRows[nip], nip, domain.ErrDuplicateNIP{}.Error())
+		}
+
+		for i := range nurses {
+			if duplicates[nurses[i].NIP] {
+				continue
+			}
+
+			report.Imported++
+			if nurses[i].Password != "" {
+				report.Granted++
+			}
+		}
+	}
+
+	// failures are reported in the order of the roster
+	slices.SortFunc(report.Errors, func(a, b domain.NurseImportError) int {
+		return a.Row - b.Row
+	})
+
+	l.Info("nurses imported",
+		zap.Int("total", report.Total),
+		zap.Int("imported", report.Imported),
+		zap.Int("granted", report.Granted),
+		zap.Int("failed", report.Failed),
+	)
+
+	return nil
+}
+
 func (s UserService) UpdateNurse(ctx context.Context, user *domain.User) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
@@ -207,4 +290,57 @@ func (s UserService) GetUsers(
 	return users, nil
 }
 
+// ExportUsers writes the users matching filter to w as a sheet in format. Rows
+// are written as they are read, so a failure comes after part of the sheet has
+// been written. progress, when given, is told how many rows have been written.
+func (s UserService) ExportUsers(
+	ctx context.Context,
+	filter *domain.FilterUser,
+	format string,
+	w io.Writer,
+	progress func(rows int),
+) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[UserService.ExportUsers]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	writer, err := sheet.NewWriter(w, format, "Users", userColumns)
+	if err != nil {
+		l.Error("failed to create sheet", zap.Error(err))
+		return err
+	}
+
+	err = s.userRepository.ExportUsers(ctx, filter, &userExport{sheet: writer, progress: progress})
+	if err != nil {
+		l.Error("failed to export users", zap.Error(err))
+		return err
+	}
+
+	if err = writer.Close(); err != nil {
+		l.Error("failed to close sheet", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// QueueJob queues job to be run in the background by the job module.
+func (s UserService) QueueJob(ctx context.Context, job *domain.Job) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[UserService.QueueJob]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo), zap.String("kind", job.Kind))
+
+	err := s.userRepository.QueueJob(ctx, job)
+	if err != nil {
+		l.Error("failed to queue job", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 var _ UserServiceContract = (*UserService)(nil)
@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/ward/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	wardIDFromParam    = "id"
+	patientIDFromParam = "identityNumber"
+)
+
+type wardHandler struct {
+	wardService service.WardServiceContract
+}
+
+func NewWardHandler(router fiber.Router, jwtMiddleware fiber.Handler, wardService service.WardServiceContract) {
+	handler := wardHandler{
+		wardService: wardService,
+	}
+
+	wardRouter := router.Group("/ward", jwtMiddleware)
+	wardRouter.Post("", access.ITStaff, handler.CreateWard)
+	wardRouter.Get("/board", handler.GetBoard)
+	wardRouter.Post("/admit", handler.Admit)
+	wardRouter.Post("/transfer", handler.Transfer)
+	wardRouter.Post("/discharge", handler.Discharge)
+	wardRouter.Get("/patient/:"+patientIDFromParam+"/history", handler.GetBedHistory)
+	wardRouter.Post("/:"+wardIDFromParam+"/bed", access.ITStaff, handler.CreateBed)
+	wardRouter.Put("/:"+wardIDFromParam+"/head-nurse", access.ITStaff, handler.SetHeadNurse)
+}
+
+func (h wardHandler) CreateWard(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.CreateWard]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createWardReqAcquire()
+	defer createWardReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	ward := domain.WardAcquire()
+	defer domain.WardRelease(ward)
+
+	ward.Code = req.Code
+	ward.Name = req.Name
+
+	err := h.wardService.CreateWard(userCtx, ward)
+	if err != nil {
+		l.Error("failed to create ward", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Ward created successfully"
+	res.Data = newWardRes(ward)
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h wardHandler) CreateBed(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.CreateBed]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	wardID, err := ulid.Parse(c.Params(wardIDFromParam))
+	if err != nil {
+		l.Error("error parsing wardIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := createBedReqAcquire()
+	defer createBedReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	bed := domain.BedAcquire()
+	defer domain.BedRelease(bed)
+
+	bed.WardID = wardID
+	bed.Code = req.Code
+
+	err = h.wardService.CreateBed(userCtx, bed)
+	if err != nil {
+		l.Error("failed to create bed", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Bed created successfully"
+	res.Data = bedRes{
+		BedID:     bed.ID,
+		WardID:    bed.WardID,
+		Code:      bed.Code,
+		CreatedAt: bed.CreatedAt.Format(dateFormat),
+	}
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h wardHandler) SetHeadNurse(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.SetHeadNurse]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	wardID, err := ulid.Parse(c.Params(wardIDFromParam))
+	if err != nil {
+		l.Error("error parsing wardIDParam", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	req := setHeadNurseReqAcquire()
+	defer setHeadNurseReqRelease(req)
+
+	if err = c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err = req.validate(); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	ward := domain.WardAcquire()
+	defer domain.WardRelease(ward)
+
+	ward.ID = wardID
+	ward.HeadNurseID = req.nurseID
+
+	err = h.wardService.SetHeadNurse(userCtx, ward)
+	if err != nil {
+		l.Error("failed to set head nurse", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Head nurse updated successfully"
+	res.Data = newWardRes(ward)
+
+	return c.JSON(res)
+}
+
+func (h wardHandler) Admit(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.Admit]"
+	return h.moveBed(c, callerInfo, true, h.wardService.Admit, "Patient admitted successfully")
+}
+
+func (h wardHandler) Transfer(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.Transfer]"
+	return h.moveBed(c, callerInfo, true, h.wardService.Transfer, "Patient transferred successfully")
+}
+
+func (h wardHandler) Discharge(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.Discharge]"
+	return h.moveBed(c, callerInfo, false, h.wardService.Discharge, "Patient discharged successfully")
+}
+
+// moveBed handles admit, transfer and discharge which only differ in
+// whether a target bed is required and in the service call.
+func (h wardHandler) moveBed(
+	c *fiber.Ctx,
+	callerInfo string,
+	requireBed bool,
+	move func(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error,
+	message string,
+) error {
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := bedMovementReqAcquire()
+	defer bedMovementReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(requireBed); err != nil {
+		l.Error("error validating request", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	assignment := domain.BedAssignmentAcquire()
+	defer domain.BedAssignmentRelease(assignment)
+
+	assignment.PatientID = string(*req.IdentityNumber)
+	assignment.BedID = req.bedID
+
+	err := move(userCtx, assignment, user)
+	if err != nil {
+		l.Error("failed to move patient", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = message
+	res.Data = newBedAssignmentRes(assignment)
+
+	return c.JSON(res)
+}
+
+func (h wardHandler) GetBoard(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.GetBoard]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	var wardID ulid.ULID
+	if param := c.Query("wardId", ""); param != "" {
+		wardID, _ = ulid.Parse(param)
+	}
+
+	wards := domain.WardsAcquire()
+	defer domain.WardsRelease(wards)
+
+	wards, err := h.wardService.GetBoard(userCtx, wardID, wards)
+	if err != nil {
+		l.Error("failed to get bed board", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Bed board retrieved successfully"
+
+	boardRes := make([]boardWardRes, 0, len(wards))
+	for _, ward := range wards {
+		wardRes := boardWardRes{
+			WardID: ward.ID,
+			Code:   ward.Code,
+			Name:   ward.Name,
+			Total:  len(ward.Beds),
+			Beds:   make([]boardBedRes, 0, len(ward.Beds)),
+		}
+		if !id.IsZero(ward.HeadNurseID) {
+			headNurseID := ward.HeadNurseID
+			wardRes.HeadNurseID = &headNurseID
+		}
+
+		for _, bed := range ward.Beds {
+			bedRes := boardBedRes{BedID: bed.ID, Code: bed.Code}
+			if bed.Occupancy.PatientID != "" {
+				bedRes.Occupied = true
+				bedRes.Occupant = &occupantRes{
+					IdentityNumber: idNumber(bed.Occupancy.PatientID),
+					Name:           bed.Occupancy.PatientName,
+					Since:          bed.Occupancy.StartedAt.Format(dateFormat),
+				}
+				wardRes.Occupied++
+			}
+			wardRes.Beds = append(wardRes.Beds, bedRes)
+		}
+
+		boardRes = append(boardRes, wardRes)
+	}
+
+	res.Data = boardRes
+
+	return c.JSON(res)
+}
+
+func (h wardHandler) GetBedHistory(c *fiber.Ctx) error {
+	callerInfo := "[wardHandler.GetBedHistory]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	identityNumber, err := idNumberFromParam(c.Params(patientIDFromParam))
+	if err != nil {
+		l.Error("error validating identityNumber", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	assignments := domain.BedAssignmentsAcquire()
+	defer domain.BedAssignmentsRelease(assignments)
+
+	assignments, err = h.wardService.GetBedHistory(userCtx, string(identityNumber), assignments)
+	if err != nil {
+		l.Error("failed to get bed history", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Bed history retrieved successfully"
+
+	historyRes := make([]bedAssignmentRes, 0, len(assignments))
+	for i := range assignments {
+		historyRes = append(historyRes, newBedAssignmentRes(&assignments[i]))
+	}
+
+	res.Data = historyRes
+
+	return c.JSON(res)
+}
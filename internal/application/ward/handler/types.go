@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type idNumber string
+
+func (n *idNumber) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("identityNumber is required")
+	}
+
+	var jsonID int
+	if err := json.Unmarshal(b, &jsonID); err != nil {
+		return errors.New("identityNumber must be a number")
+	}
+	*n = idNumber(strconv.Itoa(jsonID))
+	return nil
+}
+
+func (n *idNumber) MarshalJSON() ([]byte, error) {
+	jsonID, err := strconv.Atoi(string(*n))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonID)
+}
+
+func (n *idNumber) validate() error {
+	const idNumberLength = 16
+
+	if len(*n) != idNumberLength {
+		return errors.New("identityNumber must have 16 characters")
+	}
+
+	return nil
+}
+
+func idNumberFromParam(param string) (idNumber, error) {
+	if _, err := strconv.ParseUint(param, 10, 64); err != nil {
+		return "", errors.New("identityNumber must be a number")
+	}
+
+	n := idNumber(param)
+	return n, n.validate()
+}
+
+var createWardReqPool = sync.Pool{
+	New: func() any {
+		return new(createWardReq)
+	},
+}
+
+func createWardReqAcquire() *createWardReq {
+	return createWardReqPool.Get().(*createWardReq)
+}
+
+func createWardReqRelease(t *createWardReq) {
+	*t = createWardReq{}
+	createWardReqPool.Put(t)
+}
+
+type createWardReq struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (r createWardReq) validate() error {
+	var errs error
+
+	if r.Code == "" {
+		errs = multierr.Append(errs, errors.New("code is required"))
+	} else if len(r.Code) > 20 {
+		errs = multierr.Append(errs, errors.New("code must have 1 to 20 characters"))
+	}
+
+	if r.Name == "" {
+		errs = multierr.Append(errs, errors.New("name is required"))
+	} else if len(r.Name) < 3 || len(r.Name) > 50 {
+		errs = multierr.Append(errs, errors.New("name must have 3 to 50 characters"))
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var createBedReqPool = sync.Pool{
+	New: func() any {
+		return new(createBedReq)
+	},
+}
+
+func createBedReqAcquire() *createBedReq {
+	return createBedReqPool.Get().(*createBedReq)
+}
+
+func createBedReqRelease(t *createBedReq) {
+	*t = createBedReq{}
+	createBedReqPool.Put(t)
+}
+
+type createBedReq struct {
+	Code string `json:"code"`
+}
+
+func (r createBedReq) validate() error {
+	if r.Code == "" {
+		return errors.New("code is required")
+	} else if len(r.Code) > 20 {
+		return errors.New("code must have 1 to 20 characters")
+	}
+
+	return nil
+}
+
+var bedMovementReqPool = sync.Pool{
+	New: func() any {
+		return new(bedMovementReq)
+	},
+}
+
+func bedMovementReqAcquire() *bedMovementReq {
+	return bedMovementReqPool.Get().(*bedMovementReq)
+}
+
+func bedMovementReqRelease(t *bedMovementReq) {
+	*t = bedMovementReq{}
+	bedMovementReqPool.Put(t)
+}
+
+// bedMovementReq is the body of admit, transfer and discharge, BedID is
+// ignored when discharging.
+type bedMovementReq struct {
+	IdentityNumber *idNumber `json:"identityNumber"`
+	BedID          string    `json:"bedId"`
+	bedID          ulid.ULID
+}
+
+func (r *bedMovementReq) validate(requireBed bool) error {
+	var errs error
+
+	if r.IdentityNumber == nil {
+		errs = multierr.Append(errs, errors.New("identityNumber is required"))
+	} else {
+		errs = multierr.Append(errs, r.IdentityNumber.validate())
+	}
+
+	if requireBed {
+		bedID, err := ulid.Parse(r.BedID)
+		if err != nil {
+			errs = multierr.Append(errs, errors.New("bedId must be a valid bed id"))
+		}
+		r.bedID = bedID
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+var setHeadNurseReqPool = sync.Pool{
+	New: func() any {
+		return new(setHeadNurseReq)
+	},
+}
+
+func setHeadNurseReqAcquire() *setHeadNurseReq {
+	return setHeadNurseReqPool.Get().(*setHeadNurseReq)
+}
+
+func setHeadNurseReqRelease(t *setHeadNurseReq) {
+	*t = setHeadNurseReq{}
+	setHeadNurseReqPool.Put(t)
+}
+
+// setHeadNurseReq removes the head nurse of the ward when NurseID is empty.
+type setHeadNurseReq struct {
+	NurseID string `json:"nurseId"`
+	nurseID ulid.ULID
+}
+
+func (r *setHeadNurseReq) validate() error {
+	if r.NurseID == "" {
+		return nil
+	}
+
+	nurseID, err := ulid.Parse(r.NurseID)
+	if err != nil {
+		return errors.New("nurseId must be a valid user id")
+	}
+	r.nurseID = nurseID
+
+	return nil
+}
+
+type wardRes struct {
+	WardID      ulid.ULID  `json:"wardId"`
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	HeadNurseID *ulid.ULID `json:"headNurseId"`
+	CreatedAt   string     `json:"createdAt"`
+}
+
+func newWardRes(ward *domain.Ward) wardRes {
+	res := wardRes{
+		WardID:    ward.ID,
+		Code:      ward.Code,
+		Name:      ward.Name,
+		CreatedAt: ward.CreatedAt.Format(dateFormat),
+	}
+	if !id.IsZero(ward.HeadNurseID) {
+		headNurseID := ward.HeadNurseID
+		res.HeadNurseID = &headNurseID
+	}
+
+	return res
+}
+
+type bedRes struct {
+	BedID     ulid.ULID `json:"bedId"`
+	WardID    ulid.ULID `json:"wardId"`
+	Code      string    `json:"code"`
+	CreatedAt string    `json:"createdAt"`
+}
+
+type occupantRes struct {
+	IdentityNumber idNumber `json:"identityNumber"`
+	Name           string   `json:"name"`
+	Since          string   `json:"since"`
+}
+
+type boardBedRes struct {
+	BedID    ulid.ULID    `json:"bedId"`
+	Code     string       `json:"code"`
+	Occupied bool         `json:"occupied"`
+	Occupant *occupantRes `json:"occupant"`
+}
+
+type boardWardRes struct {
+	WardID      ulid.ULID     `json:"wardId"`
+	Code        string        `json:"code"`
+	Name        string        `json:"name"`
+	HeadNurseID *ulid.ULID    `json:"headNurseId"`
+	Total       int           `json:"totalBeds"`
+	Occupied    int           `json:"occupiedBeds"`
+	Beds        []boardBedRes `json:"beds"`
+}
+
+type bedAssignmentRes struct {
+	AssignmentID ulid.ULID `json:"assignmentId"`
+	WardID       ulid.ULID `json:"wardId"`
+	WardCode     string    `json:"wardCode"`
+	BedID        ulid.ULID `json:"bedId"`
+	BedCode      string    `json:"bedCode"`
+	StartedAt    string    `json:"startedAt"`
+	EndedAt      string    `json:"endedAt,omitempty"`
+	EndReason    string    `json:"endReason,omitempty"`
+}
+
+func newBedAssignmentRes(assignment *domain.BedAssignment) bedAssignmentRes {
+	res := bedAssignmentRes{
+		AssignmentID: assignment.ID,
+		WardID:       assignment.WardID,
+		WardCode:     assignment.WardCode,
+		BedID:        assignment.BedID,
+		BedCode:      assignment.BedCode,
+		StartedAt:    assignment.StartedAt.Format(dateFormat),
+		EndReason:    assignment.EndReason,
+	}
+	if !assignment.EndedAt.IsZero() {
+		res.EndedAt = assignment.EndedAt.Format(dateFormat)
+	}
+
+	return res
+}
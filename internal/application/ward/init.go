@@ -0,0 +1,21 @@
+package ward
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/ward/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/ward/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/ward/service"
+)
+
+func NewModule(router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+
+	wardRepository := repository.NewWardRepository(db)
+	wardService := service.NewWardService(ctxTimeout, wardRepository)
+	handler.NewWardHandler(router, jwtMiddleware, wardService)
+}
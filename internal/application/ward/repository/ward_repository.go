@@ -0,0 +1,517 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/patientevent"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	currentBedIndex     = "idx_bed_assignments_bed_id_current"
+	currentPatientIndex = "idx_bed_assignments_patient_id_current"
+)
+
+type WardRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWardRepository(db *pgxpool.Pool) *WardRepository {
+	return &WardRepository{db: db}
+}
+
+func (r WardRepository) CreateWard(ctx context.Context, ward *domain.Ward) error {
+	callerInfo := "[WardRepository.CreateWard]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	ward.ID = id.New()
+	ward.CreatedAt = time.Now()
+
+	insertQuery := `INSERT INTO wards (id, code, name, created_at) VALUES (@id, @code, @name, @created_at)`
+	args := pgx.NamedArgs{
+		"id":         ward.ID,
+		"code":       ward.Code,
+		"name":       ward.Name,
+		"created_at": ward.CreatedAt,
+	}
+
+	_, err := r.db.Exec(ctx, insertQuery, args)
+	if err != nil {
+		l.Error("failed to create ward", zap.Error(err))
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return new(domain.ErrDuplicateWard)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r WardRepository) CreateBed(ctx context.Context, bed *domain.Bed) error {
+	callerInfo := "[WardRepository.CreateBed]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	bed.ID = id.New()
+	bed.CreatedAt = time.Now()
+
+	insertQuery := `INSERT INTO beds (id, ward_id, code, created_at) VALUES (@id, @ward_id, @code, @created_at)`
+	args := pgx.NamedArgs{
+		"id":         bed.ID,
+		"ward_id":    bed.WardID,
+		"code":       bed.Code,
+		"created_at": bed.CreatedAt,
+	}
+
+	_, err := r.db.Exec(ctx, insertQuery, args)
+	if err != nil {
+		l.Error("failed to create bed", zap.Error(err))
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return new(domain.ErrDuplicateBed)
+			case pgerrcode.ForeignKeyViolation:
+				return new(domain.ErrWardNotFound)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// SetHeadNurse makes ward.HeadNurseID the head nurse of the ward, a zero
+// HeadNurseID removes the current one.
+func (r WardRepository) SetHeadNurse(ctx context.Context, ward *domain.Ward) error {
+	callerInfo := "[WardRepository.SetHeadNurse]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	updateQuery := `UPDATE wards SET head_nurse_id = NULL WHERE id = @id 
+		RETURNING code, name, created_at`
+	args := pgx.NamedArgs{"id": ward.ID}
+
+	if !id.IsZero(ward.HeadNurseID) {
+		updateQuery = `UPDATE wards w SET head_nurse_id = u.id FROM users u 
+			WHERE w.id = @id AND u.id = @head_nurse_id AND u.nip LIKE '303%'
+			RETURNING w.code, w.name, w.created_at`
+		args["head_nurse_id"] = ward.HeadNurseID
+	}
+
+	err := r.db.QueryRow(ctx, updateQuery, args).Scan(&ward.Code, &ward.Name, &ward.CreatedAt)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			l.Error("failed to set head nurse", zap.Error(err))
+			return err
+		}
+
+		var wardExists bool
+		existsQuery := `SELECT EXISTS (SELECT 1 FROM wards WHERE id = @id)`
+		if err = r.db.QueryRow(ctx, existsQuery, args).Scan(&wardExists); err != nil {
+			l.Error("failed to check ward", zap.Error(err))
+			return err
+		}
+
+		if !wardExists {
+			return new(domain.ErrWardNotFound)
+		}
+		return new(domain.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+func (r WardRepository) Admit(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error {
+	callerInfo := "[WardRepository.Admit]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.insertAssignment(ctx, tx, assignment, user); err != nil {
+		l.Error("failed to admit patient", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, assignment.PatientID, domain.PatientEventAdmitted, user, map[string]string{
+		"wardCode": assignment.WardCode,
+		"bedCode":  assignment.BedCode,
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r WardRepository) Transfer(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error {
+	callerInfo := "[WardRepository.Transfer]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	previous := domain.BedAssignmentAcquire()
+	defer domain.BedAssignmentRelease(previous)
+
+	previous.PatientID = assignment.PatientID
+	previous.EndReason = domain.BedEndTransfer
+	if err = r.endAssignment(ctx, tx, previous, user); err != nil {
+		l.Error("failed to end current bed assignment", zap.Error(err))
+		return err
+	}
+
+	if previous.BedID == assignment.BedID {
+		return new(domain.ErrBedOccupied)
+	}
+
+	if err = r.insertAssignment(ctx, tx, assignment, user); err != nil {
+		l.Error("failed to transfer patient", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, assignment.PatientID, domain.PatientEventTransferred, user, map[string]string{
+		"fromWardCode": previous.WardCode,
+		"fromBedCode":  previous.BedCode,
+		"wardCode":     assignment.WardCode,
+		"bedCode":      assignment.BedCode,
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r WardRepository) Discharge(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error {
+	callerInfo := "[WardRepository.Discharge]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	assignment.EndReason = domain.BedEndDischarge
+	if err = r.endAssignment(ctx, tx, assignment, user); err != nil {
+		l.Error("failed to discharge patient", zap.Error(err))
+		return err
+	}
+
+	err = r.insertEvent(ctx, tx, assignment.PatientID, domain.PatientEventDischarged, user, map[string]string{
+		"wardCode": assignment.WardCode,
+		"bedCode":  assignment.BedCode,
+	})
+	if err != nil {
+		l.Error("failed to save patient event", zap.Error(err))
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// insertAssignment puts the patient in assignment.BedID, the partial unique
+// indexes on current assignments reject occupied beds and patients who are
+// already lying in another bed.
+func (r WardRepository) insertAssignment(
+	ctx context.Context,
+	tx pgx.Tx,
+	assignment *domain.BedAssignment,
+	user *domain.User,
+) error {
+	assignment.ID = id.New()
+	assignment.StartedBy = user.ID
+	assignment.StartedAt = time.Now()
+
+	insertQuery := `WITH inserted AS (
+			INSERT INTO bed_assignments (id, bed_id, patient_id, started_by, started_at) 
+			SELECT @id, b.id, @patient_id, @started_by, @started_at FROM beds b WHERE b.id = @bed_id
+			RETURNING bed_id
+		)
+		SELECT b.code, w.id, w.code FROM inserted i JOIN beds b ON b.id = i.bed_id JOIN wards w ON w.id = b.ward_id`
+	args := pgx.NamedArgs{
+		"id":         assignment.ID,
+		"bed_id":     assignment.BedID,
+		"patient_id": assignment.PatientID,
+		"started_by": assignment.StartedBy,
+		"started_at": assignment.StartedAt,
+	}
+
+	err := tx.QueryRow(ctx, insertQuery, args).Scan(&assignment.BedCode, &assignment.WardID, &assignment.WardCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrBedNotFound)
+		}
+
+		pgErr := &pgconn.PgError{}
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == currentBedIndex:
+				return new(domain.ErrBedOccupied)
+			case pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == currentPatientIndex:
+				return new(domain.ErrPatientAlreadyAdmitted)
+			case pgErr.Code == pgerrcode.ForeignKeyViolation:
+				return new(domain.ErrPatientNotFound)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// endAssignment closes the current assignment of assignment.PatientID and
+// fills assignment with the bed the patient is leaving.
+func (r WardRepository) endAssignment(
+	ctx context.Context,
+	tx pgx.Tx,
+	assignment *domain.BedAssignment,
+	user *domain.User,
+) error {
+	assignment.EndedBy = user.ID
+	assignment.EndedAt = time.Now()
+
+	updateQuery := `UPDATE bed_assignments a SET ended_by = @ended_by, ended_at = @ended_at, end_reason = @end_reason 
+		FROM beds b JOIN wards w ON w.id = b.ward_id
+		WHERE b.id = a.bed_id AND a.patient_id = @patient_id AND a.ended_at IS NULL
+		RETURNING a.id, a.bed_id, b.code, w.id, w.code, a.started_by, a.started_at`
+	args := pgx.NamedArgs{
+		"patient_id": assignment.PatientID,
+		"ended_by":   assignment.EndedBy,
+		"ended_at":   assignment.EndedAt,
+		"end_reason": assignment.EndReason,
+	}
+
+	err := tx.QueryRow(ctx, updateQuery, args).Scan(
+		&assignment.ID,
+		&assignment.BedID,
+		&assignment.BedCode,
+		&assignment.WardID,
+		&assignment.WardCode,
+		&assignment.StartedBy,
+		&assignment.StartedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return new(domain.ErrPatientNotAdmitted)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r WardRepository) insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	patientID, eventType string,
+	user *domain.User,
+	data map[string]string,
+) error {
+	event, err := patientevent.New(patientID, eventType, user, data)
+	if err != nil {
+		return err
+	}
+	defer domain.PatientEventRelease(event)
+
+	return patientevent.Insert(ctx, tx, event)
+}
+
+func (r WardRepository) GetBoard(ctx context.Context, wardID ulid.ULID, wards domain.Wards) (domain.Wards, error) {
+	callerInfo := "[WardRepository.GetBoard]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	getQuery := `SELECT w.id, w.code, w.name, w.head_nurse_id, w.created_at, b.id, b.code, b.created_at, 
+			a.id, a.patient_id, p.name, a.started_by, a.started_at
+		FROM wards w 
+		LEFT JOIN beds b ON b.ward_id = w.id
+		LEFT JOIN bed_assignments a ON a.bed_id = b.id AND a.ended_at IS NULL
+		LEFT JOIN patients p ON p.id = a.patient_id`
+	args := pgx.NamedArgs{}
+
+	if !id.IsZero(wardID) {
+		getQuery += ` WHERE w.id = @ward_id`
+		args["ward_id"] = wardID
+	}
+
+	getQuery += ` ORDER BY w.code ASC, b.code ASC`
+
+	rows, err := r.db.Query(ctx, getQuery, args)
+	if err != nil {
+		l.Error("failed to get bed board", zap.Error(err))
+		return wards, err
+	}
+
+	var (
+		dWard                           domain.Ward
+		bedID, assignmentID, startedBy  ulid.ULID
+		bedCode, patientID, patientName *string
+		bedCreatedAt, startedAt         *time.Time
+	)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dWard.ID, &dWard.Code, &dWard.Name, &dWard.HeadNurseID, &dWard.CreatedAt,
+			&bedID, &bedCode, &bedCreatedAt,
+			&assignmentID, &patientID, &patientName, &startedBy, &startedAt,
+		},
+		func() error {
+			if len(wards) == 0 || wards[len(wards)-1].ID != dWard.ID {
+				wards = append(wards, domain.Ward{
+					ID:          dWard.ID,
+					Code:        dWard.Code,
+					Name:        dWard.Name,
+					HeadNurseID: dWard.HeadNurseID,
+					CreatedAt:   dWard.CreatedAt,
+				})
+			}
+
+			if bedCode != nil {
+				bed := domain.Bed{ID: bedID, WardID: dWard.ID, Code: *bedCode, CreatedAt: *bedCreatedAt}
+				if patientID != nil {
+					bed.Occupancy = domain.BedAssignment{
+						ID:          assignmentID,
+						BedID:       bedID,
+						BedCode:     *bedCode,
+						WardID:      dWard.ID,
+						WardCode:    dWard.Code,
+						PatientID:   *patientID,
+						PatientName: *patientName,
+						StartedBy:   startedBy,
+						StartedAt:   *startedAt,
+					}
+				}
+
+				current := &wards[len(wards)-1]
+				current.Beds = append(current.Beds, bed)
+			}
+
+			dWard.HeadNurseID, bedID, assignmentID, startedBy = ulid.ULID{}, ulid.ULID{}, ulid.ULID{}, ulid.ULID{}
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get bed board", zap.Error(err))
+		return wards, err
+	}
+
+	return wards, nil
+}
+
+func (r WardRepository) GetBedHistory(
+	ctx context.Context,
+	patientID string,
+	assignments domain.BedAssignments,
+) (domain.BedAssignments, error) {
+	callerInfo := "[WardRepository.GetBedHistory]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	getQuery := `SELECT a.id, a.bed_id, b.code, w.id, w.code, a.patient_id, p.name, a.started_by, a.started_at, 
+			a.ended_by, a.ended_at, a.end_reason
+		FROM bed_assignments a 
+		JOIN beds b ON b.id = a.bed_id
+		JOIN wards w ON w.id = b.ward_id
+		JOIN patients p ON p.id = a.patient_id
+		WHERE a.patient_id = @patient_id
+		ORDER BY a.started_at DESC`
+	args := pgx.NamedArgs{"patient_id": patientID}
+
+	rows, err := r.db.Query(ctx, getQuery, args)
+	if err != nil {
+		l.Error("failed to get bed history", zap.Error(err))
+		return assignments, err
+	}
+
+	var (
+		dAssignment domain.BedAssignment
+		endedAt     *time.Time
+		endReason   *string
+	)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{
+			&dAssignment.ID,
+			&dAssignment.BedID,
+			&dAssignment.BedCode,
+			&dAssignment.WardID,
+			&dAssignment.WardCode,
+			&dAssignment.PatientID,
+			&dAssignment.PatientName,
+			&dAssignment.StartedBy,
+			&dAssignment.StartedAt,
+			&dAssignment.EndedBy,
+			&endedAt,
+			&endReason,
+		},
+		func() error {
+			if endedAt != nil {
+				dAssignment.EndedAt = *endedAt
+			}
+			if endReason != nil {
+				dAssignment.EndReason = *endReason
+			}
+			assignments = append(assignments, dAssignment)
+			dAssignment = domain.BedAssignment{}
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get bed history", zap.Error(err))
+		return assignments, err
+	}
+
+	return assignments, nil
+}
+
+var _ WardRepositoryContract = (*WardRepository)(nil)
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type WardServiceContract interface {
+	CreateWard(ctx context.Context, ward *domain.Ward) error
+	CreateBed(ctx context.Context, bed *domain.Bed) error
+	SetHeadNurse(ctx context.Context, ward *domain.Ward) error
+	Admit(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error
+	Transfer(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error
+	Discharge(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error
+	GetBoard(ctx context.Context, wardID ulid.ULID, wards domain.Wards) (domain.Wards, error)
+	GetBedHistory(
+		ctx context.Context,
+		patientID string,
+		assignments domain.BedAssignments,
+	) (domain.BedAssignments, error)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/ward/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type WardService struct {
+	wardRepository repository.WardRepositoryContract
+	contextTimeout time.Duration
+}
+
+func NewWardService(timeout time.Duration, wardRepository repository.WardRepositoryContract) *WardService {
+	return &WardService{
+		wardRepository: wardRepository,
+		contextTimeout: timeout,
+	}
+}
+
+func (s WardService) CreateWard(ctx context.Context, ward *domain.Ward) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.CreateWard]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.wardRepository.CreateWard(ctx, ward)
+	if err != nil {
+		l.Error("failed to create ward", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WardService) CreateBed(ctx context.Context, bed *domain.Bed) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.CreateBed]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.wardRepository.CreateBed(ctx, bed)
+	if err != nil {
+		l.Error("failed to create bed", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WardService) SetHeadNurse(ctx context.Context, ward *domain.Ward) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.SetHeadNurse]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.wardRepository.SetHeadNurse(ctx, ward)
+	if err != nil {
+		l.Error("failed to set head nurse", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WardService) Admit(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.Admit]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.wardRepository.Admit(ctx, assignment, user)
+	if err != nil {
+		l.Error("failed to admit patient", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WardService) Transfer(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.Transfer]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.wardRepository.Transfer(ctx, assignment, user)
+	if err != nil {
+		l.Error("failed to transfer patient", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WardService) Discharge(ctx context.Context, assignment *domain.BedAssignment, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.Discharge]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	err := s.wardRepository.Discharge(ctx, assignment, user)
+	if err != nil {
+		l.Error("failed to discharge patient", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WardService) GetBoard(ctx context.Context, wardID ulid.ULID, wards domain.Wards) (domain.Wards, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.GetBoard]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	wards, err := s.wardRepository.GetBoard(ctx, wardID, wards)
+	if err != nil {
+		l.Error("failed to get bed board", zap.Error(err))
+		return nil, err
+	}
+
+	return wards, nil
+}
+
+func (s WardService) GetBedHistory(
+	ctx context.Context,
+	patientID string,
+	assignments domain.BedAssignments,
+) (domain.BedAssignments, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WardService.GetBedHistory]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	assignments, err := s.wardRepository.GetBedHistory(ctx, patientID, assignments)
+	if err != nil {
+		l.Error("failed to get bed history", zap.Error(err))
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+var _ WardServiceContract = (*WardService)(nil)
@@ -0,0 +1,134 @@
+// Package client sends outbox events to webhooks.
+//
+// An event is POSTed as JSON with these headers:
+//
+//	X-Webhook-Id         the id of the event, the same on every attempt
+//	X-Webhook-Event      the type of the event
+//	X-Webhook-Timestamp  when it was sent, in Unix seconds
+//	X-Webhook-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// The HMAC is keyed with the secret of the webhook. A receiver checks it and
+// turns down a timestamp too far in the past to stop replays, and uses the
+// id to skip an event it already has, since an event may be sent again when
+// a response is lost.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// maxErrorBody caps how much of an error response is kept on a delivery.
+const maxErrorBody = 1024
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+// StatusError is a response outside 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("webhook responded %d: %s", e.StatusCode, e.Body)
+}
+
+type Client struct {
+	httpClient *http.Client
+}
+
+func New(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// a webhook is registered with the URL events go to, a redirect
+			// would send them and their signature elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+type payload struct {
+	ID        ulid.ULID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt string          `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Send posts event to url signed with secret and returns the status code of
+// the response, 0 when there was none.
+func (c *Client) Send(ctx context.Context, url, secret string, event *domain.OutboxEvent) (int, error) {
+	body, err := json.Marshal(payload{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt.Format(dateFormat),
+		Data:      event.Data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, event.ID.String())
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		return res.StatusCode, StatusError{StatusCode: res.StatusCode, Body: string(b)}
+	}
+
+	// the connection is reused once the body is read
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBody))
+
+	return res.StatusCode, nil
+}
+
+// Sign returns the signature of body sent at timestamp, as found in
+// HeaderSignature.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,46 @@
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/service"
+)
+
+// Dispatcher sends the outbox to the webhooks every interval until its
+// context is done. Events and deliveries are locked while they are taken, so
+// running it in more than one process is safe.
+type Dispatcher struct {
+	webhookService service.WebhookServiceContract
+	interval       time.Duration
+}
+
+func NewDispatcher(interval time.Duration, webhookService service.WebhookServiceContract) *Dispatcher {
+	return &Dispatcher{
+		webhookService: webhookService,
+		interval:       interval,
+	}
+}
+
+func (d Dispatcher) Run(ctx context.Context) {
+	callerInfo := "[Dispatcher.Run]"
+	l := zap.L().With(zap.String("caller", callerInfo))
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	l.Info("webhook dispatcher started", zap.Duration("interval", d.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Info("webhook dispatcher stopped")
+			return
+		case now := <-ticker.C:
+			// the error is already logged by the service, the next tick retries
+			_ = d.webhookService.Dispatch(ctx, now)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/access"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/service"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const idFromParam = "id"
+
+type webhookHandler struct {
+	webhookService service.WebhookServiceContract
+}
+
+func NewWebhookHandler(
+	router fiber.Router,
+	jwtMiddleware fiber.Handler,
+	webhookService service.WebhookServiceContract,
+) {
+	handler := webhookHandler{
+		webhookService: webhookService,
+	}
+
+	webhookRouter := router.Group("/webhook", jwtMiddleware, access.ITStaff)
+	webhookRouter.Post("", handler.CreateWebhook)
+	webhookRouter.Get("", handler.GetWebhooks)
+	webhookRouter.Get("/delivery", handler.GetDeliveries)
+	webhookRouter.Post("/delivery/:"+idFromParam+"/retry", handler.RetryDelivery)
+	webhookRouter.Delete("/:"+idFromParam, handler.DeleteWebhook)
+}
+
+// CreateWebhook registers a URL the events are sent to. The secret the
+// events are signed with is only returned here.
+func (h webhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	callerInfo := "[webhookHandler.CreateWebhook]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	req := createWebhookReqAcquire()
+	defer createWebhookReqRelease(req)
+
+	if err := c.BodyParser(req); err != nil {
+		l.Error("error parsing request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	if err := req.validate(); err != nil {
+		l.Error("error validating request body", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	user := domain.UserAcquire()
+	defer domain.UserRelease(user)
+	*user = c.Locals(domain.UserFromToken).(domain.User)
+
+	webhook := domain.WebhookAcquire()
+	defer domain.WebhookRelease(webhook)
+
+	webhook.URL = req.URL
+	webhook.EventTypes = req.EventTypes
+
+	err := h.webhookService.CreateWebhook(userCtx, webhook, user)
+	if err != nil {
+		l.Error("failed to create webhook", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Webhook created successfully"
+
+	data := newWebhookRes(webhook)
+	data.Secret = webhook.Secret
+	res.Data = data
+
+	return c.Status(http.StatusCreated).JSON(res)
+}
+
+func (h webhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	callerInfo := "[webhookHandler.GetWebhooks]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	webhooks := domain.WebhooksAcquire()
+	defer domain.WebhooksRelease(webhooks)
+
+	webhooks, err := h.webhookService.GetWebhooks(userCtx, webhooks)
+	if err != nil {
+		l.Error("failed to get webhooks", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Webhooks retrieved successfully"
+
+	webhooksRes := getWebhooksResAcquire()
+	defer getWebhooksResRelease(webhooksRes)
+
+	for i := range webhooks {
+		webhooksRes = append(webhooksRes, newWebhookRes(&webhooks[i]))
+	}
+
+	res.Data = webhooksRes
+
+	return c.JSON(res)
+}
+
+// DeleteWebhook stops sending events to a webhook and drops its deliveries.
+func (h webhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	callerInfo := "[webhookHandler.DeleteWebhook]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	webhookID, err := ulid.Parse(c.Params(idFromParam))
+	if err != nil {
+		l.Error("error parsing webhookIDParam", zap.Error(err))
+		return new(domain.ErrWebhookNotFound)
+	}
+
+	webhook := domain.WebhookAcquire()
+	defer domain.WebhookRelease(webhook)
+
+	webhook.ID = webhookID
+
+	err = h.webhookService.DeleteWebhook(userCtx, webhook)
+	if err != nil {
+		l.Error("failed to delete webhook", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Webhook deleted successfully"
+
+	return c.JSON(res)
+}
+
+// GetDeliveries returns the deliveries of the events, most recently updated
+// first. The ones that ran out of attempts are found with status=failed.
+func (h webhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	callerInfo := "[webhookHandler.GetDeliveries]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	query := queryDeliveryAcquire()
+	defer queryDeliveryRelease(query)
+
+	if err := c.QueryParser(query); err != nil {
+		l.Error("error parsing query params", zap.Error(err))
+		return errBadRequest{err: err}
+	}
+
+	query.validate()
+
+	filter := domain.FilterWebhookDeliveryAcquire()
+	defer domain.FilterWebhookDeliveryRelease(filter)
+
+	query.toFilter(filter)
+
+	deliveries := domain.WebhookDeliveriesAcquire()
+	defer domain.WebhookDeliveriesRelease(deliveries)
+
+	deliveries, err := h.webhookService.GetDeliveries(userCtx, filter, deliveries)
+	if err != nil {
+		l.Error("failed to get deliveries", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Webhook deliveries retrieved successfully"
+
+	deliveriesRes := getDeliveriesResAcquire()
+	defer getDeliveriesResRelease(deliveriesRes)
+
+	for i := range deliveries {
+		deliveriesRes = append(deliveriesRes, newDeliveryRes(&deliveries[i]))
+	}
+
+	res.Data = deliveriesRes
+
+	return c.JSON(res)
+}
+
+// RetryDelivery queues a failed delivery again with its attempts reset.
+func (h webhookHandler) RetryDelivery(c *fiber.Ctx) error {
+	callerInfo := "[webhookHandler.RetryDelivery]"
+
+	userCtx := c.UserContext()
+	l := logger.FromCtx(userCtx).With(zap.String("caller", callerInfo))
+
+	deliveryID, err := ulid.Parse(c.Params(idFromParam))
+	if err != nil {
+		l.Error("error parsing deliveryIDParam", zap.Error(err))
+		return new(domain.ErrWebhookDeliveryNotFound)
+	}
+
+	delivery := domain.WebhookDeliveryAcquire()
+	defer domain.WebhookDeliveryRelease(delivery)
+
+	delivery.ID = deliveryID
+
+	err = h.webhookService.RetryDelivery(userCtx, delivery)
+	if err != nil {
+		l.Error("failed to retry delivery", zap.Error(err))
+		return err
+	}
+
+	res := baseResponseAcquire()
+	defer baseResponseRelease(res)
+
+	res.Message = "Webhook delivery queued again"
+	res.Data = newDeliveryRes(delivery)
+
+	return c.JSON(res)
+}
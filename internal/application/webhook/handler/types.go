@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/multierr"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+const dateFormat = "2006-01-02T15:04:05.999Z"
+
+type errBadRequest struct {
+	err error
+}
+
+func (e errBadRequest) Error() string {
+	return e.err.Error()
+}
+
+func (e errBadRequest) Status() int {
+	return http.StatusBadRequest
+}
+
+var baseResponsePool = sync.Pool{
+	New: func() any {
+		return new(baseResponse)
+	},
+}
+
+func baseResponseAcquire() *baseResponse {
+	return baseResponsePool.Get().(*baseResponse)
+}
+
+func baseResponseRelease(t *baseResponse) {
+	*t = baseResponse{}
+	baseResponsePool.Put(t)
+}
+
+type baseResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+var createWebhookReqPool = sync.Pool{
+	New: func() any {
+		return new(createWebhookReq)
+	},
+}
+
+func createWebhookReqAcquire() *createWebhookReq {
+	return createWebhookReqPool.Get().(*createWebhookReq)
+}
+
+func createWebhookReqRelease(t *createWebhookReq) {
+	*t = createWebhookReq{}
+	createWebhookReqPool.Put(t)
+}
+
+type createWebhookReq struct {
+	URL string `json:"url"`
+	// every event type when empty
+	EventTypes []string `json:"eventTypes"`
+}
+
+func (r createWebhookReq) validate() error {
+	var errs error
+
+	if r.URL == "" {
+		errs = multierr.Append(errs, errors.New("url is required"))
+	} else if len(r.URL) > 255 {
+		errs = multierr.Append(errs, errors.New("url must have at most 255 characters"))
+	} else {
+		u, err := url.Parse(r.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, errors.New("url must be a valid http or https URL"))
+		}
+	}
+
+	for _, eventType := range r.EventTypes {
+		if !slices.Contains(domain.OutboxTypes, eventType) {
+			errs = multierr.Append(errs, errors.New("eventTypes has an unknown event type "+eventType))
+		}
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	return nil
+}
+
+type webhookRes struct {
+	WebhookID  ulid.ULID `json:"webhookId"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedBy  ulid.ULID `json:"createdBy"`
+	CreatedAt  string    `json:"createdAt"`
+}
+
+func newWebhookRes(webhook *domain.Webhook) webhookRes {
+	res := webhookRes{
+		WebhookID:  webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: webhook.EventTypes,
+		CreatedBy:  webhook.CreatedBy,
+		CreatedAt:  webhook.CreatedAt.Format(dateFormat),
+	}
+	if res.EventTypes == nil {
+		res.EventTypes = []string{}
+	}
+	return res
+}
+
+const webhookInitCap = 5
+
+var getWebhooksResPool = sync.Pool{
+	New: func() any {
+		return make(getWebhooksRes, 0, webhookInitCap)
+	},
+}
+
+func getWebhooksResAcquire() getWebhooksRes {
+	return getWebhooksResPool.Get().(getWebhooksRes)
+}
+
+func getWebhooksResRelease(t getWebhooksRes) {
+	t = t[:0]
+	getWebhooksResPool.Put(t) // nolint:staticcheck
+}
+
+type getWebhooksRes []webhookRes
+
+var queryDeliveryPool = sync.Pool{
+	New: func() any {
+		return new(queryDelivery)
+	},
+}
+
+func queryDeliveryAcquire() *queryDelivery {
+	return queryDeliveryPool.Get().(*queryDelivery)
+}
+
+func queryDeliveryRelease(t *queryDelivery) {
+	*t = queryDelivery{}
+	queryDeliveryPool.Put(t)
+}
+
+type queryDelivery struct {
+	WebhookID string `query:"webhookId"`
+	webhookID ulid.ULID
+	EventType string `query:"eventType"`
+	Status    string `query:"status"`
+	Limit     int    `query:"limit"`
+	Offset    int    `query:"offset"`
+}
+
+func (q *queryDelivery) validate() {
+	if q.WebhookID != "" {
+		q.webhookID, _ = ulid.Parse(q.WebhookID)
+	}
+
+	if !slices.Contains(domain.OutboxTypes, q.EventType) {
+		q.EventType = ""
+	}
+
+	switch q.Status {
+	case domain.WebhookPending, domain.WebhookDelivered, domain.WebhookFailed:
+	default:
+		q.Status = ""
+	}
+
+	if q.Limit < 0 {
+		q.Limit = 0
+	}
+
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+func (q *queryDelivery) toFilter(filter *domain.FilterWebhookDelivery) {
+	filter.WebhookID = q.webhookID
+	filter.EventType = q.EventType
+	filter.Status = q.Status
+	filter.Limit = q.Limit
+	filter.Offset = q.Offset
+}
+
+type deliveryRes struct {
+	DeliveryID     ulid.ULID `json:"deliveryId"`
+	WebhookID      ulid.ULID `json:"webhookId"`
+	URL            string    `json:"url"`
+	EventID        ulid.ULID `json:"eventId"`
+	EventType      string    `json:"eventType"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  string    `json:"nextAttemptAt,omitempty"`
+	LastError      string    `json:"lastError,omitempty"`
+	ResponseStatus int       `json:"responseStatus,omitempty"`
+	CreatedAt      string    `json:"createdAt"`
+	UpdatedAt      string    `json:"updatedAt"`
+	DeliveredAt    string    `json:"deliveredAt,omitempty"`
+}
+
+func newDeliveryRes(delivery *domain.WebhookDelivery) deliveryRes {
+	res := deliveryRes{
+		DeliveryID:     delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		URL:            delivery.URL,
+		EventID:        delivery.Event.ID,
+		EventType:      delivery.Event.Type,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastError:      delivery.LastError,
+		ResponseStatus: delivery.ResponseStatus,
+		CreatedAt:      delivery.CreatedAt.Format(dateFormat),
+		UpdatedAt:      delivery.UpdatedAt.Format(dateFormat),
+	}
+	// only pending deliveries have another attempt coming
+	if delivery.Status == domain.WebhookPending {
+		res.NextAttemptAt = delivery.NextAttemptAt.Format(dateFormat)
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		res.DeliveredAt = delivery.DeliveredAt.Format(dateFormat)
+	}
+	return res
+}
+
+const deliveryInitCap = 5
+
+var getDeliveriesResPool = sync.Pool{
+	New: func() any {
+		return make(getDeliveriesRes, 0, deliveryInitCap)
+	},
+}
+
+func getDeliveriesResAcquire() getDeliveriesRes {
+	return getDeliveriesResPool.Get().(getDeliveriesRes)
+}
+
+func getDeliveriesResRelease(t getDeliveriesRes) {
+	t = t[:0]
+	getDeliveriesResPool.Put(t) // nolint:staticcheck
+}
+
+type getDeliveriesRes []deliveryRes
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/j03hanafi/halo-suster/common/configs"
+	"github.com/j03hanafi/halo-suster/internal/application/retry"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/client"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/dispatcher"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/handler"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/repository"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/service"
+)
+
+// NewModule registers the webhook routes and sends the outbox to the webhooks
+// until ctx is done. With prefork only the parent process sends.
+func NewModule(ctx context.Context, router fiber.Router, db *pgxpool.Pool, jwtMiddleware fiber.Handler) {
+	cfg := configs.Get().Webhook
+	ctxTimeout := time.Duration(configs.Get().App.ContextTimeout) * time.Second
+	requestTimeout := time.Duration(cfg.RequestTimeout) * time.Second
+	dispatchInterval := time.Duration(cfg.DispatchInterval) * time.Second
+
+	options := retry.Options{
+		BatchSize:      cfg.BatchSize,
+		MaxAttempts:    cfg.MaxAttempts,
+		RetryBaseDelay: time.Duration(cfg.RetryBaseDelay) * time.Second,
+		RetryMaxDelay:  time.Duration(cfg.RetryMaxDelay) * time.Second,
+		// long enough for every request of a batch to time out
+		Lease: time.Duration(cfg.BatchSize+1) * requestTimeout,
+	}
+
+	webhookClient := client.New(requestTimeout)
+	webhookRepository := repository.NewWebhookRepository(db)
+	webhookService := service.NewWebhookService(ctxTimeout, options, webhookRepository, webhookClient)
+	handler.NewWebhookHandler(router, jwtMiddleware, webhookService)
+
+	if !fiber.IsChild() && dispatchInterval > 0 {
+		go dispatcher.NewDispatcher(dispatchInterval, webhookService).Run(ctx)
+	}
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type WebhookRepositoryContract interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) error
+	GetWebhooks(ctx context.Context, webhooks domain.Webhooks) (domain.Webhooks, error)
+	DeleteWebhook(ctx context.Context, webhook *domain.Webhook) error
+	Enqueue(ctx context.Context, now time.Time, limit int) (int, error)
+	Claim(
+		ctx context.Context,
+		now, leaseUntil time.Time,
+		limit int,
+		deliveries domain.WebhookDeliveries,
+	) (domain.WebhookDeliveries, error)
+	SaveResult(ctx context.Context, delivery *domain.WebhookDelivery) error
+	Retry(ctx context.Context, delivery *domain.WebhookDelivery, now time.Time) error
+	GetDeliveries(
+		ctx context.Context,
+		filter *domain.FilterWebhookDelivery,
+		deliveries domain.WebhookDeliveries,
+	) (domain.WebhookDeliveries, error)
+}
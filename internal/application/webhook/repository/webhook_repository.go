@@ -0,0 +1,462 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/id"
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// deliveryColumns are read from webhook_deliveries d joined with the event e
+// and the webhook w it is for. The secret of the webhook is only read when a
+// delivery is claimed to be sent.
+const deliveryColumns = `d.id, d.webhook_id, e.id, e.type, e.patient_id, e.data, e.created_at, w.url, d.status,
+	d.attempts, d.next_attempt_at, d.last_error, d.response_status, d.created_at, d.updated_at, d.delivered_at`
+
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r WebhookRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	callerInfo := "[WebhookRepository.CreateWebhook]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	webhook.ID = id.New()
+	webhook.CreatedAt = time.Now()
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []string{}
+	}
+
+	insertQuery := `INSERT INTO webhooks (id, url, secret, event_types, created_by, created_at)
+		VALUES (@id, @url, @secret, @event_types, @created_by, @created_at)`
+	args := pgx.NamedArgs{
+		"id":          webhook.ID,
+		"url":         webhook.URL,
+		"secret":      webhook.Secret,
+		"event_types": webhook.EventTypes,
+		"created_by":  webhook.CreatedBy,
+		"created_at":  webhook.CreatedAt,
+	}
+
+	if _, err := r.db.Exec(ctx, insertQuery, args); err != nil {
+		l.Error("failed to create webhook", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhooks returns every webhook, oldest first, without their secrets.
+func (r WebhookRepository) GetWebhooks(ctx context.Context, webhooks domain.Webhooks) (domain.Webhooks, error) {
+	callerInfo := "[WebhookRepository.GetWebhooks]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	getQuery := `SELECT id, url, event_types, created_by, created_at FROM webhooks ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, getQuery)
+	if err != nil {
+		l.Error("failed to get webhooks", zap.Error(err))
+		return webhooks, err
+	}
+
+	dWebhook := domain.WebhookAcquire()
+	defer domain.WebhookRelease(dWebhook)
+
+	_, err = pgx.ForEachRow(
+		rows,
+		[]any{&dWebhook.ID, &dWebhook.URL, &dWebhook.EventTypes, &dWebhook.CreatedBy, &dWebhook.CreatedAt},
+		func() error {
+			webhooks = append(webhooks, *dWebhook)
+			dWebhook.EventTypes = nil
+			return nil
+		},
+	)
+	if err != nil {
+		l.Error("failed to get webhooks", zap.Error(err))
+		return webhooks, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook along with its deliveries.
+func (r WebhookRepository) DeleteWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	callerInfo := "[WebhookRepository.DeleteWebhook]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	deleteQuery := `DELETE FROM webhooks WHERE id = @id`
+
+	result, err := r.db.Exec(ctx, deleteQuery, pgx.NamedArgs{"id": webhook.ID})
+	if err != nil {
+		l.Error("failed to delete webhook", zap.Error(err))
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return new(domain.ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+// Enqueue queues a delivery of up to limit outbox events for every webhook
+// they are for, oldest events first, and returns how many events it took.
+// Events are locked while they are queued, so running it in more than one
+// process is safe.
+func (r WebhookRepository) Enqueue(ctx context.Context, now time.Time, limit int) (int, error) {
+	callerInfo := "[WebhookRepository.Enqueue]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		l.Error("failed to begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	eventQuery := `SELECT id, type FROM outbox_events WHERE dispatched_at IS NULL
+		ORDER BY id ASC LIMIT @limit FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(ctx, eventQuery, pgx.NamedArgs{"limit": limit})
+	if err != nil {
+		l.Error("failed to get outbox events", zap.Error(err))
+		return 0, err
+	}
+
+	events := make([]domain.OutboxEvent, 0, limit)
+	var eventID ulid.ULID
+	var eventType string
+
+	_, err = pgx.ForEachRow(rows, []any{&eventID, &eventType}, func() error {
+		events = append(events, domain.OutboxEvent{ID: eventID, Type: eventType})
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to get outbox events", zap.Error(err))
+		return 0, err
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	rows, err = tx.Query(ctx, `SELECT id, event_types FROM webhooks`)
+	if err != nil {
+		l.Error("failed to get webhooks", zap.Error(err))
+		return 0, err
+	}
+
+	webhooks := make(domain.Webhooks, 0)
+	var webhookID ulid.ULID
+	var eventTypes []string
+
+	_, err = pgx.ForEachRow(rows, []any{&webhookID, &eventTypes}, func() error {
+		webhooks = append(webhooks, domain.Webhook{ID: webhookID, EventTypes: eventTypes})
+		eventTypes = nil
+		return nil
+	})
+	if err != nil {
+		l.Error("failed to get webhooks", zap.Error(err))
+		return 0, err
+	}
+
+	insertQuery := `INSERT INTO webhook_deliveries (id, webhook_id, event_id, status, next_attempt_at, created_at,
+		updated_at) VALUES (@id, @webhook_id, @event_id, @status, @now, @now, @now) ON CONFLICT DO NOTHING`
+
+	eventIDs := make([][]byte, 0, len(events))
+	for i := range events {
+		eventIDs = append(eventIDs, events[i].ID.Bytes())
+
+		for j := range webhooks {
+			if len(webhooks[j].EventTypes) > 0 && !slices.Contains(webhooks[j].EventTypes, events[i].Type) {
+				continue
+			}
+
+			_, err = tx.Exec(ctx, insertQuery, pgx.NamedArgs{
+				"id":         id.New(),
+				"webhook_id": webhooks[j].ID,
+				"event_id":   events[i].ID,
+				"status":     domain.WebhookPending,
+				"now":        now,
+			})
+			if err != nil {
+				l.Error("failed to queue delivery", zap.Error(err))
+				return 0, err
+			}
+		}
+	}
+
+	updateQuery := `UPDATE outbox_events SET dispatched_at = @now WHERE id = ANY(@ids)`
+	if _, err = tx.Exec(ctx, updateQuery, pgx.NamedArgs{"now": now, "ids": eventIDs}); err != nil {
+		l.Error("failed to mark outbox events dispatched", zap.Error(err))
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		l.Error("failed to commit transaction", zap.Error(err))
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// Claim leases up to limit pending deliveries that are due until leaseUntil,
+// other workers skip them meanwhile. A delivery whose worker dies is due
+// again once its lease runs out.
+func (r WebhookRepository) Claim(
+	ctx context.Context,
+	now, leaseUntil time.Time,
+	limit int,
+	deliveries domain.WebhookDeliveries,
+) (domain.WebhookDeliveries, error) {
+	callerInfo := "[WebhookRepository.Claim]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	claimQuery := `UPDATE webhook_deliveries d SET next_attempt_at = @lease_until, updated_at = @now
+		FROM outbox_events e, webhooks w
+		WHERE d.id IN (
+			SELECT id FROM webhook_deliveries WHERE status = @status AND next_attempt_at <= @now
+			ORDER BY next_attempt_at ASC LIMIT @limit FOR UPDATE SKIP LOCKED
+		) AND e.id = d.event_id AND w.id = d.webhook_id
+		RETURNING ` + deliveryColumns + `, w.secret`
+	args := pgx.NamedArgs{
+		"status":      domain.WebhookPending,
+		"now":         now,
+		"lease_until": leaseUntil,
+		"limit":       limit,
+	}
+
+	rows, err := r.db.Query(ctx, claimQuery, args)
+	if err != nil {
+		l.Error("failed to claim deliveries", zap.Error(err))
+		return deliveries, err
+	}
+
+	deliveries, err = r.collectDeliveries(rows, deliveries, true)
+	if err != nil {
+		l.Error("failed to claim deliveries", zap.Error(err))
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+// SaveResult stores the outcome of sending delivery.
+func (r WebhookRepository) SaveResult(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	callerInfo := "[WebhookRepository.SaveResult]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	var deliveredAt any
+	if !delivery.DeliveredAt.IsZero() {
+		deliveredAt = delivery.DeliveredAt
+	}
+
+	updateQuery := `UPDATE webhook_deliveries SET status = @status, attempts = @attempts,
+		next_attempt_at = @next_attempt_at, last_error = @last_error, response_status = @response_status,
+		updated_at = @updated_at, delivered_at = @delivered_at WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id":              delivery.ID,
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"last_error":      delivery.LastError,
+		"response_status": delivery.ResponseStatus,
+		"updated_at":      delivery.UpdatedAt,
+		"delivered_at":    deliveredAt,
+	}
+
+	if _, err := r.db.Exec(ctx, updateQuery, args); err != nil {
+		l.Error("failed to save delivery result", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Retry queues the failed delivery in delivery.ID again with its attempts
+// reset, and fills delivery in.
+func (r WebhookRepository) Retry(ctx context.Context, delivery *domain.WebhookDelivery, now time.Time) error {
+	callerInfo := "[WebhookRepository.Retry]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	retryQuery := `UPDATE webhook_deliveries d SET status = @pending, attempts = 0, next_attempt_at = @now,
+		last_error = '', response_status = 0, updated_at = @now
+		FROM outbox_events e, webhooks w
+		WHERE d.id = @id AND d.status = @failed AND e.id = d.event_id AND w.id = d.webhook_id
+		RETURNING ` + deliveryColumns
+	args := pgx.NamedArgs{
+		"id":      delivery.ID,
+		"pending": domain.WebhookPending,
+		"failed":  domain.WebhookFailed,
+		"now":     now,
+	}
+
+	rows, err := r.db.Query(ctx, retryQuery, args)
+	if err != nil {
+		l.Error("failed to retry delivery", zap.Error(err))
+		return err
+	}
+
+	deliveries, err := r.collectDeliveries(rows, make(domain.WebhookDeliveries, 0, 1), false)
+	if err != nil {
+		l.Error("failed to retry delivery", zap.Error(err))
+		return err
+	}
+
+	if len(deliveries) == 0 {
+		return new(domain.ErrWebhookDeliveryNotFailed)
+	}
+	*delivery = deliveries[0]
+
+	return nil
+}
+
+func (r WebhookRepository) GetDeliveries(
+	ctx context.Context,
+	filter *domain.FilterWebhookDelivery,
+	deliveries domain.WebhookDeliveries,
+) (domain.WebhookDeliveries, error) {
+	callerInfo := "[WebhookRepository.GetDeliveries]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	conditions, params := r.filterDelivery(filter)
+	getQuery := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id` + conditions
+
+	rows, err := r.db.Query(ctx, getQuery, params)
+	if err != nil {
+		l.Error("failed to get deliveries", zap.Error(err))
+		return deliveries, err
+	}
+
+	deliveries, err = r.collectDeliveries(rows, deliveries, false)
+	if err != nil {
+		l.Error("failed to get deliveries", zap.Error(err))
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+// collectDeliveries scans rows of deliveryColumns, followed by the secret of
+// the webhook when withSecret is set.
+func (r WebhookRepository) collectDeliveries(
+	rows pgx.Rows,
+	deliveries domain.WebhookDeliveries,
+	withSecret bool,
+) (domain.WebhookDeliveries, error) {
+	dDelivery := domain.WebhookDeliveryAcquire()
+	defer domain.WebhookDeliveryRelease(dDelivery)
+	var patientID *string
+	var deliveredAt *time.Time
+
+	scans := []any{
+		&dDelivery.ID,
+		&dDelivery.WebhookID,
+		&dDelivery.Event.ID,
+		&dDelivery.Event.Type,
+		&patientID,
+		&dDelivery.Event.Data,
+		&dDelivery.Event.CreatedAt,
+		&dDelivery.URL,
+		&dDelivery.Status,
+		&dDelivery.Attempts,
+		&dDelivery.NextAttemptAt,
+		&dDelivery.LastError,
+		&dDelivery.ResponseStatus,
+		&dDelivery.CreatedAt,
+		&dDelivery.UpdatedAt,
+		&deliveredAt,
+	}
+	if withSecret {
+		scans = append(scans, &dDelivery.Secret)
+	}
+
+	_, err := pgx.ForEachRow(rows, scans, func() error {
+		dDelivery.Event.PatientID = ""
+		if patientID != nil {
+			dDelivery.Event.PatientID = *patientID
+		}
+		dDelivery.DeliveredAt = time.Time{}
+		if deliveredAt != nil {
+			dDelivery.DeliveredAt = *deliveredAt
+		}
+		deliveries = append(deliveries, *dDelivery)
+		dDelivery.Event.Data = nil
+		return nil
+	})
+
+	return deliveries, err
+}
+
+func (r WebhookRepository) filterDelivery(filter *domain.FilterWebhookDelivery) (string, pgx.NamedArgs) {
+	const totalConditions = 4
+	conditions, params := make([]string, 0, totalConditions), pgx.NamedArgs{}
+
+	if !id.IsZero(filter.ID) {
+		conditions = append(conditions, "d.id = @id")
+		params["id"] = filter.ID
+	}
+
+	if !id.IsZero(filter.WebhookID) {
+		conditions = append(conditions, "d.webhook_id = @webhook_id")
+		params["webhook_id"] = filter.WebhookID
+	}
+
+	if filter.EventType != "" {
+		conditions = append(conditions, "e.type = @event_type")
+		params["event_type"] = filter.EventType
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "d.status = @status")
+		params["status"] = filter.Status
+	}
+
+	order := " ORDER BY d.updated_at DESC"
+
+	const totalLimitOffset = 2
+	limitOffset := make([]string, 0, totalLimitOffset)
+
+	limitOffset = append(limitOffset, "LIMIT @limit")
+	params["limit"] = 5
+	if filter.Limit != 0 {
+		params["limit"] = filter.Limit
+	}
+
+	if filter.Offset != 0 {
+		limitOffset = append(limitOffset, "OFFSET @offset")
+		params["offset"] = filter.Offset
+	}
+
+	queryConditions := ""
+	if len(conditions) > 0 {
+		queryConditions = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	queryConditions += order
+
+	if len(limitOffset) > 0 {
+		queryConditions += " " + strings.Join(limitOffset, " ")
+	}
+
+	return queryConditions, params
+}
+
+var _ WebhookRepositoryContract = (*WebhookRepository)(nil)
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+type WebhookServiceContract interface {
+	Dispatch(ctx context.Context, now time.Time) error
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook, user *domain.User) error
+	GetWebhooks(ctx context.Context, webhooks domain.Webhooks) (domain.Webhooks, error)
+	DeleteWebhook(ctx context.Context, webhook *domain.Webhook) error
+	GetDeliveries(
+		ctx context.Context,
+		filter *domain.FilterWebhookDelivery,
+		deliveries domain.WebhookDeliveries,
+	) (domain.WebhookDeliveries, error)
+	RetryDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/j03hanafi/halo-suster/common/logger"
+	"github.com/j03hanafi/halo-suster/internal/application/retry"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/client"
+	"github.com/j03hanafi/halo-suster/internal/application/webhook/repository"
+	"github.com/j03hanafi/halo-suster/internal/domain"
+)
+
+// secretLength is the number of random bytes of a webhook secret.
+const secretLength = 32
+
+type WebhookService struct {
+	webhookRepository repository.WebhookRepositoryContract
+	client            *client.Client
+	contextTimeout    time.Duration
+	options           retry.Options
+}
+
+func NewWebhookService(
+	timeout time.Duration,
+	options retry.Options,
+	webhookRepository repository.WebhookRepositoryContract,
+	client *client.Client,
+) *WebhookService {
+	return &WebhookService{
+		webhookRepository: webhookRepository,
+		client:            client,
+		contextTimeout:    timeout,
+		options:           options,
+	}
+}
+
+// Dispatch queues the outbox events written since the last run for the
+// webhooks they are for, and sends a batch of the deliveries that are due. A
+// delivery that fails is tried again later with an exponential backoff until
+// it runs out of attempts.
+func (s WebhookService) Dispatch(ctx context.Context, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.options.Lease)
+	defer cancel()
+
+	callerInfo := "[WebhookService.Dispatch]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	queued, err := s.webhookRepository.Enqueue(ctx, now, s.options.BatchSize)
+	if err != nil {
+		l.Error("failed to queue outbox events", zap.Error(err))
+		return err
+	}
+
+	deliveries := domain.WebhookDeliveriesAcquire()
+	defer domain.WebhookDeliveriesRelease(deliveries)
+
+	deliveries, err = s.webhookRepository.Claim(ctx, now, now.Add(s.options.Lease), s.options.BatchSize, deliveries)
+	if err != nil {
+		l.Error("failed to claim deliveries", zap.Error(err))
+		return err
+	}
+
+	counts, err := retry.Send(ctx, deliveries, s.send, s.webhookRepository.SaveResult,
+		func(delivery *domain.WebhookDelivery) string { return delivery.Status })
+	if err != nil {
+		l.Error("failed to save delivery result", zap.Error(err))
+		return err
+	}
+
+	if queued > 0 || len(deliveries) > 0 {
+		l.Info("webhooks dispatched",
+			zap.Int("events queued", queued),
+			zap.Int(domain.WebhookDelivered, counts[domain.WebhookDelivered]),
+			zap.Int(domain.WebhookPending, counts[domain.WebhookPending]),
+			zap.Int(domain.WebhookFailed, counts[domain.WebhookFailed]),
+		)
+	}
+
+	return nil
+}
+
+// send sends delivery and records the outcome on it.
+func (s WebhookService) send(ctx context.Context, delivery *domain.WebhookDelivery) {
+	callerInfo := "[WebhookService.send]"
+	l := logger.FromCtx(ctx).With(
+		zap.String("caller", callerInfo),
+		zap.String("id", delivery.ID.String()),
+		zap.String("event", delivery.Event.Type),
+	)
+
+	statusCode, err := s.client.Send(ctx, delivery.URL, delivery.Secret, &delivery.Event)
+
+	now := time.Now()
+	delivery.UpdatedAt = now
+	delivery.ResponseStatus = statusCode
+	delivery.Attempts++
+
+	if err == nil {
+		delivery.Status = domain.WebhookDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = now
+		return
+	}
+
+	delivery.LastError = retry.LastError(err)
+
+	// a webhook that is down may be back by the next attempt
+	next, ok := s.options.Next(delivery.Attempts, true, now)
+	if !ok {
+		// kept as failed until it is retried
+		delivery.Status = domain.WebhookFailed
+		l.Error("failed to deliver event", zap.Int("attempts", delivery.Attempts), zap.Error(err))
+		return
+	}
+
+	delivery.NextAttemptAt = next
+	l.Warn("failed to deliver event, retrying",
+		zap.Int("attempts", delivery.Attempts),
+		zap.Time("next attempt", delivery.NextAttemptAt),
+		zap.Error(err),
+	)
+}
+
+// CreateWebhook registers webhook for user with a new secret, which is only
+// returned here.
+func (s WebhookService) CreateWebhook(ctx context.Context, webhook *domain.Webhook, user *domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WebhookService.CreateWebhook]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	secret := make([]byte, secretLength)
+	if _, err := crand.Read(secret); err != nil {
+		l.Error("failed to generate secret", zap.Error(err))
+		return err
+	}
+
+	webhook.Secret = hex.EncodeToString(secret)
+	webhook.CreatedBy = user.ID
+
+	if err := s.webhookRepository.CreateWebhook(ctx, webhook); err != nil {
+		l.Error("failed to create webhook", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WebhookService) GetWebhooks(ctx context.Context, webhooks domain.Webhooks) (domain.Webhooks, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WebhookService.GetWebhooks]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	webhooks, err := s.webhookRepository.GetWebhooks(ctx, webhooks)
+	if err != nil {
+		l.Error("failed to get webhooks", zap.Error(err))
+		return webhooks, err
+	}
+
+	return webhooks, nil
+}
+
+func (s WebhookService) DeleteWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WebhookService.DeleteWebhook]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	if err := s.webhookRepository.DeleteWebhook(ctx, webhook); err != nil {
+		l.Error("failed to delete webhook", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s WebhookService) GetDeliveries(
+	ctx context.Context,
+	filter *domain.FilterWebhookDelivery,
+	deliveries domain.WebhookDeliveries,
+) (domain.WebhookDeliveries, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WebhookService.GetDeliveries]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	deliveries, err := s.webhookRepository.GetDeliveries(ctx, filter, deliveries)
+	if err != nil {
+		l.Error("failed to get deliveries", zap.Error(err))
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+// RetryDelivery queues a failed delivery again with its attempts reset.
+func (s WebhookService) RetryDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	callerInfo := "[WebhookService.RetryDelivery]"
+	l := logger.FromCtx(ctx).With(zap.String("caller", callerInfo))
+
+	filter := domain.FilterWebhookDeliveryAcquire()
+	defer domain.FilterWebhookDeliveryRelease(filter)
+
+	filter.ID = delivery.ID
+	filter.Limit = 1
+
+	deliveries := domain.WebhookDeliveriesAcquire()
+	defer domain.WebhookDeliveriesRelease(deliveries)
+
+	deliveries, err := s.webhookRepository.GetDeliveries(ctx, filter, deliveries)
+	if err != nil {
+		l.Error("failed to get delivery", zap.Error(err))
+		return err
+	}
+
+	if len(deliveries) == 0 {
+		return new(domain.ErrWebhookDeliveryNotFound)
+	}
+
+	if err = s.webhookRepository.Retry(ctx, delivery, time.Now()); err != nil {
+		l.Error("failed to retry delivery", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+var _ WebhookServiceContract = (*WebhookService)(nil)
@@ -0,0 +1,299 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	AppointmentScheduled   = "scheduled"
+	AppointmentCompleted   = "completed"
+	AppointmentCancelled   = "cancelled"
+	AppointmentNoShow      = "no_show"
+	AppointmentRescheduled = "rescheduled"
+
+	PatientEventAppointmentBooked      = "appointment.booked"
+	PatientEventAppointmentRescheduled = "appointment.rescheduled"
+	PatientEventAppointmentClosed      = "appointment.closed"
+)
+
+var AppointmentTypes = []string{"consultation", "follow_up", "procedure", "other"}
+
+var StaffSchedulePool = sync.Pool{
+	New: func() any {
+		return new(StaffSchedule)
+	},
+}
+
+func StaffScheduleAcquire() *StaffSchedule {
+	return StaffSchedulePool.Get().(*StaffSchedule)
+}
+
+func StaffScheduleRelease(t *StaffSchedule) {
+	*t = StaffSchedule{}
+	StaffSchedulePool.Put(t)
+}
+
+// StaffSchedule is a weekly window in which the staff member takes
+// appointments, split into slots of SlotMinutes. Minutes count from midnight.
+type StaffSchedule struct {
+	ID          ulid.ULID
+	StaffID     ulid.ULID
+	Weekday     time.Weekday
+	StartMinute int
+	EndMinute   int
+	SlotMinutes int
+}
+
+// window returns when the schedule opens and closes on day.
+func (s *StaffSchedule) window(day time.Time) (time.Time, time.Time) {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, s.StartMinute, 0, 0, day.Location()),
+		time.Date(y, m, d, 0, s.EndMinute, 0, 0, day.Location())
+}
+
+const staffSchedulesInitCap = 5
+
+var StaffSchedulesPool = sync.Pool{
+	New: func() any {
+		return make(StaffSchedules, 0, staffSchedulesInitCap)
+	},
+}
+
+func StaffSchedulesAcquire() StaffSchedules {
+	return StaffSchedulesPool.Get().(StaffSchedules)
+}
+
+func StaffSchedulesRelease(t StaffSchedules) {
+	t = t[:0]
+	StaffSchedulesPool.Put(t) // nolint:staticcheck
+}
+
+type StaffSchedules []StaffSchedule
+
+// Covers reports whether [start, end) lies within one window of the
+// schedules.
+func (s StaffSchedules) Covers(start, end time.Time) bool {
+	for i := range s {
+		if s[i].Weekday != start.Weekday() {
+			continue
+		}
+
+		opens, closes := s[i].window(start)
+		if !start.Before(opens) && !end.After(closes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Slots splits the windows falling on day into slots and leaves out those
+// overlapping booked or starting before notBefore.
+func (s StaffSchedules) Slots(day time.Time, booked Appointments, notBefore time.Time) Slots {
+	slots := make(Slots, 0, len(s))
+
+	for i := range s {
+		if s[i].Weekday != day.Weekday() || s[i].SlotMinutes <= 0 {
+			continue
+		}
+
+		length := time.Duration(s[i].SlotMinutes) * time.Minute
+		opens, closes := s[i].window(day)
+
+		for start := opens; !start.Add(length).After(closes); start = start.Add(length) {
+			end := start.Add(length)
+			if start.Before(notBefore) || booked.overlap(start, end) {
+				continue
+			}
+			slots = append(slots, Slot{StartAt: start, EndAt: end})
+		}
+	}
+
+	return slots
+}
+
+type Slot struct {
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+type Slots []Slot
+
+var AppointmentPool = sync.Pool{
+	New: func() any {
+		return new(Appointment)
+	},
+}
+
+func AppointmentAcquire() *Appointment {
+	return AppointmentPool.Get().(*Appointment)
+}
+
+func AppointmentRelease(t *Appointment) {
+	*t = Appointment{}
+	AppointmentPool.Put(t)
+}
+
+// Appointment books a patient with a staff member, RescheduledFrom links a
+// rescheduled appointment to the one it replaces.
+type Appointment struct {
+	ID              ulid.ULID
+	PatientID       string
+	PatientName     string
+	StaffID         ulid.ULID
+	StaffName       string
+	RecordID        ulid.ULID
+	StartAt         time.Time
+	EndAt           time.Time
+	Type            string
+	Status          string
+	Notes           string
+	RescheduledFrom ulid.ULID
+	CreatedBy       ulid.ULID
+	CreatedByName   string
+	CreatedAt       time.Time
+	ClosedBy        ulid.ULID
+	ClosedByName    string
+	ClosedAt        time.Time
+	CloseReason     string
+}
+
+const appointmentsInitCap = 5
+
+var AppointmentsPool = sync.Pool{
+	New: func() any {
+		return make(Appointments, 0, appointmentsInitCap)
+	},
+}
+
+func AppointmentsAcquire() Appointments {
+	return AppointmentsPool.Get().(Appointments)
+}
+
+func AppointmentsRelease(t Appointments) {
+	t = t[:0]
+	AppointmentsPool.Put(t) // nolint:staticcheck
+}
+
+type Appointments []Appointment
+
+func (a Appointments) overlap(start, end time.Time) bool {
+	for i := range a {
+		if a[i].Status == AppointmentScheduled && a[i].StartAt.Before(end) && a[i].EndAt.After(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var FilterAppointmentPool = sync.Pool{
+	New: func() any {
+		return new(FilterAppointment)
+	},
+}
+
+func FilterAppointmentAcquire() *FilterAppointment {
+	return FilterAppointmentPool.Get().(*FilterAppointment)
+}
+
+func FilterAppointmentRelease(t *FilterAppointment) {
+	*t = FilterAppointment{}
+	FilterAppointmentPool.Put(t)
+}
+
+// FilterAppointment selects appointments overlapping [From, To).
+type FilterAppointment struct {
+	ID        ulid.ULID
+	PatientID string
+	StaffID   ulid.ULID
+	Status    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
+
+type ErrAppointmentNotFound struct{}
+
+func (e ErrAppointmentNotFound) Error() string {
+	return "Appointment not found"
+}
+
+func (e ErrAppointmentNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrStaffDoubleBooked struct{}
+
+func (e ErrStaffDoubleBooked) Error() string {
+	return "Staff already has an appointment at that time"
+}
+
+func (e ErrStaffDoubleBooked) Status() int {
+	return http.StatusConflict
+}
+
+type ErrPatientDoubleBooked struct{}
+
+func (e ErrPatientDoubleBooked) Error() string {
+	return "Patient already has an appointment at that time"
+}
+
+func (e ErrPatientDoubleBooked) Status() int {
+	return http.StatusConflict
+}
+
+type ErrOutsideStaffSchedule struct{}
+
+func (e ErrOutsideStaffSchedule) Error() string {
+	return "Appointment is outside the schedule of the staff"
+}
+
+func (e ErrOutsideStaffSchedule) Status() int {
+	return http.StatusConflict
+}
+
+type ErrAppointmentClosed struct{}
+
+func (e ErrAppointmentClosed) Error() string {
+	return "Appointment is no longer scheduled"
+}
+
+func (e ErrAppointmentClosed) Status() int {
+	return http.StatusConflict
+}
+
+type ErrAppointmentNotStarted struct{}
+
+func (e ErrAppointmentNotStarted) Error() string {
+	return "Appointment has not started yet"
+}
+
+func (e ErrAppointmentNotStarted) Status() int {
+	return http.StatusConflict
+}
+
+type ErrAppointmentInPast struct{}
+
+func (e ErrAppointmentInPast) Error() string {
+	return "Appointment must start in the future"
+}
+
+func (e ErrAppointmentInPast) Status() int {
+	return http.StatusBadRequest
+}
+
+type ErrScheduleForbidden struct{}
+
+func (e ErrScheduleForbidden) Error() string {
+	return "Only IT staff and the staff themselves can change a schedule"
+}
+
+func (e ErrScheduleForbidden) Status() int {
+	return http.StatusForbidden
+}
@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	DocumentReferral  = "referral"
+	DocumentDischarge = "discharge"
+
+	DocumentFormatHTML = "html"
+	DocumentFormatPDF  = "pdf"
+
+	PatientEventDocumentGenerated = "document.generated"
+)
+
+var DocumentTemplatePool = sync.Pool{
+	New: func() any {
+		return new(DocumentTemplate)
+	},
+}
+
+func DocumentTemplateAcquire() *DocumentTemplate {
+	return DocumentTemplatePool.Get().(*DocumentTemplate)
+}
+
+func DocumentTemplateRelease(t *DocumentTemplate) {
+	*t = DocumentTemplate{}
+	DocumentTemplatePool.Put(t)
+}
+
+// DocumentTemplate is a text/template source rendered with DocumentData into
+// HTML, Version goes up on every edit.
+type DocumentTemplate struct {
+	ID            ulid.ULID
+	Kind          string
+	Name          string
+	Body          string
+	Version       int
+	UpdatedBy     ulid.ULID
+	UpdatedByName string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+const documentTemplatesInitCap = 5
+
+var DocumentTemplatesPool = sync.Pool{
+	New: func() any {
+		return make(DocumentTemplates, 0, documentTemplatesInitCap)
+	},
+}
+
+func DocumentTemplatesAcquire() DocumentTemplates {
+	return DocumentTemplatesPool.Get().(DocumentTemplates)
+}
+
+func DocumentTemplatesRelease(t DocumentTemplates) {
+	t = t[:0]
+	DocumentTemplatesPool.Put(t) // nolint:staticcheck
+}
+
+type DocumentTemplates []DocumentTemplate
+
+var FilterDocumentTemplatePool = sync.Pool{
+	New: func() any {
+		return new(FilterDocumentTemplate)
+	},
+}
+
+func FilterDocumentTemplateAcquire() *FilterDocumentTemplate {
+	return FilterDocumentTemplatePool.Get().(*FilterDocumentTemplate)
+}
+
+func FilterDocumentTemplateRelease(t *FilterDocumentTemplate) {
+	*t = FilterDocumentTemplate{}
+	FilterDocumentTemplatePool.Put(t)
+}
+
+type FilterDocumentTemplate struct {
+	ID     ulid.ULID
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+var DocumentPool = sync.Pool{
+	New: func() any {
+		return new(Document)
+	},
+}
+
+func DocumentAcquire() *Document {
+	return DocumentPool.Get().(*Document)
+}
+
+func DocumentRelease(t *Document) {
+	*t = Document{}
+	DocumentPool.Put(t)
+}
+
+// Document is a letter generated from a template for the record in RecordID,
+// the rendered files are kept in object storage and attached to the record.
+type Document struct {
+	ID              ulid.ULID
+	TemplateID      ulid.ULID
+	TemplateName    string
+	TemplateVersion int
+	Kind            string
+	PatientID       string
+	PatientName     string
+	RecordID        ulid.ULID
+	Recipient       string
+	Notes           string
+	HTMLURL         string
+	PDFURL          string
+	CreatedBy       ulid.ULID
+	CreatedByName   string
+	CreatedAt       time.Time
+}
+
+const documentsInitCap = 5
+
+var DocumentsPool = sync.Pool{
+	New: func() any {
+		return make(Documents, 0, documentsInitCap)
+	},
+}
+
+func DocumentsAcquire() Documents {
+	return DocumentsPool.Get().(Documents)
+}
+
+func DocumentsRelease(t Documents) {
+	t = t[:0]
+	DocumentsPool.Put(t) // nolint:staticcheck
+}
+
+type Documents []Document
+
+var FilterDocumentPool = sync.Pool{
+	New: func() any {
+		return new(FilterDocument)
+	},
+}
+
+func FilterDocumentAcquire() *FilterDocument {
+	return FilterDocumentPool.Get().(*FilterDocument)
+}
+
+func FilterDocumentRelease(t *FilterDocument) {
+	*t = FilterDocument{}
+	FilterDocumentPool.Put(t)
+}
+
+type FilterDocument struct {
+	ID        ulid.ULID
+	PatientID string
+	RecordID  ulid.ULID
+	Kind      string
+	Limit     int
+	Offset    int
+}
+
+var DocumentDataPool = sync.Pool{
+	New: func() any {
+		return new(DocumentData)
+	},
+}
+
+func DocumentDataAcquire() *DocumentData {
+	return DocumentDataPool.Get().(*DocumentData)
+}
+
+func DocumentDataRelease(t *DocumentData) {
+	*t = DocumentData{}
+	DocumentDataPool.Put(t)
+}
+
+// DocumentData is what a template is executed with. Record is the record the
+// document is generated from, Records are the latest records of the patient
+// newest first, Medications are the active medication orders and LabResults
+// the latest resulted tests.
+type DocumentData struct {
+	Kind        string
+	Recipient   string
+	Notes       string
+	Patient     Patient
+	Record      DocumentRecord
+	Records     []DocumentRecord
+	Medications MedicationOrders
+	LabResults  LabResults
+	Author      User
+	GeneratedAt time.Time
+}
+
+// DocumentRecord is a medical record as it reads after its amendments.
+type DocumentRecord struct {
+	ID          ulid.ULID
+	Symptoms    string
+	Medications string
+	StaffName   string
+	StaffNIP    string
+	CreatedAt   time.Time
+}
+
+type ErrDocumentTemplateNotFound struct{}
+
+func (e ErrDocumentTemplateNotFound) Error() string {
+	return "Document template not found"
+}
+
+func (e ErrDocumentTemplateNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrDuplicateDocumentTemplate struct{}
+
+func (e ErrDuplicateDocumentTemplate) Error() string {
+	return "Document template name already exists"
+}
+
+func (e ErrDuplicateDocumentTemplate) Status() int {
+	return http.StatusConflict
+}
+
+type ErrDocumentNotFound struct{}
+
+func (e ErrDocumentNotFound) Error() string {
+	return "Document not found"
+}
+
+func (e ErrDocumentNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrDocumentRender struct{}
+
+func (e ErrDocumentRender) Error() string {
+	return "Document template failed to render for this patient"
+}
+
+func (e ErrDocumentRender) Status() int {
+	return http.StatusUnprocessableEntity
+}
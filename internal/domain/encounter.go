@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	EncounterOutpatient = "outpatient"
+	EncounterInpatient  = "inpatient"
+	EncounterEmergency  = "emergency"
+
+	EncounterOpen   = "open"
+	EncounterClosed = "closed"
+
+	PatientEventEncounterOpened = "encounter.opened"
+	PatientEventEncounterClosed = "encounter.closed"
+)
+
+var EncounterPool = sync.Pool{
+	New: func() any {
+		return new(Encounter)
+	},
+}
+
+func EncounterAcquire() *Encounter {
+	return EncounterPool.Get().(*Encounter)
+}
+
+func EncounterRelease(t *Encounter) {
+	*t = Encounter{}
+	EncounterPool.Put(t)
+}
+
+// Encounter is a single visit or admission, medical records saved while it
+// is open can be attached to it.
+type Encounter struct {
+	ID                 ulid.ULID
+	PatientID          string
+	Type               string
+	Status             string
+	Location           string
+	AttendingStaffID   ulid.ULID
+	AttendingStaffNIP  string
+	AttendingStaffName string
+	OpenedBy           ulid.ULID
+	OpenedAt           time.Time
+	ClosedBy           ulid.ULID
+	ClosedAt           time.Time
+}
+
+const encountersInitCap = 5
+
+var EncountersPool = sync.Pool{
+	New: func() any {
+		return make(Encounters, 0, encountersInitCap)
+	},
+}
+
+func EncountersAcquire() Encounters {
+	return EncountersPool.Get().(Encounters)
+}
+
+func EncountersRelease(t Encounters) {
+	t = t[:0]
+	EncountersPool.Put(t) // nolint:staticcheck
+}
+
+type Encounters []Encounter
+
+var FilterEncounterPool = sync.Pool{
+	New: func() any {
+		return new(FilterEncounter)
+	},
+}
+
+func FilterEncounterAcquire() *FilterEncounter {
+	return FilterEncounterPool.Get().(*FilterEncounter)
+}
+
+func FilterEncounterRelease(t *FilterEncounter) {
+	*t = FilterEncounter{}
+	FilterEncounterPool.Put(t)
+}
+
+type FilterEncounter struct {
+	ID               ulid.ULID
+	PatientID        string
+	AttendingStaffID ulid.ULID
+	Type             string
+	Status           string
+	Limit            int
+	Offset           int
+	OpenedAt         string
+}
+
+type ErrEncounterNotFound struct{}
+
+func (e ErrEncounterNotFound) Error() string {
+	return "Encounter not found"
+}
+
+func (e ErrEncounterNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrEncounterAlreadyOpen struct{}
+
+func (e ErrEncounterAlreadyOpen) Error() string {
+	return "Patient already has an open encounter"
+}
+
+func (e ErrEncounterAlreadyOpen) Status() int {
+	return http.StatusConflict
+}
+
+type ErrEncounterClosed struct{}
+
+func (e ErrEncounterClosed) Error() string {
+	return "Encounter is closed"
+}
+
+func (e ErrEncounterClosed) Status() int {
+	return http.StatusConflict
+}
+
+type ErrEncounterPatientMismatch struct{}
+
+func (e ErrEncounterPatientMismatch) Error() string {
+	return "Encounter belongs to another patient"
+}
+
+func (e ErrEncounterPatientMismatch) Status() int {
+	return http.StatusBadRequest
+}
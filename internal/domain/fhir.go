@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const PatientEventRecordExported = "record.exported"
+
+var FilterFHIRRecordPool = sync.Pool{
+	New: func() any {
+		return new(FilterFHIRRecord)
+	},
+}
+
+func FilterFHIRRecordAcquire() *FilterFHIRRecord {
+	return FilterFHIRRecordPool.Get().(*FilterFHIRRecord)
+}
+
+func FilterFHIRRecordRelease(t *FilterFHIRRecord) {
+	*t = FilterFHIRRecord{}
+	FilterFHIRRecordPool.Put(t)
+}
+
+// FilterFHIRRecord narrows the medical records served as FHIR resources.
+type FilterFHIRRecord struct {
+	ID        ulid.ULID
+	PatientID string
+	Limit     int
+	Offset    int
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	PatientEventHandoverCreated      = "handover.created"
+	PatientEventHandoverAcknowledged = "handover.acknowledged"
+)
+
+var HandoverPool = sync.Pool{
+	New: func() any {
+		return new(Handover)
+	},
+}
+
+func HandoverAcquire() *Handover {
+	return HandoverPool.Get().(*Handover)
+}
+
+func HandoverRelease(t *Handover) {
+	*t = Handover{}
+	HandoverPool.Put(t)
+}
+
+// Handover is an SBAR note the outgoing nurse leaves for the incoming nurse
+// about a patient, it is pending until the incoming nurse acknowledges it.
+type Handover struct {
+	ID             ulid.ULID
+	PatientID      string
+	PatientName    string
+	FromNurseID    ulid.ULID
+	FromNurseName  string
+	ToNurseID      ulid.ULID
+	ToNurseName    string
+	Situation      string
+	Background     string
+	Assessment     string
+	Recommendation string
+	CreatedAt      time.Time
+	AcknowledgedAt time.Time
+}
+
+const handoversInitCap = 5
+
+var HandoversPool = sync.Pool{
+	New: func() any {
+		return make(Handovers, 0, handoversInitCap)
+	},
+}
+
+func HandoversAcquire() Handovers {
+	return HandoversPool.Get().(Handovers)
+}
+
+func HandoversRelease(t Handovers) {
+	t = t[:0]
+	HandoversPool.Put(t) // nolint:staticcheck
+}
+
+type Handovers []Handover
+
+var FilterHandoverPool = sync.Pool{
+	New: func() any {
+		return new(FilterHandover)
+	},
+}
+
+func FilterHandoverAcquire() *FilterHandover {
+	return FilterHandoverPool.Get().(*FilterHandover)
+}
+
+func FilterHandoverRelease(t *FilterHandover) {
+	*t = FilterHandover{}
+	FilterHandoverPool.Put(t)
+}
+
+type FilterHandover struct {
+	PatientID   string
+	FromNurseID ulid.ULID
+	ToNurseID   ulid.ULID
+	PendingOnly bool
+	Limit       int
+	Offset      int
+}
+
+type ErrHandoverNotFound struct{}
+
+func (e ErrHandoverNotFound) Error() string {
+	return "Handover not found"
+}
+
+func (e ErrHandoverNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrHandoverNotRecipient struct{}
+
+func (e ErrHandoverNotRecipient) Error() string {
+	return "Only the incoming nurse can acknowledge a handover"
+}
+
+func (e ErrHandoverNotRecipient) Status() int {
+	return http.StatusForbidden
+}
+
+type ErrHandoverAcknowledged struct{}
+
+func (e ErrHandoverAcknowledged) Error() string {
+	return "Handover has already been acknowledged"
+}
+
+func (e ErrHandoverAcknowledged) Status() int {
+	return http.StatusConflict
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	HL7MessagePending  = "pending"
+	HL7MessageReplayed = "replayed"
+)
+
+var HL7MessagePool = sync.Pool{
+	New: func() any {
+		return new(HL7Message)
+	},
+}
+
+func HL7MessageAcquire() *HL7Message {
+	return HL7MessagePool.Get().(*HL7Message)
+}
+
+func HL7MessageRelease(t *HL7Message) {
+	*t = HL7Message{}
+	HL7MessagePool.Put(t)
+}
+
+// HL7Message is an inbound message that was turned down, kept as received so
+// it can be replayed once the cause is fixed. ReplayedAt is set when a replay
+// is accepted.
+type HL7Message struct {
+	ID                 ulid.ULID
+	ControlID          string
+	MessageType        string
+	SendingApplication string
+	RemoteAddr         string
+	Raw                string
+	Error              string
+	ReceivedAt         time.Time
+	ReplayCount        int
+	ReplayedAt         time.Time
+}
+
+const hl7MessagesInitCap = 5
+
+var HL7MessagesPool = sync.Pool{
+	New: func() any {
+		return make(HL7Messages, 0, hl7MessagesInitCap)
+	},
+}
+
+func HL7MessagesAcquire() HL7Messages {
+	return HL7MessagesPool.Get().(HL7Messages)
+}
+
+func HL7MessagesRelease(t HL7Messages) {
+	t = t[:0]
+	HL7MessagesPool.Put(t) // nolint:staticcheck
+}
+
+type HL7Messages []HL7Message
+
+var FilterHL7MessagePool = sync.Pool{
+	New: func() any {
+		return new(FilterHL7Message)
+	},
+}
+
+func FilterHL7MessageAcquire() *FilterHL7Message {
+	return FilterHL7MessagePool.Get().(*FilterHL7Message)
+}
+
+func FilterHL7MessageRelease(t *FilterHL7Message) {
+	*t = FilterHL7Message{}
+	FilterHL7MessagePool.Put(t)
+}
+
+type FilterHL7Message struct {
+	ID          ulid.ULID
+	ControlID   string
+	MessageType string
+	Status      string
+	Limit       int
+	Offset      int
+}
+
+type ErrHL7MessageNotFound struct{}
+
+func (e ErrHL7MessageNotFound) Error() string {
+	return "HL7 message not found"
+}
+
+func (e ErrHL7MessageNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrHL7MessageReplayed struct{}
+
+func (e ErrHL7MessageReplayed) Error() string {
+	return "HL7 message has already been replayed"
+}
+
+func (e ErrHL7MessageReplayed) Status() int {
+	return http.StatusConflict
+}
+
+// ErrHL7MessageRejected is a replay that was turned down again, Reason is
+// what the ACK would have said.
+type ErrHL7MessageRejected struct {
+	Reason string
+}
+
+func (e ErrHL7MessageRejected) Error() string {
+	return "HL7 message rejected again: " + e.Reason
+}
+
+func (e ErrHL7MessageRejected) Status() int {
+	return http.StatusUnprocessableEntity
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+
+	JobPatientExport = "patient-export"
+	JobRecordExport  = "record-export"
+	JobUserExport    = "user-export"
+	JobPatientImport = "patient-import"
+)
+
+var JobPool = sync.Pool{
+	New: func() any {
+		return new(Job)
+	},
+}
+
+func JobAcquire() *Job {
+	return JobPool.Get().(*Job)
+}
+
+func JobRelease(t *Job) {
+	*t = Job{}
+	JobPool.Put(t)
+}
+
+// Job is an export or import run in the background by a worker. Params is
+// the JSON of the params of its kind and Input the file uploaded for an
+// import. RunAt is when a queued job is due, or when the lease of a running
+// one runs out and another worker may take it over. The result file is
+// stored under ResultKey once the job has succeeded.
+type Job struct {
+	ID          ulid.ULID
+	Kind        string
+	Status      string
+	Params      []byte
+	Input       []byte
+	UserID      ulid.ULID
+	UserNIP     string
+	UserName    string
+	Progress    int
+	Total       int
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	ResultKey   string
+	ResultName  string
+	ResultType  string
+	ResultSize  int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+const jobsInitCap = 5
+
+var JobsPool = sync.Pool{
+	New: func() any {
+		return make(Jobs, 0, jobsInitCap)
+	},
+}
+
+func JobsAcquire() Jobs {
+	return JobsPool.Get().(Jobs)
+}
+
+func JobsRelease(t Jobs) {
+	t = t[:0]
+	JobsPool.Put(t) // nolint:staticcheck
+}
+
+type Jobs []Job
+
+var FilterJobPool = sync.Pool{
+	New: func() any {
+		return new(FilterJob)
+	},
+}
+
+func FilterJobAcquire() *FilterJob {
+	return FilterJobPool.Get().(*FilterJob)
+}
+
+func FilterJobRelease(t *FilterJob) {
+	*t = FilterJob{}
+	FilterJobPool.Put(t)
+}
+
+type FilterJob struct {
+	ID     ulid.ULID
+	UserID ulid.ULID
+	Kind   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+// Export params, like everything a body stream writer reads, are never taken
+// from a pool. A streamed response is written after its handler has returned
+// and a queued job runs later still, so they hold values allocated for them
+// rather than ones the handler acquires and releases.
+
+// PatientExportParams are the params of a patient export job.
+type PatientExportParams struct {
+	Format string
+	Filter FilterPatient
+}
+
+// RecordExportParams are the params of a medical record export job.
+type RecordExportParams struct {
+	Format string
+	Filter FilterMedicalRecord
+}
+
+// UserExportParams are the params of a user export job.
+type UserExportParams struct {
+	Format string
+	Filter FilterUser
+}
+
+// PatientImportParams are the params of a patient import job, the file is
+// the Input of the job.
+type PatientImportParams struct {
+	Format   string
+	FileName string
+}
+
+type ErrJobNotFound struct{}
+
+func (e ErrJobNotFound) Error() string {
+	return "Job not found"
+}
+
+func (e ErrJobNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrJobNotFailed struct{}
+
+func (e ErrJobNotFailed) Error() string {
+	return "Only failed jobs can be retried"
+}
+
+func (e ErrJobNotFailed) Status() int {
+	return http.StatusConflict
+}
+
+// ErrJobLeaseLost reports a worker whose job was taken over by another one
+// after its lease ran out, what it did is dropped.
+type ErrJobLeaseLost struct{}
+
+func (e ErrJobLeaseLost) Error() string {
+	return "Job was taken over by another worker"
+}
+
+func (e ErrJobLeaseLost) Status() int {
+	return http.StatusConflict
+}
+
+type ErrJobNoResult struct{}
+
+func (e ErrJobNoResult) Error() string {
+	return "Job has no result yet"
+}
+
+func (e ErrJobNoResult) Status() int {
+	return http.StatusConflict
+}
+
+// ErrJobInvalid wraps why a job cannot be done, running it again would fail
+// the same way.
+type ErrJobInvalid struct {
+	Err error
+}
+
+func (e ErrJobInvalid) Error() string {
+	return e.Err.Error()
+}
+
+func (e ErrJobInvalid) Unwrap() error {
+	return e.Err
+}
+
+func (e ErrJobInvalid) Status() int {
+	return http.StatusUnprocessableEntity
+}
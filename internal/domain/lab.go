@@ -0,0 +1,416 @@
+package domain
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	LabOrderOrdered   = "ordered"
+	LabOrderCollected = "collected"
+	LabOrderResulted  = "resulted"
+	LabOrderCancelled = "cancelled"
+
+	LabPriorityRoutine = "routine"
+	LabPriorityUrgent  = "urgent"
+
+	LabResultPending   = "pending"
+	LabResultResulted  = "resulted"
+	LabResultCancelled = "cancelled"
+
+	LabResultNumeric = "numeric"
+	LabResultText    = "text"
+
+	LabFlagNormal       = "normal"
+	LabFlagLow          = "low"
+	LabFlagHigh         = "high"
+	LabFlagCriticalLow  = "critical_low"
+	LabFlagCriticalHigh = "critical_high"
+	LabFlagAbnormal     = "abnormal"
+
+	// MaxTestsPerLabOrder bounds how many tests a single order can request
+	MaxTestsPerLabOrder = 30
+
+	PatientEventLabOrdered   = "lab.ordered"
+	PatientEventLabCollected = "lab.collected"
+	PatientEventLabResulted  = "lab.resulted"
+	PatientEventLabCancelled = "lab.cancelled"
+)
+
+// LabRange is a reference range, a nil bound is open.
+type LabRange struct {
+	Low  *float64
+	High *float64
+}
+
+func (r LabRange) isSet() bool {
+	return r.Low != nil || r.High != nil
+}
+
+// LabRanges are the reference ranges of a test, MaleRange and FemaleRange
+// take precedence over Range when set.
+type LabRanges struct {
+	Range       LabRange
+	MaleRange   LabRange
+	FemaleRange LabRange
+}
+
+// For returns the range that applies to a patient of the given sex.
+func (r LabRanges) For(isMale bool) LabRange {
+	if isMale && r.MaleRange.isSet() {
+		return r.MaleRange
+	}
+	if !isMale && r.FemaleRange.isSet() {
+		return r.FemaleRange
+	}
+
+	return r.Range
+}
+
+// LabAgeRange holds the reference ranges of patients from MinAge up to but
+// not including MaxAge years old.
+type LabAgeRange struct {
+	MinAge int
+	MaxAge int
+	LabRanges
+}
+
+func (r LabAgeRange) contains(age int) bool {
+	return age >= r.MinAge && age < r.MaxAge
+}
+
+// LabTest is an entry of the test catalogue. The ranges of the age band a
+// patient is in take precedence over the ones of the test, which are for
+// adults. NormalValues are the results of a text test that are not abnormal.
+type LabTest struct {
+	Code       string
+	Name       string
+	Specimen   string
+	Unit       string
+	ResultType string
+	LabRanges
+	AgeRanges    []LabAgeRange
+	CriticalLow  *float64
+	CriticalHigh *float64
+	NormalValues []string
+}
+
+// RangeFor returns the reference range that applies to a patient.
+func (t *LabTest) RangeFor(isMale bool, age int) LabRange {
+	for i := range t.AgeRanges {
+		if t.AgeRanges[i].contains(age) {
+			return t.AgeRanges[i].For(isMale)
+		}
+	}
+
+	return t.LabRanges.For(isMale)
+}
+
+// FlagNumeric flags value against the critical limits first and then the
+// reference range of the patient, tests without any range are not flagged.
+func (t *LabTest) FlagNumeric(value float64, isMale bool, age int) string {
+	switch {
+	case t.CriticalLow != nil && value < *t.CriticalLow:
+		return LabFlagCriticalLow
+	case t.CriticalHigh != nil && value > *t.CriticalHigh:
+		return LabFlagCriticalHigh
+	}
+
+	r := t.RangeFor(isMale, age)
+	switch {
+	case r.Low != nil && value < *r.Low:
+		return LabFlagLow
+	case r.High != nil && value > *r.High:
+		return LabFlagHigh
+	case r.isSet():
+		return LabFlagNormal
+	}
+
+	return ""
+}
+
+// FlagText flags value as abnormal unless it is one of NormalValues, ignoring
+// case and surrounding spaces.
+func (t *LabTest) FlagText(value string) string {
+	if len(t.NormalValues) == 0 {
+		return ""
+	}
+
+	value = strings.TrimSpace(value)
+	for _, normal := range t.NormalValues {
+		if strings.EqualFold(value, normal) {
+			return LabFlagNormal
+		}
+	}
+
+	return LabFlagAbnormal
+}
+
+type LabTests []LabTest
+
+// AgeAt is the age in whole years on at of a patient born on birthDate.
+func AgeAt(birthDate, at time.Time) int {
+	age := at.Year() - birthDate.Year()
+	if at.Month() < birthDate.Month() || (at.Month() == birthDate.Month() && at.Day() < birthDate.Day()) {
+		age--
+	}
+
+	return age
+}
+
+var LabOrderPool = sync.Pool{
+	New: func() any {
+		return new(LabOrder)
+	},
+}
+
+func LabOrderAcquire() *LabOrder {
+	return LabOrderPool.Get().(*LabOrder)
+}
+
+func LabOrderRelease(t *LabOrder) {
+	*t = LabOrder{}
+	LabOrderPool.Put(t)
+}
+
+// LabOrder requests the tests in Results for a patient, it is resulted once
+// every test has a result.
+type LabOrder struct {
+	ID              ulid.ULID
+	PatientID       string
+	PatientName     string
+	RecordID        ulid.ULID
+	Priority        string
+	Status          string
+	Notes           string
+	OrderedBy       ulid.ULID
+	OrderedByName   string
+	OrderedAt       time.Time
+	CollectedBy     ulid.ULID
+	CollectedByName string
+	CollectedAt     time.Time
+	CancelledBy     ulid.ULID
+	CancelledAt     time.Time
+	CancelReason    string
+	Results         LabResults
+}
+
+const labOrdersInitCap = 5
+
+var LabOrdersPool = sync.Pool{
+	New: func() any {
+		return make(LabOrders, 0, labOrdersInitCap)
+	},
+}
+
+func LabOrdersAcquire() LabOrders {
+	return LabOrdersPool.Get().(LabOrders)
+}
+
+func LabOrdersRelease(t LabOrders) {
+	t = t[:0]
+	LabOrdersPool.Put(t) // nolint:staticcheck
+}
+
+type LabOrders []LabOrder
+
+var FilterLabOrderPool = sync.Pool{
+	New: func() any {
+		return new(FilterLabOrder)
+	},
+}
+
+func FilterLabOrderAcquire() *FilterLabOrder {
+	return FilterLabOrderPool.Get().(*FilterLabOrder)
+}
+
+func FilterLabOrderRelease(t *FilterLabOrder) {
+	*t = FilterLabOrder{}
+	FilterLabOrderPool.Put(t)
+}
+
+type FilterLabOrder struct {
+	ID        ulid.ULID
+	PatientID string
+	RecordID  ulid.ULID
+	Status    string
+	Priority  string
+	Limit     int
+	Offset    int
+}
+
+var LabResultPool = sync.Pool{
+	New: func() any {
+		return new(LabResult)
+	},
+}
+
+func LabResultAcquire() *LabResult {
+	return LabResultPool.Get().(*LabResult)
+}
+
+func LabResultRelease(t *LabResult) {
+	*t = LabResult{}
+	LabResultPool.Put(t)
+}
+
+// LabResult is one test of an order. Value holds numeric results and
+// ValueText text ones, the reference range is the one Flag was computed
+// against.
+type LabResult struct {
+	ID                    ulid.ULID
+	OrderID               ulid.ULID
+	OrderStatus           string
+	PatientID             string
+	PatientIsMale         bool
+	PatientBirthDate      time.Time
+	TestCode              string
+	TestName              string
+	Unit                  string
+	ResultType            string
+	Status                string
+	Value                 *float64
+	ValueText             string
+	Flag                  string
+	ReferenceLow          *float64
+	ReferenceHigh         *float64
+	ReferenceText         string
+	AttachmentURL         string
+	AttachmentName        string
+	AttachmentContentType string
+	Note                  string
+	ResultedBy            ulid.ULID
+	ResultedByName        string
+	ResultedAt            time.Time
+}
+
+const labResultsInitCap = 5
+
+var LabResultsPool = sync.Pool{
+	New: func() any {
+		return make(LabResults, 0, labResultsInitCap)
+	},
+}
+
+func LabResultsAcquire() LabResults {
+	return LabResultsPool.Get().(LabResults)
+}
+
+func LabResultsRelease(t LabResults) {
+	t = t[:0]
+	LabResultsPool.Put(t) // nolint:staticcheck
+}
+
+type LabResults []LabResult
+
+var FilterLabResultPool = sync.Pool{
+	New: func() any {
+		return new(FilterLabResult)
+	},
+}
+
+func FilterLabResultAcquire() *FilterLabResult {
+	return FilterLabResultPool.Get().(*FilterLabResult)
+}
+
+func FilterLabResultRelease(t *FilterLabResult) {
+	*t = FilterLabResult{}
+	FilterLabResultPool.Put(t)
+}
+
+// FilterLabResult selects results resulted in [From, To), ordered by test
+// and then by time so results of the same test line up.
+type FilterLabResult struct {
+	ID        ulid.ULID
+	PatientID string
+	TestCode  string
+	Status    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
+
+type ErrLabTestNotFound struct{}
+
+func (e ErrLabTestNotFound) Error() string {
+	return "Lab test is not in the catalogue"
+}
+
+func (e ErrLabTestNotFound) Status() int {
+	return http.StatusBadRequest
+}
+
+type ErrLabOrderNotFound struct{}
+
+func (e ErrLabOrderNotFound) Error() string {
+	return "Lab order not found"
+}
+
+func (e ErrLabOrderNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrLabOrderNotOpen struct{}
+
+func (e ErrLabOrderNotOpen) Error() string {
+	return "Lab order has already been resulted or cancelled"
+}
+
+func (e ErrLabOrderNotOpen) Status() int {
+	return http.StatusConflict
+}
+
+type ErrLabOrderCollected struct{}
+
+func (e ErrLabOrderCollected) Error() string {
+	return "Lab order samples have already been collected"
+}
+
+func (e ErrLabOrderCollected) Status() int {
+	return http.StatusConflict
+}
+
+type ErrLabOrderNotCollected struct{}
+
+func (e ErrLabOrderNotCollected) Error() string {
+	return "Lab order samples have not been collected"
+}
+
+func (e ErrLabOrderNotCollected) Status() int {
+	return http.StatusConflict
+}
+
+type ErrLabResultNotFound struct{}
+
+func (e ErrLabResultNotFound) Error() string {
+	return "Lab result not found"
+}
+
+func (e ErrLabResultNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrLabResultRecorded struct{}
+
+func (e ErrLabResultRecorded) Error() string {
+	return "Lab result has already been recorded or cancelled"
+}
+
+func (e ErrLabResultRecorded) Status() int {
+	return http.StatusConflict
+}
+
+type ErrLabResultType struct{}
+
+func (e ErrLabResultType) Error() string {
+	return "Lab result value does not match the result type of the test"
+}
+
+func (e ErrLabResultType) Status() int {
+	return http.StatusBadRequest
+}
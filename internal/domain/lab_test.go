@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+// hemoglobin has ranges by sex for adults, one range for young children and
+// ranges by sex for teenagers, children from 5 to 12 have no band of their
+// own and fall back to the ranges of adults.
+var hemoglobin = LabTest{
+	Code:       "HGB",
+	Unit:       "g/dL",
+	ResultType: LabResultNumeric,
+	LabRanges: LabRanges{
+		MaleRange:   LabRange{Low: bound(13.5), High: bound(17.5)},
+		FemaleRange: LabRange{Low: bound(12.0), High: bound(15.5)},
+	},
+	AgeRanges: []LabAgeRange{
+		{
+			MinAge:    1,
+			MaxAge:    5,
+			LabRanges: LabRanges{Range: LabRange{Low: bound(11.0), High: bound(14.0)}},
+		},
+		{
+			MinAge: 12,
+			MaxAge: 18,
+			LabRanges: LabRanges{
+				MaleRange:   LabRange{Low: bound(13.0), High: bound(16.0)},
+				FemaleRange: LabRange{Low: bound(12.0), High: bound(16.0)},
+			},
+		},
+	},
+	CriticalLow:  bound(7.0),
+	CriticalHigh: bound(20.0),
+}
+
+func TestLabTestFlagNumeric(t *testing.T) {
+	// creatinine falls back to one range when there is none for the sex
+	creatinine := LabTest{
+		Code:       "CREA",
+		ResultType: LabResultNumeric,
+		LabRanges: LabRanges{
+			Range:     LabRange{Low: bound(0.59), High: bound(1.35)},
+			MaleRange: LabRange{Low: bound(0.74), High: bound(1.35)},
+		},
+		CriticalHigh: bound(10.0),
+	}
+	cholesterol := LabTest{
+		Code:       "CHOL",
+		ResultType: LabResultNumeric,
+		LabRanges:  LabRanges{Range: LabRange{High: bound(200)}},
+	}
+	unranged := LabTest{Code: "ESR", ResultType: LabResultNumeric}
+
+	tests := []struct {
+		name   string
+		test   LabTest
+		value  float64
+		isMale bool
+		age    int
+		want   string
+	}{
+		{name: "male at the low bound", test: hemoglobin, value: 13.5, isMale: true, age: 40, want: LabFlagNormal},
+		{name: "male under the low bound", test: hemoglobin, value: 13.49, isMale: true, age: 40, want: LabFlagLow},
+		{name: "male at the high bound", test: hemoglobin, value: 17.5, isMale: true, age: 40, want: LabFlagNormal},
+		{name: "male over the high bound", test: hemoglobin, value: 17.51, isMale: true, age: 40, want: LabFlagHigh},
+		{name: "female range for a female", test: hemoglobin, value: 12.5, age: 40, want: LabFlagNormal},
+		{name: "male range for a male", test: hemoglobin, value: 12.5, isMale: true, age: 40, want: LabFlagLow},
+		{name: "female over the female high bound", test: hemoglobin, value: 16.0, age: 40, want: LabFlagHigh},
+		{name: "male under the male high bound", test: hemoglobin, value: 16.0, isMale: true, age: 40, want: LabFlagNormal},
+		{name: "at the critical low limit", test: hemoglobin, value: 7.0, isMale: true, age: 40, want: LabFlagLow},
+		{name: "under the critical low limit", test: hemoglobin, value: 6.99, isMale: true, age: 40, want: LabFlagCriticalLow},
+		{name: "at the critical high limit", test: hemoglobin, value: 20.0, isMale: true, age: 40, want: LabFlagHigh},
+		{name: "over the critical high limit", test: hemoglobin, value: 20.01, isMale: true, age: 40, want: LabFlagCriticalHigh},
+		{name: "negative value", test: hemoglobin, value: -1, age: 40, want: LabFlagCriticalLow},
+		{name: "child in the range of children", test: hemoglobin, value: 11.2, isMale: true, age: 3, want: LabFlagNormal},
+		{name: "child under the range of children", test: hemoglobin, value: 10.9, age: 3, want: LabFlagLow},
+		{name: "child over the range of children", test: hemoglobin, value: 14.1, age: 3, want: LabFlagHigh},
+		{name: "first year of a band", test: hemoglobin, value: 11.0, isMale: true, age: 1, want: LabFlagNormal},
+		{name: "year a band ends", test: hemoglobin, value: 11.0, isMale: true, age: 5, want: LabFlagLow},
+		{name: "younger than every band", test: hemoglobin, value: 11.0, age: 0, want: LabFlagLow},
+		{name: "child under the critical limit", test: hemoglobin, value: 6.5, age: 3, want: LabFlagCriticalLow},
+		{name: "teenage boy", test: hemoglobin, value: 13.2, isMale: true, age: 12, want: LabFlagNormal},
+		{name: "teenage girl", test: hemoglobin, value: 16.0, age: 17, want: LabFlagNormal},
+		{name: "adult woman", test: hemoglobin, value: 16.0, age: 18, want: LabFlagHigh},
+		{name: "range for a sex without its own", test: creatinine, value: 0.6, age: 40, want: LabFlagNormal},
+		{name: "own range for a sex", test: creatinine, value: 0.6, isMale: true, age: 40, want: LabFlagLow},
+		{name: "critical limit on one side", test: creatinine, value: 10.5, isMale: true, age: 40, want: LabFlagCriticalHigh},
+		{name: "open low bound", test: cholesterol, value: 0, age: 40, want: LabFlagNormal},
+		{name: "at an upper bound only", test: cholesterol, value: 200, age: 40, want: LabFlagNormal},
+		{name: "over an upper bound only", test: cholesterol, value: 200.5, age: 40, want: LabFlagHigh},
+		{name: "no range", test: unranged, value: 120, age: 40, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.test.FlagNumeric(tt.value, tt.isMale, tt.age); got != tt.want {
+				t.Errorf("FlagNumeric(%v, %v, %d) = %q, want %q", tt.value, tt.isMale, tt.age, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabTestRangeFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		isMale    bool
+		age       int
+		low, high float64
+	}{
+		{name: "adult man", isMale: true, age: 40, low: 13.5, high: 17.5},
+		{name: "adult woman", age: 40, low: 12.0, high: 15.5},
+		{name: "young boy", isMale: true, age: 4, low: 11.0, high: 14.0},
+		{name: "young girl", age: 1, low: 11.0, high: 14.0},
+		{name: "boy between bands", isMale: true, age: 8, low: 13.5, high: 17.5},
+		{name: "teenage boy", isMale: true, age: 15, low: 13.0, high: 16.0},
+		{name: "teenage girl", age: 12, low: 12.0, high: 16.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := hemoglobin.RangeFor(tt.isMale, tt.age)
+			if r.Low == nil || r.High == nil || *r.Low != tt.low || *r.High != tt.high {
+				t.Errorf("RangeFor(%v, %d) = %v-%v, want %v-%v", tt.isMale, tt.age, r.Low, r.High, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestLabTestFlagText(t *testing.T) {
+	hepatitis := LabTest{
+		Code:         "HBSAG",
+		ResultType:   LabResultText,
+		NormalValues: []string{"negative", "non-reactive"},
+	}
+	unlisted := LabTest{Code: "GRAM", ResultType: LabResultText}
+
+	tests := []struct {
+		name  string
+		test  LabTest
+		value string
+		want  string
+	}{
+		{name: "normal value", test: hepatitis, value: "negative", want: LabFlagNormal},
+		{name: "another normal value", test: hepatitis, value: "non-reactive", want: LabFlagNormal},
+		{name: "case ignored", test: hepatitis, value: "NEGATIVE", want: LabFlagNormal},
+		{name: "surrounding spaces ignored", test: hepatitis, value: "  Non-Reactive\n", want: LabFlagNormal},
+		{name: "other value", test: hepatitis, value: "positive", want: LabFlagAbnormal},
+		{name: "abbreviated normal value", test: hepatitis, value: "neg", want: LabFlagAbnormal},
+		{name: "normal value with more text", test: hepatitis, value: "negative, repeat in 2 weeks", want: LabFlagAbnormal},
+		{name: "number", test: hepatitis, value: "0", want: LabFlagAbnormal},
+		{name: "blank", test: hepatitis, value: " ", want: LabFlagAbnormal},
+		{name: "no normal values", test: unlisted, value: "gram positive cocci", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.test.FlagText(tt.value); got != tt.want {
+				t.Errorf("FlagText(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgeAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		birthDate time.Time
+		at        time.Time
+		want      int
+	}{
+		{
+			name:      "day of birth",
+			birthDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC),
+			want:      0,
+		},
+		{
+			name:      "day before a birthday",
+			birthDate: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+			want:      4,
+		},
+		{
+			name:      "birthday",
+			birthDate: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			want:      5,
+		},
+		{
+			name:      "later month",
+			birthDate: time.Date(2006, 3, 15, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			want:      18,
+		},
+		{
+			name:      "leap day before the birthday in a common year",
+			birthDate: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+			want:      22,
+		},
+		{
+			name:      "leap day after the birthday in a common year",
+			birthDate: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:      23,
+		},
+		{
+			name:      "end of a leap year",
+			birthDate: time.Date(2011, 12, 31, 0, 0, 0, 0, time.UTC),
+			at:        time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+			want:      12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AgeAt(tt.birthDate, tt.at); got != tt.want {
+				t.Errorf("AgeAt(%s, %s) = %d, want %d",
+					tt.birthDate.Format(time.DateOnly), tt.at.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package domain
+
+const (
+	LabelWristband = "wristband"
+	LabelSpecimen  = "specimen"
+
+	LabelFormatPNG = "png"
+	LabelFormatPDF = "pdf"
+)
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var LiveEventPool = sync.Pool{
+	New: func() any {
+		return new(LiveEvent)
+	},
+}
+
+func LiveEventAcquire() *LiveEvent {
+	return LiveEventPool.Get().(*LiveEvent)
+}
+
+func LiveEventRelease(t *LiveEvent) {
+	*t = LiveEvent{}
+	LiveEventPool.Put(t)
+}
+
+// LiveEvent is an outbox event about a patient pushed to the screens that
+// follow the patient or the ward they are in. WardID is the ward the patient
+// is in when the event is read, zero when they are not admitted.
+type LiveEvent struct {
+	Event  OutboxEvent
+	WardID ulid.ULID
+}
+
+const liveEventsInitCap = 5
+
+var LiveEventsPool = sync.Pool{
+	New: func() any {
+		return make(LiveEvents, 0, liveEventsInitCap)
+	},
+}
+
+func LiveEventsAcquire() LiveEvents {
+	return LiveEventsPool.Get().(LiveEvents)
+}
+
+func LiveEventsRelease(t LiveEvents) {
+	t = t[:0]
+	LiveEventsPool.Put(t) // nolint:staticcheck
+}
+
+type LiveEvents []LiveEvent
+
+var FilterLiveEventPool = sync.Pool{
+	New: func() any {
+		return new(FilterLiveEvent)
+	},
+}
+
+func FilterLiveEventAcquire() *FilterLiveEvent {
+	return FilterLiveEventPool.Get().(*FilterLiveEvent)
+}
+
+func FilterLiveEventRelease(t *FilterLiveEvent) {
+	*t = FilterLiveEvent{}
+	FilterLiveEventPool.Put(t)
+}
+
+// FilterLiveEvent selects the events in IDs, or the ones after After, oldest
+// first.
+type FilterLiveEvent struct {
+	IDs       []ulid.ULID
+	After     ulid.ULID
+	PatientID string
+	WardID    ulid.ULID
+	Limit     int
+}
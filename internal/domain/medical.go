@@ -13,6 +13,11 @@ const (
 	GenderFemale = "female"
 )
 
+const (
+	AmendmentAddendum   = "addendum"
+	AmendmentCorrection = "correction"
+)
+
 var PatientPool = sync.Pool{
 	New: func() any {
 		return new(Patient)
@@ -57,6 +62,10 @@ func PatientsRelease(t Patients) {
 
 type Patients []Patient
 
+// LimitAll as the Limit of a patient, medical record or user filter returns
+// every match rather than a page, for exports.
+const LimitAll = -1
+
 var FilterPatientPool = sync.Pool{
 	New: func() any {
 		return new(FilterPatient)
@@ -79,6 +88,20 @@ type FilterPatient struct {
 	Name        string
 	PhoneNumber string
 	CreatedAt   string
+	FilterDemographic
+}
+
+// FilterDemographic narrows patients, or the patient snapshot of medical
+// records, by creation time and demographics. Zero values are ignored,
+// CreatedTo is exclusive while the birth date range is inclusive.
+type FilterDemographic struct {
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	AgeMin        int
+	AgeMax        int
+	Gender        string
+	BirthDateFrom time.Time
+	BirthDateTo   time.Time
 }
 
 var MedicalRecordPool = sync.Pool{
@@ -109,9 +132,103 @@ type MedicalRecord struct {
 	StaffID            ulid.ULID
 	StaffNIP           string
 	StaffName          string
+	EncounterID        ulid.ULID
 	CreatedAt          time.Time
+	Amendments         MedicalRecordAmendments
+	Attachments        MedicalRecordAttachments
+
+	// set only when the records are filtered by a full-text query
+	SearchRank          float32
+	SymptomsHeadline    string
+	MedicationsHeadline string
+}
+
+// Current returns the symptoms and medications as they read after every
+// amendment has been applied in order. Corrections replace the non-empty
+// fields, addenda are appended to them.
+func (m *MedicalRecord) Current() (string, string) {
+	symptoms, medications := m.Symptoms, m.Medications
+
+	for _, amendment := range m.Amendments {
+		switch amendment.Type {
+		case AmendmentCorrection:
+			if amendment.Symptoms != "" {
+				symptoms = amendment.Symptoms
+			}
+			if amendment.Medications != "" {
+				medications = amendment.Medications
+			}
+		case AmendmentAddendum:
+			if amendment.Symptoms != "" {
+				symptoms += "\n" + amendment.Symptoms
+			}
+			if amendment.Medications != "" {
+				medications += "\n" + amendment.Medications
+			}
+		}
+	}
+
+	return symptoms, medications
 }
 
+var MedicalRecordAmendmentPool = sync.Pool{
+	New: func() any {
+		return new(MedicalRecordAmendment)
+	},
+}
+
+func MedicalRecordAmendmentAcquire() *MedicalRecordAmendment {
+	return MedicalRecordAmendmentPool.Get().(*MedicalRecordAmendment)
+}
+
+func MedicalRecordAmendmentRelease(t *MedicalRecordAmendment) {
+	*t = MedicalRecordAmendment{}
+	MedicalRecordAmendmentPool.Put(t)
+}
+
+type MedicalRecordAmendment struct {
+	ID          ulid.ULID
+	RecordID    ulid.ULID
+	Type        string
+	Symptoms    string
+	Medications string
+	Reason      string
+	StaffID     ulid.ULID
+	StaffNIP    string
+	StaffName   string
+	CreatedAt   time.Time
+}
+
+type MedicalRecordAmendments []MedicalRecordAmendment
+
+var MedicalRecordAttachmentPool = sync.Pool{
+	New: func() any {
+		return new(MedicalRecordAttachment)
+	},
+}
+
+func MedicalRecordAttachmentAcquire() *MedicalRecordAttachment {
+	return MedicalRecordAttachmentPool.Get().(*MedicalRecordAttachment)
+}
+
+func MedicalRecordAttachmentRelease(t *MedicalRecordAttachment) {
+	*t = MedicalRecordAttachment{}
+	MedicalRecordAttachmentPool.Put(t)
+}
+
+type MedicalRecordAttachment struct {
+	ID          ulid.ULID
+	RecordID    ulid.ULID
+	URL         string
+	Name        string
+	ContentType string
+	Description string
+	StaffID     ulid.ULID
+	CreatedAt   time.Time
+}
+
+type MedicalRecordAttachments []MedicalRecordAttachment
+
 var FilterMedicalRecordPool = sync.Pool{
 	New: func() any {
 		return new(FilterMedicalRecord)
@@ -127,13 +244,19 @@ func FilterMedicalRecordRelease(t *FilterMedicalRecord) {
 	FilterMedicalRecordPool.Put(t)
 }
 
+// FilterMedicalRecord pages records, or encounters when GroupByEncounter is
+// set so that an encounter is never split across pages.
 type FilterMedicalRecord struct {
-	PatientID string
-	StaffID   ulid.ULID
-	StaffNIP  string
-	Limit     int
-	Offset    int
-	CreatedAt string
+	PatientID        string
+	StaffID          ulid.ULID
+	StaffNIP         string
+	EncounterID      ulid.ULID
+	Limit            int
+	Offset           int
+	CreatedAt        string
+	Query            string
+	GroupByEncounter bool
+	FilterDemographic
 }
 
 const medicalRecordsInitCap = 5
@@ -174,3 +297,23 @@ func (e ErrPatientNotFound) Error() string {
 func (e ErrPatientNotFound) Status() int {
 	return http.StatusNotFound
 }
+
+type ErrMedicalRecordNotFound struct{}
+
+func (e ErrMedicalRecordNotFound) Error() string {
+	return "Medical record not found"
+}
+
+func (e ErrMedicalRecordNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrRecordPatientMismatch struct{}
+
+func (e ErrRecordPatientMismatch) Error() string {
+	return "Medical record belongs to another patient"
+}
+
+func (e ErrRecordPatientMismatch) Status() int {
+	return http.StatusBadRequest
+}
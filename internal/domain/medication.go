@@ -0,0 +1,269 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	MedicationOrderActive       = "active"
+	MedicationOrderDiscontinued = "discontinued"
+
+	DoseDue       = "due"
+	DoseGiven     = "given"
+	DoseHeld      = "held"
+	DoseRefused   = "refused"
+	DoseCancelled = "cancelled"
+
+	// MaxDosesPerOrder bounds how many doses a single order can schedule
+	MaxDosesPerOrder = 500
+	// DoseGracePeriod is how late a dose can be given before it is overdue
+	DoseGracePeriod = 30 * time.Minute
+
+	PatientEventMedicationOrdered      = "medication.ordered"
+	PatientEventMedicationDiscontinued = "medication.discontinued"
+	PatientEventMedicationAdministered = "medication.administered"
+)
+
+var MedicationRoutes = []string{"oral", "iv", "im", "sc", "topical", "inhalation", "other"}
+
+var MedicationOrderPool = sync.Pool{
+	New: func() any {
+		return new(MedicationOrder)
+	},
+}
+
+func MedicationOrderAcquire() *MedicationOrder {
+	return MedicationOrderPool.Get().(*MedicationOrder)
+}
+
+func MedicationOrderRelease(t *MedicationOrder) {
+	*t = MedicationOrder{}
+	MedicationOrderPool.Put(t)
+}
+
+// MedicationOrder prescribes Dose of Medication every IntervalHours from
+// StartAt until EndAt, each occurrence becomes a MedicationDose.
+type MedicationOrder struct {
+	ID             ulid.ULID
+	PatientID      string
+	PatientName    string
+	Medication     string
+	Dose           string
+	Route          string
+	Instructions   string
+	StartAt        time.Time
+	EndAt          time.Time
+	IntervalHours  int
+	Status         string
+	OrderedBy      ulid.ULID
+	OrderedByName  string
+	OrderedAt      time.Time
+	DiscontinuedBy ulid.ULID
+	DiscontinuedAt time.Time
+}
+
+// DoseCount returns how many doses the order schedules.
+func (o *MedicationOrder) DoseCount() int {
+	if o.IntervalHours <= 0 || o.EndAt.Before(o.StartAt) {
+		return 0
+	}
+
+	return int(o.EndAt.Sub(o.StartAt)/(time.Duration(o.IntervalHours)*time.Hour)) + 1
+}
+
+// Schedule returns the due time of every dose of the order.
+func (o *MedicationOrder) Schedule() []time.Time {
+	count := o.DoseCount()
+	if count == 0 {
+		return nil
+	}
+
+	interval := time.Duration(o.IntervalHours) * time.Hour
+	schedule := make([]time.Time, 0, count)
+	for dueAt := o.StartAt; !dueAt.After(o.EndAt); dueAt = dueAt.Add(interval) {
+		schedule = append(schedule, dueAt)
+	}
+
+	return schedule
+}
+
+const medicationOrdersInitCap = 5
+
+var MedicationOrdersPool = sync.Pool{
+	New: func() any {
+		return make(MedicationOrders, 0, medicationOrdersInitCap)
+	},
+}
+
+func MedicationOrdersAcquire() MedicationOrders {
+	return MedicationOrdersPool.Get().(MedicationOrders)
+}
+
+func MedicationOrdersRelease(t MedicationOrders) {
+	t = t[:0]
+	MedicationOrdersPool.Put(t) // nolint:staticcheck
+}
+
+type MedicationOrders []MedicationOrder
+
+var FilterMedicationOrderPool = sync.Pool{
+	New: func() any {
+		return new(FilterMedicationOrder)
+	},
+}
+
+func FilterMedicationOrderAcquire() *FilterMedicationOrder {
+	return FilterMedicationOrderPool.Get().(*FilterMedicationOrder)
+}
+
+func FilterMedicationOrderRelease(t *FilterMedicationOrder) {
+	*t = FilterMedicationOrder{}
+	FilterMedicationOrderPool.Put(t)
+}
+
+type FilterMedicationOrder struct {
+	ID        ulid.ULID
+	PatientID string
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+var MedicationDosePool = sync.Pool{
+	New: func() any {
+		return new(MedicationDose)
+	},
+}
+
+func MedicationDoseAcquire() *MedicationDose {
+	return MedicationDosePool.Get().(*MedicationDose)
+}
+
+func MedicationDoseRelease(t *MedicationDose) {
+	*t = MedicationDose{}
+	MedicationDosePool.Put(t)
+}
+
+// MedicationDose is one scheduled administration of an order, the ward and
+// bed are those the patient currently occupies, if any.
+type MedicationDose struct {
+	ID             ulid.ULID
+	OrderID        ulid.ULID
+	PatientID      string
+	PatientName    string
+	Medication     string
+	Dose           string
+	Route          string
+	DueAt          time.Time
+	Status         string
+	RecordedBy     ulid.ULID
+	RecordedByName string
+	AdministeredAt time.Time
+	Reason         string
+	Note           string
+	WardID         ulid.ULID
+	WardCode       string
+	BedCode        string
+}
+
+const medicationDosesInitCap = 5
+
+var MedicationDosesPool = sync.Pool{
+	New: func() any {
+		return make(MedicationDoses, 0, medicationDosesInitCap)
+	},
+}
+
+func MedicationDosesAcquire() MedicationDoses {
+	return MedicationDosesPool.Get().(MedicationDoses)
+}
+
+func MedicationDosesRelease(t MedicationDoses) {
+	t = t[:0]
+	MedicationDosesPool.Put(t) // nolint:staticcheck
+}
+
+type MedicationDoses []MedicationDose
+
+var FilterMedicationDosePool = sync.Pool{
+	New: func() any {
+		return new(FilterMedicationDose)
+	},
+}
+
+func FilterMedicationDoseAcquire() *FilterMedicationDose {
+	return FilterMedicationDosePool.Get().(*FilterMedicationDose)
+}
+
+func FilterMedicationDoseRelease(t *FilterMedicationDose) {
+	*t = FilterMedicationDose{}
+	FilterMedicationDosePool.Put(t)
+}
+
+// FilterMedicationDose selects doses due in [DueFrom, DueTo), doses still due
+// before OverdueBefore are overdue.
+type FilterMedicationDose struct {
+	PatientID     string
+	OrderID       ulid.ULID
+	WardID        ulid.ULID
+	Status        string
+	DueFrom       time.Time
+	DueTo         time.Time
+	OverdueBefore time.Time
+	Limit         int
+	Offset        int
+}
+
+type ErrMedicationOrderNotFound struct{}
+
+func (e ErrMedicationOrderNotFound) Error() string {
+	return "Medication order not found"
+}
+
+func (e ErrMedicationOrderNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrMedicationOrderDiscontinued struct{}
+
+func (e ErrMedicationOrderDiscontinued) Error() string {
+	return "Medication order is already discontinued"
+}
+
+func (e ErrMedicationOrderDiscontinued) Status() int {
+	return http.StatusConflict
+}
+
+type ErrTooManyDoses struct{}
+
+func (e ErrTooManyDoses) Error() string {
+	return "Medication order schedules too many doses"
+}
+
+func (e ErrTooManyDoses) Status() int {
+	return http.StatusBadRequest
+}
+
+type ErrMedicationDoseNotFound struct{}
+
+func (e ErrMedicationDoseNotFound) Error() string {
+	return "Medication dose not found"
+}
+
+func (e ErrMedicationDoseNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrMedicationDoseRecorded struct{}
+
+func (e ErrMedicationDoseRecorded) Error() string {
+	return "Medication dose has already been recorded or cancelled"
+}
+
+func (e ErrMedicationDoseRecorded) Status() int {
+	return http.StatusConflict
+}
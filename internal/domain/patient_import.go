@@ -0,0 +1,44 @@
+package domain
+
+import "sync"
+
+var PatientImportPool = sync.Pool{
+	New: func() any {
+		return new(PatientImport)
+	},
+}
+
+func PatientImportAcquire() *PatientImport {
+	return PatientImportPool.Get().(*PatientImport)
+}
+
+func PatientImportRelease(t *PatientImport) {
+	*t = PatientImport{}
+	PatientImportPool.Put(t)
+}
+
+// PatientImport is the outcome of registering patients in bulk. Every row
+// that was not imported has an entry in Errors.
+type PatientImport struct {
+	Total    int
+	Imported int
+	Failed   int
+	Errors   []PatientImportError
+}
+
+// PatientImportError is why the patient on Row of the file was not imported.
+type PatientImportError struct {
+	Row            int
+	IdentityNumber string
+	Errors         []string
+}
+
+// Fail records that the patient on row was not imported.
+func (p *PatientImport) Fail(row int, identityNumber string, errs ...string) {
+	p.Failed++
+	p.Errors = append(p.Errors, PatientImportError{
+		Row:            row,
+		IdentityNumber: identityNumber,
+		Errors:         errs,
+	})
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	SatuSehatPending = "pending"
+	SatuSehatSynced  = "synced"
+	SatuSehatFailed  = "failed"
+)
+
+var SatuSehatSyncPool = sync.Pool{
+	New: func() any {
+		return new(SatuSehatSync)
+	},
+}
+
+func SatuSehatSyncAcquire() *SatuSehatSync {
+	return SatuSehatSyncPool.Get().(*SatuSehatSync)
+}
+
+func SatuSehatSyncRelease(t *SatuSehatSync) {
+	*t = SatuSehatSync{}
+	SatuSehatSyncPool.Put(t)
+}
+
+// SatuSehatSync is a resource queued to be sent to SATUSEHAT. EncounterID is
+// set on the encounter and its records, RecordID on the records, and RemoteID
+// is the id SATUSEHAT gave the resource once it is synced.
+type SatuSehatSync struct {
+	ID            ulid.ULID
+	ResourceType  string
+	PatientID     string
+	EncounterID   ulid.ULID
+	RecordID      ulid.ULID
+	Priority      int
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	RemoteID      string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	SyncedAt      time.Time
+}
+
+const satuSehatSyncsInitCap = 5
+
+var SatuSehatSyncsPool = sync.Pool{
+	New: func() any {
+		return make(SatuSehatSyncs, 0, satuSehatSyncsInitCap)
+	},
+}
+
+func SatuSehatSyncsAcquire() SatuSehatSyncs {
+	return SatuSehatSyncsPool.Get().(SatuSehatSyncs)
+}
+
+func SatuSehatSyncsRelease(t SatuSehatSyncs) {
+	t = t[:0]
+	SatuSehatSyncsPool.Put(t) // nolint:staticcheck
+}
+
+type SatuSehatSyncs []SatuSehatSync
+
+var FilterSatuSehatSyncPool = sync.Pool{
+	New: func() any {
+		return new(FilterSatuSehatSync)
+	},
+}
+
+func FilterSatuSehatSyncAcquire() *FilterSatuSehatSync {
+	return FilterSatuSehatSyncPool.Get().(*FilterSatuSehatSync)
+}
+
+func FilterSatuSehatSyncRelease(t *FilterSatuSehatSync) {
+	*t = FilterSatuSehatSync{}
+	FilterSatuSehatSyncPool.Put(t)
+}
+
+type FilterSatuSehatSync struct {
+	ID           ulid.ULID
+	ResourceType string
+	PatientID    string
+	EncounterID  ulid.ULID
+	Status       string
+	Limit        int
+	Offset       int
+}
+
+type ErrSatuSehatSyncNotFound struct{}
+
+func (e ErrSatuSehatSyncNotFound) Error() string {
+	return "SATUSEHAT sync item not found"
+}
+
+func (e ErrSatuSehatSyncNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrSatuSehatSyncNotFailed struct{}
+
+func (e ErrSatuSehatSyncNotFailed) Error() string {
+	return "Only failed SATUSEHAT sync items can be retried"
+}
+
+func (e ErrSatuSehatSyncNotFailed) Status() int {
+	return http.StatusConflict
+}
@@ -0,0 +1,275 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	ShiftDraft     = "draft"
+	ShiftPublished = "published"
+
+	ShiftSwapPending  = "pending"
+	ShiftSwapApproved = "approved"
+	ShiftSwapRejected = "rejected"
+)
+
+var ShiftPool = sync.Pool{
+	New: func() any {
+		return new(Shift)
+	},
+}
+
+func ShiftAcquire() *Shift {
+	return ShiftPool.Get().(*Shift)
+}
+
+func ShiftRelease(t *Shift) {
+	*t = Shift{}
+	ShiftPool.Put(t)
+}
+
+// Shift is a nurse working a ward between StartAt and EndAt, nurses only see
+// it once the roster containing it is published.
+type Shift struct {
+	ID          ulid.ULID
+	WardID      ulid.ULID
+	WardCode    string
+	WardName    string
+	NurseID     ulid.ULID
+	NurseNIP    string
+	NurseName   string
+	StartAt     time.Time
+	EndAt       time.Time
+	Status      string
+	CreatedBy   ulid.ULID
+	CreatedAt   time.Time
+	PublishedAt time.Time
+}
+
+const shiftsInitCap = 5
+
+var ShiftsPool = sync.Pool{
+	New: func() any {
+		return make(Shifts, 0, shiftsInitCap)
+	},
+}
+
+func ShiftsAcquire() Shifts {
+	return ShiftsPool.Get().(Shifts)
+}
+
+func ShiftsRelease(t Shifts) {
+	t = t[:0]
+	ShiftsPool.Put(t) // nolint:staticcheck
+}
+
+type Shifts []Shift
+
+var FilterShiftPool = sync.Pool{
+	New: func() any {
+		return new(FilterShift)
+	},
+}
+
+func FilterShiftAcquire() *FilterShift {
+	return FilterShiftPool.Get().(*FilterShift)
+}
+
+func FilterShiftRelease(t *FilterShift) {
+	*t = FilterShift{}
+	FilterShiftPool.Put(t)
+}
+
+// FilterShift selects shifts starting in [From, To), drafts are hidden from
+// ViewerID unless they are the head nurse of the ward.
+type FilterShift struct {
+	ID       ulid.ULID
+	WardID   ulid.ULID
+	NurseID  ulid.ULID
+	ViewerID ulid.ULID
+	Status   string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+var ShiftSwapPool = sync.Pool{
+	New: func() any {
+		return new(ShiftSwap)
+	},
+}
+
+func ShiftSwapAcquire() *ShiftSwap {
+	return ShiftSwapPool.Get().(*ShiftSwap)
+}
+
+func ShiftSwapRelease(t *ShiftSwap) {
+	*t = ShiftSwap{}
+	ShiftSwapPool.Put(t)
+}
+
+// ShiftSwap asks to hand Shift over to TargetNurseID, when TargetShift has an
+// ID the requester takes that shift in exchange.
+type ShiftSwap struct {
+	ID              ulid.ULID
+	Shift           Shift
+	RequestedBy     ulid.ULID
+	RequestedByName string
+	TargetNurseID   ulid.ULID
+	TargetNurseName string
+	TargetShift     Shift
+	Status          string
+	Reason          string
+	DecidedBy       ulid.ULID
+	DecidedAt       time.Time
+	CreatedAt       time.Time
+}
+
+const shiftSwapsInitCap = 5
+
+var ShiftSwapsPool = sync.Pool{
+	New: func() any {
+		return make(ShiftSwaps, 0, shiftSwapsInitCap)
+	},
+}
+
+func ShiftSwapsAcquire() ShiftSwaps {
+	return ShiftSwapsPool.Get().(ShiftSwaps)
+}
+
+func ShiftSwapsRelease(t ShiftSwaps) {
+	t = t[:0]
+	ShiftSwapsPool.Put(t) // nolint:staticcheck
+}
+
+type ShiftSwaps []ShiftSwap
+
+var FilterShiftSwapPool = sync.Pool{
+	New: func() any {
+		return new(FilterShiftSwap)
+	},
+}
+
+func FilterShiftSwapAcquire() *FilterShiftSwap {
+	return FilterShiftSwapPool.Get().(*FilterShiftSwap)
+}
+
+func FilterShiftSwapRelease(t *FilterShiftSwap) {
+	*t = FilterShiftSwap{}
+	FilterShiftSwapPool.Put(t)
+}
+
+// FilterShiftSwap limits ViewerID to swaps they are part of or that belong
+// to a ward they head.
+type FilterShiftSwap struct {
+	WardID   ulid.ULID
+	ViewerID ulid.ULID
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+type ErrShiftNotFound struct{}
+
+func (e ErrShiftNotFound) Error() string {
+	return "Shift not found"
+}
+
+func (e ErrShiftNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrShiftOverlap struct{}
+
+func (e ErrShiftOverlap) Error() string {
+	return "Shift overlaps another shift of the nurse"
+}
+
+func (e ErrShiftOverlap) Status() int {
+	return http.StatusConflict
+}
+
+type ErrShiftRestPeriod struct{}
+
+func (e ErrShiftRestPeriod) Error() string {
+	return "Shift leaves less than the minimum rest period between shifts"
+}
+
+func (e ErrShiftRestPeriod) Status() int {
+	return http.StatusConflict
+}
+
+type ErrShiftTooLong struct{}
+
+func (e ErrShiftTooLong) Error() string {
+	return "Shift is longer than the maximum shift length"
+}
+
+func (e ErrShiftTooLong) Status() int {
+	return http.StatusBadRequest
+}
+
+type ErrShiftPublished struct{}
+
+func (e ErrShiftPublished) Error() string {
+	return "Published shifts can only be changed through a swap"
+}
+
+func (e ErrShiftPublished) Status() int {
+	return http.StatusConflict
+}
+
+type ErrShiftNotSwappable struct{}
+
+func (e ErrShiftNotSwappable) Error() string {
+	return "Only upcoming published shifts of the nurses involved can be swapped"
+}
+
+func (e ErrShiftNotSwappable) Status() int {
+	return http.StatusConflict
+}
+
+type ErrShiftSwapNotFound struct{}
+
+func (e ErrShiftSwapNotFound) Error() string {
+	return "Shift swap not found"
+}
+
+func (e ErrShiftSwapNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrShiftSwapPending struct{}
+
+func (e ErrShiftSwapPending) Error() string {
+	return "Shift already has a pending swap"
+}
+
+func (e ErrShiftSwapPending) Status() int {
+	return http.StatusConflict
+}
+
+type ErrShiftSwapDecided struct{}
+
+func (e ErrShiftSwapDecided) Error() string {
+	return "Shift swap has already been decided"
+}
+
+func (e ErrShiftSwapDecided) Status() int {
+	return http.StatusConflict
+}
+
+type ErrRosterForbidden struct{}
+
+func (e ErrRosterForbidden) Error() string {
+	return "Only IT staff and the head nurse of the ward can manage its roster"
+}
+
+func (e ErrRosterForbidden) Status() int {
+	return http.StatusForbidden
+}
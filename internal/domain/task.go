@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	TaskOpen      = "open"
+	TaskCompleted = "completed"
+	TaskCancelled = "cancelled"
+
+	TaskEventReminder = "reminder"
+	TaskEventOverdue  = "overdue"
+
+	PatientEventTaskCreated   = "task.created"
+	PatientEventTaskCompleted = "task.completed"
+	PatientEventTaskCancelled = "task.cancelled"
+	PatientEventTaskOverdue   = "task.overdue"
+)
+
+var TaskPool = sync.Pool{
+	New: func() any {
+		return new(Task)
+	},
+}
+
+func TaskAcquire() *Task {
+	return TaskPool.Get().(*Task)
+}
+
+func TaskRelease(t *Task) {
+	*t = Task{}
+	TaskPool.Put(t)
+}
+
+// Task is a piece of nursing work for a patient, it is assigned to a nurse,
+// to whoever covers a ward, or both. RemindedAt and OverdueAt are set by the
+// scheduler once the matching TaskEvent has been emitted.
+type Task struct {
+	ID            ulid.ULID
+	PatientID     string
+	PatientName   string
+	RecordID      ulid.ULID
+	WardID        ulid.ULID
+	WardCode      string
+	AssigneeID    ulid.ULID
+	AssigneeName  string
+	Title         string
+	Description   string
+	DueAt         time.Time
+	RemindAt      time.Time
+	Status        string
+	CreatedBy     ulid.ULID
+	CreatedByName string
+	CreatedAt     time.Time
+	RemindedAt    time.Time
+	OverdueAt     time.Time
+	ClosedBy      ulid.ULID
+	ClosedByName  string
+	ClosedAt      time.Time
+	ClosingNote   string
+}
+
+const tasksInitCap = 5
+
+var TasksPool = sync.Pool{
+	New: func() any {
+		return make(Tasks, 0, tasksInitCap)
+	},
+}
+
+func TasksAcquire() Tasks {
+	return TasksPool.Get().(Tasks)
+}
+
+func TasksRelease(t Tasks) {
+	t = t[:0]
+	TasksPool.Put(t) // nolint:staticcheck
+}
+
+type Tasks []Task
+
+var FilterTaskPool = sync.Pool{
+	New: func() any {
+		return new(FilterTask)
+	},
+}
+
+func FilterTaskAcquire() *FilterTask {
+	return FilterTaskPool.Get().(*FilterTask)
+}
+
+func FilterTaskRelease(t *FilterTask) {
+	*t = FilterTask{}
+	FilterTaskPool.Put(t)
+}
+
+// FilterTask selects tasks due in [DueFrom, DueTo), open tasks due before
+// OverdueBefore are overdue. ForNurseID keeps the tasks assigned to the nurse
+// together with those of the wards the nurse is rostered on at CoveredAt.
+type FilterTask struct {
+	PatientID     string
+	AssigneeID    ulid.ULID
+	WardID        ulid.ULID
+	Status        string
+	DueFrom       time.Time
+	DueTo         time.Time
+	OverdueBefore time.Time
+	ForNurseID    ulid.ULID
+	CoveredAt     time.Time
+	Limit         int
+	Offset        int
+}
+
+var TaskEventPool = sync.Pool{
+	New: func() any {
+		return new(TaskEvent)
+	},
+}
+
+func TaskEventAcquire() *TaskEvent {
+	return TaskEventPool.Get().(*TaskEvent)
+}
+
+func TaskEventRelease(t *TaskEvent) {
+	*t = TaskEvent{}
+	TaskEventPool.Put(t)
+}
+
+// TaskEvent is a reminder or overdue notice emitted by the scheduler, Task
+// holds the task as it was when the event is read.
+type TaskEvent struct {
+	ID        ulid.ULID
+	Type      string
+	CreatedAt time.Time
+	Task      Task
+}
+
+const taskEventsInitCap = 5
+
+var TaskEventsPool = sync.Pool{
+	New: func() any {
+		return make(TaskEvents, 0, taskEventsInitCap)
+	},
+}
+
+func TaskEventsAcquire() TaskEvents {
+	return TaskEventsPool.Get().(TaskEvents)
+}
+
+func TaskEventsRelease(t TaskEvents) {
+	t = t[:0]
+	TaskEventsPool.Put(t) // nolint:staticcheck
+}
+
+type TaskEvents []TaskEvent
+
+var FilterTaskEventPool = sync.Pool{
+	New: func() any {
+		return new(FilterTaskEvent)
+	},
+}
+
+func FilterTaskEventAcquire() *FilterTaskEvent {
+	return FilterTaskEventPool.Get().(*FilterTaskEvent)
+}
+
+func FilterTaskEventRelease(t *FilterTaskEvent) {
+	*t = FilterTaskEvent{}
+	FilterTaskEventPool.Put(t)
+}
+
+// FilterTaskEvent selects events emitted after After, ForNurseID works as in
+// FilterTask.
+type FilterTaskEvent struct {
+	After      ulid.ULID
+	Type       string
+	ForNurseID ulid.ULID
+	CoveredAt  time.Time
+	Limit      int
+}
+
+type ErrTaskNotFound struct{}
+
+func (e ErrTaskNotFound) Error() string {
+	return "Task not found"
+}
+
+func (e ErrTaskNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrTaskClosed struct{}
+
+func (e ErrTaskClosed) Error() string {
+	return "Task is already completed or cancelled"
+}
+
+func (e ErrTaskClosed) Status() int {
+	return http.StatusConflict
+}
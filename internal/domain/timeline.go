@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	PatientEventRegistered = "patient.registered"
+	PatientEventUpdated    = "patient.updated"
+
+	TimelineMedicalRecord = "medical_record"
+)
+
+var PatientEventPool = sync.Pool{
+	New: func() any {
+		return new(PatientEvent)
+	},
+}
+
+func PatientEventAcquire() *PatientEvent {
+	return PatientEventPool.Get().(*PatientEvent)
+}
+
+func PatientEventRelease(t *PatientEvent) {
+	*t = PatientEvent{}
+	PatientEventPool.Put(t)
+}
+
+// PatientEvent is anything that happened to a patient outside a medical
+// record, Data holds the JSON encoded details of the event.
+type PatientEvent struct {
+	ID        ulid.ULID
+	PatientID string
+	Type      string
+	Data      []byte
+	StaffID   ulid.ULID
+	StaffName string
+	CreatedAt time.Time
+}
+
+type TimelineEntry struct {
+	ID        ulid.ULID
+	Type      string
+	RecordID  ulid.ULID
+	Data      []byte
+	StaffID   ulid.ULID
+	StaffName string
+	CreatedAt time.Time
+}
+
+const timelineInitCap = 20
+
+var TimelineEntriesPool = sync.Pool{
+	New: func() any {
+		return make(TimelineEntries, 0, timelineInitCap)
+	},
+}
+
+func TimelineEntriesAcquire() TimelineEntries {
+	return TimelineEntriesPool.Get().(TimelineEntries)
+}
+
+func TimelineEntriesRelease(t TimelineEntries) {
+	t = t[:0]
+	TimelineEntriesPool.Put(t) // nolint:staticcheck
+}
+
+type TimelineEntries []TimelineEntry
+
+var FilterTimelinePool = sync.Pool{
+	New: func() any {
+		return new(FilterTimeline)
+	},
+}
+
+func FilterTimelineAcquire() *FilterTimeline {
+	return FilterTimelinePool.Get().(*FilterTimeline)
+}
+
+func FilterTimelineRelease(t *FilterTimeline) {
+	*t = FilterTimeline{}
+	FilterTimelinePool.Put(t)
+}
+
+// FilterTimeline pages through a timeline newest first, entries strictly
+// older than (CursorAt, CursorID) are returned when CursorAt is set.
+type FilterTimeline struct {
+	PatientID string
+	Limit     int
+	CursorAt  time.Time
+	CursorID  ulid.ULID
+}
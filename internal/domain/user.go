@@ -83,6 +83,58 @@ type FilterUser struct {
 	CreatedAt string
 }
 
+var NurseImportPool = sync.Pool{
+	New: func() any {
+		return new(NurseImport)
+	},
+}
+
+func NurseImportAcquire() *NurseImport {
+	return NurseImportPool.Get().(*NurseImport)
+}
+
+func NurseImportRelease(t *NurseImport) {
+	*t = NurseImport{}
+	NurseImportPool.Put(t)
+}
+
+// NurseImport is the outcome of registering nurses from a roster file.
+// Granted counts the imported nurses that were given access with the
+// password in their row. Every row that was not imported has an entry in
+// Errors.
+type NurseImport struct {
+	Total    int
+	Imported int
+	Granted  int
+	Failed   int
+	Errors   []NurseImportError
+}
+
+// NurseImportError is why the nurse on Row of the roster was not imported.
+type NurseImportError struct {
+	Row    int
+	NIP    string
+	Errors []string
+}
+
+// Fail records that the nurse on row was not imported.
+func (n *NurseImport) Fail(row int, nip string, errs ...string) {
+	n.Failed++
+	n.Errors = append(n.Errors, NurseImportError{
+		Row:    row,
+		NIP:    nip,
+		Errors: errs,
+	})
+}
+
+// NurseImportRow is a valid nurse of a roster and the row they are on. The
+// password of Nurse is in plain text until it is hashed for saving, it is
+// empty when the nurse is not given access.
+type NurseImportRow struct {
+	Row   int
+	Nurse User
+}
+
 type ErrDuplicateNIP struct{}
 
 func (e ErrDuplicateNIP) Error() string {
@@ -142,3 +194,13 @@ func (e ErrNotFoundOrNotNurse) Error() string {
 func (e ErrNotFoundOrNotNurse) Status() int {
 	return http.StatusNotFound
 }
+
+type ErrNurseHasHistory struct{}
+
+func (e ErrNurseHasHistory) Error() string {
+	return "Nurse has appointments, handovers or shifts and cannot be deleted"
+}
+
+func (e ErrNurseHasHistory) Status() int {
+	return http.StatusConflict
+}
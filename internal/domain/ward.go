@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	BedEndTransfer  = "transfer"
+	BedEndDischarge = "discharge"
+
+	PatientEventAdmitted    = "bed.admitted"
+	PatientEventTransferred = "bed.transferred"
+	PatientEventDischarged  = "bed.discharged"
+)
+
+var WardPool = sync.Pool{
+	New: func() any {
+		return new(Ward)
+	},
+}
+
+func WardAcquire() *Ward {
+	return WardPool.Get().(*Ward)
+}
+
+func WardRelease(t *Ward) {
+	*t = Ward{}
+	WardPool.Put(t)
+}
+
+type Ward struct {
+	ID          ulid.ULID
+	Code        string
+	Name        string
+	HeadNurseID ulid.ULID
+	CreatedAt   time.Time
+	Beds        Beds
+}
+
+const wardsInitCap = 5
+
+var WardsPool = sync.Pool{
+	New: func() any {
+		return make(Wards, 0, wardsInitCap)
+	},
+}
+
+func WardsAcquire() Wards {
+	return WardsPool.Get().(Wards)
+}
+
+func WardsRelease(t Wards) {
+	t = t[:0]
+	WardsPool.Put(t) // nolint:staticcheck
+}
+
+type Wards []Ward
+
+var BedPool = sync.Pool{
+	New: func() any {
+		return new(Bed)
+	},
+}
+
+func BedAcquire() *Bed {
+	return BedPool.Get().(*Bed)
+}
+
+func BedRelease(t *Bed) {
+	*t = Bed{}
+	BedPool.Put(t)
+}
+
+// Bed is a bed within a ward, Occupancy has a zero ID when the bed is free.
+type Bed struct {
+	ID        ulid.ULID
+	WardID    ulid.ULID
+	Code      string
+	CreatedAt time.Time
+	Occupancy BedAssignment
+}
+
+type Beds []Bed
+
+var BedAssignmentPool = sync.Pool{
+	New: func() any {
+		return new(BedAssignment)
+	},
+}
+
+func BedAssignmentAcquire() *BedAssignment {
+	return BedAssignmentPool.Get().(*BedAssignment)
+}
+
+func BedAssignmentRelease(t *BedAssignment) {
+	*t = BedAssignment{}
+	BedAssignmentPool.Put(t)
+}
+
+// BedAssignment is one stay of a patient in a bed, it is current while
+// EndedAt is zero.
+type BedAssignment struct {
+	ID          ulid.ULID
+	BedID       ulid.ULID
+	BedCode     string
+	WardID      ulid.ULID
+	WardCode    string
+	PatientID   string
+	PatientName string
+	StartedBy   ulid.ULID
+	StartedAt   time.Time
+	EndedBy     ulid.ULID
+	EndedAt     time.Time
+	EndReason   string
+}
+
+const bedAssignmentsInitCap = 5
+
+var BedAssignmentsPool = sync.Pool{
+	New: func() any {
+		return make(BedAssignments, 0, bedAssignmentsInitCap)
+	},
+}
+
+func BedAssignmentsAcquire() BedAssignments {
+	return BedAssignmentsPool.Get().(BedAssignments)
+}
+
+func BedAssignmentsRelease(t BedAssignments) {
+	t = t[:0]
+	BedAssignmentsPool.Put(t) // nolint:staticcheck
+}
+
+type BedAssignments []BedAssignment
+
+type ErrWardNotFound struct{}
+
+func (e ErrWardNotFound) Error() string {
+	return "Ward not found"
+}
+
+func (e ErrWardNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrDuplicateWard struct{}
+
+func (e ErrDuplicateWard) Error() string {
+	return "Ward code already registered"
+}
+
+func (e ErrDuplicateWard) Status() int {
+	return http.StatusConflict
+}
+
+type ErrBedNotFound struct{}
+
+func (e ErrBedNotFound) Error() string {
+	return "Bed not found"
+}
+
+func (e ErrBedNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrDuplicateBed struct{}
+
+func (e ErrDuplicateBed) Error() string {
+	return "Bed code already registered in this ward"
+}
+
+func (e ErrDuplicateBed) Status() int {
+	return http.StatusConflict
+}
+
+type ErrBedOccupied struct{}
+
+func (e ErrBedOccupied) Error() string {
+	return "Bed is occupied"
+}
+
+func (e ErrBedOccupied) Status() int {
+	return http.StatusConflict
+}
+
+type ErrPatientAlreadyAdmitted struct{}
+
+func (e ErrPatientAlreadyAdmitted) Error() string {
+	return "Patient already occupies a bed"
+}
+
+func (e ErrPatientAlreadyAdmitted) Status() int {
+	return http.StatusConflict
+}
+
+type ErrPatientNotAdmitted struct{}
+
+func (e ErrPatientNotAdmitted) Error() string {
+	return "Patient does not occupy a bed"
+}
+
+func (e ErrPatientNotAdmitted) Status() int {
+	return http.StatusConflict
+}
@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// The events sent to webhooks, written to the outbox with the change they
+// describe.
+const (
+	OutboxPatientRegistered = "patient.registered"
+	OutboxPatientUpdated    = "patient.updated"
+	OutboxRecordCreated     = "medical-record.created"
+	OutboxRecordAmended     = "medical-record.amended"
+	OutboxUserRegistered    = "user.registered"
+	OutboxUserUpdated       = "user.updated"
+	OutboxUserDeleted       = "user.deleted"
+	OutboxUserAccessGranted = "user.access-granted"
+)
+
+// OutboxTypes are the event types a webhook can subscribe to.
+var OutboxTypes = []string{
+	OutboxPatientRegistered,
+	OutboxPatientUpdated,
+	OutboxRecordCreated,
+	OutboxRecordAmended,
+	OutboxUserRegistered,
+	OutboxUserUpdated,
+	OutboxUserDeleted,
+	OutboxUserAccessGranted,
+}
+
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+var OutboxEventPool = sync.Pool{
+	New: func() any {
+		return new(OutboxEvent)
+	},
+}
+
+func OutboxEventAcquire() *OutboxEvent {
+	return OutboxEventPool.Get().(*OutboxEvent)
+}
+
+func OutboxEventRelease(t *OutboxEvent) {
+	*t = OutboxEvent{}
+	OutboxEventPool.Put(t)
+}
+
+// OutboxEvent is a change written in the same transaction as the change
+// itself, so it is sent to the webhooks if and only if the change is
+// committed. PatientID is set on events about a patient or their records.
+type OutboxEvent struct {
+	ID        ulid.ULID
+	Type      string
+	PatientID string
+	Data      []byte
+	CreatedAt time.Time
+}
+
+var WebhookPool = sync.Pool{
+	New: func() any {
+		return new(Webhook)
+	},
+}
+
+func WebhookAcquire() *Webhook {
+	return WebhookPool.Get().(*Webhook)
+}
+
+func WebhookRelease(t *Webhook) {
+	*t = Webhook{}
+	WebhookPool.Put(t)
+}
+
+// Webhook is a URL the outbox events are sent to, signed with Secret. It
+// gets every event type when EventTypes is empty.
+type Webhook struct {
+	ID         ulid.ULID
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedBy  ulid.ULID
+	CreatedAt  time.Time
+}
+
+const webhooksInitCap = 5
+
+var WebhooksPool = sync.Pool{
+	New: func() any {
+		return make(Webhooks, 0, webhooksInitCap)
+	},
+}
+
+func WebhooksAcquire() Webhooks {
+	return WebhooksPool.Get().(Webhooks)
+}
+
+func WebhooksRelease(t Webhooks) {
+	t = t[:0]
+	WebhooksPool.Put(t) // nolint:staticcheck
+}
+
+type Webhooks []Webhook
+
+var WebhookDeliveryPool = sync.Pool{
+	New: func() any {
+		return new(WebhookDelivery)
+	},
+}
+
+func WebhookDeliveryAcquire() *WebhookDelivery {
+	return WebhookDeliveryPool.Get().(*WebhookDelivery)
+}
+
+func WebhookDeliveryRelease(t *WebhookDelivery) {
+	*t = WebhookDelivery{}
+	WebhookDeliveryPool.Put(t)
+}
+
+// WebhookDelivery is an outbox event queued to be sent to a webhook, Event
+// and the URL and Secret of the webhook are filled in when it is claimed to
+// be sent. A delivery that runs out of attempts is failed and stays so until
+// it is retried.
+type WebhookDelivery struct {
+	ID             ulid.ULID
+	WebhookID      ulid.ULID
+	Event          OutboxEvent
+	URL            string
+	Secret         string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	ResponseStatus int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+const webhookDeliveriesInitCap = 5
+
+var WebhookDeliveriesPool = sync.Pool{
+	New: func() any {
+		return make(WebhookDeliveries, 0, webhookDeliveriesInitCap)
+	},
+}
+
+func WebhookDeliveriesAcquire() WebhookDeliveries {
+	return WebhookDeliveriesPool.Get().(WebhookDeliveries)
+}
+
+func WebhookDeliveriesRelease(t WebhookDeliveries) {
+	t = t[:0]
+	WebhookDeliveriesPool.Put(t) // nolint:staticcheck
+}
+
+type WebhookDeliveries []WebhookDelivery
+
+var FilterWebhookDeliveryPool = sync.Pool{
+	New: func() any {
+		return new(FilterWebhookDelivery)
+	},
+}
+
+func FilterWebhookDeliveryAcquire() *FilterWebhookDelivery {
+	return FilterWebhookDeliveryPool.Get().(*FilterWebhookDelivery)
+}
+
+func FilterWebhookDeliveryRelease(t *FilterWebhookDelivery) {
+	*t = FilterWebhookDelivery{}
+	FilterWebhookDeliveryPool.Put(t)
+}
+
+type FilterWebhookDelivery struct {
+	ID        ulid.ULID
+	WebhookID ulid.ULID
+	EventType string
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+type ErrWebhookNotFound struct{}
+
+func (e ErrWebhookNotFound) Error() string {
+	return "Webhook not found"
+}
+
+func (e ErrWebhookNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrWebhookDeliveryNotFound struct{}
+
+func (e ErrWebhookDeliveryNotFound) Error() string {
+	return "Webhook delivery not found"
+}
+
+func (e ErrWebhookDeliveryNotFound) Status() int {
+	return http.StatusNotFound
+}
+
+type ErrWebhookDeliveryNotFailed struct{}
+
+func (e ErrWebhookDeliveryNotFailed) Error() string {
+	return "Only failed webhook deliveries can be retried"
+}
+
+func (e ErrWebhookDeliveryNotFailed) Status() int {
+	return http.StatusConflict
+}
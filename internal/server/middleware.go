@@ -67,6 +67,10 @@ func zapMiddleware() fiber.Handler {
 		SkipBody: func(c *fiber.Ctx) bool {
 			return strings.HasSuffix(c.Path(), "image")
 		},
+		// reading a streamed body here would wait for the whole stream
+		SkipResBody: func(c *fiber.Ctx) bool {
+			return c.Response().IsBodyStream()
+		},
 		Levels: []zapcore.Level{zapcore.ErrorLevel, zapcore.ErrorLevel, zapcore.InfoLevel},
 	})
 }
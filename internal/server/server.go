@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -30,6 +31,8 @@ func Run() {
 	jwtCache := adapter.GetJWTCache()
 	defer jwtCache.Flush()
 
+	// a document uploaded to the image module is up to 10MB
+	const bodyLimit = 12 * 1024 * 1024
 	serverTimeout := time.Duration(configs.Get().API.Timeout) * time.Second
 	serverConfig := fiber.Config{
 		AppName:                   configs.Get().App.Name,
@@ -38,6 +41,7 @@ func Run() {
 		JSONDecoder:               json.Unmarshal,
 		JSONEncoder:               json.Marshal,
 		ReadTimeout:               serverTimeout,
+		BodyLimit:                 bodyLimit,
 		CaseSensitive:             true,
 		StrictRouting:             true,
 		DisableHeaderNormalizing:  true,
@@ -71,9 +75,13 @@ func Run() {
 		serverConfig.Prefork = true
 	}
 
+	// cancelled on shutdown to stop the background work of the modules
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	app := fiber.New(serverConfig)
 	setMiddlewares(app)
-	application.New(app, db, s3, jwtCache, jwtMiddleware(jwtCache))
+	application.New(ctx, app, db, s3, jwtCache, jwtMiddleware(jwtCache))
 	l.Debug("Server Config", zap.Any("Config", app.Config()))
 
 	go func() {
@@ -91,6 +99,7 @@ func Run() {
 
 	<-c
 	l.Info("shutting down gracefully, press Ctrl+C again to force")
+	cancel()
 
 	if err := app.ShutdownWithTimeout(serverTimeout); err != nil {
 		l.Panic("Server forced to shutdown", zap.Error(err))
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_medical_record_amendments_immutable ON medical_record_amendments;
+DROP TRIGGER IF EXISTS trg_medical_records_immutable ON medical_records;
+DROP FUNCTION IF EXISTS reject_medical_record_change();
+
+DROP INDEX IF EXISTS idx_medical_record_amendments_record_id;
+
+DROP TABLE IF EXISTS medical_record_amendments;
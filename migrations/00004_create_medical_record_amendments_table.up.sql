@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS medical_record_amendments
+(
+    id          bytea         NOT NULL PRIMARY KEY,
+    record_id   bytea         NOT NULL REFERENCES medical_records (id),
+    type        VARCHAR(10)   NOT NULL CHECK (type IN ('addendum', 'correction')),
+    symptoms    VARCHAR(2000) NOT NULL,
+    medications VARCHAR(2000) NOT NULL,
+    reason      VARCHAR(500)  NOT NULL,
+    staff_id    bytea         NOT NULL,
+    staff_nip   varchar(15)   NOT NULL,
+    staff_name  VARCHAR(30)   NOT NULL,
+    created_at  timestamp     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_medical_record_amendments_record_id ON medical_record_amendments (record_id, created_at ASC);
+
+-- medical records and their amendments are append-only
+CREATE OR REPLACE FUNCTION reject_medical_record_change() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_medical_records_immutable
+    BEFORE UPDATE OR DELETE
+    ON medical_records
+    FOR EACH ROW
+EXECUTE FUNCTION reject_medical_record_change();
+
+CREATE TRIGGER trg_medical_record_amendments_immutable
+    BEFORE UPDATE OR DELETE
+    ON medical_record_amendments
+    FOR EACH ROW
+EXECUTE FUNCTION reject_medical_record_change();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS medical_record_attachments;
+
+DROP INDEX IF EXISTS idx_medical_record_attachments_record_id;
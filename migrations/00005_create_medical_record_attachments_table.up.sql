@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS medical_record_attachments
+(
+    id           bytea        NOT NULL PRIMARY KEY,
+    record_id    bytea        NOT NULL REFERENCES medical_records (id),
+    url          TEXT         NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    description  VARCHAR(500) NOT NULL,
+    staff_id     bytea        NOT NULL,
+    created_at   timestamp    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_medical_record_attachments_record_id ON medical_record_attachments (record_id, created_at ASC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS patient_events;
+
+DROP INDEX IF EXISTS idx_patient_events_patient_id;
+DROP INDEX IF EXISTS idx_medical_records_patient_id_created_at;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS patient_events
+(
+    id         bytea       NOT NULL PRIMARY KEY,
+    patient_id VARCHAR(16) NOT NULL REFERENCES patients (id),
+    type       VARCHAR(50) NOT NULL,
+    data       jsonb       NOT NULL,
+    staff_id   bytea       NULL,
+    staff_name VARCHAR(50) NOT NULL,
+    created_at timestamp   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_events_patient_id ON patient_events (patient_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_medical_records_patient_id_created_at ON medical_records (patient_id, created_at DESC, id DESC);
+
+-- backfill registration events, ids are ULIDs built from created_at and a hash of the patient id
+INSERT INTO patient_events (id, patient_id, type, data, staff_id, staff_name, created_at)
+SELECT decode(lpad(to_hex((extract(EPOCH FROM p.created_at) * 1000)::bigint), 12, '0') || substr(md5(p.id), 1, 20),
+              'hex'),
+       p.id,
+       'patient.registered',
+       jsonb_build_object(
+               'phoneNumber', p.phone_number,
+               'name', p.name,
+               'birthDate', p.birth_date,
+               'gender', CASE WHEN p.is_male THEN 'male' ELSE 'female' END
+       ),
+       NULL,
+       '',
+       p.created_at
+FROM patients p
+ON CONFLICT DO NOTHING;
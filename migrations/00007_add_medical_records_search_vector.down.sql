@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_medical_records_search_vector;
+DROP INDEX IF EXISTS idx_medical_record_amendments_search_vector;
+
+ALTER TABLE medical_record_amendments DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE medical_records DROP COLUMN IF EXISTS search_vector;
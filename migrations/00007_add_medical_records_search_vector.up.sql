@@ -0,0 +1,18 @@
+ALTER TABLE medical_records
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('indonesian', symptoms), 'A') ||
+        setweight(to_tsvector('english', symptoms), 'A') ||
+        setweight(to_tsvector('indonesian', medications), 'B') ||
+        setweight(to_tsvector('english', medications), 'B')
+        ) STORED;
+
+ALTER TABLE medical_record_amendments
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('indonesian', symptoms), 'A') ||
+        setweight(to_tsvector('english', symptoms), 'A') ||
+        setweight(to_tsvector('indonesian', medications), 'B') ||
+        setweight(to_tsvector('english', medications), 'B')
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_medical_records_search_vector ON medical_records USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_medical_record_amendments_search_vector ON medical_record_amendments USING gin (search_vector);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_patients_birth_date;
+DROP INDEX IF EXISTS idx_patients_is_male_birth_date;
+
+DROP INDEX IF EXISTS idx_medical_records_patient_birth_date;
+DROP INDEX IF EXISTS idx_medical_records_patient_is_male_birth_date;
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_patients_birth_date ON patients (birth_date);
+CREATE INDEX IF NOT EXISTS idx_patients_is_male_birth_date ON patients (is_male, birth_date);
+
+CREATE INDEX IF NOT EXISTS idx_medical_records_patient_birth_date ON medical_records (patient_birth_date);
+CREATE INDEX IF NOT EXISTS idx_medical_records_patient_is_male_birth_date ON medical_records (patient_is_male, patient_birth_date);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_medical_records_encounter_id;
+ALTER TABLE medical_records DROP COLUMN IF EXISTS encounter_id;
+
+DROP TABLE IF EXISTS encounters;
+
+DROP INDEX IF EXISTS idx_encounters_patient_id_open;
+DROP INDEX IF EXISTS idx_encounters_patient_id;
+DROP INDEX IF EXISTS idx_encounters_attending_staff_id;
+DROP INDEX IF EXISTS idx_encounters_opened_at_desc;
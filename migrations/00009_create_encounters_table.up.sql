@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS encounters
+(
+    id                   bytea        NOT NULL PRIMARY KEY,
+    patient_id           VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    type                 VARCHAR(15)  NOT NULL CHECK (type IN ('outpatient', 'inpatient', 'emergency')),
+    status               VARCHAR(10)  NOT NULL CHECK (status IN ('open', 'closed')),
+    location             VARCHAR(100) NOT NULL,
+    attending_staff_id   bytea        NOT NULL,
+    attending_staff_nip  varchar(15)  NOT NULL,
+    attending_staff_name VARCHAR(50)  NOT NULL,
+    opened_by            bytea        NOT NULL,
+    opened_at            timestamp    NOT NULL,
+    closed_by            bytea        NULL,
+    closed_at            timestamp    NULL
+);
+
+-- a patient can only have one open encounter at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encounters_patient_id_open ON encounters (patient_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_encounters_patient_id ON encounters USING hash (patient_id);
+CREATE INDEX IF NOT EXISTS idx_encounters_attending_staff_id ON encounters USING hash (attending_staff_id);
+CREATE INDEX IF NOT EXISTS idx_encounters_opened_at_desc ON encounters (opened_at DESC);
+
+ALTER TABLE medical_records
+    ADD COLUMN IF NOT EXISTS encounter_id bytea NULL REFERENCES encounters (id);
+
+CREATE INDEX IF NOT EXISTS idx_medical_records_encounter_id ON medical_records USING hash (encounter_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS bed_assignments;
+DROP TABLE IF EXISTS beds;
+DROP TABLE IF EXISTS wards;
+
+DROP INDEX IF EXISTS idx_bed_assignments_bed_id_current;
+DROP INDEX IF EXISTS idx_bed_assignments_patient_id_current;
+DROP INDEX IF EXISTS idx_bed_assignments_patient_id;
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS wards
+(
+    id         bytea       NOT NULL PRIMARY KEY,
+    code       VARCHAR(20) NOT NULL UNIQUE,
+    name       VARCHAR(50) NOT NULL,
+    created_at timestamp   NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS beds
+(
+    id         bytea       NOT NULL PRIMARY KEY,
+    ward_id    bytea       NOT NULL REFERENCES wards (id),
+    code       VARCHAR(20) NOT NULL,
+    created_at timestamp   NOT NULL,
+    UNIQUE (ward_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS bed_assignments
+(
+    id         bytea       NOT NULL PRIMARY KEY,
+    bed_id     bytea       NOT NULL REFERENCES beds (id),
+    patient_id VARCHAR(16) NOT NULL REFERENCES patients (id),
+    started_by bytea       NOT NULL,
+    started_at timestamp   NOT NULL,
+    ended_by   bytea       NULL,
+    ended_at   timestamp   NULL,
+    end_reason VARCHAR(10) NULL CHECK (end_reason IN ('transfer', 'discharge'))
+);
+
+-- a bed holds one patient and a patient lies in one bed at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bed_assignments_bed_id_current ON bed_assignments (bed_id) WHERE ended_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bed_assignments_patient_id_current ON bed_assignments (patient_id) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_bed_assignments_patient_id ON bed_assignments (patient_id, started_at DESC);
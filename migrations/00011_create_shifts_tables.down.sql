@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS shift_swaps;
+DROP TABLE IF EXISTS shifts;
+
+DROP INDEX IF EXISTS idx_shift_swaps_shift_id_pending;
+DROP INDEX IF EXISTS idx_shift_swaps_created_at_desc;
+DROP INDEX IF EXISTS idx_shifts_nurse_id_start_at;
+DROP INDEX IF EXISTS idx_shifts_ward_id_start_at;
+
+ALTER TABLE wards
+    DROP COLUMN IF EXISTS head_nurse_id;
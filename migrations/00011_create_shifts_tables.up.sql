@@ -0,0 +1,38 @@
+ALTER TABLE wards
+    ADD COLUMN IF NOT EXISTS head_nurse_id bytea NULL REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS shifts
+(
+    id           bytea       NOT NULL PRIMARY KEY,
+    ward_id      bytea       NOT NULL REFERENCES wards (id),
+    nurse_id     bytea       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    start_at     timestamp   NOT NULL,
+    end_at       timestamp   NOT NULL,
+    status       VARCHAR(10) NOT NULL CHECK (status IN ('draft', 'published')),
+    created_by   bytea       NOT NULL,
+    created_at   timestamp   NOT NULL,
+    published_by bytea       NULL,
+    published_at timestamp   NULL,
+    CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shifts_nurse_id_start_at ON shifts (nurse_id, start_at);
+CREATE INDEX IF NOT EXISTS idx_shifts_ward_id_start_at ON shifts (ward_id, start_at);
+
+CREATE TABLE IF NOT EXISTS shift_swaps
+(
+    id              bytea       NOT NULL PRIMARY KEY,
+    shift_id        bytea       NOT NULL REFERENCES shifts (id) ON DELETE CASCADE,
+    requested_by    bytea       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    target_nurse_id bytea       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    target_shift_id bytea       NULL REFERENCES shifts (id) ON DELETE CASCADE,
+    status          VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected')),
+    reason          VARCHAR(200) NOT NULL,
+    decided_by      bytea       NULL,
+    decided_at      timestamp   NULL,
+    created_at      timestamp   NOT NULL
+);
+
+-- a shift can only have one swap waiting for approval
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shift_swaps_shift_id_pending ON shift_swaps (shift_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_shift_swaps_created_at_desc ON shift_swaps (created_at DESC);
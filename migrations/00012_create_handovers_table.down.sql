@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS handovers;
+
+DROP INDEX IF EXISTS idx_handovers_to_nurse_id_pending;
+DROP INDEX IF EXISTS idx_handovers_patient_id;
+DROP INDEX IF EXISTS idx_handovers_created_at_desc;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS handovers
+(
+    id              bytea       NOT NULL PRIMARY KEY,
+    patient_id      VARCHAR(16) NOT NULL REFERENCES patients (id),
+    from_nurse_id   bytea       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    to_nurse_id     bytea       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    situation       text        NOT NULL,
+    background      text        NOT NULL,
+    assessment      text        NOT NULL,
+    recommendation  text        NOT NULL,
+    created_at      timestamp   NOT NULL,
+    acknowledged_at timestamp   NULL,
+    CHECK (from_nurse_id <> to_nurse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_handovers_to_nurse_id_pending ON handovers (to_nurse_id, created_at) WHERE acknowledged_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_handovers_patient_id ON handovers (patient_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_handovers_created_at_desc ON handovers (created_at DESC);
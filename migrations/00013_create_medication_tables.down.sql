@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS medication_doses;
+DROP TABLE IF EXISTS medication_orders;
+
+DROP INDEX IF EXISTS idx_medication_doses_due;
+DROP INDEX IF EXISTS idx_medication_doses_patient_id_due_at;
+DROP INDEX IF EXISTS idx_medication_orders_patient_id;
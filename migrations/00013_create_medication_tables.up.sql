@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS medication_orders
+(
+    id                bytea        NOT NULL PRIMARY KEY,
+    patient_id        VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    medication        VARCHAR(200) NOT NULL,
+    dose              VARCHAR(100) NOT NULL,
+    route             VARCHAR(15)  NOT NULL CHECK (route IN ('oral', 'iv', 'im', 'sc', 'topical', 'inhalation', 'other')),
+    instructions      text         NOT NULL,
+    start_at          timestamp    NOT NULL,
+    end_at            timestamp    NOT NULL,
+    interval_hours    INT          NOT NULL CHECK (interval_hours BETWEEN 1 AND 168),
+    status            VARCHAR(15)  NOT NULL CHECK (status IN ('active', 'discontinued')),
+    ordered_by        bytea        NOT NULL,
+    ordered_by_name   VARCHAR(50)  NOT NULL,
+    ordered_at        timestamp    NOT NULL,
+    discontinued_by   bytea        NULL,
+    discontinued_at   timestamp    NULL,
+    CHECK (end_at >= start_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_medication_orders_patient_id ON medication_orders (patient_id, ordered_at DESC);
+
+CREATE TABLE IF NOT EXISTS medication_doses
+(
+    id               bytea        NOT NULL PRIMARY KEY,
+    order_id         bytea        NOT NULL REFERENCES medication_orders (id),
+    patient_id       VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    due_at           timestamp    NOT NULL,
+    status           VARCHAR(10)  NOT NULL CHECK (status IN ('due', 'given', 'held', 'refused', 'cancelled')),
+    recorded_by      bytea        NULL,
+    recorded_by_name VARCHAR(50)  NULL,
+    administered_at  timestamp    NULL,
+    reason           VARCHAR(200) NULL,
+    note             text         NULL,
+    UNIQUE (order_id, due_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_medication_doses_due ON medication_doses (due_at) WHERE status = 'due';
+CREATE INDEX IF NOT EXISTS idx_medication_doses_patient_id_due_at ON medication_doses (patient_id, due_at);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS task_events;
+DROP TABLE IF EXISTS tasks;
+
+DROP INDEX IF EXISTS idx_task_events_created_at;
+DROP INDEX IF EXISTS idx_tasks_remind_at_open;
+DROP INDEX IF EXISTS idx_tasks_due_at_open;
+DROP INDEX IF EXISTS idx_tasks_assignee_id;
+DROP INDEX IF EXISTS idx_tasks_ward_id;
+DROP INDEX IF EXISTS idx_tasks_patient_id;
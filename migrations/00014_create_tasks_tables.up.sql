@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS tasks
+(
+    id              bytea        NOT NULL PRIMARY KEY,
+    patient_id      VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    record_id       bytea        NULL REFERENCES medical_records (id),
+    ward_id         bytea        NULL REFERENCES wards (id),
+    assignee_id     bytea        NULL REFERENCES users (id) ON DELETE SET NULL,
+    title           VARCHAR(100) NOT NULL,
+    description     text         NOT NULL,
+    due_at          timestamp    NOT NULL,
+    remind_at       timestamp    NOT NULL,
+    status          VARCHAR(10)  NOT NULL CHECK (status IN ('open', 'completed', 'cancelled')),
+    created_by      bytea        NOT NULL,
+    created_by_name VARCHAR(50)  NOT NULL,
+    created_at      timestamp    NOT NULL,
+    reminded_at     timestamp    NULL,
+    overdue_at      timestamp    NULL,
+    closed_by       bytea        NULL,
+    closed_by_name  VARCHAR(50)  NULL,
+    closed_at       timestamp    NULL,
+    closing_note    text         NULL
+);
+
+-- the scheduler only ever looks at open tasks
+CREATE INDEX IF NOT EXISTS idx_tasks_remind_at_open ON tasks (remind_at) WHERE status = 'open' AND reminded_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_due_at_open ON tasks (due_at) WHERE status = 'open' AND overdue_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_assignee_id ON tasks (assignee_id, due_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_ward_id ON tasks (ward_id, due_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_patient_id ON tasks (patient_id, due_at);
+
+CREATE TABLE IF NOT EXISTS task_events
+(
+    id         bytea       NOT NULL PRIMARY KEY,
+    task_id    bytea       NOT NULL REFERENCES tasks (id),
+    type       VARCHAR(10) NOT NULL CHECK (type IN ('reminder', 'overdue')),
+    created_at timestamp   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_created_at ON task_events (created_at, id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS appointments;
+DROP TABLE IF EXISTS staff_schedules;
+
+DROP INDEX IF EXISTS idx_appointments_staff_id_start_at;
+DROP INDEX IF EXISTS idx_appointments_patient_id_start_at;
+DROP INDEX IF EXISTS idx_staff_schedules_staff_id;
@@ -0,0 +1,49 @@
+-- btree_gist lets the exclusion constraints mix equality on ids with range overlap
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- weekly availability of a staff member, minutes are counted from midnight
+CREATE TABLE IF NOT EXISTS staff_schedules
+(
+    id           bytea     NOT NULL PRIMARY KEY,
+    staff_id     bytea     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    weekday      SMALLINT  NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_minute SMALLINT  NOT NULL CHECK (start_minute BETWEEN 0 AND 1440),
+    end_minute   SMALLINT  NOT NULL CHECK (end_minute BETWEEN 0 AND 1440),
+    slot_minutes SMALLINT  NOT NULL CHECK (slot_minutes BETWEEN 5 AND 480),
+    created_at   timestamp NOT NULL,
+    CHECK (end_minute > start_minute)
+);
+
+CREATE INDEX IF NOT EXISTS idx_staff_schedules_staff_id ON staff_schedules (staff_id, weekday, start_minute);
+
+CREATE TABLE IF NOT EXISTS appointments
+(
+    id               bytea        NOT NULL PRIMARY KEY,
+    patient_id       VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    staff_id         bytea        NOT NULL REFERENCES users (id),
+    record_id        bytea        NULL REFERENCES medical_records (id),
+    start_at         timestamp    NOT NULL,
+    end_at           timestamp    NOT NULL,
+    type             VARCHAR(15)  NOT NULL CHECK (type IN ('consultation', 'follow_up', 'procedure', 'other')),
+    status           VARCHAR(15)  NOT NULL CHECK (status IN ('scheduled', 'completed', 'cancelled', 'no_show', 'rescheduled')),
+    notes            VARCHAR(500) NOT NULL,
+    rescheduled_from bytea        NULL REFERENCES appointments (id),
+    created_by       bytea        NOT NULL,
+    created_by_name  VARCHAR(50)  NOT NULL,
+    created_at       timestamp    NOT NULL,
+    closed_by        bytea        NULL,
+    closed_by_name   VARCHAR(50)  NULL,
+    closed_at        timestamp    NULL,
+    close_reason     VARCHAR(200) NULL,
+    CHECK (end_at > start_at),
+    -- only appointments still to happen hold their slot
+    CONSTRAINT appointments_staff_no_overlap EXCLUDE USING gist (
+        staff_id WITH =, tsrange(start_at, end_at) WITH &&
+        ) WHERE (status = 'scheduled'),
+    CONSTRAINT appointments_patient_no_overlap EXCLUDE USING gist (
+        patient_id WITH =, tsrange(start_at, end_at) WITH &&
+        ) WHERE (status = 'scheduled')
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointments_staff_id_start_at ON appointments (staff_id, start_at);
+CREATE INDEX IF NOT EXISTS idx_appointments_patient_id_start_at ON appointments (patient_id, start_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS lab_results;
+DROP TABLE IF EXISTS lab_orders;
+
+DROP INDEX IF EXISTS idx_lab_results_patient_id_test_code;
+DROP INDEX IF EXISTS idx_lab_orders_patient_id;
+DROP INDEX IF EXISTS idx_lab_orders_record_id;
@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS lab_orders
+(
+    id                bytea        NOT NULL PRIMARY KEY,
+    patient_id        VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    record_id         bytea        NOT NULL REFERENCES medical_records (id),
+    priority          VARCHAR(10)  NOT NULL CHECK (priority IN ('routine', 'urgent')),
+    status            VARCHAR(10)  NOT NULL CHECK (status IN ('ordered', 'collected', 'resulted', 'cancelled')),
+    notes             VARCHAR(500) NOT NULL,
+    ordered_by        bytea        NOT NULL,
+    ordered_by_name   VARCHAR(50)  NOT NULL,
+    ordered_at        timestamp    NOT NULL,
+    collected_by      bytea        NULL,
+    collected_by_name VARCHAR(50)  NULL,
+    collected_at      timestamp    NULL,
+    cancelled_by      bytea        NULL,
+    cancelled_at      timestamp    NULL,
+    cancel_reason     VARCHAR(200) NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_lab_orders_patient_id ON lab_orders (patient_id, ordered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_lab_orders_record_id ON lab_orders (record_id);
+
+-- one row per test of an order, test details and the reference range used
+-- for flagging are copied so results stay readable if the catalogue changes
+CREATE TABLE IF NOT EXISTS lab_results
+(
+    id                      bytea            NOT NULL PRIMARY KEY,
+    order_id                bytea            NOT NULL REFERENCES lab_orders (id),
+    patient_id              VARCHAR(16)      NOT NULL REFERENCES patients (id),
+    test_code               VARCHAR(20)      NOT NULL,
+    test_name               VARCHAR(100)     NOT NULL,
+    unit                    VARCHAR(20)      NOT NULL,
+    result_type             VARCHAR(10)      NOT NULL CHECK (result_type IN ('numeric', 'text')),
+    status                  VARCHAR(10)      NOT NULL CHECK (status IN ('pending', 'resulted', 'cancelled')),
+    value_numeric           double precision NULL,
+    value_text              VARCHAR(500)     NULL,
+    flag                    VARCHAR(15)      NULL,
+    reference_low           double precision NULL,
+    reference_high          double precision NULL,
+    reference_text          VARCHAR(200)     NULL,
+    attachment_url          TEXT             NULL,
+    attachment_name         VARCHAR(255)     NULL,
+    attachment_content_type VARCHAR(100)     NULL,
+    note                    VARCHAR(2000)    NULL,
+    resulted_by             bytea            NULL,
+    resulted_by_name        VARCHAR(50)      NULL,
+    resulted_at             timestamp        NULL,
+    UNIQUE (order_id, test_code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lab_results_patient_id_test_code ON lab_results (patient_id, test_code, resulted_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS documents;
+DROP TABLE IF EXISTS document_templates;
+
+DROP INDEX IF EXISTS idx_documents_patient_id;
+DROP INDEX IF EXISTS idx_documents_record_id;
+DROP INDEX IF EXISTS idx_document_templates_kind;
@@ -0,0 +1,136 @@
+CREATE TABLE IF NOT EXISTS document_templates
+(
+    id              bytea        NOT NULL PRIMARY KEY,
+    kind            VARCHAR(15)  NOT NULL CHECK (kind IN ('referral', 'discharge')),
+    name            VARCHAR(100) NOT NULL UNIQUE,
+    body            text         NOT NULL,
+    version         INT          NOT NULL,
+    updated_by      bytea        NULL,
+    updated_by_name VARCHAR(50)  NOT NULL,
+    created_at      timestamp    NOT NULL,
+    updated_at      timestamp    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_templates_kind ON document_templates (kind, name);
+
+-- the template and the data it was rendered with are not kept, the rendered
+-- files are the document
+CREATE TABLE IF NOT EXISTS documents
+(
+    id               bytea        NOT NULL PRIMARY KEY,
+    template_id      bytea        NOT NULL REFERENCES document_templates (id),
+    template_name    VARCHAR(100) NOT NULL,
+    template_version INT          NOT NULL,
+    kind             VARCHAR(15)  NOT NULL,
+    patient_id       VARCHAR(16)  NOT NULL REFERENCES patients (id),
+    record_id        bytea        NOT NULL REFERENCES medical_records (id),
+    recipient        VARCHAR(200) NOT NULL,
+    notes            text         NOT NULL,
+    html_url         TEXT         NOT NULL,
+    pdf_url          TEXT         NOT NULL,
+    created_by       bytea        NOT NULL,
+    created_by_name  VARCHAR(50)  NOT NULL,
+    created_at       timestamp    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_patient_id ON documents (patient_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_documents_record_id ON documents (record_id);
+
+-- default templates, ids are ULIDs built from the migration time and a hash of the name
+INSERT INTO document_templates (id, kind, name, body, version, updated_by, updated_by_name, created_at, updated_at)
+VALUES (decode(lpad(to_hex((extract(EPOCH FROM now()) * 1000)::bigint), 12, '0') || substr(md5('Referral letter'), 1, 20),
+               'hex'),
+        'referral',
+        'Referral letter',
+        $tmpl$<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Referral Letter</title>
+</head>
+<body>
+<h1>Referral Letter</h1>
+<p>Date: {{date .GeneratedAt}}</p>
+<p>To: {{.Recipient}}</p>
+<h2>Patient</h2>
+<table>
+<tr><td>Name</td><td>{{.Patient.Name}}</td></tr>
+<tr><td>Identity number</td><td>{{.Patient.ID}}</td></tr>
+<tr><td>Date of birth</td><td>{{date .Patient.BirthDate}} ({{age .Patient.BirthDate}} years)</td></tr>
+<tr><td>Gender</td><td>{{.Patient.Gender}}</td></tr>
+<tr><td>Phone number</td><td>{{.Patient.PhoneNumber}}</td></tr>
+</table>
+<h2>Reason for referral</h2>
+<p>{{lines .Notes}}</p>
+<h2>Diagnosis and findings</h2>
+<p>{{lines .Record.Symptoms}}</p>
+<h2>Current medications</h2>
+{{if .Medications}}<ul>
+{{range .Medications}}<li>{{.Medication}} {{.Dose}} ({{.Route}}) every {{.IntervalHours}} hours</li>
+{{end}}</ul>{{else}}<p>{{lines .Record.Medications}}</p>{{end}}
+<h2>Recent laboratory results</h2>
+{{if .LabResults}}<table>
+<tr><th>Date</th><th>Test</th><th>Result</th><th>Flag</th></tr>
+{{range .LabResults}}<tr><td>{{date .ResultedAt}}</td><td>{{.TestName}}</td><td>{{labValue .}} {{.Unit}}</td><td>{{.Flag}}</td></tr>
+{{end}}</table>{{else}}<p>No laboratory results.</p>{{end}}
+<h2>Recent visits</h2>
+<ul>
+{{range .Records}}<li>{{date .CreatedAt}}, {{.StaffName}}: {{lines .Symptoms}}</li>
+{{end}}</ul>
+<p>Sincerely,</p>
+<p>{{.Author.Name}}<br>NIP {{.Author.NIP}}</p>
+</body>
+</html>
+$tmpl$,
+        1,
+        NULL,
+        '',
+        now(),
+        now()),
+       (decode(lpad(to_hex((extract(EPOCH FROM now()) * 1000)::bigint), 12, '0') || substr(md5('Discharge summary'), 1, 20),
+               'hex'),
+        'discharge',
+        'Discharge summary',
+        $tmpl$<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Discharge Summary</title>
+</head>
+<body>
+<h1>Discharge Summary</h1>
+<p>Date: {{date .GeneratedAt}}</p>
+<h2>Patient</h2>
+<table>
+<tr><td>Name</td><td>{{.Patient.Name}}</td></tr>
+<tr><td>Identity number</td><td>{{.Patient.ID}}</td></tr>
+<tr><td>Date of birth</td><td>{{date .Patient.BirthDate}} ({{age .Patient.BirthDate}} years)</td></tr>
+<tr><td>Gender</td><td>{{.Patient.Gender}}</td></tr>
+</table>
+<h2>Diagnosis</h2>
+<p>{{lines .Record.Symptoms}}</p>
+<h2>Course of care</h2>
+<ul>
+{{range .Records}}<li>{{date .CreatedAt}}, {{.StaffName}}: {{lines .Symptoms}}</li>
+{{end}}</ul>
+<h2>Laboratory results</h2>
+{{if .LabResults}}<table>
+<tr><th>Date</th><th>Test</th><th>Result</th><th>Flag</th></tr>
+{{range .LabResults}}<tr><td>{{date .ResultedAt}}</td><td>{{.TestName}}</td><td>{{labValue .}} {{.Unit}}</td><td>{{.Flag}}</td></tr>
+{{end}}</table>{{else}}<p>No laboratory results.</p>{{end}}
+<h2>Discharge medications</h2>
+{{if .Medications}}<ul>
+{{range .Medications}}<li>{{.Medication}} {{.Dose}} ({{.Route}}) every {{.IntervalHours}} hours until {{date .EndAt}}</li>
+{{end}}</ul>{{else}}<p>{{lines .Record.Medications}}</p>{{end}}
+<h2>Follow-up instructions</h2>
+<p>{{lines .Notes}}</p>
+<p>{{.Author.Name}}<br>NIP {{.Author.NIP}}</p>
+</body>
+</html>
+$tmpl$,
+        1,
+        NULL,
+        '',
+        now(),
+        now())
+ON CONFLICT DO NOTHING;
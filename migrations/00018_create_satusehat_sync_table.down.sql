@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS satusehat_sync;
+
+DROP INDEX IF EXISTS idx_satusehat_sync_patient;
+DROP INDEX IF EXISTS idx_satusehat_sync_encounter;
+DROP INDEX IF EXISTS idx_satusehat_sync_record;
+DROP INDEX IF EXISTS idx_satusehat_sync_pending;
+DROP INDEX IF EXISTS idx_satusehat_sync_status;
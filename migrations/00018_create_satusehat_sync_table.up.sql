@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS satusehat_sync
+(
+    id              bytea       NOT NULL PRIMARY KEY,
+    resource_type   VARCHAR(32) NOT NULL CHECK (resource_type IN
+                                                ('Patient', 'Encounter', 'Condition', 'Observation',
+                                                 'MedicationStatement')),
+    patient_id      VARCHAR(16) NOT NULL REFERENCES patients (id),
+    encounter_id    bytea       NULL REFERENCES encounters (id),
+    record_id       bytea       NULL REFERENCES medical_records (id),
+    -- lower goes first, a resource is sent after the ones it refers to
+    priority        SMALLINT    NOT NULL,
+    status          VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'synced', 'failed')),
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at timestamp   NOT NULL,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    remote_id       VARCHAR(64) NULL,
+    created_at      timestamp   NOT NULL,
+    updated_at      timestamp   NOT NULL,
+    synced_at       timestamp   NULL
+);
+
+-- every local resource is queued once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_satusehat_sync_patient ON satusehat_sync (patient_id)
+    WHERE resource_type = 'Patient';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_satusehat_sync_encounter ON satusehat_sync (encounter_id)
+    WHERE resource_type = 'Encounter';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_satusehat_sync_record ON satusehat_sync (resource_type, record_id)
+    WHERE record_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_satusehat_sync_pending ON satusehat_sync (priority, next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_satusehat_sync_status ON satusehat_sync (status, updated_at DESC);
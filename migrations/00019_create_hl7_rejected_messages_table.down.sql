@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS hl7_rejected_messages;
+
+DROP INDEX IF EXISTS idx_hl7_rejected_messages_received_at;
+DROP INDEX IF EXISTS idx_hl7_rejected_messages_pending;
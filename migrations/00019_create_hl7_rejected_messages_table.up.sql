@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS hl7_rejected_messages
+(
+    id                  bytea       NOT NULL PRIMARY KEY,
+    control_id          VARCHAR(64) NOT NULL,
+    message_type        VARCHAR(16) NOT NULL,
+    sending_application VARCHAR(64) NOT NULL,
+    remote_addr         VARCHAR(64) NOT NULL,
+    raw                 TEXT        NOT NULL,
+    error               TEXT        NOT NULL,
+    received_at         timestamp   NOT NULL,
+    replay_count        INT         NOT NULL DEFAULT 0,
+    replayed_at         timestamp   NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_hl7_rejected_messages_received_at ON hl7_rejected_messages (received_at DESC);
+CREATE INDEX IF NOT EXISTS idx_hl7_rejected_messages_pending ON hl7_rejected_messages (received_at DESC)
+    WHERE replayed_at IS NULL;
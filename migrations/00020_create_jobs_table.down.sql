@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS jobs;
+
+DROP INDEX IF EXISTS idx_jobs_due;
+DROP INDEX IF EXISTS idx_jobs_user;
+DROP INDEX IF EXISTS idx_jobs_created_at;
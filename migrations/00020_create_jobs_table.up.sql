@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS jobs
+(
+    id           bytea        NOT NULL PRIMARY KEY,
+    kind         VARCHAR(32)  NOT NULL CHECK (kind IN
+                                              ('patient-export', 'record-export', 'user-export', 'patient-import')),
+    status       VARCHAR(10)  NOT NULL CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    params       jsonb        NOT NULL,
+    -- the uploaded file of an import, cleared once the job is done
+    input        bytea        NULL,
+    user_id      bytea        NOT NULL,
+    user_nip     VARCHAR(15)  NOT NULL,
+    user_name    VARCHAR(50)  NOT NULL,
+    progress     INT          NOT NULL DEFAULT 0,
+    total        INT          NOT NULL DEFAULT 0,
+    attempts     INT          NOT NULL DEFAULT 0,
+    max_attempts INT          NOT NULL,
+    -- when a queued job is due, or when the lease of a running one runs out
+    run_at       timestamp    NOT NULL,
+    last_error   TEXT         NOT NULL DEFAULT '',
+    result_key   VARCHAR(255) NOT NULL DEFAULT '',
+    result_name  VARCHAR(255) NOT NULL DEFAULT '',
+    result_type  VARCHAR(128) NOT NULL DEFAULT '',
+    result_size  BIGINT       NOT NULL DEFAULT 0,
+    created_at   timestamp    NOT NULL,
+    updated_at   timestamp    NOT NULL,
+    started_at   timestamp    NULL,
+    finished_at  timestamp    NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_user ON jobs (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at DESC);
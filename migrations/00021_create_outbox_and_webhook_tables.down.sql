@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS outbox_events;
+
+DROP INDEX IF EXISTS idx_outbox_events_undispatched;
+DROP INDEX IF EXISTS idx_outbox_events_created_at;
+DROP INDEX IF EXISTS idx_webhook_deliveries_pending;
+DROP INDEX IF EXISTS idx_webhook_deliveries_status;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id            bytea       NOT NULL PRIMARY KEY,
+    type          VARCHAR(32) NOT NULL,
+    -- the patient the event is about, NULL for events about users
+    patient_id    VARCHAR(16) NULL,
+    data          jsonb       NOT NULL,
+    created_at    timestamp   NOT NULL,
+    -- set once a delivery is queued for every webhook the event is for
+    dispatched_at timestamp   NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_undispatched ON outbox_events (id) WHERE dispatched_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          bytea        NOT NULL PRIMARY KEY,
+    url         VARCHAR(255) NOT NULL,
+    secret      VARCHAR(64)  NOT NULL,
+    -- the event types sent to the webhook, every type when empty
+    event_types TEXT[]       NOT NULL DEFAULT '{}',
+    created_by  bytea        NOT NULL,
+    created_at  timestamp    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              bytea       NOT NULL PRIMARY KEY,
+    webhook_id      bytea       NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        bytea       NOT NULL REFERENCES outbox_events (id),
+    status          VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at timestamp   NOT NULL,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    response_status INT         NOT NULL DEFAULT 0,
+    created_at      timestamp   NOT NULL,
+    updated_at      timestamp   NOT NULL,
+    delivered_at    timestamp   NULL,
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status, updated_at DESC);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_outbox_events_patient_id ON outbox_events (patient_id, id)
+    WHERE patient_id IS NOT NULL;
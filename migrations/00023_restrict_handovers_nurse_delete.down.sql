@@ -0,0 +1,5 @@
+ALTER TABLE handovers
+    DROP CONSTRAINT IF EXISTS handovers_from_nurse_id_fkey,
+    DROP CONSTRAINT IF EXISTS handovers_to_nurse_id_fkey,
+    ADD CONSTRAINT handovers_from_nurse_id_fkey FOREIGN KEY (from_nurse_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT handovers_to_nurse_id_fkey FOREIGN KEY (to_nurse_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+-- a handover is part of the history of its patient, deleting a nurse must not
+-- take it away, so a nurse with handovers is kept
+ALTER TABLE handovers
+    DROP CONSTRAINT IF EXISTS handovers_from_nurse_id_fkey,
+    DROP CONSTRAINT IF EXISTS handovers_to_nurse_id_fkey,
+    ADD CONSTRAINT handovers_from_nurse_id_fkey FOREIGN KEY (from_nurse_id) REFERENCES users (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT handovers_to_nurse_id_fkey FOREIGN KEY (to_nurse_id) REFERENCES users (id) ON DELETE RESTRICT;
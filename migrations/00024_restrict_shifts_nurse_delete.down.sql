@@ -0,0 +1,9 @@
+ALTER TABLE shift_swaps
+    DROP CONSTRAINT IF EXISTS shift_swaps_requested_by_fkey,
+    DROP CONSTRAINT IF EXISTS shift_swaps_target_nurse_id_fkey,
+    ADD CONSTRAINT shift_swaps_requested_by_fkey FOREIGN KEY (requested_by) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT shift_swaps_target_nurse_id_fkey FOREIGN KEY (target_nurse_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE shifts
+    DROP CONSTRAINT IF EXISTS shifts_nurse_id_fkey,
+    ADD CONSTRAINT shifts_nurse_id_fkey FOREIGN KEY (nurse_id) REFERENCES users (id) ON DELETE CASCADE;
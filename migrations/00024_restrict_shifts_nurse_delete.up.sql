@@ -0,0 +1,11 @@
+-- the shifts a nurse worked and the swaps they asked for stay on the roster of
+-- their ward, so a nurse with any is kept rather than deleted with them
+ALTER TABLE shifts
+    DROP CONSTRAINT IF EXISTS shifts_nurse_id_fkey,
+    ADD CONSTRAINT shifts_nurse_id_fkey FOREIGN KEY (nurse_id) REFERENCES users (id) ON DELETE RESTRICT;
+
+ALTER TABLE shift_swaps
+    DROP CONSTRAINT IF EXISTS shift_swaps_requested_by_fkey,
+    DROP CONSTRAINT IF EXISTS shift_swaps_target_nurse_id_fkey,
+    ADD CONSTRAINT shift_swaps_requested_by_fkey FOREIGN KEY (requested_by) REFERENCES users (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT shift_swaps_target_nurse_id_fkey FOREIGN KEY (target_nurse_id) REFERENCES users (id) ON DELETE RESTRICT;